@@ -22,5 +22,14 @@ func main() {
 		log.Fatalf("config error: %s", err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = app.Migrate(cfg, os.Args[2:])
+		if err != nil {
+			log.Fatalf("migrate error: %s", err)
+		}
+
+		return
+	}
+
 	app.Run(cfg)
 }
@@ -1,35 +1,195 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/mail"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
 
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+
+	CacheNone  = "none"
+	CacheLRU   = "lru"
+	CacheRedis = "redis"
+
+	DepthPolicyFlatten = "flatten"
+	DepthPolicyReject  = "reject"
+
+	MailerNone = "none"
+	MailerSMTP = "smtp"
+	MailerFile = "file"
+
+	AttachmentStoreNone  = "none"
+	AttachmentStoreLocal = "local"
+	AttachmentStoreS3    = "s3"
+)
+
 type (
 	Config struct {
-		HTTP    HTTP
-		Log     Log
-		PG      PG
-		Swagger Swagger
+		HTTP       HTTP
+		GRPC       GRPC
+		GraphQL    GraphQL
+		Log        Log
+		Storage    Storage
+		PG         PG
+		Cache      Cache
+		Swagger    Swagger
+		Metrics    Metrics
+		Trace      Trace
+		Health     Health
+		CDN        CDN
+		Archive    Archive
+		Thread     Thread
+		Digest     Digest
+		SMTP       SMTP
+		Attachment Attachment
+		S3         S3
+		Admin      Admin
 	}
 
 	HTTP struct {
-		Port string `env:"HTTP_PORT,required"`
+		Port         string `env:"HTTP_PORT,required"`
+		CacheControl string `env:"HTTP_CACHE_CONTROL" envDefault:"no-cache"`
+	}
+
+	// GRPC - пустой порт выключает gRPC-сервер.
+	GRPC struct {
+		Port string `env:"GRPC_PORT" envDefault:"8081"`
+	}
+
+	// GraphQL - MaxComplexity: каждое поле стоит 1, списки умножают стоимость подполей на размер страницы.
+	GraphQL struct {
+		Enabled       bool `env:"GRAPHQL_ENABLED" envDefault:"true"`
+		MaxDepth      int  `env:"GRAPHQL_MAX_DEPTH" envDefault:"10"`
+		MaxComplexity int  `env:"GRAPHQL_MAX_COMPLEXITY" envDefault:"5000"`
 	}
 
 	Log struct {
-		Level string `env:"LOG_LEVEL,required"`
+		Level            string  `env:"LOG_LEVEL,required"`
+		AccessSampleRate float64 `env:"LOG_ACCESS_SAMPLE_RATE" envDefault:"1"`
+	}
+
+	Storage struct {
+		Driver     string `env:"STORAGE_DRIVER" envDefault:"postgres"`
+		SQLitePath string `env:"SQLITE_PATH" envDefault:"comment_tree.db"`
 	}
 
 	PG struct {
-		PoolMax int    `env:"PG_POOL_MAX,required"`
-		URL     string `env:"PG_URL,required"`
+		PoolMax     int    `env:"PG_POOL_MAX" envDefault:"2"`
+		URL         string `env:"PG_URL"`
+		AutoMigrate bool   `env:"PG_AUTO_MIGRATE" envDefault:"false"`
+	}
+
+	Cache struct {
+		Backend       string        `env:"CACHE_BACKEND" envDefault:"none"`
+		TTL           time.Duration `env:"CACHE_TTL" envDefault:"1m"`
+		LRUMaxEntries int           `env:"CACHE_LRU_MAX_ENTRIES" envDefault:"10000"`
+		LRUMaxBytes   int64         `env:"CACHE_LRU_MAX_BYTES" envDefault:"67108864"`
+		RedisAddr     string        `env:"CACHE_REDIS_ADDR" envDefault:"localhost:6379"`
+		RedisPassword string        `env:"CACHE_REDIS_PASSWORD"`
+		RedisDB       int           `env:"CACHE_REDIS_DB" envDefault:"0"`
+		RedisPrefix   string        `env:"CACHE_REDIS_PREFIX" envDefault:"comment-tree:"`
 	}
 
 	Swagger struct {
 		Enabled bool `env:"SWAGGER_ENABLED" envDefault:"false"`
 	}
+
+	Metrics struct {
+		Enabled bool `env:"METRICS_ENABLED" envDefault:"true"`
+	}
+
+	Trace struct {
+		Exporter     string  `env:"TRACE_EXPORTER" envDefault:"none"`
+		ServiceName  string  `env:"TRACE_SERVICE_NAME" envDefault:"comment-tree"`
+		OTLPEndpoint string  `env:"TRACE_OTLP_ENDPOINT" envDefault:"localhost:4318"`
+		OTLPInsecure bool    `env:"TRACE_OTLP_INSECURE" envDefault:"true"`
+		FilePath     string  `env:"TRACE_FILE_PATH" envDefault:"traces.json"`
+		SampleRatio  float64 `env:"TRACE_SAMPLE_RATIO" envDefault:"1"`
+	}
+
+	Health struct {
+		CheckTimeout  time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"1s"`
+		ShutdownDelay time.Duration `env:"HEALTH_SHUTDOWN_DELAY" envDefault:"0s"`
+	}
+
+	CDN struct {
+		PurgeURL        string        `env:"CDN_PURGE_URL"`
+		PurgeMethod     string        `env:"CDN_PURGE_METHOD" envDefault:"POST"`
+		PurgeAuthHeader string        `env:"CDN_PURGE_AUTH_HEADER" envDefault:"Authorization"`
+		PurgeAuth       string        `env:"CDN_PURGE_AUTH"`
+		PurgeTimeout    time.Duration `env:"CDN_PURGE_TIMEOUT" envDefault:"2s"`
+	}
+
+	// Thread - MaxDepth: лимит глубины деревьев (0 - без лимита), у корня может быть свой.
+	// DepthPolicy: flatten - ответ глубже лимита переносится к предку на последнем уровне, reject - отклоняется.
+	Thread struct {
+		MaxDepth    int    `env:"THREAD_MAX_DEPTH" envDefault:"0"`
+		DepthPolicy string `env:"THREAD_DEPTH_POLICY" envDefault:"flatten"`
+	}
+
+	// Digest - подписчики получают письмо не чаще раза в Period, фоновая задача проверяет раз в Interval.
+	// Mailer: none - письма не отправляются, smtp - через SMTP, file - .eml файлы в FileDir.
+	// Secret подписывает ссылки отписки, BaseURL - публичный адрес сервиса для них.
+	Digest struct {
+		Mailer   string        `env:"DIGEST_MAILER" envDefault:"none"`
+		Period   time.Duration `env:"DIGEST_PERIOD" envDefault:"24h"`
+		Interval time.Duration `env:"DIGEST_INTERVAL" envDefault:"10m"`
+		Secret   string        `env:"DIGEST_SECRET"`
+		BaseURL  string        `env:"DIGEST_BASE_URL" envDefault:"http://localhost:8080"`
+		From     string        `env:"DIGEST_FROM" envDefault:"Comment Tree <noreply@localhost>"`
+		FileDir  string        `env:"DIGEST_FILE_DIR" envDefault:"mail"`
+	}
+
+	SMTP struct {
+		Addr     string        `env:"SMTP_ADDR"`
+		Username string        `env:"SMTP_USERNAME"`
+		Password string        `env:"SMTP_PASSWORD"`
+		Timeout  time.Duration `env:"SMTP_TIMEOUT" envDefault:"30s"`
+	}
+
+	// Attachment - Store: none - вложения выключены, local - файлы в Dir, s3 - S3-совместимое хранилище.
+	// Secret подписывает ссылки на файлы, ссылка действует от URLTTL до 2*URLTTL.
+	// Загруженные, но не прикрепленные за UploadTTL файлы удаляет фоновая задача раз в GCInterval.
+	Attachment struct {
+		Store         string        `env:"ATTACHMENT_STORE" envDefault:"none"`
+		Dir           string        `env:"ATTACHMENT_DIR" envDefault:"attachments"`
+		MaxSize       int64         `env:"ATTACHMENT_MAX_SIZE" envDefault:"10485760"`
+		MaxPerComment int           `env:"ATTACHMENT_MAX_PER_COMMENT" envDefault:"10"`
+		Types         []string      `env:"ATTACHMENT_TYPES" envDefault:"image/jpeg,image/png,image/gif,image/webp,application/pdf,text/plain"`
+		ThumbnailSize int           `env:"ATTACHMENT_THUMBNAIL_SIZE" envDefault:"320"`
+		Secret        string        `env:"ATTACHMENT_SECRET"`
+		URLTTL        time.Duration `env:"ATTACHMENT_URL_TTL" envDefault:"1h"`
+		UploadTTL     time.Duration `env:"ATTACHMENT_UPLOAD_TTL" envDefault:"24h"`
+		GCInterval    time.Duration `env:"ATTACHMENT_GC_INTERVAL" envDefault:"1h"`
+	}
+
+	// S3 - адресация path-style, подходит и для локальных заменителей (MinIO и т.п.).
+	S3 struct {
+		Endpoint  string `env:"S3_ENDPOINT"`
+		Region    string `env:"S3_REGION" envDefault:"us-east-1"`
+		Bucket    string `env:"S3_BUCKET"`
+		AccessKey string `env:"S3_ACCESS_KEY"`
+		SecretKey string `env:"S3_SECRET_KEY"`
+	}
+
+	// Admin - Token открывает /v1/admin (заголовок "Authorization: Bearer <Token>"), пустой - маршруты выключены.
+	Admin struct {
+		Token string `env:"ADMIN_TOKEN"`
+	}
+
+	// Archive - деревья без изменений дольше After архивируются фоновой задачей раз в Interval.
+	// After = 0 выключает автоархивацию.
+	Archive struct {
+		After    time.Duration `env:"ARCHIVE_AFTER" envDefault:"0s"`
+		Interval time.Duration `env:"ARCHIVE_INTERVAL" envDefault:"1h"`
+	}
 )
 
 func New() (*Config, error) {
@@ -39,5 +199,71 @@ func New() (*Config, error) {
 		return nil, fmt.Errorf("config error: %w", err)
 	}
 
+	switch cfg.Storage.Driver {
+	case StoragePostgres:
+		if cfg.PG.URL == "" {
+			return nil, errors.New("config error: PG_URL is required for postgres storage")
+		}
+	case StorageSQLite, StorageMemory:
+	default:
+		return nil, fmt.Errorf("config error: unknown STORAGE_DRIVER %q", cfg.Storage.Driver)
+	}
+
+	switch cfg.Cache.Backend {
+	case CacheNone, CacheLRU, CacheRedis:
+	default:
+		return nil, fmt.Errorf("config error: unknown CACHE_BACKEND %q", cfg.Cache.Backend)
+	}
+
+	if cfg.Thread.MaxDepth < 0 {
+		return nil, errors.New("config error: THREAD_MAX_DEPTH must not be negative")
+	}
+
+	if cfg.Thread.DepthPolicy != DepthPolicyFlatten && cfg.Thread.DepthPolicy != DepthPolicyReject {
+		return nil, fmt.Errorf("config error: unknown THREAD_DEPTH_POLICY %q", cfg.Thread.DepthPolicy)
+	}
+
+	if cfg.Archive.After > 0 && cfg.Archive.Interval <= 0 {
+		return nil, errors.New("config error: ARCHIVE_INTERVAL must be positive when ARCHIVE_AFTER is set")
+	}
+
+	switch cfg.Digest.Mailer {
+	case MailerNone:
+	case MailerSMTP, MailerFile:
+		if cfg.Digest.Secret == "" {
+			return nil, errors.New("config error: DIGEST_SECRET is required to send digests")
+		}
+		if cfg.Digest.Period <= 0 || cfg.Digest.Interval <= 0 {
+			return nil, errors.New("config error: DIGEST_PERIOD and DIGEST_INTERVAL must be positive")
+		}
+		if _, err := mail.ParseAddress(cfg.Digest.From); err != nil {
+			return nil, fmt.Errorf("config error: invalid DIGEST_FROM: %w", err)
+		}
+		if cfg.Digest.Mailer == MailerSMTP && cfg.SMTP.Addr == "" {
+			return nil, errors.New("config error: SMTP_ADDR is required for smtp mailer")
+		}
+	default:
+		return nil, fmt.Errorf("config error: unknown DIGEST_MAILER %q", cfg.Digest.Mailer)
+	}
+
+	switch cfg.Attachment.Store {
+	case AttachmentStoreNone:
+	case AttachmentStoreLocal, AttachmentStoreS3:
+		if cfg.Attachment.Secret == "" {
+			return nil, errors.New("config error: ATTACHMENT_SECRET is required for attachments")
+		}
+		if cfg.Attachment.MaxSize <= 0 || cfg.Attachment.MaxPerComment <= 0 || cfg.Attachment.ThumbnailSize <= 0 {
+			return nil, errors.New("config error: ATTACHMENT_MAX_SIZE, ATTACHMENT_MAX_PER_COMMENT and ATTACHMENT_THUMBNAIL_SIZE must be positive")
+		}
+		if cfg.Attachment.URLTTL <= 0 || cfg.Attachment.UploadTTL <= 0 || cfg.Attachment.GCInterval <= 0 {
+			return nil, errors.New("config error: ATTACHMENT_URL_TTL, ATTACHMENT_UPLOAD_TTL and ATTACHMENT_GC_INTERVAL must be positive")
+		}
+		if cfg.Attachment.Store == AttachmentStoreS3 && (cfg.S3.Endpoint == "" || cfg.S3.Bucket == "") {
+			return nil, errors.New("config error: S3_ENDPOINT and S3_BUCKET are required for s3 attachment store")
+		}
+	default:
+		return nil, fmt.Errorf("config error: unknown ATTACHMENT_STORE %q", cfg.Attachment.Store)
+	}
+
 	return cfg, nil
 }
@@ -15,61 +15,78 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/comments": {
+        "/v1/admin/audit": {
             "get": {
-                "description": "Get comment(s) with all replies, search, sort",
+                "description": "Changes of comments from newest to oldest: who made them (X-User of the request), from which address, with which request id, and snapshots before and after. Erasing an author removes their name, address and texts from the log",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "admin"
                 ],
-                "summary": "Get comments",
+                "summary": "Get audit log",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Parent ID",
-                        "name": "parent_id",
-                        "in": "query"
+                        "description": "Bearer ADMIN_TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Search text",
-                        "name": "search",
+                        "description": "Only changes made by this user",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only changes of this comment (for delete - root of the deleted subtree)",
+                        "name": "comment_id",
                         "in": "query"
                     },
                     {
                         "enum": [
-                            "created_at",
-                            "id"
+                            "create",
+                            "delete",
+                            "import",
+                            "pin",
+                            "unpin",
+                            "highlight",
+                            "unhighlight",
+                            "lock",
+                            "unlock",
+                            "archive",
+                            "max_depth",
+                            "erase"
                         ],
                         "type": "string",
-                        "description": "Sort option",
-                        "name": "sort_by",
+                        "description": "Only this action",
+                        "name": "action",
                         "in": "query"
                     },
                     {
-                        "enum": [
-                            "asc",
-                            "ASC",
-                            "desc",
-                            "DESC"
-                        ],
                         "type": "string",
-                        "description": "Sort order",
-                        "name": "order",
+                        "description": "Changes at or after this time, RFC 3339",
+                        "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Limit of comments on one page, default 20",
-                        "name": "limit",
+                        "description": "Changes before this time, RFC 3339",
+                        "name": "to",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Offset for displaying a specific page, default 0",
-                        "name": "offset",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Page size, default 20, max 100",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
@@ -77,7 +94,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.PaginatedCommentsResponse"
+                            "$ref": "#/definitions/response.AuditLogResponse"
                         }
                     },
                     "400": {
@@ -86,6 +103,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Error"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -93,9 +116,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/v1/admin/authors/{author}/erase": {
             "post": {
-                "description": "Creates new comment",
+                "description": "Erases the author: \"anonymize\" keeps their comments in the tree without author and with \"[deleted]\" instead of the text, \"delete\" removes those without replies of other authors regardless of locks and leaves the rest as in \"anonymize\". In both modes their attachments, notifications, mutes, subscriptions and visits are deleted and their name is removed from notifications of others. The request is recorded in the privacy request log",
                 "consumes": [
                     "application/json"
                 ],
@@ -103,25 +128,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "admin"
                 ],
-                "summary": "Create new comment",
+                "summary": "Erase author data",
                 "parameters": [
                     {
-                        "description": "Comment",
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author",
+                        "name": "author",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Erasure mode and reason",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.CreateCommentRequest"
+                            "$ref": "#/definitions/request.EraseAuthorRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin performing the request",
+                        "name": "X-User",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.CreateCommentResponse"
+                            "$ref": "#/definitions/response.PrivacyRequestResponse"
                         }
                     },
                     "400": {
@@ -130,8 +175,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Error"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/response.Error"
                         }
@@ -145,28 +190,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/comments/{id}": {
-            "delete": {
-                "description": "Deletes comment by ID",
+        "/v1/admin/authors/{author}/export": {
+            "get": {
+                "description": "Returns a ZIP archive with everything stored about the author: comments.json, attachments.json (with signed links), notifications.json, subscriptions.json, mutes.json and visits.json. The request is recorded in the privacy request log",
                 "produces": [
-                    "application/json"
+                    "application/zip"
                 ],
                 "tags": [
-                    "comments"
+                    "admin"
                 ],
-                "summary": "Delete comments",
+                "summary": "Export author data",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Comment ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author",
+                        "name": "author",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason recorded in the log",
+                        "name": "reason",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin performing the request",
+                        "name": "X-User",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -174,8 +242,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Error"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/response.Error"
                         }
@@ -188,76 +256,2231 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "request.CreateCommentRequest": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "parent_id": {
-                    "type": "integer"
-                }
-            }
         },
-        "response.CommentTreeResponse": {
-            "type": "object",
-            "properties": {
-                "children": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/response.CommentTreeResponse"
+        "/v1/attachments": {
+            "post": {
+                "description": "Uploads a file to attach to a comment later via attachment_ids. The type is detected from the content and must be allowed; images get width, height and a thumbnail. Uploads not attached in time are deleted",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Upload attachment",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Uploader; only they can attach the file, anonymous uploads are rejected (422)",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/response.AttachmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
                     }
-                },
-                "content": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "depth": {
-                    "type": "integer"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "parent_id": {
-                    "type": "integer"
                 }
             }
         },
-        "response.CreateCommentResponse": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string",
-                    "example": "nice picture!!!"
-                },
-                "created_at": {
-                    "type": "string",
-                    "example": "2026-02-02T14:31:00Z"
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 12
-                },
-                "parent_id": {
-                    "type": "integer",
-                    "example": 1
+        "/v1/attachments/{id}": {
+            "get": {
+                "description": "Serves an attachment file by a signed link from url of the attachment",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Get attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Link expiry (Unix time)",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link signature",
+                        "name": "sig",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
                 }
             }
         },
-        "response.Error": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
+        "/v1/attachments/{id}/thumbnail": {
+            "get": {
+                "description": "Serves an image thumbnail by a signed link from thumbnail_url of the attachment",
+                "produces": [
+                    "image/png",
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Get attachment thumbnail",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Link expiry (Unix time)",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link signature",
+                        "name": "sig",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments": {
+            "get": {
+                "description": "Get comment(s) with all replies, search, sort",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Parent ID",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search text",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "id"
+                        ],
+                        "type": "string",
+                        "description": "Sort option",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "ASC",
+                            "desc",
+                            "DESC"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "oldest",
+                            "newest",
+                            "score"
+                        ],
+                        "type": "string",
+                        "description": "Order of replies at every depth, default oldest; score - most replies first",
+                        "name": "child_sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Limit of comments on one page, default 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Offset for displaying a specific page, default 0",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Viewer: comments new since the viewer's last POST /v1/comments/{id}/seen get is_new, roots get new_count (not for search)",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified from a previous response (parent_id only)",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.PaginatedCommentsResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the response"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last change in the subtree (parent_id only, not with X-User)"
+                            },
+                            "Surrogate-Key": {
+                                "type": "string",
+                                "description": "CDN purge keys of the roots in the response (not with X-User)"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates new comment on behalf of X-User (anonymous without it). Content is Markdown (emphasis, links, code, quotes, lists), content_html is its sanitized rendering. A reply deeper than the thread max depth is either rejected (422) or attached to the ancestor on the last allowed level with reply_to_id set to the comment replied to, depending on the depth policy. Attachments are either uploaded beforehand and passed in attachment_ids, or sent as files of a multipart/form-data request with the same fields; anonymous comments cannot have attachments (422)",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Create new comment",
+                "parameters": [
+                    {
+                        "description": "Comment",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.CreateCommentRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author of the comment; the parent's author and @mentioned users are notified",
+                        "name": "X-User",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/response.CreateCommentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}": {
+            "delete": {
+                "description": "Deletes comment by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Delete comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/backlinks": {
+            "get": {
+                "description": "Comments that reference this one with \u003e\u003eid, from newest to oldest",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get backlinks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Page size, default 20, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Offset, default 0",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.BacklinksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/highlight": {
+            "put": {
+                "description": "Marks comment as featured",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Highlight comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN of a moderator",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Thread owner: the author of its root",
+                        "name": "X-User",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes featured mark from comment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Remove highlight",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN of a moderator",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Thread owner: the author of its root",
+                        "name": "X-User",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/lock": {
+            "put": {
+                "description": "Locks comment with all its replies: \"locked\" rejects new replies, \"archived\" makes the thread read-only (no replies, pins, highlights, deletes or lock changes below it). Replaces previous lock of the same comment; inside an archived branch the request is rejected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Lock comment thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN of a moderator",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Thread owner: the author of its root",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Lock state and reason, default state - locked",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/request.LockCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes lock of the comment itself, an archived comment included; a lock of its ancestors still applies, and inside an archived branch the request is rejected",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Unlock comment thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN of a moderator",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Thread owner: the author of its root",
+                        "name": "X-User",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/max-depth": {
+            "put": {
+                "description": "Sets max reply depth for the thread of a root comment, 0 - unlimited. Existing deeper replies are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Set thread max depth",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Root comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Max depth",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.SetMaxDepthRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Returns the thread of a root comment to the global max depth",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Reset thread max depth",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Root comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/mute": {
+            "put": {
+                "description": "X-User stops getting notifications about new comments in the subtree of the comment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mute thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes X-User's mute from the comment; mutes of its ancestors still apply",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Unmute thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/pin": {
+            "put": {
+                "description": "Pins comment above its unpinned siblings (a root - above all unpinned roots on every sort order). Pinned comments are ordered by pin order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Pin comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN of a moderator",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Thread owner: the author of its root may pin replies, roots are pinned by moderators only",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Pin order, default 0",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/request.PinCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Returns comment to its regular place among siblings",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Unpin comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN of a moderator",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Thread owner: the author of its root may unpin replies, roots are unpinned by moderators only",
+                        "name": "X-User",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/seen": {
+            "post": {
+                "description": "X-User has read the whole thread of the root comment: comments up to last_seen_id are no longer is_new for them.\nThe position never moves back",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Mark thread seen",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Root comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Viewer",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.MarkSeenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/comments/{id}/subscription": {
+            "put": {
+                "description": "X-User gets periodic email digests of new comments in the subtree of the comment.\nThe address replaces the one of X-User's other subscriptions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Subscribe to thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscriber",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Digest address",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.SubscribeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes X-User's subscription to the comment; subscriptions to its ancestors still apply",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Unsubscribe from thread",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscriber",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/export": {
+            "get": {
+                "description": "Streams comments (the whole database or a single thread) with parents always before children.\nThe top comment of an exported thread has no parent_id, so the file can be imported back as is.",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Export comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export only the subtree of this comment",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "ndjson",
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Output format, default ndjson",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.ExportComment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/import": {
+            "post": {
+                "description": "Bulk-loads comments in the export format as new trees. Ids are reassigned, parent_id must refer\nto a record of the same import. Invalid records are skipped together with their replies\nand reported with their line number; the rest is imported.",
+                "consumes": [
+                    "application/x-ndjson",
+                    "application/json",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "export"
+                ],
+                "summary": "Import comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer ADMIN_TOKEN",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "ndjson",
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Input format, default ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Comments",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.ExportComment"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications": {
+            "get": {
+                "description": "Notifications of X-User from newest to oldest: replies to their comments and @mentions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "unread",
+                            "read",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "Which notifications to return, default all",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Page size, default 20, max 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotificationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications/read": {
+            "post": {
+                "description": "Marks the given notifications of X-User (or all of them) as read",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Mark notifications read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Notifications to mark",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.MarkNotificationsReadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.MarkNotificationsReadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/notifications/stream": {
+            "get": {
+                "description": "Server-Sent Events: each new notification of X-User arrives as a \"notification\" event while the connection is open.\nOnly notifications created by this instance are streamed; the inbox has all of them",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Stream notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotificationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/subscriptions": {
+            "get": {
+                "description": "Threads X-User gets digests for",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscriber",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.SubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/unsubscribe": {
+            "get": {
+                "description": "Page with an unsubscribe button for the signed link from a digest email",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Unsubscribe page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "post": {
+                "description": "Unsubscribes by the signed token from a digest email: from one thread or from all of them.\nAlso serves one-click unsubscribe from mail clients (RFC 8058)",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Unsubscribe by link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "request.CreateCommentRequest": {
+            "type": "object",
+            "properties": {
+                "attachment_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "content": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "request.EraseAuthorRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "anonymize",
+                        "delete"
+                    ],
+                    "example": "anonymize"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "GDPR request #123"
+                }
+            }
+        },
+        "request.LockCommentRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "off-topic"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "locked"
+                }
+            }
+        },
+        "request.MarkNotificationsReadRequest": {
+            "type": "object",
+            "properties": {
+                "all": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        5
+                    ]
+                }
+            }
+        },
+        "request.PinCommentRequest": {
+            "type": "object",
+            "properties": {
+                "order": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "request.SetMaxDepthRequest": {
+            "type": "object",
+            "properties": {
+                "max_depth": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "request.SubscribeRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "alice@example.com"
+                }
+            }
+        },
+        "response.AttachmentResponse": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "image/png"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-02-02T14:31:00Z"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "cat.png"
+                },
+                "height": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 48213
+                },
+                "thumbnail_url": {
+                    "type": "string",
+                    "example": "/v1/attachments/3/thumbnail?expires=1790000000\u0026sig=..."
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/v1/attachments/3?expires=1790000000\u0026sig=..."
+                },
+                "width": {
+                    "type": "integer",
+                    "example": 800
+                }
+            }
+        },
+        "response.AuditEntryResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "lock"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "mod"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "comment_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-02-02T14:31:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "4b1f0c1e9a7d4c2e"
+                }
+            }
+        },
+        "response.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.AuditEntryResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.BacklinkResponse": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "snippet": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.BacklinksResponse": {
+            "type": "object",
+            "properties": {
+                "backlinks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.BacklinkResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.CommentTreeResponse": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.AttachmentResponse"
+                    }
+                },
+                "author": {
+                    "type": "string"
+                },
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.CommentTreeResponse"
+                    }
+                },
+                "content": {
+                    "type": "string"
+                },
+                "content_html": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "depth": {
+                    "type": "integer"
+                },
+                "highlighted": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_new": {
+                    "type": "boolean"
+                },
+                "lock_reason": {
+                    "type": "string"
+                },
+                "lock_state": {
+                    "type": "string"
+                },
+                "max_depth": {
+                    "type": "integer"
+                },
+                "new_count": {
+                    "type": "integer"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "pin_order": {
+                    "type": "integer"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "references": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.ReferenceResponse"
+                    }
+                },
+                "reply_to_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.CreateCommentResponse": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.AttachmentResponse"
+                    }
+                },
+                "author": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "nice *picture*!!!"
+                },
+                "content_html": {
+                    "type": "string",
+                    "example": "\u003cp\u003enice \u003cem\u003epicture\u003c/em\u003e!!!\u003c/p\u003e"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-02-02T14:31:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reply_to_id": {
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "response.Error": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
                     "example": "invalid request body"
                 }
             }
         },
+        "response.ExportComment": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "hello"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-02-02T14:48:37Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "response.ImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "parent 41 not found in import"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "response.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.ImportError"
+                    }
+                },
+                "ids": {
+                    "description": "IDs - новые id по id источника",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "response.MarkNotificationsReadResponse": {
+            "type": "object",
+            "properties": {
+                "read": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "response.MarkSeenResponse": {
+            "type": "object",
+            "properties": {
+                "last_seen_id": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "response.NotificationResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "bob"
+                },
+                "comment_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "content": {
+                    "type": "string",
+                    "example": "@alice agreed"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-02-02T14:31:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "reply",
+                        "mention"
+                    ],
+                    "example": "reply"
+                },
+                "read_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.NotificationsResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.NotificationResponse"
+                    }
+                }
+            }
+        },
         "response.PaginatedCommentsResponse": {
             "type": "object",
             "properties": {
@@ -283,6 +2506,96 @@ const docTemplate = `{
                     "type": "integer"
                 }
             }
+        },
+        "response.PrivacyRequestResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "export",
+                        "anonymize",
+                        "delete"
+                    ],
+                    "example": "anonymize"
+                },
+                "actor": {
+                    "type": "string",
+                    "example": "dpo"
+                },
+                "author": {
+                    "type": "string",
+                    "example": "alice"
+                },
+                "comments": {
+                    "type": "integer",
+                    "example": 17
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-02-02T14:31:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "GDPR request #123"
+                }
+            }
+        },
+        "response.ReferenceResponse": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "available": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "snippet": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.SubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "comment_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-02-02T14:31:00Z"
+                },
+                "digested_at": {
+                    "type": "string",
+                    "example": "2026-02-03T14:31:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "alice@example.com"
+                }
+            }
+        },
+        "response.SubscriptionsResponse": {
+            "type": "object",
+            "properties": {
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.SubscriptionResponse"
+                    }
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,1041 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: docs/proto/v1/comment.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SortBy int32
+
+const (
+	SortBy_SORT_BY_UNSPECIFIED SortBy = 0 // created_at
+	SortBy_SORT_BY_CREATED_AT  SortBy = 1
+	SortBy_SORT_BY_ID          SortBy = 2
+)
+
+// Enum value maps for SortBy.
+var (
+	SortBy_name = map[int32]string{
+		0: "SORT_BY_UNSPECIFIED",
+		1: "SORT_BY_CREATED_AT",
+		2: "SORT_BY_ID",
+	}
+	SortBy_value = map[string]int32{
+		"SORT_BY_UNSPECIFIED": 0,
+		"SORT_BY_CREATED_AT":  1,
+		"SORT_BY_ID":          2,
+	}
+)
+
+func (x SortBy) Enum() *SortBy {
+	p := new(SortBy)
+	*p = x
+	return p
+}
+
+func (x SortBy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortBy) Descriptor() protoreflect.EnumDescriptor {
+	return file_docs_proto_v1_comment_proto_enumTypes[0].Descriptor()
+}
+
+func (SortBy) Type() protoreflect.EnumType {
+	return &file_docs_proto_v1_comment_proto_enumTypes[0]
+}
+
+func (x SortBy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortBy.Descriptor instead.
+func (SortBy) EnumDescriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{0}
+}
+
+type Order int32
+
+const (
+	Order_ORDER_UNSPECIFIED Order = 0 // desc
+	Order_ORDER_DESC        Order = 1
+	Order_ORDER_ASC         Order = 2
+)
+
+// Enum value maps for Order.
+var (
+	Order_name = map[int32]string{
+		0: "ORDER_UNSPECIFIED",
+		1: "ORDER_DESC",
+		2: "ORDER_ASC",
+	}
+	Order_value = map[string]int32{
+		"ORDER_UNSPECIFIED": 0,
+		"ORDER_DESC":        1,
+		"ORDER_ASC":         2,
+	}
+)
+
+func (x Order) Enum() *Order {
+	p := new(Order)
+	*p = x
+	return p
+}
+
+func (x Order) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Order) Descriptor() protoreflect.EnumDescriptor {
+	return file_docs_proto_v1_comment_proto_enumTypes[1].Descriptor()
+}
+
+func (Order) Type() protoreflect.EnumType {
+	return &file_docs_proto_v1_comment_proto_enumTypes[1]
+}
+
+func (x Order) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Order.Descriptor instead.
+func (Order) EnumDescriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{1}
+}
+
+type Comment struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ParentId  *int64                 `protobuf:"varint,2,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"`
+	Content   string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Author    string                 `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// version растет при любом изменении в поддереве коммента
+	Version       int64                  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Comment) Reset() {
+	*x = Comment{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Comment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Comment) ProtoMessage() {}
+
+func (x *Comment) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Comment.ProtoReflect.Descriptor instead.
+func (*Comment) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Comment) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Comment) GetParentId() int64 {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return 0
+}
+
+func (x *Comment) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Comment) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Comment) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Comment) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Comment) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CommentNode struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Comment *Comment               `protobuf:"bytes,1,opt,name=comment,proto3" json:"comment,omitempty"`
+	// depth и path - относительно коммента, с которого начато поддерево
+	Depth         int32   `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	Path          []int64 `protobuf:"varint,3,rep,packed,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommentNode) Reset() {
+	*x = CommentNode{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommentNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommentNode) ProtoMessage() {}
+
+func (x *CommentNode) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommentNode.ProtoReflect.Descriptor instead.
+func (*CommentNode) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CommentNode) GetComment() *Comment {
+	if x != nil {
+		return x.Comment
+	}
+	return nil
+}
+
+func (x *CommentNode) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *CommentNode) GetPath() []int64 {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+type CreateCommentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ParentId      *int64                 `protobuf:"varint,1,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCommentRequest) Reset() {
+	*x = CreateCommentRequest{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCommentRequest) ProtoMessage() {}
+
+func (x *CreateCommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCommentRequest.ProtoReflect.Descriptor instead.
+func (*CreateCommentRequest) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateCommentRequest) GetParentId() int64 {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return 0
+}
+
+func (x *CreateCommentRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type CreateCommentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Comment       *Comment               `protobuf:"bytes,1,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCommentResponse) Reset() {
+	*x = CreateCommentResponse{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCommentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCommentResponse) ProtoMessage() {}
+
+func (x *CreateCommentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCommentResponse.ProtoReflect.Descriptor instead.
+func (*CreateCommentResponse) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateCommentResponse) GetComment() *Comment {
+	if x != nil {
+		return x.Comment
+	}
+	return nil
+}
+
+type GetCommentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCommentRequest) Reset() {
+	*x = GetCommentRequest{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommentRequest) ProtoMessage() {}
+
+func (x *GetCommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommentRequest.ProtoReflect.Descriptor instead.
+func (*GetCommentRequest) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetCommentRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetCommentResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Comment          *Comment               `protobuf:"bytes,1,opt,name=comment,proto3" json:"comment,omitempty"`
+	DescendantsCount int32                  `protobuf:"varint,2,opt,name=descendants_count,json=descendantsCount,proto3" json:"descendants_count,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetCommentResponse) Reset() {
+	*x = GetCommentResponse{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCommentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommentResponse) ProtoMessage() {}
+
+func (x *GetCommentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommentResponse.ProtoReflect.Descriptor instead.
+func (*GetCommentResponse) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetCommentResponse) GetComment() *Comment {
+	if x != nil {
+		return x.Comment
+	}
+	return nil
+}
+
+func (x *GetCommentResponse) GetDescendantsCount() int32 {
+	if x != nil {
+		return x.DescendantsCount
+	}
+	return 0
+}
+
+type ListRootsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	SortBy SortBy                 `protobuf:"varint,1,opt,name=sort_by,json=sortBy,proto3,enum=comment.v1.SortBy" json:"sort_by,omitempty"`
+	Order  Order                  `protobuf:"varint,2,opt,name=order,proto3,enum=comment.v1.Order" json:"order,omitempty"`
+	// limit - от 1 до 100, по умолчанию 20
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRootsRequest) Reset() {
+	*x = ListRootsRequest{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRootsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRootsRequest) ProtoMessage() {}
+
+func (x *ListRootsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRootsRequest.ProtoReflect.Descriptor instead.
+func (*ListRootsRequest) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListRootsRequest) GetSortBy() SortBy {
+	if x != nil {
+		return x.SortBy
+	}
+	return SortBy_SORT_BY_UNSPECIFIED
+}
+
+func (x *ListRootsRequest) GetOrder() Order {
+	if x != nil {
+		return x.Order
+	}
+	return Order_ORDER_UNSPECIFIED
+}
+
+func (x *ListRootsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRootsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListRootsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Comments      []*Comment             `protobuf:"bytes,1,rep,name=comments,proto3" json:"comments,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRootsResponse) Reset() {
+	*x = ListRootsResponse{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRootsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRootsResponse) ProtoMessage() {}
+
+func (x *ListRootsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRootsResponse.ProtoReflect.Descriptor instead.
+func (*ListRootsResponse) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListRootsResponse) GetComments() []*Comment {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+func (x *ListRootsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListRootsResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRootsResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetTreeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTreeRequest) Reset() {
+	*x = GetTreeRequest{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTreeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTreeRequest) ProtoMessage() {}
+
+func (x *GetTreeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTreeRequest.ProtoReflect.Descriptor instead.
+func (*GetTreeRequest) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetTreeRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	SortBy        SortBy                 `protobuf:"varint,2,opt,name=sort_by,json=sortBy,proto3,enum=comment.v1.SortBy" json:"sort_by,omitempty"`
+	Order         Order                  `protobuf:"varint,3,opt,name=order,proto3,enum=comment.v1.Order" json:"order,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetSortBy() SortBy {
+	if x != nil {
+		return x.SortBy
+	}
+	return SortBy_SORT_BY_UNSPECIFIED
+}
+
+func (x *SearchRequest) GetOrder() Order {
+	if x != nil {
+		return x.Order
+	}
+	return Order_ORDER_UNSPECIFIED
+}
+
+func (x *SearchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Comments      []*Comment             `protobuf:"bytes,1,rep,name=comments,proto3" json:"comments,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SearchResponse) GetComments() []*Comment {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docs_proto_v1_comment_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_docs_proto_v1_comment_proto_rawDescGZIP(), []int{12}
+}
+
+var File_docs_proto_v1_comment_proto protoreflect.FileDescriptor
+
+const file_docs_proto_v1_comment_proto_rawDesc = "" +
+	"\n" +
+	"\x1bdocs/proto/v1/comment.proto\x12\n" +
+	"comment.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8b\x02\n" +
+	"\aComment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12 \n" +
+	"\tparent_id\x18\x02 \x01(\x03H\x00R\bparentId\x88\x01\x01\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\f\n" +
+	"\n" +
+	"_parent_id\"f\n" +
+	"\vCommentNode\x12-\n" +
+	"\acomment\x18\x01 \x01(\v2\x13.comment.v1.CommentR\acomment\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\x12\x12\n" +
+	"\x04path\x18\x03 \x03(\x03R\x04path\"`\n" +
+	"\x14CreateCommentRequest\x12 \n" +
+	"\tparent_id\x18\x01 \x01(\x03H\x00R\bparentId\x88\x01\x01\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontentB\f\n" +
+	"\n" +
+	"_parent_id\"F\n" +
+	"\x15CreateCommentResponse\x12-\n" +
+	"\acomment\x18\x01 \x01(\v2\x13.comment.v1.CommentR\acomment\"#\n" +
+	"\x11GetCommentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"p\n" +
+	"\x12GetCommentResponse\x12-\n" +
+	"\acomment\x18\x01 \x01(\v2\x13.comment.v1.CommentR\acomment\x12+\n" +
+	"\x11descendants_count\x18\x02 \x01(\x05R\x10descendantsCount\"\x96\x01\n" +
+	"\x10ListRootsRequest\x12+\n" +
+	"\asort_by\x18\x01 \x01(\x0e2\x12.comment.v1.SortByR\x06sortBy\x12'\n" +
+	"\x05order\x18\x02 \x01(\x0e2\x11.comment.v1.OrderR\x05order\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\"\x88\x01\n" +
+	"\x11ListRootsResponse\x12/\n" +
+	"\bcomments\x18\x01 \x03(\v2\x13.comment.v1.CommentR\bcomments\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\" \n" +
+	"\x0eGetTreeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xa9\x01\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12+\n" +
+	"\asort_by\x18\x02 \x01(\x0e2\x12.comment.v1.SortByR\x06sortBy\x12'\n" +
+	"\x05order\x18\x03 \x01(\x0e2\x11.comment.v1.OrderR\x05order\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\"\x85\x01\n" +
+	"\x0eSearchResponse\x12/\n" +
+	"\bcomments\x18\x01 \x03(\v2\x13.comment.v1.CommentR\bcomments\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\"\x1f\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x10\n" +
+	"\x0eDeleteResponse*I\n" +
+	"\x06SortBy\x12\x17\n" +
+	"\x13SORT_BY_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12SORT_BY_CREATED_AT\x10\x01\x12\x0e\n" +
+	"\n" +
+	"SORT_BY_ID\x10\x02*=\n" +
+	"\x05Order\x12\x15\n" +
+	"\x11ORDER_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"ORDER_DESC\x10\x01\x12\r\n" +
+	"\tORDER_ASC\x10\x022\xc1\x03\n" +
+	"\x0eCommentService\x12T\n" +
+	"\rCreateComment\x12 .comment.v1.CreateCommentRequest\x1a!.comment.v1.CreateCommentResponse\x12K\n" +
+	"\n" +
+	"GetComment\x12\x1d.comment.v1.GetCommentRequest\x1a\x1e.comment.v1.GetCommentResponse\x12H\n" +
+	"\tListRoots\x12\x1c.comment.v1.ListRootsRequest\x1a\x1d.comment.v1.ListRootsResponse\x12@\n" +
+	"\aGetTree\x12\x1a.comment.v1.GetTreeRequest\x1a\x17.comment.v1.CommentNode0\x01\x12?\n" +
+	"\x06Search\x12\x19.comment.v1.SearchRequest\x1a\x1a.comment.v1.SearchResponse\x12?\n" +
+	"\x06Delete\x12\x19.comment.v1.DeleteRequest\x1a\x1a.comment.v1.DeleteResponseB2Z0github.com/andreyxaxa/Comment-Tree/docs/proto/v1b\x06proto3"
+
+var (
+	file_docs_proto_v1_comment_proto_rawDescOnce sync.Once
+	file_docs_proto_v1_comment_proto_rawDescData []byte
+)
+
+func file_docs_proto_v1_comment_proto_rawDescGZIP() []byte {
+	file_docs_proto_v1_comment_proto_rawDescOnce.Do(func() {
+		file_docs_proto_v1_comment_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_docs_proto_v1_comment_proto_rawDesc), len(file_docs_proto_v1_comment_proto_rawDesc)))
+	})
+	return file_docs_proto_v1_comment_proto_rawDescData
+}
+
+var file_docs_proto_v1_comment_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_docs_proto_v1_comment_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_docs_proto_v1_comment_proto_goTypes = []any{
+	(SortBy)(0),                   // 0: comment.v1.SortBy
+	(Order)(0),                    // 1: comment.v1.Order
+	(*Comment)(nil),               // 2: comment.v1.Comment
+	(*CommentNode)(nil),           // 3: comment.v1.CommentNode
+	(*CreateCommentRequest)(nil),  // 4: comment.v1.CreateCommentRequest
+	(*CreateCommentResponse)(nil), // 5: comment.v1.CreateCommentResponse
+	(*GetCommentRequest)(nil),     // 6: comment.v1.GetCommentRequest
+	(*GetCommentResponse)(nil),    // 7: comment.v1.GetCommentResponse
+	(*ListRootsRequest)(nil),      // 8: comment.v1.ListRootsRequest
+	(*ListRootsResponse)(nil),     // 9: comment.v1.ListRootsResponse
+	(*GetTreeRequest)(nil),        // 10: comment.v1.GetTreeRequest
+	(*SearchRequest)(nil),         // 11: comment.v1.SearchRequest
+	(*SearchResponse)(nil),        // 12: comment.v1.SearchResponse
+	(*DeleteRequest)(nil),         // 13: comment.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 14: comment.v1.DeleteResponse
+	(*timestamppb.Timestamp)(nil), // 15: google.protobuf.Timestamp
+}
+var file_docs_proto_v1_comment_proto_depIdxs = []int32{
+	15, // 0: comment.v1.Comment.created_at:type_name -> google.protobuf.Timestamp
+	15, // 1: comment.v1.Comment.updated_at:type_name -> google.protobuf.Timestamp
+	2,  // 2: comment.v1.CommentNode.comment:type_name -> comment.v1.Comment
+	2,  // 3: comment.v1.CreateCommentResponse.comment:type_name -> comment.v1.Comment
+	2,  // 4: comment.v1.GetCommentResponse.comment:type_name -> comment.v1.Comment
+	0,  // 5: comment.v1.ListRootsRequest.sort_by:type_name -> comment.v1.SortBy
+	1,  // 6: comment.v1.ListRootsRequest.order:type_name -> comment.v1.Order
+	2,  // 7: comment.v1.ListRootsResponse.comments:type_name -> comment.v1.Comment
+	0,  // 8: comment.v1.SearchRequest.sort_by:type_name -> comment.v1.SortBy
+	1,  // 9: comment.v1.SearchRequest.order:type_name -> comment.v1.Order
+	2,  // 10: comment.v1.SearchResponse.comments:type_name -> comment.v1.Comment
+	4,  // 11: comment.v1.CommentService.CreateComment:input_type -> comment.v1.CreateCommentRequest
+	6,  // 12: comment.v1.CommentService.GetComment:input_type -> comment.v1.GetCommentRequest
+	8,  // 13: comment.v1.CommentService.ListRoots:input_type -> comment.v1.ListRootsRequest
+	10, // 14: comment.v1.CommentService.GetTree:input_type -> comment.v1.GetTreeRequest
+	11, // 15: comment.v1.CommentService.Search:input_type -> comment.v1.SearchRequest
+	13, // 16: comment.v1.CommentService.Delete:input_type -> comment.v1.DeleteRequest
+	5,  // 17: comment.v1.CommentService.CreateComment:output_type -> comment.v1.CreateCommentResponse
+	7,  // 18: comment.v1.CommentService.GetComment:output_type -> comment.v1.GetCommentResponse
+	9,  // 19: comment.v1.CommentService.ListRoots:output_type -> comment.v1.ListRootsResponse
+	3,  // 20: comment.v1.CommentService.GetTree:output_type -> comment.v1.CommentNode
+	12, // 21: comment.v1.CommentService.Search:output_type -> comment.v1.SearchResponse
+	14, // 22: comment.v1.CommentService.Delete:output_type -> comment.v1.DeleteResponse
+	17, // [17:23] is the sub-list for method output_type
+	11, // [11:17] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_docs_proto_v1_comment_proto_init() }
+func file_docs_proto_v1_comment_proto_init() {
+	if File_docs_proto_v1_comment_proto != nil {
+		return
+	}
+	file_docs_proto_v1_comment_proto_msgTypes[0].OneofWrappers = []any{}
+	file_docs_proto_v1_comment_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_docs_proto_v1_comment_proto_rawDesc), len(file_docs_proto_v1_comment_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_docs_proto_v1_comment_proto_goTypes,
+		DependencyIndexes: file_docs_proto_v1_comment_proto_depIdxs,
+		EnumInfos:         file_docs_proto_v1_comment_proto_enumTypes,
+		MessageInfos:      file_docs_proto_v1_comment_proto_msgTypes,
+	}.Build()
+	File_docs_proto_v1_comment_proto = out.File
+	file_docs_proto_v1_comment_proto_goTypes = nil
+	file_docs_proto_v1_comment_proto_depIdxs = nil
+}
@@ -1,42 +1,186 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/graphql"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/grpc"
 	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi"
-	"github.com/andreyxaxa/Comment-Tree/internal/repo/persistent"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/metrics"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/tracing"
 	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/grpcserver"
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
 	"github.com/andreyxaxa/Comment-Tree/pkg/httpserver"
 	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
-	"github.com/andreyxaxa/Comment-Tree/pkg/postgres"
+	"github.com/andreyxaxa/Comment-Tree/pkg/pubsub"
+	"github.com/andreyxaxa/Comment-Tree/pkg/tracer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 func Run(cfg *config.Config) {
 	// Logger
 	l := logger.New(cfg.Log.Level)
 
+	// Tracing
+	tr, err := tracer.New(
+		tracer.Exporter(cfg.Trace.Exporter),
+		tracer.ServiceName(cfg.Trace.ServiceName),
+		tracer.OTLPEndpoint(cfg.Trace.OTLPEndpoint),
+		tracer.OTLPInsecure(cfg.Trace.OTLPInsecure),
+		tracer.FilePath(cfg.Trace.FilePath),
+		tracer.SampleRatio(cfg.Trace.SampleRatio),
+	)
+	if err != nil {
+		l.Fatal(fmt.Errorf("app - Run - tracer.New: %w", err))
+	}
+
+	// Metrics
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m := metrics.New(reg)
+
+	// Health
+	h := health.New(health.Timeout(cfg.Health.CheckTimeout))
+
 	// Repository
-	pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PG.PoolMax))
+	commentRepo, closeRepo, err := newCommentRepo(cfg, tr.Provider, reg, h)
 	if err != nil {
-		l.Fatal(fmt.Errorf("app - Run - postgres.New: %w", err))
+		l.Fatal(fmt.Errorf("app - Run - newCommentRepo: %w", err))
 	}
-	defer pg.Close()
+	defer closeRepo()
+
+	commentRepo = tracing.NewCommentRepo(metrics.NewCommentRepo(commentRepo, m), tr.Provider)
+
+	// Cache
+	commentRepo, closeCache := withCache(cfg, commentRepo, reg, h)
+	defer closeCache()
+
+	// CDN
+	cdnWebAPI := webapi.NewCDN(
+		cfg.CDN.PurgeURL,
+		cfg.CDN.PurgeMethod,
+		cfg.CDN.PurgeAuthHeader,
+		cfg.CDN.PurgeAuth,
+		cfg.CDN.PurgeTimeout,
+		l,
+	)
+
+	// Notifications
+	notificationHub := pubsub.New[entity.Notification]()
+
+	// Mailer
+	digestMailer := newMailer(cfg)
+
+	// Attachments
+	blobStore := newBlobStore(cfg)
 
 	// Use-Case
-	commentUseCase := comment.New(
-		persistent.New(pg),
+	commentUseCase := tracing.NewCommentUseCase(
+		metrics.NewCommentUseCase(
+			comment.New(commentRepo, cdnWebAPI, l,
+				comment.MaxDepth(cfg.Thread.MaxDepth, cfg.Thread.DepthPolicy),
+				comment.Notifications(notificationHub),
+				comment.Digests(digestMailer, cfg.Digest.Secret, cfg.Digest.BaseURL, cfg.Digest.Period),
+				comment.Attachments(blobStore, cfg.Attachment.Secret, comment.AttachmentLimits{
+					MaxSize:       cfg.Attachment.MaxSize,
+					MaxPerComment: cfg.Attachment.MaxPerComment,
+					Types:         cfg.Attachment.Types,
+					ThumbnailSize: cfg.Attachment.ThumbnailSize,
+				}, cfg.Attachment.URLTTL, cfg.Attachment.UploadTTL),
+			),
+			m,
+		),
+		tr.Provider,
 	)
 
+	// Auto-Archive
+	archiveCtx, stopArchive := context.WithCancel(context.Background())
+	archiveDone := make(chan struct{})
+
+	if cfg.Archive.After > 0 {
+		go func() {
+			defer close(archiveDone)
+			autoArchive(archiveCtx, commentUseCase, cfg.Archive.After, cfg.Archive.Interval, l)
+		}()
+	} else {
+		close(archiveDone)
+	}
+
+	// Digests
+	digestCtx, stopDigests := context.WithCancel(context.Background())
+	digestDone := make(chan struct{})
+
+	if digestMailer != nil {
+		go func() {
+			defer close(digestDone)
+			sendDigests(digestCtx, commentUseCase, cfg.Digest.Interval, l)
+		}()
+	} else {
+		close(digestDone)
+	}
+
+	// Attachment GC
+	collectCtx, stopCollect := context.WithCancel(context.Background())
+	collectDone := make(chan struct{})
+
+	if blobStore != nil {
+		go func() {
+			defer close(collectDone)
+			collectAttachments(collectCtx, commentUseCase, cfg.Attachment.GCInterval, l)
+		}()
+	} else {
+		close(collectDone)
+	}
+
 	// HTTP Server
-	httpServer := httpserver.New(l, httpserver.Port(cfg.HTTP.Port))
-	restapi.NewRouter(httpServer.App, cfg, commentUseCase, l)
+	httpServer := httpserver.New(l,
+		httpserver.Port(cfg.HTTP.Port),
+		httpserver.BodyLimit(bodyLimit(cfg)),
+		httpserver.Metrics(reg),
+		httpserver.Tracing(tr.Provider),
+		httpserver.AccessLog(cfg.Log.AccessSampleRate),
+	)
+	restapi.NewRouter(httpServer.App, cfg, commentUseCase, reg, h, l)
+	if cfg.GraphQL.Enabled {
+		graphql.NewRouter(httpServer.App, cfg, commentUseCase, l)
+	}
 
-	// Start Server
+	// gRPC Server
+	var grpcServer *grpcserver.Server
+	// nil-канал в select никогда не срабатывает, если gRPC выключен
+	var grpcNotify <-chan error
+
+	if cfg.GRPC.Port != "" {
+		grpcServer = grpcserver.New(l,
+			grpcserver.Port(cfg.GRPC.Port),
+			grpcserver.Metrics(reg),
+			grpcserver.Tracing(tr.Provider),
+			grpcserver.AccessLog(),
+		)
+		grpc.NewRouter(grpcServer.App, commentUseCase, h, l)
+
+		grpcNotify = grpcServer.Notify()
+	}
+
+	// Start Servers
 	httpServer.Start()
+	if grpcServer != nil {
+		grpcServer.Start()
+	}
+	h.MarkStarted()
 
 	// Waiting Signal
 	interrupt := make(chan os.Signal, 1)
@@ -47,11 +191,43 @@ func Run(cfg *config.Config) {
 		l.Info("app - Run - signal: %s", s.String())
 	case err = <-httpServer.Notify():
 		l.Error(fmt.Errorf("app - Run - httpServer.Notify: %v", err))
+	case err = <-grpcNotify:
+		l.Error(fmt.Errorf("app - Run - grpcServer.Notify: %v", err))
 	}
 
 	// Shutdown
+	// readiness начинает падать до остановки сервера, чтобы балансировщик успел убрать инстанс
+	h.MarkShuttingDown()
+	time.Sleep(cfg.Health.ShutdownDelay)
+
+	// открытые потоки уведомлений иначе держали бы соединения до таймаута остановки
+	notificationHub.Close()
+
 	err = httpServer.Shutdown()
 	if err != nil {
 		l.Error(fmt.Errorf("app - Run - httpServer.Shutdown: %v", err))
 	}
+
+	if grpcServer != nil {
+		err = grpcServer.Shutdown()
+		if err != nil {
+			l.Error(fmt.Errorf("app - Run - grpcServer.Shutdown: %v", err))
+		}
+	}
+
+	// фоновые задачи должны закончить до закрытия хранилища
+	stopArchive()
+	stopDigests()
+	stopCollect()
+	<-archiveDone
+	<-digestDone
+	<-collectDone
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = tr.Shutdown(ctx)
+	if err != nil {
+		l.Error(fmt.Errorf("app - Run - tracer.Shutdown: %v", err))
+	}
 }
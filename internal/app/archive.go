@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+)
+
+// autoArchive - сразу и затем раз в interval архивирует деревья без изменений дольше after,
+// пока не закрыт ctx. Реплики могут делать это одновременно: уже заархивированное не трогается.
+func autoArchive(ctx context.Context, uc usecase.CommentUseCase, after, interval time.Duration, l logger.Interface) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		n, err := uc.ArchiveInactive(ctx, after)
+		if err != nil && ctx.Err() == nil {
+			l.Error(fmt.Errorf("app - autoArchive - uc.ArchiveInactive: %w", err))
+		} else if n > 0 {
+			l.Info("app - autoArchive - archived %d trees", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
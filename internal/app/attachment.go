@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/blob"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+)
+
+// _multipartOverhead - запас размера тела multipart-запроса сверх самих файлов.
+const _multipartOverhead = 1 << 20
+
+func newBlobStore(cfg *config.Config) repo.BlobStore {
+	switch cfg.Attachment.Store {
+	case config.AttachmentStoreLocal:
+		return blob.NewLocal(cfg.Attachment.Dir)
+	case config.AttachmentStoreS3:
+		return blob.NewS3(cfg.S3.Endpoint, cfg.S3.Region, cfg.S3.Bucket, cfg.S3.AccessKey, cfg.S3.SecretKey)
+	default:
+		return nil
+	}
+}
+
+// bodyLimit - в запрос на создание коммента помещаются все его файлы, 0 - лимит fiber по умолчанию.
+func bodyLimit(cfg *config.Config) int {
+	if cfg.Attachment.Store == config.AttachmentStoreNone {
+		return 0
+	}
+
+	return int(cfg.Attachment.MaxSize)*cfg.Attachment.MaxPerComment + _multipartOverhead
+}
+
+// collectAttachments - сразу и затем раз в interval удаляет брошенные вложения, пока не закрыт ctx.
+// Реплики могут делать это одновременно: удаление уже удаленного файла - не ошибка.
+func collectAttachments(ctx context.Context, uc usecase.CommentUseCase, interval time.Duration, l logger.Interface) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		n, err := uc.CollectAttachments(ctx)
+		if err != nil && ctx.Err() == nil {
+			l.Error(fmt.Errorf("app - collectAttachments - uc.CollectAttachments: %w", err))
+		} else if n > 0 {
+			l.Info("app - collectAttachments - removed %d attachments", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/mailer"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+)
+
+// newMailer - nil, если дайджесты выключены.
+func newMailer(cfg *config.Config) repo.Mailer {
+	switch cfg.Digest.Mailer {
+	case config.MailerSMTP:
+		return mailer.NewSMTP(cfg.SMTP.Addr, cfg.SMTP.Username, cfg.SMTP.Password, cfg.Digest.From, cfg.SMTP.Timeout)
+	case config.MailerFile:
+		return mailer.NewFileDrop(cfg.Digest.FileDir, cfg.Digest.From)
+	default:
+		return nil
+	}
+}
+
+// sendDigests - сразу и затем раз в interval рассылает дайджесты, которым пора, пока не закрыт ctx.
+// Рассылку должен вести один экземпляр сервиса, на остальных DIGEST_MAILER=none.
+func sendDigests(ctx context.Context, uc usecase.CommentUseCase, interval time.Duration, l logger.Interface) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		n, err := uc.SendDigests(ctx)
+		if err != nil && ctx.Err() == nil {
+			l.Error(fmt.Errorf("app - sendDigests - uc.SendDigests: %w", err))
+		}
+		if n > 0 {
+			l.Info("app - sendDigests - sent %d digests", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
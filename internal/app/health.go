@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+	"github.com/andreyxaxa/Comment-Tree/pkg/postgres"
+)
+
+func schemaVersionCheck(pg *postgres.Postgres, expected uint) health.Checker {
+	return func(ctx context.Context) error {
+		version, dirty, err := pg.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty", version)
+		}
+
+		if version != expected {
+			return fmt.Errorf("schema version %d, expected %d", version, expected)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/dump"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/importer"
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var errImportUsage = errors.New("usage: import -format disqus|wordpress|reddit [-source NAME] FILE...")
+
+// Import - команда `import`: переносит комменты из выгрузок Disqus, WordPress и Reddit
+// в хранилище по STORAGE_DRIVER. Каждая запись фиксируется отдельно вместе с id источника,
+// так что прерванный или повторный запуск продолжает с того же места без дублей.
+func Import(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "dump format: disqus, wordpress or reddit")
+	source := fs.String("source", "", "id namespace of the dump (default: derived from the dump)")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return errImportUsage
+	}
+
+	if *format == "" || fs.NArg() == 0 {
+		return errImportUsage
+	}
+
+	if cfg.Storage.Driver == config.StorageMemory {
+		return fmt.Errorf("app - Import: storage %q does not keep imported comments", cfg.Storage.Driver)
+	}
+
+	l := logger.New(cfg.Log.Level)
+
+	commentRepo, closeRepo, err := newCommentRepo(cfg, noop.NewTracerProvider(), prometheus.NewRegistry(), health.New())
+	if err != nil {
+		return fmt.Errorf("app - Import - newCommentRepo: %w", err)
+	}
+	defer closeRepo()
+
+	// общий с сервером Redis-кэш должен узнать о новых ответах в закэшированных деревьях
+	commentRepo, closeCache := withCache(cfg, commentRepo, prometheus.NewRegistry(), health.New())
+	defer closeCache()
+
+	cdnWebAPI := webapi.NewCDN(
+		cfg.CDN.PurgeURL,
+		cfg.CDN.PurgeMethod,
+		cfg.CDN.PurgeAuthHeader,
+		cfg.CDN.PurgeAuth,
+		cfg.CDN.PurgeTimeout,
+		l,
+	)
+
+	uc := importer.New(commentRepo, cdnWebAPI)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for _, path := range fs.Args() {
+		d, err := parseDumpFile(*format, path)
+		if err != nil {
+			return fmt.Errorf("app - Import - parseDumpFile: %w", err)
+		}
+
+		if *source != "" {
+			d.Source = *source
+		}
+
+		summary, err := uc.Import(ctx, d.Source, d.Comments)
+		printImportSummary(os.Stdout, path, summary)
+
+		if err != nil {
+			return fmt.Errorf("app - Import - uc.Import: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func parseDumpFile(format, path string) (dump.Dump, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return dump.Dump{}, err
+	}
+	defer f.Close()
+
+	d, err := dump.Parse(format, f)
+	if err != nil {
+		return dump.Dump{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return d, nil
+}
+
+func printImportSummary(w io.Writer, path string, s dto.SourceImportSummary) {
+	fmt.Fprintf(w, "%s (source %s)\n", path, s.Source)
+	fmt.Fprintf(w, "  records:          %d\n", s.Total)
+	fmt.Fprintf(w, "  imported:         %d\n", s.Imported)
+	fmt.Fprintf(w, "  already imported: %d\n", s.AlreadyImported)
+	fmt.Fprintf(w, "  skipped:          %d\n", len(s.Skipped))
+
+	for _, i := range s.Skipped {
+		fmt.Fprintf(w, "    %s: %s\n", i.SourceID, i.Reason)
+	}
+
+	fmt.Fprintf(w, "  broken parents:   %d\n", len(s.BrokenParents))
+
+	for _, i := range s.BrokenParents {
+		fmt.Fprintf(w, "    %s -> %s: %s\n", i.SourceID, i.ParentSourceID, i.Reason)
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/migrations"
+	"github.com/andreyxaxa/Comment-Tree/pkg/migrator"
+	"github.com/andreyxaxa/Comment-Tree/pkg/postgres"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up | down [N] | status | to VERSION")
+
+// Migrate - подкоманда `app migrate ...`, работает с вшитыми миграциями выбранного хранилища.
+func Migrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	var (
+		mg  *migrator.Migrator
+		err error
+	)
+
+	switch cfg.Storage.Driver {
+	case config.StoragePostgres:
+		pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PG.PoolMax))
+		if err != nil {
+			return fmt.Errorf("app - Migrate - postgres.New: %w", err)
+		}
+		defer pg.Close()
+
+		mg, err = migrator.NewPostgres(pg, migrations.FS)
+		if err != nil {
+			return fmt.Errorf("app - Migrate - migrator.NewPostgres: %w", err)
+		}
+	case config.StorageSQLite:
+		mg, err = migrator.NewSQLite(cfg.Storage.SQLitePath, migrations.SQLite())
+		if err != nil {
+			return fmt.Errorf("app - Migrate - migrator.NewSQLite: %w", err)
+		}
+	default:
+		return fmt.Errorf("app - Migrate: storage %q has no migrations", cfg.Storage.Driver)
+	}
+	defer mg.Close()
+
+	switch args[0] {
+	case "up":
+		return mg.Up()
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return errMigrateUsage
+			}
+		}
+
+		return mg.Down(n)
+	case "to":
+		if len(args) < 2 {
+			return errMigrateUsage
+		}
+
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return errMigrateUsage
+		}
+
+		return mg.To(uint(version))
+	case "status":
+		s, err := mg.Status()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("current: %d (dirty: %t)\n", s.Current, s.Dirty)
+		fmt.Printf("latest:  %d\n", s.Latest)
+
+		for _, v := range s.Versions {
+			mark := " "
+			if v <= s.Current {
+				mark = "x"
+			}
+
+			fmt.Printf("  [%s] %d\n", mark, v)
+		}
+
+		return nil
+	default:
+		return errMigrateUsage
+	}
+}
+
+// migrate - применяет миграции, если autoMigrate, и проверяет, что схема не новее бинарника.
+// Возвращает версию схемы, ожидаемую бинарником. Мигратор закрывается здесь же:
+// он держит отдельное соединение с базой.
+func migrate(mg *migrator.Migrator, autoMigrate bool) (uint, error) {
+	defer mg.Close()
+
+	if autoMigrate {
+		err := mg.Up()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err := mg.CheckCompatible()
+	if err != nil {
+		return 0, err
+	}
+
+	return mg.Latest(), nil
+}
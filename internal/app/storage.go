@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/cached"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/persistent"
+	"github.com/andreyxaxa/Comment-Tree/migrations"
+	"github.com/andreyxaxa/Comment-Tree/pkg/cache"
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+	"github.com/andreyxaxa/Comment-Tree/pkg/migrator"
+	"github.com/andreyxaxa/Comment-Tree/pkg/postgres"
+	"github.com/andreyxaxa/Comment-Tree/pkg/sqlite"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newCommentRepo - хранилище по STORAGE_DRIVER вместе с его миграциями,
+// метриками и проверками готовности. Возвращает функцию закрытия хранилища.
+func newCommentRepo(cfg *config.Config, tp trace.TracerProvider, reg prometheus.Registerer, h *health.Health) (repo.CommentRepo, func(), error) {
+	switch cfg.Storage.Driver {
+	case config.StoragePostgres:
+		pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PG.PoolMax), postgres.Tracing(tp))
+		if err != nil {
+			return nil, nil, fmt.Errorf("postgres.New: %w", err)
+		}
+
+		mg, err := migrator.NewPostgres(pg, migrations.FS)
+		if err != nil {
+			pg.Close()
+
+			return nil, nil, fmt.Errorf("migrator.NewPostgres: %w", err)
+		}
+
+		schemaVersion, err := migrate(mg, cfg.PG.AutoMigrate)
+		if err != nil {
+			pg.Close()
+
+			return nil, nil, fmt.Errorf("migrate: %w", err)
+		}
+
+		reg.MustRegister(postgres.NewCollector(pg))
+		h.AddReadinessCheck("postgres", pg.Ping)
+		h.AddReadinessCheck("schema", schemaVersionCheck(pg, schemaVersion))
+
+		return persistent.New(pg), pg.Close, nil
+	case config.StorageSQLite:
+		// SQLite - хранилище для локальной разработки, миграции применяются всегда
+		mg, err := migrator.NewSQLite(cfg.Storage.SQLitePath, migrations.SQLite())
+		if err != nil {
+			return nil, nil, fmt.Errorf("migrator.NewSQLite: %w", err)
+		}
+
+		_, err = migrate(mg, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("migrate: %w", err)
+		}
+
+		sl, err := sqlite.New(cfg.Storage.SQLitePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sqlite.New: %w", err)
+		}
+
+		h.AddReadinessCheck("sqlite", sl.Ping)
+
+		return persistent.NewSQLite(sl), sl.Close, nil
+	case config.StorageMemory:
+		return memory.New(), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// withCache - оборачивает хранилище в read-through кэш по CACHE_BACKEND.
+// Возвращает функцию закрытия кэша.
+func withCache(cfg *config.Config, r repo.CommentRepo, reg prometheus.Registerer, h *health.Health) (repo.CommentRepo, func()) {
+	switch cfg.Cache.Backend {
+	case config.CacheLRU:
+		c := cache.NewLRU(
+			cache.MaxEntries(cfg.Cache.LRUMaxEntries),
+			cache.MaxBytes(cfg.Cache.LRUMaxBytes),
+		)
+
+		return cached.New(r, c, cfg.Cache.TTL, reg), func() {}
+	case config.CacheRedis:
+		c := cache.NewRedis(cfg.Cache.RedisAddr,
+			cache.Password(cfg.Cache.RedisPassword),
+			cache.DB(cfg.Cache.RedisDB),
+			cache.Prefix(cfg.Cache.RedisPrefix),
+		)
+
+		h.AddReadinessCheck("cache", c.Ping)
+
+		return cached.New(r, c, cfg.Cache.TTL, reg), func() { c.Close() }
+	default:
+		return r, func() {}
+	}
+}
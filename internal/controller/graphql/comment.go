@@ -0,0 +1,442 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+const _cursorPrefix = "offset:"
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type commentResolver struct {
+	// c - Depth и Path заполнены, только если коммент загружен по id
+	c entity.Comment
+}
+
+type childrenArgs struct {
+	First   int32
+	After   *string
+	OrderBy *orderInput
+}
+
+func (r *commentResolver) ID() gql.ID {
+	return formatID(r.c.ID)
+}
+
+func (r *commentResolver) ParentID() *gql.ID {
+	if !r.c.ParentID.Valid {
+		return nil
+	}
+
+	id := formatID(r.c.ParentID.Int64)
+
+	return &id
+}
+
+func (r *commentResolver) ReplyToID() *gql.ID {
+	if !r.c.ReplyToID.Valid {
+		return nil
+	}
+
+	id := formatID(r.c.ReplyToID.Int64)
+
+	return &id
+}
+
+func (r *commentResolver) ReplyTo(ctx context.Context) (*commentResolver, error) {
+	if !r.c.ReplyToID.Valid {
+		return nil, nil
+	}
+
+	c, err := loadersFrom(ctx).comments.Load(ctx, r.c.ReplyToID.Int64)()
+	if err != nil || c == nil {
+		return nil, err
+	}
+
+	return &commentResolver{c: *c}, nil
+}
+
+func (r *commentResolver) Content() string {
+	return r.c.Content
+}
+
+func (r *commentResolver) ContentHTML() string {
+	return markdown.HTML(r.c.Content)
+}
+
+func (r *commentResolver) Author() string {
+	return r.c.Author
+}
+
+func (r *commentResolver) CreatedAt() gql.Time {
+	return gql.Time{Time: r.c.CreatedAt}
+}
+
+func (r *commentResolver) UpdatedAt() gql.Time {
+	return gql.Time{Time: r.c.UpdatedAt}
+}
+
+func (r *commentResolver) Version() int32 {
+	return int32(r.c.Version) //nolint:gosec // версия растет на единицу за изменение
+}
+
+func (r *commentResolver) Depth(ctx context.Context) (int32, error) {
+	path, err := r.path(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(len(path) - 1), nil //nolint:gosec // глубина дерева далека от int32
+}
+
+func (r *commentResolver) Parent(ctx context.Context) (*commentResolver, error) {
+	if !r.c.ParentID.Valid {
+		return nil, nil
+	}
+
+	parent, err := loadersFrom(ctx).comments.Load(ctx, r.c.ParentID.Int64)()
+	if err != nil || parent == nil {
+		return nil, err
+	}
+
+	return &commentResolver{c: *parent}, nil
+}
+
+// Ancestors - путь коммента, затем все предки одной пачкой.
+func (r *commentResolver) Ancestors(ctx context.Context) ([]*commentResolver, error) {
+	path, err := r.path(ctx)
+	if err != nil || len(path) < 2 {
+		return []*commentResolver{}, err
+	}
+
+	ancestors, errs := loadersFrom(ctx).comments.LoadMany(ctx, path[:len(path)-1])()
+
+	resolvers := make([]*commentResolver, 0, len(ancestors))
+	for i, a := range ancestors {
+		if len(errs) > i && errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		// предок удален между запросами - пропускаем
+		if a != nil {
+			resolvers = append(resolvers, &commentResolver{c: *a})
+		}
+	}
+
+	return resolvers, nil
+}
+
+// Children - страница ответов; на один больше, чтобы узнать, есть ли следующая.
+func (r *commentResolver) Children(ctx context.Context, args childrenArgs) (*connectionResolver, error) {
+	offset := 0
+
+	if args.After != nil {
+		var err error
+
+		offset, err = decodeCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	page := pageParams(args.OrderBy, args.First, int32(offset)) //nolint:gosec // курсор - наш же offset
+	limit := page.Limit
+	page.Limit++
+
+	children, err := loadersFrom(ctx).children.Load(ctx, childrenKey{parentID: r.c.ID, page: page})()
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &connectionResolver{parentID: r.c.ID, nodes: children}
+	if len(children) > limit {
+		conn.nodes = children[:limit]
+		conn.hasNextPage = true
+	}
+
+	if len(conn.nodes) > 0 {
+		cursor := encodeCursor(offset + len(conn.nodes))
+		conn.endCursor = &cursor
+	}
+
+	return conn, nil
+}
+
+func (r *commentResolver) ChildrenCount(ctx context.Context) (int32, error) {
+	count, err := loadersFrom(ctx).counts.Load(ctx, r.c.ID)()
+
+	return int32(count.Children), err //nolint:gosec // число комментов далеко от int32
+}
+
+func (r *commentResolver) DescendantsCount(ctx context.Context) (int32, error) {
+	count, err := loadersFrom(ctx).counts.Load(ctx, r.c.ID)()
+
+	return int32(count.Descendants), err //nolint:gosec // число комментов далеко от int32
+}
+
+func (r *commentResolver) PinOrder() *int32 {
+	if !r.c.PinOrder.Valid {
+		return nil
+	}
+
+	order := int32(r.c.PinOrder.Int64) //nolint:gosec // места закрепления задает модератор
+
+	return &order
+}
+
+func (r *commentResolver) Pinned() bool {
+	return r.c.PinOrder.Valid
+}
+
+func (r *commentResolver) Highlighted() bool {
+	return r.c.Highlighted
+}
+
+func (r *commentResolver) LockState(ctx context.Context) (*string, error) {
+	state, _, err := r.lock(ctx)
+	if err != nil || state == entity.LockNone {
+		return nil, err
+	}
+
+	state = strings.ToUpper(state)
+
+	return &state, nil
+}
+
+func (r *commentResolver) LockReason(ctx context.Context) (*string, error) {
+	state, reason, err := r.lock(ctx)
+	if err != nil || state == entity.LockNone {
+		return nil, err
+	}
+
+	return &reason, nil
+}
+
+// IsNew - визит запоминается у корня треда, поэтому нужен путь.
+func (r *commentResolver) IsNew(ctx context.Context) (bool, error) {
+	viewer := userFrom(ctx)
+	if viewer == "" {
+		return false, nil
+	}
+
+	path, err := r.path(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	lastSeenID, err := loadersFrom(ctx).lastSeen.Load(ctx, path[0])()
+	if err != nil {
+		return false, err
+	}
+
+	return entity.Unread(r.c, viewer, lastSeenID), nil
+}
+
+func (r *commentResolver) References(ctx context.Context) ([]*referenceResolver, error) {
+	refs, err := loadersFrom(ctx).references.Load(ctx, r.c.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*referenceResolver, len(refs))
+	for i, ref := range refs {
+		resolvers[i] = &referenceResolver{ref: ref}
+	}
+
+	return resolvers, nil
+}
+
+func (r *commentResolver) Attachments(ctx context.Context) ([]*attachmentResolver, error) {
+	attachments, err := loadersFrom(ctx).attachments.Load(ctx, r.c.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*attachmentResolver, len(attachments))
+	for i, a := range attachments {
+		resolvers[i] = &attachmentResolver{a: a}
+	}
+
+	return resolvers, nil
+}
+
+// lock - блокировка действует на все поддерево, поэтому берется самая строгая среди предков и самого коммента.
+func (r *commentResolver) lock(ctx context.Context) (state, reason string, err error) {
+	path, err := r.path(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	ancestors, errs := loadersFrom(ctx).comments.LoadMany(ctx, path[:len(path)-1])()
+
+	chain := make([]entity.Comment, 0, len(path))
+	for i, a := range ancestors {
+		if len(errs) > i && errs[i] != nil {
+			return "", "", errs[i]
+		}
+
+		// предок удален между запросами - пропускаем
+		if a != nil {
+			chain = append(chain, *a)
+		}
+	}
+
+	state, reason = entity.EffectiveLock(append(chain, r.c)...)
+
+	return state, reason, nil
+}
+
+// path - у комментов из списков пути нет, тогда он догружается по id.
+func (r *commentResolver) path(ctx context.Context) ([]int64, error) {
+	if r.c.Path != nil {
+		return r.c.Path, nil
+	}
+
+	c, err := loadersFrom(ctx).comments.Load(ctx, r.c.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	// коммент удален между запросами
+	if c == nil {
+		return []int64{r.c.ID}, nil
+	}
+
+	return c.Path, nil
+}
+
+type connectionResolver struct {
+	parentID    int64
+	nodes       []entity.Comment
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (c *connectionResolver) Nodes() []*commentResolver {
+	return commentResolvers(c.nodes)
+}
+
+func (c *connectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := loadersFrom(ctx).counts.Load(ctx, c.parentID)()
+
+	return int32(count.Children), err //nolint:gosec // число комментов далеко от int32
+}
+
+func (c *connectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{hasNextPage: c.hasNextPage, endCursor: c.endCursor}
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (p *pageInfoResolver) HasNextPage() bool {
+	return p.hasNextPage
+}
+
+func (p *pageInfoResolver) EndCursor() *string {
+	return p.endCursor
+}
+
+type referenceResolver struct {
+	ref entity.Reference
+}
+
+func (r *referenceResolver) ID() gql.ID {
+	return formatID(r.ref.TargetID)
+}
+
+func (r *referenceResolver) Available() bool {
+	return r.ref.Target != nil
+}
+
+func (r *referenceResolver) Comment() *commentResolver {
+	if r.ref.Target == nil {
+		return nil
+	}
+
+	return &commentResolver{c: *r.ref.Target}
+}
+
+type attachmentResolver struct {
+	a entity.Attachment
+}
+
+func (r *attachmentResolver) ID() gql.ID {
+	return formatID(r.a.ID)
+}
+
+func (r *attachmentResolver) Filename() string {
+	return r.a.Filename
+}
+
+func (r *attachmentResolver) ContentType() string {
+	return r.a.ContentType
+}
+
+func (r *attachmentResolver) Size() int32 {
+	return int32(r.a.Size) //nolint:gosec // размер файла ограничен лимитом загрузки
+}
+
+func (r *attachmentResolver) Width() *int32 {
+	return optionalInt32(r.a.Width)
+}
+
+func (r *attachmentResolver) Height() *int32 {
+	return optionalInt32(r.a.Height)
+}
+
+func (r *attachmentResolver) URL() string {
+	return r.a.URL
+}
+
+func (r *attachmentResolver) ThumbnailURL() *string {
+	if r.a.ThumbnailURL == "" {
+		return nil
+	}
+
+	return &r.a.ThumbnailURL
+}
+
+func (r *attachmentResolver) CreatedAt() gql.Time {
+	return gql.Time{Time: r.a.CreatedAt}
+}
+
+// optionalInt32 - 0 - значения нет.
+func optionalInt32(n int) *int32 {
+	if n == 0 {
+		return nil
+	}
+
+	v := int32(n) //nolint:gosec // размеры картинки ограничены декодером
+
+	return &v
+}
+
+// курсор - непрозрачная для клиента строка, внутри - offset следующей страницы
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(_cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), _cursorPrefix))
+	if err != nil || offset < 0 || !strings.HasPrefix(string(raw), _cursorPrefix) {
+		return 0, errInvalidCursor
+	}
+
+	return offset, nil
+}
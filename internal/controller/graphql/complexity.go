@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"strconv"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// _pageArgs - аргументы, задающие размер списка: стоимость подполей умножается на них.
+var _pageArgs = []string{"first", "limit"}
+
+// complexity - оценка стоимости запроса до выполнения: каждое поле стоит 1,
+// у поля с first или limit стоимость подполей умножается на размер страницы
+// (с теми же ограничениями и значением по умолчанию, что и при выполнении).
+// ok - false, если запрос не разбирается; такой запрос отклонит сама схема.
+func complexity(query, operationName string, variables map[string]any) (int, bool) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0, false
+	}
+
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return 0, false
+	}
+
+	c := &complexityCounter{
+		fragments: doc.Fragments,
+		variables: variables,
+		visiting:  make(map[string]bool),
+	}
+
+	return c.selectionSet(op.SelectionSet), true
+}
+
+type complexityCounter struct {
+	fragments ast.FragmentDefinitionList
+	variables map[string]any
+	// visiting - защита от циклических фрагментов
+	visiting map[string]bool
+}
+
+func (c *complexityCounter) selectionSet(set ast.SelectionSet) int {
+	cost := 0
+
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			cost += 1 + c.multiplier(sel)*c.selectionSet(sel.SelectionSet)
+		case *ast.InlineFragment:
+			cost += c.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			fragment := c.fragments.ForName(sel.Name)
+			if fragment == nil || c.visiting[sel.Name] {
+				continue
+			}
+
+			c.visiting[sel.Name] = true
+			cost += c.selectionSet(fragment.SelectionSet)
+			delete(c.visiting, sel.Name)
+		}
+	}
+
+	return cost
+}
+
+func (c *complexityCounter) multiplier(f *ast.Field) int {
+	for _, name := range _pageArgs {
+		arg := f.Arguments.ForName(name)
+		if arg == nil {
+			continue
+		}
+
+		return pageSize(c.intValue(arg.Value))
+	}
+
+	// поля без аргумента, но со значением по умолчанию в схеме
+	switch f.Name {
+	case "children", "roots", "search":
+		return _defaultPageSize
+	// ссылок у коммента не больше entity.MaxReferences, и за каждой может стоять целый коммент
+	case "references":
+		return entity.MaxReferences
+	}
+
+	return 1
+}
+
+func (c *complexityCounter) intValue(v *ast.Value) int {
+	switch v.Kind {
+	case ast.IntValue:
+		n, err := strconv.Atoi(v.Raw)
+		if err != nil {
+			return 0
+		}
+
+		return n
+	case ast.Variable:
+		// из JSON числа приходят как float64
+		switch n := c.variables[v.Raw].(type) {
+		case float64:
+			return int(n)
+		case int:
+			return n
+		}
+	}
+
+	return 0
+}
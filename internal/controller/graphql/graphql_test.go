@@ -0,0 +1,471 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	pngenc "image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/graphql"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/blob"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// countingRepo - считает пакетные чтения, чтобы проверить, что loader их собирает.
+type countingRepo struct {
+	repo.CommentRepo
+
+	byIDs, children, counts atomic.Int32
+}
+
+func (r *countingRepo) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	r.byIDs.Add(1)
+
+	return r.CommentRepo.GetCommentsByIDs(ctx, ids)
+}
+
+func (r *countingRepo) GetChildren(ctx context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error) {
+	r.children.Add(1)
+
+	return r.CommentRepo.GetChildren(ctx, parentIDs, sortBy, order, limit, offset)
+}
+
+func (r *countingRepo) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	r.counts.Add(1)
+
+	return r.CommentRepo.CountReplies(ctx, ids)
+}
+
+func (r *countingRepo) reset() {
+	r.byIDs.Store(0)
+	r.children.Store(0)
+	r.counts.Store(0)
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func newApp(t *testing.T, opts ...comment.Option) (*fiber.App, *countingRepo, *comment.CommentUseCase) {
+	t.Helper()
+
+	l := logger.New("error")
+	r := &countingRepo{CommentRepo: memory.New()}
+	uc := comment.New(r, webapi.NewCDN("", "", "", "", time.Second, l), l, opts...)
+
+	cfg := &config.Config{}
+	cfg.GraphQL.MaxDepth = 10
+	cfg.GraphQL.MaxComplexity = 5000
+
+	app := fiber.New()
+	graphql.NewRouter(app, cfg, uc, l)
+
+	return app, r, uc
+}
+
+func exec(t *testing.T, app *fiber.App, query string, variables map[string]any) response {
+	t.Helper()
+
+	return execAs(t, app, "", query, variables)
+}
+
+// execAs - запрос от имени user из X-User, "" - анонимный.
+func execAs(t *testing.T, app *fiber.App, user, query string, variables map[string]any) response {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/graphql", bytes.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out response
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	return out
+}
+
+func mustExec(t *testing.T, app *fiber.App, query string, variables map[string]any, dst any) {
+	t.Helper()
+
+	mustExecAs(t, app, "", query, variables, dst)
+}
+
+func mustExecAs(t *testing.T, app *fiber.App, user, query string, variables map[string]any, dst any) {
+	t.Helper()
+
+	resp := execAs(t, app, user, query, variables)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors: %+v", resp.Errors)
+	}
+
+	err := json.Unmarshal(resp.Data, dst)
+	if err != nil {
+		t.Fatalf("unmarshal data %s: %v", resp.Data, err)
+	}
+}
+
+type node struct {
+	ID       string `json:"id"`
+	Depth    int    `json:"depth"`
+	Children struct {
+		Nodes      []node `json:"nodes"`
+		TotalCount int    `json:"totalCount"`
+		PageInfo   struct {
+			HasNextPage bool    `json:"hasNextPage"`
+			EndCursor   *string `json:"endCursor"`
+		} `json:"pageInfo"`
+	} `json:"children"`
+	DescendantsCount int    `json:"descendantsCount"`
+	Ancestors        []node `json:"ancestors"`
+	Parent           *node  `json:"parent"`
+}
+
+func create(t *testing.T, app *fiber.App, parentID *string, content string) string {
+	t.Helper()
+
+	var data struct {
+		CreateComment node `json:"createComment"`
+	}
+
+	mustExec(t, app, `mutation($p: ID, $c: String!) { createComment(parentId: $p, content: $c) { id } }`,
+		map[string]any{"p": parentID, "c": content}, &data)
+
+	return data.CreateComment.ID
+}
+
+func TestNestedQueryIsBatched(t *testing.T) {
+	app, r, _ := newApp(t)
+
+	// 3 корня, у каждого 3 ответа, у каждого ответа 2 ответа
+	for range 3 {
+		root := create(t, app, nil, "root")
+		for range 3 {
+			reply := create(t, app, &root, "reply")
+			for range 2 {
+				create(t, app, &reply, "nested")
+			}
+		}
+	}
+
+	r.reset()
+
+	var data struct {
+		Roots struct {
+			Nodes []node `json:"nodes"`
+		} `json:"roots"`
+	}
+
+	mustExec(t, app, `{
+		roots(limit: 10) {
+			nodes {
+				id
+				descendantsCount
+				children(first: 2) {
+					totalCount
+					pageInfo { hasNextPage endCursor }
+					nodes {
+						depth
+						children { nodes { id depth ancestors { id } parent { id } } }
+					}
+				}
+			}
+		}
+	}`, nil, &data)
+
+	if len(data.Roots.Nodes) != 3 {
+		t.Fatalf("got %d roots, want 3", len(data.Roots.Nodes))
+	}
+
+	for _, root := range data.Roots.Nodes {
+		if root.DescendantsCount != 9 || root.Children.TotalCount != 3 || len(root.Children.Nodes) != 2 || !root.Children.PageInfo.HasNextPage {
+			t.Fatalf("root = %+v, want 9 descendants and the first 2 of 3 replies", root)
+		}
+
+		for _, reply := range root.Children.Nodes {
+			if reply.Depth != 1 || len(reply.Children.Nodes) != 2 {
+				t.Fatalf("reply = %+v, want depth 1 with 2 replies", reply)
+			}
+
+			for _, nested := range reply.Children.Nodes {
+				if nested.Depth != 2 || len(nested.Ancestors) != 2 || nested.Ancestors[0].ID != root.ID || nested.Parent == nil {
+					t.Fatalf("nested = %+v, want depth 2 with the root first among ancestors", nested)
+				}
+			}
+		}
+	}
+
+	// по одному вызову на уровень, а не на коммент
+	if got := r.children.Load(); got != 2 {
+		t.Fatalf("GetChildren called %d times, want 2 (one per level)", got)
+	}
+
+	if got := r.counts.Load(); got != 1 {
+		t.Fatalf("CountReplies called %d times, want 1", got)
+	}
+
+	// depth ответов и пути вложенных - одна пачка, затем предки и родители - вторая
+	if got := r.byIDs.Load(); got > 3 {
+		t.Fatalf("GetCommentsByIDs called %d times, want at most 3", got)
+	}
+
+	// следующая страница по курсору
+	var next struct {
+		Comment node `json:"comment"`
+	}
+
+	rootID := data.Roots.Nodes[0].ID
+	mustExec(t, app, `query($id: ID!, $after: String) { comment(id: $id) { children(first: 2, after: $after) { nodes { id } pageInfo { hasNextPage } } } }`,
+		map[string]any{"id": rootID, "after": *data.Roots.Nodes[0].Children.PageInfo.EndCursor}, &next)
+
+	if len(next.Comment.Children.Nodes) != 1 || next.Comment.Children.PageInfo.HasNextPage {
+		t.Fatalf("second page = %+v, want the last reply", next.Comment.Children)
+	}
+}
+
+func TestMutations(t *testing.T) {
+	app, _, _ := newApp(t)
+
+	root := create(t, app, nil, "root")
+	create(t, app, &root, "reply")
+
+	missing := "42"
+	resp := exec(t, app, `mutation($p: ID) { createComment(parentId: $p, content: "x") { id } }`, map[string]any{"p": missing})
+	assertError(t, resp, "parent not found")
+
+	var deleted struct {
+		DeleteComment bool `json:"deleteComment"`
+	}
+	mustExec(t, app, `mutation($id: ID!) { deleteComment(id: $id) }`, map[string]any{"id": root}, &deleted)
+
+	if !deleted.DeleteComment {
+		t.Fatal("deleteComment = false, want true")
+	}
+
+	var data struct {
+		Comment *node `json:"comment"`
+	}
+	mustExec(t, app, `query($id: ID!) { comment(id: $id) { id } }`, map[string]any{"id": root}, &data)
+
+	if data.Comment != nil {
+		t.Fatalf("comment after delete = %+v, want null", data.Comment)
+	}
+
+	resp = exec(t, app, `mutation($id: ID!) { deleteComment(id: $id) }`, map[string]any{"id": root})
+	assertError(t, resp, "comment not found")
+}
+
+func TestLimits(t *testing.T) {
+	app, _, _ := newApp(t)
+
+	// 1 + 100 * (2 + 100 * (1 + 1)) > 5000
+	resp := exec(t, app, `{ roots(limit: 100) { nodes { children(first: 100) { nodes { id } } } } }`, nil)
+	assertError(t, resp, "query complexity")
+
+	// то же через переменную
+	resp = exec(t, app, `query($n: Int) { roots(limit: $n) { nodes { children(first: $n) { nodes { id } } } } }`, map[string]any{"n": 100})
+	assertError(t, resp, "query complexity")
+
+	deep := "{ roots { nodes { " + strings.Repeat("children(first: 1) { nodes { ", 10) + "id" + strings.Repeat(" } }", 10) + " } } }"
+	resp = exec(t, app, deep, nil)
+	assertError(t, resp, "exceeds max depth")
+}
+
+func TestCommentState(t *testing.T) {
+	ctx := context.Background()
+
+	app, r, uc := newApp(t,
+		comment.MaxDepth(1, entity.DepthPolicyFlatten),
+		comment.Attachments(blob.NewLocal(t.TempDir()), "secret", comment.AttachmentLimits{
+			MaxSize:       1 << 20,
+			MaxPerComment: 5,
+			Types:         []string{"image/png"},
+			ThumbnailSize: 1,
+		}, time.Hour, time.Hour),
+	)
+
+	root := create(t, app, nil, "root")
+	reply := create(t, app, &root, "reply")
+
+	// ответ глубже лимита переносится к корню, но помнит, что отвечали на reply
+	nested := create(t, app, &reply, "nested")
+
+	rootID, _ := strconv.ParseInt(root, 10, 64)
+	replyID, _ := strconv.ParseInt(reply, 10, 64)
+	order := int64(1)
+
+	if err := r.SetPinOrder(ctx, replyID, &order); err != nil {
+		t.Fatalf("SetPinOrder: %v", err)
+	}
+	if err := r.SetHighlighted(ctx, replyID, true); err != nil {
+		t.Fatalf("SetHighlighted: %v", err)
+	}
+
+	// bob видел тред до nested включительно
+	if _, err := r.MarkSeen(ctx, "bob", rootID); err != nil {
+		t.Fatalf("MarkSeen: %v", err)
+	}
+
+	var png bytes.Buffer
+	if err := pngenc.Encode(&png, image.NewGray(image.Rect(0, 0, 2, 3))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	a, err := uc.UploadAttachment(ctx, "alice", "dot.png", &png)
+	if err != nil {
+		t.Fatalf("UploadAttachment: %v", err)
+	}
+
+	var created struct {
+		CreateComment struct {
+			ID string `json:"id"`
+		} `json:"createComment"`
+	}
+	mustExecAs(t, app, "alice", `mutation($p: ID, $c: String!, $a: [ID!]) { createComment(parentId: $p, content: $c, attachmentIds: $a) { id } }`,
+		map[string]any{"p": root, "c": ">>" + reply + " and >>" + nested, "a": []string{strconv.FormatInt(a.ID, 10)}}, &created)
+
+	if err = r.SetLock(ctx, rootID, entity.LockLocked, "offtopic"); err != nil {
+		t.Fatalf("SetLock: %v", err)
+	}
+
+	type state struct {
+		ID          string  `json:"id"`
+		ReplyToID   *string `json:"replyToId"`
+		ReplyTo     *node   `json:"replyTo"`
+		PinOrder    *int    `json:"pinOrder"`
+		Pinned      bool    `json:"pinned"`
+		Highlighted bool    `json:"highlighted"`
+		LockState   *string `json:"lockState"`
+		LockReason  *string `json:"lockReason"`
+		IsNew       bool    `json:"isNew"`
+		References  []struct {
+			ID        string `json:"id"`
+			Available bool   `json:"available"`
+			Comment   *node  `json:"comment"`
+		} `json:"references"`
+		Attachments []struct {
+			ID           string  `json:"id"`
+			Filename     string  `json:"filename"`
+			ContentType  string  `json:"contentType"`
+			Width        *int    `json:"width"`
+			Height       *int    `json:"height"`
+			URL          string  `json:"url"`
+			ThumbnailURL *string `json:"thumbnailUrl"`
+		} `json:"attachments"`
+	}
+
+	query := `query($id: ID!) { comment(id: $id) {
+		id replyToId replyTo { id } pinOrder pinned highlighted lockState lockReason isNew
+		references { id available comment { id } }
+		attachments { id filename contentType width height url thumbnailUrl }
+	} }`
+
+	get := func(user, id string) state {
+		t.Helper()
+
+		var data struct {
+			Comment state `json:"comment"`
+		}
+		mustExecAs(t, app, user, query, map[string]any{"id": id}, &data)
+
+		return data.Comment
+	}
+
+	got := get("bob", reply)
+	if got.PinOrder == nil || *got.PinOrder != 1 || !got.Pinned || !got.Highlighted || got.IsNew {
+		t.Fatalf("reply = %+v, want pinned first, highlighted, seen", got)
+	}
+	// блокировка корня действует на ответ
+	if got.LockState == nil || *got.LockState != "LOCKED" || got.LockReason == nil || *got.LockReason != "offtopic" {
+		t.Fatalf("reply lock = %v %v, want LOCKED offtopic inherited from the root", got.LockState, got.LockReason)
+	}
+
+	got = get("bob", nested)
+	if got.ReplyToID == nil || *got.ReplyToID != reply || got.ReplyTo == nil || got.ReplyTo.ID != reply || got.Pinned {
+		t.Fatalf("nested = %+v, want a reply to %s", got, reply)
+	}
+
+	// новое для bob, но не для автора и не для анонима
+	if got = get("bob", created.CreateComment.ID); !got.IsNew {
+		t.Fatal("comment created after the visit is not new for bob")
+	}
+	if got = get("alice", created.CreateComment.ID); got.IsNew {
+		t.Fatal("own comment is new for alice")
+	}
+
+	// удаленная цель ссылки остается в списке недоступной
+	nestedID, _ := strconv.ParseInt(nested, 10, 64)
+	if _, err = r.DeleteCommentWithChildren(ctx, nestedID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	got = get("", created.CreateComment.ID)
+	if got.IsNew {
+		t.Fatal("comment is new for an anonymous viewer")
+	}
+	if len(got.References) != 2 || got.References[0].ID != reply || !got.References[0].Available || got.References[0].Comment == nil ||
+		got.References[1].ID != nested || got.References[1].Available || got.References[1].Comment != nil {
+		t.Fatalf("references = %+v, want %s available and %s deleted", got.References, reply, nested)
+	}
+	if len(got.Attachments) != 1 {
+		t.Fatalf("attachments = %+v, want 1", got.Attachments)
+	}
+
+	at := got.Attachments[0]
+	if at.Filename != "dot.png" || at.ContentType != "image/png" || at.Width == nil || *at.Width != 2 || at.Height == nil || *at.Height != 3 ||
+		!strings.Contains(at.URL, "sig=") || at.ThumbnailURL == nil {
+		t.Fatalf("attachment = %+v", at)
+	}
+
+	// корень без блокировки и без закрепления
+	if err = r.SetLock(ctx, rootID, entity.LockNone, ""); err != nil {
+		t.Fatalf("SetLock: %v", err)
+	}
+	if got = get("", root); got.LockState != nil || got.LockReason != nil || got.PinOrder != nil || len(got.References) != 0 || len(got.Attachments) != 0 {
+		t.Fatalf("root = %+v, want no lock, pin, references or attachments", got)
+	}
+}
+
+func assertError(t *testing.T, resp response, want string) {
+	t.Helper()
+
+	for _, e := range resp.Errors {
+		if strings.Contains(e.Message, want) {
+			return
+		}
+	}
+
+	t.Fatalf("errors = %+v, want one containing %q", resp.Errors, want)
+}
@@ -0,0 +1,198 @@
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+const (
+	// _loaderWait - сколько ждать остальные ключи одного уровня запроса перед походом в use-case
+	_loaderWait = 2 * time.Millisecond
+	// _loaderBatchCapacity - больше ключей в одном запросе к хранилищу не отправляем
+	_loaderBatchCapacity = _maxPageSize
+)
+
+type loadersKey struct{}
+
+// loaders - создаются на каждый запрос, кэш живет до конца запроса.
+// Каждый уровень вложенного запроса превращается в один вызов use-case на каждый loader
+// (а для children - на каждый набор аргументов first/after/orderBy).
+type loaders struct {
+	// comments - nil, если коммента нет
+	comments *dataloader.Loader[int64, *entity.Comment]
+	children *dataloader.Loader[childrenKey, []entity.Comment]
+	counts   *dataloader.Loader[int64, entity.ReplyCount]
+
+	references  *dataloader.Loader[int64, []entity.Reference]
+	attachments *dataloader.Loader[int64, []entity.Attachment]
+	// lastSeen - по id корня, для зрителя из X-User
+	lastSeen *dataloader.Loader[int64, int64]
+}
+
+type childrenKey struct {
+	parentID int64
+	page     dto.GetCommentsParams
+}
+
+func newLoaders(c usecase.CommentUseCase, l logger.Interface, viewer string) *loaders {
+	return &loaders{
+		comments: dataloader.NewBatchedLoader(commentsBatch(c, l),
+			dataloader.WithWait[int64, *entity.Comment](_loaderWait),
+			dataloader.WithBatchCapacity[int64, *entity.Comment](_loaderBatchCapacity),
+		),
+		children: dataloader.NewBatchedLoader(childrenBatch(c, l),
+			dataloader.WithWait[childrenKey, []entity.Comment](_loaderWait),
+			dataloader.WithBatchCapacity[childrenKey, []entity.Comment](_loaderBatchCapacity),
+		),
+		counts: dataloader.NewBatchedLoader(countsBatch(c, l),
+			dataloader.WithWait[int64, entity.ReplyCount](_loaderWait),
+			dataloader.WithBatchCapacity[int64, entity.ReplyCount](_loaderBatchCapacity),
+		),
+		references: dataloader.NewBatchedLoader(referencesBatch(c, l),
+			dataloader.WithWait[int64, []entity.Reference](_loaderWait),
+			dataloader.WithBatchCapacity[int64, []entity.Reference](_loaderBatchCapacity),
+		),
+		attachments: dataloader.NewBatchedLoader(attachmentsBatch(c, l),
+			dataloader.WithWait[int64, []entity.Attachment](_loaderWait),
+			dataloader.WithBatchCapacity[int64, []entity.Attachment](_loaderBatchCapacity),
+		),
+		lastSeen: dataloader.NewBatchedLoader(lastSeenBatch(c, l, viewer),
+			dataloader.WithWait[int64, int64](_loaderWait),
+			dataloader.WithBatchCapacity[int64, int64](_loaderBatchCapacity),
+		),
+	}
+}
+
+func withLoaders(ctx context.Context, ld *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, ld)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders) //nolint:forcetypeassert // кладет сам handler
+}
+
+func commentsBatch(c usecase.CommentUseCase, l logger.Interface) dataloader.BatchFunc[int64, *entity.Comment] {
+	return func(ctx context.Context, ids []int64) []*dataloader.Result[*entity.Comment] {
+		results := make([]*dataloader.Result[*entity.Comment], len(ids))
+
+		comments, err := c.GetCommentsByIDs(ctx, ids)
+		if err != nil {
+			l.WithContext(ctx).Error(err, "graphql - commentsBatch")
+		}
+
+		for i, id := range ids {
+			results[i] = &dataloader.Result[*entity.Comment]{Error: storageError(err)}
+
+			if comment, ok := comments[id]; ok {
+				results[i].Data = &comment
+			}
+		}
+
+		return results
+	}
+}
+
+// childrenBatch - один вызов use-case на каждую страницу, общую для нескольких родителей.
+func childrenBatch(c usecase.CommentUseCase, l logger.Interface) dataloader.BatchFunc[childrenKey, []entity.Comment] {
+	return func(ctx context.Context, keys []childrenKey) []*dataloader.Result[[]entity.Comment] {
+		parents := make(map[dto.GetCommentsParams][]int64)
+		for _, k := range keys {
+			parents[k.page] = append(parents[k.page], k.parentID)
+		}
+
+		children := make(map[childrenKey][]entity.Comment)
+		errs := make(map[dto.GetCommentsParams]error)
+
+		for page, parentIDs := range parents {
+			replies, err := c.GetChildren(ctx, parentIDs, page)
+			if err != nil {
+				l.WithContext(ctx).Error(err, "graphql - childrenBatch")
+
+				errs[page] = storageError(err)
+
+				continue
+			}
+
+			for parentID, comments := range replies {
+				children[childrenKey{parentID: parentID, page: page}] = comments
+			}
+		}
+
+		results := make([]*dataloader.Result[[]entity.Comment], len(keys))
+		for i, k := range keys {
+			results[i] = &dataloader.Result[[]entity.Comment]{Data: children[k], Error: errs[k.page]}
+		}
+
+		return results
+	}
+}
+
+func countsBatch(c usecase.CommentUseCase, l logger.Interface) dataloader.BatchFunc[int64, entity.ReplyCount] {
+	return func(ctx context.Context, ids []int64) []*dataloader.Result[entity.ReplyCount] {
+		counts, err := c.CountReplies(ctx, ids)
+		if err != nil {
+			l.WithContext(ctx).Error(err, "graphql - countsBatch")
+		}
+
+		results := make([]*dataloader.Result[entity.ReplyCount], len(ids))
+		for i, id := range ids {
+			results[i] = &dataloader.Result[entity.ReplyCount]{Data: counts[id], Error: storageError(err)}
+		}
+
+		return results
+	}
+}
+
+func referencesBatch(c usecase.CommentUseCase, l logger.Interface) dataloader.BatchFunc[int64, []entity.Reference] {
+	return func(ctx context.Context, ids []int64) []*dataloader.Result[[]entity.Reference] {
+		refs, err := c.GetReferences(ctx, ids)
+		if err != nil {
+			l.WithContext(ctx).Error(err, "graphql - referencesBatch")
+		}
+
+		results := make([]*dataloader.Result[[]entity.Reference], len(ids))
+		for i, id := range ids {
+			results[i] = &dataloader.Result[[]entity.Reference]{Data: refs[id], Error: storageError(err)}
+		}
+
+		return results
+	}
+}
+
+func attachmentsBatch(c usecase.CommentUseCase, l logger.Interface) dataloader.BatchFunc[int64, []entity.Attachment] {
+	return func(ctx context.Context, ids []int64) []*dataloader.Result[[]entity.Attachment] {
+		attachments, err := c.GetAttachments(ctx, ids)
+		if err != nil {
+			l.WithContext(ctx).Error(err, "graphql - attachmentsBatch")
+		}
+
+		results := make([]*dataloader.Result[[]entity.Attachment], len(ids))
+		for i, id := range ids {
+			results[i] = &dataloader.Result[[]entity.Attachment]{Data: attachments[id], Error: storageError(err)}
+		}
+
+		return results
+	}
+}
+
+func lastSeenBatch(c usecase.CommentUseCase, l logger.Interface, viewer string) dataloader.BatchFunc[int64, int64] {
+	return func(ctx context.Context, rootIDs []int64) []*dataloader.Result[int64] {
+		lastSeen, err := c.GetLastSeen(ctx, viewer, rootIDs)
+		if err != nil {
+			l.WithContext(ctx).Error(err, "graphql - lastSeenBatch")
+		}
+
+		results := make([]*dataloader.Result[int64], len(rootIDs))
+		for i, id := range rootIDs {
+			results[i] = &dataloader.Result[int64]{Data: lastSeen[id], Error: storageError(err)}
+		}
+
+		return results
+	}
+}
@@ -0,0 +1,255 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+const (
+	_defaultPageSize = 20
+	_maxPageSize     = 100
+)
+
+var (
+	errStorage   = errors.New("storage problems")
+	errInvalidID = errors.New("invalid comment id")
+)
+
+// resolver - корень схемы: Query и Mutation.
+type resolver struct {
+	c usecase.CommentUseCase
+	l logger.Interface
+}
+
+type orderInput struct {
+	Field     string
+	Direction string
+}
+
+type pageArgs struct {
+	Limit   int32
+	Offset  int32
+	OrderBy *orderInput
+}
+
+type searchArgs struct {
+	Query   string
+	Limit   int32
+	Offset  int32
+	OrderBy *orderInput
+}
+
+func (r *resolver) Comment(ctx context.Context, args struct{ ID gql.ID }) (*commentResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := loadersFrom(ctx).comments.Load(ctx, id)()
+	if err != nil || comment == nil {
+		return nil, err
+	}
+
+	return &commentResolver{c: *comment}, nil
+}
+
+func (r *resolver) Roots(ctx context.Context, args pageArgs) (*pageResolver, error) {
+	result, err := r.c.GetRootComments(ctx, pageParams(args.OrderBy, args.Limit, args.Offset))
+	if err != nil {
+		r.l.WithContext(ctx).Error(err, "graphql - Roots")
+
+		return nil, errStorage
+	}
+
+	return &pageResolver{result: result}, nil
+}
+
+func (r *resolver) Search(ctx context.Context, args searchArgs) (*pageResolver, error) {
+	if args.Query == "" {
+		return nil, errors.New("empty query")
+	}
+
+	params := pageParams(args.OrderBy, args.Limit, args.Offset)
+	params.Search = args.Query
+
+	result, err := r.c.GetComments(ctx, params)
+	if err != nil {
+		r.l.WithContext(ctx).Error(err, "graphql - Search")
+
+		return nil, errStorage
+	}
+
+	return &pageResolver{result: result}, nil
+}
+
+func (r *resolver) CreateComment(ctx context.Context, args struct {
+	ParentID      *gql.ID
+	Content       string
+	AttachmentIDs *[]gql.ID
+}) (*commentResolver, error) {
+	var parentID *int64
+
+	if args.ParentID != nil {
+		id, err := parseID(*args.ParentID)
+		if err != nil {
+			return nil, err
+		}
+
+		parentID = &id
+	}
+
+	var attachmentIDs []int64
+
+	if args.AttachmentIDs != nil {
+		for _, gid := range *args.AttachmentIDs {
+			id, err := parseID(gid)
+			if err != nil {
+				return nil, errs.ErrInvalidAttachment
+			}
+
+			attachmentIDs = append(attachmentIDs, id)
+		}
+	}
+
+	comment, err := r.c.CreateComment(ctx, parentID, userFrom(ctx), args.Content, attachmentIDs)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return nil, errors.New("parent not found")
+		}
+		if errors.Is(err, errs.ErrLocked) {
+			return nil, errs.ErrLocked
+		}
+		if errors.Is(err, errs.ErrArchived) {
+			return nil, errs.ErrArchived
+		}
+		if errors.Is(err, errs.ErrMaxDepth) {
+			return nil, errs.ErrMaxDepth
+		}
+		if errors.Is(err, errs.ErrInvalidAttachment) {
+			return nil, errs.ErrInvalidAttachment
+		}
+		if errors.Is(err, errs.ErrTooManyAttachments) {
+			return nil, errs.ErrTooManyAttachments
+		}
+		r.l.WithContext(ctx).Error(err, "graphql - CreateComment")
+
+		return nil, errStorage
+	}
+
+	return &commentResolver{c: comment}, nil
+}
+
+func (r *resolver) DeleteComment(ctx context.Context, args struct{ ID gql.ID }) (bool, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.c.DeleteCommentWithChildren(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return false, errors.New("comment not found")
+		}
+		if errors.Is(err, errs.ErrLocked) {
+			return false, errs.ErrLocked
+		}
+		if errors.Is(err, errs.ErrArchived) {
+			return false, errs.ErrArchived
+		}
+		r.l.WithContext(ctx).Error(err, "graphql - DeleteComment")
+
+		return false, errStorage
+	}
+
+	return true, nil
+}
+
+type pageResolver struct {
+	result dto.PaginatedComments
+}
+
+func (p *pageResolver) Nodes() []*commentResolver {
+	return commentResolvers(p.result.Comments)
+}
+
+func (p *pageResolver) TotalCount() int32 {
+	return int32(p.result.Total) //nolint:gosec // число комментов далеко от int32
+}
+
+func (p *pageResolver) Limit() int32 {
+	return int32(p.result.Limit) //nolint:gosec // не больше _maxPageSize
+}
+
+func (p *pageResolver) Offset() int32 {
+	return int32(p.result.Offset) //nolint:gosec // пришло из int32
+}
+
+// pageParams - те же значения по умолчанию, что и у GET /v1/comments.
+func pageParams(order *orderInput, limit, offset int32) dto.GetCommentsParams {
+	params := dto.GetCommentsParams{
+		SortBy: "created_at",
+		Order:  "DESC",
+		Limit:  pageSize(int(limit)),
+		Offset: max(int(offset), 0),
+	}
+
+	if order != nil {
+		if order.Field == "ID" {
+			params.SortBy = "id"
+		}
+
+		if order.Direction == "ASC" {
+			params.Order = "ASC"
+		}
+	}
+
+	return params
+}
+
+// pageSize - вне 1..100 берется размер по умолчанию, как и в REST.
+func pageSize(n int) int {
+	if n <= 0 || n > _maxPageSize {
+		return _defaultPageSize
+	}
+
+	return n
+}
+
+func parseID(id gql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errInvalidID
+	}
+
+	return n, nil
+}
+
+func formatID(id int64) gql.ID {
+	return gql.ID(strconv.FormatInt(id, 10))
+}
+
+// storageError - наружу уходит только факт ошибки хранилища, подробности - в лог.
+func storageError(err error) error {
+	if err != nil {
+		return errStorage
+	}
+
+	return nil
+}
+
+func commentResolvers(comments []entity.Comment) []*commentResolver {
+	resolvers := make([]*commentResolver, len(comments))
+	for i, c := range comments {
+		resolvers[i] = &commentResolver{c: c}
+	}
+
+	return resolvers
+}
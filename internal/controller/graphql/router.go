@@ -0,0 +1,95 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+	gql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var _schema string
+
+// _userHeader - автор комментов из мутаций, как и в REST API.
+const _userHeader = "X-User"
+
+type userKey struct{}
+
+type handler struct {
+	schema *gql.Schema
+	c      usecase.CommentUseCase
+	l      logger.Interface
+
+	maxComplexity int
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func NewRouter(app *fiber.App, cfg *config.Config, c usecase.CommentUseCase, l logger.Interface) {
+	schema := gql.MustParseSchema(_schema, &resolver{c: c, l: l},
+		gql.MaxDepth(cfg.GraphQL.MaxDepth),
+		// элементы одного списка резолвятся параллельно, чтобы loader собрал их в одну пачку
+		gql.MaxParallelism(_maxPageSize),
+	)
+
+	h := &handler{
+		schema:        schema,
+		c:             c,
+		l:             l,
+		maxComplexity: cfg.GraphQL.MaxComplexity,
+	}
+
+	app.Post("/v1/graphql", h.serve)
+}
+
+// serve - ошибки запроса, как и ошибки выполнения, уходят в errors со статусом 200,
+// 400 - только если тело не разобрать.
+func (h *handler) serve(ctx *fiber.Ctx) error {
+	var req request
+
+	err := ctx.BodyParser(&req)
+	if err != nil || req.Query == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(gql.Response{
+			Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("invalid request body")},
+		})
+	}
+
+	cost, ok := complexity(req.Query, req.OperationName, req.Variables)
+	if ok && cost > h.maxComplexity {
+		return ctx.Status(http.StatusOK).JSON(gql.Response{
+			Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("query complexity %d exceeds the limit %d", cost, h.maxComplexity)},
+		})
+	}
+
+	user := ctx.Get(_userHeader)
+	if user != "" && !entity.ValidUsername(user) {
+		return ctx.Status(http.StatusBadRequest).JSON(gql.Response{
+			Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("invalid X-User header")},
+		})
+	}
+
+	uctx := withLoaders(ctx.UserContext(), newLoaders(h.c, h.l, user))
+	uctx = context.WithValue(uctx, userKey{}, user)
+	uctx = caller.WithUser(uctx, user)
+
+	return ctx.Status(http.StatusOK).JSON(h.schema.Exec(uctx, req.Query, req.OperationName, req.Variables))
+}
+
+// userFrom - имя из X-User, "" - анонимный запрос.
+func userFrom(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+
+	return user
+}
@@ -0,0 +1,36 @@
+package grpc
+
+import (
+	"context"
+
+	v1 "github.com/andreyxaxa/Comment-Tree/docs/proto/v1"
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthServer - grpc.health.v1 поверх тех же проверок, что и /readyz.
+// Пустое имя сервиса - состояние всего сервера.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	h *health.Health
+}
+
+func newHealthServer(h *health.Health) *healthServer {
+	return &healthServer{h: h}
+}
+
+func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if req.GetService() != "" && req.GetService() != v1.CommentService_ServiceDesc.ServiceName {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	resp := &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}
+	if !s.h.Readiness(ctx).OK() {
+		resp.Status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	return resp, nil
+}
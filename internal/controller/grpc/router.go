@@ -0,0 +1,24 @@
+package grpc
+
+import (
+	v1 "github.com/andreyxaxa/Comment-Tree/internal/controller/grpc/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	pbgrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func NewRouter(app *pbgrpc.Server, c usecase.CommentUseCase, h *health.Health, l logger.Interface) {
+	// Probes
+	grpc_health_v1.RegisterHealthServer(app, newHealthServer(h))
+
+	// Reflection - для grpcurl и подобных клиентов
+	reflection.Register(app)
+
+	// Routers
+	{
+		v1.NewCommentRoutes(app, c, l)
+	}
+}
@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/andreyxaxa/Comment-Tree/docs/proto/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (r *V1) CreateComment(ctx context.Context, req *pb.CreateCommentRequest) (*pb.CreateCommentResponse, error) {
+	author, ok := callUser(ctx)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid x-user metadata")
+	}
+
+	ctx = caller.WithUser(ctx, author)
+
+	comment, err := r.c.CreateComment(ctx, req.ParentId, author, req.GetContent(), nil)
+	if err != nil {
+		return nil, r.errorStatus(ctx, err, "parent not found", "grpc - v1 - CreateComment")
+	}
+
+	return &pb.CreateCommentResponse{Comment: toComment(comment)}, nil
+}
+
+func (r *V1) GetComment(ctx context.Context, req *pb.GetCommentRequest) (*pb.GetCommentResponse, error) {
+	comments, err := r.subtree(ctx, req.GetId(), "grpc - v1 - GetComment")
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetCommentResponse{
+		Comment:          toComment(comments[0]),
+		DescendantsCount: int32(len(comments) - 1), //nolint:gosec // размер поддерева ограничен памятью
+	}, nil
+}
+
+func (r *V1) ListRoots(ctx context.Context, req *pb.ListRootsRequest) (*pb.ListRootsResponse, error) {
+	sortBy, order, limit, offset := pageParams(req.GetSortBy(), req.GetOrder(), req.GetLimit(), req.GetOffset())
+
+	result, err := r.c.GetRootComments(ctx, dto.GetCommentsParams{
+		SortBy: sortBy,
+		Order:  order,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, r.errorStatus(ctx, err, "", "grpc - v1 - ListRoots")
+	}
+
+	return &pb.ListRootsResponse{
+		Comments: toComments(result.Comments),
+		Total:    int32(result.Total),  //nolint:gosec // число комментов далеко от int32
+		Limit:    int32(result.Limit),  //nolint:gosec // не больше _maxLimit
+		Offset:   int32(result.Offset), //nolint:gosec // пришло из int32
+	}, nil
+}
+
+// GetTree - узлы уходят по одному в порядке path, родитель всегда раньше детей.
+func (r *V1) GetTree(req *pb.GetTreeRequest, stream pb.CommentService_GetTreeServer) error {
+	ctx := stream.Context()
+
+	comments, err := r.subtree(ctx, req.GetId(), "grpc - v1 - GetTree")
+	if err != nil {
+		return err
+	}
+
+	for _, c := range comments {
+		err = stream.Send(toNode(c))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *V1) Search(ctx context.Context, req *pb.SearchRequest) (*pb.SearchResponse, error) {
+	if req.GetQuery() == "" {
+		return nil, status.Error(codes.InvalidArgument, "empty query")
+	}
+
+	sortBy, order, limit, offset := pageParams(req.GetSortBy(), req.GetOrder(), req.GetLimit(), req.GetOffset())
+
+	result, err := r.c.GetComments(ctx, dto.GetCommentsParams{
+		Search: req.GetQuery(),
+		SortBy: sortBy,
+		Order:  order,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, r.errorStatus(ctx, err, "", "grpc - v1 - Search")
+	}
+
+	return &pb.SearchResponse{
+		Comments: toComments(result.Comments),
+		Total:    int32(result.Total),  //nolint:gosec // число комментов далеко от int32
+		Limit:    int32(result.Limit),  //nolint:gosec // не больше _maxLimit
+		Offset:   int32(result.Offset), //nolint:gosec // пришло из int32
+	}, nil
+}
+
+func (r *V1) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid comment id")
+	}
+
+	// удаление анонимно доступно, имя нужно только журналу изменений
+	if user, ok := callUser(ctx); ok {
+		ctx = caller.WithUser(ctx, user)
+	}
+
+	err := r.c.DeleteCommentWithChildren(ctx, req.GetId())
+	if err != nil {
+		return nil, r.errorStatus(ctx, err, "comment not found", "grpc - v1 - Delete")
+	}
+
+	return &pb.DeleteResponse{}, nil
+}
+
+func (r *V1) subtree(ctx context.Context, id int64, op string) ([]entity.Comment, error) {
+	if id <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid comment id")
+	}
+
+	result, err := r.c.GetComments(ctx, dto.GetCommentsParams{ParentID: &id, ChildSort: entity.ChildSortOldest})
+	if err != nil {
+		return nil, r.errorStatus(ctx, err, "comment not found", op)
+	}
+
+	if len(result.Comments) == 0 {
+		return nil, status.Error(codes.NotFound, "comment not found")
+	}
+
+	return result.Comments, nil
+}
+
+// errorStatus - ErrRecordNotFound превращается в NotFound с текстом notFound,
+// отказ из-за блокировки ветки или лимита глубины - в FailedPrecondition,
+// остальное логируется и уходит клиенту как Internal без подробностей.
+func (r *V1) errorStatus(ctx context.Context, err error, notFound, op string) error {
+	if notFound != "" && errors.Is(err, errs.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, notFound)
+	}
+
+	if errors.Is(err, errs.ErrLocked) {
+		return status.Error(codes.FailedPrecondition, errs.ErrLocked.Error())
+	}
+
+	if errors.Is(err, errs.ErrArchived) {
+		return status.Error(codes.FailedPrecondition, errs.ErrArchived.Error())
+	}
+
+	if errors.Is(err, errs.ErrMaxDepth) {
+		return status.Error(codes.FailedPrecondition, errs.ErrMaxDepth.Error())
+	}
+
+	r.l.WithContext(ctx).Error(err, op)
+
+	return status.Error(codes.Internal, "storage problems")
+}
@@ -0,0 +1,162 @@
+package v1_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/andreyxaxa/Comment-Tree/docs/proto/v1"
+	v1 "github.com/andreyxaxa/Comment-Tree/internal/controller/grpc/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newClient(t *testing.T) pb.CommentServiceClient {
+	t.Helper()
+
+	l := logger.New("error")
+	uc := comment.New(memory.New(), webapi.NewCDN("", "", "", "", time.Second, l), l)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	v1.NewCommentRoutes(srv, uc, l)
+
+	go srv.Serve(lis) //nolint:errcheck // завершается вместе с тестом
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewCommentServiceClient(conn)
+}
+
+func TestCommentService(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+
+	root, err := client.CreateComment(ctx, &pb.CreateCommentRequest{Content: "root"})
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	rootID := root.GetComment().GetId()
+
+	reply, err := client.CreateComment(ctx, &pb.CreateCommentRequest{ParentId: &rootID, Content: "reply"})
+	if err != nil {
+		t.Fatalf("CreateComment reply: %v", err)
+	}
+
+	_, err = client.CreateComment(ctx, &pb.CreateCommentRequest{ParentId: &rootID, Content: "second reply"})
+	if err != nil {
+		t.Fatalf("CreateComment second reply: %v", err)
+	}
+
+	replyID := reply.GetComment().GetId()
+
+	_, err = client.CreateComment(ctx, &pb.CreateCommentRequest{ParentId: &replyID, Content: "nested"})
+	if err != nil {
+		t.Fatalf("CreateComment nested: %v", err)
+	}
+
+	got, err := client.GetComment(ctx, &pb.GetCommentRequest{Id: rootID})
+	if err != nil || got.GetComment().GetContent() != "root" || got.GetDescendantsCount() != 3 {
+		t.Fatalf("GetComment = %v, %v; want root with 3 descendants", got, err)
+	}
+
+	// стрим отдает узлы в порядке path: родитель раньше детей
+	stream, err := client.GetTree(ctx, &pb.GetTreeRequest{Id: rootID})
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	seen := map[int64]bool{}
+	var depths []int32
+
+	for {
+		node, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("GetTree Recv: %v", err)
+		}
+
+		c := node.GetComment()
+		if c.ParentId != nil && !seen[c.GetParentId()] {
+			t.Fatalf("node %d came before its parent %d", c.GetId(), c.GetParentId())
+		}
+
+		seen[c.GetId()] = true
+		depths = append(depths, node.GetDepth())
+	}
+
+	if len(depths) != 4 || depths[0] != 0 {
+		t.Fatalf("GetTree depths = %v, want 4 nodes starting from the root", depths)
+	}
+
+	roots, err := client.ListRoots(ctx, &pb.ListRootsRequest{})
+	if err != nil || len(roots.GetComments()) != 1 || roots.GetTotal() != 1 || roots.GetLimit() != 20 {
+		t.Fatalf("ListRoots = %v, %v; want one root with the default limit", roots, err)
+	}
+
+	found, err := client.Search(ctx, &pb.SearchRequest{Query: "nested"})
+	if err != nil || len(found.GetComments()) != 1 {
+		t.Fatalf("Search = %v, %v; want one match", found, err)
+	}
+
+	_, err = client.Delete(ctx, &pb.DeleteRequest{Id: rootID})
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	_, err = client.GetComment(ctx, &pb.GetCommentRequest{Id: rootID})
+	assertCode(t, "GetComment after Delete", err, codes.NotFound)
+}
+
+func TestCommentServiceErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+
+	missing := int64(42)
+
+	_, err := client.CreateComment(ctx, &pb.CreateCommentRequest{ParentId: &missing, Content: "orphan"})
+	assertCode(t, "CreateComment with missing parent", err, codes.NotFound)
+
+	_, err = client.Delete(ctx, &pb.DeleteRequest{Id: missing})
+	assertCode(t, "Delete missing", err, codes.NotFound)
+
+	stream, err := client.GetTree(ctx, &pb.GetTreeRequest{Id: missing})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	assertCode(t, "GetTree missing", err, codes.NotFound)
+
+	_, err = client.GetComment(ctx, &pb.GetCommentRequest{})
+	assertCode(t, "GetComment without id", err, codes.InvalidArgument)
+
+	_, err = client.Search(ctx, &pb.SearchRequest{})
+	assertCode(t, "Search without query", err, codes.InvalidArgument)
+}
+
+func assertCode(t *testing.T, op string, err error, want codes.Code) {
+	t.Helper()
+
+	if got := status.Code(err); got != want {
+		t.Fatalf("%s: code %v (%v), want %v", op, got, err, want)
+	}
+}
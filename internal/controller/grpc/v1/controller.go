@@ -0,0 +1,14 @@
+package v1
+
+import (
+	pb "github.com/andreyxaxa/Comment-Tree/docs/proto/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+)
+
+type V1 struct {
+	pb.UnimplementedCommentServiceServer
+
+	c usecase.CommentUseCase
+	l logger.Interface
+}
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"context"
+
+	pb "github.com/andreyxaxa/Comment-Tree/docs/proto/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	_defaultLimit = 20
+	_maxLimit     = 100
+
+	// _userMetadata - имя пользователя, от которого идет вызов, как заголовок X-User в REST API.
+	_userMetadata = "x-user"
+)
+
+// pageParams - те же значения по умолчанию, что и у GET /v1/comments.
+func pageParams(sortBy pb.SortBy, order pb.Order, limit, offset int32) (string, string, int, int) {
+	sort := "created_at"
+	if sortBy == pb.SortBy_SORT_BY_ID {
+		sort = "id"
+	}
+
+	ord := "DESC"
+	if order == pb.Order_ORDER_ASC {
+		ord = "ASC"
+	}
+
+	if limit <= 0 || limit > _maxLimit {
+		limit = _defaultLimit
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	return sort, ord, int(limit), int(offset)
+}
+
+// callUser - имя из метаданных x-user, "" - анонимный вызов; ok=false - имя недопустимо.
+func callUser(ctx context.Context) (string, bool) {
+	values := metadata.ValueFromIncomingContext(ctx, _userMetadata)
+	if len(values) == 0 || values[0] == "" {
+		return "", true
+	}
+
+	return values[0], entity.ValidUsername(values[0])
+}
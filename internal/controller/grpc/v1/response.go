@@ -0,0 +1,41 @@
+package v1
+
+import (
+	pb "github.com/andreyxaxa/Comment-Tree/docs/proto/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toComment(c entity.Comment) *pb.Comment {
+	resp := &pb.Comment{
+		Id:        c.ID,
+		Content:   c.Content,
+		Author:    c.Author,
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		Version:   c.Version,
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+
+	if c.ParentID.Valid {
+		resp.ParentId = &c.ParentID.Int64
+	}
+
+	return resp
+}
+
+func toComments(comments []entity.Comment) []*pb.Comment {
+	resp := make([]*pb.Comment, 0, len(comments))
+	for _, c := range comments {
+		resp = append(resp, toComment(c))
+	}
+
+	return resp
+}
+
+func toNode(c entity.Comment) *pb.CommentNode {
+	return &pb.CommentNode{
+		Comment: toComment(c),
+		Depth:   int32(c.Depth), //nolint:gosec // глубина дерева далека от int32
+		Path:    c.Path,
+	}
+}
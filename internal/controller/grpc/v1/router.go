@@ -0,0 +1,16 @@
+package v1
+
+import (
+	pb "github.com/andreyxaxa/Comment-Tree/docs/proto/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	pbgrpc "google.golang.org/grpc"
+)
+
+func NewCommentRoutes(app *pbgrpc.Server, c usecase.CommentUseCase, l logger.Interface) {
+	r := &V1{c: c, l: l}
+
+	{
+		pb.RegisterCommentServiceServer(app, r)
+	}
+}
@@ -0,0 +1,22 @@
+package restapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+	"github.com/gofiber/fiber/v2"
+)
+
+func probe(check func(ctx context.Context) health.Report) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		report := check(ctx.UserContext())
+
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+
+		return ctx.Status(status).JSON(report)
+	}
+}
@@ -5,24 +5,38 @@ import (
 	_ "github.com/andreyxaxa/Comment-Tree/docs" // Swagger docs.
 	v1 "github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1"
 	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
 	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/swagger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // @title Comment tree
 // @version 1.0.0
 // @host localhost:8080
 // @BasePath /v1
-func NewRouter(app *fiber.App, cfg *config.Config, c usecase.CommentUseCase, l logger.Interface) {
+func NewRouter(app *fiber.App, cfg *config.Config, c usecase.CommentUseCase, g prometheus.Gatherer, h *health.Health, l logger.Interface) {
+	// Probes
+	app.Get("/healthz", probe(h.Liveness))
+	app.Get("/readyz", probe(h.Readiness))
+	app.Get("/startupz", probe(h.Startup))
+
 	// Swagger
 	if cfg.Swagger.Enabled {
 		app.Get("/swagger/*", swagger.HandlerDefault)
 	}
 
+	// Metrics
+	if cfg.Metrics.Enabled {
+		app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(g, promhttp.HandlerOpts{})))
+	}
+
 	// Routers
 	apiV1Group := app.Group("/v1")
 	{
-		v1.NewCommentRoutes(apiV1Group, c, l)
+		v1.NewCommentRoutes(apiV1Group, c, l, cfg.HTTP.CacheControl, cfg.Admin.Token)
 	}
 }
@@ -0,0 +1,184 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/utils"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// _fileField - файл в POST /v1/attachments, _filesField - файлы в multipart POST /v1/comments.
+	_fileField  = "file"
+	_filesField = "files"
+)
+
+// @Summary Upload attachment
+// @Description Uploads a file to attach to a comment later via attachment_ids. The type is detected from the content and must be allowed; images get width, height and a thumbnail. Uploads not attached in time are deleted
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "File"
+// @Param X-User header string true "Uploader; only they can attach the file, anonymous uploads are rejected (422)"
+// @Success 201 {object} response.AttachmentResponse
+// @Failure 400 {object} response.Error
+// @Failure 413 {object} response.Error
+// @Failure 415 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Failure 501 {object} response.Error
+// @Router /v1/attachments [post]
+func (r *V1) uploadAttachment(ctx *fiber.Ctx) error {
+	uploader, ok := optionalUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid X-User header")
+	}
+
+	fh, err := ctx.FormFile(_fileField)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "file is required")
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid file")
+	}
+	defer f.Close()
+
+	a, err := r.c.UploadAttachment(ctx.UserContext(), uploader, fh.Filename, f)
+	if err != nil {
+		if handled, resp := attachmentError(ctx, err); handled {
+			return resp
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - uploadAttachment")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.BuildAttachment(a))
+}
+
+// @Summary Get attachment
+// @Description Serves an attachment file by a signed link from url of the attachment
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Link expiry (Unix time)"
+// @Param sig query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Failure 501 {object} response.Error
+// @Router /v1/attachments/{id} [get]
+func (r *V1) getAttachment(ctx *fiber.Ctx) error {
+	return r.serveAttachment(ctx, entity.AttachmentOriginal)
+}
+
+// @Summary Get attachment thumbnail
+// @Description Serves an image thumbnail by a signed link from thumbnail_url of the attachment
+// @Tags attachments
+// @Produce png
+// @Produce jpeg
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Link expiry (Unix time)"
+// @Param sig query string true "Link signature"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Failure 501 {object} response.Error
+// @Router /v1/attachments/{id}/thumbnail [get]
+func (r *V1) getAttachmentThumbnail(ctx *fiber.Ctx) error {
+	return r.serveAttachment(ctx, entity.AttachmentThumbnail)
+}
+
+// serveAttachment - файл пользователя не должен исполняться в контексте сервиса:
+// тип не угадывается браузером, скрипты запрещены, не-картинки скачиваются.
+func (r *V1) serveAttachment(ctx *fiber.Ctx, variant string) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid attachment id")
+	}
+
+	var req request.GetAttachmentRequest
+
+	err = ctx.QueryParser(&req)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid query parameters")
+	}
+
+	a, rc, err := r.c.OpenAttachment(ctx.UserContext(), int64(id), variant, req.Expires, req.Sig)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrInvalidToken):
+			return errorResponse(ctx, http.StatusForbidden, "invalid or expired link")
+		case errors.Is(err, errs.ErrRecordNotFound):
+			return errorResponse(ctx, http.StatusNotFound, "attachment not found")
+		case errors.Is(err, errs.ErrAttachmentsDisabled):
+			return errorResponse(ctx, http.StatusNotImplemented, errs.ErrAttachmentsDisabled.Error())
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - serveAttachment")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	contentType := a.ContentType
+	if strings.HasPrefix(contentType, "text/") {
+		contentType += "; charset=utf-8"
+	}
+
+	disposition := "attachment"
+	if strings.HasPrefix(a.ContentType, "image/") {
+		disposition = "inline"
+	}
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	ctx.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	ctx.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; img-src 'self'; sandbox")
+	// файл по ключу не меняется, ссылка же действует до expires
+	ctx.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.FormatInt(max(req.Expires-time.Now().Unix(), 0), 10))
+
+	size := -1
+	if a.Size > 0 {
+		size = int(a.Size)
+	}
+
+	// fasthttp закрывает rc после отправки
+	return ctx.SendStream(rc, size)
+}
+
+// uploadFiles - файлы multipart-запроса на создание коммента, возвращает id вложений.
+func (r *V1) uploadFiles(ctx *fiber.Ctx, uploader string, files []*multipart.FileHeader) ([]int64, error) {
+	ids := make([]int64, 0, len(files))
+
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			return nil, fmt.Errorf("fh.Open: %w", err)
+		}
+
+		a, err := r.c.UploadAttachment(ctx.UserContext(), uploader, fh.Filename, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("r.c.UploadAttachment: %w", err)
+		}
+
+		ids = append(ids, a.ID)
+	}
+
+	return ids, nil
+}
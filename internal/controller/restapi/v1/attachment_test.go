@@ -0,0 +1,31 @@
+package v1_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAnonymousAttachments(t *testing.T) {
+	app, uc := newApp(t, memory.New())
+
+	// анонимы неотличимы друг от друга, поэтому загрузить файл аноним не может
+	if _, err := uc.UploadAttachment(context.Background(), "", "dot.txt", strings.NewReader("dot")); !errors.Is(err, errs.ErrInvalidAttachment) {
+		t.Fatalf("UploadAttachment(anonymous) = %v, want ErrInvalidAttachment", err)
+	}
+
+	// и прикрепить к анонимному комменту чужую загрузку тоже
+	a := upload(t, uc, "alice")
+
+	decode(t, do(t, app, fiber.MethodPost, "/v1/comments", "", map[string]any{
+		"content": "anonymous", "attachment_ids": []int64{a.ID},
+	}), http.StatusUnprocessableEntity, nil)
+
+	create(t, app, nil, "alice", "with attachment", a.ID)
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Get audit log
+// @Description Changes of comments from newest to oldest: who made them (X-User of the request), from which address, with which request id, and snapshots before and after. Erasing an author removes their name, address and texts from the log
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param actor query string false "Only changes made by this user"
+// @Param comment_id query int false "Only changes of this comment (for delete - root of the deleted subtree)"
+// @Param action query string false "Only this action" Enums(create, delete, import, pin, unpin, highlight, unhighlight, lock, unlock, archive, max_depth, erase)
+// @Param from query string false "Changes at or after this time, RFC 3339"
+// @Param to query string false "Changes before this time, RFC 3339"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query string false "Page size, default 20, max 100"
+// @Success 200 {object} response.AuditLogResponse
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/admin/audit [get]
+func (r *V1) getAuditLog(ctx *fiber.Ctx) error {
+	var req request.GetAuditLogRequest
+
+	err := ctx.QueryParser(&req)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid query parameters")
+	}
+
+	err = req.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	var beforeID int64
+	if req.Cursor != "" {
+		beforeID, err = decodeBeforeCursor(req.Cursor)
+		if err != nil {
+			return errorResponse(ctx, http.StatusBadRequest, err.Error())
+		}
+	}
+
+	// лишняя запись показывает, есть ли следующая страница
+	entries, err := r.c.GetAuditLog(ctx.UserContext(), req.Filter(), beforeID, req.Limit+1)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - getAuditLog")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	resp := response.AuditLogResponse{Entries: make([]response.AuditEntryResponse, 0, len(entries))}
+
+	if len(entries) > req.Limit {
+		entries = entries[:req.Limit]
+		resp.NextCursor = encodeBeforeCursor(entries[len(entries)-1].ID)
+	}
+
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, response.AuditEntryResponse{
+			ID:        e.ID,
+			Action:    e.Action,
+			CommentID: utils.NullInt64ToPtr(e.CommentID),
+			Actor:     e.Actor,
+			IP:        e.IP,
+			RequestID: e.RequestID,
+			Before:    e.Before,
+			After:     e.After,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(resp)
+}
@@ -0,0 +1,258 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	v1 "github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/persistent"
+	"github.com/andreyxaxa/Comment-Tree/migrations"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/migrator"
+	"github.com/andreyxaxa/Comment-Tree/pkg/sqlite"
+	"github.com/gofiber/fiber/v2"
+)
+
+type auditEntry struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	CommentID *int64          `json:"comment_id"`
+	Actor     string          `json:"actor"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+}
+
+type auditPage struct {
+	Entries    []auditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor"`
+}
+
+// newSQLiteApp - как newApp, но журнал пишет SQLite: в своих транзакциях, как в проде.
+func newSQLiteApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "comments.db")
+
+	mg, err := migrator.NewSQLite(path, migrations.SQLite())
+	if err != nil {
+		t.Fatalf("migrator.NewSQLite: %v", err)
+	}
+
+	err = mg.Up()
+	mg.Close()
+	if err != nil {
+		t.Fatalf("mg.Up: %v", err)
+	}
+
+	sl, err := sqlite.New(path)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(sl.Close)
+
+	app, _ := newApp(t, persistent.NewSQLite(sl))
+
+	return app
+}
+
+func auditLog(t *testing.T, app *fiber.App, query url.Values) auditPage {
+	t.Helper()
+
+	var page auditPage
+	decode(t, do(t, app, fiber.MethodGet, "/v1/admin/audit?"+query.Encode(), "", nil), http.StatusOK, &page)
+
+	return page
+}
+
+func entryIDs(entries []auditEntry) []int64 {
+	ids := make([]int64, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+
+	return ids
+}
+
+func TestAuditAuth(t *testing.T) {
+	app, _ := newApp(t, memory.New())
+
+	for _, tt := range []struct {
+		name, header string
+		status       int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"not bearer", _adminToken, http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/v1/admin/audit", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatalf("GET /v1/admin/audit: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+
+	// без ADMIN_TOKEN админских маршрутов нет совсем
+	bare := fiber.New()
+	v1.NewCommentRoutes(bare.Group("/v1"), nil, logger.New("error"), "", "")
+
+	if resp := do(t, bare, fiber.MethodGet, "/v1/admin/audit", "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("without ADMIN_TOKEN status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestAuditFilters(t *testing.T) {
+	app := newSQLiteApp(t)
+
+	root := create(t, app, nil, "alice", "root")
+	reply := create(t, app, &root, "bob", "reply")
+	decode(t, do(t, app, fiber.MethodPut, "/v1/comments/"+strconv.FormatInt(reply, 10)+"/pin", "mod", nil), http.StatusNoContent, nil)
+
+	all := auditLog(t, app, nil).Entries
+	if len(all) != 3 || all[0].Action != entity.AuditPin || all[1].Action != entity.AuditCreate || all[2].Action != entity.AuditCreate {
+		t.Fatalf("audit log = %+v; want pin and two creates, newest first", all)
+	}
+
+	hourAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	inHour := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []int64
+	}{
+		{"actor", url.Values{"actor": {"bob"}}, entryIDs(all[1:2])},
+		{"comment", url.Values{"comment_id": {strconv.FormatInt(reply, 10)}}, entryIDs(all[:2])},
+		{"action", url.Values{"action": {entity.AuditCreate}}, entryIDs(all[1:])},
+		{"range", url.Values{"from": {hourAgo}, "to": {inHour}}, entryIDs(all)},
+		{"past", url.Values{"to": {hourAgo}}, []int64{}},
+		{"future", url.Values{"from": {inHour}}, []int64{}},
+		{"combined", url.Values{"actor": {"alice"}, "action": {entity.AuditPin}}, []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entryIDs(auditLog(t, app, tt.query).Entries); !slices.Equal(got, tt.want) {
+				t.Fatalf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, query := range []string{"action=edit", "comment_id=-1", "from=yesterday", "from=" + inHour + "&to=" + hourAgo, "cursor=bogus"} {
+		decode(t, do(t, app, fiber.MethodGet, "/v1/admin/audit?"+query, "", nil), http.StatusBadRequest, nil)
+	}
+}
+
+func TestAuditPagination(t *testing.T) {
+	app := newSQLiteApp(t)
+
+	for i := range 5 {
+		create(t, app, nil, "alice", "root "+strconv.Itoa(i))
+	}
+
+	all := entryIDs(auditLog(t, app, nil).Entries)
+	if len(all) != 5 {
+		t.Fatalf("audit log = %v, want 5 entries", all)
+	}
+
+	var (
+		got    []int64
+		cursor string
+		pages  int
+	)
+
+	for {
+		query := url.Values{"limit": {"2"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+
+		page := auditLog(t, app, query)
+		got = append(got, entryIDs(page.Entries)...)
+		pages++
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		cursor = page.NextCursor
+	}
+
+	if pages != 3 || !slices.Equal(got, all) {
+		t.Fatalf("paged %v in %d pages, want %v in 3", got, pages, all)
+	}
+}
+
+func TestAuditCreateDelete(t *testing.T) {
+	app := newSQLiteApp(t)
+
+	entries := func() []auditEntry {
+		t.Helper()
+
+		return auditLog(t, app, nil).Entries
+	}
+
+	// создание - ровно одна запись со снимком нового коммента
+	root := create(t, app, nil, "alice", "root")
+
+	log := entries()
+	if len(log) != 1 || log[0].Action != entity.AuditCreate || log[0].CommentID == nil || *log[0].CommentID != root || log[0].Actor != "alice" {
+		t.Fatalf("after create = %+v; want one create of %d by alice", log, root)
+	}
+
+	var after struct {
+		ID      int64  `json:"id"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(log[0].After, &after); err != nil || after.ID != root || after.Content != "root" {
+		t.Fatalf("create snapshot = %s, %v", log[0].After, err)
+	}
+
+	reply := create(t, app, &root, "bob", "reply")
+
+	// отклоненные изменения записей не оставляют
+	decode(t, do(t, app, fiber.MethodDelete, "/v1/comments/"+strconv.FormatInt(reply+100, 10), "mod", nil), http.StatusNotFound, nil)
+	decode(t, do(t, app, fiber.MethodPut, "/v1/comments/"+strconv.FormatInt(root, 10)+"/lock", "mod",
+		map[string]string{"reason": "offtopic"}), http.StatusNoContent, nil)
+	decode(t, do(t, app, fiber.MethodPost, "/v1/comments", "bob", map[string]any{"parent_id": reply, "content": "late"}), http.StatusLocked, nil)
+	decode(t, do(t, app, fiber.MethodDelete, "/v1/comments/"+strconv.FormatInt(root, 10)+"/lock", "mod", nil), http.StatusNoContent, nil)
+
+	before := len(entries())
+	if before != 4 {
+		t.Fatalf("entries before delete = %d, want 2 creates, lock and unlock", before)
+	}
+
+	// удаление поддерева - одна запись на корень со снимком всего поддерева
+	decode(t, do(t, app, fiber.MethodDelete, "/v1/comments/"+strconv.FormatInt(root, 10), "mod", nil), http.StatusNoContent, nil)
+
+	log = entries()
+	if len(log) != before+1 || log[0].Action != entity.AuditDelete || log[0].CommentID == nil || *log[0].CommentID != root || log[0].Actor != "mod" {
+		t.Fatalf("after delete = %+v; want one delete of %d by mod", log[:1], root)
+	}
+
+	var subtree []struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(log[0].Before, &subtree); err != nil || len(subtree) != 2 {
+		t.Fatalf("delete snapshot = %s, %v; want root and reply", log[0].Before, err)
+	}
+}
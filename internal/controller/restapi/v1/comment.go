@@ -3,26 +3,37 @@ package v1
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
 	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
 	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/utils"
 	"github.com/andreyxaxa/Comment-Tree/internal/dto"
 	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
 	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // @Summary Create new comment
-// @Description Creates new comment
+// @Description Creates new comment on behalf of X-User (anonymous without it). Content is Markdown (emphasis, links, code, quotes, lists), content_html is its sanitized rendering. A reply deeper than the thread max depth is either rejected (422) or attached to the ancestor on the last allowed level with reply_to_id set to the comment replied to, depending on the depth policy. Attachments are either uploaded beforehand and passed in attachment_ids, or sent as files of a multipart/form-data request with the same fields; anonymous comments cannot have attachments (422)
 // @Tags comments
 // @Accept json
+// @Accept mpfd
 // @Produce json
 // @Param request body request.CreateCommentRequest true "Comment"
+// @Param X-User header string false "Author of the comment; the parent's author and @mentioned users are notified"
 // @Success 201 {object} response.CreateCommentResponse
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 413 {object} response.Error
+// @Failure 415 {object} response.Error
+// @Failure 422 {object} response.Error
+// @Failure 423 {object} response.Error
 // @Failure 500 {object} response.Error
+// @Failure 501 {object} response.Error
 // @Router /v1/comments [post]
 func (r *V1) create(ctx *fiber.Ctx) error {
 	var body request.CreateCommentRequest
@@ -32,21 +43,59 @@ func (r *V1) create(ctx *fiber.Ctx) error {
 		return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
 	}
 
-	comment, err := r.c.CreateComment(ctx.UserContext(), body.ParentID, body.Content)
+	author, ok := optionalUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid X-User header")
+	}
+
+	// файлы multipart-запроса загружаются до коммента, неприкрепленные потом удалит сборщик
+	if strings.HasPrefix(string(ctx.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		form, err := ctx.MultipartForm()
+		if err != nil {
+			return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
+		}
+
+		ids, err := r.uploadFiles(ctx, author, form.File[_filesField])
+		if err != nil {
+			if handled, resp := attachmentError(ctx, err); handled {
+				return resp
+			}
+			r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - create - r.uploadFiles")
+
+			return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+		}
+
+		body.AttachmentIDs = append(body.AttachmentIDs, ids...)
+	}
+
+	comment, err := r.c.CreateComment(ctx.UserContext(), body.ParentID, author, body.Content, body.AttachmentIDs)
 	if err != nil {
 		if errors.Is(err, errs.ErrRecordNotFound) {
 			return errorResponse(ctx, http.StatusNotFound, "parent not found")
 		}
-		r.l.Error(err, "restapi - v1 - create")
+		if locked, resp := lockedError(ctx, err); locked {
+			return resp
+		}
+		if handled, resp := attachmentError(ctx, err); handled {
+			return resp
+		}
+		if errors.Is(err, errs.ErrMaxDepth) {
+			return errorResponse(ctx, http.StatusUnprocessableEntity, "max depth exceeded")
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - create")
 
 		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
 	}
 
 	resp := response.CreateCommentResponse{
-		ID:        comment.ID,
-		ParentID:  utils.NullInt64ToPtr(comment.ParentID),
-		Content:   comment.Content,
-		CreatedAt: comment.CreatedAt,
+		ID:          comment.ID,
+		ParentID:    utils.NullInt64ToPtr(comment.ParentID),
+		ReplyToID:   utils.NullInt64ToPtr(comment.ReplyToID),
+		Content:     comment.Content,
+		ContentHTML: markdown.HTML(comment.Content),
+		Author:      comment.Author,
+		CreatedAt:   comment.CreatedAt,
+		Attachments: utils.BuildAttachments(comment.Attachments),
 	}
 
 	return ctx.Status(http.StatusCreated).JSON(resp)
@@ -60,10 +109,19 @@ func (r *V1) create(ctx *fiber.Ctx) error {
 // @Param search query string false "Search text"
 // @Param sort_by query string false "Sort option" Enums(created_at, id)
 // @Param order query string false "Sort order" Enums(asc, ASC, desc, DESC)
+// @Param child_sort query string false "Order of replies at every depth, default oldest; score - most replies first" Enums(oldest, newest, score)
 // @Param limit query string false "Limit of comments on one page, default 20"
 // @Param offset query string false "Offset for displaying a specific page, default 0"
+// @Param X-User header string false "Viewer: comments new since the viewer's last POST /v1/comments/{id}/seen get is_new, roots get new_count (not for search)"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Param If-Modified-Since header string false "Last-Modified from a previous response (parent_id only)"
 // @Success 200 {object} response.PaginatedCommentsResponse
+// @Header 200 {string} ETag "Strong validator of the response"
+// @Header 200 {string} Last-Modified "Last change in the subtree (parent_id only, not with X-User)"
+// @Header 200 {string} Surrogate-Key "CDN purge keys of the roots in the response (not with X-User)"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/comments [get]
 func (r *V1) getComments(ctx *fiber.Ctx) error {
@@ -76,26 +134,58 @@ func (r *V1) getComments(ctx *fiber.Ctx) error {
 
 	req.Validate()
 
-	result, err := r.c.GetComments(ctx.UserContext(), dto.GetCommentsParams{
-		ParentID: req.ParentID,
-		Search:   req.Search,
-		SortBy:   req.SortBy,
-		Order:    req.Order,
-		Limit:    req.Limit,
-		Offset:   req.Offset,
-	})
+	viewer, ok := optionalUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid X-User header")
+	}
+
+	// поиск непрочитанное не отмечает, и его ответ для всех одинаков
+	if req.Search != "" {
+		viewer = ""
+	}
+
+	params := dto.GetCommentsParams{
+		ParentID:  req.ParentID,
+		Search:    req.Search,
+		SortBy:    req.SortBy,
+		Order:     req.Order,
+		ChildSort: req.ChildSort,
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+		Viewer:    viewer,
+	}
+
+	result, err := r.c.GetComments(ctx.UserContext(), params)
 	if err != nil {
-		r.l.Error(err, "restapi - v1 - getComments")
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errorResponse(ctx, http.StatusNotFound, "comment not found")
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - getComments")
 
 		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
 	}
 
+	// условный запрос - дерево не строим и тело не отправляем
+	etag, lastModified, keys := cacheValidators(params, result)
+	r.setCacheHeaders(ctx, viewer, etag, lastModified, keys)
+
+	if notModified(ctx, etag, lastModified) {
+		return ctx.SendStatus(http.StatusNotModified)
+	}
+
+	_, span := trace.SpanFromContext(ctx.UserContext()).TracerProvider().
+		Tracer(_tracerName).
+		Start(ctx.UserContext(), "BuildTree", trace.WithAttributes(attribute.Int("comments.count", len(result.Comments))))
+
 	var trees []*response.CommentTreeResponse
 
-	// собираем все комменты для каждого корня
+	// собираем все комменты для каждого корня,
+	// корни - в том порядке, в котором их вернул use-case
+	var rootIDs []int64
 	rootMap := make(map[int64][]entity.Comment)
 	for _, c := range result.Comments {
 		if c.Depth == 0 {
+			rootIDs = append(rootIDs, c.ID)
 			rootMap[c.ID] = []entity.Comment{c}
 		} else {
 			if len(c.Path) > 0 {
@@ -107,13 +197,18 @@ func (r *V1) getComments(ctx *fiber.Ctx) error {
 
 	// проходимся по всем корням
 	// строим дерево для каждого корня
-	for _, comments := range rootMap {
-		tree := utils.BuildTree(comments)
+	for _, rootID := range rootIDs {
+		tree := utils.BuildTree(rootMap[rootID], params.ChildSort)
 		if tree != nil {
+			if viewer != "" && tree.ParentID == nil {
+				tree.NewCount = &rootMap[rootID][0].NewCount
+			}
 			trees = append(trees, tree)
 		}
 	}
 
+	span.End()
+
 	// считаем страницы
 	pages := result.Total / result.Limit
 	if result.Total%result.Limit != 0 {
@@ -142,6 +237,7 @@ func (r *V1) getComments(ctx *fiber.Ctx) error {
 // @Success 204
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/comments/{id} [delete]
 func (r *V1) deleteCommentTree(ctx *fiber.Ctx) error {
@@ -155,7 +251,10 @@ func (r *V1) deleteCommentTree(ctx *fiber.Ctx) error {
 		if errors.Is(err, errs.ErrRecordNotFound) {
 			return errorResponse(ctx, http.StatusNotFound, "comment not found")
 		}
-		r.l.Error(err, "restapi - v1 - deleteCommentTree")
+		if locked, resp := lockedError(ctx, err); locked {
+			return resp
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - deleteCommentTree")
 
 		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
 	}
@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// cacheValidators - ETag, Last-Modified и Surrogate-Key ответа GET /v1/comments.
+//
+// Версия коммента растет при любом изменении в его поддереве, поэтому
+// ответ однозначно определяется параметрами запроса, total и версиями корней ответа
+// (у поиска корни - все найденные комменты). Блокировка предков поддерева его версию
+// не меняет, поэтому действующая блокировка корня ответа тоже входит в ETag. Last-Modified есть только у дерева
+// одного коммента: у списков удаление корня не сдвигает время вперед.
+//
+// Ответ зрителю зависит еще и от его визитов, которые версии не меняют: в ETag входят
+// зритель и id всех непрочитанных комментов, а Last-Modified такого ответа нет.
+//
+// Подписанные ссылки на вложения меняются со сменой окна их срока, а версии - нет:
+// ссылки входят в ETag, и Last-Modified у ответа с ними тоже нет.
+func cacheValidators(params dto.GetCommentsParams, result dto.PaginatedComments) (string, time.Time, []string) {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%v|%s|%s|%s|%s|%d|%d|%d|%s",
+		params.ParentID != nil, params.Search, params.SortBy, params.Order, params.ChildSort, result.Limit, result.Offset, result.Total, params.Viewer)
+
+	var (
+		lastModified time.Time
+		keys         []string
+	)
+
+	switch {
+	case params.Search != "":
+		keys = append(keys, entity.SearchSurrogateKey)
+	case params.ParentID != nil:
+		keys = append(keys, entity.CommentSurrogateKey(*params.ParentID))
+	default:
+		keys = append(keys, entity.RootsSurrogateKey)
+	}
+
+	signed := false
+
+	for _, c := range result.Comments {
+		if c.IsNew {
+			fmt.Fprintf(h, "|new:%d", c.ID)
+		}
+
+		for _, a := range c.Attachments {
+			fmt.Fprintf(h, "|att:%s", a.URL)
+			signed = true
+		}
+
+		if c.Depth != 0 {
+			continue
+		}
+
+		fmt.Fprintf(h, "|%d:%d:%s", c.ID, c.Version, c.LockState)
+
+		if params.ParentID != nil && params.Viewer == "" {
+			lastModified = c.UpdatedAt
+		} else if params.Search == "" {
+			keys = append(keys, entity.CommentSurrogateKey(c.ID))
+		}
+	}
+
+	if signed {
+		lastModified = time.Time{}
+	}
+
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+
+	return etag, lastModified, keys
+}
+
+// setCacheHeaders - заголовки ставятся и на 200, и на 304.
+// Ответ зрителю общим кэшам (CDN) хранить нельзя, браузер же пусть сверяет его по ETag.
+func (r *V1) setCacheHeaders(ctx *fiber.Ctx, viewer, etag string, lastModified time.Time, keys []string) {
+	ctx.Set(fiber.HeaderETag, etag)
+	ctx.Set(fiber.HeaderVary, _userHeader)
+
+	if viewer != "" {
+		ctx.Set(fiber.HeaderCacheControl, "private, no-cache")
+	} else {
+		ctx.Set(fiber.HeaderCacheControl, r.cacheControl)
+		ctx.Set("Surrogate-Key", strings.Join(keys, " "))
+	}
+
+	if !lastModified.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified - If-None-Match важнее If-Modified-Since (RFC 9110, 13.2.2).
+// fiber.Ctx.Fresh не подходит: при одном If-Modified-Since он не сравнивает даты.
+func notModified(ctx *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if inm := ctx.Get(fiber.HeaderIfNoneMatch); inm != "" {
+		return etagMatches(inm, etag)
+	}
+
+	ims := ctx.Get(fiber.HeaderIfModifiedSince)
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	// в заголовке точность до секунды
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches - слабое сравнение, как требует If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,7 +5,12 @@ import (
 	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
 )
 
+const _tracerName = "github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1"
+
 type V1 struct {
 	c usecase.CommentUseCase
 	l logger.Interface
+
+	// cacheControl - значение Cache-Control для ответов на чтение
+	cacheControl string
 }
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Set thread max depth
+// @Description Sets max reply depth for the thread of a root comment, 0 - unlimited. Existing deeper replies are kept
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Root comment ID"
+// @Param request body request.SetMaxDepthRequest true "Max depth"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/max-depth [put]
+func (r *V1) setMaxDepth(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	var body request.SetMaxDepthRequest
+
+	err = ctx.BodyParser(&body)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
+	}
+
+	if body.MaxDepth == nil || *body.MaxDepth < 0 {
+		return errorResponse(ctx, http.StatusBadRequest, "max_depth must be a non-negative number")
+	}
+
+	err = r.c.SetMaxDepth(ctx.UserContext(), int64(id), body.MaxDepth)
+	if err != nil {
+		return r.maxDepthError(ctx, err, "restapi - v1 - setMaxDepth")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Reset thread max depth
+// @Description Returns the thread of a root comment to the global max depth
+// @Tags comments
+// @Produce json
+// @Param id path int true "Root comment ID"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/max-depth [delete]
+func (r *V1) resetMaxDepth(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	err = r.c.SetMaxDepth(ctx.UserContext(), int64(id), nil)
+	if err != nil {
+		return r.maxDepthError(ctx, err, "restapi - v1 - resetMaxDepth")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *V1) maxDepthError(ctx *fiber.Ctx, err error, op string) error {
+	if errors.Is(err, errs.ErrNotRoot) {
+		return errorResponse(ctx, http.StatusBadRequest, "max depth is set on root comments only")
+	}
+
+	return r.flagError(ctx, err, op)
+}
@@ -1,10 +1,44 @@
 package v1
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
 	"github.com/gofiber/fiber/v2"
 )
 
 func errorResponse(ctx *fiber.Ctx, code int, msg string) error {
 	return ctx.Status(code).JSON(response.Error{Error: msg})
 }
+
+// lockedError - отказ из-за блокировки ветки отдается как 423 с ее видом.
+func lockedError(ctx *fiber.Ctx, err error) (bool, error) {
+	switch {
+	case errors.Is(err, errs.ErrLocked):
+		return true, errorResponse(ctx, http.StatusLocked, errs.ErrLocked.Error())
+	case errors.Is(err, errs.ErrArchived):
+		return true, errorResponse(ctx, http.StatusLocked, errs.ErrArchived.Error())
+	default:
+		return false, nil
+	}
+}
+
+// attachmentError - отказ из-за вложений: выключены, слишком большие, не того типа или не подходят к комменту.
+func attachmentError(ctx *fiber.Ctx, err error) (bool, error) {
+	switch {
+	case errors.Is(err, errs.ErrAttachmentsDisabled):
+		return true, errorResponse(ctx, http.StatusNotImplemented, errs.ErrAttachmentsDisabled.Error())
+	case errors.Is(err, errs.ErrFileTooLarge):
+		return true, errorResponse(ctx, http.StatusRequestEntityTooLarge, errs.ErrFileTooLarge.Error())
+	case errors.Is(err, errs.ErrUnsupportedType):
+		return true, errorResponse(ctx, http.StatusUnsupportedMediaType, errs.ErrUnsupportedType.Error())
+	case errors.Is(err, errs.ErrInvalidAttachment):
+		return true, errorResponse(ctx, http.StatusUnprocessableEntity, errs.ErrInvalidAttachment.Error())
+	case errors.Is(err, errs.ErrTooManyAttachments):
+		return true, errorResponse(ctx, http.StatusUnprocessableEntity, errs.ErrTooManyAttachments.Error())
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,200 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/utils"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// _exportFlushEvery - сколько строк копится в буфере перед отправкой клиенту
+	_exportFlushEvery = 500
+	// _exportWriteTimeout - сколько ждем, пока клиент примет очередную пачку.
+	// Общий WriteTimeout сервера на потоковый ответ не годится: выгрузка идет дольше.
+	_exportWriteTimeout = 30 * time.Second
+	// _exportTimeout - предел всей выгрузки. Пока она идет, в Postgres занято соединение пула
+	// с открытой транзакцией, и медленный клиент не должен держать его дольше.
+	_exportTimeout = 10 * time.Minute
+)
+
+var _csvHeader = []string{"id", "parent_id", "content", "created_at", "author"}
+
+// @Summary Export comments
+// @Description Streams comments (the whole database or a single thread) with parents always before children.
+// @Description The top comment of an exported thread has no parent_id, so the file can be imported back as is.
+// @Tags export
+// @Produce json
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param parent_id query string false "Export only the subtree of this comment"
+// @Param format query string false "Output format, default ndjson" Enums(ndjson, json, csv)
+// @Success 200 {array} response.ExportComment
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/export [get]
+func (r *V1) export(ctx *fiber.Ctx) error {
+	var req request.ExportRequest
+
+	err := ctx.QueryParser(&req)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid query parameters")
+	}
+
+	err = req.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	// контекст живет до конца потока, а не обработчика
+	exportCtx, cancel := context.WithTimeout(ctx.UserContext(), _exportTimeout)
+	deadline, _ := exportCtx.Deadline()
+
+	comments, err := r.c.ExportComments(exportCtx, req.ParentID)
+	if err != nil {
+		cancel()
+
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errorResponse(ctx, http.StatusNotFound, "comment not found")
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - export")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	ctx.Set(fiber.HeaderContentType, exportContentType(req.Format))
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="comments.`+req.Format+`"`)
+
+	// поток пишется уже после выхода из обработчика, fiber.Ctx в нем использовать нельзя
+	l := r.l.WithContext(ctx.UserContext())
+	conn := ctx.Context().Conn()
+	format := req.Format
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		flush := func() error {
+			_ = conn.SetWriteDeadline(time.Now().Add(min(_exportWriteTimeout, time.Until(deadline))))
+
+			return w.Flush()
+		}
+
+		// заголовок уже отправлен, поэтому ошибка только обрывает поток и пишется в лог
+		err := writeExport(w, format, comments, flush)
+		if err != nil {
+			l.Error(fmt.Errorf("restapi - v1 - export - writeExport: %w", err))
+		}
+	})
+
+	return nil
+}
+
+func exportContentType(format string) string {
+	switch format {
+	case request.FormatJSON:
+		return fiber.MIMEApplicationJSONCharsetUTF8
+	case request.FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// writeExport - прерывание range (ошибка записи, отключение клиента) закрывает курсор в хранилище.
+func writeExport(w *bufio.Writer, format string, comments iter.Seq2[entity.Comment, error], flush func() error) error {
+	var (
+		n  int
+		cw *csv.Writer
+	)
+
+	switch format {
+	case request.FormatJSON:
+		_ = w.WriteByte('[')
+	case request.FormatCSV:
+		cw = csv.NewWriter(w)
+		_ = cw.Write(_csvHeader)
+	}
+
+	for c, err := range comments {
+		if err != nil {
+			return err
+		}
+
+		row := response.ExportComment{
+			ID:        c.ID,
+			ParentID:  utils.NullInt64ToPtr(c.ParentID),
+			Content:   c.Content,
+			Author:    c.Author,
+			CreatedAt: c.CreatedAt,
+		}
+
+		switch format {
+		case request.FormatCSV:
+			err = cw.Write(csvRecord(row))
+		case request.FormatJSON:
+			if n > 0 {
+				_ = w.WriteByte(',')
+			}
+			err = json.NewEncoder(w).Encode(row)
+		default:
+			err = json.NewEncoder(w).Encode(row)
+		}
+		if err != nil {
+			return fmt.Errorf("encode: %w", err)
+		}
+
+		n++
+
+		if n%_exportFlushEvery == 0 {
+			if cw != nil {
+				cw.Flush()
+			}
+
+			err = flush()
+			if err != nil {
+				return fmt.Errorf("flush: %w", err)
+			}
+		}
+	}
+
+	if format == request.FormatJSON {
+		_ = w.WriteByte(']')
+	}
+
+	if cw != nil {
+		cw.Flush()
+	}
+
+	return flush()
+}
+
+func csvRecord(c response.ExportComment) []string {
+	parentID := ""
+	if c.ParentID != nil {
+		parentID = strconv.FormatInt(*c.ParentID, 10)
+	}
+
+	return []string{
+		strconv.FormatInt(c.ID, 10),
+		parentID,
+		c.Content,
+		c.CreatedAt.UTC().Format(time.RFC3339Nano),
+		c.Author,
+	}
+}
@@ -0,0 +1,67 @@
+package v1_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v1 "github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestExportImportAuth(t *testing.T) {
+	app, _ := newApp(t, memory.New())
+	create(t, app, nil, "alice", "root")
+
+	// без токена вся база не отдается и не загружается
+	for _, req := range []*http.Request{
+		httptest.NewRequest(fiber.MethodGet, "/v1/export", nil),
+		httptest.NewRequest(fiber.MethodPost, "/v1/import?format=json", strings.NewReader(`[{"id": 1, "content": "x"}]`)),
+	} {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s %s without token: status = %d, want 401", req.Method, req.URL, resp.StatusCode)
+		}
+	}
+
+	resp := do(t, app, fiber.MethodGet, "/v1/export", "", nil)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"content":"root"`) {
+		t.Fatalf("export = %d %s, %v; want the root", resp.StatusCode, body, err)
+	}
+
+	var imported struct {
+		Imported int `json:"imported"`
+	}
+	decode(t, do(t, app, fiber.MethodPost, "/v1/import?format=json", "",
+		[]map[string]any{{"id": 1, "content": "imported"}}), http.StatusOK, &imported)
+	if imported.Imported != 1 {
+		t.Fatalf("imported = %d, want 1", imported.Imported)
+	}
+
+	// без ADMIN_TOKEN маршрутов нет совсем
+	bare := fiber.New()
+	v1.NewCommentRoutes(bare.Group("/v1"), nil, logger.New("error"), "", "")
+
+	for _, method := range []string{fiber.MethodGet, fiber.MethodPost} {
+		target := "/v1/export"
+		if method == fiber.MethodPost {
+			target = "/v1/import"
+		}
+
+		if resp := do(t, bare, method, target, "", nil); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("%s %s without ADMIN_TOKEN: status = %d, want 404", method, target, resp.StatusCode)
+		}
+	}
+}
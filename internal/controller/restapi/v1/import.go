@@ -0,0 +1,292 @@
+package v1
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Import comments
+// @Description Bulk-loads comments in the export format as new trees. Ids are reassigned, parent_id must refer
+// @Description to a record of the same import. Invalid records are skipped together with their replies
+// @Description and reported with their line number; the rest is imported.
+// @Tags export
+// @Accept application/x-ndjson
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param format query string false "Input format, default ndjson" Enums(ndjson, json, csv)
+// @Param request body []response.ExportComment true "Comments"
+// @Success 200 {object} response.ImportResponse
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/import [post]
+func (r *V1) importComments(ctx *fiber.Ctx) error {
+	var req request.ImportRequest
+
+	err := ctx.QueryParser(&req)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid query parameters")
+	}
+
+	err = req.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	comments, parseErrors, err := parseImport(req.Format, ctx.Body())
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	result, err := r.c.ImportComments(ctx.UserContext(), comments)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - importComments")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	resp := response.ImportResponse{
+		Imported: result.Imported,
+		IDs:      result.IDs,
+		Errors:   make([]response.ImportError, 0, len(parseErrors)+len(result.Errors)),
+	}
+
+	for _, e := range append(parseErrors, result.Errors...) {
+		resp.Errors = append(resp.Errors, response.ImportError{Line: e.Line, ID: e.ID, Error: e.Error})
+	}
+
+	slices.SortStableFunc(resp.Errors, func(a, b response.ImportError) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+
+	return ctx.Status(http.StatusOK).JSON(resp)
+}
+
+// parseImport - ошибки отдельных записей возвращаются построчно,
+// err - только если разобрать файл дальше невозможно.
+func parseImport(format string, body []byte) ([]dto.ImportComment, []dto.ImportError, error) {
+	switch format {
+	case request.FormatJSON:
+		return parseImportJSON(body)
+	case request.FormatCSV:
+		return parseImportCSV(body)
+	default:
+		return parseImportNDJSON(body)
+	}
+}
+
+func parseImportNDJSON(body []byte) ([]dto.ImportComment, []dto.ImportError, error) {
+	var (
+		comments    []dto.ImportComment
+		parseErrors []dto.ImportError
+	)
+
+	for i, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec request.ImportComment
+
+		err := json.Unmarshal(line, &rec)
+		if err != nil {
+			parseErrors = append(parseErrors, dto.ImportError{Line: i + 1, Error: "invalid json: " + err.Error()})
+
+			continue
+		}
+
+		c, err := importComment(i+1, rec)
+		if err != nil {
+			parseErrors = append(parseErrors, dto.ImportError{Line: i + 1, ID: rec.ID, Error: err.Error()})
+
+			continue
+		}
+
+		comments = append(comments, c)
+	}
+
+	return comments, parseErrors, nil
+}
+
+// parseImportJSON - номер строки записи считается по смещению в теле.
+func parseImportJSON(body []byte) ([]dto.ImportComment, []dto.ImportError, error) {
+	var (
+		comments    []dto.ImportComment
+		parseErrors []dto.ImportError
+	)
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('[') {
+		return nil, nil, errors.New("json body must be an array")
+	}
+
+	for dec.More() {
+		line := lineAt(body, dec.InputOffset())
+
+		var rec request.ImportComment
+
+		err = dec.Decode(&rec)
+		if err != nil {
+			parseErrors = append(parseErrors, dto.ImportError{Line: line, Error: "invalid json: " + err.Error()})
+
+			// после синтаксической ошибки продолжить разбор нельзя
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				return comments, parseErrors, nil
+			}
+
+			continue
+		}
+
+		c, err := importComment(line, rec)
+		if err != nil {
+			parseErrors = append(parseErrors, dto.ImportError{Line: line, ID: rec.ID, Error: err.Error()})
+
+			continue
+		}
+
+		comments = append(comments, c)
+	}
+
+	return comments, parseErrors, nil
+}
+
+func parseImportCSV(body []byte) ([]dto.ImportComment, []dto.ImportError, error) {
+	var (
+		comments    []dto.ImportComment
+		parseErrors []dto.ImportError
+	)
+
+	cr := csv.NewReader(bytes.NewReader(body))
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{"id", "content"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("csv header must contain %q", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return record[i]
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		line, _ := cr.FieldPos(0)
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.StartLine
+			}
+
+			parseErrors = append(parseErrors, dto.ImportError{Line: line, Error: "invalid csv: " + err.Error()})
+
+			continue
+		}
+
+		rec := request.ImportComment{
+			Content:   field(record, "content"),
+			Author:    field(record, "author"),
+			CreatedAt: field(record, "created_at"),
+		}
+
+		rec.ID, err = strconv.ParseInt(field(record, "id"), 10, 64)
+		if err != nil {
+			parseErrors = append(parseErrors, dto.ImportError{Line: line, Error: "invalid id"})
+
+			continue
+		}
+
+		if p := field(record, "parent_id"); p != "" {
+			parentID, err := strconv.ParseInt(p, 10, 64)
+			if err != nil {
+				parseErrors = append(parseErrors, dto.ImportError{Line: line, ID: rec.ID, Error: "invalid parent_id"})
+
+				continue
+			}
+			rec.ParentID = &parentID
+		}
+
+		c, err := importComment(line, rec)
+		if err != nil {
+			parseErrors = append(parseErrors, dto.ImportError{Line: line, ID: rec.ID, Error: err.Error()})
+
+			continue
+		}
+
+		comments = append(comments, c)
+	}
+
+	return comments, parseErrors, nil
+}
+
+func importComment(line int, rec request.ImportComment) (dto.ImportComment, error) {
+	c := dto.ImportComment{
+		Line:     line,
+		ID:       rec.ID,
+		ParentID: rec.ParentID,
+		Content:  rec.Content,
+		Author:   rec.Author,
+	}
+
+	if rec.CreatedAt != "" {
+		createdAt, err := time.Parse(time.RFC3339Nano, rec.CreatedAt)
+		if err != nil {
+			return dto.ImportComment{}, errors.New("created_at must be in RFC 3339 format")
+		}
+		c.CreatedAt = createdAt
+	}
+
+	return c, nil
+}
+
+// lineAt - номер строки (с 1), на которой начинается следующее значение после offset.
+func lineAt(body []byte, offset int64) int {
+	rest := body[offset:]
+	skipped := len(rest) - len(bytes.TrimLeft(rest, " \t\r\n,"))
+
+	return bytes.Count(body[:int(offset)+skipped], []byte("\n")) + 1
+}
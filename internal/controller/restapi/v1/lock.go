@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Lock comment thread
+// @Description Locks comment with all its replies: "locked" rejects new replies, "archived" makes the thread read-only (no replies, pins, highlights, deletes or lock changes below it). Replaces previous lock of the same comment; inside an archived branch the request is rejected
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param Authorization header string false "Bearer ADMIN_TOKEN of a moderator"
+// @Param X-User header string false "Thread owner: the author of its root"
+// @Param request body request.LockCommentRequest false "Lock state and reason, default state - locked"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/lock [put]
+func (r *V1) lock(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	var body request.LockCommentRequest
+
+	if len(ctx.Body()) > 0 {
+		err = ctx.BodyParser(&body)
+		if err != nil {
+			return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
+		}
+	}
+
+	state := strings.ToLower(body.State)
+	if state == "" {
+		state = entity.LockLocked
+	}
+
+	if !entity.ValidLockState(state) {
+		return errorResponse(ctx, http.StatusBadRequest, "state must be locked or archived")
+	}
+
+	err = r.c.LockComment(ctx.UserContext(), int64(id), state, body.Reason)
+	if err != nil {
+		return r.flagError(ctx, err, "restapi - v1 - lock")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Unlock comment thread
+// @Description Removes lock of the comment itself, an archived comment included; a lock of its ancestors still applies, and inside an archived branch the request is rejected
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param Authorization header string false "Bearer ADMIN_TOKEN of a moderator"
+// @Param X-User header string false "Thread owner: the author of its root"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/lock [delete]
+func (r *V1) unlock(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	err = r.c.UnlockComment(ctx.UserContext(), int64(id))
+	if err != nil {
+		return r.flagError(ctx, err, "restapi - v1 - unlock")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
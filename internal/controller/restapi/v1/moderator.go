@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// moderatorOrOwner - менять тред коммента :id может модератор ("Authorization: Bearer <ADMIN_TOKEN>")
+// или владелец треда - автор его корня из X-User. У анонимного треда владельца нет.
+// ownRoot=false - сам корень владельцу недоступен: например, закрепление корня поднимает его над чужими.
+func (r *V1) moderatorOrOwner(adminToken string, ownRoot bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if isAdmin(ctx, adminToken) {
+			return ctx.Next()
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+		}
+
+		user, ok := requiredUser(ctx)
+		if !ok {
+			return errorResponse(ctx, http.StatusForbidden, "moderator or thread owner only")
+		}
+
+		root, err := r.c.GetThreadRoot(ctx.UserContext(), int64(id))
+		if err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return errorResponse(ctx, http.StatusNotFound, "comment not found")
+			}
+			r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - moderatorOrOwner")
+
+			return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+		}
+
+		if root.Author != user || !ownRoot && root.ID == int64(id) {
+			return errorResponse(ctx, http.StatusForbidden, "moderator or thread owner only")
+		}
+
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package v1_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/gofiber/fiber/v2"
+)
+
+// doAs - как do, но без токена модератора: только X-User (если user не пустой).
+func doAs(t *testing.T, app *fiber.App, method, target, user string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, nil)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func TestModeratorOrOwner(t *testing.T) {
+	app, _ := newApp(t, memory.New())
+
+	root := create(t, app, nil, "alice", "root")
+	reply := create(t, app, &root, "bob", "reply")
+	anonymous := create(t, app, nil, "", "anonymous root")
+
+	comment := func(id int64, action string) string {
+		return "/v1/comments/" + strconv.FormatInt(id, 10) + "/" + action
+	}
+
+	tests := []struct {
+		name, method, target, user string
+		status                     int
+	}{
+		// чужие и анонимы треды не модерируют
+		{"stranger pins", fiber.MethodPut, comment(reply, "pin"), "bob", http.StatusForbidden},
+		{"stranger unpins", fiber.MethodDelete, comment(reply, "pin"), "carol", http.StatusForbidden},
+		{"anonymous pins", fiber.MethodPut, comment(reply, "pin"), "", http.StatusForbidden},
+		{"stranger highlights", fiber.MethodPut, comment(root, "highlight"), "bob", http.StatusForbidden},
+		{"anonymous unhighlights", fiber.MethodDelete, comment(root, "highlight"), "", http.StatusForbidden},
+		{"nobody owns anonymous thread", fiber.MethodPut, comment(anonymous, "highlight"), "", http.StatusForbidden},
+		// владелец - автор корня: ответы закрепляет, корень среди чужих корней - нет
+		{"owner pins reply", fiber.MethodPut, comment(reply, "pin"), "alice", http.StatusNoContent},
+		{"owner unpins reply", fiber.MethodDelete, comment(reply, "pin"), "alice", http.StatusNoContent},
+		{"owner pins root", fiber.MethodPut, comment(root, "pin"), "alice", http.StatusForbidden},
+		{"owner highlights", fiber.MethodPut, comment(reply, "highlight"), "alice", http.StatusNoContent},
+		{"owner unhighlights", fiber.MethodDelete, comment(reply, "highlight"), "alice", http.StatusNoContent},
+		{"stranger locks", fiber.MethodPut, comment(root, "lock"), "bob", http.StatusForbidden},
+		{"anonymous locks", fiber.MethodPut, comment(reply, "lock"), "", http.StatusForbidden},
+		{"stranger unlocks", fiber.MethodDelete, comment(root, "lock"), "bob", http.StatusForbidden},
+		{"owner locks", fiber.MethodPut, comment(root, "lock"), "alice", http.StatusNoContent},
+		{"owner unlocks", fiber.MethodDelete, comment(root, "lock"), "alice", http.StatusNoContent},
+		{"missing comment", fiber.MethodPut, comment(reply+100, "pin"), "alice", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := doAs(t, app, tt.method, tt.target, tt.user); status != tt.status {
+				t.Fatalf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+
+	// модератор - с токеном, X-User ему не нужен
+	decode(t, do(t, app, fiber.MethodPut, comment(root, "pin"), "", nil), http.StatusNoContent, nil)
+	decode(t, do(t, app, fiber.MethodPut, comment(anonymous, "highlight"), "", nil), http.StatusNoContent, nil)
+}
@@ -0,0 +1,298 @@
+package v1
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/utils"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	_beforeCursorPrefix = "before:"
+
+	// _streamHeartbeat - как часто в поток уходит комментарий-пинг: по ошибке его записи
+	// замечаем отключившегося клиента, а прокси не закрывают соединение по простою.
+	_streamHeartbeat = 25 * time.Second
+	// _streamWriteTimeout - сколько ждем, пока клиент примет очередное событие.
+	_streamWriteTimeout = 10 * time.Second
+
+	_userRequired = "valid X-User header is required"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// @Summary Get notifications
+// @Description Notifications of X-User from newest to oldest: replies to their comments and @mentions
+// @Tags notifications
+// @Produce json
+// @Param X-User header string true "Recipient"
+// @Param status query string false "Which notifications to return, default all" Enums(unread, read, all)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query string false "Page size, default 20, max 100"
+// @Success 200 {object} response.NotificationsResponse
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/notifications [get]
+func (r *V1) getNotifications(ctx *fiber.Ctx) error {
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	var req request.GetNotificationsRequest
+
+	err := ctx.QueryParser(&req)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid query parameters")
+	}
+
+	err = req.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	var beforeID int64
+	if req.Cursor != "" {
+		beforeID, err = decodeBeforeCursor(req.Cursor)
+		if err != nil {
+			return errorResponse(ctx, http.StatusBadRequest, err.Error())
+		}
+	}
+
+	// лишнее уведомление показывает, есть ли следующая страница
+	ns, err := r.c.GetNotifications(ctx.UserContext(), user, req.Status, beforeID, req.Limit+1)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - getNotifications")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	resp := response.NotificationsResponse{Notifications: make([]response.NotificationResponse, 0, len(ns))}
+
+	if len(ns) > req.Limit {
+		ns = ns[:req.Limit]
+		resp.NextCursor = encodeBeforeCursor(ns[len(ns)-1].ID)
+	}
+
+	for _, n := range ns {
+		resp.Notifications = append(resp.Notifications, notificationResponse(n))
+	}
+
+	return ctx.Status(http.StatusOK).JSON(resp)
+}
+
+// @Summary Mark notifications read
+// @Description Marks the given notifications of X-User (or all of them) as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param X-User header string true "Recipient"
+// @Param request body request.MarkNotificationsReadRequest true "Notifications to mark"
+// @Success 200 {object} response.MarkNotificationsReadResponse
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/notifications/read [post]
+func (r *V1) markNotificationsRead(ctx *fiber.Ctx) error {
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	var body request.MarkNotificationsReadRequest
+
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
+	}
+
+	err = body.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	// all - nil: отмечаются все уведомления получателя
+	var ids []int64
+	if !body.All {
+		ids = body.IDs
+	}
+
+	read, err := r.c.MarkNotificationsRead(ctx.UserContext(), user, ids)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - markNotificationsRead")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.MarkNotificationsReadResponse{Read: read})
+}
+
+// @Summary Stream notifications
+// @Description Server-Sent Events: each new notification of X-User arrives as a "notification" event while the connection is open.
+// @Description Only notifications created by this instance are streamed; the inbox has all of them
+// @Tags notifications
+// @Produce text/event-stream
+// @Param X-User header string true "Recipient"
+// @Success 200 {object} response.NotificationResponse
+// @Failure 400 {object} response.Error
+// @Router /v1/notifications/stream [get]
+func (r *V1) streamNotifications(ctx *fiber.Ctx) error {
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	notifications, unsubscribe := r.c.SubscribeNotifications(ctx.UserContext(), user)
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	// поток пишется уже после выхода из обработчика, fiber.Ctx в нем использовать нельзя
+	conn := ctx.Context().Conn()
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		flush := func() error {
+			_ = conn.SetWriteDeadline(time.Now().Add(_streamWriteTimeout))
+
+			return w.Flush()
+		}
+
+		// клиент сразу видит, что подписка открыта
+		_, _ = w.WriteString(": connected\n\n")
+		if flush() != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(_streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case n, ok := <-notifications:
+				// подписки закрываются при остановке сервиса
+				if !ok {
+					return
+				}
+
+				data, err := json.Marshal(notificationResponse(n))
+				if err != nil {
+					return
+				}
+
+				_, _ = fmt.Fprintf(w, "event: notification\nid: %d\ndata: %s\n\n", n.ID, data)
+			case <-heartbeat.C:
+				_, _ = w.WriteString(": ping\n\n")
+			}
+
+			if flush() != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// @Summary Mute thread
+// @Description X-User stops getting notifications about new comments in the subtree of the comment
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param X-User header string true "User"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/mute [put]
+func (r *V1) mute(ctx *fiber.Ctx) error {
+	return r.setMute(ctx, true)
+}
+
+// @Summary Unmute thread
+// @Description Removes X-User's mute from the comment; mutes of its ancestors still apply
+// @Tags notifications
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param X-User header string true "User"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/mute [delete]
+func (r *V1) unmute(ctx *fiber.Ctx) error {
+	return r.setMute(ctx, false)
+}
+
+func (r *V1) setMute(ctx *fiber.Ctx, muted bool) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	if muted {
+		err = r.c.MuteThread(ctx.UserContext(), user, int64(id))
+	} else {
+		err = r.c.UnmuteThread(ctx.UserContext(), user, int64(id))
+	}
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errorResponse(ctx, http.StatusNotFound, "comment not found")
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - setMute")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func notificationResponse(n entity.Notification) response.NotificationResponse {
+	return response.NotificationResponse{
+		ID:        n.ID,
+		Kind:      n.Kind,
+		CommentID: n.CommentID,
+		Actor:     n.Actor,
+		Content:   n.Content,
+		CreatedAt: n.CreatedAt,
+		ReadAt:    utils.NullTimeToPtr(n.ReadAt),
+	}
+}
+
+// encodeBeforeCursor - курсор страниц от новых к старым (уведомления, журнал изменений):
+// id последней записи предыдущей страницы.
+func encodeBeforeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(_beforeCursorPrefix + strconv.FormatInt(id, 10)))
+}
+
+func decodeBeforeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), _beforeCursorPrefix) {
+		return 0, errInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(string(raw), _beforeCursorPrefix), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errInvalidCursor
+	}
+
+	return id, nil
+}
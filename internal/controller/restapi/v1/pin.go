@@ -0,0 +1,139 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Pin comment
+// @Description Pins comment above its unpinned siblings (a root - above all unpinned roots on every sort order). Pinned comments are ordered by pin order
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param Authorization header string false "Bearer ADMIN_TOKEN of a moderator"
+// @Param X-User header string false "Thread owner: the author of its root may pin replies, roots are pinned by moderators only"
+// @Param request body request.PinCommentRequest false "Pin order, default 0"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/pin [put]
+func (r *V1) pin(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	var body request.PinCommentRequest
+
+	if len(ctx.Body()) > 0 {
+		err = ctx.BodyParser(&body)
+		if err != nil {
+			return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
+		}
+	}
+
+	err = r.c.PinComment(ctx.UserContext(), int64(id), body.Order)
+	if err != nil {
+		return r.flagError(ctx, err, "restapi - v1 - pin")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Unpin comment
+// @Description Returns comment to its regular place among siblings
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param Authorization header string false "Bearer ADMIN_TOKEN of a moderator"
+// @Param X-User header string false "Thread owner: the author of its root may unpin replies, roots are unpinned by moderators only"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/pin [delete]
+func (r *V1) unpin(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	err = r.c.UnpinComment(ctx.UserContext(), int64(id))
+	if err != nil {
+		return r.flagError(ctx, err, "restapi - v1 - unpin")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Highlight comment
+// @Description Marks comment as featured
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param Authorization header string false "Bearer ADMIN_TOKEN of a moderator"
+// @Param X-User header string false "Thread owner: the author of its root"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/highlight [put]
+func (r *V1) highlight(ctx *fiber.Ctx) error {
+	return r.setHighlighted(ctx, true, "restapi - v1 - highlight")
+}
+
+// @Summary Remove highlight
+// @Description Removes featured mark from comment
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param Authorization header string false "Bearer ADMIN_TOKEN of a moderator"
+// @Param X-User header string false "Thread owner: the author of its root"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 423 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/highlight [delete]
+func (r *V1) unhighlight(ctx *fiber.Ctx) error {
+	return r.setHighlighted(ctx, false, "restapi - v1 - unhighlight")
+}
+
+func (r *V1) setHighlighted(ctx *fiber.Ctx, highlighted bool, op string) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	err = r.c.SetHighlighted(ctx.UserContext(), int64(id), highlighted)
+	if err != nil {
+		return r.flagError(ctx, err, op)
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (r *V1) flagError(ctx *fiber.Ctx, err error, op string) error {
+	if errors.Is(err, errs.ErrRecordNotFound) {
+		return errorResponse(ctx, http.StatusNotFound, "comment not found")
+	}
+	if locked, resp := lockedError(ctx, err); locked {
+		return resp
+	}
+	r.l.WithContext(ctx.UserContext()).Error(err, op)
+
+	return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+}
@@ -0,0 +1,234 @@
+package v1
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/utils"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/gofiber/fiber/v2"
+)
+
+// adminAuth - админские запросы идут с "Authorization: Bearer <ADMIN_TOKEN>".
+func adminAuth(token string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !isAdmin(ctx, token) {
+			return errorResponse(ctx, http.StatusUnauthorized, "invalid admin token")
+		}
+
+		return ctx.Next()
+	}
+}
+
+// isAdmin - пустой token не подходит ни к какому заголовку.
+func isAdmin(ctx *fiber.Ctx, token string) bool {
+	got, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// @Summary Export author data
+// @Description Returns a ZIP archive with everything stored about the author: comments.json, attachments.json (with signed links), notifications.json, subscriptions.json, mutes.json and visits.json. The request is recorded in the privacy request log
+// @Tags admin
+// @Produce application/zip
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param author path string true "Author"
+// @Param reason query string true "Reason recorded in the log"
+// @Param X-User header string false "Admin performing the request"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/admin/authors/{author}/export [get]
+func (r *V1) exportAuthor(ctx *fiber.Ctx) error {
+	// имя попадает в журнал privacy_requests, а fiber отдает параметры из переиспользуемого буфера
+	author := strings.Clone(ctx.Params("author"))
+	if !entity.ValidUsername(author) {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid author")
+	}
+
+	actor, ok := optionalUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid X-User header")
+	}
+
+	var req request.ExportAuthorRequest
+
+	err := ctx.QueryParser(&req)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid query parameters")
+	}
+
+	err = req.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	data, err := r.c.ExportAuthor(ctx.UserContext(), author, actor, req.Reason)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - exportAuthor")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	archive, err := authorArchive(data)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(fmt.Errorf("restapi - v1 - exportAuthor - authorArchive: %w", err))
+
+		return errorResponse(ctx, http.StatusInternalServerError, "export problems")
+	}
+
+	ctx.Set(fiber.HeaderContentType, "application/zip")
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="`+author+`.zip"`)
+
+	return ctx.Status(http.StatusOK).Send(archive)
+}
+
+// @Summary Erase author data
+// @Description Erases the author: "anonymize" keeps their comments in the tree without author and with "[deleted]" instead of the text, "delete" removes those without replies of other authors regardless of locks and leaves the rest as in "anonymize". In both modes their attachments, notifications, mutes, subscriptions and visits are deleted and their name is removed from notifications of others. The request is recorded in the privacy request log
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer ADMIN_TOKEN"
+// @Param author path string true "Author"
+// @Param request body request.EraseAuthorRequest true "Erasure mode and reason"
+// @Param X-User header string false "Admin performing the request"
+// @Success 200 {object} response.PrivacyRequestResponse
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/admin/authors/{author}/erase [post]
+func (r *V1) eraseAuthor(ctx *fiber.Ctx) error {
+	author := strings.Clone(ctx.Params("author"))
+	if !entity.ValidUsername(author) {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid author")
+	}
+
+	actor, ok := optionalUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid X-User header")
+	}
+
+	var body request.EraseAuthorRequest
+
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
+	}
+
+	err = body.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	p, err := r.c.EraseAuthor(ctx.UserContext(), author, body.Mode, actor, body.Reason)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - eraseAuthor")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.PrivacyRequestResponse{
+		ID:        p.ID,
+		Action:    p.Action,
+		Author:    p.Author,
+		Actor:     p.Actor,
+		Reason:    p.Reason,
+		Comments:  p.Comments,
+		CreatedAt: p.CreatedAt,
+	})
+}
+
+// authorArchive - по файлу на вид данных, пустые виды - пустые массивы.
+func authorArchive(data entity.AuthorData) ([]byte, error) {
+	comments := make([]response.PrivacyComment, len(data.Comments))
+	for i, c := range data.Comments {
+		comments[i] = response.PrivacyComment{
+			ID:        c.ID,
+			ParentID:  utils.NullInt64ToPtr(c.ParentID),
+			ReplyToID: utils.NullInt64ToPtr(c.ReplyToID),
+			Content:   c.Content,
+			CreatedAt: c.CreatedAt,
+		}
+	}
+
+	attachments := make([]response.PrivacyAttachment, len(data.Attachments))
+	for i, a := range data.Attachments {
+		attachments[i] = response.PrivacyAttachment{
+			AttachmentResponse: utils.BuildAttachment(a),
+			CommentID:          utils.NullInt64ToPtr(a.CommentID),
+		}
+	}
+
+	notifications := make([]response.NotificationResponse, len(data.Notifications))
+	for i, n := range data.Notifications {
+		notifications[i] = notificationResponse(n)
+	}
+
+	subscriptions := make([]response.SubscriptionResponse, len(data.Subscriptions))
+	for i, s := range data.Subscriptions {
+		subscriptions[i] = response.SubscriptionResponse{
+			CommentID:  s.CommentID,
+			Email:      s.Email,
+			CreatedAt:  s.CreatedAt,
+			DigestedAt: s.DigestedAt,
+		}
+	}
+
+	mutes := make([]response.PrivacyMute, len(data.Mutes))
+	for i, m := range data.Mutes {
+		mutes[i] = response.PrivacyMute{CommentID: m.CommentID, CreatedAt: m.CreatedAt}
+	}
+
+	visits := make([]response.PrivacyVisit, len(data.Visits))
+	for i, v := range data.Visits {
+		visits[i] = response.PrivacyVisit{RootID: v.RootID, LastSeenID: v.LastSeenID, SeenAt: v.SeenAt}
+	}
+
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"comments.json", comments},
+		{"attachments.json", attachments},
+		{"notifications.json", notifications},
+		{"subscriptions.json", subscriptions},
+		{"mutes.json", mutes},
+		{"visits.json", visits},
+	}
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+	now := time.Now()
+
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, fmt.Errorf("zw.CreateHeader: %w", err)
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(f.v)
+		if err != nil {
+			return nil, fmt.Errorf("enc.Encode: %w", err)
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return nil, fmt.Errorf("zw.Close: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,368 @@
+package v1_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	pngenc "image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/blob"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+const _adminToken = "s3cret"
+
+// privacyRepo - запоминает записи журнала privacy_requests: читать его хранилище не умеет.
+type privacyRepo struct {
+	repo.CommentRepo
+
+	mu       sync.Mutex
+	requests []entity.PrivacyRequest
+}
+
+func (r *privacyRepo) LogPrivacyRequest(ctx context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error) {
+	p, err := r.CommentRepo.LogPrivacyRequest(ctx, p)
+	if err == nil {
+		r.mu.Lock()
+		r.requests = append(r.requests, p)
+		r.mu.Unlock()
+	}
+
+	return p, err
+}
+
+func (r *privacyRepo) logged() []entity.PrivacyRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]entity.PrivacyRequest(nil), r.requests...)
+}
+
+// newApp - маршруты /v1 с админскими, вложения - в TempDir.
+func newApp(t *testing.T, r repo.CommentRepo) (*fiber.App, *comment.CommentUseCase) {
+	t.Helper()
+
+	l := logger.New("error")
+	uc := comment.New(r, webapi.NewCDN("", "", "", "", time.Second, l), l,
+		comment.Attachments(blob.NewLocal(t.TempDir()), "secret", comment.AttachmentLimits{
+			MaxSize:       1 << 20,
+			MaxPerComment: 5,
+			Types:         []string{"image/png"},
+			ThumbnailSize: 1,
+		}, time.Hour, time.Hour),
+	)
+
+	app := fiber.New()
+	v1.NewCommentRoutes(app.Group("/v1"), uc, l, "", _adminToken)
+
+	return app, uc
+}
+
+// do - запрос от user с админским токеном; body, если не nil, уходит как JSON.
+func do(t *testing.T, app *fiber.App, method, target, user string, body any) *http.Response {
+	t.Helper()
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("json.Marshal: %v", err)
+		}
+
+		r = bytes.NewReader(b)
+	}
+
+	req := httptest.NewRequest(method, target, r)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+_adminToken)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+// decode - ответ со статусом status, тело - в v.
+func decode(t *testing.T, resp *http.Response, status int, v any) {
+	t.Helper()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	if resp.StatusCode != status {
+		t.Fatalf("status = %d, want %d: %s", resp.StatusCode, status, body)
+	}
+
+	if v != nil {
+		if err = json.Unmarshal(body, v); err != nil {
+			t.Fatalf("json.Unmarshal(%s): %v", body, err)
+		}
+	}
+}
+
+// create - POST /v1/comments от author, возвращает id.
+func create(t *testing.T, app *fiber.App, parentID *int64, author, content string, attachmentIDs ...int64) int64 {
+	t.Helper()
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	decode(t, do(t, app, fiber.MethodPost, "/v1/comments", author, map[string]any{
+		"parent_id": parentID, "content": content, "attachment_ids": attachmentIDs,
+	}), http.StatusCreated, &created)
+
+	return created.ID
+}
+
+func upload(t *testing.T, uc *comment.CommentUseCase, uploader string) entity.Attachment {
+	t.Helper()
+
+	var png bytes.Buffer
+	if err := pngenc.Encode(&png, image.NewGray(image.Rect(0, 0, 2, 3))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	a, err := uc.UploadAttachment(context.Background(), uploader, "dot.png", &png)
+	if err != nil {
+		t.Fatalf("UploadAttachment: %v", err)
+	}
+
+	return a
+}
+
+// unzip - JSON-файлы архива по именам.
+func unzip(t *testing.T, resp *http.Response) map[string][]byte {
+	t.Helper()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(fiber.HeaderContentType) != "application/zip" {
+		t.Fatalf("export = %d %s: %s", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), body)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+
+	files := make(map[string][]byte)
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+
+		files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+	}
+
+	return files
+}
+
+func TestExportAuthor(t *testing.T) {
+	r := &privacyRepo{CommentRepo: memory.New()}
+	app, uc := newApp(t, r)
+
+	a := upload(t, uc, "alice")
+	root := create(t, app, nil, "alice", "root", a.ID)
+	create(t, app, &root, "bob", "reply")
+
+	decode(t, do(t, app, fiber.MethodPut, "/v1/comments/"+strconv.FormatInt(root, 10)+"/subscription", "alice",
+		map[string]string{"email": "alice@example.com"}), http.StatusNoContent, nil)
+
+	// без причины выгрузки нет, и в журнал она не попадает
+	decode(t, do(t, app, fiber.MethodGet, "/v1/admin/authors/alice/export", "dpo", nil), http.StatusBadRequest, nil)
+
+	files := unzip(t, do(t, app, fiber.MethodGet, "/v1/admin/authors/alice/export?reason=gdpr", "dpo", nil))
+
+	for _, name := range []string{"comments.json", "attachments.json", "notifications.json", "subscriptions.json", "mutes.json", "visits.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive has no %s", name)
+		}
+	}
+
+	var comments []struct {
+		ID      int64  `json:"id"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(files["comments.json"], &comments); err != nil || len(comments) != 1 || comments[0].ID != root || comments[0].Content != "root" {
+		t.Fatalf("comments.json = %s, %v; want root only", files["comments.json"], err)
+	}
+
+	var notifications []struct {
+		Kind  string `json:"kind"`
+		Actor string `json:"actor"`
+	}
+	if err := json.Unmarshal(files["notifications.json"], &notifications); err != nil || len(notifications) != 1 ||
+		notifications[0].Kind != entity.NotificationReply || notifications[0].Actor != "bob" {
+		t.Fatalf("notifications.json = %s, %v; want reply from bob", files["notifications.json"], err)
+	}
+
+	var subscriptions []struct {
+		CommentID int64  `json:"comment_id"`
+		Email     string `json:"email"`
+	}
+	if err := json.Unmarshal(files["subscriptions.json"], &subscriptions); err != nil || len(subscriptions) != 1 ||
+		subscriptions[0].CommentID != root || subscriptions[0].Email != "alice@example.com" {
+		t.Fatalf("subscriptions.json = %s, %v; want root", files["subscriptions.json"], err)
+	}
+
+	var attachments []struct {
+		ID        int64  `json:"id"`
+		CommentID *int64 `json:"comment_id"`
+		URL       string `json:"url"`
+	}
+	if err := json.Unmarshal(files["attachments.json"], &attachments); err != nil || len(attachments) != 1 ||
+		attachments[0].ID != a.ID || attachments[0].CommentID == nil || *attachments[0].CommentID != root {
+		t.Fatalf("attachments.json = %s, %v; want the attachment of root", files["attachments.json"], err)
+	}
+
+	// ссылка из выгрузки подписана и отдает файл
+	file := do(t, app, fiber.MethodGet, attachments[0].URL, "", nil)
+	if file.StatusCode != http.StatusOK || file.Header.Get(fiber.HeaderContentType) != "image/png" {
+		t.Fatalf("GET %s = %d %s, want the png", attachments[0].URL, file.StatusCode, file.Header.Get(fiber.HeaderContentType))
+	}
+
+	logged := r.logged()
+	if len(logged) != 1 || logged[0].Action != entity.PrivacyExport || logged[0].Author != "alice" ||
+		logged[0].Actor != "dpo" || logged[0].Reason != "gdpr" || logged[0].Comments != 1 {
+		t.Fatalf("privacy requests = %+v; want one export", logged)
+	}
+}
+
+func TestEraseAuthor(t *testing.T) {
+	ctx := context.Background()
+
+	r := &privacyRepo{CommentRepo: memory.New()}
+	app, _ := newApp(t, r)
+
+	// alice: корень с ответом bob и ее ответ на него
+	root := create(t, app, nil, "alice", "root")
+	reply := create(t, app, &root, "bob", "reply")
+	answer := create(t, app, &reply, "alice", "answer")
+
+	erase := func(author, mode string) entity.PrivacyRequest {
+		t.Helper()
+
+		var p struct {
+			Action   string `json:"action"`
+			Comments int    `json:"comments"`
+		}
+		decode(t, do(t, app, fiber.MethodPost, "/v1/admin/authors/"+author+"/erase", "dpo",
+			map[string]string{"mode": mode, "reason": "gdpr"}), http.StatusOK, &p)
+
+		return entity.PrivacyRequest{Action: p.Action, Comments: p.Comments}
+	}
+
+	if p := erase("alice", entity.ErasureAnonymize); p.Action != entity.ErasureAnonymize || p.Comments != 2 {
+		t.Fatalf("anonymize = %+v, want 2 comments", p)
+	}
+
+	// дерево на месте, от alice остались заглушки
+	tree, err := r.GetCommentWithChildren(ctx, root, entity.ChildSortOldest)
+	if err != nil || len(tree) != 3 {
+		t.Fatalf("GetCommentWithChildren = %d comments, %v; want 3", len(tree), err)
+	}
+	for _, c := range tree {
+		erased := c.ID == root || c.ID == answer
+		if erased && (c.Author != "" || c.Content != entity.ErasedContent) {
+			t.Errorf("comment %d = %q by %q, want erased", c.ID, c.Content, c.Author)
+		}
+		if !erased && (c.Author != "bob" || c.Content != "reply") {
+			t.Errorf("comment %d = %q by %q, want bob's reply", c.ID, c.Content, c.Author)
+		}
+	}
+
+	// повтор ничего не находит, но тоже пишется
+	if p := erase("alice", entity.ErasureAnonymize); p.Comments != 0 {
+		t.Fatalf("second anonymize = %+v, want 0 comments", p)
+	}
+
+	// carol: ветка с чужим ответом остается заглушкой, свои поддеревья удаляются целиком
+	kept := create(t, app, nil, "carol", "kept")
+	foreign := create(t, app, &kept, "bob", "foreign")
+	own := create(t, app, &foreign, "carol", "own")
+	alone := create(t, app, nil, "carol", "alone")
+	create(t, app, &alone, "carol", "alone reply")
+
+	if p := erase("carol", entity.ErasureDelete); p.Action != entity.ErasureDelete || p.Comments != 4 {
+		t.Fatalf("delete = %+v, want 3 deleted and 1 erased", p)
+	}
+
+	for _, id := range []int64{own, alone} {
+		if err = r.CommentExists(ctx, id); !errors.Is(err, errs.ErrRecordNotFound) {
+			t.Errorf("CommentExists(%d) = %v, want ErrRecordNotFound", id, err)
+		}
+	}
+
+	tree, err = r.GetCommentWithChildren(ctx, kept, entity.ChildSortOldest)
+	if err != nil || len(tree) != 2 || tree[0].Content != entity.ErasedContent || tree[1].ID != foreign || tree[1].Author != "bob" {
+		t.Fatalf("GetCommentWithChildren(kept) = %+v, %v; want erased kept with bob's reply", tree, err)
+	}
+
+	logged := r.logged()
+	if len(logged) != 3 {
+		t.Fatalf("privacy requests = %+v; want 3", logged)
+	}
+	for i, want := range []struct {
+		action, author string
+		comments       int
+	}{
+		{entity.ErasureAnonymize, "alice", 2},
+		{entity.ErasureAnonymize, "alice", 0},
+		{entity.ErasureDelete, "carol", 4},
+	} {
+		if got := logged[i]; got.Action != want.action || got.Author != want.author || got.Actor != "dpo" || got.Comments != want.comments {
+			t.Errorf("privacy request %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestEraseAuthorValidation(t *testing.T) {
+	r := &privacyRepo{CommentRepo: memory.New()}
+	app, _ := newApp(t, r)
+
+	decode(t, do(t, app, fiber.MethodPost, "/v1/admin/authors/alice/erase", "dpo",
+		map[string]string{"mode": "shred", "reason": "gdpr"}), http.StatusBadRequest, nil)
+	decode(t, do(t, app, fiber.MethodPost, "/v1/admin/authors/alice/erase", "dpo",
+		map[string]string{"mode": entity.ErasureDelete}), http.StatusBadRequest, nil)
+
+	if logged := r.logged(); len(logged) != 0 {
+		t.Fatalf("privacy requests = %+v; want none for rejected requests", logged)
+	}
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/utils"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Get backlinks
+// @Description Comments that reference this one with >>id, from newest to oldest
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param limit query string false "Page size, default 20, max 100"
+// @Param offset query string false "Offset, default 0"
+// @Success 200 {object} response.BacklinksResponse
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/backlinks [get]
+func (r *V1) getBacklinks(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	var req request.GetBacklinksRequest
+
+	err = ctx.QueryParser(&req)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid query parameters")
+	}
+
+	req.Validate()
+
+	comments, total, err := r.c.GetBacklinks(ctx.UserContext(), int64(id), req.Limit, req.Offset)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errorResponse(ctx, http.StatusNotFound, "comment not found")
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - getBacklinks")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	resp := response.BacklinksResponse{
+		Backlinks: make([]response.BacklinkResponse, len(comments)),
+		Total:     total,
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+	}
+
+	for i, c := range comments {
+		resp.Backlinks[i] = response.BacklinkResponse{
+			ID:        c.ID,
+			ParentID:  utils.NullInt64ToPtr(c.ParentID),
+			Author:    c.Author,
+			Snippet:   markdown.Snippet(c.Content, utils.SnippetLength),
+			CreatedAt: c.CreatedAt,
+		}
+	}
+
+	return ctx.Status(http.StatusOK).JSON(resp)
+}
@@ -0,0 +1,76 @@
+package request
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+var (
+	ErrUnknownAuditAction = errors.New("unknown action")
+	ErrInvalidActor       = errors.New("invalid actor")
+	ErrInvalidCommentID   = errors.New("comment_id must be positive")
+	ErrInvalidTimeRange   = errors.New("from and to must be RFC 3339 times, from before to")
+)
+
+// GetAuditLogRequest - пустые фильтры не применяются, from включительно, to - нет;
+// cursor - next_cursor из предыдущей страницы.
+type GetAuditLogRequest struct {
+	Actor     string `query:"actor"`
+	CommentID int64  `query:"comment_id"`
+	Action    string `query:"action"`
+	From      string `query:"from"`
+	To        string `query:"to"`
+	Cursor    string `query:"cursor"`
+	Limit     int    `query:"limit"`
+
+	filter entity.AuditFilter
+}
+
+func (r *GetAuditLogRequest) Validate() error {
+	if r.Limit <= 0 || r.Limit > 100 {
+		r.Limit = 20
+	}
+
+	r.Action = strings.ToLower(r.Action)
+	if r.Action != "" && !entity.ValidAuditAction(r.Action) {
+		return ErrUnknownAuditAction
+	}
+
+	if r.Actor != "" && !entity.ValidUsername(r.Actor) {
+		return ErrInvalidActor
+	}
+
+	if r.CommentID < 0 {
+		return ErrInvalidCommentID
+	}
+
+	r.filter = entity.AuditFilter{Actor: r.Actor, Action: r.Action, CommentID: r.CommentID}
+
+	var err error
+
+	if r.From != "" {
+		if r.filter.From, err = time.Parse(time.RFC3339, r.From); err != nil {
+			return ErrInvalidTimeRange
+		}
+	}
+
+	if r.To != "" {
+		if r.filter.To, err = time.Parse(time.RFC3339, r.To); err != nil {
+			return ErrInvalidTimeRange
+		}
+	}
+
+	if !r.filter.From.IsZero() && !r.filter.To.IsZero() && !r.filter.From.Before(r.filter.To) {
+		return ErrInvalidTimeRange
+	}
+
+	return nil
+}
+
+// Filter - фильтр журнала после Validate.
+func (r *GetAuditLogRequest) Filter() entity.AuditFilter {
+	return r.filter
+}
@@ -1,6 +1,9 @@
 package request
 
+// CreateCommentRequest - тело JSON или поля multipart/form-data (файлы - в files).
+// attachment_ids - вложения, загруженные заранее через POST /v1/attachments.
 type CreateCommentRequest struct {
-	ParentID *int64 `json:"parent_id"`
-	Content  string `json:"content"`
+	ParentID      *int64  `json:"parent_id" form:"parent_id"`
+	Content       string  `json:"content" form:"content"`
+	AttachmentIDs []int64 `json:"attachment_ids" form:"attachment_ids"`
 }
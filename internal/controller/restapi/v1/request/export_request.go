@@ -0,0 +1,59 @@
+package request
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+)
+
+var ErrUnknownFormat = errors.New("format must be one of ndjson, json, csv")
+
+type ExportRequest struct {
+	ParentID *int64 `query:"parent_id"`
+	Format   string `query:"format"`
+}
+
+func (r *ExportRequest) Validate() error {
+	var err error
+	r.Format, err = normalizeFormat(r.Format)
+
+	return err
+}
+
+type ImportRequest struct {
+	Format string `query:"format"`
+}
+
+func (r *ImportRequest) Validate() error {
+	var err error
+	r.Format, err = normalizeFormat(r.Format)
+
+	return err
+}
+
+// ImportComment - запись импорта в формате экспорта, id и parent_id - из источника.
+type ImportComment struct {
+	ID        int64  `json:"id"`
+	ParentID  *int64 `json:"parent_id"`
+	Content   string `json:"content"`
+	Author    string `json:"author"`
+	CreatedAt string `json:"created_at"`
+}
+
+func normalizeFormat(format string) (string, error) {
+	format = strings.ToLower(format)
+
+	switch format {
+	case "":
+		return FormatNDJSON, nil
+	case FormatNDJSON, FormatJSON, FormatCSV:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
@@ -0,0 +1,7 @@
+package request
+
+// GetAttachmentRequest - подпись ссылки на файл вложения.
+type GetAttachmentRequest struct {
+	Expires int64  `query:"expires"`
+	Sig     string `query:"sig"`
+}
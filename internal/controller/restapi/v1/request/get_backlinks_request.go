@@ -0,0 +1,16 @@
+package request
+
+type GetBacklinksRequest struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+func (r *GetBacklinksRequest) Validate() {
+	if r.Limit <= 0 || r.Limit > 100 {
+		r.Limit = 20
+	}
+
+	if r.Offset < 0 {
+		r.Offset = 0
+	}
+}
@@ -1,6 +1,10 @@
 package request
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
 
 type GetCommentsReqeust struct {
 	ParentID *int64 `query:"parent_id"`
@@ -9,6 +13,9 @@ type GetCommentsReqeust struct {
 	Order    string `query:"order"`
 	Limit    int    `query:"limit"`
 	Offset   int    `query:"offset"`
+
+	// ChildSort - порядок ответов на любой глубине: oldest, newest, score
+	ChildSort string `query:"child_sort"`
 }
 
 func (r *GetCommentsReqeust) Validate() {
@@ -29,4 +36,10 @@ func (r *GetCommentsReqeust) Validate() {
 	if r.Order != "DESC" && r.Order != "ASC" {
 		r.Order = "DESC"
 	}
+
+	r.ChildSort = strings.ToLower(r.ChildSort)
+
+	if !entity.ValidChildSort(r.ChildSort) {
+		r.ChildSort = entity.ChildSortOldest
+	}
 }
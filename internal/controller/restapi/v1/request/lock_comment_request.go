@@ -0,0 +1,7 @@
+package request
+
+// LockCommentRequest - state: locked (без новых ответов, по умолчанию) или archived (только чтение).
+type LockCommentRequest struct {
+	State  string `json:"state" example:"locked"`
+	Reason string `json:"reason" example:"off-topic"`
+}
@@ -0,0 +1,54 @@
+package request
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+const _maxMarkRead = 100
+
+var (
+	ErrUnknownStatus = errors.New("status must be one of unread, read, all")
+	ErrMarkRead      = errors.New("either ids (up to 100) or all must be set")
+)
+
+// GetNotificationsRequest - cursor - next_cursor из предыдущей страницы.
+type GetNotificationsRequest struct {
+	Status string `query:"status"`
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit"`
+}
+
+func (r *GetNotificationsRequest) Validate() error {
+	if r.Limit <= 0 || r.Limit > 100 {
+		r.Limit = 20
+	}
+
+	r.Status = strings.ToLower(r.Status)
+
+	if r.Status == "" {
+		r.Status = entity.NotificationsAll
+	}
+
+	if !entity.ValidNotificationStatus(r.Status) {
+		return ErrUnknownStatus
+	}
+
+	return nil
+}
+
+// MarkNotificationsReadRequest - либо конкретные ids, либо all: все уведомления получателя.
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids" example:"3,5"`
+	All bool    `json:"all" example:"false"`
+}
+
+func (r *MarkNotificationsReadRequest) Validate() error {
+	if r.All == (len(r.IDs) > 0) || len(r.IDs) > _maxMarkRead {
+		return ErrMarkRead
+	}
+
+	return nil
+}
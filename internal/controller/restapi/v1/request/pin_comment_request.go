@@ -0,0 +1,6 @@
+package request
+
+// PinCommentRequest - тело необязательно, без order коммент встает на место 0.
+type PinCommentRequest struct {
+	Order int64 `json:"order" example:"1"`
+}
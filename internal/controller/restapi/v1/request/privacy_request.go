@@ -0,0 +1,47 @@
+package request
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+var (
+	ErrUnknownErasureMode = errors.New("mode must be anonymize or delete")
+	ErrReasonRequired     = errors.New("reason is required")
+)
+
+// ExportAuthorRequest - reason попадает в журнал запросов.
+type ExportAuthorRequest struct {
+	Reason string `query:"reason"`
+}
+
+func (r *ExportAuthorRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return ErrReasonRequired
+	}
+
+	return nil
+}
+
+// EraseAuthorRequest - mode: anonymize (комменты остаются без автора и текста) или delete (удаляются с ответами).
+type EraseAuthorRequest struct {
+	Mode   string `json:"mode" example:"anonymize" enums:"anonymize,delete"`
+	Reason string `json:"reason" example:"GDPR request #123"`
+}
+
+func (r *EraseAuthorRequest) Validate() error {
+	r.Mode = strings.ToLower(r.Mode)
+	if r.Mode != entity.ErasureAnonymize && r.Mode != entity.ErasureDelete {
+		return ErrUnknownErasureMode
+	}
+
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return ErrReasonRequired
+	}
+
+	return nil
+}
@@ -0,0 +1,6 @@
+package request
+
+// SetMaxDepthRequest - 0 снимает лимит у этого дерева независимо от общего.
+type SetMaxDepthRequest struct {
+	MaxDepth *int64 `json:"max_depth" example:"10"`
+}
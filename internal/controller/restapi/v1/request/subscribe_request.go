@@ -0,0 +1,22 @@
+package request
+
+import (
+	"errors"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+var ErrInvalidEmail = errors.New("email must be a plain address like alice@example.com")
+
+// SubscribeRequest - адрес для дайджестов, он же заменяет адрес у остальных подписок пользователя.
+type SubscribeRequest struct {
+	Email string `json:"email" example:"alice@example.com"`
+}
+
+func (r *SubscribeRequest) Validate() error {
+	if !entity.ValidEmail(r.Email) {
+		return ErrInvalidEmail
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package response
+
+import "time"
+
+// AttachmentResponse - url и thumbnail_url - подписанные ссылки, действуют ограниченное время;
+// миниатюра и размеры есть только у картинок.
+type AttachmentResponse struct {
+	ID           int64     `json:"id" example:"3"`
+	Filename     string    `json:"filename" example:"cat.png"`
+	ContentType  string    `json:"content_type" example:"image/png"`
+	Size         int64     `json:"size" example:"48213"`
+	Width        int       `json:"width,omitempty" example:"800"`
+	Height       int       `json:"height,omitempty" example:"600"`
+	URL          string    `json:"url" example:"/v1/attachments/3?expires=1790000000&sig=..."`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty" example:"/v1/attachments/3/thumbnail?expires=1790000000&sig=..."`
+	CreatedAt    time.Time `json:"created_at" example:"2026-02-02T14:31:00Z"`
+}
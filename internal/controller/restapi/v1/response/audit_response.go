@@ -0,0 +1,25 @@
+package response
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntryResponse - before и after - снимки до и после изменения, их вид зависит от action.
+type AuditEntryResponse struct {
+	ID        int64           `json:"id" example:"42"`
+	Action    string          `json:"action" example:"lock"`
+	CommentID *int64          `json:"comment_id,omitempty" example:"12"`
+	Actor     string          `json:"actor,omitempty" example:"mod"`
+	IP        string          `json:"ip,omitempty" example:"203.0.113.7"`
+	RequestID string          `json:"request_id,omitempty" example:"4b1f0c1e9a7d4c2e"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at" example:"2026-02-02T14:31:00Z"`
+}
+
+// AuditLogResponse - next_cursor нет на последней странице.
+type AuditLogResponse struct {
+	Entries    []AuditEntryResponse `json:"entries"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
@@ -2,9 +2,17 @@ package response
 
 import "time"
 
+// CreateCommentResponse - если ответ перенесен выше из-за лимита глубины,
+// parent_id - новый родитель, а reply_to_id - коммент, на который отвечали.
+// content - исходный markdown, content_html - он же в безопасном HTML.
 type CreateCommentResponse struct {
-	ID        int64     `json:"id" example:"12"`
-	ParentID  *int64    `json:"parent_id" example:"1"`
-	Content   string    `json:"content" example:"nice picture!!!"`
-	CreatedAt time.Time `json:"created_at" example:"2026-02-02T14:31:00Z"`
+	ID          int64     `json:"id" example:"12"`
+	ParentID    *int64    `json:"parent_id" example:"1"`
+	ReplyToID   *int64    `json:"reply_to_id,omitempty" example:"7"`
+	Content     string    `json:"content" example:"nice *picture*!!!"`
+	ContentHTML string    `json:"content_html" example:"<p>nice <em>picture</em>!!!</p>"`
+	Author      string    `json:"author,omitempty" example:"alice"`
+	CreatedAt   time.Time `json:"created_at" example:"2026-02-02T14:31:00Z"`
+
+	Attachments []AttachmentResponse `json:"attachments,omitempty"`
 }
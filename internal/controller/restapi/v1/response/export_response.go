@@ -0,0 +1,25 @@
+package response
+
+import "time"
+
+// ExportComment - одна строка экспорта; тот же формат принимает POST /v1/import.
+type ExportComment struct {
+	ID        int64     `json:"id" example:"1"`
+	ParentID  *int64    `json:"parent_id" example:"1"`
+	Content   string    `json:"content" example:"hello"`
+	Author    string    `json:"author,omitempty" example:"alice"`
+	CreatedAt time.Time `json:"created_at" example:"2026-02-02T14:48:37Z"`
+}
+
+type ImportError struct {
+	Line  int    `json:"line" example:"3"`
+	ID    int64  `json:"id,omitempty" example:"42"`
+	Error string `json:"error" example:"parent 41 not found in import"`
+}
+
+type ImportResponse struct {
+	Imported int `json:"imported" example:"2"`
+	// IDs - новые id по id источника
+	IDs    map[int64]int64 `json:"ids"`
+	Errors []ImportError   `json:"errors"`
+}
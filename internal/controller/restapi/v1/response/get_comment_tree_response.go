@@ -2,11 +2,26 @@ package response
 
 import "time"
 
+// CommentTreeResponse - content - исходный markdown, content_html - он же в безопасном HTML,
+// references - ссылки ">>id" из текста в порядке первого упоминания, attachments - вложения в порядке загрузки.
 type CommentTreeResponse struct {
-	ID        int64                  `json:"id"`
-	ParentID  *int64                 `json:"parent_id"`
-	Content   string                 `json:"content"`
-	CreatedAt time.Time              `json:"created_at"`
-	Depth     int                    `json:"depth"`
-	Children  []*CommentTreeResponse `json:"children,omitempty"`
+	ID          int64                  `json:"id"`
+	ParentID    *int64                 `json:"parent_id"`
+	ReplyToID   *int64                 `json:"reply_to_id,omitempty"`
+	Content     string                 `json:"content"`
+	ContentHTML string                 `json:"content_html"`
+	Author      string                 `json:"author,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Depth       int                    `json:"depth"`
+	PinOrder    *int64                 `json:"pin_order,omitempty"`
+	Pinned      bool                   `json:"pinned,omitempty"`
+	Highlighted bool                   `json:"highlighted,omitempty"`
+	LockState   string                 `json:"lock_state,omitempty"`
+	LockReason  string                 `json:"lock_reason,omitempty"`
+	MaxDepth    *int64                 `json:"max_depth,omitempty"`
+	IsNew       bool                   `json:"is_new,omitempty"`
+	NewCount    *int                   `json:"new_count,omitempty"`
+	References  []ReferenceResponse    `json:"references,omitempty"`
+	Attachments []AttachmentResponse   `json:"attachments,omitempty"`
+	Children    []*CommentTreeResponse `json:"children,omitempty"`
 }
@@ -0,0 +1,23 @@
+package response
+
+import "time"
+
+type NotificationResponse struct {
+	ID        int64      `json:"id" example:"3"`
+	Kind      string     `json:"kind" example:"reply" enums:"reply,mention"`
+	CommentID int64      `json:"comment_id" example:"12"`
+	Actor     string     `json:"actor,omitempty" example:"bob"`
+	Content   string     `json:"content" example:"@alice agreed"`
+	CreatedAt time.Time  `json:"created_at" example:"2026-02-02T14:31:00Z"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// NotificationsResponse - next_cursor нет на последней странице.
+type NotificationsResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+type MarkNotificationsReadResponse struct {
+	Read int `json:"read" example:"2"`
+}
@@ -0,0 +1,39 @@
+package response
+
+import "time"
+
+// PrivacyComment - коммент автора в выгрузке, content - исходный текст.
+type PrivacyComment struct {
+	ID        int64     `json:"id" example:"12"`
+	ParentID  *int64    `json:"parent_id" example:"1"`
+	ReplyToID *int64    `json:"reply_to_id,omitempty" example:"5"`
+	Content   string    `json:"content" example:"hello"`
+	CreatedAt time.Time `json:"created_at" example:"2026-02-02T14:31:00Z"`
+}
+
+// PrivacyAttachment - comment_id нет у неприкрепленных вложений.
+type PrivacyAttachment struct {
+	AttachmentResponse
+	CommentID *int64 `json:"comment_id" example:"12"`
+}
+
+type PrivacyMute struct {
+	CommentID int64     `json:"comment_id" example:"12"`
+	CreatedAt time.Time `json:"created_at" example:"2026-02-02T14:31:00Z"`
+}
+
+type PrivacyVisit struct {
+	RootID     int64     `json:"root_id" example:"1"`
+	LastSeenID int64     `json:"last_seen_id" example:"42"`
+	SeenAt     time.Time `json:"seen_at" example:"2026-02-02T14:31:00Z"`
+}
+
+type PrivacyRequestResponse struct {
+	ID        int64     `json:"id" example:"7"`
+	Action    string    `json:"action" example:"anonymize" enums:"export,anonymize,delete"`
+	Author    string    `json:"author" example:"alice"`
+	Actor     string    `json:"actor,omitempty" example:"dpo"`
+	Reason    string    `json:"reason" example:"GDPR request #123"`
+	Comments  int       `json:"comments" example:"17"`
+	CreatedAt time.Time `json:"created_at" example:"2026-02-02T14:31:00Z"`
+}
@@ -0,0 +1,29 @@
+package response
+
+import "time"
+
+// ReferenceResponse - ссылка ">>id" на коммент ID. Удаленный коммент недоступен (available=false),
+// остальных полей у него нет.
+type ReferenceResponse struct {
+	ID        int64      `json:"id"`
+	Available bool       `json:"available"`
+	Author    string     `json:"author,omitempty"`
+	Snippet   string     `json:"snippet,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// BacklinkResponse - коммент, ссылающийся на запрошенный, snippet - начало его текста без разметки.
+type BacklinkResponse struct {
+	ID        int64     `json:"id"`
+	ParentID  *int64    `json:"parent_id"`
+	Author    string    `json:"author,omitempty"`
+	Snippet   string    `json:"snippet"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BacklinksResponse struct {
+	Backlinks []BacklinkResponse `json:"backlinks"`
+	Total     int                `json:"total"`
+	Limit     int                `json:"limit"`
+	Offset    int                `json:"offset"`
+}
@@ -0,0 +1,5 @@
+package response
+
+type MarkSeenResponse struct {
+	LastSeenID int64 `json:"last_seen_id" example:"42"`
+}
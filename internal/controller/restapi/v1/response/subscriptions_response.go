@@ -0,0 +1,14 @@
+package response
+
+import "time"
+
+type SubscriptionResponse struct {
+	CommentID  int64     `json:"comment_id" example:"12"`
+	Email      string    `json:"email" example:"alice@example.com"`
+	CreatedAt  time.Time `json:"created_at" example:"2026-02-02T14:31:00Z"`
+	DigestedAt time.Time `json:"digested_at" example:"2026-02-03T14:31:00Z"`
+}
+
+type SubscriptionsResponse struct {
+	Subscriptions []SubscriptionResponse `json:"subscriptions"`
+}
@@ -6,8 +6,11 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func NewCommentRoutes(apiV1Group fiber.Router, c usecase.CommentUseCase, l logger.Interface) {
-	r := &V1{c: c, l: l}
+// NewCommentRoutes - adminToken "" не регистрирует админские маршруты.
+func NewCommentRoutes(apiV1Group fiber.Router, c usecase.CommentUseCase, l logger.Interface, cacheControl, adminToken string) {
+	r := &V1{c: c, l: l, cacheControl: cacheControl}
+
+	apiV1Group.Use(callerUser)
 
 	commentsGroup := apiV1Group.Group("/comments")
 
@@ -17,7 +20,61 @@ func NewCommentRoutes(apiV1Group fiber.Router, c usecase.CommentUseCase, l logge
 		commentsGroup.Get("/", r.getComments)
 		commentsGroup.Delete("/:id", r.deleteCommentTree)
 
+		// Pin / Highlight - модератор или владелец треда, корни закрепляет только модератор
+		commentsGroup.Put("/:id/pin", r.moderatorOrOwner(adminToken, false), r.pin)
+		commentsGroup.Delete("/:id/pin", r.moderatorOrOwner(adminToken, false), r.unpin)
+		commentsGroup.Put("/:id/highlight", r.moderatorOrOwner(adminToken, true), r.highlight)
+		commentsGroup.Delete("/:id/highlight", r.moderatorOrOwner(adminToken, true), r.unhighlight)
+
+		// Lock / Archive - модератор или владелец треда
+		commentsGroup.Put("/:id/lock", r.moderatorOrOwner(adminToken, true), r.lock)
+		commentsGroup.Delete("/:id/lock", r.moderatorOrOwner(adminToken, true), r.unlock)
+
+		// Max Depth
+		commentsGroup.Put("/:id/max-depth", r.setMaxDepth)
+		commentsGroup.Delete("/:id/max-depth", r.resetMaxDepth)
+
+		// Notifications
+		commentsGroup.Put("/:id/mute", r.mute)
+		commentsGroup.Delete("/:id/mute", r.unmute)
+		apiV1Group.Get("/notifications", r.getNotifications)
+		apiV1Group.Post("/notifications/read", r.markNotificationsRead)
+		apiV1Group.Get("/notifications/stream", r.streamNotifications)
+
+		// References
+		commentsGroup.Get("/:id/backlinks", r.getBacklinks)
+
+		// Attachments
+		apiV1Group.Post("/attachments", r.uploadAttachment)
+		apiV1Group.Get("/attachments/:id", r.getAttachment)
+		apiV1Group.Get("/attachments/:id/thumbnail", r.getAttachmentThumbnail)
+
+		// Unread
+		commentsGroup.Post("/:id/seen", r.markSeen)
+
+		// Subscriptions
+		commentsGroup.Put("/:id/subscription", r.subscribe)
+		commentsGroup.Delete("/:id/subscription", r.unsubscribe)
+		apiV1Group.Get("/subscriptions", r.getSubscriptions)
+		apiV1Group.Get("/unsubscribe", r.showUnsubscribe)
+		apiV1Group.Post("/unsubscribe", r.unsubscribeByToken)
+
 		// UI
 		apiV1Group.Get("/ui", r.showUI)
 	}
+
+	if adminToken != "" {
+		adminGroup := apiV1Group.Group("/admin", adminAuth(adminToken))
+
+		// Privacy
+		adminGroup.Get("/authors/:author/export", r.exportAuthor)
+		adminGroup.Post("/authors/:author/erase", r.eraseAuthor)
+
+		// Audit
+		adminGroup.Get("/audit", r.getAuditLog)
+
+		// Export / Import - вся база, поэтому тоже только с токеном
+		apiV1Group.Get("/export", adminAuth(adminToken), r.export)
+		apiV1Group.Post("/import", adminAuth(adminToken), r.importComments)
+	}
 }
@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Mark thread seen
+// @Description X-User has read the whole thread of the root comment: comments up to last_seen_id are no longer is_new for them.
+// @Description The position never moves back
+// @Tags comments
+// @Produce json
+// @Param id path int true "Root comment ID"
+// @Param X-User header string true "Viewer"
+// @Success 200 {object} response.MarkSeenResponse
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/seen [post]
+func (r *V1) markSeen(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	lastSeenID, err := r.c.MarkSeen(ctx.UserContext(), user, int64(id))
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errorResponse(ctx, http.StatusNotFound, "comment not found")
+		}
+		if errors.Is(err, errs.ErrNotRoot) {
+			return errorResponse(ctx, http.StatusBadRequest, "only root comments can be marked seen")
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - markSeen")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response.MarkSeenResponse{LastSeenID: lastSeenID})
+}
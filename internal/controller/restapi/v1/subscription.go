@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/request"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/gofiber/fiber/v2"
+)
+
+// _unsubscribePage - GET только показывает кнопку: почтовые сканеры открывают ссылки из писем
+// и не должны отписывать пользователя сами.
+var _unsubscribePage = template.Must(template.ParseFS(webFiles, "web/unsubscribe.html"))
+
+type unsubscribePage struct {
+	Token   string
+	Done    bool
+	Invalid bool
+}
+
+// @Summary Subscribe to thread
+// @Description X-User gets periodic email digests of new comments in the subtree of the comment.
+// @Description The address replaces the one of X-User's other subscriptions
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param X-User header string true "Subscriber"
+// @Param request body request.SubscribeRequest true "Digest address"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/subscription [put]
+func (r *V1) subscribe(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	var body request.SubscribeRequest
+
+	err = ctx.BodyParser(&body)
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid request body")
+	}
+
+	err = body.Validate()
+	if err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	err = r.c.Subscribe(ctx.UserContext(), user, int64(id), body.Email)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errorResponse(ctx, http.StatusNotFound, "comment not found")
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - subscribe")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Unsubscribe from thread
+// @Description Removes X-User's subscription to the comment; subscriptions to its ancestors still apply
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param X-User header string true "Subscriber"
+// @Success 204
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/comments/{id}/subscription [delete]
+func (r *V1) unsubscribe(ctx *fiber.Ctx) error {
+	id, err := ctx.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return errorResponse(ctx, http.StatusBadRequest, "invalid comment id")
+	}
+
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	err = r.c.Unsubscribe(ctx.UserContext(), user, int64(id))
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - unsubscribe")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Get subscriptions
+// @Description Threads X-User gets digests for
+// @Tags subscriptions
+// @Produce json
+// @Param X-User header string true "Subscriber"
+// @Success 200 {object} response.SubscriptionsResponse
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/subscriptions [get]
+func (r *V1) getSubscriptions(ctx *fiber.Ctx) error {
+	user, ok := requiredUser(ctx)
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, _userRequired)
+	}
+
+	subs, err := r.c.GetSubscriptions(ctx.UserContext(), user)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - getSubscriptions")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	resp := response.SubscriptionsResponse{Subscriptions: make([]response.SubscriptionResponse, 0, len(subs))}
+	for _, s := range subs {
+		resp.Subscriptions = append(resp.Subscriptions, response.SubscriptionResponse{
+			CommentID:  s.CommentID,
+			Email:      s.Email,
+			CreatedAt:  s.CreatedAt,
+			DigestedAt: s.DigestedAt,
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(resp)
+}
+
+// @Summary Unsubscribe page
+// @Description Page with an unsubscribe button for the signed link from a digest email
+// @Tags subscriptions
+// @Produce html
+// @Param token query string true "Token from the email"
+// @Success 200
+// @Router /v1/unsubscribe [get]
+func (r *V1) showUnsubscribe(ctx *fiber.Ctx) error {
+	return r.renderUnsubscribe(ctx, http.StatusOK, unsubscribePage{Token: ctx.Query("token")})
+}
+
+// @Summary Unsubscribe by link
+// @Description Unsubscribes by the signed token from a digest email: from one thread or from all of them.
+// @Description Also serves one-click unsubscribe from mail clients (RFC 8058)
+// @Tags subscriptions
+// @Produce html
+// @Param token query string true "Token from the email"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /v1/unsubscribe [post]
+func (r *V1) unsubscribeByToken(ctx *fiber.Ctx) error {
+	err := r.c.UnsubscribeByToken(ctx.UserContext(), ctx.Query("token"))
+	if err != nil {
+		if errors.Is(err, errs.ErrInvalidToken) {
+			return r.renderUnsubscribe(ctx, http.StatusBadRequest, unsubscribePage{Invalid: true})
+		}
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - unsubscribeByToken")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "storage problems")
+	}
+
+	return r.renderUnsubscribe(ctx, http.StatusOK, unsubscribePage{Done: true})
+}
+
+func (r *V1) renderUnsubscribe(ctx *fiber.Ctx, status int, page unsubscribePage) error {
+	var buf bytes.Buffer
+
+	err := _unsubscribePage.Execute(&buf, page)
+	if err != nil {
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - renderUnsubscribe")
+
+		return errorResponse(ctx, http.StatusInternalServerError, "problems with load page")
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+
+	return ctx.Status(status).Send(buf.Bytes())
+}
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// _userHeader - имя пользователя, от которого идет запрос. Проверка, что он тот, за кого себя выдает,
+// - забота шлюза перед сервисом: сам сервис заголовку доверяет.
+const _userHeader = "X-User"
+
+// optionalUser - имя из X-User, "" - анонимный запрос; ok=false - заголовок есть, но имя недопустимо.
+// Строка копируется: fiber отдает заголовки из буфера, который переиспользуется следующим запросом,
+// а имя может остаться в хранилище (memory) или в фоновой задаче.
+func optionalUser(ctx *fiber.Ctx) (string, bool) {
+	user := strings.Clone(ctx.Get(_userHeader))
+	if user == "" {
+		return "", true
+	}
+
+	return user, entity.ValidUsername(user)
+}
+
+// requiredUser - как optionalUser, но без имени запрос тоже не подходит.
+func requiredUser(ctx *fiber.Ctx) (string, bool) {
+	user, ok := optionalUser(ctx)
+
+	return user, ok && user != ""
+}
+
+// callerUser - имя из X-User попадает в журнал изменений как actor.
+// Недопустимое имя здесь пропускается: его отклоняют сами обработчики.
+func callerUser(ctx *fiber.Ctx) error {
+	if user, ok := optionalUser(ctx); ok && user != "" {
+		ctx.SetUserContext(caller.WithUser(ctx.UserContext(), user))
+	}
+
+	return ctx.Next()
+}
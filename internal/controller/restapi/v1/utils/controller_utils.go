@@ -2,11 +2,16 @@ package utils
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi/v1/response"
 	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
 )
 
+// SnippetLength - сколько символов текста коммента показывается в ссылках на него.
+const SnippetLength = 100
+
 func NullInt64ToPtr(n sql.NullInt64) *int64 {
 	if n.Valid {
 		return &n.Int64
@@ -15,18 +20,43 @@ func NullInt64ToPtr(n sql.NullInt64) *int64 {
 	return nil
 }
 
-func BuildTree(comments []entity.Comment) *response.CommentTreeResponse {
+func NullTimeToPtr(t sql.NullTime) *time.Time {
+	if t.Valid {
+		return &t.Time
+	}
+
+	return nil
+}
+
+// BuildTree - ответы каждого коммента идут в порядке entity.SortTree: сначала закрепленные,
+// затем остальные по childSort, так же, как их отдает хранилище.
+// Блокировка родителя, если она строже, показывается и у ответов.
+func BuildTree(comments []entity.Comment, childSort string) *response.CommentTreeResponse {
+	comments = entity.SortTree(comments, childSort)
+
 	nodeMap := make(map[int64]*response.CommentTreeResponse)
 
 	// для быстрого доступа по ID
 	for _, c := range comments {
 		nodeMap[c.ID] = &response.CommentTreeResponse{
-			ID:        c.ID,
-			ParentID:  NullInt64ToPtr(c.ParentID),
-			Content:   c.Content,
-			CreatedAt: c.CreatedAt,
-			Depth:     c.Depth,
-			Children:  []*response.CommentTreeResponse{},
+			ID:          c.ID,
+			ParentID:    NullInt64ToPtr(c.ParentID),
+			ReplyToID:   NullInt64ToPtr(c.ReplyToID),
+			Content:     c.Content,
+			ContentHTML: markdown.HTML(c.Content),
+			Author:      c.Author,
+			CreatedAt:   c.CreatedAt,
+			Depth:       c.Depth,
+			PinOrder:    NullInt64ToPtr(c.PinOrder),
+			Pinned:      c.PinOrder.Valid,
+			Highlighted: c.Highlighted,
+			LockState:   c.LockState,
+			LockReason:  c.LockReason,
+			MaxDepth:    NullInt64ToPtr(c.MaxDepth),
+			IsNew:       c.IsNew,
+			References:  BuildReferences(c.References),
+			Attachments: BuildAttachments(c.Attachments),
+			Children:    []*response.CommentTreeResponse{},
 		}
 	}
 
@@ -40,6 +70,11 @@ func BuildTree(comments []entity.Comment) *response.CommentTreeResponse {
 			parent, exists := nodeMap[c.ParentID.Int64]
 			if exists {
 				parent.Children = append(parent.Children, node)
+
+				// родитель идет раньше ответов, его блокировка уже унаследована
+				if entity.StrongerLock(parent.LockState, node.LockState) {
+					node.LockState, node.LockReason = parent.LockState, parent.LockReason
+				}
 			} else {
 				if root == nil {
 					root = node
@@ -57,3 +92,52 @@ func BuildTree(comments []entity.Comment) *response.CommentTreeResponse {
 
 	return root
 }
+
+// BuildReferences - nil без ссылок, чтобы references не попадал в ответ.
+func BuildReferences(refs []entity.Reference) []response.ReferenceResponse {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	resp := make([]response.ReferenceResponse, len(refs))
+	for i, ref := range refs {
+		resp[i] = response.ReferenceResponse{ID: ref.TargetID}
+
+		if ref.Target != nil {
+			resp[i].Available = true
+			resp[i].Author = ref.Target.Author
+			resp[i].Snippet = markdown.Snippet(ref.Target.Content, SnippetLength)
+			resp[i].CreatedAt = &ref.Target.CreatedAt
+		}
+	}
+
+	return resp
+}
+
+// BuildAttachments - nil без вложений, чтобы attachments не попадал в ответ.
+func BuildAttachments(attachments []entity.Attachment) []response.AttachmentResponse {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	resp := make([]response.AttachmentResponse, len(attachments))
+	for i, a := range attachments {
+		resp[i] = BuildAttachment(a)
+	}
+
+	return resp
+}
+
+func BuildAttachment(a entity.Attachment) response.AttachmentResponse {
+	return response.AttachmentResponse{
+		ID:           a.ID,
+		Filename:     a.Filename,
+		ContentType:  a.ContentType,
+		Size:         a.Size,
+		Width:        a.Width,
+		Height:       a.Height,
+		URL:          a.URL,
+		ThumbnailURL: a.ThumbnailURL,
+		CreatedAt:    a.CreatedAt,
+	}
+}
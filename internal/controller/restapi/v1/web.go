@@ -8,14 +8,14 @@ import (
 )
 
 var (
-	//go:embed web/index.html
+	//go:embed web/index.html web/unsubscribe.html
 	webFiles embed.FS
 )
 
 func (r *V1) showUI(ctx *fiber.Ctx) error {
 	file, err := webFiles.ReadFile("web/index.html")
 	if err != nil {
-		r.l.Error(err, "restapi - v1 - showUI")
+		r.l.WithContext(ctx.UserContext()).Error(err, "restapi - v1 - showUI")
 
 		return errorResponse(ctx, http.StatusInternalServerError, "problems with load UI")
 	}
@@ -7,4 +7,10 @@ type GetCommentsParams struct {
 	Order    string
 	Limit    int
 	Offset   int
+
+	// ChildSort - порядок ответов на любой глубине, entity.ChildSort*
+	ChildSort string
+
+	// Viewer - для кого отмечать непрочитанное, "" - ни для кого
+	Viewer string
 }
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// ImportComment - одна запись импорта. ID и ParentID - id источника,
+// Line - номер строки (или записи) во входном файле для отчета об ошибках.
+type ImportComment struct {
+	Line      int
+	ID        int64
+	ParentID  *int64
+	Content   string
+	Author    string
+	CreatedAt time.Time
+}
+
+type ImportError struct {
+	Line  int
+	ID    int64
+	Error string
+}
+
+type ImportResult struct {
+	Imported int
+	// IDs - новые id по id источника
+	IDs    map[int64]int64
+	Errors []ImportError
+}
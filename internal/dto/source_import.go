@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// SourceComment - коммент из выгрузки внешней системы (Disqus, WordPress, Reddit).
+// SourceID и ParentSourceID - id источника, пустой ParentSourceID - корень.
+type SourceComment struct {
+	SourceID       string
+	ParentSourceID string
+	Author         string
+	Content        string
+	CreatedAt      time.Time
+	// Skip - причина, по которой запись не загружается (удалена, спам), пустая - загружается.
+	Skip string
+}
+
+type SourceImportIssue struct {
+	SourceID       string
+	ParentSourceID string
+	Reason         string
+}
+
+type SourceImportSummary struct {
+	Source   string
+	Total    int
+	Imported int
+	// AlreadyImported - загружены прошлыми запусками
+	AlreadyImported int
+	// Skipped - записи, которые источник пометил удаленными или которые некорректны сами по себе
+	Skipped []SourceImportIssue
+	// BrokenParents - ответы, чей родитель не найден, пропущен или удален после прошлого импорта
+	BrokenParents []SourceImportIssue
+}
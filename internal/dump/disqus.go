@@ -0,0 +1,93 @@
+package dump
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+)
+
+// dsq:id - атрибут из пространства имен http://disqus.com/disqus-internals
+type disqusPost struct {
+	ID        string `xml:"http://disqus.com/disqus-internals id,attr"`
+	Message   string `xml:"message"`
+	CreatedAt string `xml:"createdAt"`
+	IsDeleted bool   `xml:"isDeleted"`
+	IsSpam    bool   `xml:"isSpam"`
+	Author    struct {
+		Name     string `xml:"name"`
+		Username string `xml:"username"`
+	} `xml:"author"`
+	Parent *struct {
+		ID string `xml:"http://disqus.com/disqus-internals id,attr"`
+	} `xml:"parent"`
+}
+
+// ParseDisqus - XML-экспорт Disqus. Треды (страницы сайта) не переносятся:
+// посты без <parent> становятся корнями, id постов глобальны для всего Disqus.
+func ParseDisqus(r io.Reader) (Dump, error) {
+	d := Dump{Source: FormatDisqus}
+
+	dec := xml.NewDecoder(r)
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Dump{}, fmt.Errorf("dump - ParseDisqus - dec.Token: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "post" {
+			continue
+		}
+
+		var p disqusPost
+
+		err = dec.DecodeElement(&p, &start)
+		if err != nil {
+			return Dump{}, fmt.Errorf("dump - ParseDisqus - dec.DecodeElement: %w", err)
+		}
+
+		d.Comments = append(d.Comments, p.comment())
+	}
+
+	return d, nil
+}
+
+func (p disqusPost) comment() dto.SourceComment {
+	c := dto.SourceComment{
+		SourceID: strings.TrimSpace(p.ID),
+		Author:   strings.TrimSpace(p.Author.Name),
+		Content:  htmlToText(p.Message),
+	}
+
+	if c.Author == "" {
+		c.Author = strings.TrimSpace(p.Author.Username)
+	}
+
+	if p.Parent != nil {
+		c.ParentSourceID = strings.TrimSpace(p.Parent.ID)
+	}
+
+	switch {
+	case p.IsSpam:
+		c.Skip = "marked as spam"
+	case p.IsDeleted:
+		c.Skip = "deleted"
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, strings.TrimSpace(p.CreatedAt))
+	if err != nil && c.Skip == "" {
+		c.Skip = fmt.Sprintf("invalid createdAt %q", p.CreatedAt)
+	}
+	c.CreatedAt = createdAt
+
+	return c
+}
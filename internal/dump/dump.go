@@ -0,0 +1,67 @@
+// Package dump - разбор выгрузок комментариев из внешних систем в dto.SourceComment.
+package dump
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"golang.org/x/net/html"
+)
+
+const (
+	FormatDisqus    = "disqus"
+	FormatWordPress = "wordpress"
+	FormatReddit    = "reddit"
+)
+
+var ErrUnknownFormat = errors.New("format must be one of disqus, wordpress, reddit")
+
+// Dump - разобранная выгрузка. Source - пространство id источника: под ним
+// запоминаются загруженные записи, чтобы повторный импорт их пропустил.
+type Dump struct {
+	Source   string
+	Comments []dto.SourceComment
+}
+
+func Parse(format string, r io.Reader) (Dump, error) {
+	switch format {
+	case FormatDisqus:
+		return ParseDisqus(r)
+	case FormatWordPress:
+		return ParseWordPress(r)
+	case FormatReddit:
+		return ParseReddit(r)
+	default:
+		return Dump{}, ErrUnknownFormat
+	}
+}
+
+// htmlToText - Disqus и WordPress хранят комменты в HTML, сервис - простым текстом:
+// теги выбрасываются, абзацы и <br> становятся переводами строк, сущности раскодируются.
+func htmlToText(s string) string {
+	var b strings.Builder
+
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if string(name) == "br" {
+				b.WriteByte('\n')
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "p", "div", "blockquote", "pre", "li":
+				b.WriteString("\n\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package dump
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+)
+
+const disqusXML = `<?xml version="1.0" encoding="utf-8"?>
+<disqus xmlns="http://disqus.com" xmlns:dsq="http://disqus.com/disqus-internals">
+  <thread dsq:id="900"><link>https://example.com/post</link></thread>
+  <post dsq:id="1">
+    <id>wp_id=5</id>
+    <message><![CDATA[<p>Hello &amp; welcome</p><p>second<br>line</p>]]></message>
+    <createdAt>2012-11-06T16:41:38Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>false</isSpam>
+    <author><name>Alice</name><username>alice</username></author>
+    <thread dsq:id="900"/>
+  </post>
+  <post dsq:id="2">
+    <message><![CDATA[<p>reply</p>]]></message>
+    <createdAt>2012-11-07T10:00:00Z</createdAt>
+    <isDeleted>true</isDeleted>
+    <isSpam>false</isSpam>
+    <author><name></name><username>bob</username></author>
+    <thread dsq:id="900"/>
+    <parent dsq:id="1"/>
+  </post>
+</disqus>`
+
+const wordpressXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+  <wp:base_site_url>https://blog.example.com</wp:base_site_url>
+  <item>
+    <title>Post</title>
+    <wp:comment>
+      <wp:comment_id>10</wp:comment_id>
+      <wp:comment_author><![CDATA[Carol]]></wp:comment_author>
+      <wp:comment_date>2015-01-02 05:04:05</wp:comment_date>
+      <wp:comment_date_gmt>2015-01-02 03:04:05</wp:comment_date_gmt>
+      <wp:comment_content><![CDATA[Nice <em>post</em>]]></wp:comment_content>
+      <wp:comment_approved>1</wp:comment_approved>
+      <wp:comment_type>comment</wp:comment_type>
+      <wp:comment_parent>0</wp:comment_parent>
+    </wp:comment>
+    <wp:comment>
+      <wp:comment_id>11</wp:comment_id>
+      <wp:comment_author><![CDATA[Dave]]></wp:comment_author>
+      <wp:comment_date>2015-01-03 00:00:00</wp:comment_date>
+      <wp:comment_date_gmt>0000-00-00 00:00:00</wp:comment_date_gmt>
+      <wp:comment_content><![CDATA[Thanks]]></wp:comment_content>
+      <wp:comment_approved>1</wp:comment_approved>
+      <wp:comment_type></wp:comment_type>
+      <wp:comment_parent>10</wp:comment_parent>
+    </wp:comment>
+    <wp:comment>
+      <wp:comment_id>12</wp:comment_id>
+      <wp:comment_content><![CDATA[buy now]]></wp:comment_content>
+      <wp:comment_date_gmt>2015-01-03 00:00:00</wp:comment_date_gmt>
+      <wp:comment_approved>spam</wp:comment_approved>
+      <wp:comment_parent>0</wp:comment_parent>
+    </wp:comment>
+  </item>
+</channel>
+</rss>`
+
+const redditJSON = `[
+  {"kind": "Listing", "data": {"children": [{"kind": "t3", "data": {"id": "post1", "title": "Thread"}}]}},
+  {"kind": "Listing", "data": {"children": [
+    {"kind": "t1", "data": {
+      "id": "c1", "parent_id": "t3_post1", "author": "erin", "body": "a &gt; b", "created_utc": 1600000000.5,
+      "replies": {"kind": "Listing", "data": {"children": [
+        {"kind": "t1", "data": {"id": "c2", "parent_id": "t1_c1", "author": "[deleted]", "body": "[removed]", "created_utc": 1600000100, "replies": ""}},
+        {"kind": "more", "data": {"children": ["c3", "c4"], "parent_id": "t1_c1"}}
+      ]}}
+    }}
+  ]}}
+]`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		source string
+		want   []dto.SourceComment
+	}{
+		{
+			name:   "disqus",
+			format: FormatDisqus,
+			input:  disqusXML,
+			source: "disqus",
+			want: []dto.SourceComment{
+				{SourceID: "1", Author: "Alice", Content: "Hello & welcome\n\nsecond\nline", CreatedAt: time.Date(2012, 11, 6, 16, 41, 38, 0, time.UTC)},
+				{SourceID: "2", ParentSourceID: "1", Author: "bob", Content: "reply", CreatedAt: time.Date(2012, 11, 7, 10, 0, 0, 0, time.UTC), Skip: "deleted"},
+			},
+		},
+		{
+			name:   "wordpress",
+			format: FormatWordPress,
+			input:  wordpressXML,
+			source: "wordpress:https://blog.example.com",
+			want: []dto.SourceComment{
+				{SourceID: "10", Author: "Carol", Content: "Nice post", CreatedAt: time.Date(2015, 1, 2, 3, 4, 5, 0, time.UTC)},
+				{SourceID: "11", ParentSourceID: "10", Author: "Dave", Content: "Thanks", CreatedAt: time.Date(2015, 1, 3, 0, 0, 0, 0, time.UTC)},
+				{SourceID: "12", Content: "buy now", CreatedAt: time.Date(2015, 1, 3, 0, 0, 0, 0, time.UTC), Skip: "marked as spam"},
+			},
+		},
+		{
+			name:   "reddit",
+			format: FormatReddit,
+			input:  redditJSON,
+			source: "reddit",
+			want: []dto.SourceComment{
+				{SourceID: "c1", Author: "erin", Content: "a > b", CreatedAt: time.Unix(1600000000, 5e8).UTC()},
+				{SourceID: "c2", ParentSourceID: "c1", Content: "[removed]", CreatedAt: time.Unix(1600000100, 0).UTC(), Skip: "deleted"},
+				{SourceID: "c3", Skip: "not included in the dump"},
+				{SourceID: "c4", Skip: "not included in the dump"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.format, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if d.Source != tt.source {
+				t.Fatalf("source = %q, want %q", d.Source, tt.source)
+			}
+
+			if len(d.Comments) != len(tt.want) {
+				t.Fatalf("got %d comments, want %d: %+v", len(d.Comments), len(tt.want), d.Comments)
+			}
+
+			for i, want := range tt.want {
+				got := d.Comments[i]
+				if !got.CreatedAt.Equal(want.CreatedAt) {
+					t.Fatalf("comment #%d created_at = %v, want %v", i, got.CreatedAt, want.CreatedAt)
+				}
+
+				got.CreatedAt = want.CreatedAt
+				if got != want {
+					t.Fatalf("comment #%d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	_, err := Parse("livejournal", strings.NewReader(""))
+	if err != ErrUnknownFormat {
+		t.Fatalf("err = %v, want ErrUnknownFormat", err)
+	}
+}
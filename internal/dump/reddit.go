@@ -0,0 +1,159 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+)
+
+// redditThing - элемент листинга Reddit API: t1 - коммент, t3 - пост, more - не подгруженные ответы.
+type redditThing struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+type redditListing struct {
+	Children []redditThing `json:"children"`
+}
+
+type redditComment struct {
+	ID         string          `json:"id"`
+	ParentID   string          `json:"parent_id"`
+	Author     string          `json:"author"`
+	Body       string          `json:"body"`
+	CreatedUTC float64         `json:"created_utc"`
+	Replies    json.RawMessage `json:"replies"`
+}
+
+type redditMore struct {
+	Children []string `json:"children"`
+}
+
+// ParseReddit - JSON треда в формате /comments/{id}.json: массив листингов (пост и комменты)
+// или один листинг. Сам пост не переносится, комменты верхнего уровня становятся корнями.
+func ParseReddit(r io.Reader) (Dump, error) {
+	d := Dump{Source: FormatReddit}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return Dump{}, fmt.Errorf("dump - ParseReddit - io.ReadAll: %w", err)
+	}
+
+	var things []redditThing
+
+	err = json.Unmarshal(body, &things)
+	if err != nil {
+		var single redditThing
+
+		err = json.Unmarshal(body, &single)
+		if err != nil {
+			return Dump{}, fmt.Errorf("dump - ParseReddit - json.Unmarshal: %w", err)
+		}
+
+		things = []redditThing{single}
+	}
+
+	for _, t := range things {
+		d.Comments, err = appendRedditThing(d.Comments, t)
+		if err != nil {
+			return Dump{}, fmt.Errorf("dump - ParseReddit - appendRedditThing: %w", err)
+		}
+	}
+
+	return d, nil
+}
+
+// appendRedditThing - обход в глубину, родитель всегда раньше ответов.
+func appendRedditThing(dst []dto.SourceComment, t redditThing) ([]dto.SourceComment, error) {
+	switch t.Kind {
+	case "Listing":
+		var l redditListing
+
+		err := json.Unmarshal(t.Data, &l)
+		if err != nil {
+			return nil, fmt.Errorf("listing: %w", err)
+		}
+
+		for _, child := range l.Children {
+			dst, err = appendRedditThing(dst, child)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case "t1":
+		var rc redditComment
+
+		err := json.Unmarshal(t.Data, &rc)
+		if err != nil {
+			return nil, fmt.Errorf("comment: %w", err)
+		}
+
+		dst = append(dst, rc.comment())
+
+		// у коммента без ответов replies - пустая строка, а не листинг
+		var replies redditThing
+		if json.Unmarshal(rc.Replies, &replies) == nil && replies.Kind != "" {
+			dst, err = appendRedditThing(dst, replies)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case "more":
+		var m redditMore
+
+		err := json.Unmarshal(t.Data, &m)
+		if err != nil {
+			return nil, fmt.Errorf("more: %w", err)
+		}
+
+		// в children бывают и более глубокие ответы, так что родитель у них неизвестен
+		for _, id := range m.Children {
+			dst = append(dst, dto.SourceComment{
+				SourceID: id,
+				Skip:     "not included in the dump",
+			})
+		}
+	}
+
+	return dst, nil
+}
+
+func (rc redditComment) comment() dto.SourceComment {
+	sec, frac := math.Modf(rc.CreatedUTC)
+
+	c := dto.SourceComment{
+		SourceID:       rc.ID,
+		ParentSourceID: redditParent(rc.ParentID),
+		Author:         rc.Author,
+		// Reddit экранирует &, < и > в body
+		Content:   html.UnescapeString(rc.Body),
+		CreatedAt: time.Unix(int64(sec), int64(frac*1e9)).UTC(),
+	}
+
+	if c.Author == "[deleted]" {
+		c.Author = ""
+	}
+
+	switch c.Content {
+	case "[deleted]", "[removed]":
+		c.Skip = "deleted"
+	}
+
+	return c
+}
+
+// redditParent - id родителя без префикса типа; ответ на пост (t3_) - корень.
+func redditParent(fullname string) string {
+	kind, id, ok := strings.Cut(fullname, "_")
+	if !ok || kind != "t1" {
+		return ""
+	}
+
+	return id
+}
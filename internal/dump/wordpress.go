@@ -0,0 +1,114 @@
+package dump
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+)
+
+// _wxrDateLayout - формат wp:comment_date и wp:comment_date_gmt.
+const _wxrDateLayout = "2006-01-02 15:04:05"
+
+// теги без пространства имен совпадают с wp:* любой версии WXR
+type wxrComment struct {
+	ID       string `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	Date     string `xml:"comment_date"`
+	DateGMT  string `xml:"comment_date_gmt"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Type     string `xml:"comment_type"`
+	Parent   string `xml:"comment_parent"`
+}
+
+// ParseWordPress - WXR-экспорт WordPress, берутся только wp:comment из записей.
+// id комментов уникальны лишь в пределах сайта, поэтому Source включает wp:base_site_url.
+func ParseWordPress(r io.Reader) (Dump, error) {
+	d := Dump{Source: FormatWordPress}
+
+	dec := xml.NewDecoder(r)
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Dump{}, fmt.Errorf("dump - ParseWordPress - dec.Token: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "base_site_url":
+			var siteURL string
+
+			err = dec.DecodeElement(&siteURL, &start)
+			if err != nil {
+				return Dump{}, fmt.Errorf("dump - ParseWordPress - dec.DecodeElement: %w", err)
+			}
+
+			if siteURL = strings.TrimSpace(siteURL); siteURL != "" {
+				d.Source = FormatWordPress + ":" + siteURL
+			}
+		case "comment":
+			var wc wxrComment
+
+			err = dec.DecodeElement(&wc, &start)
+			if err != nil {
+				return Dump{}, fmt.Errorf("dump - ParseWordPress - dec.DecodeElement: %w", err)
+			}
+
+			d.Comments = append(d.Comments, wc.comment())
+		}
+	}
+
+	return d, nil
+}
+
+func (wc wxrComment) comment() dto.SourceComment {
+	c := dto.SourceComment{
+		SourceID: strings.TrimSpace(wc.ID),
+		Author:   strings.TrimSpace(wc.Author),
+		Content:  htmlToText(wc.Content),
+	}
+
+	if parent := strings.TrimSpace(wc.Parent); parent != "" && parent != "0" {
+		c.ParentSourceID = parent
+	}
+
+	commentType := strings.TrimSpace(wc.Type)
+
+	switch approved := strings.TrimSpace(wc.Approved); {
+	case commentType == "pingback" || commentType == "trackback":
+		c.Skip = commentType
+	case approved == "spam":
+		c.Skip = "marked as spam"
+	case approved == "trash":
+		c.Skip = "deleted"
+	case approved != "1":
+		c.Skip = "not approved"
+	}
+
+	// у старых записей comment_date_gmt бывает нулевым - тогда берется локальное время
+	date := strings.TrimSpace(wc.DateGMT)
+	if date == "" || strings.HasPrefix(date, "0000") {
+		date = strings.TrimSpace(wc.Date)
+	}
+
+	createdAt, err := time.Parse(_wxrDateLayout, date)
+	if err != nil && c.Skip == "" {
+		c.Skip = fmt.Sprintf("invalid comment_date %q", date)
+	}
+	c.CreatedAt = createdAt
+
+	return c
+}
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	// AttachmentOriginal и AttachmentThumbnail - вариант файла вложения в подписанной ссылке.
+	AttachmentOriginal  = "original"
+	AttachmentThumbnail = "thumbnail"
+)
+
+// Attachment - файл, загруженный в BlobStore под Key.
+type Attachment struct {
+	ID int64 `json:"id"`
+	// CommentID - NULL, пока вложение не прикреплено к комменту или после удаления коммента.
+	CommentID sql.NullInt64 `json:"comment_id"`
+	Uploader  string        `json:"uploader,omitempty"`
+
+	// Key и ThumbnailKey - ключи оригинала и миниатюры в BlobStore, у не-картинок миниатюры нет.
+	Key          string `json:"-"`
+	ThumbnailKey string `json:"-"`
+
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// Width и Height - размеры картинки, 0 - не картинка.
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// AttachedAt - когда вложение прикрепили; прикрепить второй раз, даже после удаления коммента, нельзя.
+	AttachedAt sql.NullTime `json:"-"`
+
+	// URL и ThumbnailURL - подписанные ссылки на файлы, заполняются usecase при чтении.
+	URL          string `json:"-"`
+	ThumbnailURL string `json:"-"`
+}
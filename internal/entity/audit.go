@@ -0,0 +1,193 @@
+package entity
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Действия в журнале изменений. Каждое пишется в той же транзакции, что и само изменение.
+const (
+	AuditCreate      = "create"
+	AuditDelete      = "delete"
+	AuditImport      = "import"
+	AuditPin         = "pin"
+	AuditUnpin       = "unpin"
+	AuditHighlight   = "highlight"
+	AuditUnhighlight = "unhighlight"
+	AuditLock        = "lock"
+	AuditUnlock      = "unlock"
+	AuditArchive     = "archive"
+	AuditMaxDepth    = "max_depth"
+	AuditErase       = "erase"
+)
+
+var _auditActions = []string{
+	AuditCreate, AuditDelete, AuditImport, AuditPin, AuditUnpin, AuditHighlight, AuditUnhighlight,
+	AuditLock, AuditUnlock, AuditArchive, AuditMaxDepth, AuditErase,
+}
+
+func ValidAuditAction(action string) bool {
+	return slices.Contains(_auditActions, action)
+}
+
+// AuditEntry - запись журнала изменений. CommentID - коммент, над которым действие
+// (у delete - корень удаленного поддерева, у import и erase - нет).
+// Actor, IP и RequestID - из запроса, у фоновых задач пустые.
+// Before и After - снимки до и после: коммент (AuditComment), у delete - массив комментов поддерева,
+// у import - новые id, у erase - анонимизированные комменты. Нет снимка - nil.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	CommentID sql.NullInt64   `json:"comment_id"`
+	Actor     string          `json:"actor"`
+	IP        string          `json:"ip"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter - пустые поля не фильтруют, From включительно, To - нет.
+type AuditFilter struct {
+	Actor     string
+	Action    string
+	CommentID int64
+	From      time.Time
+	To        time.Time
+}
+
+// Match - для хранилищ без SQL.
+func (f AuditFilter) Match(e AuditEntry) bool {
+	return (f.Actor == "" || e.Actor == f.Actor) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.CommentID == 0 || e.CommentID.Valid && e.CommentID.Int64 == f.CommentID) &&
+		(f.From.IsZero() || !e.CreatedAt.Before(f.From)) &&
+		(f.To.IsZero() || e.CreatedAt.Before(f.To))
+}
+
+// AuditComment - снимок коммента в журнале: его собственные поля без версий и производных.
+type AuditComment struct {
+	ID          int64     `json:"id"`
+	ParentID    *int64    `json:"parent_id"`
+	ReplyToID   *int64    `json:"reply_to_id,omitempty"`
+	Author      string    `json:"author"`
+	Content     string    `json:"content"`
+	CreatedAt   time.Time `json:"created_at"`
+	PinOrder    *int64    `json:"pin_order,omitempty"`
+	Highlighted bool      `json:"highlighted,omitempty"`
+	LockState   string    `json:"lock_state,omitempty"`
+	LockReason  string    `json:"lock_reason,omitempty"`
+	MaxDepth    *int64    `json:"max_depth,omitempty"`
+}
+
+func NewAuditComment(c Comment) AuditComment {
+	return AuditComment{
+		ID:          c.ID,
+		ParentID:    nullInt64Ptr(c.ParentID),
+		ReplyToID:   nullInt64Ptr(c.ReplyToID),
+		Author:      c.Author,
+		Content:     c.Content,
+		CreatedAt:   c.CreatedAt.UTC(),
+		PinOrder:    nullInt64Ptr(c.PinOrder),
+		Highlighted: c.Highlighted,
+		LockState:   c.LockState,
+		LockReason:  c.LockReason,
+		MaxDepth:    nullInt64Ptr(c.MaxDepth),
+	}
+}
+
+// AuditSnapshot - снимок одного коммента, AuditSnapshots - нескольких (массив).
+func AuditSnapshot(c Comment) json.RawMessage {
+	return marshalSnapshot(NewAuditComment(c))
+}
+
+func AuditSnapshots(comments []Comment) json.RawMessage {
+	snapshots := make([]AuditComment, len(comments))
+	for i, c := range comments {
+		snapshots[i] = NewAuditComment(c)
+	}
+
+	return marshalSnapshot(snapshots)
+}
+
+// AuditIDs - снимок из одних id.
+func AuditIDs(ids []int64) json.RawMessage {
+	if ids == nil {
+		ids = []int64{}
+	}
+
+	return marshalSnapshot(ids)
+}
+
+// AuthorPattern - как автор выглядит в тексте снимка; по нему хранилища ищут снимки для ScrubAuditSnapshot.
+func AuthorPattern(author string) string {
+	return `"author":` + string(marshalSnapshot(author))
+}
+
+// ScrubAuditSnapshot - в снимке у комментов author стираются автор и текст (текст - на content).
+// ok=false - в снимке нечего менять.
+func ScrubAuditSnapshot(raw json.RawMessage, author, content string) (json.RawMessage, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return raw, false
+	}
+
+	scrub := func(c *AuditComment) bool {
+		if c.Author != author {
+			return false
+		}
+
+		c.Author, c.Content = "", content
+
+		return true
+	}
+
+	// id из import и erase не объекты - они не разбираются и не меняются
+	switch raw[0] {
+	case '{':
+		var c AuditComment
+		if json.Unmarshal(raw, &c) != nil || !scrub(&c) {
+			return raw, false
+		}
+
+		return marshalSnapshot(c), true
+	case '[':
+		var cs []AuditComment
+		if json.Unmarshal(raw, &cs) != nil {
+			return raw, false
+		}
+
+		changed := false
+		for i := range cs {
+			if scrub(&cs[i]) {
+				changed = true
+			}
+		}
+
+		if !changed {
+			return raw, false
+		}
+
+		return marshalSnapshot(cs), true
+	default:
+		return raw, false
+	}
+}
+
+// marshalSnapshot - у снимков только простые типы, ошибки кодирования быть не может.
+func marshalSnapshot(v any) json.RawMessage {
+	b, _ := json.Marshal(v)
+
+	return b
+}
+
+func nullInt64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+
+	return &n.Int64
+}
@@ -0,0 +1,115 @@
+package entity
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Порядок ответов одного родителя на любой глубине дерева.
+const (
+	ChildSortOldest = "oldest"
+	ChildSortNewest = "newest"
+	// ChildSortScore - сначала ответы с большим числом потомков, при равенстве - как oldest.
+	ChildSortScore = "score"
+)
+
+// ValidChildSort - пустое и неизвестное значение не подходят.
+func ValidChildSort(childSort string) bool {
+	return childSort == ChildSortOldest || childSort == ChildSortNewest || childSort == ChildSortScore
+}
+
+// SortTree - переставляет комменты поддеревьев в порядок, в котором их отдает хранилище
+// для childSort: обход в глубину, родитель раньше детей, братья - сначала закрепленные
+// по PinOrder, затем остальные по childSort.
+// Корни (комменты без родителя в comments) упорядочиваются так же.
+// Неизвестный childSort - как oldest. comments не меняется.
+func SortTree(comments []Comment, childSort string) []Comment {
+	byID := make(map[int64]int, len(comments))
+	for i, c := range comments {
+		byID[c.ID] = i
+	}
+
+	var roots []int
+
+	children := make(map[int64][]int)
+
+	for i, c := range comments {
+		if _, ok := byID[c.ParentID.Int64]; c.ParentID.Valid && ok {
+			children[c.ParentID.Int64] = append(children[c.ParentID.Int64], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var scores map[int64]int
+	if childSort == ChildSortScore {
+		scores = descendants(comments, byID)
+	}
+
+	compare := func(a, b int) int {
+		ca, cb := comments[a], comments[b]
+
+		if c := ComparePinned(ca, cb); c != 0 {
+			return c
+		}
+
+		switch childSort {
+		case ChildSortNewest:
+			return cmp.Or(cb.CreatedAt.Compare(ca.CreatedAt), cmp.Compare(cb.ID, ca.ID))
+		case ChildSortScore:
+			return cmp.Or(cmp.Compare(scores[cb.ID], scores[ca.ID]), ca.CreatedAt.Compare(cb.CreatedAt), cmp.Compare(ca.ID, cb.ID))
+		default:
+			return cmp.Or(ca.CreatedAt.Compare(cb.CreatedAt), cmp.Compare(ca.ID, cb.ID))
+		}
+	}
+
+	sorted := make([]Comment, 0, len(comments))
+
+	var walk func(siblings []int)
+	walk = func(siblings []int) {
+		slices.SortFunc(siblings, compare)
+
+		for _, i := range siblings {
+			sorted = append(sorted, comments[i])
+			walk(children[comments[i].ID])
+		}
+	}
+
+	walk(roots)
+
+	return sorted
+}
+
+// ComparePinned - закрепленные раньше незакрепленных и между собой по PinOrder;
+// 0 - оба не закреплены или у них одинаковый PinOrder.
+func ComparePinned(a, b Comment) int {
+	switch {
+	case a.PinOrder.Valid && b.PinOrder.Valid:
+		return cmp.Compare(a.PinOrder.Int64, b.PinOrder.Int64)
+	case a.PinOrder.Valid:
+		return -1
+	case b.PinOrder.Valid:
+		return 1
+	}
+
+	return 0
+}
+
+// descendants - число потомков каждого коммента внутри comments.
+func descendants(comments []Comment, byID map[int64]int) map[int64]int {
+	counts := make(map[int64]int, len(comments))
+
+	for _, c := range comments {
+		for p := c.ParentID; p.Valid; {
+			i, ok := byID[p.Int64]
+			if !ok {
+				break
+			}
+
+			counts[p.Int64]++
+			p = comments[i].ParentID
+		}
+	}
+
+	return counts
+}
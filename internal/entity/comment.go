@@ -9,8 +9,54 @@ type Comment struct {
 	ID        int64         `json:"id"`
 	ParentID  sql.NullInt64 `json:"parent_id"`
 	Content   string        `json:"content"`
+	Author    string        `json:"author,omitempty"`
 	CreatedAt time.Time     `json:"created_at"`
 
+	// Version и UpdatedAt меняются при любом изменении в поддереве коммента,
+	// так что у корня это версия всего треда.
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// PinOrder - у закрепленного коммента: место среди закрепленных братьев (по возрастанию),
+	// закрепленные идут раньше остальных. Highlighted - выделенный ответ.
+	PinOrder    sql.NullInt64 `json:"pin_order"`
+	Highlighted bool          `json:"highlighted,omitempty"`
+
+	// LockState и LockReason - собственная блокировка коммента (см. LockLocked, LockArchived),
+	// на ответы она действует так же.
+	LockState  string `json:"lock_state,omitempty"`
+	LockReason string `json:"lock_reason,omitempty"`
+
+	// ReplyToID - коммент, на который на самом деле отвечали, если ответ перенесен
+	// выше из-за лимита глубины. MaxDepth - лимит глубины дерева, задается только у корня.
+	ReplyToID sql.NullInt64 `json:"reply_to_id"`
+	MaxDepth  sql.NullInt64 `json:"max_depth"`
+
 	Depth int     `json:"depth"`
 	Path  []int64 `json:"path,omitempty"`
+
+	// Непрочитанное для зрителя, заполняется только при чтении с ним.
+	// LastSeenID и NewCount - у корня: последний увиденный коммент треда (0 - тред не открывался)
+	// и сколько комментов треда, включая корень, новее него и написаны не зрителем.
+	// IsNew - коммент из этого числа.
+	LastSeenID int64 `json:"-"`
+	NewCount   int   `json:"new_count,omitempty"`
+	IsNew      bool  `json:"is_new,omitempty"`
+
+	// References - ссылки ">>id" из текста, заполняются только при чтении дерева.
+	References []Reference `json:"-"`
+
+	// Attachments - прикрепленные файлы, заполняются только при чтении дерева.
+	Attachments []Attachment `json:"-"`
+}
+
+// Unread - коммент c непрочитан зрителем viewer, видевшим тред до lastSeenID включительно.
+// Свои комменты непрочитанными не бывают.
+func Unread(c Comment, viewer string, lastSeenID int64) bool {
+	return c.ID > lastSeenID && c.Author != viewer
+}
+
+type ReplyCount struct {
+	Children    int
+	Descendants int
 }
@@ -0,0 +1,17 @@
+package entity
+
+// Что делать с ответом глубже лимита: DepthPolicyFlatten - перенести к предку
+// на последнем разрешенном уровне (с ReplyToID), DepthPolicyReject - отказать.
+const (
+	DepthPolicyFlatten = "flatten"
+	DepthPolicyReject  = "reject"
+)
+
+// DepthLimit - лимит глубины дерева корня root: свой, если задан, иначе global. 0 - без лимита.
+func DepthLimit(root Comment, global int) int {
+	if root.MaxDepth.Valid {
+		return int(root.MaxDepth.Int64)
+	}
+
+	return global
+}
@@ -0,0 +1,40 @@
+package entity
+
+// Блокировка действует на коммент и все его поддерево.
+// LockNone - открыт; LockLocked - новые ответы запрещены;
+// LockArchived - только чтение: ни ответов, ни изменений, ни удаления.
+const (
+	LockNone     = ""
+	LockLocked   = "locked"
+	LockArchived = "archived"
+
+	// AutoArchiveReason - причина у деревьев, заархивированных по неактивности.
+	AutoArchiveReason = "archived after inactivity"
+)
+
+var lockRank = map[string]int{
+	LockNone:     0,
+	LockLocked:   1,
+	LockArchived: 2,
+}
+
+func ValidLockState(state string) bool {
+	return state == LockLocked || state == LockArchived
+}
+
+// StrongerLock - true, если блокировка a строже b.
+func StrongerLock(a, b string) bool {
+	return lockRank[a] > lockRank[b]
+}
+
+// EffectiveLock - самая строгая блокировка среди комментов. Предки передаются от корня,
+// так что при равной строгости побеждает верхний, вместе со своей причиной.
+func EffectiveLock(comments ...Comment) (state, reason string) {
+	for _, c := range comments {
+		if StrongerLock(c.LockState, state) {
+			state, reason = c.LockState, c.LockReason
+		}
+	}
+
+	return state, reason
+}
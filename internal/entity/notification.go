@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"database/sql"
+	"regexp"
+	"time"
+)
+
+const (
+	// NotificationReply - ответили на коммент получателя, NotificationMention - упомянули его через @username.
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+
+	// NotificationsUnread, NotificationsRead и NotificationsAll - какие уведомления показывать во входящих.
+	NotificationsUnread = "unread"
+	NotificationsRead   = "read"
+	NotificationsAll    = "all"
+)
+
+type Notification struct {
+	ID        int64  `json:"id"`
+	Recipient string `json:"recipient"`
+	Kind      string `json:"kind"`
+	CommentID int64  `json:"comment_id"`
+	// Actor - автор нового коммента, Content - его текст (заполняется при чтении).
+	Actor     string       `json:"actor"`
+	Content   string       `json:"content"`
+	CreatedAt time.Time    `json:"created_at"`
+	ReadAt    sql.NullTime `json:"read_at"`
+}
+
+// имя начинается и заканчивается буквой, цифрой или "_", чтобы точка или дефис
+// после упоминания в конце предложения не попадали в имя
+const _usernamePattern = `[A-Za-z0-9_](?:[A-Za-z0-9_.-]{0,62}[A-Za-z0-9_])?`
+
+var (
+	_usernameRe = regexp.MustCompile(`^` + _usernamePattern + `$`)
+	// перед "@" - начало строки или символ, который не может быть частью адреса почты
+	_mentionRe = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@-])@(` + _usernamePattern + `)`)
+)
+
+func ValidUsername(name string) bool {
+	return _usernameRe.MatchString(name)
+}
+
+// Mentions - имена из @username в тексте без повторов, в порядке первого упоминания.
+func Mentions(content string) []string {
+	var names []string
+
+	seen := make(map[string]bool)
+	for _, m := range _mentionRe.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+
+	return names
+}
+
+func ValidNotificationStatus(s string) bool {
+	return s == NotificationsUnread || s == NotificationsRead || s == NotificationsAll
+}
@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+const (
+	// ErasureAnonymize - комменты автора остаются в дереве с ErasedContent вместо текста и без автора,
+	// ErasureDelete - удаляются, если под ними нет чужих ответов, иначе - как при ErasureAnonymize.
+	ErasureAnonymize = "anonymize"
+	ErasureDelete    = "delete"
+
+	// PrivacyExport - выгрузка данных автора, в журнале запросов рядом с ErasureAnonymize и ErasureDelete.
+	PrivacyExport = "export"
+
+	// ErasedContent - текст анонимизированного коммента.
+	ErasedContent = "[deleted]"
+)
+
+// AuthorData - все, что сервис хранит о пользователе.
+type AuthorData struct {
+	Author        string         `json:"author"`
+	Comments      []Comment      `json:"comments"`
+	Attachments   []Attachment   `json:"attachments"`
+	Notifications []Notification `json:"notifications"`
+	Subscriptions []Subscription `json:"subscriptions"`
+	Mutes         []Mute         `json:"mutes"`
+	Visits        []Visit        `json:"visits"`
+}
+
+// Mute - ветка CommentID заглушена пользователем.
+type Mute struct {
+	CommentID int64     `json:"comment_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Visit - пользователь видел тред корня RootID до коммента LastSeenID.
+type Visit struct {
+	RootID     int64     `json:"root_id"`
+	LastSeenID int64     `json:"last_seen_id"`
+	SeenAt     time.Time `json:"seen_at"`
+}
+
+// Erasure - что изменило стирание автора: Comments - его анонимизированные комменты,
+// Touched - комменты с повышенной версией (они, их предки и ссылающиеся на них ветки),
+// Attachments - его вложения, отвязанные от комментов; их файлы еще надо удалить.
+type Erasure struct {
+	Comments    []int64
+	Touched     []int64
+	Attachments []Attachment
+}
+
+// PrivacyRequest - запись журнала выгрузок и стираний данных автора.
+// Action - PrivacyExport, ErasureAnonymize или ErasureDelete, Actor - кто выполнил ("" - не назвался),
+// Comments - сколько комментов автора выгружено или стерто.
+type PrivacyRequest struct {
+	ID        int64     `json:"id"`
+	Action    string    `json:"action"`
+	Author    string    `json:"author"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason"`
+	Comments  int       `json:"comments"`
+	CreatedAt time.Time `json:"created_at"`
+}
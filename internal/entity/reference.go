@@ -0,0 +1,25 @@
+package entity
+
+// MaxReferences - сколько ссылок ">>id" одного коммента сохраняются.
+const MaxReferences = 20
+
+// ReferenceTargets - цели ссылок ids (в порядке первого упоминания) коммента sourceID,
+// которые сохраняются: без ссылки на самого себя и не больше MaxReferences.
+func ReferenceTargets(sourceID int64, ids []int64) []int64 {
+	targets := make([]int64, 0, min(len(ids), MaxReferences))
+	for _, id := range ids {
+		if id != sourceID && len(targets) < MaxReferences {
+			targets = append(targets, id)
+		}
+	}
+
+	return targets
+}
+
+// Reference - ссылка ">>id" из коммента SourceID на коммент TargetID.
+type Reference struct {
+	SourceID int64
+	TargetID int64
+	// Target - коммент TargetID без поддерева, nil - он удален.
+	Target *Comment
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"net/mail"
+	"time"
+)
+
+// Subscription - подписка на дайджест новых комментов в поддереве CommentID.
+type Subscription struct {
+	Subscriber string    `json:"subscriber"`
+	CommentID  int64     `json:"comment_id"`
+	Email      string    `json:"email"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// LastCommentID - самый новый коммент, уже попавший в дайджест (или существовавший при подписке),
+	// DigestedAt - когда подписка последний раз попадала в дайджест (или создана).
+	LastCommentID int64     `json:"last_comment_id"`
+	DigestedAt    time.Time `json:"digested_at"`
+}
+
+// ValidEmail - только голый адрес без имени и угловых скобок, он же уходит в заголовок To.
+func ValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+
+	return err == nil && addr.Address == email && len(email) <= 254
+}
+
+// Email - письмо с текстовой и HTML-версией. Headers - дополнительные заголовки (List-Unsubscribe и т.п.).
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
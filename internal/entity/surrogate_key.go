@@ -0,0 +1,16 @@
+package entity
+
+import "strconv"
+
+// Surrogate-Key - метки закэшированных ответов, по которым CDN сбрасывает их точечно.
+const (
+	// RootsSurrogateKey - списки корней: меняются при создании и удалении корня.
+	RootsSurrogateKey = "comment-roots"
+	// SearchSurrogateKey - результаты поиска: меняются при любом создании и удалении.
+	SearchSurrogateKey = "comment-search"
+)
+
+// CommentSurrogateKey - ответы, в которые входит поддерево коммента id.
+func CommentSurrogateKey(id int64) string {
+	return "comment-" + strconv.FormatInt(id, 10)
+}
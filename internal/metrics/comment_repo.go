@@ -0,0 +1,416 @@
+package metrics
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+)
+
+// CommentRepo - декоратор repo.CommentRepo, замеряющий длительность каждого метода.
+type CommentRepo struct {
+	repo repo.CommentRepo
+	m    *Metrics
+}
+
+var _ repo.CommentRepo = (*CommentRepo)(nil)
+
+func NewCommentRepo(r repo.CommentRepo, m *Metrics) *CommentRepo {
+	return &CommentRepo{
+		repo: r,
+		m:    m,
+	}
+}
+
+func (r *CommentRepo) observe(method string, start time.Time, err error) {
+	r.m.repoDuration.WithLabelValues(method, result(err)).Observe(time.Since(start).Seconds())
+}
+
+func (r *CommentRepo) CreateComment(ctx context.Context, parentID, replyToID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	start := time.Now()
+	c, err := r.repo.CreateComment(ctx, parentID, replyToID, author, content, attachmentIDs)
+	r.observe("CreateComment", start, err)
+
+	return c, err
+}
+
+func (r *CommentRepo) CommentExists(ctx context.Context, id int64) error {
+	start := time.Now()
+	err := r.repo.CommentExists(ctx, id)
+	r.observe("CommentExists", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetCommentWithChildren(ctx context.Context, id int64, childSort string) ([]entity.Comment, error) {
+	start := time.Now()
+	comments, err := r.repo.GetCommentWithChildren(ctx, id, childSort)
+	r.observe("GetCommentWithChildren", start, err)
+
+	return comments, err
+}
+
+// DeleteCommentWithChildren - comments_deleted_total растет на размер поддерева, а не на единицу:
+// так считаются и удаления при стирании автора.
+func (r *CommentRepo) DeleteCommentWithChildren(ctx context.Context, id int64) (int, error) {
+	start := time.Now()
+	deleted, err := r.repo.DeleteCommentWithChildren(ctx, id)
+	r.observe("DeleteCommentWithChildren", start, err)
+
+	if err == nil {
+		r.m.commentsDeleted.Add(float64(deleted))
+	}
+
+	return deleted, err
+}
+
+func (r *CommentRepo) SearchComments(ctx context.Context, search string, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	start := time.Now()
+	comments, total, err := r.repo.SearchComments(ctx, search, sortBy, order, limit, offset)
+	r.observe("SearchComments", start, err)
+
+	return comments, total, err
+}
+
+func (r *CommentRepo) GetRootComments(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	start := time.Now()
+	comments, total, err := r.repo.GetRootComments(ctx, viewer, sortBy, order, limit, offset)
+	r.observe("GetRootComments", start, err)
+
+	return comments, total, err
+}
+
+func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64, childSort string) ([]entity.Comment, error) {
+	start := time.Now()
+	comments, err := r.repo.GetTreesForRoots(ctx, rootIDs, childSort)
+	r.observe("GetTreesForRoots", start, err)
+
+	return comments, err
+}
+
+func (r *CommentRepo) GetCommentPath(ctx context.Context, id int64) ([]int64, error) {
+	start := time.Now()
+	path, err := r.repo.GetCommentPath(ctx, id)
+	r.observe("GetCommentPath", start, err)
+
+	return path, err
+}
+
+// ExportComments - длительность всей выгрузки, включая время потребителя.
+func (r *CommentRepo) ExportComments(ctx context.Context, rootID *int64) iter.Seq2[entity.Comment, error] {
+	return func(yield func(entity.Comment, error) bool) {
+		start := time.Now()
+
+		var err error
+
+		for c, e := range r.repo.ExportComments(ctx, rootID) {
+			if e != nil {
+				err = e
+			}
+
+			if !yield(c, e) {
+				break
+			}
+		}
+
+		r.observe("ExportComments", start, err)
+	}
+}
+
+func (r *CommentRepo) ImportComments(ctx context.Context, comments []entity.Comment) ([]int64, error) {
+	start := time.Now()
+	ids, err := r.repo.ImportComments(ctx, comments)
+	r.observe("ImportComments", start, err)
+
+	return ids, err
+}
+
+func (r *CommentRepo) CreateFromSource(ctx context.Context, source, sourceID string, c entity.Comment) (entity.Comment, error) {
+	start := time.Now()
+	c, err := r.repo.CreateFromSource(ctx, source, sourceID, c)
+	r.observe("CreateFromSource", start, err)
+
+	return c, err
+}
+
+func (r *CommentRepo) GetSourceMapping(ctx context.Context, source string, sourceIDs []string) (map[string]int64, error) {
+	start := time.Now()
+	mapping, err := r.repo.GetSourceMapping(ctx, source, sourceIDs)
+	r.observe("GetSourceMapping", start, err)
+
+	return mapping, err
+}
+
+func (r *CommentRepo) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	start := time.Now()
+	comments, err := r.repo.GetCommentsByIDs(ctx, ids)
+	r.observe("GetCommentsByIDs", start, err)
+
+	return comments, err
+}
+
+func (r *CommentRepo) GetChildren(ctx context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error) {
+	start := time.Now()
+	children, err := r.repo.GetChildren(ctx, parentIDs, sortBy, order, limit, offset)
+	r.observe("GetChildren", start, err)
+
+	return children, err
+}
+
+func (r *CommentRepo) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	start := time.Now()
+	counts, err := r.repo.CountReplies(ctx, ids)
+	r.observe("CountReplies", start, err)
+
+	return counts, err
+}
+
+func (r *CommentRepo) SetPinOrder(ctx context.Context, id int64, order *int64) error {
+	start := time.Now()
+	err := r.repo.SetPinOrder(ctx, id, order)
+	r.observe("SetPinOrder", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	start := time.Now()
+	err := r.repo.SetHighlighted(ctx, id, highlighted)
+	r.observe("SetHighlighted", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) SetLock(ctx context.Context, id int64, state, reason string) error {
+	start := time.Now()
+	err := r.repo.SetLock(ctx, id, state, reason)
+	r.observe("SetLock", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	start := time.Now()
+	err := r.repo.SetMaxDepth(ctx, id, maxDepth)
+	r.observe("SetMaxDepth", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) ArchiveInactive(ctx context.Context, before time.Time, reason string) ([]int64, error) {
+	start := time.Now()
+	ids, err := r.repo.ArchiveInactive(ctx, before, reason)
+	r.observe("ArchiveInactive", start, err)
+
+	return ids, err
+}
+
+func (r *CommentRepo) CreateNotifications(ctx context.Context, ns []entity.Notification) ([]entity.Notification, error) {
+	start := time.Now()
+	created, err := r.repo.CreateNotifications(ctx, ns)
+	r.observe("CreateNotifications", start, err)
+
+	return created, err
+}
+
+func (r *CommentRepo) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	start := time.Now()
+	ns, err := r.repo.GetNotifications(ctx, recipient, status, beforeID, limit)
+	r.observe("GetNotifications", start, err)
+
+	return ns, err
+}
+
+func (r *CommentRepo) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	start := time.Now()
+	read, err := r.repo.MarkNotificationsRead(ctx, recipient, ids)
+	r.observe("MarkNotificationsRead", start, err)
+
+	return read, err
+}
+
+func (r *CommentRepo) SetMute(ctx context.Context, user string, id int64, muted bool) error {
+	start := time.Now()
+	err := r.repo.SetMute(ctx, user, id, muted)
+	r.observe("SetMute", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetMutedUsers(ctx context.Context, ids []int64, users []string) ([]string, error) {
+	start := time.Now()
+	muted, err := r.repo.GetMutedUsers(ctx, ids, users)
+	r.observe("GetMutedUsers", start, err)
+
+	return muted, err
+}
+
+func (r *CommentRepo) Subscribe(ctx context.Context, subscriber string, id int64, email string) error {
+	start := time.Now()
+	err := r.repo.Subscribe(ctx, subscriber, id, email)
+	r.observe("Subscribe", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) Unsubscribe(ctx context.Context, subscriber string, id int64) error {
+	start := time.Now()
+	err := r.repo.Unsubscribe(ctx, subscriber, id)
+	r.observe("Unsubscribe", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetSubscriptions(ctx context.Context, subscriber string) ([]entity.Subscription, error) {
+	start := time.Now()
+	subs, err := r.repo.GetSubscriptions(ctx, subscriber)
+	r.observe("GetSubscriptions", start, err)
+
+	return subs, err
+}
+
+func (r *CommentRepo) GetDueSubscribers(ctx context.Context, before time.Time, after string, limit int) ([]string, error) {
+	start := time.Now()
+	subscribers, err := r.repo.GetDueSubscribers(ctx, before, after, limit)
+	r.observe("GetDueSubscribers", start, err)
+
+	return subscribers, err
+}
+
+func (r *CommentRepo) MarkDigested(ctx context.Context, subscriber string, lastIDs map[int64]int64, at time.Time) error {
+	start := time.Now()
+	err := r.repo.MarkDigested(ctx, subscriber, lastIDs, at)
+	r.observe("MarkDigested", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	start := time.Now()
+	lastSeenID, err := r.repo.MarkSeen(ctx, viewer, rootID)
+	r.observe("MarkSeen", start, err)
+
+	return lastSeenID, err
+}
+
+func (r *CommentRepo) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	start := time.Now()
+	lastSeen, err := r.repo.GetLastSeen(ctx, viewer, rootIDs)
+	r.observe("GetLastSeen", start, err)
+
+	return lastSeen, err
+}
+
+func (r *CommentRepo) CreateReferences(ctx context.Context, sourceID int64, targetIDs []int64) error {
+	start := time.Now()
+	err := r.repo.CreateReferences(ctx, sourceID, targetIDs)
+	r.observe("CreateReferences", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetReferences(ctx context.Context, sourceIDs []int64) (map[int64][]entity.Reference, error) {
+	start := time.Now()
+	refs, err := r.repo.GetReferences(ctx, sourceIDs)
+	r.observe("GetReferences", start, err)
+
+	return refs, err
+}
+
+func (r *CommentRepo) GetBacklinks(ctx context.Context, targetID int64, limit, offset int) ([]entity.Comment, int, error) {
+	start := time.Now()
+	comments, total, err := r.repo.GetBacklinks(ctx, targetID, limit, offset)
+	r.observe("GetBacklinks", start, err)
+
+	return comments, total, err
+}
+
+func (r *CommentRepo) GetReferrers(ctx context.Context, targetIDs []int64) ([]int64, error) {
+	start := time.Now()
+	ids, err := r.repo.GetReferrers(ctx, targetIDs)
+	r.observe("GetReferrers", start, err)
+
+	return ids, err
+}
+
+func (r *CommentRepo) CreateAttachment(ctx context.Context, a entity.Attachment) (entity.Attachment, error) {
+	start := time.Now()
+	created, err := r.repo.CreateAttachment(ctx, a)
+	r.observe("CreateAttachment", start, err)
+
+	return created, err
+}
+
+func (r *CommentRepo) AttachAttachments(ctx context.Context, commentID int64, uploader string, ids []int64) (int, error) {
+	start := time.Now()
+	n, err := r.repo.AttachAttachments(ctx, commentID, uploader, ids)
+	r.observe("AttachAttachments", start, err)
+
+	return n, err
+}
+
+func (r *CommentRepo) GetAttachment(ctx context.Context, id int64) (entity.Attachment, error) {
+	start := time.Now()
+	a, err := r.repo.GetAttachment(ctx, id)
+	r.observe("GetAttachment", start, err)
+
+	return a, err
+}
+
+func (r *CommentRepo) GetAttachments(ctx context.Context, commentIDs []int64) (map[int64][]entity.Attachment, error) {
+	start := time.Now()
+	attachments, err := r.repo.GetAttachments(ctx, commentIDs)
+	r.observe("GetAttachments", start, err)
+
+	return attachments, err
+}
+
+func (r *CommentRepo) GetOrphanAttachments(ctx context.Context, uploadedBefore time.Time, limit int) ([]entity.Attachment, error) {
+	start := time.Now()
+	orphans, err := r.repo.GetOrphanAttachments(ctx, uploadedBefore, limit)
+	r.observe("GetOrphanAttachments", start, err)
+
+	return orphans, err
+}
+
+func (r *CommentRepo) DeleteAttachments(ctx context.Context, ids []int64) error {
+	start := time.Now()
+	err := r.repo.DeleteAttachments(ctx, ids)
+	r.observe("DeleteAttachments", start, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetAuthorData(ctx context.Context, author string) (entity.AuthorData, error) {
+	start := time.Now()
+	data, err := r.repo.GetAuthorData(ctx, author)
+	r.observe("GetAuthorData", start, err)
+
+	return data, err
+}
+
+func (r *CommentRepo) EraseAuthor(ctx context.Context, author, content string) (entity.Erasure, error) {
+	start := time.Now()
+	erasure, err := r.repo.EraseAuthor(ctx, author, content)
+	r.observe("EraseAuthor", start, err)
+
+	return erasure, err
+}
+
+func (r *CommentRepo) LogPrivacyRequest(ctx context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error) {
+	start := time.Now()
+	logged, err := r.repo.LogPrivacyRequest(ctx, p)
+	r.observe("LogPrivacyRequest", start, err)
+
+	return logged, err
+}
+
+func (r *CommentRepo) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	start := time.Now()
+	entries, err := r.repo.GetAuditLog(ctx, f, beforeID, limit)
+	r.observe("GetAuditLog", start, err)
+
+	return entries, err
+}
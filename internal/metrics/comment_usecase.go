@@ -0,0 +1,244 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+)
+
+// CommentUseCase - декоратор usecase.CommentUseCase, считающий доменные метрики.
+type CommentUseCase struct {
+	uc usecase.CommentUseCase
+	m  *Metrics
+}
+
+var _ usecase.CommentUseCase = (*CommentUseCase)(nil)
+
+func NewCommentUseCase(uc usecase.CommentUseCase, m *Metrics) *CommentUseCase {
+	return &CommentUseCase{
+		uc: uc,
+		m:  m,
+	}
+}
+
+func (u *CommentUseCase) CreateComment(ctx context.Context, parentID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	c, err := u.uc.CreateComment(ctx, parentID, author, content, attachmentIDs)
+	if err == nil {
+		u.m.commentsCreated.Inc()
+	}
+
+	return c, err
+}
+
+// DeleteCommentWithChildren - удаленные комменты считает CommentRepo: размер поддерева знает только хранилище.
+func (u *CommentUseCase) DeleteCommentWithChildren(ctx context.Context, id int64) error {
+	return u.uc.DeleteCommentWithChildren(ctx, id)
+}
+
+func (u *CommentUseCase) GetComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error) {
+	result, err := u.uc.GetComments(ctx, params)
+	if err != nil {
+		return result, err
+	}
+
+	if params.Search != "" {
+		u.m.searchQueries.Inc()
+
+		return result, nil
+	}
+
+	// размер каждого дерева - количество комментов с тем же корнем
+	sizes := make(map[int64]int)
+	for _, c := range result.Comments {
+		if c.Depth == 0 {
+			sizes[c.ID]++
+		} else if len(c.Path) > 0 {
+			sizes[c.Path[0]]++
+		}
+	}
+
+	for _, size := range sizes {
+		u.m.treeSize.Observe(float64(size))
+	}
+
+	return result, nil
+}
+
+func (u *CommentUseCase) ExportComments(ctx context.Context, parentID *int64) (iter.Seq2[entity.Comment, error], error) {
+	return u.uc.ExportComments(ctx, parentID)
+}
+
+func (u *CommentUseCase) ImportComments(ctx context.Context, comments []dto.ImportComment) (dto.ImportResult, error) {
+	result, err := u.uc.ImportComments(ctx, comments)
+	if err == nil {
+		u.m.commentsCreated.Add(float64(result.Imported))
+	}
+
+	return result, err
+}
+
+func (u *CommentUseCase) GetRootComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error) {
+	return u.uc.GetRootComments(ctx, params)
+}
+
+func (u *CommentUseCase) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	return u.uc.GetCommentsByIDs(ctx, ids)
+}
+
+func (u *CommentUseCase) GetChildren(ctx context.Context, parentIDs []int64, params dto.GetCommentsParams) (map[int64][]entity.Comment, error) {
+	return u.uc.GetChildren(ctx, parentIDs, params)
+}
+
+func (u *CommentUseCase) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	return u.uc.CountReplies(ctx, ids)
+}
+
+func (u *CommentUseCase) GetReferences(ctx context.Context, ids []int64) (map[int64][]entity.Reference, error) {
+	return u.uc.GetReferences(ctx, ids)
+}
+
+func (u *CommentUseCase) GetAttachments(ctx context.Context, ids []int64) (map[int64][]entity.Attachment, error) {
+	return u.uc.GetAttachments(ctx, ids)
+}
+
+func (u *CommentUseCase) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	return u.uc.GetLastSeen(ctx, viewer, rootIDs)
+}
+
+func (u *CommentUseCase) GetThreadRoot(ctx context.Context, id int64) (entity.Comment, error) {
+	return u.uc.GetThreadRoot(ctx, id)
+}
+
+func (u *CommentUseCase) PinComment(ctx context.Context, id int64, order int64) error {
+	return u.uc.PinComment(ctx, id, order)
+}
+
+func (u *CommentUseCase) UnpinComment(ctx context.Context, id int64) error {
+	return u.uc.UnpinComment(ctx, id)
+}
+
+func (u *CommentUseCase) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	return u.uc.SetHighlighted(ctx, id, highlighted)
+}
+
+func (u *CommentUseCase) LockComment(ctx context.Context, id int64, state, reason string) error {
+	return u.uc.LockComment(ctx, id, state, reason)
+}
+
+func (u *CommentUseCase) UnlockComment(ctx context.Context, id int64) error {
+	return u.uc.UnlockComment(ctx, id)
+}
+
+func (u *CommentUseCase) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	return u.uc.SetMaxDepth(ctx, id, maxDepth)
+}
+
+func (u *CommentUseCase) ArchiveInactive(ctx context.Context, inactiveFor time.Duration) (int, error) {
+	n, err := u.uc.ArchiveInactive(ctx, inactiveFor)
+	u.m.treesArchived.Add(float64(n))
+
+	return n, err
+}
+
+func (u *CommentUseCase) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	return u.uc.GetNotifications(ctx, recipient, status, beforeID, limit)
+}
+
+func (u *CommentUseCase) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	return u.uc.MarkNotificationsRead(ctx, recipient, ids)
+}
+
+func (u *CommentUseCase) MuteThread(ctx context.Context, user string, id int64) error {
+	return u.uc.MuteThread(ctx, user, id)
+}
+
+func (u *CommentUseCase) UnmuteThread(ctx context.Context, user string, id int64) error {
+	return u.uc.UnmuteThread(ctx, user, id)
+}
+
+func (u *CommentUseCase) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	return u.uc.MarkSeen(ctx, viewer, rootID)
+}
+
+func (u *CommentUseCase) GetBacklinks(ctx context.Context, id int64, limit, offset int) ([]entity.Comment, int, error) {
+	return u.uc.GetBacklinks(ctx, id, limit, offset)
+}
+
+func (u *CommentUseCase) SubscribeNotifications(ctx context.Context, recipient string) (<-chan entity.Notification, func()) {
+	ch, unsubscribe := u.uc.SubscribeNotifications(ctx, recipient)
+	u.m.notificationStreams.Inc()
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() {
+			unsubscribe()
+			u.m.notificationStreams.Dec()
+		})
+	}
+}
+
+func (u *CommentUseCase) Subscribe(ctx context.Context, user string, id int64, email string) error {
+	return u.uc.Subscribe(ctx, user, id, email)
+}
+
+func (u *CommentUseCase) Unsubscribe(ctx context.Context, user string, id int64) error {
+	return u.uc.Unsubscribe(ctx, user, id)
+}
+
+func (u *CommentUseCase) GetSubscriptions(ctx context.Context, user string) ([]entity.Subscription, error) {
+	return u.uc.GetSubscriptions(ctx, user)
+}
+
+func (u *CommentUseCase) UnsubscribeByToken(ctx context.Context, token string) error {
+	return u.uc.UnsubscribeByToken(ctx, token)
+}
+
+func (u *CommentUseCase) SendDigests(ctx context.Context) (int, error) {
+	n, err := u.uc.SendDigests(ctx)
+	u.m.digestsSent.Add(float64(n))
+	if err != nil {
+		u.m.digestErrors.Inc()
+	}
+
+	return n, err
+}
+
+func (u *CommentUseCase) UploadAttachment(ctx context.Context, uploader, filename string, r io.Reader) (entity.Attachment, error) {
+	a, err := u.uc.UploadAttachment(ctx, uploader, filename, r)
+	if err == nil {
+		u.m.attachmentsUploaded.Inc()
+		u.m.attachmentBytes.Add(float64(a.Size))
+	}
+
+	return a, err
+}
+
+func (u *CommentUseCase) OpenAttachment(ctx context.Context, id int64, variant string, expires int64, sig string) (entity.Attachment, io.ReadCloser, error) {
+	return u.uc.OpenAttachment(ctx, id, variant, expires, sig)
+}
+
+func (u *CommentUseCase) CollectAttachments(ctx context.Context) (int, error) {
+	n, err := u.uc.CollectAttachments(ctx)
+	u.m.attachmentsCollected.Add(float64(n))
+
+	return n, err
+}
+
+func (u *CommentUseCase) ExportAuthor(ctx context.Context, author, actor, reason string) (entity.AuthorData, error) {
+	return u.uc.ExportAuthor(ctx, author, actor, reason)
+}
+
+func (u *CommentUseCase) EraseAuthor(ctx context.Context, author, mode, actor, reason string) (entity.PrivacyRequest, error) {
+	return u.uc.EraseAuthor(ctx, author, mode, actor, reason)
+}
+
+func (u *CommentUseCase) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	return u.uc.GetAuditLog(ctx, f, beforeID, limit)
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"errors"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const _namespace = "comment_tree"
+
+type Metrics struct {
+	repoDuration *prometheus.HistogramVec
+
+	commentsCreated prometheus.Counter
+	commentsDeleted prometheus.Counter
+	searchQueries   prometheus.Counter
+	treeSize        prometheus.Histogram
+	treesArchived   prometheus.Counter
+
+	notificationStreams prometheus.Gauge
+	digestsSent         prometheus.Counter
+	digestErrors        prometheus.Counter
+
+	attachmentsUploaded  prometheus.Counter
+	attachmentBytes      prometheus.Counter
+	attachmentsCollected prometheus.Counter
+}
+
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Subsystem: "repo",
+			Name:      "query_duration_seconds",
+			Help:      "CommentRepo method duration by method and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "result"}),
+		commentsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "comments_created_total",
+			Help:      "Total number of created comments.",
+		}),
+		commentsDeleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "comments_deleted_total",
+			Help:      "Total number of deleted comments, replies removed with their parent included.",
+		}),
+		searchQueries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "search_queries_total",
+			Help:      "Total number of full-text search queries.",
+		}),
+		treeSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "tree_size_comments",
+			Help:      "Number of comments in each tree returned to clients.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		treesArchived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "trees_archived_total",
+			Help:      "Total number of trees archived for inactivity.",
+		}),
+		notificationStreams: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Name:      "notification_streams",
+			Help:      "Number of open notification streams.",
+		}),
+		digestsSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "digests_sent_total",
+			Help:      "Total number of sent digest emails.",
+		}),
+		digestErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "digest_runs_failed_total",
+			Help:      "Total number of digest runs where at least one subscriber failed.",
+		}),
+		attachmentsUploaded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "attachments_uploaded_total",
+			Help:      "Total number of uploaded attachments.",
+		}),
+		attachmentBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "attachment_uploaded_bytes_total",
+			Help:      "Total size of uploaded attachments (originals only).",
+		}),
+		attachmentsCollected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "attachments_collected_total",
+			Help:      "Total number of orphaned attachments removed by the background collector.",
+		}),
+	}
+
+	reg.MustRegister(m.repoDuration, m.commentsCreated, m.commentsDeleted, m.searchQueries, m.treeSize, m.treesArchived,
+		m.notificationStreams, m.digestsSent, m.digestErrors, m.attachmentsUploaded, m.attachmentBytes, m.attachmentsCollected)
+
+	return m
+}
+
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, errs.ErrRecordNotFound):
+		return "not_found"
+	default:
+		return "error"
+	}
+}
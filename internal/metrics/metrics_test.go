@@ -0,0 +1,90 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/metrics"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	reg := prometheus.NewRegistry()
+	m := metrics.New(reg)
+
+	// обвязка как в app.Run
+	r := metrics.NewCommentRepo(memory.New(), m)
+	l := logger.New("error")
+	uc := metrics.NewCommentUseCase(comment.New(r, webapi.NewCDN("", "", "", "", time.Second, l), l), m)
+
+	root, err := uc.CreateComment(ctx, nil, "", "root", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	a, err := uc.CreateComment(ctx, &root.ID, "", "a", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	a1, err := uc.CreateComment(ctx, &a.ID, "", "a1", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	if _, err = uc.CreateComment(ctx, &a1.ID, "", "a2", nil); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	// поддерево a - три коммента, счетчик растет на три
+	if err = uc.DeleteCommentWithChildren(ctx, a.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+	if err = uc.DeleteCommentWithChildren(ctx, a.ID); err == nil {
+		t.Fatal("second DeleteCommentWithChildren succeeded")
+	}
+
+	srv := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read /metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		"comment_tree_comments_created_total 4",
+		"comment_tree_comments_deleted_total 3",
+		"comment_tree_search_queries_total 0",
+		"comment_tree_trees_archived_total 0",
+		"comment_tree_notification_streams 0",
+		"comment_tree_digests_sent_total 0",
+		"comment_tree_digest_runs_failed_total 0",
+		"comment_tree_attachments_uploaded_total 0",
+		"comment_tree_attachment_uploaded_bytes_total 0",
+		"comment_tree_attachments_collected_total 0",
+		"# TYPE comment_tree_tree_size_comments histogram",
+		`comment_tree_repo_query_duration_seconds_count{method="CreateComment",result="ok"} 4`,
+		`comment_tree_repo_query_duration_seconds_count{method="DeleteCommentWithChildren",result="ok"} 1`,
+		`comment_tree_repo_query_duration_seconds_count{method="GetCommentPath",result="not_found"} 1`,
+	} {
+		if !strings.Contains(string(body), want+"\n") {
+			t.Errorf("/metrics has no %q", want)
+		}
+	}
+}
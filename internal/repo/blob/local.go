@@ -0,0 +1,108 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// ключи генерирует usecase, но в путь файла попадает только то, что не выйдет за пределы dir
+var _keyRe = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]{0,254}$`)
+
+// Local - файлы в каталоге dir, раскладываются по подкаталогам из первых двух символов ключа.
+// Файл появляется целиком (запись во временный и rename).
+type Local struct {
+	dir string
+}
+
+var _ repo.BlobStore = (*Local)(nil)
+
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (s *Local) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("Local - Put - s.path: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return fmt.Errorf("Local - Put - os.MkdirAll: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("Local - Put - os.CreateTemp: %w", err)
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck // после Rename файла уже нет
+
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("Local - Put - io.Copy: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("Local - Put - f.Close: %w", err)
+	}
+
+	err = os.Rename(f.Name(), name)
+	if err != nil {
+		return fmt.Errorf("Local - Put - os.Rename: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, fmt.Errorf("Local - Get - s.path: %w", err)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("Local - Get: %w", errs.ErrRecordNotFound)
+		}
+
+		return nil, fmt.Errorf("Local - Get - os.Open: %w", err)
+	}
+
+	return f, nil
+}
+
+func (s *Local) Delete(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		name, err := s.path(key)
+		if err != nil {
+			return fmt.Errorf("Local - Delete - s.path: %w", err)
+		}
+
+		err = os.Remove(name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("Local - Delete - os.Remove: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Local) path(key string) (string, error) {
+	if !_keyRe.MatchString(key) || len(key) < 2 {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+
+	return filepath.Join(s.dir, key[:2], key), nil
+}
@@ -0,0 +1,238 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+const (
+	_s3Algorithm = "AWS4-HMAC-SHA256"
+	_s3Service   = "s3"
+	// тело не хешируется: файл читается потоком один раз
+	_s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	_s3EmptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3 - S3-совместимое хранилище (AWS, MinIO и т.п.): path-style адреса endpoint/bucket/key
+// и подпись AWS Signature V4. Без accessKey запросы не подписываются.
+type S3 struct {
+	client    *http.Client
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+}
+
+var _ repo.BlobStore = (*S3)(nil)
+
+func NewS3(endpoint, region, bucket, accessKey, secretKey string) *S3 {
+	return &S3{
+		client:    &http.Client{},
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+	}
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return fmt.Errorf("S3 - Put - s.request: %w", err)
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req, _s3UnsignedPayload)
+	if err != nil {
+		return fmt.Errorf("S3 - Put - s.do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("S3 - Put: %w", responseError(resp))
+	}
+
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("S3 - Get - s.request: %w", err)
+	}
+
+	resp, err := s.do(req, _s3EmptyPayload)
+	if err != nil {
+		return nil, fmt.Errorf("S3 - Get - s.do: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 - Get: %w", errs.ErrRecordNotFound)
+	default:
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("S3 - Get: %w", responseError(resp))
+	}
+}
+
+// Delete - по запросу на ключ: DeleteObjects требует Content-MD5, а ключей у вложения не больше двух.
+func (s *S3) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		req, err := s.request(ctx, http.MethodDelete, key, nil)
+		if err != nil {
+			return fmt.Errorf("S3 - Delete - s.request: %w", err)
+		}
+
+		resp, err := s.do(req, _s3EmptyPayload)
+		if err != nil {
+			return fmt.Errorf("S3 - Delete - s.do: %w", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("S3 - Delete: %w", responseError(resp))
+		}
+	}
+
+	return nil
+}
+
+func (s *S3) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	u := s.endpoint + "/" + awsEscape(s.bucket) + "/" + awsEscape(key)
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	return req, nil
+}
+
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if s.accessKey != "" {
+		s.sign(req, payloadHash, time.Now())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s.client.Do: %w", err)
+	}
+
+	return resp, nil
+}
+
+// sign - AWS Signature V4 в заголовке Authorization, подписываются host и все заголовки запроса.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/" + _s3Service + "/aws4_request"
+	stringToSign := _s3Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, _s3Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		_s3Algorithm, s.accessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(q url.Values) string {
+	pairs := make([]string, 0, len(q))
+	for name, values := range q {
+		for _, v := range values {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape - URI-кодирование по правилам SigV4: не кодируются только A-Z, a-z, 0-9, "-", "_", ".", "~".
+func awsEscape(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,582 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/cache"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	_treeKeyPrefix = "tree:"
+
+	// _loadTimeout - таймаут общей загрузки поддеревьев из хранилища, см. load.
+	_loadTimeout = 10 * time.Second
+)
+
+// CommentRepo - read-through кэш поддеревьев поверх repo.CommentRepo.
+//
+// Ключ tree:<id> хранит поддерево коммента id в том же виде, что возвращает
+// GetCommentWithChildren с entity.ChildSortOldest, другие порядки получаются из него
+// через entity.SortTree. При создании ответа сбрасываются поддеревья всех предков
+// родителя, при удалении - еще и всех удаленных потомков. Одновременные промахи
+// по одному ключу схлопываются в один запрос к хранилищу, который не зависит от отмены
+// запроса, начавшего загрузку. Запись, прочитанная из базы
+// до инвалидации и сохраненная после нее, живет не дольше ttl - как и запись,
+// которую не удалось сбросить из-за ошибки кэша (изменение в хранилище при этом не откатывается).
+type CommentRepo struct {
+	repo  repo.CommentRepo
+	cache cache.Interface
+	ttl   time.Duration
+	group singleflight.Group
+
+	requests      *prometheus.CounterVec
+	invalidations *prometheus.CounterVec
+}
+
+var _ repo.CommentRepo = (*CommentRepo)(nil)
+
+func New(r repo.CommentRepo, c cache.Interface, ttl time.Duration, reg prometheus.Registerer) *CommentRepo {
+	cr := &CommentRepo{
+		repo:  r,
+		cache: c,
+		ttl:   ttl,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "comment_tree",
+			Subsystem: "cache",
+			Name:      "requests_total",
+			Help:      "Comment tree cache lookups by result (hit, miss, error).",
+		}, []string{"result"}),
+		invalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "comment_tree",
+			Subsystem: "cache",
+			Name:      "invalidated_keys_total",
+			Help:      "Cache keys invalidated by writes, by result (ok, error).",
+		}, []string{"result"}),
+	}
+
+	reg.MustRegister(cr.requests, cr.invalidations)
+
+	return cr
+}
+
+func (r *CommentRepo) CreateComment(ctx context.Context, parentID, replyToID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	c, err := r.repo.CreateComment(ctx, parentID, replyToID, author, content, attachmentIDs)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	r.invalidateAncestors(ctx, c)
+
+	return c, nil
+}
+
+func (r *CommentRepo) CommentExists(ctx context.Context, id int64) error {
+	return r.repo.CommentExists(ctx, id)
+}
+
+func (r *CommentRepo) GetCommentWithChildren(ctx context.Context, id int64, childSort string) ([]entity.Comment, error) {
+	tree, ok := r.get(ctx, id)
+	if ok {
+		return sortTree(tree, childSort), nil
+	}
+
+	key := treeKey(id)
+
+	v, err := r.load(ctx, key, func(ctx context.Context) (any, error) {
+		comments, err := r.repo.GetCommentWithChildren(ctx, id, entity.ChildSortOldest)
+		if err != nil {
+			return nil, err
+		}
+
+		r.set(ctx, id, comments)
+
+		return comments, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortTree(slices.Clone(v.([]entity.Comment)), childSort), nil //nolint:forcetypeassert
+}
+
+func (r *CommentRepo) DeleteCommentWithChildren(ctx context.Context, id int64) (int, error) {
+	// потомки нужны до удаления, иначе их ключи уже не найти
+	subtree, err := r.repo.GetCommentWithChildren(ctx, id, entity.ChildSortOldest)
+	if err != nil && !errors.Is(err, errs.ErrRecordNotFound) {
+		return 0, fmt.Errorf("cached - CommentRepo - DeleteCommentWithChildren - r.repo.GetCommentWithChildren: %w", err)
+	}
+
+	path, err := r.repo.GetCommentPath(ctx, id)
+	if err != nil && !errors.Is(err, errs.ErrRecordNotFound) {
+		return 0, fmt.Errorf("cached - CommentRepo - DeleteCommentWithChildren - r.repo.GetCommentPath: %w", err)
+	}
+
+	// у ссылающихся на удаленные комменты хранилище повышает версии
+	ids := make([]int64, len(subtree))
+	for i, c := range subtree {
+		ids[i] = c.ID
+	}
+
+	referrers, err := r.repo.GetReferrers(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("cached - CommentRepo - DeleteCommentWithChildren - r.repo.GetReferrers: %w", err)
+	}
+
+	deleted, err := r.repo.DeleteCommentWithChildren(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0, len(path)+len(subtree))
+	for _, ancestorID := range path {
+		keys = append(keys, treeKey(ancestorID))
+	}
+	for _, c := range subtree {
+		keys = append(keys, treeKey(c.ID))
+	}
+
+	r.delete(ctx, keys)
+
+	for _, referrerID := range referrers {
+		r.Invalidate(ctx, referrerID)
+	}
+
+	return deleted, nil
+}
+
+func (r *CommentRepo) SearchComments(ctx context.Context, search string, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	return r.repo.SearchComments(ctx, search, sortBy, order, limit, offset)
+}
+
+func (r *CommentRepo) GetRootComments(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	return r.repo.GetRootComments(ctx, viewer, sortBy, order, limit, offset)
+}
+
+func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64, childSort string) ([]entity.Comment, error) {
+	ids := slices.Clone(rootIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	trees := make(map[int64][]entity.Comment, len(ids))
+
+	var missing []int64
+	for _, id := range ids {
+		tree, ok := r.get(ctx, id)
+		if ok {
+			trees[id] = tree
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		v, err := r.load(ctx, treesKey(missing), func(ctx context.Context) (any, error) {
+			comments, err := r.repo.GetTreesForRoots(ctx, missing, entity.ChildSortOldest)
+			if err != nil {
+				return nil, err
+			}
+
+			fetched := splitByRoot(comments)
+			for id, tree := range fetched {
+				r.set(ctx, id, tree)
+			}
+
+			return fetched, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for id, tree := range v.(map[int64][]entity.Comment) { //nolint:forcetypeassert
+			trees[id] = tree
+		}
+	}
+
+	comments := []entity.Comment{}
+	for _, id := range ids {
+		comments = append(comments, trees[id]...)
+	}
+
+	// корни тоже упорядочиваются по childSort, как и в хранилище
+	return entity.SortTree(comments, childSort), nil
+}
+
+// load - схлопывает одновременные загрузки key в одну. Ее результат нужен всем ждущим,
+// поэтому она идет без отмены ctx первого вызвавшего, но со своим таймаутом;
+// каждый вызвавший при этом перестает ждать по своему ctx.
+func (r *CommentRepo) load(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	ch := r.group.DoChan(key, func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _loadTimeout)
+		defer cancel()
+
+		return fn(loadCtx)
+	})
+
+	select {
+	case res := <-ch:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *CommentRepo) GetCommentPath(ctx context.Context, id int64) ([]int64, error) {
+	return r.repo.GetCommentPath(ctx, id)
+}
+
+func (r *CommentRepo) ExportComments(ctx context.Context, rootID *int64) iter.Seq2[entity.Comment, error] {
+	return r.repo.ExportComments(ctx, rootID)
+}
+
+// ImportComments - импорт создает только новые деревья, сбрасывать нечего.
+func (r *CommentRepo) ImportComments(ctx context.Context, comments []entity.Comment) ([]int64, error) {
+	return r.repo.ImportComments(ctx, comments)
+}
+
+func (r *CommentRepo) CreateFromSource(ctx context.Context, source, sourceID string, c entity.Comment) (entity.Comment, error) {
+	c, err := r.repo.CreateFromSource(ctx, source, sourceID, c)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	r.invalidateAncestors(ctx, c)
+
+	return c, nil
+}
+
+func (r *CommentRepo) GetSourceMapping(ctx context.Context, source string, sourceIDs []string) (map[string]int64, error) {
+	return r.repo.GetSourceMapping(ctx, source, sourceIDs)
+}
+
+// GetCommentsByIDs, GetChildren и CountReplies не кэшируются: ключи кэша - целые поддеревья,
+// а эти чтения идут точечно и пачками из GraphQL.
+func (r *CommentRepo) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	return r.repo.GetCommentsByIDs(ctx, ids)
+}
+
+func (r *CommentRepo) GetChildren(ctx context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error) {
+	return r.repo.GetChildren(ctx, parentIDs, sortBy, order, limit, offset)
+}
+
+func (r *CommentRepo) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	return r.repo.CountReplies(ctx, ids)
+}
+
+// SetPinOrder, SetHighlighted, SetLock и SetMaxDepth меняют сам коммент - его поддерево и поддеревья предков устаревают.
+func (r *CommentRepo) SetPinOrder(ctx context.Context, id int64, order *int64) error {
+	err := r.repo.SetPinOrder(ctx, id, order)
+	if err != nil {
+		return err
+	}
+
+	r.Invalidate(ctx, id)
+
+	return nil
+}
+
+func (r *CommentRepo) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	err := r.repo.SetHighlighted(ctx, id, highlighted)
+	if err != nil {
+		return err
+	}
+
+	r.Invalidate(ctx, id)
+
+	return nil
+}
+
+func (r *CommentRepo) SetLock(ctx context.Context, id int64, state, reason string) error {
+	err := r.repo.SetLock(ctx, id, state, reason)
+	if err != nil {
+		return err
+	}
+
+	r.Invalidate(ctx, id)
+
+	return nil
+}
+
+func (r *CommentRepo) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	err := r.repo.SetMaxDepth(ctx, id, maxDepth)
+	if err != nil {
+		return err
+	}
+
+	r.Invalidate(ctx, id)
+
+	return nil
+}
+
+// ArchiveInactive - архивируются только корни, так что предков для сброса у них нет.
+func (r *CommentRepo) ArchiveInactive(ctx context.Context, before time.Time, reason string) ([]int64, error) {
+	ids, err := r.repo.ArchiveInactive(ctx, before, reason)
+	if err != nil || len(ids) == 0 {
+		return ids, err
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = treeKey(id)
+	}
+
+	r.delete(ctx, keys)
+
+	return ids, nil
+}
+
+// invalidateAncestors - сбрасывает поддеревья предков только что созданного коммента c.
+func (r *CommentRepo) invalidateAncestors(ctx context.Context, c entity.Comment) {
+	// путь нового коммента уже известен хранилищу - лишний запрос не нужен
+	if len(c.Path) > 1 {
+		keys := make([]string, len(c.Path)-1)
+		for i, ancestorID := range c.Path[:len(c.Path)-1] {
+			keys[i] = treeKey(ancestorID)
+		}
+
+		r.delete(ctx, keys)
+	} else if c.ParentID.Valid {
+		r.Invalidate(ctx, c.ParentID.Int64)
+	}
+}
+
+// Invalidate - сбрасывает поддеревья коммента id и всех его предков.
+// Вызывается после любого изменения внутри поддерева id.
+func (r *CommentRepo) Invalidate(ctx context.Context, id int64) {
+	path, err := r.repo.GetCommentPath(ctx, id)
+	if err != nil {
+		if !errors.Is(err, errs.ErrRecordNotFound) {
+			r.invalidations.WithLabelValues("error").Inc()
+		}
+
+		return
+	}
+
+	keys := make([]string, len(path))
+	for i, ancestorID := range path {
+		keys[i] = treeKey(ancestorID)
+	}
+
+	r.delete(ctx, keys)
+}
+
+func (r *CommentRepo) get(ctx context.Context, id int64) ([]entity.Comment, bool) {
+	data, ok, err := r.cache.Get(ctx, treeKey(id))
+	if err != nil {
+		r.requests.WithLabelValues("error").Inc()
+
+		return nil, false
+	}
+
+	if !ok {
+		r.requests.WithLabelValues("miss").Inc()
+
+		return nil, false
+	}
+
+	var tree []entity.Comment
+
+	err = json.Unmarshal(data, &tree)
+	if err != nil {
+		r.requests.WithLabelValues("error").Inc()
+
+		return nil, false
+	}
+
+	r.requests.WithLabelValues("hit").Inc()
+
+	return tree, true
+}
+
+// set - ошибка записи в кэш не должна ломать чтение, данные уже получены из хранилища.
+func (r *CommentRepo) set(ctx context.Context, id int64, tree []entity.Comment) {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return
+	}
+
+	_ = r.cache.Set(ctx, treeKey(id), data, r.ttl)
+}
+
+func (r *CommentRepo) delete(ctx context.Context, keys []string) {
+	err := r.cache.Delete(ctx, keys...)
+	if err != nil {
+		r.invalidations.WithLabelValues("error").Add(float64(len(keys)))
+
+		return
+	}
+
+	r.invalidations.WithLabelValues("ok").Add(float64(len(keys)))
+}
+
+func treeKey(id int64) string {
+	return _treeKeyPrefix + strconv.FormatInt(id, 10)
+}
+
+func treesKey(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+
+	return "trees:" + strings.Join(parts, ",")
+}
+
+// sortTree - в кэше поддеревья уже в порядке oldest.
+func sortTree(tree []entity.Comment, childSort string) []entity.Comment {
+	if childSort == entity.ChildSortOldest {
+		return tree
+	}
+
+	return entity.SortTree(tree, childSort)
+}
+
+// splitByRoot - первый элемент path - корень.
+func splitByRoot(comments []entity.Comment) map[int64][]entity.Comment {
+	trees := make(map[int64][]entity.Comment)
+
+	for _, c := range comments {
+		rootID := c.ID
+		if len(c.Path) > 0 {
+			rootID = c.Path[0]
+		}
+
+		trees[rootID] = append(trees[rootID], c)
+	}
+
+	return trees
+}
+
+// Уведомления и заглушки не кэшируются: они не входят в поддеревья и читаются каждым получателем отдельно.
+func (r *CommentRepo) CreateNotifications(ctx context.Context, ns []entity.Notification) ([]entity.Notification, error) {
+	return r.repo.CreateNotifications(ctx, ns)
+}
+
+func (r *CommentRepo) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	return r.repo.GetNotifications(ctx, recipient, status, beforeID, limit)
+}
+
+func (r *CommentRepo) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	return r.repo.MarkNotificationsRead(ctx, recipient, ids)
+}
+
+func (r *CommentRepo) SetMute(ctx context.Context, user string, id int64, muted bool) error {
+	return r.repo.SetMute(ctx, user, id, muted)
+}
+
+func (r *CommentRepo) GetMutedUsers(ctx context.Context, ids []int64, users []string) ([]string, error) {
+	return r.repo.GetMutedUsers(ctx, ids, users)
+}
+
+// Подписки тоже не кэшируются: их читает только задача дайджестов.
+func (r *CommentRepo) Subscribe(ctx context.Context, subscriber string, id int64, email string) error {
+	return r.repo.Subscribe(ctx, subscriber, id, email)
+}
+
+func (r *CommentRepo) Unsubscribe(ctx context.Context, subscriber string, id int64) error {
+	return r.repo.Unsubscribe(ctx, subscriber, id)
+}
+
+func (r *CommentRepo) GetSubscriptions(ctx context.Context, subscriber string) ([]entity.Subscription, error) {
+	return r.repo.GetSubscriptions(ctx, subscriber)
+}
+
+func (r *CommentRepo) GetDueSubscribers(ctx context.Context, before time.Time, after string, limit int) ([]string, error) {
+	return r.repo.GetDueSubscribers(ctx, before, after, limit)
+}
+
+func (r *CommentRepo) MarkDigested(ctx context.Context, subscriber string, lastIDs map[int64]int64, at time.Time) error {
+	return r.repo.MarkDigested(ctx, subscriber, lastIDs, at)
+}
+
+// Визиты у каждого пользователя свои, кэшируются только общие для всех деревья.
+func (r *CommentRepo) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	return r.repo.MarkSeen(ctx, viewer, rootID)
+}
+
+func (r *CommentRepo) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	return r.repo.GetLastSeen(ctx, viewer, rootIDs)
+}
+
+func (r *CommentRepo) CreateReferences(ctx context.Context, sourceID int64, targetIDs []int64) error {
+	return r.repo.CreateReferences(ctx, sourceID, targetIDs)
+}
+
+// GetReferences - ссылки в кэш деревьев не входят, недоступность цели видна сразу.
+func (r *CommentRepo) GetReferences(ctx context.Context, sourceIDs []int64) (map[int64][]entity.Reference, error) {
+	return r.repo.GetReferences(ctx, sourceIDs)
+}
+
+func (r *CommentRepo) GetBacklinks(ctx context.Context, targetID int64, limit, offset int) ([]entity.Comment, int, error) {
+	return r.repo.GetBacklinks(ctx, targetID, limit, offset)
+}
+
+func (r *CommentRepo) GetReferrers(ctx context.Context, targetIDs []int64) ([]int64, error) {
+	return r.repo.GetReferrers(ctx, targetIDs)
+}
+
+func (r *CommentRepo) CreateAttachment(ctx context.Context, a entity.Attachment) (entity.Attachment, error) {
+	return r.repo.CreateAttachment(ctx, a)
+}
+
+func (r *CommentRepo) AttachAttachments(ctx context.Context, commentID int64, uploader string, ids []int64) (int, error) {
+	return r.repo.AttachAttachments(ctx, commentID, uploader, ids)
+}
+
+func (r *CommentRepo) GetAttachment(ctx context.Context, id int64) (entity.Attachment, error) {
+	return r.repo.GetAttachment(ctx, id)
+}
+
+// GetAttachments - вложения, как и ссылки, в кэш деревьев не входят.
+func (r *CommentRepo) GetAttachments(ctx context.Context, commentIDs []int64) (map[int64][]entity.Attachment, error) {
+	return r.repo.GetAttachments(ctx, commentIDs)
+}
+
+func (r *CommentRepo) GetOrphanAttachments(ctx context.Context, uploadedBefore time.Time, limit int) ([]entity.Attachment, error) {
+	return r.repo.GetOrphanAttachments(ctx, uploadedBefore, limit)
+}
+
+func (r *CommentRepo) DeleteAttachments(ctx context.Context, ids []int64) error {
+	return r.repo.DeleteAttachments(ctx, ids)
+}
+
+func (r *CommentRepo) GetAuthorData(ctx context.Context, author string) (entity.AuthorData, error) {
+	return r.repo.GetAuthorData(ctx, author)
+}
+
+// EraseAuthor - хранилище само сообщает, чьи версии выросли: сбрасываются поддеревья всех этих комментов.
+func (r *CommentRepo) EraseAuthor(ctx context.Context, author, content string) (entity.Erasure, error) {
+	erasure, err := r.repo.EraseAuthor(ctx, author, content)
+	if err != nil {
+		return erasure, err
+	}
+
+	keys := make([]string, len(erasure.Touched))
+	for i, id := range erasure.Touched {
+		keys[i] = treeKey(id)
+	}
+
+	r.delete(ctx, keys)
+
+	return erasure, nil
+}
+
+func (r *CommentRepo) LogPrivacyRequest(ctx context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error) {
+	return r.repo.LogPrivacyRequest(ctx, p)
+}
+
+func (r *CommentRepo) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	return r.repo.GetAuditLog(ctx, f, beforeID, limit)
+}
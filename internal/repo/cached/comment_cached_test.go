@@ -0,0 +1,141 @@
+package cached_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/cached"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/repotest"
+	"github.com/andreyxaxa/Comment-Tree/pkg/cache"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newRepo(_ *testing.T) repo.CommentRepo {
+	return cached.New(memory.New(), cache.NewLRU(), time.Minute, prometheus.NewRegistry())
+}
+
+func TestCommentRepo(t *testing.T) {
+	repotest.TestCommentRepo(t, newRepo)
+}
+
+func TestInvalidation(t *testing.T) {
+	ctx := context.Background()
+	r := newRepo(t)
+
+	root, _ := r.CreateComment(ctx, nil, nil, "", "root", nil)
+	a, _ := r.CreateComment(ctx, &root.ID, nil, "", "a", nil)
+	a1, _ := r.CreateComment(ctx, &a.ID, nil, "", "a1", nil)
+
+	// прогреваем кэш всех уровней
+	mustLen(t, r, root.ID, 3)
+	mustLen(t, r, a.ID, 2)
+	mustLen(t, r, a1.ID, 1)
+
+	trees, err := r.GetTreesForRoots(ctx, []int64{root.ID}, entity.ChildSortOldest)
+	if err != nil || len(trees) != 3 {
+		t.Fatalf("GetTreesForRoots = %d comments, %v; want 3", len(trees), err)
+	}
+
+	// ответ на самом глубоком уровне виден во всех предках
+	if _, err = r.CreateComment(ctx, &a1.ID, nil, "", "a1x", nil); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	mustLen(t, r, root.ID, 4)
+	mustLen(t, r, a.ID, 3)
+	mustLen(t, r, a1.ID, 2)
+
+	// удаление сбрасывает и предков, и удаленных потомков
+	if _, err = r.DeleteCommentWithChildren(ctx, a.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	mustLen(t, r, root.ID, 1)
+
+	if _, err = r.GetCommentWithChildren(ctx, a1.ID, entity.ChildSortOldest); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("GetCommentWithChildren(deleted) = %v, want ErrRecordNotFound", err)
+	}
+}
+
+// blockingRepo - GetCommentWithChildren ждет release и, как настоящее хранилище, падает по отмене ctx.
+type blockingRepo struct {
+	repo.CommentRepo
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRepo) GetCommentWithChildren(ctx context.Context, id int64, childSort string) ([]entity.Comment, error) {
+	r.started <- struct{}{}
+	<-r.release
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.CommentRepo.GetCommentWithChildren(ctx, id, childSort)
+}
+
+func TestSharedLoadOutlivesFirstCaller(t *testing.T) {
+	ctx := context.Background()
+
+	mem := memory.New()
+	root, _ := mem.CreateComment(ctx, nil, nil, "", "root", nil)
+
+	br := &blockingRepo{CommentRepo: mem, started: make(chan struct{}, 1), release: make(chan struct{})}
+	r := cached.New(br, cache.NewLRU(), time.Minute, prometheus.NewRegistry())
+
+	firstCtx, cancel := context.WithCancel(ctx)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := r.GetCommentWithChildren(firstCtx, root.ID, entity.ChildSortOldest)
+		first <- err
+	}()
+	<-br.started
+
+	type result struct {
+		comments []entity.Comment
+		err      error
+	}
+
+	second := make(chan result, 1)
+	go func() {
+		comments, err := r.GetCommentWithChildren(ctx, root.ID, entity.ChildSortOldest)
+		second <- result{comments, err}
+	}()
+	// второй вызов присоединяется к загрузке первого
+	time.Sleep(50 * time.Millisecond)
+
+	// первый перестает ждать сразу, загрузка продолжается
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller = %v, want context.Canceled", err)
+	}
+
+	close(br.release)
+
+	res := <-second
+	if res.err != nil || len(res.comments) != 1 {
+		t.Fatalf("second caller = %d comments, %v; want 1", len(res.comments), res.err)
+	}
+}
+
+func mustLen(t *testing.T, r repo.CommentRepo, id int64, want int) {
+	t.Helper()
+
+	got, err := r.GetCommentWithChildren(context.Background(), id, entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetCommentWithChildren(%d): %v", id, err)
+	}
+
+	if len(got) != want {
+		t.Fatalf("GetCommentWithChildren(%d) = %d comments, want %d", id, len(got), want)
+	}
+}
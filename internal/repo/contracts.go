@@ -2,18 +2,159 @@ package repo
 
 import (
 	"context"
+	"io"
+	"iter"
+	"time"
 
 	"github.com/andreyxaxa/Comment-Tree/internal/entity"
 )
 
 type (
 	CommentRepo interface {
-		CreateComment(ctx context.Context, parentID *int64, content string) (entity.Comment, error)
+		// CreateComment - replyToID (может быть nil) - коммент, на который отвечали,
+		// если ответ перенесен к parentID из-за лимита глубины. Блокировка ветки parentID
+		// проверяется в той же транзакции, что и вставка: errs.ErrLocked или errs.ErrArchived.
+		// Там же сохраняются ссылки ">>id" из content (entity.ReferenceTargets), как CreateReferences,
+		// и прикрепляются вложения attachmentIDs (без повторов), как AttachAttachments с uploader author:
+		// если прикрепить удалось не все, вставка откатывается с errs.ErrInvalidAttachment.
+		CreateComment(ctx context.Context, parentID, replyToID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error)
 		CommentExists(ctx context.Context, id int64) error
-		GetCommentWithChildren(ctx context.Context, id int64) ([]entity.Comment, error)
-		DeleteCommentWithChildren(ctx context.Context, id int64) error
+		// GetCommentWithChildren - поддерево коммента, родитель раньше детей,
+		// ответы одного родителя - в порядке childSort (entity.ChildSort*), как у entity.SortTree.
+		GetCommentWithChildren(ctx context.Context, id int64, childSort string) ([]entity.Comment, error)
+		// DeleteCommentWithChildren - повышает версии предков коммента, а также комментов,
+		// ссылающихся на удаленные, и их предков: эти ссылки становятся недоступными.
+		// Возвращает число удаленных комментов вместе с самим id.
+		DeleteCommentWithChildren(ctx context.Context, id int64) (int, error)
 		SearchComments(ctx context.Context, search string, sortBy, order string, limit, offset int) ([]entity.Comment, int, error)
-		GetRootComments(ctx context.Context, sortBy, order string, limit, offset int) ([]entity.Comment, int, error)
-		GetTreesForRoots(ctx context.Context, rootIDs []int64) ([]entity.Comment, error)
+		// GetRootComments - viewer ("" - без непрочитанного) получает у корней LastSeenID и NewCount
+		// тем же запросом.
+		GetRootComments(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error)
+		// GetTreesForRoots - поддеревья корней в том же порядке, что и GetCommentWithChildren.
+		// Сами корни тоже упорядочены по childSort, а не по rootIDs.
+		GetTreesForRoots(ctx context.Context, rootIDs []int64, childSort string) ([]entity.Comment, error)
+		// GetCommentPath - id предков от корня до самого коммента включительно.
+		GetCommentPath(ctx context.Context, id int64) ([]int64, error)
+		// ExportComments - комменты по одному, родитель всегда раньше ребенка.
+		// rootID - только поддерево этого коммента, nil - вся база.
+		ExportComments(ctx context.Context, rootID *int64) iter.Seq2[entity.Comment, error]
+		// ImportComments - массовая загрузка новых деревьев. comments идут родитель раньше ребенка,
+		// ID и ParentID - id источника, родитель всегда из того же импорта.
+		// Возвращает новые id в порядке comments.
+		ImportComments(ctx context.Context, comments []entity.Comment) ([]int64, error)
+		// CreateFromSource - создает коммент из внешней системы (Author и CreatedAt берутся из c)
+		// и в той же транзакции запоминает, что source/sourceID загружен в него.
+		// Повторная загрузка того же sourceID - errs.ErrAlreadyExists.
+		CreateFromSource(ctx context.Context, source, sourceID string, c entity.Comment) (entity.Comment, error)
+		// GetSourceMapping - id комментов, уже загруженных из source, по sourceID.
+		// 0 - загружен, но с тех пор удален. Не загруженных в ответе нет.
+		GetSourceMapping(ctx context.Context, source string, sourceIDs []string) (map[string]int64, error)
+		// GetCommentsByIDs - комменты без поддеревьев, Depth и Path - от корня треда.
+		// Отсутствующих в ответе нет.
+		GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error)
+		// GetChildren - страница прямых ответов каждого из parentIDs,
+		// limit и offset отсчитываются у каждого родителя отдельно. Родителей без ответов в ответе нет.
+		GetChildren(ctx context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error)
+		// CountReplies - число прямых ответов и всех потомков. Отсутствующих в ответе нет.
+		CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error)
+		// SetPinOrder - закрепляет коммент на месте order среди братьев, nil - открепляет.
+		// SetHighlighted - выделяет коммент или снимает выделение.
+		// Оба повышают версии коммента и всех его предков.
+		SetPinOrder(ctx context.Context, id int64, order *int64) error
+		SetHighlighted(ctx context.Context, id int64, highlighted bool) error
+		// SetLock - блокировка коммента (entity.Lock*) с причиной, entity.LockNone снимает ее.
+		// Повышает версии коммента и всех его предков.
+		SetLock(ctx context.Context, id int64, state, reason string) error
+		// ArchiveInactive - архивирует корни, чьи деревья не менялись с before
+		// (по updated_at корня), и возвращает их id.
+		ArchiveInactive(ctx context.Context, before time.Time, reason string) ([]int64, error)
+		// SetMaxDepth - лимит глубины дерева корня id, nil - общий лимит.
+		// Повышает версии коммента и всех его предков.
+		SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error
+		// CreateNotifications - сохраняет уведомления и возвращает их с ID и CreatedAt.
+		CreateNotifications(ctx context.Context, ns []entity.Notification) ([]entity.Notification, error)
+		// GetNotifications - уведомления получателя от новых к старым со статусом
+		// entity.Notifications*, только с id меньше beforeID (0 - с самого нового).
+		GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error)
+		// MarkNotificationsRead - отмечает прочитанными уведомления получателя с ids
+		// (nil - все), возвращает, сколько из них было непрочитано.
+		MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error)
+		// SetMute - заглушает для user поддерево коммента id или снимает заглушку.
+		SetMute(ctx context.Context, user string, id int64, muted bool) error
+		// GetMutedUsers - кто из users заглушил хотя бы один коммент из ids.
+		GetMutedUsers(ctx context.Context, ids []int64, users []string) ([]string, error)
+		// Subscribe - подписывает subscriber на дайджест поддерева id с адресом email
+		// (адрес меняется у всех его подписок). Повторная подписка позицию не сбрасывает.
+		Subscribe(ctx context.Context, subscriber string, id int64, email string) error
+		// Unsubscribe - отписка от id, 0 - от всего.
+		Unsubscribe(ctx context.Context, subscriber string, id int64) error
+		GetSubscriptions(ctx context.Context, subscriber string) ([]entity.Subscription, error)
+		// GetDueSubscribers - подписчики после after (по имени), у которых хотя бы одна подписка
+		// не попадала в дайджест с before.
+		GetDueSubscribers(ctx context.Context, before time.Time, after string, limit int) ([]string, error)
+		// MarkDigested - сдвигает позиции подписок subscriber (id коммента -> последний коммент в дайджесте)
+		// и запоминает время дайджеста at.
+		MarkDigested(ctx context.Context, subscriber string, lastIDs map[int64]int64, at time.Time) error
+		// MarkSeen - viewer видел тред корня rootID до самого нового коммента в базе,
+		// возвращает этот коммент. Позиция назад не сдвигается.
+		MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error)
+		// GetLastSeen - последние увиденные viewer комменты тредов корней rootIDs одним запросом
+		// (id корня -> id коммента). Тредов, которые viewer не открывал, в карте нет.
+		GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error)
+		// CreateReferences - запоминает ссылки коммента sourceID на targetIDs в их порядке,
+		// отсутствующие комменты пропускаются.
+		CreateReferences(ctx context.Context, sourceID int64, targetIDs []int64) error
+		// GetReferences - ссылки комментов sourceIDs в порядке текста, у удаленных целей Target - nil.
+		// Комментов без ссылок в ответе нет.
+		GetReferences(ctx context.Context, sourceIDs []int64) (map[int64][]entity.Reference, error)
+		// GetBacklinks - страница комментов, ссылающихся на targetID, от новых к старым, и их total.
+		GetBacklinks(ctx context.Context, targetID int64, limit, offset int) ([]entity.Comment, int, error)
+		// GetReferrers - id комментов, ссылающихся хотя бы на один из targetIDs.
+		GetReferrers(ctx context.Context, targetIDs []int64) ([]int64, error)
+		// CreateAttachment - сохраняет описание загруженного файла, еще не прикрепленного к комменту,
+		// и возвращает его с ID и CreatedAt.
+		CreateAttachment(ctx context.Context, a entity.Attachment) (entity.Attachment, error)
+		// AttachAttachments - прикрепляет к commentID вложения из ids, загруженные uploader
+		// и ни разу не прикреплявшиеся, и возвращает, сколько прикреплено.
+		AttachAttachments(ctx context.Context, commentID int64, uploader string, ids []int64) (int, error)
+		GetAttachment(ctx context.Context, id int64) (entity.Attachment, error)
+		// GetAttachments - вложения комментов commentIDs в порядке загрузки. Комментов без вложений в ответе нет.
+		GetAttachments(ctx context.Context, commentIDs []int64) (map[int64][]entity.Attachment, error)
+		// GetOrphanAttachments - вложения без коммента: открепленные удалением коммента
+		// и не прикрепленные, загруженные раньше uploadedBefore.
+		GetOrphanAttachments(ctx context.Context, uploadedBefore time.Time, limit int) ([]entity.Attachment, error)
+		DeleteAttachments(ctx context.Context, ids []int64) error
+		// GetAuthorData - комменты автора (без поддеревьев, по id), его загрузки, заглушки и визиты.
+		// Уведомления и подписки читаются через GetNotifications и GetSubscriptions.
+		GetAuthorData(ctx context.Context, author string) (entity.AuthorData, error)
+		// EraseAuthor - в одной транзакции заменяет текст комментов автора на content и стирает в них автора,
+		// убирает их ссылки ">>id", отвязывает его вложения (их файлы удаляет вызывающий или сборщик),
+		// стирает его имя в чужих уведомлениях и удаляет его уведомления, заглушки, подписки и визиты.
+		// Повышает версии его комментов, ссылающихся на них и предков тех и других.
+		EraseAuthor(ctx context.Context, author, content string) (entity.Erasure, error)
+		// LogPrivacyRequest - запись в журнал выгрузок и стираний, возвращается с ID и CreatedAt.
+		LogPrivacyRequest(ctx context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error)
+		// GetAuditLog - страница журнала изменений от новых к старым, beforeID - как у GetNotifications.
+		// Журнал пишут сами изменяющие методы в своих транзакциях (entity.Audit*), кто и откуда - из ctx
+		// (caller и requestid). EraseAuthor стирает автора и из журнала.
+		GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error)
+	}
+
+	// CDNWebAPI - сброс закэшированных на CDN ответов по Surrogate-Key.
+	CDNWebAPI interface {
+		Purge(ctx context.Context, keys []string) error
+	}
+
+	// BlobStore - хранилище файлов вложений. Get отсутствующего ключа - errs.ErrRecordNotFound,
+	// Delete отсутствующие ключи пропускает.
+	BlobStore interface {
+		Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+		Get(ctx context.Context, key string) (io.ReadCloser, error)
+		Delete(ctx context.Context, keys ...string) error
+	}
+
+	// Mailer - отправка писем.
+	Mailer interface {
+		Send(ctx context.Context, email entity.Email) error
 	}
 )
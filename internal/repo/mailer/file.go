@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+)
+
+// FileDrop - вместо отправки кладет каждое письмо в отдельный .eml файл в dir,
+// для локальной разработки и тестов. Файл появляется целиком (запись во временный и rename).
+type FileDrop struct {
+	dir  string
+	from string
+}
+
+var _ repo.Mailer = (*FileDrop)(nil)
+
+func NewFileDrop(dir, from string) *FileDrop {
+	return &FileDrop{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *FileDrop) Send(_ context.Context, email entity.Email) error {
+	now := time.Now()
+
+	msg, err := message(m.from, email, now)
+	if err != nil {
+		return fmt.Errorf("FileDrop - Send - message: %w", err)
+	}
+
+	err = os.MkdirAll(m.dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("FileDrop - Send - os.MkdirAll: %w", err)
+	}
+
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+
+	// имена сортируются в порядке отправки
+	name := filepath.Join(m.dir, now.UTC().Format("20060102T150405.000000000")+"-"+hex.EncodeToString(b)+".eml")
+
+	err = os.WriteFile(name+".tmp", msg, 0o644) //nolint:gosec // письма читает разработчик
+	if err != nil {
+		return fmt.Errorf("FileDrop - Send - os.WriteFile: %w", err)
+	}
+
+	err = os.Rename(name+".tmp", name)
+	if err != nil {
+		return fmt.Errorf("FileDrop - Send - os.Rename: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/mailer"
+)
+
+var _email = entity.Email{
+	To:      "alice@example.com",
+	Subject: "Новые ответы",
+	Text:    "2 new replies",
+	HTML:    "<p>2 new replies</p>",
+	Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u?token=t>"},
+}
+
+func TestFileDrop(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m := mailer.NewFileDrop(dir, "Comments <noreply@example.com>")
+	if err := m.Send(context.Background(), _email); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("files = %v, %v; want one .eml", files, err)
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	checkMessage(t, f)
+}
+
+func TestHeaderInjection(t *testing.T) {
+	email := _email
+	email.To = "alice@example.com\r\nBcc: eve@example.com"
+
+	m := mailer.NewFileDrop(t.TempDir(), "noreply@example.com")
+	if err := m.Send(context.Background(), email); err == nil {
+		t.Fatal("Send with line break in header = nil, want error")
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	got := make(chan string, 1)
+	go serveSMTP(t, ln, got)
+
+	m := mailer.NewSMTP(ln.Addr().String(), "", "", "noreply@example.com", time.Second)
+	if err = m.Send(context.Background(), _email); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	checkMessage(t, strings.NewReader(<-got))
+}
+
+func checkMessage(t *testing.T, r io.Reader) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != _email.Subject {
+		t.Fatalf("Subject = %q, %v; want %q", subject, err, _email.Subject)
+	}
+
+	if msg.Header.Get("To") != _email.To || msg.Header.Get("List-Unsubscribe") == "" {
+		t.Fatalf("headers = %v", msg.Header)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("ParseMediaType: %v", err)
+	}
+
+	var bodies []string
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+
+		// multipart.Reader сам снимает quoted-printable
+		b, _ := io.ReadAll(p)
+		bodies = append(bodies, string(b))
+	}
+
+	if len(bodies) != 2 || bodies[0] != _email.Text || bodies[1] != _email.HTML {
+		t.Fatalf("bodies = %q, want text and html", bodies)
+	}
+}
+
+// serveSMTP - минимальный SMTP-сервер на одно письмо без расширений.
+func serveSMTP(t *testing.T, ln net.Listener, got chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM"), strings.HasPrefix(cmd, "RCPT TO"):
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+
+			got <- data.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			t.Errorf("unexpected command %q", cmd)
+			reply("500 unknown")
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+// message - письмо в формате RFC 5322: multipart/alternative с текстовой и HTML-версией
+// в quoted-printable, тема - в RFC 2047.
+func message(from string, email entity.Email, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	mw := multipart.NewWriter(&buf)
+
+	headers := map[string]string{
+		"From":         from,
+		"To":           email.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", email.Subject),
+		"Date":         now.Format(time.RFC1123Z),
+		"Message-ID":   messageID(from),
+		"MIME-Version": "1.0",
+		"Content-Type": `multipart/alternative; boundary="` + mw.Boundary() + `"`,
+	}
+	for k, v := range email.Headers {
+		headers[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k, v := range headers {
+		// перевод строки в значении добавил бы в письмо чужие заголовки
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, fmt.Errorf("header %s contains a line break", k)
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var head bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&head, "%s: %s\r\n", k, headers[k])
+	}
+	head.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("mw.CreatePart: %w", err)
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err = qp.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("qp.Write: %w", err)
+		}
+		if err = qp.Close(); err != nil {
+			return nil, fmt.Errorf("qp.Close: %w", err)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("mw.Close: %w", err)
+	}
+
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+// messageID - случайный id с доменом отправителя.
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		domain = strings.Trim(from[i+1:], "> ")
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+)
+
+// SMTP - отправка через SMTP-сервер: STARTTLS, если сервер его поддерживает,
+// и AUTH PLAIN, если задан username (net/smtp не отдает пароль по незашифрованному соединению не на localhost).
+type SMTP struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	timeout  time.Duration
+}
+
+var _ repo.Mailer = (*SMTP)(nil)
+
+// NewSMTP - addr в виде host:port, from - адрес отправителя, можно с именем ("Comments <noreply@example.com>").
+func NewSMTP(addr, username, password, from string, timeout time.Duration) *SMTP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	return &SMTP{
+		addr:     addr,
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+		timeout:  timeout,
+	}
+}
+
+func (m *SMTP) Send(ctx context.Context, email entity.Email) error {
+	msg, err := message(m.from, email, time.Now())
+	if err != nil {
+		return fmt.Errorf("SMTP - Send - message: %w", err)
+	}
+
+	err = m.send(ctx, email.To, msg)
+	if err != nil {
+		return fmt.Errorf("SMTP - Send - m.send: %w", err)
+	}
+
+	return nil
+}
+
+func (m *SMTP) send(ctx context.Context, to string, msg []byte) error {
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("mail.ParseAddress: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	d := net.Dialer{}
+
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("d.DialContext: %w", err)
+	}
+
+	// net/smtp не принимает context, весь диалог ограничен дедлайном соединения
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp.NewClient: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: m.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("c.StartTLS: %w", err)
+		}
+	}
+
+	if m.username != "" {
+		if err = c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("c.Auth: %w", err)
+		}
+	}
+
+	if err = c.Mail(from.Address); err != nil {
+		return fmt.Errorf("c.Mail: %w", err)
+	}
+
+	if err = c.Rcpt(to); err != nil {
+		return fmt.Errorf("c.Rcpt: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("c.Data: %w", err)
+	}
+
+	if _, err = w.Write(msg); err != nil {
+		return fmt.Errorf("w.Write: %w", err)
+	}
+
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("w.Close: %w", err)
+	}
+
+	if err = c.Quit(); err != nil {
+		return fmt.Errorf("c.Quit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+func (r *CommentRepo) CreateAttachment(_ context.Context, a entity.Attachment) (entity.Attachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastAttachmentID++
+	a.ID = r.lastAttachmentID
+	a.CommentID = sql.NullInt64{}
+	a.AttachedAt = sql.NullTime{}
+	a.CreatedAt = time.Now().UTC()
+
+	r.attachments[a.ID] = a
+
+	return a, nil
+}
+
+func (r *CommentRepo) AttachAttachments(_ context.Context, commentID int64, uploader string, ids []int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// как внешний ключ comment_id в базе
+	if _, ok := r.comments[commentID]; !ok && len(ids) > 0 {
+		return 0, fmt.Errorf("CommentRepo - AttachAttachments: %w", errs.ErrRecordNotFound)
+	}
+
+	return r.attach(commentID, uploader, ids), nil
+}
+
+// attachable - вложение id можно прикрепить: загружено uploader и ни разу не прикреплялось.
+func (r *CommentRepo) attachable(id int64, uploader string) bool {
+	a, ok := r.attachments[id]
+
+	return ok && a.Uploader == uploader && !a.CommentID.Valid && !a.AttachedAt.Valid
+}
+
+// attach - AttachAttachments под уже взятой блокировкой.
+func (r *CommentRepo) attach(commentID int64, uploader string, ids []int64) int {
+	now := time.Now().UTC()
+	attached := 0
+
+	for _, id := range ids {
+		if !r.attachable(id, uploader) {
+			continue
+		}
+
+		a := r.attachments[id]
+		a.CommentID = sql.NullInt64{Int64: commentID, Valid: true}
+		a.AttachedAt = sql.NullTime{Time: now, Valid: true}
+		r.attachments[id] = a
+		attached++
+	}
+
+	return attached
+}
+
+func (r *CommentRepo) GetAttachment(_ context.Context, id int64) (entity.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.attachments[id]
+	if !ok {
+		return entity.Attachment{}, fmt.Errorf("CommentRepo - GetAttachment: %w", errs.ErrRecordNotFound)
+	}
+
+	return a, nil
+}
+
+func (r *CommentRepo) GetAttachments(_ context.Context, commentIDs []int64) (map[int64][]entity.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachments := make(map[int64][]entity.Attachment)
+
+	for _, a := range r.sortedAttachments() {
+		if a.CommentID.Valid && slices.Contains(commentIDs, a.CommentID.Int64) {
+			attachments[a.CommentID.Int64] = append(attachments[a.CommentID.Int64], a)
+		}
+	}
+
+	return attachments, nil
+}
+
+func (r *CommentRepo) GetOrphanAttachments(_ context.Context, uploadedBefore time.Time, limit int) ([]entity.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var orphans []entity.Attachment
+
+	for _, a := range r.sortedAttachments() {
+		if len(orphans) == limit {
+			break
+		}
+
+		if !a.CommentID.Valid && (a.AttachedAt.Valid || a.CreatedAt.Before(uploadedBefore)) {
+			orphans = append(orphans, a)
+		}
+	}
+
+	return orphans, nil
+}
+
+func (r *CommentRepo) DeleteAttachments(_ context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		delete(r.attachments, id)
+	}
+
+	return nil
+}
+
+// sortedAttachments - вложения в порядке загрузки, вызывается под r.mu.
+func (r *CommentRepo) sortedAttachments() []entity.Attachment {
+	as := make([]entity.Attachment, 0, len(r.attachments))
+	for _, a := range r.attachments {
+		as = append(as, a)
+	}
+
+	slices.SortFunc(as, func(a, b entity.Attachment) int { return int(a.ID - b.ID) })
+
+	return as
+}
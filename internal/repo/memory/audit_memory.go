@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+)
+
+// audit - пишет entries от имени того, кто сделал запрос ctx; вызывается под r.mu.
+func (r *CommentRepo) audit(ctx context.Context, entries ...entity.AuditEntry) {
+	info := caller.FromContext(ctx)
+	now := time.Now().UTC()
+
+	for _, e := range entries {
+		r.lastAuditID++
+
+		e.ID = r.lastAuditID
+		e.Actor, e.IP, e.RequestID = info.User, info.IP, requestid.FromContext(ctx)
+		e.CreatedAt = now
+
+		r.auditLog = append(r.auditLog, e)
+	}
+}
+
+// scrubAudit - как у Postgres; вызывается под r.mu.
+func (r *CommentRepo) scrubAudit(author, content string) {
+	for i, e := range r.auditLog {
+		if e.Actor == author {
+			r.auditLog[i].Actor, r.auditLog[i].IP = "", ""
+		}
+
+		if before, ok := entity.ScrubAuditSnapshot(e.Before, author, content); ok {
+			r.auditLog[i].Before = before
+		}
+
+		if after, ok := entity.ScrubAuditSnapshot(e.After, author, content); ok {
+			r.auditLog[i].After = after
+		}
+	}
+}
+
+func (r *CommentRepo) GetAuditLog(_ context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []entity.AuditEntry
+
+	for _, e := range slices.Backward(r.auditLog) {
+		if len(entries) == limit {
+			break
+		}
+
+		if beforeID > 0 && e.ID >= beforeID || !f.Match(e) {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
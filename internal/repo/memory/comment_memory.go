@@ -0,0 +1,805 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// CommentRepo - потокобезопасное хранилище комментариев в памяти.
+// Повторяет семантику Postgres-реализации: деревья в порядке entity.SortTree,
+// total как COUNT(*) OVER() и поиск по словам с AND между ними (без стемминга).
+type CommentRepo struct {
+	mu sync.RWMutex
+
+	lastID   int64
+	comments map[int64]entity.Comment
+	// дети каждого коммента в порядке возрастания id
+	children map[int64][]int64
+
+	// sources - id коммента по source/sourceID (0 - удален), imported - обратное соответствие
+	sources  map[sourceKey]int64
+	imported map[int64]sourceKey
+
+	// notifications - в порядке возрастания id, mutes - заглушенные ветки
+	lastNotificationID int64
+	notifications      []entity.Notification
+	mutes              map[threadKey]struct{}
+
+	// subscriptions - подписки на дайджесты, visits - последний увиденный коммент треда корня
+	subscriptions map[threadKey]entity.Subscription
+	visits        map[threadKey]int64
+
+	// references - цели ссылок ">>id" каждого коммента в порядке текста
+	references map[int64][]int64
+
+	// attachments - вложения по id
+	lastAttachmentID int64
+	attachments      map[int64]entity.Attachment
+
+	// privacyRequests - журнал выгрузок и стираний в порядке записи
+	privacyRequests []entity.PrivacyRequest
+
+	// auditLog - журнал изменений в порядке возрастания id
+	lastAuditID int64
+	auditLog    []entity.AuditEntry
+}
+
+type sourceKey struct {
+	source, sourceID string
+}
+
+type threadKey struct {
+	user      string
+	commentID int64
+}
+
+func New() *CommentRepo {
+	return &CommentRepo{
+		comments: make(map[int64]entity.Comment),
+		children: make(map[int64][]int64),
+		sources:  make(map[sourceKey]int64),
+		imported: make(map[int64]sourceKey),
+		mutes:    make(map[threadKey]struct{}),
+
+		subscriptions: make(map[threadKey]entity.Subscription),
+		visits:        make(map[threadKey]int64),
+
+		references:  make(map[int64][]int64),
+		attachments: make(map[int64]entity.Attachment),
+	}
+}
+
+func (r *CommentRepo) CreateComment(ctx context.Context, parentID, replyToID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	c := entity.Comment{
+		Content:   content,
+		Author:    author,
+		CreatedAt: now,
+		Version:   1,
+		UpdatedAt: now,
+	}
+
+	var path []int64
+
+	if parentID != nil {
+		if _, ok := r.comments[*parentID]; !ok {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - parent: %w", errs.ErrRecordNotFound)
+		}
+
+		c.ParentID = sql.NullInt64{Int64: *parentID, Valid: true}
+
+		path = r.path(*parentID)
+
+		chain := make([]entity.Comment, len(path))
+		for i, id := range path {
+			chain[i] = r.comments[id]
+		}
+
+		switch state, _ := entity.EffectiveLock(chain...); state {
+		case entity.LockArchived:
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment: %w", errs.ErrArchived)
+		case entity.LockLocked:
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment: %w", errs.ErrLocked)
+		}
+	}
+
+	// проверки до первого изменения, как откат транзакции в базе
+	for _, id := range attachmentIDs {
+		if !r.attachable(id, author) {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - attachment %d: %w", id, errs.ErrInvalidAttachment)
+		}
+	}
+
+	r.touch(path, now)
+
+	if replyToID != nil {
+		c.ReplyToID = sql.NullInt64{Int64: *replyToID, Valid: true}
+	}
+
+	r.lastID++
+	c.ID = r.lastID
+
+	r.comments[c.ID] = c
+	if parentID != nil {
+		r.children[*parentID] = append(r.children[*parentID], c.ID)
+	}
+
+	r.addReferences(c.ID, entity.ReferenceTargets(c.ID, markdown.References(content)))
+	r.attach(c.ID, author, attachmentIDs)
+
+	r.audit(ctx, entity.AuditEntry{
+		Action:    entity.AuditCreate,
+		CommentID: sql.NullInt64{Int64: c.ID, Valid: true},
+		After:     entity.AuditSnapshot(c),
+	})
+
+	c.Depth = len(path)
+	c.Path = append(path, c.ID)
+
+	return c, nil
+}
+
+func (r *CommentRepo) CommentExists(_ context.Context, id int64) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return fmt.Errorf("CommentRepo - CommentExists: %w", errs.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) GetCommentWithChildren(_ context.Context, id int64, childSort string) ([]entity.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return nil, fmt.Errorf("CommentRepo - GetComments: %w", errs.ErrRecordNotFound)
+	}
+
+	return entity.SortTree(r.subtree(id, nil), childSort), nil
+}
+
+func (r *CommentRepo) DeleteCommentWithChildren(ctx context.Context, id int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.comments[id]
+	if !ok {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren: %w", errs.ErrRecordNotFound)
+	}
+
+	path := r.path(id)
+	subtree := r.subtree(id, nil)
+
+	// версия каждого предка и ссылающегося на поддерево (с его предками) растет один раз
+	touched := slices.Clone(path[:len(path)-1])
+	for _, referrerID := range r.referrers(subtree) {
+		for _, pathID := range r.path(referrerID) {
+			if !slices.Contains(touched, pathID) {
+				touched = append(touched, pathID)
+			}
+		}
+	}
+	r.touch(touched, time.Now().UTC())
+
+	// снимок - по id, как из базы
+	snapshot := slices.SortedFunc(slices.Values(subtree), func(a, b entity.Comment) int { return cmp.Compare(a.ID, b.ID) })
+	r.audit(ctx, entity.AuditEntry{
+		Action:    entity.AuditDelete,
+		CommentID: sql.NullInt64{Int64: id, Valid: true},
+		Before:    entity.AuditSnapshots(snapshot),
+	})
+
+	for _, sc := range subtree {
+		delete(r.comments, sc.ID)
+		delete(r.children, sc.ID)
+		// ссылки на удаленный коммент остаются, как и в базе
+		delete(r.references, sc.ID)
+
+		if key, ok := r.imported[sc.ID]; ok {
+			r.sources[key] = 0
+			delete(r.imported, sc.ID)
+		}
+	}
+
+	if c.ParentID.Valid {
+		siblings := r.children[c.ParentID.Int64]
+		r.children[c.ParentID.Int64] = slices.DeleteFunc(siblings, func(s int64) bool { return s == id })
+	}
+
+	// как ON DELETE SET NULL у reply_to_id в базе
+	for otherID, other := range r.comments {
+		if other.ReplyToID.Valid {
+			if _, ok := r.comments[other.ReplyToID.Int64]; !ok {
+				other.ReplyToID = sql.NullInt64{}
+				r.comments[otherID] = other
+			}
+		}
+	}
+
+	// как ON DELETE CASCADE у уведомлений, заглушек, подписок и визитов
+	r.notifications = slices.DeleteFunc(r.notifications, func(n entity.Notification) bool {
+		_, ok := r.comments[n.CommentID]
+		return !ok
+	})
+	for key := range r.mutes {
+		if _, ok := r.comments[key.commentID]; !ok {
+			delete(r.mutes, key)
+		}
+	}
+	for key := range r.subscriptions {
+		if _, ok := r.comments[key.commentID]; !ok {
+			delete(r.subscriptions, key)
+		}
+	}
+	for key := range r.visits {
+		if _, ok := r.comments[key.commentID]; !ok {
+			delete(r.visits, key)
+		}
+	}
+
+	// как ON DELETE SET NULL у вложений
+	for attachmentID, a := range r.attachments {
+		if _, ok := r.comments[a.CommentID.Int64]; a.CommentID.Valid && !ok {
+			a.CommentID = sql.NullInt64{}
+			r.attachments[attachmentID] = a
+		}
+	}
+
+	return len(subtree), nil
+}
+
+func (r *CommentRepo) SearchComments(_ context.Context, search string, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	terms := words(search)
+	if len(terms) == 0 {
+		return nil, 0, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found []entity.Comment
+
+	// как и индекс в базе - по тексту без разметки
+	for _, c := range r.comments {
+		if containsAll(words(markdown.Text(c.Content)), terms) {
+			found = append(found, c)
+		}
+	}
+
+	comments, total := page(found, sortBy, order, limit, offset, false)
+
+	return comments, total, nil
+}
+
+func (r *CommentRepo) GetRootComments(_ context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var roots []entity.Comment
+
+	for _, c := range r.comments {
+		if !c.ParentID.Valid {
+			roots = append(roots, c)
+		}
+	}
+
+	comments, total := page(roots, sortBy, order, limit, offset, true)
+
+	if viewer != "" {
+		for i := range comments {
+			r.countUnread(&comments[i], viewer)
+		}
+	}
+
+	return comments, total, nil
+}
+
+func (r *CommentRepo) GetTreesForRoots(_ context.Context, rootIDs []int64, childSort string) ([]entity.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := slices.Clone(rootIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	comments := []entity.Comment{}
+
+	for _, id := range ids {
+		if _, ok := r.comments[id]; ok {
+			comments = r.subtree(id, comments)
+		}
+	}
+
+	return entity.SortTree(comments, childSort), nil
+}
+
+func (r *CommentRepo) GetCommentPath(_ context.Context, id int64) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return nil, fmt.Errorf("CommentRepo - GetCommentPath: %w", errs.ErrRecordNotFound)
+	}
+
+	return r.path(id), nil
+}
+
+// ExportComments - снимок берется под блокировкой, отдается уже без нее,
+// чтобы медленный потребитель не держал запись.
+func (r *CommentRepo) ExportComments(_ context.Context, rootID *int64) iter.Seq2[entity.Comment, error] {
+	return func(yield func(entity.Comment, error) bool) {
+		r.mu.RLock()
+
+		var comments []entity.Comment
+
+		switch {
+		case rootID != nil:
+			if _, ok := r.comments[*rootID]; ok {
+				comments = r.subtree(*rootID, nil)
+			}
+		default:
+			comments = make([]entity.Comment, 0, len(r.comments))
+			for _, c := range r.comments {
+				comments = append(comments, c)
+			}
+
+			slices.SortFunc(comments, func(a, b entity.Comment) int {
+				return cmp.Compare(a.ID, b.ID)
+			})
+		}
+
+		r.mu.RUnlock()
+
+		for _, c := range comments {
+			c.Depth, c.Path = 0, nil
+			if !yield(c, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *CommentRepo) ImportComments(ctx context.Context, comments []entity.Comment) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// сначала проверка, чтобы при ошибке ничего не записать
+	seen := make(map[int64]struct{}, len(comments))
+	for _, c := range comments {
+		if c.ParentID.Valid {
+			if _, ok := seen[c.ParentID.Int64]; !ok {
+				return nil, fmt.Errorf("CommentRepo - ImportComments - parent %d of %d: %w", c.ParentID.Int64, c.ID, errs.ErrRecordNotFound)
+			}
+		}
+		seen[c.ID] = struct{}{}
+	}
+
+	now := time.Now().UTC()
+	ids := make([]int64, len(comments))
+	newIDs := make(map[int64]int64, len(comments))
+
+	for i, c := range comments {
+		r.lastID++
+		ids[i] = r.lastID
+		newIDs[c.ID] = r.lastID
+
+		imported := entity.Comment{
+			ID:        r.lastID,
+			Content:   c.Content,
+			Author:    c.Author,
+			CreatedAt: c.CreatedAt.UTC(),
+			Version:   1,
+		}
+		if imported.CreatedAt.IsZero() {
+			imported.CreatedAt = now
+		}
+		imported.UpdatedAt = imported.CreatedAt
+
+		if c.ParentID.Valid {
+			parentID := newIDs[c.ParentID.Int64]
+			imported.ParentID = sql.NullInt64{Int64: parentID, Valid: true}
+			r.children[parentID] = append(r.children[parentID], imported.ID)
+		}
+
+		r.comments[imported.ID] = imported
+	}
+
+	r.audit(ctx, entity.AuditEntry{Action: entity.AuditImport, After: entity.AuditIDs(ids)})
+
+	return ids, nil
+}
+
+func (r *CommentRepo) CreateFromSource(ctx context.Context, source, sourceID string, c entity.Comment) (entity.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := sourceKey{source: source, sourceID: sourceID}
+	if _, ok := r.sources[key]; ok {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - %s/%s: %w", source, sourceID, errs.ErrAlreadyExists)
+	}
+
+	now := time.Now().UTC()
+
+	var path []int64
+
+	if c.ParentID.Valid {
+		if _, ok := r.comments[c.ParentID.Int64]; !ok {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - parent: %w", errs.ErrRecordNotFound)
+		}
+
+		path = r.path(c.ParentID.Int64)
+		r.touch(path, now)
+	}
+
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+
+	r.lastID++
+	c = entity.Comment{
+		ID:        r.lastID,
+		ParentID:  c.ParentID,
+		Content:   c.Content,
+		Author:    c.Author,
+		CreatedAt: c.CreatedAt.UTC(),
+		Version:   1,
+		UpdatedAt: c.CreatedAt.UTC(),
+	}
+
+	r.comments[c.ID] = c
+	if c.ParentID.Valid {
+		r.children[c.ParentID.Int64] = append(r.children[c.ParentID.Int64], c.ID)
+	}
+
+	r.sources[key] = c.ID
+	r.imported[c.ID] = key
+
+	r.audit(ctx, entity.AuditEntry{
+		Action:    entity.AuditCreate,
+		CommentID: sql.NullInt64{Int64: c.ID, Valid: true},
+		After:     entity.AuditSnapshot(c),
+	})
+
+	c.Depth = len(path)
+	c.Path = append(path, c.ID)
+
+	return c, nil
+}
+
+func (r *CommentRepo) GetSourceMapping(_ context.Context, source string, sourceIDs []string) (map[string]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	mapping := make(map[string]int64)
+
+	for _, sourceID := range sourceIDs {
+		if id, ok := r.sources[sourceKey{source: source, sourceID: sourceID}]; ok {
+			mapping[sourceID] = id
+		}
+	}
+
+	return mapping, nil
+}
+
+func (r *CommentRepo) GetCommentsByIDs(_ context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := make(map[int64]entity.Comment)
+
+	for _, id := range ids {
+		c, ok := r.comments[id]
+		if !ok {
+			continue
+		}
+
+		c.Path = r.path(id)
+		c.Depth = len(c.Path) - 1
+		comments[id] = c
+	}
+
+	return comments, nil
+}
+
+func (r *CommentRepo) GetChildren(_ context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	children := make(map[int64][]entity.Comment)
+
+	for _, parentID := range parentIDs {
+		if len(r.children[parentID]) == 0 {
+			continue
+		}
+
+		replies := make([]entity.Comment, 0, len(r.children[parentID]))
+		for _, id := range r.children[parentID] {
+			replies = append(replies, r.comments[id])
+		}
+
+		replies, _ = page(replies, sortBy, order, limit, offset, true)
+		if len(replies) > 0 {
+			children[parentID] = replies
+		}
+	}
+
+	return children, nil
+}
+
+func (r *CommentRepo) CountReplies(_ context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int64]entity.ReplyCount)
+
+	for _, id := range ids {
+		if _, ok := r.comments[id]; !ok {
+			continue
+		}
+
+		counts[id] = entity.ReplyCount{
+			Children:    len(r.children[id]),
+			Descendants: len(r.subtree(id, nil)) - 1,
+		}
+	}
+
+	return counts, nil
+}
+
+func (r *CommentRepo) SetPinOrder(ctx context.Context, id int64, order *int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.comments[id]
+	if !ok {
+		return fmt.Errorf("CommentRepo - SetPinOrder: %w", errs.ErrRecordNotFound)
+	}
+
+	action := entity.AuditPin
+	if order == nil {
+		action = entity.AuditUnpin
+	}
+
+	before := c
+
+	c.PinOrder = sql.NullInt64{}
+	if order != nil {
+		c.PinOrder = sql.NullInt64{Int64: *order, Valid: true}
+	}
+
+	r.update(ctx, action, before, c)
+
+	return nil
+}
+
+func (r *CommentRepo) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.comments[id]
+	if !ok {
+		return fmt.Errorf("CommentRepo - SetHighlighted: %w", errs.ErrRecordNotFound)
+	}
+
+	action := entity.AuditHighlight
+	if !highlighted {
+		action = entity.AuditUnhighlight
+	}
+
+	before := c
+	c.Highlighted = highlighted
+
+	r.update(ctx, action, before, c)
+
+	return nil
+}
+
+func (r *CommentRepo) SetLock(ctx context.Context, id int64, state, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.comments[id]
+	if !ok {
+		return fmt.Errorf("CommentRepo - SetLock: %w", errs.ErrRecordNotFound)
+	}
+
+	action := entity.AuditLock
+	if state == entity.LockNone {
+		action = entity.AuditUnlock
+	}
+
+	before := c
+	c.LockState, c.LockReason = state, reason
+
+	r.update(ctx, action, before, c)
+
+	return nil
+}
+
+func (r *CommentRepo) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.comments[id]
+	if !ok {
+		return fmt.Errorf("CommentRepo - SetMaxDepth: %w", errs.ErrRecordNotFound)
+	}
+
+	before := c
+
+	c.MaxDepth = sql.NullInt64{}
+	if maxDepth != nil {
+		c.MaxDepth = sql.NullInt64{Int64: *maxDepth, Valid: true}
+	}
+
+	r.update(ctx, entity.AuditMaxDepth, before, c)
+
+	return nil
+}
+
+func (r *CommentRepo) ArchiveInactive(ctx context.Context, before time.Time, reason string) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []int64
+
+	for id, c := range r.comments {
+		if c.ParentID.Valid || c.LockState == entity.LockArchived || !c.UpdatedAt.Before(before) {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		root := r.comments[id]
+
+		archived := root
+		archived.LockState, archived.LockReason = entity.LockArchived, reason
+		r.comments[id] = archived
+
+		r.audit(ctx, entity.AuditEntry{
+			Action:    entity.AuditArchive,
+			CommentID: sql.NullInt64{Int64: id, Valid: true},
+			Before:    entity.AuditSnapshot(root),
+			After:     entity.AuditSnapshot(archived),
+		})
+	}
+
+	r.touch(ids, time.Now().UTC())
+
+	return ids, nil
+}
+
+// update - сохраняет измененный коммент, повышает версии его и предков и пишет в журнал action.
+func (r *CommentRepo) update(ctx context.Context, action string, before, after entity.Comment) {
+	r.comments[after.ID] = after
+	r.touch(r.path(after.ID), time.Now().UTC())
+
+	r.audit(ctx, entity.AuditEntry{
+		Action:    action,
+		CommentID: sql.NullInt64{Int64: after.ID, Valid: true},
+		Before:    entity.AuditSnapshot(before),
+		After:     entity.AuditSnapshot(after),
+	})
+}
+
+// path - id предков от корня до самого коммента; коммент id должен существовать.
+func (r *CommentRepo) path(id int64) []int64 {
+	c := r.comments[id]
+
+	path := []int64{c.ID}
+	for c.ParentID.Valid {
+		c = r.comments[c.ParentID.Int64]
+		path = append(path, c.ID)
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
+
+// touch - повышает версии комментов ids, как при изменении в их поддеревьях.
+func (r *CommentRepo) touch(ids []int64, now time.Time) {
+	for _, id := range ids {
+		c := r.comments[id]
+		c.Version++
+		c.UpdatedAt = now
+		r.comments[id] = c
+	}
+}
+
+// subtree - обход в глубину от id; дети в порядке id, поэтому результат упорядочен по path.
+func (r *CommentRepo) subtree(id int64, dst []entity.Comment) []entity.Comment {
+	var walk func(id int64, depth int, path []int64)
+
+	walk = func(id int64, depth int, path []int64) {
+		c := r.comments[id]
+		c.Depth = depth
+		c.Path = append(slices.Clone(path), id)
+
+		dst = append(dst, c)
+
+		for _, child := range r.children[id] {
+			walk(child, depth+1, c.Path)
+		}
+	}
+
+	walk(id, 0, nil)
+
+	return dst
+}
+
+// page - сортировка, LIMIT/OFFSET и total. Как и COUNT(*) OVER() в Postgres,
+// total равен нулю, если на странице нет ни одной строки.
+// pinnedFirst - закрепленные идут раньше остальных независимо от order.
+func page(comments []entity.Comment, sortBy, order string, limit, offset int, pinnedFirst bool) ([]entity.Comment, int) {
+	desc := strings.EqualFold(order, "DESC")
+
+	sort.Slice(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+
+		if pinnedFirst {
+			if c := entity.ComparePinned(a, b); c != 0 {
+				return c < 0
+			}
+		}
+
+		if desc {
+			a, b = b, a
+		}
+
+		if sortBy == "created_at" && !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+
+		return a.ID < b.ID
+	})
+
+	total := len(comments)
+	if offset >= total {
+		return nil, 0
+	}
+
+	end := min(offset+limit, total)
+
+	return comments[offset:end], total
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func containsAll(haystack, needles []string) bool {
+	for _, n := range needles {
+		if !slices.Contains(haystack, n) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/repotest"
+)
+
+func TestCommentRepo(t *testing.T) {
+	repotest.TestCommentRepo(t, func(_ *testing.T) repo.CommentRepo {
+		return memory.New()
+	})
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+func (r *CommentRepo) CreateNotifications(_ context.Context, ns []entity.Notification) ([]entity.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(ns) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+
+	created := make([]entity.Notification, 0, len(ns))
+	for _, n := range ns {
+		if _, ok := r.comments[n.CommentID]; !ok {
+			return nil, fmt.Errorf("CommentRepo - CreateNotifications - comment %d: %w", n.CommentID, errs.ErrRecordNotFound)
+		}
+
+		r.lastNotificationID++
+		n.ID = r.lastNotificationID
+		n.CreatedAt = now
+
+		created = append(created, n)
+	}
+
+	r.notifications = append(r.notifications, created...)
+
+	return created, nil
+}
+
+func (r *CommentRepo) GetNotifications(_ context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ns []entity.Notification
+
+	for _, n := range slices.Backward(r.notifications) {
+		if len(ns) == limit {
+			break
+		}
+
+		switch {
+		case n.Recipient != recipient,
+			beforeID > 0 && n.ID >= beforeID,
+			status == entity.NotificationsUnread && n.ReadAt.Valid,
+			status == entity.NotificationsRead && !n.ReadAt.Valid:
+			continue
+		}
+
+		n.Content = r.comments[n.CommentID].Content
+		ns = append(ns, n)
+	}
+
+	return ns, nil
+}
+
+func (r *CommentRepo) MarkNotificationsRead(_ context.Context, recipient string, ids []int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	var read int
+	for i, n := range r.notifications {
+		if n.Recipient != recipient || n.ReadAt.Valid || (ids != nil && !slices.Contains(ids, n.ID)) {
+			continue
+		}
+
+		r.notifications[i].ReadAt = sql.NullTime{Time: now, Valid: true}
+		read++
+	}
+
+	return read, nil
+}
+
+func (r *CommentRepo) SetMute(_ context.Context, user string, id int64, muted bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return fmt.Errorf("CommentRepo - SetMute: %w", errs.ErrRecordNotFound)
+	}
+
+	key := threadKey{user: user, commentID: id}
+	if muted {
+		r.mutes[key] = struct{}{}
+	} else {
+		delete(r.mutes, key)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) GetMutedUsers(_ context.Context, ids []int64, users []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var muted []string
+
+	for _, user := range users {
+		for _, id := range ids {
+			if _, ok := r.mutes[threadKey{user: user, commentID: id}]; ok && !slices.Contains(muted, user) {
+				muted = append(muted, user)
+			}
+		}
+	}
+
+	slices.Sort(muted)
+
+	return muted, nil
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+// GetAuthorData - время заглушек и визитов в памяти не хранится, у них CreatedAt и SeenAt пустые.
+func (r *CommentRepo) GetAuthorData(_ context.Context, author string) (entity.AuthorData, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data := entity.AuthorData{Author: author}
+
+	for _, c := range r.comments {
+		if c.Author == author {
+			data.Comments = append(data.Comments, entity.Comment{
+				ID:        c.ID,
+				ParentID:  c.ParentID,
+				ReplyToID: c.ReplyToID,
+				Content:   c.Content,
+				Author:    c.Author,
+				CreatedAt: c.CreatedAt,
+			})
+		}
+	}
+	slices.SortFunc(data.Comments, func(a, b entity.Comment) int { return cmp.Compare(a.ID, b.ID) })
+
+	for _, a := range r.sortedAttachments() {
+		if a.Uploader == author {
+			data.Attachments = append(data.Attachments, a)
+		}
+	}
+
+	for key := range r.mutes {
+		if key.user == author {
+			data.Mutes = append(data.Mutes, entity.Mute{CommentID: key.commentID})
+		}
+	}
+	slices.SortFunc(data.Mutes, func(a, b entity.Mute) int { return cmp.Compare(a.CommentID, b.CommentID) })
+
+	for key, lastSeenID := range r.visits {
+		if key.user == author {
+			data.Visits = append(data.Visits, entity.Visit{RootID: key.commentID, LastSeenID: lastSeenID})
+		}
+	}
+	slices.SortFunc(data.Visits, func(a, b entity.Visit) int { return cmp.Compare(a.RootID, b.RootID) })
+
+	return data, nil
+}
+
+func (r *CommentRepo) EraseAuthor(ctx context.Context, author, content string) (entity.Erasure, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var erasure entity.Erasure
+
+	for id, c := range r.comments {
+		if c.Author == author {
+			erasure.Comments = append(erasure.Comments, id)
+		}
+	}
+	slices.Sort(erasure.Comments)
+
+	// версия каждого коммента автора, ссылающихся на них и их предков растет один раз
+	affected := slices.Clone(erasure.Comments)
+	for sourceID, targetIDs := range r.references {
+		if slices.ContainsFunc(targetIDs, func(id int64) bool { return slices.Contains(erasure.Comments, id) }) {
+			affected = append(affected, sourceID)
+		}
+	}
+	for _, id := range affected {
+		for _, pathID := range r.path(id) {
+			if !slices.Contains(erasure.Touched, pathID) {
+				erasure.Touched = append(erasure.Touched, pathID)
+			}
+		}
+	}
+	slices.Sort(erasure.Touched)
+
+	now := time.Now().UTC()
+	r.touch(erasure.Touched, now)
+
+	for _, id := range erasure.Comments {
+		c := r.comments[id]
+		c.Author = ""
+		c.Content = content
+		r.comments[id] = c
+
+		delete(r.references, id)
+	}
+
+	for _, a := range r.sortedAttachments() {
+		if a.Uploader != author {
+			continue
+		}
+
+		a.CommentID = sql.NullInt64{}
+		a.Uploader = ""
+		if !a.AttachedAt.Valid {
+			a.AttachedAt = sql.NullTime{Time: now, Valid: true}
+		}
+		r.attachments[a.ID] = a
+
+		erasure.Attachments = append(erasure.Attachments, a)
+	}
+
+	r.notifications = slices.DeleteFunc(r.notifications, func(n entity.Notification) bool { return n.Recipient == author })
+	for i, n := range r.notifications {
+		if n.Actor == author {
+			r.notifications[i].Actor = ""
+		}
+	}
+
+	for key := range r.mutes {
+		if key.user == author {
+			delete(r.mutes, key)
+		}
+	}
+	for key := range r.subscriptions {
+		if key.user == author {
+			delete(r.subscriptions, key)
+		}
+	}
+	for key := range r.visits {
+		if key.user == author {
+			delete(r.visits, key)
+		}
+	}
+
+	r.scrubAudit(author, content)
+	r.audit(ctx, entity.AuditEntry{Action: entity.AuditErase, After: entity.AuditIDs(erasure.Comments)})
+
+	return erasure, nil
+}
+
+func (r *CommentRepo) LogPrivacyRequest(_ context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p.ID = int64(len(r.privacyRequests) + 1)
+	p.CreatedAt = time.Now().UTC()
+
+	r.privacyRequests = append(r.privacyRequests, p)
+
+	return p, nil
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+func (r *CommentRepo) CreateReferences(_ context.Context, sourceID int64, targetIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.addReferences(sourceID, targetIDs)
+
+	return nil
+}
+
+// addReferences - CreateReferences под уже взятой блокировкой.
+func (r *CommentRepo) addReferences(sourceID int64, targetIDs []int64) {
+	for _, targetID := range targetIDs {
+		if _, ok := r.comments[targetID]; ok && !slices.Contains(r.references[sourceID], targetID) {
+			r.references[sourceID] = append(r.references[sourceID], targetID)
+		}
+	}
+}
+
+func (r *CommentRepo) GetReferences(_ context.Context, sourceIDs []int64) (map[int64][]entity.Reference, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	refs := make(map[int64][]entity.Reference)
+
+	for _, sourceID := range sourceIDs {
+		for _, targetID := range r.references[sourceID] {
+			ref := entity.Reference{SourceID: sourceID, TargetID: targetID}
+
+			if c, ok := r.comments[targetID]; ok {
+				ref.Target = &entity.Comment{ID: c.ID, ParentID: c.ParentID, Content: c.Content, Author: c.Author, CreatedAt: c.CreatedAt}
+			}
+
+			refs[sourceID] = append(refs[sourceID], ref)
+		}
+	}
+
+	return refs, nil
+}
+
+func (r *CommentRepo) GetBacklinks(_ context.Context, targetID int64, limit, offset int) ([]entity.Comment, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var comments []entity.Comment
+
+	for sourceID, targetIDs := range r.references {
+		if slices.Contains(targetIDs, targetID) {
+			c := r.comments[sourceID]
+			comments = append(comments, entity.Comment{ID: c.ID, ParentID: c.ParentID, Content: c.Content, Author: c.Author, CreatedAt: c.CreatedAt})
+		}
+	}
+
+	comments, total := page(comments, "id", "DESC", limit, offset, false)
+
+	return comments, total, nil
+}
+
+func (r *CommentRepo) GetReferrers(_ context.Context, targetIDs []int64) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []int64
+
+	for sourceID, refs := range r.references {
+		if slices.ContainsFunc(refs, func(id int64) bool { return slices.Contains(targetIDs, id) }) {
+			ids = append(ids, sourceID)
+		}
+	}
+
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
+// referrers - комменты вне subtree, ссылающиеся на комменты из него. Вызывается под блокировкой.
+func (r *CommentRepo) referrers(subtree []entity.Comment) []int64 {
+	inSubtree := func(id int64) bool {
+		return slices.ContainsFunc(subtree, func(c entity.Comment) bool { return c.ID == id })
+	}
+
+	var ids []int64
+
+	for sourceID, targetIDs := range r.references {
+		if !inSubtree(sourceID) && slices.ContainsFunc(targetIDs, inSubtree) {
+			ids = append(ids, sourceID)
+		}
+	}
+
+	return ids
+}
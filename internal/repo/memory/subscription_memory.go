@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+func (r *CommentRepo) Subscribe(_ context.Context, subscriber string, id int64, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return fmt.Errorf("CommentRepo - Subscribe: %w", errs.ErrRecordNotFound)
+	}
+
+	key := threadKey{user: subscriber, commentID: id}
+	if _, ok := r.subscriptions[key]; !ok {
+		now := time.Now().UTC()
+
+		r.subscriptions[key] = entity.Subscription{
+			Subscriber:    subscriber,
+			CommentID:     id,
+			CreatedAt:     now,
+			LastCommentID: r.lastID,
+			DigestedAt:    now,
+		}
+	}
+
+	for key, s := range r.subscriptions {
+		if key.user == subscriber {
+			s.Email = email
+			r.subscriptions[key] = s
+		}
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) Unsubscribe(_ context.Context, subscriber string, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.subscriptions {
+		if key.user == subscriber && (id == 0 || key.commentID == id) {
+			delete(r.subscriptions, key)
+		}
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) GetSubscriptions(_ context.Context, subscriber string) ([]entity.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var subs []entity.Subscription
+
+	for key, s := range r.subscriptions {
+		if key.user == subscriber {
+			subs = append(subs, s)
+		}
+	}
+
+	slices.SortFunc(subs, func(a, b entity.Subscription) int { return cmp.Compare(a.CommentID, b.CommentID) })
+
+	return subs, nil
+}
+
+func (r *CommentRepo) GetDueSubscribers(_ context.Context, before time.Time, after string, limit int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var subscribers []string
+
+	for key, s := range r.subscriptions {
+		if key.user > after && !s.DigestedAt.After(before) && !slices.Contains(subscribers, key.user) {
+			subscribers = append(subscribers, key.user)
+		}
+	}
+
+	slices.Sort(subscribers)
+
+	if len(subscribers) > limit {
+		subscribers = subscribers[:limit]
+	}
+
+	return subscribers, nil
+}
+
+func (r *CommentRepo) MarkDigested(_ context.Context, subscriber string, lastIDs map[int64]int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, lastID := range lastIDs {
+		key := threadKey{user: subscriber, commentID: id}
+
+		s, ok := r.subscriptions[key]
+		if !ok {
+			continue
+		}
+
+		s.LastCommentID = max(s.LastCommentID, lastID)
+		s.DigestedAt = at.UTC()
+		r.subscriptions[key] = s
+	}
+
+	return nil
+}
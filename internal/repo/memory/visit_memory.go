@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+func (r *CommentRepo) MarkSeen(_ context.Context, viewer string, rootID int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[rootID]; !ok {
+		return 0, fmt.Errorf("CommentRepo - MarkSeen: %w", errs.ErrRecordNotFound)
+	}
+
+	key := threadKey{user: viewer, commentID: rootID}
+	r.visits[key] = max(r.visits[key], r.lastID)
+
+	return r.visits[key], nil
+}
+
+func (r *CommentRepo) GetLastSeen(_ context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lastSeen := make(map[int64]int64, len(rootIDs))
+
+	for _, rootID := range rootIDs {
+		if lastSeenID, ok := r.visits[threadKey{user: viewer, commentID: rootID}]; ok {
+			lastSeen[rootID] = lastSeenID
+		}
+	}
+
+	return lastSeen, nil
+}
+
+// countUnread - LastSeenID и NewCount корня root для viewer. Вызывается под блокировкой.
+func (r *CommentRepo) countUnread(root *entity.Comment, viewer string) {
+	root.LastSeenID = r.visits[threadKey{user: viewer, commentID: root.ID}]
+
+	for _, c := range r.subtree(root.ID, nil) {
+		if entity.Unread(c, viewer, root.LastSeenID) {
+			root.NewCount++
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Table
+	attachmentsTable = "attachments"
+
+	// Columns
+	attachedAtColumn = "attached_at"
+)
+
+var attachmentColumns = []string{
+	"id", "comment_id", "uploader", "storage_key", "thumbnail_key", "filename",
+	"content_type", "size", "width", "height", "created_at", "attached_at",
+}
+
+type attachmentScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttachment(s attachmentScanner) (entity.Attachment, error) {
+	var a entity.Attachment
+
+	err := s.Scan(&a.ID, &a.CommentID, &a.Uploader, &a.Key, &a.ThumbnailKey, &a.Filename,
+		&a.ContentType, &a.Size, &a.Width, &a.Height, &a.CreatedAt, &a.AttachedAt)
+
+	return a, err
+}
+
+// attachQuery - прикрепляются только ни разу не прикреплявшиеся вложения того же загрузившего.
+func attachQuery(b squirrel.StatementBuilderType, commentID int64, uploader string, ids []int64, now time.Time) squirrel.UpdateBuilder {
+	return b.
+		Update(attachmentsTable).
+		Set(commentIDColumn, commentID).
+		Set(attachedAtColumn, now).
+		Where(squirrel.Eq{idColumn: ids, "uploader": uploader, commentIDColumn: nil, attachedAtColumn: nil})
+}
+
+// orphansQuery - открепленные удалением коммента сразу, не прикрепленные - после uploadedBefore.
+func orphansQuery(b squirrel.StatementBuilderType, uploadedBefore time.Time, limit int) squirrel.SelectBuilder {
+	return b.
+		Select(attachmentColumns...).
+		From(attachmentsTable).
+		Where(squirrel.Eq{commentIDColumn: nil}).
+		Where(squirrel.Or{squirrel.NotEq{attachedAtColumn: nil}, squirrel.Lt{createdAtColumn: uploadedBefore}}).
+		OrderBy(idColumn).
+		Limit(uint64(limit)) //nolint:gosec // limit задает фоновая задача
+}
+
+func (r *CommentRepo) CreateAttachment(ctx context.Context, a entity.Attachment) (entity.Attachment, error) {
+	sqlq, args, err := r.Builder.
+		Insert(attachmentsTable).
+		Columns("uploader", "storage_key", "thumbnail_key", "filename", "content_type", "size", "width", "height").
+		Values(a.Uploader, a.Key, a.ThumbnailKey, a.Filename, a.ContentType, a.Size, a.Width, a.Height).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("CommentRepo - CreateAttachment - r.Builder.ToSql: %w", err)
+	}
+
+	err = r.Pool.QueryRow(ctx, sqlq, args...).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("CommentRepo - CreateAttachment - r.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *CommentRepo) AttachAttachments(ctx context.Context, commentID int64, uploader string, ids []int64) (int, error) {
+	n, err := r.attachAttachments(ctx, r.Pool, commentID, uploader, ids)
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - AttachAttachments - r.attachAttachments: %w", err)
+	}
+
+	return n, nil
+}
+
+// attachAttachments - AttachAttachments в транзакции вызывающего, если q - транзакция.
+func (r *CommentRepo) attachAttachments(ctx context.Context, q execer, commentID int64, uploader string, ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	sqlq, args, err := attachQuery(r.Builder, commentID, uploader, ids, time.Now().UTC()).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	tag, err := q.Exec(ctx, sqlq, args...)
+	if err != nil {
+		return 0, fmt.Errorf("q.Exec: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *CommentRepo) GetAttachment(ctx context.Context, id int64) (entity.Attachment, error) {
+	sqlq, args, err := r.Builder.
+		Select(attachmentColumns...).
+		From(attachmentsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		ToSql()
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("CommentRepo - GetAttachment - r.Builder.ToSql: %w", err)
+	}
+
+	a, err := scanAttachment(r.Pool.QueryRow(ctx, sqlq, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Attachment{}, fmt.Errorf("CommentRepo - GetAttachment: %w", errs.ErrRecordNotFound)
+		}
+
+		return entity.Attachment{}, fmt.Errorf("CommentRepo - GetAttachment - scanAttachment: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *CommentRepo) GetAttachments(ctx context.Context, commentIDs []int64) (map[int64][]entity.Attachment, error) {
+	attachments := make(map[int64][]entity.Attachment)
+
+	if len(commentIDs) == 0 {
+		return attachments, nil
+	}
+
+	sqlq, args, err := r.Builder.
+		Select(attachmentColumns...).
+		From(attachmentsTable).
+		Where(squirrel.Eq{commentIDColumn: commentIDs}).
+		OrderBy(commentIDColumn, idColumn).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetAttachments - r.Builder.ToSql: %w", err)
+	}
+
+	as, err := r.queryAttachments(ctx, sqlq, args)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetAttachments - r.queryAttachments: %w", err)
+	}
+
+	for _, a := range as {
+		attachments[a.CommentID.Int64] = append(attachments[a.CommentID.Int64], a)
+	}
+
+	return attachments, nil
+}
+
+func (r *CommentRepo) GetOrphanAttachments(ctx context.Context, uploadedBefore time.Time, limit int) ([]entity.Attachment, error) {
+	sqlq, args, err := orphansQuery(r.Builder, uploadedBefore, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetOrphanAttachments - r.Builder.ToSql: %w", err)
+	}
+
+	as, err := r.queryAttachments(ctx, sqlq, args)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetOrphanAttachments - r.queryAttachments: %w", err)
+	}
+
+	return as, nil
+}
+
+func (r *CommentRepo) DeleteAttachments(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := r.Pool.Exec(ctx, `DELETE FROM attachments WHERE id = ANY($1);`, ids)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - DeleteAttachments - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) queryAttachments(ctx context.Context, sqlq string, args []any) ([]entity.Attachment, error) {
+	rows, err := r.Pool.Query(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var as []entity.Attachment
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanAttachment: %w", err)
+		}
+
+		as = append(as, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return as, nil
+}
@@ -0,0 +1,179 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+func (r *SQLiteCommentRepo) CreateAttachment(ctx context.Context, a entity.Attachment) (entity.Attachment, error) {
+	a.CreatedAt = time.Now().UTC()
+
+	sqlq, args, err := r.Builder.
+		Insert(attachmentsTable).
+		Columns("uploader", "storage_key", "thumbnail_key", "filename", "content_type", "size", "width", "height", createdAtColumn).
+		Values(a.Uploader, a.Key, a.ThumbnailKey, a.Filename, a.ContentType, a.Size, a.Width, a.Height, a.CreatedAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("SQLiteCommentRepo - CreateAttachment - r.Builder.ToSql: %w", err)
+	}
+
+	err = r.DB.QueryRowContext(ctx, sqlq, args...).Scan(&a.ID)
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("SQLiteCommentRepo - CreateAttachment - r.DB.QueryRowContext.Scan: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *SQLiteCommentRepo) AttachAttachments(ctx context.Context, commentID int64, uploader string, ids []int64) (int, error) {
+	n, err := r.attachAttachments(ctx, r.DB, commentID, uploader, ids)
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - AttachAttachments - r.attachAttachments: %w", err)
+	}
+
+	return n, nil
+}
+
+type sqliteExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// attachAttachments - AttachAttachments в транзакции вызывающего, если q - транзакция.
+func (r *SQLiteCommentRepo) attachAttachments(ctx context.Context, q sqliteExecer, commentID int64, uploader string, ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	sqlq, args, err := attachQuery(r.Builder, commentID, uploader, ids, time.Now().UTC()).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	res, err := q.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return 0, fmt.Errorf("q.ExecContext: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("res.RowsAffected: %w", err)
+	}
+
+	return int(n), nil
+}
+
+func (r *SQLiteCommentRepo) GetAttachment(ctx context.Context, id int64) (entity.Attachment, error) {
+	sqlq, args, err := r.Builder.
+		Select(attachmentColumns...).
+		From(attachmentsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		ToSql()
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("SQLiteCommentRepo - GetAttachment - r.Builder.ToSql: %w", err)
+	}
+
+	a, err := scanAttachment(r.DB.QueryRowContext(ctx, sqlq, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Attachment{}, fmt.Errorf("SQLiteCommentRepo - GetAttachment: %w", errs.ErrRecordNotFound)
+		}
+
+		return entity.Attachment{}, fmt.Errorf("SQLiteCommentRepo - GetAttachment - scanAttachment: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *SQLiteCommentRepo) GetAttachments(ctx context.Context, commentIDs []int64) (map[int64][]entity.Attachment, error) {
+	attachments := make(map[int64][]entity.Attachment)
+
+	if len(commentIDs) == 0 {
+		return attachments, nil
+	}
+
+	sqlq, args, err := r.Builder.
+		Select(attachmentColumns...).
+		From(attachmentsTable).
+		Where(squirrel.Eq{commentIDColumn: commentIDs}).
+		OrderBy(commentIDColumn, idColumn).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetAttachments - r.Builder.ToSql: %w", err)
+	}
+
+	as, err := r.queryAttachments(ctx, sqlq, args)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetAttachments - r.queryAttachments: %w", err)
+	}
+
+	for _, a := range as {
+		attachments[a.CommentID.Int64] = append(attachments[a.CommentID.Int64], a)
+	}
+
+	return attachments, nil
+}
+
+func (r *SQLiteCommentRepo) GetOrphanAttachments(ctx context.Context, uploadedBefore time.Time, limit int) ([]entity.Attachment, error) {
+	sqlq, args, err := orphansQuery(r.Builder, uploadedBefore.UTC(), limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetOrphanAttachments - r.Builder.ToSql: %w", err)
+	}
+
+	as, err := r.queryAttachments(ctx, sqlq, args)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetOrphanAttachments - r.queryAttachments: %w", err)
+	}
+
+	return as, nil
+}
+
+func (r *SQLiteCommentRepo) DeleteAttachments(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	sqlq, args, err := r.Builder.Delete(attachmentsTable).Where(squirrel.Eq{idColumn: ids}).ToSql()
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - DeleteAttachments - r.Builder.ToSql: %w", err)
+	}
+
+	_, err = r.DB.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - DeleteAttachments - r.DB.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) queryAttachments(ctx context.Context, sqlq string, args []any) ([]entity.Attachment, error) {
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var as []entity.Attachment
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanAttachment: %w", err)
+		}
+
+		as = append(as, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return as, nil
+}
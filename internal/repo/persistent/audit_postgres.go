@@ -0,0 +1,183 @@
+package persistent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Table
+	auditLogTable = "audit_log"
+
+	// auditSnapshotColumns - колонки entity.AuditComment
+	auditSnapshotColumns = "id, parent_id, reply_to_id, author, content, created_at, pin_order, highlighted, lock_state, lock_reason, max_depth"
+)
+
+var auditColumns = []string{idColumn, "action", commentIDColumn, actorColumn, "ip", "request_id", "before", "after", createdAtColumn}
+
+// auditSource - кто и откуда сделал запрос ctx, у фоновых задач все пусто.
+func auditSource(ctx context.Context) (actor, ip, requestID string) {
+	info := caller.FromContext(ctx)
+
+	return info.User, info.IP, requestid.FromContext(ctx)
+}
+
+// auditQuery - страница журнала от новых к старым, общая для Postgres и SQLite.
+func auditQuery(b squirrel.StatementBuilderType, f entity.AuditFilter, beforeID int64, limit int) squirrel.SelectBuilder {
+	q := b.
+		Select(auditColumns...).
+		From(auditLogTable).
+		OrderBy(idColumn + " DESC").
+		Limit(uint64(limit))
+
+	if f.Actor != "" {
+		q = q.Where(squirrel.Eq{actorColumn: f.Actor})
+	}
+	if f.Action != "" {
+		q = q.Where(squirrel.Eq{"action": f.Action})
+	}
+	if f.CommentID != 0 {
+		q = q.Where(squirrel.Eq{commentIDColumn: f.CommentID})
+	}
+	if !f.From.IsZero() {
+		q = q.Where(squirrel.GtOrEq{createdAtColumn: f.From.UTC()})
+	}
+	if !f.To.IsZero() {
+		q = q.Where(squirrel.Lt{createdAtColumn: f.To.UTC()})
+	}
+	if beforeID > 0 {
+		q = q.Where(squirrel.Lt{idColumn: beforeID})
+	}
+
+	return q
+}
+
+// audit - пишет entries от имени того, кто сделал запрос ctx, в транзакции изменения.
+func (r *CommentRepo) audit(ctx context.Context, tx pgx.Tx, entries ...entity.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	actor, ip, requestID := auditSource(ctx)
+
+	q := r.Builder.
+		Insert(auditLogTable).
+		Columns("action", commentIDColumn, actorColumn, "ip", "request_id", "before", "after")
+
+	for _, e := range entries {
+		q = q.Values(e.Action, e.CommentID, actor, ip, requestID, e.Before, e.After)
+	}
+
+	sqlq, args, err := q.ToSql()
+	if err != nil {
+		return fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	return nil
+}
+
+// auditComments - комменты ids по возрастанию id с полями снимка.
+func auditComments(ctx context.Context, q querier, ids []int64) ([]entity.Comment, error) {
+	rows, err := q.Query(ctx, `SELECT `+auditSnapshotColumns+` FROM comments WHERE id = ANY($1) ORDER BY id;`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("q.Query: %w", err)
+	}
+
+	comments, err := pgx.CollectRows(rows, scanAuditComment)
+	if err != nil {
+		return nil, fmt.Errorf("pgx.CollectRows: %w", err)
+	}
+
+	return comments, nil
+}
+
+// auditSubtree - как auditComments, для коммента id со всеми потомками.
+func auditSubtree(ctx context.Context, q querier, id int64) ([]entity.Comment, error) {
+	rows, err := q.Query(ctx, `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM comments WHERE id = $1
+
+		UNION ALL
+
+		SELECT c.id
+		FROM comments c
+		INNER JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT `+auditSnapshotColumns+`
+	FROM comments
+	WHERE id IN (SELECT id FROM subtree)
+	ORDER BY id;
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("q.Query: %w", err)
+	}
+
+	comments, err := pgx.CollectRows(rows, scanAuditComment)
+	if err != nil {
+		return nil, fmt.Errorf("pgx.CollectRows: %w", err)
+	}
+
+	return comments, nil
+}
+
+func scanAuditComment(row pgx.CollectableRow) (entity.Comment, error) {
+	var c entity.Comment
+
+	err := row.Scan(&c.ID, &c.ParentID, &c.ReplyToID, &c.Author, &c.Content, &c.CreatedAt,
+		&c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.MaxDepth)
+
+	return c, err
+}
+
+// scrubAudit - стирает author из журнала: его имя и адрес в записях о его действиях,
+// его имя и текст (на content) в снимках его комментов. Менять журнал может только
+// audit_log_scrub из миграции: она выполняется от роли audit_scrubber, которую пропускает триггер.
+func (r *CommentRepo) scrubAudit(ctx context.Context, tx pgx.Tx, author, content string) error {
+	_, err := tx.Exec(ctx, `SELECT audit_log_scrub($1, $2);`, author, content)
+	if err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	sqlq, args, err := auditQuery(r.Builder, f, beforeID, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetAuditLog - r.Builder.ToSql: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetAuditLog - r.Pool.Query: %w", err)
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.AuditEntry, error) {
+		var (
+			e             entity.AuditEntry
+			before, after []byte
+		)
+
+		err := row.Scan(&e.ID, &e.Action, &e.CommentID, &e.Actor, &e.IP, &e.RequestID, &before, &after, &e.CreatedAt)
+		e.Before, e.After = json.RawMessage(before), json.RawMessage(after)
+
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetAuditLog - pgx.CollectRows: %w", err)
+	}
+
+	return entries, nil
+}
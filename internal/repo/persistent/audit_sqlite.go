@@ -0,0 +1,235 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+// sqliteAuditColumns - auditColumns одной строкой для запросов без билдера.
+const sqliteAuditColumns = "id, action, comment_id, actor, ip, request_id, before, after, created_at"
+
+// audit - как у Postgres; снимки хранятся текстом.
+func (r *SQLiteCommentRepo) audit(ctx context.Context, tx *sql.Tx, entries ...entity.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	actor, ip, requestID := auditSource(ctx)
+	now := time.Now().UTC()
+
+	q := r.Builder.
+		Insert(auditLogTable).
+		Columns("action", commentIDColumn, actorColumn, "ip", "request_id", "before", "after", createdAtColumn)
+
+	for _, e := range entries {
+		q = q.Values(e.Action, e.CommentID, actor, ip, requestID, sqliteJSON(e.Before), sqliteJSON(e.After), now)
+	}
+
+	sqlq, args, err := q.ToSql()
+	if err != nil {
+		return fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// auditComments - комменты ids по возрастанию id с полями снимка.
+func (r *SQLiteCommentRepo) auditComments(ctx context.Context, q sqliteQuerier, ids []int64) ([]entity.Comment, error) {
+	sqlq, args, err := r.Builder.
+		Select(auditSnapshotColumns).
+		From(commentsTable).
+		Where(squirrel.Eq{idColumn: ids}).
+		OrderBy(idColumn).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	return queryAuditComments(ctx, q, sqlq, args...)
+}
+
+// auditSubtree - как auditComments, для коммента id со всеми потомками.
+func (r *SQLiteCommentRepo) auditSubtree(ctx context.Context, q sqliteQuerier, id int64) ([]entity.Comment, error) {
+	return queryAuditComments(ctx, q, `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM comments WHERE id = ?
+
+		UNION ALL
+
+		SELECT c.id
+		FROM comments c
+		INNER JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT `+auditSnapshotColumns+`
+	FROM comments
+	WHERE id IN (SELECT id FROM subtree)
+	ORDER BY id;
+	`, id)
+}
+
+func queryAuditComments(ctx context.Context, q sqliteQuerier, sqlq string, args ...any) ([]entity.Comment, error) {
+	rows, err := q.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("q.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []entity.Comment
+
+	for rows.Next() {
+		var c entity.Comment
+
+		err = rows.Scan(&c.ID, &c.ParentID, &c.ReplyToID, &c.Author, &c.Content, &c.CreatedAt,
+			&c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.MaxDepth)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return comments, nil
+}
+
+// scrubAudit - как audit_log_scrub у Postgres, снимки ищутся по тексту entity.AuthorPattern.
+// Ролей в SQLite нет: триггер audit_log_no_update пропускает UPDATE, пока в audit_log_scrub
+// есть строка; она живет только внутри tx.
+func (r *SQLiteCommentRepo) scrubAudit(ctx context.Context, tx *sql.Tx, author, content string) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO audit_log_scrub (active) VALUES (1);`)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext insert audit_log_scrub: %w", err)
+	}
+
+	pattern := entity.AuthorPattern(author)
+
+	entries, err := queryAuditEntries(ctx, tx, `
+	SELECT `+sqliteAuditColumns+`
+	FROM audit_log
+	WHERE actor = ? OR instr(before, ?) > 0 OR instr(after, ?) > 0
+	ORDER BY id;
+	`, author, pattern, pattern)
+	if err != nil {
+		return fmt.Errorf("queryAuditEntries: %w", err)
+	}
+
+	for _, e := range entries {
+		values := scrubbedAudit(e, author, content)
+		for _, column := range []string{"before", "after"} {
+			if raw, ok := values[column].(json.RawMessage); ok {
+				values[column] = sqliteJSON(raw)
+			}
+		}
+
+		sqlq, args, err := r.Builder.Update(auditLogTable).SetMap(values).Where(squirrel.Eq{idColumn: e.ID}).ToSql()
+		if err != nil {
+			return fmt.Errorf("r.Builder.ToSql: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, sqlq, args...)
+		if err != nil {
+			return fmt.Errorf("tx.ExecContext: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM audit_log_scrub;`)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext delete audit_log_scrub: %w", err)
+	}
+
+	return nil
+}
+
+// scrubbedAudit - измененные стиранием колонки записи e, снимки - json.RawMessage.
+func scrubbedAudit(e entity.AuditEntry, author, content string) map[string]any {
+	values := make(map[string]any)
+
+	if e.Actor == author {
+		values[actorColumn] = ""
+		values["ip"] = ""
+	}
+
+	if before, ok := entity.ScrubAuditSnapshot(e.Before, author, content); ok {
+		values["before"] = before
+	}
+
+	if after, ok := entity.ScrubAuditSnapshot(e.After, author, content); ok {
+		values["after"] = after
+	}
+
+	return values
+}
+
+func (r *SQLiteCommentRepo) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	sqlq, args, err := auditQuery(r.Builder, f, beforeID, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetAuditLog - r.Builder.ToSql: %w", err)
+	}
+
+	entries, err := queryAuditEntries(ctx, r.DB, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetAuditLog - queryAuditEntries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func queryAuditEntries(ctx context.Context, q sqliteQuerier, sqlq string, args ...any) ([]entity.AuditEntry, error) {
+	rows, err := q.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("q.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []entity.AuditEntry
+
+	for rows.Next() {
+		var (
+			e             entity.AuditEntry
+			before, after sql.NullString
+		)
+
+		err = rows.Scan(&e.ID, &e.Action, &e.CommentID, &e.Actor, &e.IP, &e.RequestID, &before, &after, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return entries, nil
+}
+
+// sqliteJSON - JSON текстом, nil - NULL.
+func sqliteJSON(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+
+	return string(raw)
+}
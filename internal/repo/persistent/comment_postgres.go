@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"iter"
+	"slices"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
 	"github.com/andreyxaxa/Comment-Tree/pkg/postgres"
 	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
 	"github.com/jackc/pgx/v5"
@@ -15,15 +19,32 @@ import (
 
 const (
 	// Table
-	commentsTable = "comments"
+	commentsTable      = "comments"
+	importSourcesTable = "import_sources"
 
 	// Columns
-	idColumn        = "id"
-	parentIDColumn  = "parent_id"
-	contentColumn   = "content"
-	createdAtColumn = "created_at"
+	idColumn          = "id"
+	parentIDColumn    = "parent_id"
+	contentColumn     = "content"
+	contentTextColumn = "content_text"
+	authorColumn      = "author"
+	createdAtColumn   = "created_at"
+	versionColumn     = "version"
+	updatedAtColumn   = "updated_at"
+	pinOrderColumn    = "pin_order"
+	highlightedColumn = "highlighted"
+	lockStateColumn   = "lock_state"
+	lockReasonColumn  = "lock_reason"
+	replyToIDColumn   = "reply_to_id"
+	maxDepthColumn    = "max_depth"
+	sourceColumn      = "source"
+	sourceIDColumn    = "source_id"
+	commentIDColumn   = "comment_id"
 )
 
+// _exportBatchSize - сколько строк за раз читается из курсора экспорта.
+const _exportBatchSize = 500
+
 type CommentRepo struct {
 	*postgres.Postgres
 }
@@ -32,12 +53,48 @@ func New(pg *postgres.Postgres) *CommentRepo {
 	return &CommentRepo{pg}
 }
 
-func (r *CommentRepo) CreateComment(ctx context.Context, parentID *int64, content string) (entity.Comment, error) {
+// CreateComment - вставка и повышение версий всех предков в одной транзакции.
+func (r *CommentRepo) CreateComment(ctx context.Context, parentID, replyToID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	var path []int64
+
+	if parentID != nil {
+		path, err = commentPath(ctx, tx, *parentID)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - commentPath: %w", err)
+		}
+
+		if len(path) == 0 {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - parent: %w", errs.ErrRecordNotFound)
+		}
+
+		err = touchComments(ctx, tx, path)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - touchComments: %w", err)
+		}
+
+		// предки уже заблокированы touchComments, так что блокировку ветки не поменять до коммита
+		state, err := branchLock(ctx, tx, path)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - branchLock: %w", err)
+		}
+
+		err = lockError(state)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment: %w", err)
+		}
+	}
+
 	sqlq, args, err := r.Builder.
 		Insert(commentsTable).
-		Columns(parentIDColumn, contentColumn).
-		Values(parentID, content).
-		Suffix("RETURNING id, created_at").
+		Columns(parentIDColumn, replyToIDColumn, contentColumn, contentTextColumn, authorColumn).
+		Values(parentID, replyToID, content, markdown.Text(content), author).
+		Suffix("RETURNING id, created_at, version, updated_at").
 		ToSql()
 	if err != nil {
 		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - r.Builder.ToSql(): %w", err)
@@ -45,6 +102,8 @@ func (r *CommentRepo) CreateComment(ctx context.Context, parentID *int64, conten
 
 	c := entity.Comment{
 		Content: content,
+		Author:  author,
+		Depth:   len(path),
 	}
 
 	if parentID != nil {
@@ -53,11 +112,47 @@ func (r *CommentRepo) CreateComment(ctx context.Context, parentID *int64, conten
 		c.ParentID = sql.NullInt64{Valid: false}
 	}
 
-	err = r.Pool.QueryRow(ctx, sqlq, args...).Scan(&c.ID, &c.CreatedAt)
+	if replyToID != nil {
+		c.ReplyToID = sql.NullInt64{Int64: *replyToID, Valid: true}
+	}
+
+	err = tx.QueryRow(ctx, sqlq, args...).Scan(&c.ID, &c.CreatedAt, &c.Version, &c.UpdatedAt)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - tx.QueryRow.Scan: %w", err)
+	}
+
+	err = createReferences(ctx, tx, c.ID, entity.ReferenceTargets(c.ID, markdown.References(content)))
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - createReferences: %w", err)
+	}
+
+	if len(attachmentIDs) > 0 {
+		n, err := r.attachAttachments(ctx, tx, c.ID, author, attachmentIDs)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - r.attachAttachments: %w", err)
+		}
+
+		if n != len(attachmentIDs) {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - attached %d of %d: %w", n, len(attachmentIDs), errs.ErrInvalidAttachment)
+		}
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    entity.AuditCreate,
+		CommentID: sql.NullInt64{Int64: c.ID, Valid: true},
+		After:     entity.AuditSnapshot(c),
+	})
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - r.audit: %w", err)
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil {
-		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - r.Pool.QueryRow.Scan: %w", err)
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateComment - tx.Commit: %w", err)
 	}
 
+	c.Path = append(path, c.ID)
+
 	return c, nil
 }
 
@@ -88,35 +183,11 @@ func (r *CommentRepo) CommentExists(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (r *CommentRepo) GetCommentWithChildren(ctx context.Context, id int64) ([]entity.Comment, error) {
-	sql := `
-	WITH RECURSIVE comment_tree AS (
-		SELECT 
-			id, 
-			parent_id, 
-			content, 
-			created_at, 
-			0 AS depth, 
-			ARRAY[id] AS path
+func (r *CommentRepo) GetCommentWithChildren(ctx context.Context, id int64, childSort string) ([]entity.Comment, error) {
+	sql := treeSQL(`
+		SELECT id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth, 0 AS depth, ARRAY[id] AS path
 		FROM comments
-		WHERE id = $1
-
-		UNION ALL
-
-		SELECT
-			c.id,
-			c.parent_id,
-			c.content,
-			c.created_at,
-			ct.depth + 1,
-			ct.path || c.id
-		FROM comments c
-		INNER JOIN comment_tree ct ON c.parent_id = ct.id
-	)
-	SELECT id, parent_id, content, created_at, depth, path 
-	FROM comment_tree
-	ORDER BY path;
-	`
+		WHERE id = $1`, childSort)
 
 	rows, err := r.Pool.Query(ctx, sql, id)
 	if err != nil {
@@ -133,7 +204,16 @@ func (r *CommentRepo) GetCommentWithChildren(ctx context.Context, id int64) ([]e
 			&c.ID,
 			&c.ParentID,
 			&c.Content,
+			&c.Author,
 			&c.CreatedAt,
+			&c.Version,
+			&c.UpdatedAt,
+			&c.PinOrder,
+			&c.Highlighted,
+			&c.LockState,
+			&c.LockReason,
+			&c.ReplyToID,
+			&c.MaxDepth,
 			&c.Depth,
 			&c.Path,
 		)
@@ -155,30 +235,76 @@ func (r *CommentRepo) GetCommentWithChildren(ctx context.Context, id int64) ([]e
 	return comments, nil
 }
 
-func (r *CommentRepo) DeleteCommentWithChildren(ctx context.Context, id int64) error {
-	sql, args, err := r.Builder.
+// DeleteCommentWithChildren - удаление и повышение версий оставшихся предков в одной транзакции.
+func (r *CommentRepo) DeleteCommentWithChildren(ctx context.Context, id int64) (int, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	path, err := commentPath(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - commentPath: %w", err)
+	}
+
+	if len(path) == 0 {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren: %w", errs.ErrRecordNotFound)
+	}
+
+	referrers, err := referrerPaths(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - referrerPaths: %w", err)
+	}
+
+	err = touchComments(ctx, tx, touched(path[:len(path)-1], referrers))
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - touchComments: %w", err)
+	}
+
+	// снимок поддерева - после блокировки предков, ответы в него уже не добавятся
+	subtree, err := auditSubtree(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - auditSubtree: %w", err)
+	}
+
+	sqlq, args, err := r.Builder.
 		Delete(commentsTable).
 		Where(squirrel.Eq{idColumn: id}).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("CommentRepo - DeleteCommentWithChildren - r.Builder.ToSql: %w", err)
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - r.Builder.ToSql: %w", err)
 	}
 
-	tag, err := r.Pool.Exec(ctx, sql, args...)
+	tag, err := tx.Exec(ctx, sqlq, args...)
 	if err != nil {
-		return fmt.Errorf("CommentRepo - DeleteCommentWithChildren - r.Pool.Exec: %w", err)
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - tx.Exec: %w", err)
 	}
 
 	if tag.RowsAffected() == 0 {
-		return fmt.Errorf("CommentRepo - DeleteCommentWithChildren: %w", errs.ErrRecordNotFound)
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren: %w", errs.ErrRecordNotFound)
 	}
 
-	return nil
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    entity.AuditDelete,
+		CommentID: sql.NullInt64{Int64: id, Valid: true},
+		Before:    entity.AuditSnapshots(subtree),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - r.audit: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - DeleteCommentWithChildren - tx.Commit: %w", err)
+	}
+
+	return len(subtree), nil
 }
 
 func (r *CommentRepo) SearchComments(ctx context.Context, search string, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
 	sql := fmt.Sprintf(`
-		SELECT id, parent_id, content, created_at, COUNT(*) OVER() as total
+		SELECT id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth, COUNT(*) OVER() as total
 		FROM comments
 		WHERE content_tsv @@ plainto_tsquery('english', $1)
 		ORDER BY %s %s
@@ -196,7 +322,7 @@ func (r *CommentRepo) SearchComments(ctx context.Context, search string, sortBy,
 
 	for rows.Next() {
 		var c entity.Comment
-		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.CreatedAt, &total)
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &total)
 		if err != nil {
 			return nil, 0, fmt.Errorf("CommentRepo - SearchComments - rows.Scan: %w", err)
 		}
@@ -210,12 +336,21 @@ func (r *CommentRepo) SearchComments(ctx context.Context, search string, sortBy,
 	return comments, total, nil
 }
 
-func (r *CommentRepo) GetRootComments(ctx context.Context, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+func (r *CommentRepo) GetRootComments(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	if viewer != "" {
+		comments, total, err := r.getRootCommentsFor(ctx, viewer, sortBy, order, limit, offset)
+		if err != nil {
+			return nil, 0, fmt.Errorf("CommentRepo - GetRootComments - r.getRootCommentsFor: %w", err)
+		}
+
+		return comments, total, nil
+	}
+
 	sql := fmt.Sprintf(`
-		SELECT id, parent_id, content, created_at, COUNT(*) OVER()
+		SELECT id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth, COUNT(*) OVER()
 		FROM comments
 		WHERE parent_id IS NULL
-		ORDER BY %s %s
+		ORDER BY pin_order IS NULL, pin_order, %s %s
 		LIMIT $1 OFFSET $2
 	`, sortBy, order)
 
@@ -230,7 +365,7 @@ func (r *CommentRepo) GetRootComments(ctx context.Context, sortBy, order string,
 
 	for rows.Next() {
 		var c entity.Comment
-		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.CreatedAt, &total)
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &total)
 		if err != nil {
 			return nil, 0, fmt.Errorf("CommentRepo - GetRootComments - rows.Scan: %w", err)
 		}
@@ -244,13 +379,72 @@ func (r *CommentRepo) GetRootComments(ctx context.Context, sortBy, order string,
 	return comments, total, nil
 }
 
-func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64) ([]entity.Comment, error) {
+// getRootCommentsFor - страница корней вместе с визитами viewer, непрочитанное считается
+// по деревьям только этих корней.
+func (r *CommentRepo) getRootCommentsFor(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	sql := fmt.Sprintf(`
+		WITH RECURSIVE page AS (
+			SELECT c.id, c.parent_id, c.content, c.author, c.created_at, c.version, c.updated_at, c.pin_order, c.highlighted,
+				c.lock_state, c.lock_reason, c.reply_to_id, c.max_depth, COUNT(*) OVER() AS total, COALESCE(v.last_seen_id, 0) AS last_seen_id
+			FROM comments c
+			LEFT JOIN root_visits v ON v.root_id = c.id AND v.viewer = $3
+			WHERE c.parent_id IS NULL
+			ORDER BY c.pin_order IS NULL, c.pin_order, c.%[1]s %[2]s
+			LIMIT $1 OFFSET $2
+		),
+		tree AS (
+			SELECT id, id AS root_id, author FROM page
+			UNION ALL
+			SELECT c.id, t.root_id, c.author
+			FROM comments c
+			JOIN tree t ON c.parent_id = t.id
+		),
+		unread AS (
+			SELECT t.root_id, COUNT(*) AS new_count
+			FROM tree t
+			JOIN page p ON p.id = t.root_id
+			WHERE t.id > p.last_seen_id AND t.author <> $3
+			GROUP BY t.root_id
+		)
+		SELECT p.id, p.parent_id, p.content, p.author, p.created_at, p.version, p.updated_at, p.pin_order, p.highlighted,
+			p.lock_state, p.lock_reason, p.reply_to_id, p.max_depth, p.total, p.last_seen_id, COALESCE(u.new_count, 0)
+		FROM page p
+		LEFT JOIN unread u ON u.root_id = p.id
+		ORDER BY p.pin_order IS NULL, p.pin_order, p.%[1]s %[2]s
+	`, sortBy, order)
+
+	rows, err := r.Pool.Query(ctx, sql, limit, offset, viewer)
+	if err != nil {
+		return nil, 0, fmt.Errorf("r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []entity.Comment
+	var total int
+
+	for rows.Next() {
+		var c entity.Comment
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &total, &c.LastSeenID, &c.NewCount)
+		if err != nil {
+			return nil, 0, fmt.Errorf("rows.Scan: %w", err)
+		}
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64, childSort string) ([]entity.Comment, error) {
 	if len(rootIDs) == 0 {
 		return []entity.Comment{}, nil
 	}
 
 	anchorQuery, anchorArgs, err := r.Builder.
-		Select(idColumn, parentIDColumn, contentColumn, createdAtColumn, "0 AS depth", "ARRAY[id] AS path").
+		Select(idColumn, parentIDColumn, contentColumn, authorColumn, createdAtColumn, versionColumn, updatedAtColumn, pinOrderColumn, highlightedColumn, lockStateColumn, lockReasonColumn, replyToIDColumn, maxDepthColumn, "0 AS depth", "ARRAY[id] AS path").
 		From(commentsTable).
 		Where(squirrel.Eq{idColumn: rootIDs}).
 		ToSql()
@@ -258,26 +452,7 @@ func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64) ([]
 		return nil, fmt.Errorf("CommentRepo - GetTreesForRoots - r.Builder.ToSql: %w", err)
 	}
 
-	sql := fmt.Sprintf(`
-	WITH RECURSIVE comment_tree AS (
-		%s
-
-		UNION ALL
-
-		SELECT
-			c.id, 
-			c.parent_id, 
-			c.content,
-			c.created_at,
-			ct.depth + 1,
-			ct.path || c.id
-		FROM comments c
-		INNER JOIN comment_tree ct ON c.parent_id = ct.id
-	)
-	SELECT id, parent_id, content, created_at, depth, path
-	FROM comment_tree
-	ORDER BY path;
-	`, anchorQuery)
+	sql := treeSQL(anchorQuery, childSort)
 
 	rows, err := r.Pool.Query(ctx, sql, anchorArgs...)
 	if err != nil {
@@ -289,7 +464,7 @@ func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64) ([]
 
 	for rows.Next() {
 		var c entity.Comment
-		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.CreatedAt, &c.Depth, &c.Path)
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &c.Depth, &c.Path)
 		if err != nil {
 			return nil, fmt.Errorf("CommentRepo - GetTreesForRoots - rows.Scan: %w", err)
 		}
@@ -302,3 +477,837 @@ func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64) ([]
 
 	return comments, nil
 }
+
+func (r *CommentRepo) GetCommentPath(ctx context.Context, id int64) ([]int64, error) {
+	path, err := commentPath(ctx, r.Pool, id)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetCommentPath - commentPath: %w", err)
+	}
+
+	if len(path) == 0 {
+		return nil, fmt.Errorf("CommentRepo - GetCommentPath: %w", errs.ErrRecordNotFound)
+	}
+
+	return path, nil
+}
+
+// ExportComments - читает серверным курсором пачками по _exportBatchSize строк в одной
+// REPEATABLE READ транзакции, так что память не зависит от размера базы, а выгрузка согласована.
+// Вся база идет по id: id родителя всегда меньше id ребенка, поддерево - в порядке path.
+// Соединение пула занято до конца выгрузки.
+func (r *CommentRepo) ExportComments(ctx context.Context, rootID *int64) iter.Seq2[entity.Comment, error] {
+	return func(yield func(entity.Comment, error) bool) {
+		err := r.export(ctx, rootID, yield)
+		if err != nil {
+			yield(entity.Comment{}, fmt.Errorf("CommentRepo - ExportComments - r.export: %w", err))
+		}
+	}
+}
+
+func (r *CommentRepo) export(ctx context.Context, rootID *int64, yield func(entity.Comment, error) bool) error {
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("r.Pool.BeginTx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // транзакция только читает
+
+	query := `
+	DECLARE export_cursor NO SCROLL CURSOR FOR
+	SELECT id, parent_id, content, author, created_at, version, updated_at
+	FROM comments
+	ORDER BY id
+	`
+
+	// DECLARE - служебная команда, поэтому id подставляется в текст, а не параметром
+	if rootID != nil {
+		query = fmt.Sprintf(`
+		DECLARE export_cursor NO SCROLL CURSOR FOR
+		WITH RECURSIVE comment_tree AS (
+			SELECT id, parent_id, content, author, created_at, version, updated_at, ARRAY[id] AS path
+			FROM comments
+			WHERE id = %d
+
+			UNION ALL
+
+			SELECT c.id, c.parent_id, c.content, c.author, c.created_at, c.version, c.updated_at, ct.path || c.id
+			FROM comments c
+			INNER JOIN comment_tree ct ON c.parent_id = ct.id
+		)
+		SELECT id, parent_id, content, author, created_at, version, updated_at
+		FROM comment_tree
+		ORDER BY path
+		`, *rootID)
+	}
+
+	_, err = tx.Exec(ctx, query)
+	if err != nil {
+		return fmt.Errorf("tx.Exec - DECLARE: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM export_cursor", _exportBatchSize)
+
+	for {
+		// пачка читается целиком до yield: пока открыт rows, соединение занято
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("tx.Query - FETCH: %w", err)
+		}
+
+		batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Comment, error) {
+			var c entity.Comment
+			err := row.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt)
+
+			return c, err
+		})
+		if err != nil {
+			return fmt.Errorf("pgx.CollectRows: %w", err)
+		}
+
+		for _, c := range batch {
+			if !yield(c, nil) {
+				return nil
+			}
+		}
+
+		if len(batch) < _exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// ImportComments - id выделяются из последовательности заранее (по возрастанию, чтобы
+// родитель остался меньше ребенка), строки загружаются одним COPY в транзакции.
+func (r *CommentRepo) ImportComments(ctx context.Context, comments []entity.Comment) ([]int64, error) {
+	if len(comments) == 0 {
+		return []int64{}, nil
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ImportComments - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	rows, err := tx.Query(ctx,
+		"SELECT nextval(pg_get_serial_sequence('comments', 'id')) FROM generate_series(1, $1)", len(comments))
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ImportComments - tx.Query: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ImportComments - pgx.CollectRows: %w", err)
+	}
+
+	slices.Sort(ids)
+
+	newIDs := make(map[int64]int64, len(comments))
+	copyRows := make([][]any, len(comments))
+
+	for i, c := range comments {
+		newIDs[c.ID] = ids[i]
+
+		var parentID any
+		if c.ParentID.Valid {
+			newParentID, ok := newIDs[c.ParentID.Int64]
+			if !ok {
+				return nil, fmt.Errorf("CommentRepo - ImportComments - parent %d of %d: %w", c.ParentID.Int64, c.ID, errs.ErrRecordNotFound)
+			}
+			parentID = newParentID
+		}
+
+		createdAt := c.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		copyRows[i] = []any{ids[i], parentID, c.Content, markdown.Text(c.Content), c.Author, createdAt.UTC(), int64(1), createdAt.UTC()}
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{commentsTable},
+		[]string{idColumn, parentIDColumn, contentColumn, contentTextColumn, authorColumn, createdAtColumn, versionColumn, updatedAtColumn},
+		pgx.CopyFromRows(copyRows),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ImportComments - tx.CopyFrom: %w", err)
+	}
+
+	// одна запись на импорт: снимки всех комментов сделали бы журнал размером с выгрузку
+	err = r.audit(ctx, tx, entity.AuditEntry{Action: entity.AuditImport, After: entity.AuditIDs(ids)})
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ImportComments - r.audit: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ImportComments - tx.Commit: %w", err)
+	}
+
+	return ids, nil
+}
+
+// CreateFromSource - как CreateComment, но автор и время создания берутся из источника,
+// а соответствие source/sourceID записывается в той же транзакции.
+func (r *CommentRepo) CreateFromSource(ctx context.Context, source, sourceID string, c entity.Comment) (entity.Comment, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	var (
+		path     []int64
+		parentID any
+	)
+
+	if c.ParentID.Valid {
+		parentID = c.ParentID.Int64
+
+		path, err = commentPath(ctx, tx, c.ParentID.Int64)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - commentPath: %w", err)
+		}
+
+		if len(path) == 0 {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - parent: %w", errs.ErrRecordNotFound)
+		}
+
+		err = touchComments(ctx, tx, path)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - touchComments: %w", err)
+		}
+	}
+
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	c.CreatedAt = c.CreatedAt.UTC()
+
+	sqlq, args, err := r.Builder.
+		Insert(commentsTable).
+		Columns(parentIDColumn, contentColumn, contentTextColumn, authorColumn, createdAtColumn, updatedAtColumn).
+		Values(parentID, c.Content, markdown.Text(c.Content), c.Author, c.CreatedAt, c.CreatedAt).
+		Suffix("RETURNING id, version, updated_at").
+		ToSql()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - r.Builder.ToSql: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, sqlq, args...).Scan(&c.ID, &c.Version, &c.UpdatedAt)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - tx.QueryRow.Scan: %w", err)
+	}
+
+	// ON CONFLICT - параллельный импорт того же источника успел раньше
+	sqlq, args, err = r.Builder.
+		Insert(importSourcesTable).
+		Columns(sourceColumn, sourceIDColumn, commentIDColumn).
+		Values(source, sourceID, c.ID).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - r.Builder.ToSql: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sqlq, args...)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - tx.Exec: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - %s/%s: %w", source, sourceID, errs.ErrAlreadyExists)
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    entity.AuditCreate,
+		CommentID: sql.NullInt64{Int64: c.ID, Valid: true},
+		After:     entity.AuditSnapshot(c),
+	})
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - r.audit: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentRepo - CreateFromSource - tx.Commit: %w", err)
+	}
+
+	c.Depth = len(path)
+	c.Path = append(path, c.ID)
+
+	return c, nil
+}
+
+func (r *CommentRepo) GetSourceMapping(ctx context.Context, source string, sourceIDs []string) (map[string]int64, error) {
+	mapping := make(map[string]int64)
+
+	if len(sourceIDs) == 0 {
+		return mapping, nil
+	}
+
+	sqlq := `
+	SELECT source_id, COALESCE(comment_id, 0)
+	FROM import_sources
+	WHERE source = $1 AND source_id = ANY($2)
+	`
+
+	rows, err := r.Pool.Query(ctx, sqlq, source, sourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetSourceMapping - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sourceID  string
+			commentID int64
+		)
+
+		err = rows.Scan(&sourceID, &commentID)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - GetSourceMapping - rows.Scan: %w", err)
+		}
+
+		mapping[sourceID] = commentID
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetSourceMapping - rows.Err: %w", err)
+	}
+
+	return mapping, nil
+}
+
+// GetCommentsByIDs - путь строится от каждого коммента вверх до корня.
+func (r *CommentRepo) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	comments := make(map[int64]entity.Comment, len(ids))
+
+	if len(ids) == 0 {
+		return comments, nil
+	}
+
+	sql := `
+	WITH RECURSIVE ancestors AS (
+		SELECT id AS start_id, parent_id, 0 AS lvl, ARRAY[id] AS path
+		FROM comments
+		WHERE id = ANY($1)
+
+		UNION ALL
+
+		SELECT a.start_id, c.parent_id, a.lvl + 1, c.id || a.path
+		FROM comments c
+		INNER JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT c.id, c.parent_id, c.content, c.author, c.created_at, c.version, c.updated_at, c.pin_order, c.highlighted, c.lock_state, c.lock_reason, c.reply_to_id, c.max_depth, a.lvl, a.path
+	FROM ancestors a
+	INNER JOIN comments c ON c.id = a.start_id
+	WHERE a.parent_id IS NULL;
+	`
+
+	rows, err := r.Pool.Query(ctx, sql, ids)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetCommentsByIDs - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c entity.Comment
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &c.Depth, &c.Path)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - GetCommentsByIDs - rows.Scan: %w", err)
+		}
+
+		comments[c.ID] = c
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetCommentsByIDs - rows.Err: %w", err)
+	}
+
+	return comments, nil
+}
+
+// GetChildren - нумерация ответов внутри каждого родителя через ROW_NUMBER(),
+// при равных created_at порядок определяет id.
+func (r *CommentRepo) GetChildren(ctx context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error) {
+	children := make(map[int64][]entity.Comment)
+
+	if len(parentIDs) == 0 {
+		return children, nil
+	}
+
+	sql := fmt.Sprintf(`
+	SELECT id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth
+	FROM (
+		SELECT
+			id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth,
+			ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY pin_order IS NULL, pin_order, %s %s, id %s) AS rn
+		FROM comments
+		WHERE parent_id = ANY($1)
+	) replies
+	WHERE rn > $2 AND rn <= $2 + $3
+	ORDER BY parent_id, rn;
+	`, sortBy, order, order)
+
+	rows, err := r.Pool.Query(ctx, sql, parentIDs, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetChildren - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c entity.Comment
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - GetChildren - rows.Scan: %w", err)
+		}
+
+		children[c.ParentID.Int64] = append(children[c.ParentID.Int64], c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetChildren - rows.Err: %w", err)
+	}
+
+	return children, nil
+}
+
+func (r *CommentRepo) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	counts := make(map[int64]entity.ReplyCount, len(ids))
+
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	sql := `
+	WITH RECURSIVE subtree AS (
+		SELECT id AS root_id, id, 0 AS depth
+		FROM comments
+		WHERE id = ANY($1)
+
+		UNION ALL
+
+		SELECT s.root_id, c.id, s.depth + 1
+		FROM comments c
+		INNER JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT root_id, COUNT(*) FILTER (WHERE depth = 1), COUNT(*) - 1
+	FROM subtree
+	GROUP BY root_id;
+	`
+
+	rows, err := r.Pool.Query(ctx, sql, ids)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - CountReplies - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    int64
+			count entity.ReplyCount
+		)
+
+		err = rows.Scan(&id, &count.Children, &count.Descendants)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - CountReplies - rows.Scan: %w", err)
+		}
+
+		counts[id] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("CommentRepo - CountReplies - rows.Err: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (r *CommentRepo) SetPinOrder(ctx context.Context, id int64, order *int64) error {
+	action := entity.AuditPin
+	if order == nil {
+		action = entity.AuditUnpin
+	}
+
+	err := r.updateComment(ctx, id, action, map[string]any{pinOrderColumn: order})
+	if err != nil {
+		return fmt.Errorf("CommentRepo - SetPinOrder - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	action := entity.AuditHighlight
+	if !highlighted {
+		action = entity.AuditUnhighlight
+	}
+
+	err := r.updateComment(ctx, id, action, map[string]any{highlightedColumn: highlighted})
+	if err != nil {
+		return fmt.Errorf("CommentRepo - SetHighlighted - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) SetLock(ctx context.Context, id int64, state, reason string) error {
+	action := entity.AuditLock
+	if state == entity.LockNone {
+		action = entity.AuditUnlock
+	}
+
+	err := r.updateComment(ctx, id, action, map[string]any{lockStateColumn: state, lockReasonColumn: reason})
+	if err != nil {
+		return fmt.Errorf("CommentRepo - SetLock - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	err := r.updateComment(ctx, id, entity.AuditMaxDepth, map[string]any{maxDepthColumn: maxDepth})
+	if err != nil {
+		return fmt.Errorf("CommentRepo - SetMaxDepth - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveInactive - архивирует корни, в деревьях которых ничего не менялось с before.
+// updated_at корня растет при любом изменении в дереве, так что это и есть последняя активность.
+// Корни блокируются до обновления, так что снимок "до" совпадает с тем, что архивируется.
+func (r *CommentRepo) ArchiveInactive(ctx context.Context, before time.Time, reason string) ([]int64, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ArchiveInactive - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	rows, err := tx.Query(ctx, `
+	SELECT `+auditSnapshotColumns+`
+	FROM comments
+	WHERE parent_id IS NULL AND lock_state <> 'archived' AND updated_at < $1
+	ORDER BY id
+	FOR UPDATE;
+	`, before)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ArchiveInactive - tx.Query: %w", err)
+	}
+
+	roots, err := pgx.CollectRows(rows, scanAuditComment)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ArchiveInactive - pgx.CollectRows: %w", err)
+	}
+
+	if len(roots) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(roots))
+	entries := make([]entity.AuditEntry, len(roots))
+
+	for i, root := range roots {
+		ids[i] = root.ID
+
+		archived := root
+		archived.LockState, archived.LockReason = entity.LockArchived, reason
+
+		entries[i] = entity.AuditEntry{
+			Action:    entity.AuditArchive,
+			CommentID: sql.NullInt64{Int64: root.ID, Valid: true},
+			Before:    entity.AuditSnapshot(root),
+			After:     entity.AuditSnapshot(archived),
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+	UPDATE comments
+	SET lock_state = 'archived', lock_reason = $2, version = version + 1, updated_at = now()
+	WHERE id = ANY($1);
+	`, ids, reason)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ArchiveInactive - tx.Exec: %w", err)
+	}
+
+	err = r.audit(ctx, tx, entries...)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ArchiveInactive - r.audit: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - ArchiveInactive - tx.Commit: %w", err)
+	}
+
+	return ids, nil
+}
+
+// updateComment - меняет колонки коммента id и повышает версии его и всех предков в одной транзакции,
+// в ней же пишет в журнал action со снимками до и после.
+func (r *CommentRepo) updateComment(ctx context.Context, id int64, action string, values map[string]any) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	path, err := commentPath(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("commentPath: %w", err)
+	}
+
+	if len(path) == 0 {
+		return errs.ErrRecordNotFound
+	}
+
+	// сам коммент входит в path и блокируется вместе с предками
+	err = touchComments(ctx, tx, path)
+	if err != nil {
+		return fmt.Errorf("touchComments: %w", err)
+	}
+
+	before, err := auditComments(ctx, tx, []int64{id})
+	if err != nil {
+		return fmt.Errorf("auditComments: %w", err)
+	}
+
+	sqlq, args, err := r.Builder.
+		Update(commentsTable).
+		SetMap(values).
+		Where(squirrel.Eq{idColumn: id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	after, err := auditComments(ctx, tx, []int64{id})
+	if err != nil {
+		return fmt.Errorf("auditComments: %w", err)
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    action,
+		CommentID: sql.NullInt64{Int64: id, Valid: true},
+		Before:    entity.AuditSnapshot(before[0]),
+		After:     entity.AuditSnapshot(after[0]),
+	})
+	if err != nil {
+		return fmt.Errorf("r.audit: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// treeSQL - поддеревья корней из anchorQuery (колонки comments, 0 AS depth, ARRAY[id] AS path)
+// в порядке entity.SortTree: ответы каждого родителя нумеруются по childSort,
+// строки сортируются по пути из этих номеров.
+func treeSQL(anchorQuery, childSort string) string {
+	var scores, scoresJoin string
+
+	if childSort == entity.ChildSortScore {
+		// у каждого коммента в дереве path содержит всех его предков
+		scores = `
+	scores AS (
+		SELECT ancestor_id AS id, COUNT(*) - 1 AS score
+		FROM comment_tree, unnest(path) AS ancestor_id
+		GROUP BY ancestor_id
+	),`
+		scoresJoin = "INNER JOIN scores s ON s.id = ct.id"
+	}
+
+	return fmt.Sprintf(`
+	WITH RECURSIVE comment_tree AS (
+		%s
+
+		UNION ALL
+
+		SELECT
+			c.id,
+			c.parent_id,
+			c.content,
+			c.author,
+			c.created_at,
+			c.version,
+			c.updated_at,
+			c.pin_order,
+			c.highlighted,
+			c.lock_state,
+			c.lock_reason,
+			c.reply_to_id,
+			c.max_depth,
+			ct.depth + 1,
+			ct.path || c.id
+		FROM comments c
+		INNER JOIN comment_tree ct ON c.parent_id = ct.id
+	),%s
+	ranked AS (
+		SELECT ct.*, ROW_NUMBER() OVER (PARTITION BY ct.parent_id ORDER BY %s) AS rn
+		FROM comment_tree ct
+		%s
+	),
+	ordered AS (
+		SELECT id, ARRAY[rn] AS sort_path
+		FROM ranked
+		WHERE depth = 0
+
+		UNION ALL
+
+		SELECT r.id, o.sort_path || r.rn
+		FROM ranked r
+		INNER JOIN ordered o ON r.parent_id = o.id
+	)
+	SELECT r.id, r.parent_id, r.content, r.author, r.created_at, r.version, r.updated_at, r.pin_order, r.highlighted, r.lock_state, r.lock_reason, r.reply_to_id, r.max_depth, r.depth, r.path
+	FROM ranked r
+	INNER JOIN ordered o ON o.id = r.id
+	ORDER BY o.sort_path;
+	`, anchorQuery, scores, siblingOrder(childSort), scoresJoin)
+}
+
+// siblingOrder - ORDER BY для ответов одного родителя, ct - comment_tree, s - scores.
+// Общий для Postgres и SQLite.
+func siblingOrder(childSort string) string {
+	// закрепленные всегда выше остальных
+	const pinned = "ct.pin_order IS NULL, ct.pin_order, "
+
+	switch childSort {
+	case entity.ChildSortNewest:
+		return pinned + "ct.created_at DESC, ct.id DESC"
+	case entity.ChildSortScore:
+		return pinned + "s.score DESC, ct.created_at ASC, ct.id ASC"
+	default:
+		return pinned + "ct.created_at ASC, ct.id ASC"
+	}
+}
+
+// querier - общее у пула и транзакции.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// commentPath - id предков от корня до самого коммента; пустой, если коммента нет.
+func commentPath(ctx context.Context, q querier, id int64) ([]int64, error) {
+	sql := `
+	WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id, 0 AS lvl
+		FROM comments
+		WHERE id = $1
+
+		UNION ALL
+
+		SELECT c.id, c.parent_id, a.lvl + 1
+		FROM comments c
+		INNER JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT id
+	FROM ancestors
+	ORDER BY lvl DESC;
+	`
+
+	rows, err := q.Query(ctx, sql, id)
+	if err != nil {
+		return nil, fmt.Errorf("q.Query: %w", err)
+	}
+
+	path, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("pgx.CollectRows: %w", err)
+	}
+
+	return path, nil
+}
+
+// branchLock - действующая блокировка ветки path (от корня): самая строгая среди ее комментов.
+// FOR SHARE не дает изменить ее до конца транзакции.
+func branchLock(ctx context.Context, q querier, path []int64) (string, error) {
+	rows, err := q.Query(ctx, `SELECT id, lock_state, lock_reason FROM comments WHERE id = ANY($1) ORDER BY id FOR SHARE`, path)
+	if err != nil {
+		return "", fmt.Errorf("q.Query: %w", err)
+	}
+
+	locks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Comment, error) {
+		var c entity.Comment
+		err := row.Scan(&c.ID, &c.LockState, &c.LockReason)
+
+		return c, err
+	})
+	if err != nil {
+		return "", fmt.Errorf("pgx.CollectRows: %w", err)
+	}
+
+	return branchState(path, locks), nil
+}
+
+// branchState - EffectiveLock комментов locks в порядке path.
+func branchState(path []int64, locks []entity.Comment) string {
+	chain := make([]entity.Comment, 0, len(path))
+	for _, id := range path {
+		if i := slices.IndexFunc(locks, func(c entity.Comment) bool { return c.ID == id }); i >= 0 {
+			chain = append(chain, locks[i])
+		}
+	}
+
+	state, _ := entity.EffectiveLock(chain...)
+
+	return state
+}
+
+// lockError - ошибка ответа в ветку с блокировкой state, nil - ветка открыта.
+func lockError(state string) error {
+	switch state {
+	case entity.LockArchived:
+		return errs.ErrArchived
+	case entity.LockLocked:
+		return errs.ErrLocked
+	}
+
+	return nil
+}
+
+// touchComments - повышает version и updated_at у комментов ids.
+// Строки блокируются по возрастанию id: предок всегда старше потомка,
+// поэтому параллельные записи в одну ветку берут блокировки в одном порядке.
+// touched - предки удаляемого коммента и ссылающиеся на его поддерево без повторов:
+// версия каждого растет один раз.
+func touched(ancestors, referrers []int64) []int64 {
+	ids := slices.Clone(ancestors)
+	for _, id := range referrers {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func touchComments(ctx context.Context, tx pgx.Tx, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	sql := `
+	UPDATE comments
+	SET version = version + 1, updated_at = now()
+	WHERE id IN (
+		SELECT id FROM comments WHERE id = ANY($1) ORDER BY id FOR UPDATE
+	);
+	`
+
+	_, err := tx.Exec(ctx, sql, ids)
+	if err != nil {
+		return fmt.Errorf("tx.Exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package persistent_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/persistent"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/repotest"
+	"github.com/andreyxaxa/Comment-Tree/migrations"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/migrator"
+	"github.com/andreyxaxa/Comment-Tree/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+func postgresTestURL(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv("PG_TEST_URL")
+	if dsn == "" {
+		t.Skip("PG_TEST_URL is not set")
+	}
+
+	return dsn
+}
+
+// PG_TEST_URL - база для тестов. Каждый тест получает свою схему со всеми миграциями
+// и удаляет ее в конце: журнал изменений не очищается даже TRUNCATE.
+func TestPostgresCommentRepo(t *testing.T) {
+	dsn := postgresTestURL(t)
+
+	admin, err := postgres.New(dsn, postgres.ConnAttempts(1))
+	if err != nil {
+		t.Fatalf("postgres.New: %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	run := time.Now().UnixNano()
+	n := 0
+
+	repotest.TestCommentRepo(t, func(t *testing.T) repo.CommentRepo {
+		n++
+
+		return persistent.New(newSchema(t, admin, dsn, fmt.Sprintf("comment_tree_test_%d_%d", run, n)))
+	})
+}
+
+// newSchema - пустая схема schema с миграциями и пул, у которого она в search_path.
+func newSchema(t *testing.T, admin *postgres.Postgres, dsn, schema string) *postgres.Postgres {
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, err := admin.Pool.Exec(ctx, "CREATE SCHEMA "+pgx.Identifier{schema}.Sanitize())
+	if err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, err := admin.Pool.Exec(ctx, "DROP SCHEMA "+pgx.Identifier{schema}.Sanitize()+" CASCADE")
+		if err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("url.Parse: %v", err)
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	pg, err := postgres.New(u.String(), postgres.MaxPoolSize(2), postgres.ConnAttempts(1))
+	if err != nil {
+		t.Fatalf("postgres.New: %v", err)
+	}
+	t.Cleanup(pg.Close)
+
+	mg, err := migrator.NewPostgres(pg, migrations.FS)
+	if err != nil {
+		t.Fatalf("migrator.NewPostgres: %v", err)
+	}
+
+	err = mg.Up()
+	mg.Close()
+	if err != nil {
+		t.Fatalf("mg.Up: %v", err)
+	}
+
+	return pg
+}
+
+func TestPostgresAuditAppendOnly(t *testing.T) {
+	dsn := postgresTestURL(t)
+
+	admin, err := postgres.New(dsn, postgres.ConnAttempts(1))
+	if err != nil {
+		t.Fatalf("postgres.New: %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	pg := newSchema(t, admin, dsn, fmt.Sprintf("comment_tree_test_%d_audit", time.Now().UnixNano()))
+	r := persistent.New(pg)
+	ctx := caller.WithUser(context.Background(), "alice")
+
+	if _, err = r.CreateComment(ctx, nil, nil, "alice", "root", nil); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	appendOnly := func(name string) {
+		t.Helper()
+
+		for _, q := range []string{
+			"UPDATE audit_log SET actor = 'mallory'",
+			"DELETE FROM audit_log",
+			"TRUNCATE audit_log",
+			// настройки сессии клиент ставит сам - они ничего не разрешают
+			"SELECT set_config('audit_log.scrub', 'on', true); UPDATE audit_log SET actor = 'mallory'",
+		} {
+			tx, err := pg.Pool.Begin(ctx)
+			if err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+
+			_, err = tx.Exec(ctx, q)
+			_ = tx.Rollback(ctx)
+
+			if err == nil || !strings.Contains(err.Error(), "append-only") {
+				t.Fatalf("%s: %q = %v, want append-only error", name, q, err)
+			}
+		}
+	}
+
+	appendOnly("before erase")
+
+	// стирание автора - единственный, кто меняет записи
+	if _, err = r.EraseAuthor(caller.WithUser(context.Background(), "dpo"), "alice", entity.ErasedContent); err != nil {
+		t.Fatalf("EraseAuthor: %v", err)
+	}
+
+	entries, err := r.GetAuditLog(ctx, entity.AuditFilter{Actor: "alice"}, 0, 10)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("GetAuditLog(alice) after erase = %+v, %v; want none", entries, err)
+	}
+
+	appendOnly("after erase")
+}
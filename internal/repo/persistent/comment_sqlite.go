@@ -0,0 +1,1303 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
+	"github.com/andreyxaxa/Comment-Tree/pkg/sqlite"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// путь в дереве хранится строкой из id фиксированной ширины через "/",
+// тогда сортировка строк совпадает с сортировкой массивов id в Postgres
+const sqlitePathFormat = "printf('%%019d', %s)"
+
+type SQLiteCommentRepo struct {
+	*sqlite.SQLite
+}
+
+func NewSQLite(sl *sqlite.SQLite) *SQLiteCommentRepo {
+	return &SQLiteCommentRepo{sl}
+}
+
+// CreateComment - вставка и повышение версий всех предков в одной транзакции.
+func (r *SQLiteCommentRepo) CreateComment(ctx context.Context, parentID, replyToID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	now := time.Now().UTC()
+
+	c := entity.Comment{
+		Content:   content,
+		Author:    author,
+		CreatedAt: now,
+		Version:   1,
+		UpdatedAt: now,
+	}
+
+	if replyToID != nil {
+		c.ReplyToID = sql.NullInt64{Int64: *replyToID, Valid: true}
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	var path []int64
+
+	if parentID != nil {
+		c.ParentID = sql.NullInt64{Int64: *parentID, Valid: true}
+
+		path, err = r.commentPath(ctx, tx, *parentID)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - r.commentPath: %w", err)
+		}
+
+		if len(path) == 0 {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - parent: %w", errs.ErrRecordNotFound)
+		}
+
+		err = r.touchComments(ctx, tx, path, now)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - r.touchComments: %w", err)
+		}
+
+		state, err := r.branchLock(ctx, tx, path)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - r.branchLock: %w", err)
+		}
+
+		err = lockError(state)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment: %w", err)
+		}
+	}
+
+	sqlq, args, err := r.Builder.
+		Insert(commentsTable).
+		Columns(parentIDColumn, replyToIDColumn, contentColumn, contentTextColumn, authorColumn, createdAtColumn, versionColumn, updatedAtColumn).
+		Values(parentID, replyToID, content, markdown.Text(content), author, c.CreatedAt, c.Version, c.UpdatedAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - r.Builder.ToSql: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, sqlq, args...).Scan(&c.ID)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - tx.QueryRowContext.Scan: %w", err)
+	}
+
+	err = createSQLiteReferences(ctx, tx, c.ID, entity.ReferenceTargets(c.ID, markdown.References(content)))
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - createSQLiteReferences: %w", err)
+	}
+
+	if len(attachmentIDs) > 0 {
+		n, err := r.attachAttachments(ctx, tx, c.ID, author, attachmentIDs)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - r.attachAttachments: %w", err)
+		}
+
+		if n != len(attachmentIDs) {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - attached %d of %d: %w", n, len(attachmentIDs), errs.ErrInvalidAttachment)
+		}
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    entity.AuditCreate,
+		CommentID: sql.NullInt64{Int64: c.ID, Valid: true},
+		After:     entity.AuditSnapshot(c),
+	})
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - r.audit: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateComment - tx.Commit: %w", err)
+	}
+
+	c.Depth = len(path)
+	c.Path = append(path, c.ID)
+
+	return c, nil
+}
+
+func (r *SQLiteCommentRepo) CommentExists(ctx context.Context, id int64) error {
+	sqlq, args, err := r.Builder.
+		Select("1").
+		From(commentsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - CommentExists - r.Builder.ToSql: %w", err)
+	}
+
+	var exists int
+	err = r.DB.QueryRowContext(ctx, sqlq, args...).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("SQLiteCommentRepo - CommentExists: %w", errs.ErrRecordNotFound)
+		}
+		return fmt.Errorf("SQLiteCommentRepo - CommentExists - r.DB.QueryRowContext.Scan: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) GetCommentWithChildren(ctx context.Context, id int64, childSort string) ([]entity.Comment, error) {
+	comments, err := r.queryTrees(ctx, "id = ?", []any{id}, childSort)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentWithChildren - r.queryTrees: %w", err)
+	}
+
+	if len(comments) == 0 {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentWithChildren: %w", errs.ErrRecordNotFound)
+	}
+
+	return comments, nil
+}
+
+// DeleteCommentWithChildren - удаление и повышение версий оставшихся предков в одной транзакции.
+func (r *SQLiteCommentRepo) DeleteCommentWithChildren(ctx context.Context, id int64) (int, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	path, err := r.commentPath(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - r.commentPath: %w", err)
+	}
+
+	if len(path) == 0 {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren: %w", errs.ErrRecordNotFound)
+	}
+
+	referrers, err := r.referrerPaths(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - r.referrerPaths: %w", err)
+	}
+
+	err = r.touchComments(ctx, tx, touched(path[:len(path)-1], referrers), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - r.touchComments: %w", err)
+	}
+
+	subtree, err := r.auditSubtree(ctx, tx, id)
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - r.auditSubtree: %w", err)
+	}
+
+	sqlq, args, err := r.Builder.
+		Delete(commentsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - r.Builder.ToSql: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - tx.ExecContext: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - res.RowsAffected: %w", err)
+	}
+
+	if affected == 0 {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren: %w", errs.ErrRecordNotFound)
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    entity.AuditDelete,
+		CommentID: sql.NullInt64{Int64: id, Valid: true},
+		Before:    entity.AuditSnapshots(subtree),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - r.audit: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - DeleteCommentWithChildren - tx.Commit: %w", err)
+	}
+
+	return len(subtree), nil
+}
+
+func (r *SQLiteCommentRepo) SearchComments(ctx context.Context, search string, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	match := ftsMatchQuery(search)
+	if match == "" {
+		return nil, 0, nil
+	}
+
+	sqlq := fmt.Sprintf(`
+		SELECT c.id, c.parent_id, c.content, c.author, c.created_at, c.version, c.updated_at, c.pin_order, c.highlighted, c.lock_state, c.lock_reason, c.reply_to_id, c.max_depth, COUNT(*) OVER() AS total
+		FROM comments_fts f
+		INNER JOIN comments c ON c.id = f.rowid
+		WHERE comments_fts MATCH ?
+		ORDER BY c.%s %s
+		LIMIT ? OFFSET ?
+	`, sortBy, order)
+
+	comments, total, err := r.queryPage(ctx, sqlq, match, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("SQLiteCommentRepo - SearchComments - r.queryPage: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+func (r *SQLiteCommentRepo) GetRootComments(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	if viewer != "" {
+		comments, total, err := r.getRootCommentsFor(ctx, viewer, sortBy, order, limit, offset)
+		if err != nil {
+			return nil, 0, fmt.Errorf("SQLiteCommentRepo - GetRootComments - r.getRootCommentsFor: %w", err)
+		}
+
+		return comments, total, nil
+	}
+
+	sqlq := fmt.Sprintf(`
+		SELECT id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth, COUNT(*) OVER()
+		FROM comments
+		WHERE parent_id IS NULL
+		ORDER BY pin_order IS NULL, pin_order, %s %s
+		LIMIT ? OFFSET ?
+	`, sortBy, order)
+
+	comments, total, err := r.queryPage(ctx, sqlq, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("SQLiteCommentRepo - GetRootComments - r.queryPage: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+// getRootCommentsFor - как у Postgres: страница корней вместе с визитами viewer,
+// непрочитанное считается по деревьям только этих корней.
+func (r *SQLiteCommentRepo) getRootCommentsFor(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	sqlq := fmt.Sprintf(`
+		WITH RECURSIVE page AS (
+			SELECT c.id, c.parent_id, c.content, c.author, c.created_at, c.version, c.updated_at, c.pin_order, c.highlighted,
+				c.lock_state, c.lock_reason, c.reply_to_id, c.max_depth, COUNT(*) OVER() AS total, COALESCE(v.last_seen_id, 0) AS last_seen_id
+			FROM comments c
+			LEFT JOIN root_visits v ON v.root_id = c.id AND v.viewer = ?1
+			WHERE c.parent_id IS NULL
+			ORDER BY c.pin_order IS NULL, c.pin_order, c.%[1]s %[2]s
+			LIMIT ?2 OFFSET ?3
+		),
+		tree AS (
+			SELECT id, id AS root_id, author FROM page
+			UNION ALL
+			SELECT c.id, t.root_id, c.author
+			FROM comments c
+			JOIN tree t ON c.parent_id = t.id
+		),
+		unread AS (
+			SELECT t.root_id, COUNT(*) AS new_count
+			FROM tree t
+			JOIN page p ON p.id = t.root_id
+			WHERE t.id > p.last_seen_id AND t.author <> ?1
+			GROUP BY t.root_id
+		)
+		SELECT p.id, p.parent_id, p.content, p.author, p.created_at, p.version, p.updated_at, p.pin_order, p.highlighted,
+			p.lock_state, p.lock_reason, p.reply_to_id, p.max_depth, p.total, p.last_seen_id, COALESCE(u.new_count, 0)
+		FROM page p
+		LEFT JOIN unread u ON u.root_id = p.id
+		ORDER BY p.pin_order IS NULL, p.pin_order, p.%[1]s %[2]s
+	`, sortBy, order)
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, viewer, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []entity.Comment
+	var total int
+
+	for rows.Next() {
+		var c entity.Comment
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &total, &c.LastSeenID, &c.NewCount)
+		if err != nil {
+			return nil, 0, fmt.Errorf("rows.Scan: %w", err)
+		}
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+func (r *SQLiteCommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64, childSort string) ([]entity.Comment, error) {
+	if len(rootIDs) == 0 {
+		return []entity.Comment{}, nil
+	}
+
+	where, args, err := squirrel.Eq{idColumn: rootIDs}.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetTreesForRoots - squirrel.Eq.ToSql: %w", err)
+	}
+
+	comments, err := r.queryTrees(ctx, where, args, childSort)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetTreesForRoots - r.queryTrees: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (r *SQLiteCommentRepo) GetCommentPath(ctx context.Context, id int64) ([]int64, error) {
+	path, err := r.commentPath(ctx, r.DB, id)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentPath - r.commentPath: %w", err)
+	}
+
+	if len(path) == 0 {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentPath: %w", errs.ErrRecordNotFound)
+	}
+
+	return path, nil
+}
+
+// ExportComments - один SELECT без явной транзакции: в режиме WAL он видит согласованный
+// снимок и не блокирует запись, строки читаются по мере выгрузки.
+func (r *SQLiteCommentRepo) ExportComments(ctx context.Context, rootID *int64) iter.Seq2[entity.Comment, error] {
+	return func(yield func(entity.Comment, error) bool) {
+		err := r.export(ctx, rootID, yield)
+		if err != nil {
+			yield(entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - ExportComments - r.export: %w", err))
+		}
+	}
+}
+
+func (r *SQLiteCommentRepo) export(ctx context.Context, rootID *int64, yield func(entity.Comment, error) bool) error {
+	sqlq := `
+	SELECT id, parent_id, content, author, created_at, version, updated_at
+	FROM comments
+	ORDER BY id
+	`
+	args := []any{}
+
+	if rootID != nil {
+		sqlq = fmt.Sprintf(`
+		WITH RECURSIVE comment_tree AS (
+			SELECT id, parent_id, content, author, created_at, version, updated_at, %s AS path
+			FROM comments
+			WHERE id = ?
+
+			UNION ALL
+
+			SELECT c.id, c.parent_id, c.content, c.author, c.created_at, c.version, c.updated_at, ct.path || '/' || %s
+			FROM comments c
+			INNER JOIN comment_tree ct ON c.parent_id = ct.id
+		)
+		SELECT id, parent_id, content, author, created_at, version, updated_at
+		FROM comment_tree
+		ORDER BY path
+		`, fmt.Sprintf(sqlitePathFormat, "id"), fmt.Sprintf(sqlitePathFormat, "c.id"))
+		args = append(args, *rootID)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c entity.Comment
+
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("rows.Scan: %w", err)
+		}
+
+		if !yield(c, nil) {
+			return nil
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return nil
+}
+
+// ImportComments - COPY в SQLite нет, вставки идут одним подготовленным запросом в транзакции.
+func (r *SQLiteCommentRepo) ImportComments(ctx context.Context, comments []entity.Comment) ([]int64, error) {
+	if len(comments) == 0 {
+		return []int64{}, nil
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ImportComments - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	stmt, err := tx.PrepareContext(ctx, `
+	INSERT INTO comments (parent_id, content, content_text, author, created_at, version, updated_at)
+	VALUES (?, ?, ?, ?, ?, 1, ?)
+	RETURNING id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ImportComments - tx.PrepareContext: %w", err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, len(comments))
+	newIDs := make(map[int64]int64, len(comments))
+
+	for i, c := range comments {
+		var parentID *int64
+		if c.ParentID.Valid {
+			newParentID, ok := newIDs[c.ParentID.Int64]
+			if !ok {
+				return nil, fmt.Errorf("SQLiteCommentRepo - ImportComments - parent %d of %d: %w", c.ParentID.Int64, c.ID, errs.ErrRecordNotFound)
+			}
+			parentID = &newParentID
+		}
+
+		createdAt := c.CreatedAt.UTC()
+		if c.CreatedAt.IsZero() {
+			createdAt = time.Now().UTC()
+		}
+
+		err = stmt.QueryRowContext(ctx, parentID, c.Content, markdown.Text(c.Content), c.Author, createdAt, createdAt).Scan(&ids[i])
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - ImportComments - stmt.QueryRowContext.Scan: %w", err)
+		}
+
+		newIDs[c.ID] = ids[i]
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{Action: entity.AuditImport, After: entity.AuditIDs(ids)})
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ImportComments - r.audit: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ImportComments - tx.Commit: %w", err)
+	}
+
+	return ids, nil
+}
+
+// CreateFromSource - как CreateComment, но автор и время создания берутся из источника,
+// а соответствие source/sourceID записывается в той же транзакции.
+func (r *SQLiteCommentRepo) CreateFromSource(ctx context.Context, source, sourceID string, c entity.Comment) (entity.Comment, error) {
+	now := time.Now().UTC()
+
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = now
+	}
+	c.CreatedAt = c.CreatedAt.UTC()
+	c.Version = 1
+	c.UpdatedAt = c.CreatedAt
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	var (
+		path     []int64
+		parentID *int64
+	)
+
+	if c.ParentID.Valid {
+		parentID = &c.ParentID.Int64
+
+		path, err = r.commentPath(ctx, tx, c.ParentID.Int64)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - r.commentPath: %w", err)
+		}
+
+		if len(path) == 0 {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - parent: %w", errs.ErrRecordNotFound)
+		}
+
+		err = r.touchComments(ctx, tx, path, now)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - r.touchComments: %w", err)
+		}
+	}
+
+	sqlq, args, err := r.Builder.
+		Insert(commentsTable).
+		Columns(parentIDColumn, contentColumn, contentTextColumn, authorColumn, createdAtColumn, versionColumn, updatedAtColumn).
+		Values(parentID, c.Content, markdown.Text(c.Content), c.Author, c.CreatedAt, c.Version, c.UpdatedAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - r.Builder.ToSql: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, sqlq, args...).Scan(&c.ID)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - tx.QueryRowContext.Scan: %w", err)
+	}
+
+	sqlq, args, err = r.Builder.
+		Insert(importSourcesTable).
+		Columns(sourceColumn, sourceIDColumn, commentIDColumn, "imported_at").
+		Values(source, sourceID, c.ID, now).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - r.Builder.ToSql: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - tx.ExecContext: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - res.RowsAffected: %w", err)
+	}
+
+	if affected == 0 {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - %s/%s: %w", source, sourceID, errs.ErrAlreadyExists)
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    entity.AuditCreate,
+		CommentID: sql.NullInt64{Int64: c.ID, Valid: true},
+		After:     entity.AuditSnapshot(c),
+	})
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - r.audit: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("SQLiteCommentRepo - CreateFromSource - tx.Commit: %w", err)
+	}
+
+	c.Depth = len(path)
+	c.Path = append(path, c.ID)
+
+	return c, nil
+}
+
+// GetSourceMapping - id запрашиваются пачками, чтобы не упереться в лимит параметров SQLite.
+func (r *SQLiteCommentRepo) GetSourceMapping(ctx context.Context, source string, sourceIDs []string) (map[string]int64, error) {
+	mapping := make(map[string]int64)
+
+	for batch := range slices.Chunk(sourceIDs, _exportBatchSize) {
+		sqlq, args, err := r.Builder.
+			Select(sourceIDColumn, "COALESCE(comment_id, 0)").
+			From(importSourcesTable).
+			Where(squirrel.Eq{sourceColumn: source, sourceIDColumn: batch}).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetSourceMapping - r.Builder.ToSql: %w", err)
+		}
+
+		err = r.scanSourceMapping(ctx, mapping, sqlq, args)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetSourceMapping - r.scanSourceMapping: %w", err)
+		}
+	}
+
+	return mapping, nil
+}
+
+func (r *SQLiteCommentRepo) scanSourceMapping(ctx context.Context, mapping map[string]int64, sqlq string, args []any) error {
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sourceID  string
+			commentID int64
+		)
+
+		err = rows.Scan(&sourceID, &commentID)
+		if err != nil {
+			return fmt.Errorf("rows.Scan: %w", err)
+		}
+
+		mapping[sourceID] = commentID
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return nil
+}
+
+// GetCommentsByIDs - путь строится от каждого коммента вверх до корня.
+func (r *SQLiteCommentRepo) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	comments := make(map[int64]entity.Comment, len(ids))
+
+	if len(ids) == 0 {
+		return comments, nil
+	}
+
+	where, args, err := squirrel.Eq{idColumn: ids}.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentsByIDs - squirrel.Eq.ToSql: %w", err)
+	}
+
+	sqlq := fmt.Sprintf(`
+	WITH RECURSIVE ancestors AS (
+		SELECT id AS start_id, parent_id, 0 AS lvl, %s AS path
+		FROM comments
+		WHERE %s
+
+		UNION ALL
+
+		SELECT a.start_id, c.parent_id, a.lvl + 1, %s || '/' || a.path
+		FROM comments c
+		INNER JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT c.id, c.parent_id, c.content, c.author, c.created_at, c.version, c.updated_at, c.pin_order, c.highlighted, c.lock_state, c.lock_reason, c.reply_to_id, c.max_depth, a.lvl, a.path
+	FROM ancestors a
+	INNER JOIN comments c ON c.id = a.start_id
+	WHERE a.parent_id IS NULL;
+	`, fmt.Sprintf(sqlitePathFormat, "id"), where, fmt.Sprintf(sqlitePathFormat, "c.id"))
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentsByIDs - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			c    entity.Comment
+			path string
+		)
+
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &c.Depth, &path)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentsByIDs - rows.Scan: %w", err)
+		}
+
+		c.Path, err = parseSQLitePath(path)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentsByIDs - parseSQLitePath: %w", err)
+		}
+
+		comments[c.ID] = c
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetCommentsByIDs - rows.Err: %w", err)
+	}
+
+	return comments, nil
+}
+
+// GetChildren - нумерация ответов внутри каждого родителя через ROW_NUMBER(),
+// при равных created_at порядок определяет id.
+func (r *SQLiteCommentRepo) GetChildren(ctx context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error) {
+	children := make(map[int64][]entity.Comment)
+
+	if len(parentIDs) == 0 {
+		return children, nil
+	}
+
+	where, args, err := squirrel.Eq{parentIDColumn: parentIDs}.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetChildren - squirrel.Eq.ToSql: %w", err)
+	}
+
+	sqlq := fmt.Sprintf(`
+	SELECT id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth
+	FROM (
+		SELECT
+			id, parent_id, content, author, created_at, version, updated_at, pin_order, highlighted, lock_state, lock_reason, reply_to_id, max_depth,
+			ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY pin_order IS NULL, pin_order, %s %s, id %s) AS rn
+		FROM comments
+		WHERE %s
+	) replies
+	WHERE rn > ? AND rn <= ?
+	ORDER BY parent_id, rn;
+	`, sortBy, order, order, where)
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, append(args, offset, offset+limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetChildren - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c entity.Comment
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetChildren - rows.Scan: %w", err)
+		}
+
+		children[c.ParentID.Int64] = append(children[c.ParentID.Int64], c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetChildren - rows.Err: %w", err)
+	}
+
+	return children, nil
+}
+
+func (r *SQLiteCommentRepo) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	counts := make(map[int64]entity.ReplyCount, len(ids))
+
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	where, args, err := squirrel.Eq{idColumn: ids}.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - CountReplies - squirrel.Eq.ToSql: %w", err)
+	}
+
+	sqlq := fmt.Sprintf(`
+	WITH RECURSIVE subtree AS (
+		SELECT id AS root_id, id, 0 AS depth
+		FROM comments
+		WHERE %s
+
+		UNION ALL
+
+		SELECT s.root_id, c.id, s.depth + 1
+		FROM comments c
+		INNER JOIN subtree s ON c.parent_id = s.id
+	)
+	SELECT root_id, COUNT(*) FILTER (WHERE depth = 1), COUNT(*) - 1
+	FROM subtree
+	GROUP BY root_id;
+	`, where)
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - CountReplies - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    int64
+			count entity.ReplyCount
+		)
+
+		err = rows.Scan(&id, &count.Children, &count.Descendants)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - CountReplies - rows.Scan: %w", err)
+		}
+
+		counts[id] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - CountReplies - rows.Err: %w", err)
+	}
+
+	return counts, nil
+}
+
+// sqliteQuerier - общее у *sql.DB и *sql.Tx.
+func (r *SQLiteCommentRepo) SetPinOrder(ctx context.Context, id int64, order *int64) error {
+	action := entity.AuditPin
+	if order == nil {
+		action = entity.AuditUnpin
+	}
+
+	err := r.updateComment(ctx, id, action, map[string]any{pinOrderColumn: order})
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - SetPinOrder - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	action := entity.AuditHighlight
+	if !highlighted {
+		action = entity.AuditUnhighlight
+	}
+
+	err := r.updateComment(ctx, id, action, map[string]any{highlightedColumn: highlighted})
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - SetHighlighted - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) SetLock(ctx context.Context, id int64, state, reason string) error {
+	action := entity.AuditLock
+	if state == entity.LockNone {
+		action = entity.AuditUnlock
+	}
+
+	err := r.updateComment(ctx, id, action, map[string]any{lockStateColumn: state, lockReasonColumn: reason})
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - SetLock - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	err := r.updateComment(ctx, id, entity.AuditMaxDepth, map[string]any{maxDepthColumn: maxDepth})
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - SetMaxDepth - r.updateComment: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveInactive - архивирует корни, в деревьях которых ничего не менялось с before.
+// updated_at корня растет при любом изменении в дереве, так что это и есть последняя активность.
+func (r *SQLiteCommentRepo) ArchiveInactive(ctx context.Context, before time.Time, reason string) ([]int64, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ArchiveInactive - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	roots, err := queryAuditComments(ctx, tx, `
+	SELECT `+auditSnapshotColumns+`
+	FROM comments
+	WHERE parent_id IS NULL AND lock_state <> 'archived' AND updated_at < ?
+	ORDER BY id;
+	`, before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ArchiveInactive - queryAuditComments: %w", err)
+	}
+
+	if len(roots) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(roots))
+	entries := make([]entity.AuditEntry, len(roots))
+
+	for i, root := range roots {
+		ids[i] = root.ID
+
+		archived := root
+		archived.LockState, archived.LockReason = entity.LockArchived, reason
+
+		entries[i] = entity.AuditEntry{
+			Action:    entity.AuditArchive,
+			CommentID: sql.NullInt64{Int64: root.ID, Valid: true},
+			Before:    entity.AuditSnapshot(root),
+			After:     entity.AuditSnapshot(archived),
+		}
+	}
+
+	for batch := range slices.Chunk(ids, _exportBatchSize) {
+		sqlq, args, err := r.Builder.
+			Update(commentsTable).
+			Set(lockStateColumn, entity.LockArchived).
+			Set(lockReasonColumn, reason).
+			Set(versionColumn, squirrel.Expr("version + 1")).
+			Set(updatedAtColumn, time.Now().UTC()).
+			Where(squirrel.Eq{idColumn: batch}).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - ArchiveInactive - r.Builder.ToSql: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, sqlq, args...)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - ArchiveInactive - tx.ExecContext: %w", err)
+		}
+	}
+
+	err = r.audit(ctx, tx, entries...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ArchiveInactive - r.audit: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - ArchiveInactive - tx.Commit: %w", err)
+	}
+
+	return ids, nil
+}
+
+// updateComment - меняет колонки коммента id и повышает версии его и всех предков в одной транзакции,
+// в ней же пишет в журнал action со снимками до и после.
+func (r *SQLiteCommentRepo) updateComment(ctx context.Context, id int64, action string, values map[string]any) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	path, err := r.commentPath(ctx, tx, id)
+	if err != nil {
+		return fmt.Errorf("r.commentPath: %w", err)
+	}
+
+	if len(path) == 0 {
+		return errs.ErrRecordNotFound
+	}
+
+	err = r.touchComments(ctx, tx, path, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("r.touchComments: %w", err)
+	}
+
+	before, err := r.auditComments(ctx, tx, []int64{id})
+	if err != nil {
+		return fmt.Errorf("r.auditComments: %w", err)
+	}
+
+	sqlq, args, err := r.Builder.
+		Update(commentsTable).
+		SetMap(values).
+		Where(squirrel.Eq{idColumn: id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	after, err := r.auditComments(ctx, tx, []int64{id})
+	if err != nil {
+		return fmt.Errorf("r.auditComments: %w", err)
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{
+		Action:    action,
+		CommentID: sql.NullInt64{Int64: id, Valid: true},
+		Before:    entity.AuditSnapshot(before[0]),
+		After:     entity.AuditSnapshot(after[0]),
+	})
+	if err != nil {
+		return fmt.Errorf("r.audit: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+type sqliteQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// commentPath - id предков от корня до самого коммента; пустой, если коммента нет.
+func (r *SQLiteCommentRepo) commentPath(ctx context.Context, q sqliteQuerier, id int64) ([]int64, error) {
+	sqlq := `
+	WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id, 0 AS lvl
+		FROM comments
+		WHERE id = ?
+
+		UNION ALL
+
+		SELECT c.id, c.parent_id, a.lvl + 1
+		FROM comments c
+		INNER JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT id
+	FROM ancestors
+	ORDER BY lvl DESC;
+	`
+
+	rows, err := q.QueryContext(ctx, sqlq, id)
+	if err != nil {
+		return nil, fmt.Errorf("q.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var path []int64
+
+	for rows.Next() {
+		var ancestorID int64
+		err = rows.Scan(&ancestorID)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+		path = append(path, ancestorID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return path, nil
+}
+
+// branchLock - как у Postgres; запись в базу уже одна, так что блокировок строк не нужно.
+func (r *SQLiteCommentRepo) branchLock(ctx context.Context, q sqliteQuerier, path []int64) (string, error) {
+	sqlq, args, err := r.Builder.
+		Select(idColumn, lockStateColumn, lockReasonColumn).
+		From(commentsTable).
+		Where(squirrel.Eq{idColumn: path}).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	rows, err := q.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return "", fmt.Errorf("q.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var locks []entity.Comment
+
+	for rows.Next() {
+		var c entity.Comment
+		err = rows.Scan(&c.ID, &c.LockState, &c.LockReason)
+		if err != nil {
+			return "", fmt.Errorf("rows.Scan: %w", err)
+		}
+		locks = append(locks, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return branchState(path, locks), nil
+}
+
+// touchComments - повышает version и updated_at у комментов ids.
+// Транзакции открываются как BEGIN IMMEDIATE, так что гонки между чтением пути и обновлением нет.
+func (r *SQLiteCommentRepo) touchComments(ctx context.Context, tx *sql.Tx, ids []int64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	sqlq, args, err := r.Builder.
+		Update(commentsTable).
+		Set(versionColumn, squirrel.Expr("version + 1")).
+		Set(updatedAtColumn, now).
+		Where(squirrel.Eq{idColumn: ids}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("r.Builder.ToSql: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("tx.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// queryTrees - рекурсивно выбирает поддеревья корней, подходящих под anchorWhere,
+// в порядке entity.SortTree, как treeSQL у Postgres.
+func (r *SQLiteCommentRepo) queryTrees(ctx context.Context, anchorWhere string, args []any, childSort string) ([]entity.Comment, error) {
+	var scores, scoresJoin string
+
+	if childSort == entity.ChildSortScore {
+		// массивов нет - пары (предок, потомок) собираются отдельной рекурсией
+		scores = `
+	ancestry AS (
+		SELECT id AS ancestor_id, id
+		FROM comment_tree
+
+		UNION ALL
+
+		SELECT a.ancestor_id, ct.id
+		FROM ancestry a
+		INNER JOIN comment_tree ct ON ct.parent_id = a.id
+	),
+	scores AS (
+		SELECT ancestor_id AS id, COUNT(*) - 1 AS score
+		FROM ancestry
+		GROUP BY ancestor_id
+	),`
+		scoresJoin = "INNER JOIN scores s ON s.id = ct.id"
+	}
+
+	sqlq := fmt.Sprintf(`
+	WITH RECURSIVE comment_tree AS (
+		SELECT
+			id,
+			parent_id,
+			content,
+			author,
+			created_at,
+			version,
+			updated_at,
+			pin_order,
+			highlighted,
+			lock_state,
+			lock_reason,
+			reply_to_id,
+			max_depth,
+			0 AS depth,
+			%s AS path
+		FROM comments
+		WHERE %s
+
+		UNION ALL
+
+		SELECT
+			c.id,
+			c.parent_id,
+			c.content,
+			c.author,
+			c.created_at,
+			c.version,
+			c.updated_at,
+			c.pin_order,
+			c.highlighted,
+			c.lock_state,
+			c.lock_reason,
+			c.reply_to_id,
+			c.max_depth,
+			ct.depth + 1,
+			ct.path || '/' || %s
+		FROM comments c
+		INNER JOIN comment_tree ct ON c.parent_id = ct.id
+	),%s
+	ranked AS (
+		SELECT ct.*, ROW_NUMBER() OVER (PARTITION BY ct.parent_id ORDER BY %s) AS rn
+		FROM comment_tree ct
+		%s
+	),
+	ordered AS (
+		SELECT id, %s AS sort_path
+		FROM ranked
+		WHERE depth = 0
+
+		UNION ALL
+
+		SELECT r.id, o.sort_path || '/' || %s
+		FROM ranked r
+		INNER JOIN ordered o ON r.parent_id = o.id
+	)
+	SELECT r.id, r.parent_id, r.content, r.author, r.created_at, r.version, r.updated_at, r.pin_order, r.highlighted, r.lock_state, r.lock_reason, r.reply_to_id, r.max_depth, r.depth, r.path
+	FROM ranked r
+	INNER JOIN ordered o ON o.id = r.id
+	ORDER BY o.sort_path;
+	`, fmt.Sprintf(sqlitePathFormat, "id"), anchorWhere, fmt.Sprintf(sqlitePathFormat, "c.id"),
+		scores, siblingOrder(childSort), scoresJoin,
+		fmt.Sprintf(sqlitePathFormat, "rn"), fmt.Sprintf(sqlitePathFormat, "r.rn"))
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []entity.Comment
+
+	for rows.Next() {
+		var (
+			c    entity.Comment
+			path string
+		)
+
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &c.Depth, &path)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+
+		c.Path, err = parseSQLitePath(path)
+		if err != nil {
+			return nil, fmt.Errorf("parseSQLitePath: %w", err)
+		}
+
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (r *SQLiteCommentRepo) queryPage(ctx context.Context, sqlq string, args ...any) ([]entity.Comment, int, error) {
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []entity.Comment
+	var total int
+
+	for rows.Next() {
+		var c entity.Comment
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &c.Version, &c.UpdatedAt, &c.PinOrder, &c.Highlighted, &c.LockState, &c.LockReason, &c.ReplyToID, &c.MaxDepth, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("rows.Scan: %w", err)
+		}
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+func parseSQLitePath(path string) ([]int64, error) {
+	parts := strings.Split(path, "/")
+	ids := make([]int64, len(parts))
+
+	for i, p := range parts {
+		id, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+// ftsMatchQuery - превращает пользовательский текст в запрос FTS5:
+// каждое слово в кавычках, слова объединяются через AND, как plainto_tsquery.
+func ftsMatchQuery(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, w := range words {
+		words[i] = `"` + w + `"`
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,84 @@
+package persistent_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/persistent"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/repotest"
+	"github.com/andreyxaxa/Comment-Tree/migrations"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/migrator"
+	"github.com/andreyxaxa/Comment-Tree/pkg/sqlite"
+)
+
+func newSQLite(t *testing.T) (*persistent.SQLiteCommentRepo, *sqlite.SQLite) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "comments.db")
+
+	mg, err := migrator.NewSQLite(path, migrations.SQLite())
+	if err != nil {
+		t.Fatalf("migrator.NewSQLite: %v", err)
+	}
+
+	err = mg.Up()
+	mg.Close()
+	if err != nil {
+		t.Fatalf("mg.Up: %v", err)
+	}
+
+	sl, err := sqlite.New(path)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(sl.Close)
+
+	return persistent.NewSQLite(sl), sl
+}
+
+func TestSQLiteCommentRepo(t *testing.T) {
+	repotest.TestCommentRepo(t, func(t *testing.T) repo.CommentRepo {
+		r, _ := newSQLite(t)
+
+		return r
+	})
+}
+
+func TestSQLiteAuditAppendOnly(t *testing.T) {
+	ctx := caller.WithUser(context.Background(), "alice")
+	r, sl := newSQLite(t)
+
+	if _, err := r.CreateComment(ctx, nil, nil, "alice", "root", nil); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	appendOnly := func(name string) {
+		t.Helper()
+
+		for _, q := range []string{"UPDATE audit_log SET actor = 'mallory'", "DELETE FROM audit_log"} {
+			if _, err := sl.DB.ExecContext(ctx, q); err == nil || !strings.Contains(err.Error(), "append-only") {
+				t.Fatalf("%s: %q = %v, want append-only error", name, q, err)
+			}
+		}
+	}
+
+	appendOnly("before erase")
+
+	// стирание автора - единственный, кто меняет записи
+	if _, err := r.EraseAuthor(caller.WithUser(context.Background(), "dpo"), "alice", entity.ErasedContent); err != nil {
+		t.Fatalf("EraseAuthor: %v", err)
+	}
+
+	entries, err := r.GetAuditLog(ctx, entity.AuditFilter{Actor: "alice"}, 0, 10)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("GetAuditLog(alice) after erase = %+v, %v; want none", entries, err)
+	}
+
+	// и разрешение не переживает его транзакцию
+	appendOnly("after erase")
+}
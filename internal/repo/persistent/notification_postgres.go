@@ -0,0 +1,193 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Table
+	notificationsTable = "notifications"
+	threadMutesTable   = "thread_mutes"
+
+	// Columns
+	recipientColumn = "recipient"
+	kindColumn      = "kind"
+	actorColumn     = "actor"
+	readAtColumn    = "read_at"
+)
+
+func (r *CommentRepo) CreateNotifications(ctx context.Context, ns []entity.Notification) ([]entity.Notification, error) {
+	if len(ns) == 0 {
+		return nil, nil
+	}
+
+	insert := r.Builder.
+		Insert(notificationsTable).
+		Columns(recipientColumn, kindColumn, commentIDColumn, actorColumn).
+		Suffix("RETURNING id, created_at")
+	for _, n := range ns {
+		insert = insert.Values(n.Recipient, n.Kind, n.CommentID, n.Actor)
+	}
+
+	sqlq, args, err := insert.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - CreateNotifications - r.Builder.ToSql: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - CreateNotifications - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	// RETURNING многострочного INSERT ... VALUES идет в порядке VALUES
+	created := make([]entity.Notification, 0, len(ns))
+	for i := 0; rows.Next(); i++ {
+		n := ns[i]
+
+		err = rows.Scan(&n.ID, &n.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - CreateNotifications - rows.Scan: %w", err)
+		}
+
+		created = append(created, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("CommentRepo - CreateNotifications - rows.Err: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *CommentRepo) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	sqlq, args, err := notificationsQuery(r.Builder, recipient, status, beforeID, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetNotifications - r.Builder.ToSql: %w", err)
+	}
+
+	rows, err := r.Pool.Query(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetNotifications - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var ns []entity.Notification
+
+	for rows.Next() {
+		var n entity.Notification
+
+		err = rows.Scan(&n.ID, &n.Recipient, &n.Kind, &n.CommentID, &n.Actor, &n.Content, &n.CreatedAt, &n.ReadAt)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - GetNotifications - rows.Scan: %w", err)
+		}
+
+		ns = append(ns, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetNotifications - rows.Err: %w", err)
+	}
+
+	return ns, nil
+}
+
+func (r *CommentRepo) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	update := r.Builder.
+		Update(notificationsTable).
+		Set(readAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{recipientColumn: recipient, readAtColumn: nil})
+	if ids != nil {
+		update = update.Where(squirrel.Eq{idColumn: ids})
+	}
+
+	sqlq, args, err := update.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - MarkNotificationsRead - r.Builder.ToSql: %w", err)
+	}
+
+	tag, err := r.Pool.Exec(ctx, sqlq, args...)
+	if err != nil {
+		return 0, fmt.Errorf("CommentRepo - MarkNotificationsRead - r.Pool.Exec: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// SetMute - отсутствующий коммент - errs.ErrRecordNotFound, повторная заглушка ничего не меняет.
+func (r *CommentRepo) SetMute(ctx context.Context, user string, id int64, muted bool) error {
+	err := r.CommentExists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - SetMute - r.CommentExists: %w", err)
+	}
+
+	sqlq := `
+	INSERT INTO thread_mutes (recipient, comment_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING;
+	`
+	if !muted {
+		sqlq = `DELETE FROM thread_mutes WHERE recipient = $1 AND comment_id = $2;`
+	}
+
+	_, err = r.Pool.Exec(ctx, sqlq, user, id)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - SetMute - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) GetMutedUsers(ctx context.Context, ids []int64, users []string) ([]string, error) {
+	if len(ids) == 0 || len(users) == 0 {
+		return nil, nil
+	}
+
+	sqlq := `
+	SELECT DISTINCT recipient
+	FROM thread_mutes
+	WHERE comment_id = ANY($1) AND recipient = ANY($2)
+	ORDER BY recipient;
+	`
+
+	rows, err := r.Pool.Query(ctx, sqlq, ids, users)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetMutedUsers - r.Pool.Query: %w", err)
+	}
+
+	muted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetMutedUsers - pgx.CollectRows: %w", err)
+	}
+
+	return muted, nil
+}
+
+// notificationsQuery - страница входящих вместе с текстом коммента, одинаковая для Postgres и SQLite.
+func notificationsQuery(b squirrel.StatementBuilderType, recipient, status string, beforeID int64, limit int) squirrel.SelectBuilder {
+	q := b.
+		Select("n.id", "n.recipient", "n.kind", "n.comment_id", "n.actor", "c.content", "n.created_at", "n.read_at").
+		From(notificationsTable + " n").
+		Join(commentsTable + " c ON c.id = n.comment_id").
+		Where(squirrel.Eq{"n.recipient": recipient}).
+		OrderBy("n.id DESC").
+		Limit(uint64(limit)) //nolint:gosec // limit проверен в контроллере
+
+	switch status {
+	case entity.NotificationsUnread:
+		q = q.Where(squirrel.Eq{"n.read_at": nil})
+	case entity.NotificationsRead:
+		q = q.Where(squirrel.NotEq{"n.read_at": nil})
+	}
+
+	if beforeID > 0 {
+		q = q.Where(squirrel.Lt{"n.id": beforeID})
+	}
+
+	return q
+}
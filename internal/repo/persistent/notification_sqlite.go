@@ -0,0 +1,173 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+func (r *SQLiteCommentRepo) CreateNotifications(ctx context.Context, ns []entity.Notification) ([]entity.Notification, error) {
+	if len(ns) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - CreateNotifications - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	stmt, err := tx.PrepareContext(ctx, `
+	INSERT INTO notifications (recipient, kind, comment_id, actor, created_at)
+	VALUES (?, ?, ?, ?, ?)
+	RETURNING id;
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - CreateNotifications - tx.PrepareContext: %w", err)
+	}
+	defer stmt.Close()
+
+	created := make([]entity.Notification, 0, len(ns))
+	for _, n := range ns {
+		n.CreatedAt = now
+
+		err = stmt.QueryRowContext(ctx, n.Recipient, n.Kind, n.CommentID, n.Actor, now).Scan(&n.ID)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - CreateNotifications - stmt.QueryRowContext.Scan: %w", err)
+		}
+
+		created = append(created, n)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - CreateNotifications - tx.Commit: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *SQLiteCommentRepo) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	sqlq, args, err := notificationsQuery(r.Builder, recipient, status, beforeID, limit).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetNotifications - r.Builder.ToSql: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetNotifications - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var ns []entity.Notification
+
+	for rows.Next() {
+		var n entity.Notification
+
+		err = rows.Scan(&n.ID, &n.Recipient, &n.Kind, &n.CommentID, &n.Actor, &n.Content, &n.CreatedAt, &n.ReadAt)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetNotifications - rows.Scan: %w", err)
+		}
+
+		ns = append(ns, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetNotifications - rows.Err: %w", err)
+	}
+
+	return ns, nil
+}
+
+func (r *SQLiteCommentRepo) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	update := r.Builder.
+		Update(notificationsTable).
+		Set(readAtColumn, time.Now().UTC()).
+		Where(squirrel.Eq{recipientColumn: recipient, readAtColumn: nil})
+	if ids != nil {
+		update = update.Where(squirrel.Eq{idColumn: ids})
+	}
+
+	sqlq, args, err := update.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - MarkNotificationsRead - r.Builder.ToSql: %w", err)
+	}
+
+	res, err := r.DB.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - MarkNotificationsRead - r.DB.ExecContext: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("SQLiteCommentRepo - MarkNotificationsRead - res.RowsAffected: %w", err)
+	}
+
+	return int(n), nil
+}
+
+// SetMute - отсутствующий коммент - errs.ErrRecordNotFound, повторная заглушка ничего не меняет.
+func (r *SQLiteCommentRepo) SetMute(ctx context.Context, user string, id int64, muted bool) error {
+	err := r.CommentExists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - SetMute - r.CommentExists: %w", err)
+	}
+
+	if muted {
+		_, err = r.DB.ExecContext(ctx, `
+		INSERT INTO thread_mutes (recipient, comment_id, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT DO NOTHING;
+		`, user, id, time.Now().UTC())
+	} else {
+		_, err = r.DB.ExecContext(ctx, `DELETE FROM thread_mutes WHERE recipient = ? AND comment_id = ?;`, user, id)
+	}
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - SetMute - r.DB.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) GetMutedUsers(ctx context.Context, ids []int64, users []string) ([]string, error) {
+	if len(ids) == 0 || len(users) == 0 {
+		return nil, nil
+	}
+
+	sqlq, args, err := r.Builder.
+		Select("DISTINCT " + recipientColumn).
+		From(threadMutesTable).
+		Where(squirrel.Eq{commentIDColumn: ids, recipientColumn: users}).
+		OrderBy(recipientColumn).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetMutedUsers - r.Builder.ToSql: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetMutedUsers - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var muted []string
+
+	for rows.Next() {
+		var user string
+		if err = rows.Scan(&user); err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetMutedUsers - rows.Scan: %w", err)
+		}
+		muted = append(muted, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetMutedUsers - rows.Err: %w", err)
+	}
+
+	return muted, nil
+}
@@ -0,0 +1,275 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Table
+	privacyRequestsTable = "privacy_requests"
+	rootVisitsTable      = "root_visits"
+	subscriptionsTable   = "subscriptions"
+)
+
+// authorCommentsQuery - комменты автора без поддеревьев, по id.
+func authorCommentsQuery(b squirrel.StatementBuilderType, author string) squirrel.SelectBuilder {
+	return b.
+		Select(idColumn, parentIDColumn, replyToIDColumn, contentColumn, authorColumn, createdAtColumn).
+		From(commentsTable).
+		Where(squirrel.Eq{authorColumn: author}).
+		OrderBy(idColumn)
+}
+
+func authorAttachmentsQuery(b squirrel.StatementBuilderType, author string) squirrel.SelectBuilder {
+	return b.
+		Select(attachmentColumns...).
+		From(attachmentsTable).
+		Where(squirrel.Eq{"uploader": author}).
+		OrderBy(idColumn)
+}
+
+func authorMutesQuery(b squirrel.StatementBuilderType, author string) squirrel.SelectBuilder {
+	return b.
+		Select(commentIDColumn, createdAtColumn).
+		From(threadMutesTable).
+		Where(squirrel.Eq{recipientColumn: author}).
+		OrderBy(commentIDColumn)
+}
+
+func authorVisitsQuery(b squirrel.StatementBuilderType, author string) squirrel.SelectBuilder {
+	return b.
+		Select("root_id", "last_seen_id", "seen_at").
+		From(rootVisitsTable).
+		Where(squirrel.Eq{"viewer": author}).
+		OrderBy("root_id")
+}
+
+// anonymizeQuery - текст комментов автора заменяется на content, автор стирается.
+func anonymizeQuery(b squirrel.StatementBuilderType, author, content string) squirrel.UpdateBuilder {
+	return b.
+		Update(commentsTable).
+		Set(authorColumn, "").
+		Set(contentColumn, content).
+		Set(contentTextColumn, content).
+		Where(squirrel.Eq{authorColumn: author}).
+		Suffix("RETURNING id")
+}
+
+// detachQuery - вложения автора отвязываются от комментов и помечаются прикрепленными,
+// чтобы их нельзя было прикрепить снова: такие строки - брошенные, их подбирает сборщик.
+func detachQuery(b squirrel.StatementBuilderType, author string, now time.Time) squirrel.UpdateBuilder {
+	return b.
+		Update(attachmentsTable).
+		Set(commentIDColumn, nil).
+		Set("uploader", "").
+		Set(attachedAtColumn, squirrel.Expr("COALESCE(attached_at, ?)", now)).
+		Where(squirrel.Eq{"uploader": author}).
+		Suffix("RETURNING " + strings.Join(attachmentColumns, ", "))
+}
+
+// eraseQueries - остальные следы автора: имя в чужих уведомлениях стирается,
+// его уведомления, заглушки, подписки и визиты удаляются.
+func eraseQueries(b squirrel.StatementBuilderType, author string) []squirrel.Sqlizer {
+	return []squirrel.Sqlizer{
+		b.Update(notificationsTable).Set(actorColumn, "").Where(squirrel.Eq{actorColumn: author}),
+		b.Delete(notificationsTable).Where(squirrel.Eq{recipientColumn: author}),
+		b.Delete(threadMutesTable).Where(squirrel.Eq{recipientColumn: author}),
+		b.Delete(subscriptionsTable).Where(squirrel.Eq{"subscriber": author}),
+		b.Delete(rootVisitsTable).Where(squirrel.Eq{"viewer": author}),
+	}
+}
+
+// GetAuthorData - одно согласованное чтение в транзакции REPEATABLE READ.
+func (r *CommentRepo) GetAuthorData(ctx context.Context, author string) (entity.AuthorData, error) {
+	data := entity.AuthorData{Author: author}
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return data, fmt.Errorf("CommentRepo - GetAuthorData - r.Pool.BeginTx: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // транзакция только читает
+
+	data.Comments, err = collect(ctx, tx, authorCommentsQuery(r.Builder, author), func(row pgx.CollectableRow) (entity.Comment, error) {
+		var c entity.Comment
+		err := row.Scan(&c.ID, &c.ParentID, &c.ReplyToID, &c.Content, &c.Author, &c.CreatedAt)
+
+		return c, err
+	})
+	if err != nil {
+		return data, fmt.Errorf("CommentRepo - GetAuthorData - comments: %w", err)
+	}
+
+	data.Attachments, err = collect(ctx, tx, authorAttachmentsQuery(r.Builder, author), func(row pgx.CollectableRow) (entity.Attachment, error) {
+		return scanAttachment(row)
+	})
+	if err != nil {
+		return data, fmt.Errorf("CommentRepo - GetAuthorData - attachments: %w", err)
+	}
+
+	data.Mutes, err = collect(ctx, tx, authorMutesQuery(r.Builder, author), func(row pgx.CollectableRow) (entity.Mute, error) {
+		var m entity.Mute
+		err := row.Scan(&m.CommentID, &m.CreatedAt)
+
+		return m, err
+	})
+	if err != nil {
+		return data, fmt.Errorf("CommentRepo - GetAuthorData - mutes: %w", err)
+	}
+
+	data.Visits, err = collect(ctx, tx, authorVisitsQuery(r.Builder, author), func(row pgx.CollectableRow) (entity.Visit, error) {
+		var v entity.Visit
+		err := row.Scan(&v.RootID, &v.LastSeenID, &v.SeenAt)
+
+		return v, err
+	})
+	if err != nil {
+		return data, fmt.Errorf("CommentRepo - GetAuthorData - visits: %w", err)
+	}
+
+	return data, nil
+}
+
+// EraseAuthor - все в одной транзакции; версии повышаются до изменений, как у остальных записей.
+func (r *CommentRepo) EraseAuthor(ctx context.Context, author, content string) (entity.Erasure, error) {
+	var erasure entity.Erasure
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	// комменты автора, ссылающиеся на них и предки тех и других
+	rows, err := tx.Query(ctx, `
+	WITH RECURSIVE affected AS (
+		SELECT id, parent_id
+		FROM comments
+		WHERE author = $1
+			OR id IN (
+				SELECT r.source_id
+				FROM comment_references r
+				INNER JOIN comments t ON t.id = r.target_id
+				WHERE t.author = $1
+			)
+
+		UNION
+
+		SELECT c.id, c.parent_id
+		FROM comments c
+		INNER JOIN affected a ON c.id = a.parent_id
+	)
+	SELECT id FROM affected ORDER BY id;
+	`, author)
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - tx.Query: %w", err)
+	}
+
+	erasure.Touched, err = pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - pgx.CollectRows: %w", err)
+	}
+
+	err = touchComments(ctx, tx, erasure.Touched)
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - touchComments: %w", err)
+	}
+
+	erasure.Comments, err = collect(ctx, tx, anonymizeQuery(r.Builder, author, content), pgx.RowTo[int64])
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - anonymize: %w", err)
+	}
+
+	slices.Sort(erasure.Comments)
+
+	// ссылки ">>id" были в стертом тексте
+	if len(erasure.Comments) > 0 {
+		_, err = tx.Exec(ctx, `DELETE FROM comment_references WHERE source_id = ANY($1);`, erasure.Comments)
+		if err != nil {
+			return erasure, fmt.Errorf("CommentRepo - EraseAuthor - tx.Exec: %w", err)
+		}
+	}
+
+	erasure.Attachments, err = collect(ctx, tx, detachQuery(r.Builder, author, time.Now().UTC()), func(row pgx.CollectableRow) (entity.Attachment, error) {
+		return scanAttachment(row)
+	})
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - detach: %w", err)
+	}
+
+	for _, q := range eraseQueries(r.Builder, author) {
+		sqlq, args, err := q.ToSql()
+		if err != nil {
+			return erasure, fmt.Errorf("CommentRepo - EraseAuthor - r.Builder.ToSql: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, sqlq, args...)
+		if err != nil {
+			return erasure, fmt.Errorf("CommentRepo - EraseAuthor - tx.Exec: %w", err)
+		}
+	}
+
+	err = r.scrubAudit(ctx, tx, author, content)
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - r.scrubAudit: %w", err)
+	}
+
+	// имени автора в записи нет - иначе его пришлось бы стирать следующим стиранием
+	err = r.audit(ctx, tx, entity.AuditEntry{Action: entity.AuditErase, After: entity.AuditIDs(erasure.Comments)})
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - r.audit: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return erasure, fmt.Errorf("CommentRepo - EraseAuthor - tx.Commit: %w", err)
+	}
+
+	return erasure, nil
+}
+
+func (r *CommentRepo) LogPrivacyRequest(ctx context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error) {
+	sqlq, args, err := r.Builder.
+		Insert(privacyRequestsTable).
+		Columns("action", authorColumn, actorColumn, "reason", "comments").
+		Values(p.Action, p.Author, p.Actor, p.Reason, p.Comments).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+	if err != nil {
+		return p, fmt.Errorf("CommentRepo - LogPrivacyRequest - r.Builder.ToSql: %w", err)
+	}
+
+	err = r.Pool.QueryRow(ctx, sqlq, args...).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return p, fmt.Errorf("CommentRepo - LogPrivacyRequest - r.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return p, nil
+}
+
+// collect - строки запроса q в транзакции tx.
+func collect[T any](ctx context.Context, tx pgx.Tx, q squirrel.Sqlizer, fn pgx.RowToFunc[T]) ([]T, error) {
+	sqlq, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("ToSql: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("tx.Query: %w", err)
+	}
+
+	items, err := pgx.CollectRows(rows, fn)
+	if err != nil {
+		return nil, fmt.Errorf("pgx.CollectRows: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,218 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+// GetAuthorData - чтения в одной транзакции, чтобы запись не вклинилась между ними.
+func (r *SQLiteCommentRepo) GetAuthorData(ctx context.Context, author string) (entity.AuthorData, error) {
+	data := entity.AuthorData{Author: author}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return data, fmt.Errorf("SQLiteCommentRepo - GetAuthorData - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // транзакция только читает
+
+	data.Comments, err = collectSQLite(ctx, tx, authorCommentsQuery(r.Builder, author), func(rows *sql.Rows) (entity.Comment, error) {
+		var c entity.Comment
+		err := rows.Scan(&c.ID, &c.ParentID, &c.ReplyToID, &c.Content, &c.Author, &c.CreatedAt)
+
+		return c, err
+	})
+	if err != nil {
+		return data, fmt.Errorf("SQLiteCommentRepo - GetAuthorData - comments: %w", err)
+	}
+
+	data.Attachments, err = collectSQLite(ctx, tx, authorAttachmentsQuery(r.Builder, author), func(rows *sql.Rows) (entity.Attachment, error) {
+		return scanAttachment(rows)
+	})
+	if err != nil {
+		return data, fmt.Errorf("SQLiteCommentRepo - GetAuthorData - attachments: %w", err)
+	}
+
+	data.Mutes, err = collectSQLite(ctx, tx, authorMutesQuery(r.Builder, author), func(rows *sql.Rows) (entity.Mute, error) {
+		var m entity.Mute
+		err := rows.Scan(&m.CommentID, &m.CreatedAt)
+
+		return m, err
+	})
+	if err != nil {
+		return data, fmt.Errorf("SQLiteCommentRepo - GetAuthorData - mutes: %w", err)
+	}
+
+	data.Visits, err = collectSQLite(ctx, tx, authorVisitsQuery(r.Builder, author), func(rows *sql.Rows) (entity.Visit, error) {
+		var v entity.Visit
+		err := rows.Scan(&v.RootID, &v.LastSeenID, &v.SeenAt)
+
+		return v, err
+	})
+	if err != nil {
+		return data, fmt.Errorf("SQLiteCommentRepo - GetAuthorData - visits: %w", err)
+	}
+
+	return data, nil
+}
+
+// EraseAuthor - как у Postgres, в одной транзакции.
+func (r *SQLiteCommentRepo) EraseAuthor(ctx context.Context, author, content string) (entity.Erasure, error) {
+	var erasure entity.Erasure
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	// комменты автора, ссылающиеся на них и предки тех и других
+	erasure.Touched, err = r.queryIDs(ctx, tx, `
+	WITH RECURSIVE affected AS (
+		SELECT id, parent_id
+		FROM comments
+		WHERE author = ?1
+			OR id IN (
+				SELECT r.source_id
+				FROM comment_references r
+				INNER JOIN comments t ON t.id = r.target_id
+				WHERE t.author = ?1
+			)
+
+		UNION
+
+		SELECT c.id, c.parent_id
+		FROM comments c
+		INNER JOIN affected a ON c.id = a.parent_id
+	)
+	SELECT id FROM affected ORDER BY id;
+	`, author)
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - r.queryIDs: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	err = r.touchComments(ctx, tx, erasure.Touched, now)
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - r.touchComments: %w", err)
+	}
+
+	erasure.Comments, err = collectSQLite(ctx, tx, anonymizeQuery(r.Builder, author, content), func(rows *sql.Rows) (int64, error) {
+		var id int64
+		err := rows.Scan(&id)
+
+		return id, err
+	})
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - anonymize: %w", err)
+	}
+
+	slices.Sort(erasure.Comments)
+
+	// ссылки ">>id" были в стертом тексте
+	if len(erasure.Comments) > 0 {
+		sqlq, args, err := r.Builder.Delete("comment_references").Where(squirrel.Eq{"source_id": erasure.Comments}).ToSql()
+		if err != nil {
+			return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - r.Builder.ToSql: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, sqlq, args...)
+		if err != nil {
+			return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - tx.ExecContext: %w", err)
+		}
+	}
+
+	erasure.Attachments, err = collectSQLite(ctx, tx, detachQuery(r.Builder, author, now), func(rows *sql.Rows) (entity.Attachment, error) {
+		return scanAttachment(rows)
+	})
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - detach: %w", err)
+	}
+
+	for _, q := range eraseQueries(r.Builder, author) {
+		sqlq, args, err := q.ToSql()
+		if err != nil {
+			return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - r.Builder.ToSql: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, sqlq, args...)
+		if err != nil {
+			return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - tx.ExecContext: %w", err)
+		}
+	}
+
+	err = r.scrubAudit(ctx, tx, author, content)
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - r.scrubAudit: %w", err)
+	}
+
+	err = r.audit(ctx, tx, entity.AuditEntry{Action: entity.AuditErase, After: entity.AuditIDs(erasure.Comments)})
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - r.audit: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return erasure, fmt.Errorf("SQLiteCommentRepo - EraseAuthor - tx.Commit: %w", err)
+	}
+
+	return erasure, nil
+}
+
+func (r *SQLiteCommentRepo) LogPrivacyRequest(ctx context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error) {
+	p.CreatedAt = time.Now().UTC()
+
+	sqlq, args, err := r.Builder.
+		Insert(privacyRequestsTable).
+		Columns("action", authorColumn, actorColumn, "reason", "comments", createdAtColumn).
+		Values(p.Action, p.Author, p.Actor, p.Reason, p.Comments, p.CreatedAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return p, fmt.Errorf("SQLiteCommentRepo - LogPrivacyRequest - r.Builder.ToSql: %w", err)
+	}
+
+	err = r.DB.QueryRowContext(ctx, sqlq, args...).Scan(&p.ID)
+	if err != nil {
+		return p, fmt.Errorf("SQLiteCommentRepo - LogPrivacyRequest - r.DB.QueryRowContext.Scan: %w", err)
+	}
+
+	return p, nil
+}
+
+// collectSQLite - строки запроса q в транзакции tx.
+func collectSQLite[T any](ctx context.Context, tx *sql.Tx, q squirrel.Sqlizer, scan func(*sql.Rows) (T, error)) ([]T, error) {
+	sqlq, args, err := q.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("ToSql: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("tx.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var items []T
+
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return items, nil
+}
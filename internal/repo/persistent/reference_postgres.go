@@ -0,0 +1,188 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *CommentRepo) CreateReferences(ctx context.Context, sourceID int64, targetIDs []int64) error {
+	err := createReferences(ctx, r.Pool, sourceID, targetIDs)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - CreateReferences - createReferences: %w", err)
+	}
+
+	return nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// createReferences - CreateReferences в транзакции вызывающего, если q - транзакция.
+func createReferences(ctx context.Context, q execer, sourceID int64, targetIDs []int64) error {
+	if len(targetIDs) == 0 {
+		return nil
+	}
+
+	_, err := q.Exec(ctx, `
+	INSERT INTO comment_references (source_id, target_id, position)
+	SELECT $1, t.id, t.position
+	FROM unnest($2::bigint[]) WITH ORDINALITY AS t(id, position)
+	INNER JOIN comments c ON c.id = t.id
+	ON CONFLICT DO NOTHING;
+	`, sourceID, targetIDs)
+	if err != nil {
+		return fmt.Errorf("q.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) GetReferences(ctx context.Context, sourceIDs []int64) (map[int64][]entity.Reference, error) {
+	refs := make(map[int64][]entity.Reference)
+
+	if len(sourceIDs) == 0 {
+		return refs, nil
+	}
+
+	rows, err := r.Pool.Query(ctx, `
+	SELECT r.source_id, r.target_id, c.id, c.parent_id, c.content, c.author, c.created_at
+	FROM comment_references r
+	LEFT JOIN comments c ON c.id = r.target_id
+	WHERE r.source_id = ANY($1)
+	ORDER BY r.source_id, r.position;
+	`, sourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetReferences - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			ref       entity.Reference
+			target    entity.Comment
+			id        sql.NullInt64
+			content   sql.NullString
+			author    sql.NullString
+			createdAt sql.NullTime
+		)
+
+		err = rows.Scan(&ref.SourceID, &ref.TargetID, &id, &target.ParentID, &content, &author, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - GetReferences - rows.Scan: %w", err)
+		}
+
+		// без строки в comments цель удалена
+		if id.Valid {
+			target.ID, target.Content, target.Author, target.CreatedAt = id.Int64, content.String, author.String, createdAt.Time
+			ref.Target = &target
+		}
+
+		refs[ref.SourceID] = append(refs[ref.SourceID], ref)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetReferences - rows.Err: %w", err)
+	}
+
+	return refs, nil
+}
+
+func (r *CommentRepo) GetBacklinks(ctx context.Context, targetID int64, limit, offset int) ([]entity.Comment, int, error) {
+	rows, err := r.Pool.Query(ctx, `
+	SELECT c.id, c.parent_id, c.content, c.author, c.created_at, COUNT(*) OVER() AS total
+	FROM comment_references r
+	INNER JOIN comments c ON c.id = r.source_id
+	WHERE r.target_id = $1
+	ORDER BY c.id DESC
+	LIMIT $2 OFFSET $3;
+	`, targetID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("CommentRepo - GetBacklinks - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		comments []entity.Comment
+		total    int
+	)
+
+	for rows.Next() {
+		var c entity.Comment
+
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("CommentRepo - GetBacklinks - rows.Scan: %w", err)
+		}
+
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("CommentRepo - GetBacklinks - rows.Err: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+func (r *CommentRepo) GetReferrers(ctx context.Context, targetIDs []int64) ([]int64, error) {
+	if len(targetIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.Pool.Query(ctx, `SELECT DISTINCT source_id FROM comment_references WHERE target_id = ANY($1) ORDER BY source_id;`, targetIDs)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetReferrers - r.Pool.Query: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetReferrers - pgx.CollectRows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// referrerPaths - комменты, которые ссылаются на поддерево id, но сами в него не входят,
+// вместе с их предками.
+func referrerPaths(ctx context.Context, tx pgx.Tx, id int64) ([]int64, error) {
+	rows, err := tx.Query(ctx, `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM comments WHERE id = $1
+
+		UNION ALL
+
+		SELECT c.id
+		FROM comments c
+		INNER JOIN subtree s ON c.parent_id = s.id
+	),
+	referrers AS (
+		SELECT c.id, c.parent_id
+		FROM comments c
+		WHERE c.id IN (SELECT source_id FROM comment_references WHERE target_id IN (SELECT id FROM subtree))
+			AND c.id NOT IN (SELECT id FROM subtree)
+
+		UNION
+
+		SELECT c.id, c.parent_id
+		FROM comments c
+		INNER JOIN referrers r ON c.id = r.parent_id
+	)
+	SELECT id FROM referrers;
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("tx.Query: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("pgx.CollectRows: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,221 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+func (r *SQLiteCommentRepo) CreateReferences(ctx context.Context, sourceID int64, targetIDs []int64) error {
+	if len(targetIDs) == 0 {
+		return nil
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - CreateReferences - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	err = createSQLiteReferences(ctx, tx, sourceID, targetIDs)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - CreateReferences - createSQLiteReferences: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - CreateReferences - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+// createSQLiteReferences - CreateReferences в транзакции вызывающего.
+func createSQLiteReferences(ctx context.Context, tx *sql.Tx, sourceID int64, targetIDs []int64) error {
+	for i, targetID := range targetIDs {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO comment_references (source_id, target_id, position)
+		SELECT ?, id, ? FROM comments WHERE id = ?
+		ON CONFLICT DO NOTHING;
+		`, sourceID, i+1, targetID)
+		if err != nil {
+			return fmt.Errorf("tx.ExecContext: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) GetReferences(ctx context.Context, sourceIDs []int64) (map[int64][]entity.Reference, error) {
+	refs := make(map[int64][]entity.Reference)
+
+	if len(sourceIDs) == 0 {
+		return refs, nil
+	}
+
+	where, args, err := squirrel.Eq{"r.source_id": sourceIDs}.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetReferences - squirrel.Eq.ToSql: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+	SELECT r.source_id, r.target_id, c.id, c.parent_id, c.content, c.author, c.created_at
+	FROM comment_references r
+	LEFT JOIN comments c ON c.id = r.target_id
+	WHERE `+where+`
+	ORDER BY r.source_id, r.position;
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetReferences - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			ref       entity.Reference
+			target    entity.Comment
+			id        sql.NullInt64
+			content   sql.NullString
+			author    sql.NullString
+			createdAt sql.NullTime
+		)
+
+		err = rows.Scan(&ref.SourceID, &ref.TargetID, &id, &target.ParentID, &content, &author, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetReferences - rows.Scan: %w", err)
+		}
+
+		// без строки в comments цель удалена
+		if id.Valid {
+			target.ID, target.Content, target.Author, target.CreatedAt = id.Int64, content.String, author.String, createdAt.Time
+			ref.Target = &target
+		}
+
+		refs[ref.SourceID] = append(refs[ref.SourceID], ref)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetReferences - rows.Err: %w", err)
+	}
+
+	return refs, nil
+}
+
+func (r *SQLiteCommentRepo) GetBacklinks(ctx context.Context, targetID int64, limit, offset int) ([]entity.Comment, int, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+	SELECT c.id, c.parent_id, c.content, c.author, c.created_at, COUNT(*) OVER() AS total
+	FROM comment_references r
+	INNER JOIN comments c ON c.id = r.source_id
+	WHERE r.target_id = ?
+	ORDER BY c.id DESC
+	LIMIT ? OFFSET ?;
+	`, targetID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("SQLiteCommentRepo - GetBacklinks - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		comments []entity.Comment
+		total    int
+	)
+
+	for rows.Next() {
+		var c entity.Comment
+
+		err = rows.Scan(&c.ID, &c.ParentID, &c.Content, &c.Author, &c.CreatedAt, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("SQLiteCommentRepo - GetBacklinks - rows.Scan: %w", err)
+		}
+
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("SQLiteCommentRepo - GetBacklinks - rows.Err: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+func (r *SQLiteCommentRepo) GetReferrers(ctx context.Context, targetIDs []int64) ([]int64, error) {
+	if len(targetIDs) == 0 {
+		return nil, nil
+	}
+
+	where, args, err := squirrel.Eq{"target_id": targetIDs}.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetReferrers - squirrel.Eq.ToSql: %w", err)
+	}
+
+	ids, err := r.queryIDs(ctx, r.DB, `SELECT DISTINCT source_id FROM comment_references WHERE `+where+` ORDER BY source_id;`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetReferrers - r.queryIDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+// referrerPaths - комменты, которые ссылаются на поддерево id, но сами в него не входят,
+// вместе с их предками.
+func (r *SQLiteCommentRepo) referrerPaths(ctx context.Context, tx *sql.Tx, id int64) ([]int64, error) {
+	ids, err := r.queryIDs(ctx, tx, `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM comments WHERE id = ?
+
+		UNION ALL
+
+		SELECT c.id
+		FROM comments c
+		INNER JOIN subtree s ON c.parent_id = s.id
+	),
+	referrers AS (
+		SELECT c.id, c.parent_id
+		FROM comments c
+		WHERE c.id IN (SELECT source_id FROM comment_references WHERE target_id IN (SELECT id FROM subtree))
+			AND c.id NOT IN (SELECT id FROM subtree)
+
+		UNION
+
+		SELECT c.id, c.parent_id
+		FROM comments c
+		INNER JOIN referrers r ON c.id = r.parent_id
+	)
+	SELECT id FROM referrers;
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("r.queryIDs: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *SQLiteCommentRepo) queryIDs(ctx context.Context, q sqliteQuerier, sqlq string, args ...any) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("q.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+
+	for rows.Next() {
+		var id int64
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return ids, nil
+}
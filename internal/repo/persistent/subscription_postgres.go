@@ -0,0 +1,132 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/jackc/pgx/v5"
+)
+
+// Subscribe - новая подписка начинается с самого нового коммента в базе, повторная сохраняет позицию.
+// Адрес меняется у всех подписок subscriber.
+func (r *CommentRepo) Subscribe(ctx context.Context, subscriber string, id int64, email string) error {
+	err := r.CommentExists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - Subscribe - r.CommentExists: %w", err)
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - Subscribe - r.Pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // после Commit это no-op
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO subscriptions (subscriber, comment_id, email, last_comment_id)
+	SELECT $1, $2, $3, COALESCE(MAX(id), 0) FROM comments
+	ON CONFLICT (subscriber, comment_id) DO NOTHING;
+	`, subscriber, id, email)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - Subscribe - tx.Exec(insert): %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE subscriptions SET email = $2 WHERE subscriber = $1;`, subscriber, email)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - Subscribe - tx.Exec(update): %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - Subscribe - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) Unsubscribe(ctx context.Context, subscriber string, id int64) error {
+	sqlq := `DELETE FROM subscriptions WHERE subscriber = $1 AND comment_id = $2;`
+	args := []any{subscriber, id}
+	if id == 0 {
+		sqlq = `DELETE FROM subscriptions WHERE subscriber = $1;`
+		args = args[:1]
+	}
+
+	_, err := r.Pool.Exec(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - Unsubscribe - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CommentRepo) GetSubscriptions(ctx context.Context, subscriber string) ([]entity.Subscription, error) {
+	rows, err := r.Pool.Query(ctx, `
+	SELECT subscriber, comment_id, email, created_at, last_comment_id, digested_at
+	FROM subscriptions
+	WHERE subscriber = $1
+	ORDER BY comment_id;
+	`, subscriber)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetSubscriptions - r.Pool.Query: %w", err)
+	}
+
+	subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Subscription, error) {
+		var s entity.Subscription
+		err := row.Scan(&s.Subscriber, &s.CommentID, &s.Email, &s.CreatedAt, &s.LastCommentID, &s.DigestedAt)
+
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetSubscriptions - pgx.CollectRows: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (r *CommentRepo) GetDueSubscribers(ctx context.Context, before time.Time, after string, limit int) ([]string, error) {
+	rows, err := r.Pool.Query(ctx, `
+	SELECT DISTINCT subscriber
+	FROM subscriptions
+	WHERE digested_at <= $1 AND subscriber > $2
+	ORDER BY subscriber
+	LIMIT $3;
+	`, before, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetDueSubscribers - r.Pool.Query: %w", err)
+	}
+
+	subscribers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetDueSubscribers - pgx.CollectRows: %w", err)
+	}
+
+	return subscribers, nil
+}
+
+// MarkDigested - позиция подписки не уходит назад, даже если lastIDs старше.
+func (r *CommentRepo) MarkDigested(ctx context.Context, subscriber string, lastIDs map[int64]int64, at time.Time) error {
+	if len(lastIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(lastIDs))
+	last := make([]int64, 0, len(lastIDs))
+	for id, lastID := range lastIDs {
+		ids = append(ids, id)
+		last = append(last, lastID)
+	}
+
+	_, err := r.Pool.Exec(ctx, `
+	UPDATE subscriptions s
+	SET last_comment_id = GREATEST(s.last_comment_id, v.last_id), digested_at = $4
+	FROM unnest($2::BIGINT[], $3::BIGINT[]) AS v(comment_id, last_id)
+	WHERE s.subscriber = $1 AND s.comment_id = v.comment_id;
+	`, subscriber, ids, last, at)
+	if err != nil {
+		return fmt.Errorf("CommentRepo - MarkDigested - r.Pool.Exec: %w", err)
+	}
+
+	return nil
+}
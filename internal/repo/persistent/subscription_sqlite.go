@@ -0,0 +1,163 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+// Subscribe - новая подписка начинается с самого нового коммента в базе, повторная сохраняет позицию.
+// Адрес меняется у всех подписок subscriber.
+func (r *SQLiteCommentRepo) Subscribe(ctx context.Context, subscriber string, id int64, email string) error {
+	err := r.CommentExists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - Subscribe - r.CommentExists: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - Subscribe - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO subscriptions (subscriber, comment_id, email, last_comment_id, created_at, digested_at)
+	SELECT ?, ?, ?, COALESCE(MAX(id), 0), ?, ? FROM comments
+	WHERE true
+	ON CONFLICT (subscriber, comment_id) DO NOTHING;
+	`, subscriber, id, email, now, now)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - Subscribe - tx.ExecContext(insert): %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE subscriptions SET email = ? WHERE subscriber = ?;`, email, subscriber)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - Subscribe - tx.ExecContext(update): %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - Subscribe - tx.Commit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) Unsubscribe(ctx context.Context, subscriber string, id int64) error {
+	sqlq := `DELETE FROM subscriptions WHERE subscriber = ? AND comment_id = ?;`
+	args := []any{subscriber, id}
+	if id == 0 {
+		sqlq = `DELETE FROM subscriptions WHERE subscriber = ?;`
+		args = args[:1]
+	}
+
+	_, err := r.DB.ExecContext(ctx, sqlq, args...)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - Unsubscribe - r.DB.ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteCommentRepo) GetSubscriptions(ctx context.Context, subscriber string) ([]entity.Subscription, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+	SELECT subscriber, comment_id, email, created_at, last_comment_id, digested_at
+	FROM subscriptions
+	WHERE subscriber = ?
+	ORDER BY comment_id;
+	`, subscriber)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetSubscriptions - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []entity.Subscription
+
+	for rows.Next() {
+		var s entity.Subscription
+
+		err = rows.Scan(&s.Subscriber, &s.CommentID, &s.Email, &s.CreatedAt, &s.LastCommentID, &s.DigestedAt)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetSubscriptions - rows.Scan: %w", err)
+		}
+
+		subs = append(subs, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetSubscriptions - rows.Err: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (r *SQLiteCommentRepo) GetDueSubscribers(ctx context.Context, before time.Time, after string, limit int) ([]string, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+	SELECT DISTINCT subscriber
+	FROM subscriptions
+	WHERE digested_at <= ? AND subscriber > ?
+	ORDER BY subscriber
+	LIMIT ?;
+	`, before.UTC(), after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetDueSubscribers - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	var subscribers []string
+
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetDueSubscribers - rows.Scan: %w", err)
+		}
+		subscribers = append(subscribers, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetDueSubscribers - rows.Err: %w", err)
+	}
+
+	return subscribers, nil
+}
+
+// MarkDigested - позиция подписки не уходит назад, даже если lastIDs старше.
+func (r *SQLiteCommentRepo) MarkDigested(ctx context.Context, subscriber string, lastIDs map[int64]int64, at time.Time) error {
+	if len(lastIDs) == 0 {
+		return nil
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - MarkDigested - r.DB.BeginTx: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // после Commit это no-op
+
+	stmt, err := tx.PrepareContext(ctx, `
+	UPDATE subscriptions
+	SET last_comment_id = MAX(last_comment_id, ?), digested_at = ?
+	WHERE subscriber = ? AND comment_id = ?;
+	`)
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - MarkDigested - tx.PrepareContext: %w", err)
+	}
+	defer stmt.Close()
+
+	for id, lastID := range lastIDs {
+		_, err = stmt.ExecContext(ctx, lastID, at.UTC(), subscriber, id)
+		if err != nil {
+			return fmt.Errorf("SQLiteCommentRepo - MarkDigested - stmt.ExecContext: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("SQLiteCommentRepo - MarkDigested - tx.Commit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"github.com/jackc/pgx/v5"
+)
+
+// MarkSeen - отсутствующий корень - errs.ErrRecordNotFound.
+func (r *CommentRepo) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	var lastSeenID int64
+
+	err := r.Pool.QueryRow(ctx, `
+	INSERT INTO root_visits (viewer, root_id, last_seen_id)
+	SELECT $1, id, (SELECT MAX(id) FROM comments) FROM comments WHERE id = $2
+	ON CONFLICT (viewer, root_id) DO UPDATE
+	SET last_seen_id = GREATEST(root_visits.last_seen_id, EXCLUDED.last_seen_id), seen_at = now()
+	RETURNING last_seen_id;
+	`, viewer, rootID).Scan(&lastSeenID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("CommentRepo - MarkSeen: %w", errs.ErrRecordNotFound)
+		}
+
+		return 0, fmt.Errorf("CommentRepo - MarkSeen - r.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return lastSeenID, nil
+}
+
+func (r *CommentRepo) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	lastSeen := make(map[int64]int64, len(rootIDs))
+
+	if len(rootIDs) == 0 {
+		return lastSeen, nil
+	}
+
+	rows, err := r.Pool.Query(ctx, `SELECT root_id, last_seen_id FROM root_visits WHERE viewer = $1 AND root_id = ANY($2);`, viewer, rootIDs)
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetLastSeen - r.Pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rootID, lastSeenID int64
+
+		err = rows.Scan(&rootID, &lastSeenID)
+		if err != nil {
+			return nil, fmt.Errorf("CommentRepo - GetLastSeen - rows.Scan: %w", err)
+		}
+
+		lastSeen[rootID] = lastSeenID
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("CommentRepo - GetLastSeen - rows.Err: %w", err)
+	}
+
+	return lastSeen, nil
+}
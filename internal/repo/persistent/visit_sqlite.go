@@ -0,0 +1,75 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// MarkSeen - отсутствующий корень - errs.ErrRecordNotFound.
+func (r *SQLiteCommentRepo) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	var lastSeenID int64
+
+	err := r.DB.QueryRowContext(ctx, `
+	INSERT INTO root_visits (viewer, root_id, last_seen_id, seen_at)
+	SELECT ?, id, (SELECT MAX(id) FROM comments), ? FROM comments WHERE id = ?
+	ON CONFLICT (viewer, root_id) DO UPDATE
+	SET last_seen_id = MAX(last_seen_id, excluded.last_seen_id), seen_at = excluded.seen_at
+	RETURNING last_seen_id;
+	`, viewer, time.Now().UTC(), rootID).Scan(&lastSeenID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("SQLiteCommentRepo - MarkSeen: %w", errs.ErrRecordNotFound)
+		}
+
+		return 0, fmt.Errorf("SQLiteCommentRepo - MarkSeen - r.DB.QueryRowContext.Scan: %w", err)
+	}
+
+	return lastSeenID, nil
+}
+
+func (r *SQLiteCommentRepo) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	lastSeen := make(map[int64]int64, len(rootIDs))
+
+	if len(rootIDs) == 0 {
+		return lastSeen, nil
+	}
+
+	sqlq, args, err := r.Builder.
+		Select("root_id", "last_seen_id").
+		From("root_visits").
+		Where(squirrel.Eq{"viewer": viewer, "root_id": rootIDs}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetLastSeen - r.Builder.ToSql: %w", err)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, sqlq, args...)
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetLastSeen - r.DB.QueryContext: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rootID, lastSeenID int64
+
+		err = rows.Scan(&rootID, &lastSeenID)
+		if err != nil {
+			return nil, fmt.Errorf("SQLiteCommentRepo - GetLastSeen - rows.Scan: %w", err)
+		}
+
+		lastSeen[rootID] = lastSeenID
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("SQLiteCommentRepo - GetLastSeen - rows.Err: %w", err)
+	}
+
+	return lastSeen, nil
+}
@@ -0,0 +1,1826 @@
+// Package repotest - общий набор тестов, который должна проходить каждая реализация repo.CommentRepo.
+package repotest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// NewRepo - возвращает пустое хранилище для одного теста.
+type NewRepo func(t *testing.T) repo.CommentRepo
+
+func TestCommentRepo(t *testing.T, newRepo NewRepo) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r repo.CommentRepo)
+	}{
+		{"CreateComment", testCreateComment},
+		{"CommentExists", testCommentExists},
+		{"GetCommentWithChildren", testGetCommentWithChildren},
+		{"DeleteCommentWithChildren", testDeleteCommentWithChildren},
+		{"GetRootComments", testGetRootComments},
+		{"GetTreesForRoots", testGetTreesForRoots},
+		{"ChildSort", testChildSort},
+		{"SearchComments", testSearchComments},
+		{"GetCommentPath", testGetCommentPath},
+		{"Versions", testVersions},
+		{"ExportComments", testExportComments},
+		{"ImportComments", testImportComments},
+		{"CreateFromSource", testCreateFromSource},
+		{"GetCommentsByIDs", testGetCommentsByIDs},
+		{"GetChildren", testGetChildren},
+		{"CountReplies", testCountReplies},
+		{"Pins", testPins},
+		{"Locks", testLocks},
+		{"ReplyTo", testReplyTo},
+		{"Notifications", testNotifications},
+		{"Mutes", testMutes},
+		{"Subscriptions", testSubscriptions},
+		{"Visits", testVisits},
+		{"References", testReferences},
+		{"Attachments", testAttachments},
+		{"EraseAuthor", testEraseAuthor},
+		{"AuditLog", testAuditLog},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func testCreateComment(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	if root.ID == 0 || root.ParentID.Valid || root.Content != "root" || root.CreatedAt.IsZero() {
+		t.Fatalf("unexpected root: %+v", root)
+	}
+
+	reply := mustCreate(t, r, &root.ID, "reply")
+	if !reply.ParentID.Valid || reply.ParentID.Int64 != root.ID {
+		t.Fatalf("reply parent = %+v, want %d", reply.ParentID, root.ID)
+	}
+
+	if reply.ID == root.ID {
+		t.Fatalf("ids must be unique, got %d twice", reply.ID)
+	}
+
+	if reply.Depth != 1 || !slices.Equal(reply.Path, []int64{root.ID, reply.ID}) {
+		t.Fatalf("reply depth/path = %d/%v, want 1/[%d %d]", reply.Depth, reply.Path, root.ID, reply.ID)
+	}
+
+	missing := int64(1 << 40)
+	if _, err := r.CreateComment(ctx, &missing, nil, "", "orphan", nil); err == nil {
+		t.Fatal("expected error for missing parent")
+	}
+
+	signed, err := r.CreateComment(ctx, &root.ID, nil, "alice", "signed", nil)
+	if err != nil || signed.Author != "alice" {
+		t.Fatalf("CreateComment(signed) = %+v, %v; want author alice", signed, err)
+	}
+
+	tree, err := r.GetCommentWithChildren(ctx, root.ID, entity.ChildSortOldest)
+	if err != nil || tree[len(tree)-1].Author != "alice" {
+		t.Fatalf("GetCommentWithChildren = %+v, %v; want author alice stored", tree, err)
+	}
+}
+
+func testCommentExists(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	c := mustCreate(t, r, nil, "root")
+
+	if err := r.CommentExists(ctx, c.ID); err != nil {
+		t.Fatalf("CommentExists(%d) = %v", c.ID, err)
+	}
+
+	if err := r.CommentExists(ctx, c.ID+1000); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("CommentExists(missing) = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func testGetCommentWithChildren(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	// root
+	// ├── a
+	// │   └── a1
+	// └── b
+	root := mustCreate(t, r, nil, "root")
+	a := mustCreate(t, r, &root.ID, "a")
+	b := mustCreate(t, r, &root.ID, "b")
+	a1 := mustCreate(t, r, &a.ID, "a1")
+	other := mustCreate(t, r, nil, "other")
+
+	got, err := r.GetCommentWithChildren(ctx, root.ID, entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetCommentWithChildren: %v", err)
+	}
+
+	assertTree(t, got, []node{
+		{root.ID, 0, []int64{root.ID}},
+		{a.ID, 1, []int64{root.ID, a.ID}},
+		{a1.ID, 2, []int64{root.ID, a.ID, a1.ID}},
+		{b.ID, 1, []int64{root.ID, b.ID}},
+	})
+
+	// поддерево считается от запрошенного коммента
+	got, err = r.GetCommentWithChildren(ctx, a.ID, entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetCommentWithChildren(a): %v", err)
+	}
+
+	assertTree(t, got, []node{
+		{a.ID, 0, []int64{a.ID}},
+		{a1.ID, 1, []int64{a.ID, a1.ID}},
+	})
+
+	if _, err = r.GetCommentWithChildren(ctx, other.ID+1000, entity.ChildSortOldest); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("GetCommentWithChildren(missing) = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func testDeleteCommentWithChildren(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	a := mustCreate(t, r, &root.ID, "a")
+	a1 := mustCreate(t, r, &a.ID, "a1")
+	b := mustCreate(t, r, &root.ID, "b")
+
+	// удаляется a вместе с ответом a1
+	if deleted, err := r.DeleteCommentWithChildren(ctx, a.ID); err != nil || deleted != 2 {
+		t.Fatalf("DeleteCommentWithChildren = %d, %v; want 2", deleted, err)
+	}
+
+	for _, id := range []int64{a.ID, a1.ID} {
+		if err := r.CommentExists(ctx, id); !errors.Is(err, errs.ErrRecordNotFound) {
+			t.Fatalf("comment %d must be deleted, CommentExists = %v", id, err)
+		}
+	}
+
+	got, err := r.GetCommentWithChildren(ctx, root.ID, entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetCommentWithChildren: %v", err)
+	}
+
+	assertTree(t, got, []node{
+		{root.ID, 0, []int64{root.ID}},
+		{b.ID, 1, []int64{root.ID, b.ID}},
+	})
+
+	// удаленные ответы не находятся поиском
+	found, _, err := r.SearchComments(ctx, "a1", "id", "ASC", 10, 0)
+	if err != nil || len(found) != 0 {
+		t.Fatalf("SearchComments(a1) after delete = %v, %v; want nothing", found, err)
+	}
+
+	if _, err = r.DeleteCommentWithChildren(ctx, a.ID); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("second delete = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func testGetRootComments(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	var ids []int64
+	for range 5 {
+		c := mustCreate(t, r, nil, "root")
+		mustCreate(t, r, &c.ID, "reply")
+		ids = append(ids, c.ID)
+	}
+
+	got, total, err := r.GetRootComments(ctx, "", "id", "ASC", 2, 0)
+	if err != nil {
+		t.Fatalf("GetRootComments: %v", err)
+	}
+
+	if total != 5 {
+		t.Fatalf("total = %d, want 5", total)
+	}
+
+	assertIDs(t, got, ids[:2])
+
+	got, _, err = r.GetRootComments(ctx, "", "id", "DESC", 2, 2)
+	if err != nil {
+		t.Fatalf("GetRootComments: %v", err)
+	}
+
+	assertIDs(t, got, []int64{ids[2], ids[1]})
+
+	got, _, err = r.GetRootComments(ctx, "", "created_at", "ASC", 10, 4)
+	if err != nil {
+		t.Fatalf("GetRootComments: %v", err)
+	}
+
+	assertIDs(t, got, ids[4:])
+}
+
+func testGetTreesForRoots(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	r1 := mustCreate(t, r, nil, "r1")
+	r1a := mustCreate(t, r, &r1.ID, "r1a")
+	r2 := mustCreate(t, r, nil, "r2")
+	r2a := mustCreate(t, r, &r2.ID, "r2a")
+	r2a1 := mustCreate(t, r, &r2a.ID, "r2a1")
+	mustCreate(t, r, nil, "r3")
+
+	got, err := r.GetTreesForRoots(ctx, []int64{r2.ID, r1.ID}, entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetTreesForRoots: %v", err)
+	}
+
+	assertTree(t, got, []node{
+		{r1.ID, 0, []int64{r1.ID}},
+		{r1a.ID, 1, []int64{r1.ID, r1a.ID}},
+		{r2.ID, 0, []int64{r2.ID}},
+		{r2a.ID, 1, []int64{r2.ID, r2a.ID}},
+		{r2a1.ID, 2, []int64{r2.ID, r2a.ID, r2a1.ID}},
+	})
+
+	got, err = r.GetTreesForRoots(ctx, nil, entity.ChildSortOldest)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("GetTreesForRoots(nil) = %v, %v; want empty", got, err)
+	}
+}
+
+// testChildSort - created_at ответов корня не совпадает с порядком id,
+// так что oldest должен сортировать по времени, а не по path.
+func testChildSort(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+	base := time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)
+
+	fromSource := func(sourceID string, parentID *int64, createdAt time.Time) entity.Comment {
+		t.Helper()
+
+		c := entity.Comment{Content: sourceID, CreatedAt: createdAt}
+		if parentID != nil {
+			c.ParentID = sql.NullInt64{Int64: *parentID, Valid: true}
+		}
+
+		c, err := r.CreateFromSource(ctx, "test", sourceID, c)
+		if err != nil {
+			t.Fatalf("CreateFromSource(%s): %v", sourceID, err)
+		}
+
+		return c
+	}
+
+	root := fromSource("root", nil, base)
+	a := fromSource("a", &root.ID, base.Add(2*time.Hour))
+	b := fromSource("b", &root.ID, base.Add(time.Hour))
+	c := fromSource("c", &root.ID, base.Add(3*time.Hour))
+	b1 := mustCreate(t, r, &b.ID, "b1")
+	c1 := mustCreate(t, r, &c.ID, "c1")
+	c2 := mustCreate(t, r, &c.ID, "c2")
+
+	tests := []struct {
+		childSort string
+		want      []int64
+	}{
+		{entity.ChildSortOldest, []int64{root.ID, b.ID, b1.ID, a.ID, c.ID, c1.ID, c2.ID}},
+		{entity.ChildSortNewest, []int64{root.ID, c.ID, c2.ID, c1.ID, a.ID, b.ID, b1.ID}},
+		{entity.ChildSortScore, []int64{root.ID, c.ID, c1.ID, c2.ID, b.ID, b1.ID, a.ID}},
+	}
+
+	for _, tt := range tests {
+		got, err := r.GetCommentWithChildren(ctx, root.ID, tt.childSort)
+		if err != nil {
+			t.Fatalf("GetCommentWithChildren(%s): %v", tt.childSort, err)
+		}
+
+		assertIDs(t, got, tt.want)
+
+		// entity.SortTree дает тот же порядок из любого исходного
+		reversed := slices.Clone(got)
+		slices.Reverse(reversed)
+		assertIDs(t, entity.SortTree(reversed, tt.childSort), tt.want)
+	}
+
+	// корни тоже упорядочиваются по childSort
+	other := mustCreate(t, r, nil, "other")
+
+	got, err := r.GetTreesForRoots(ctx, []int64{root.ID, other.ID}, entity.ChildSortNewest)
+	if err != nil {
+		t.Fatalf("GetTreesForRoots: %v", err)
+	}
+
+	assertIDs(t, got, []int64{other.ID, root.ID, c.ID, c2.ID, c1.ID, a.ID, b.ID, b1.ID})
+}
+
+func testSearchComments(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "Nice picture of a mountain")
+	reply := mustCreate(t, r, &root.ID, "which mountain is it?")
+	mustCreate(t, r, nil, "completely unrelated")
+
+	got, total, err := r.SearchComments(ctx, "mountain", "id", "ASC", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchComments: %v", err)
+	}
+
+	if total != 2 {
+		t.Fatalf("total = %d, want 2", total)
+	}
+
+	assertIDs(t, got, []int64{root.ID, reply.ID})
+
+	got, _, err = r.SearchComments(ctx, "picture mountain", "id", "DESC", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchComments: %v", err)
+	}
+
+	assertIDs(t, got, []int64{root.ID})
+
+	got, total, err = r.SearchComments(ctx, "volcano", "id", "ASC", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchComments: %v", err)
+	}
+
+	if total != 0 || len(got) != 0 {
+		t.Fatalf("SearchComments(volcano) = %v, %d; want nothing", got, total)
+	}
+
+	// ищется текст без разметки: слова из адресов ссылок не находятся,
+	// а выделенные части слова склеиваются
+	linked := mustCreate(t, r, nil, "see [this photo](https://example.com/volcano.png) of **snow**capped peaks")
+
+	got, _, err = r.SearchComments(ctx, "volcano", "id", "ASC", 10, 0)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("SearchComments(link address) = %v, %v; want nothing", got, err)
+	}
+
+	got, _, err = r.SearchComments(ctx, "photo snowcapped", "id", "ASC", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchComments: %v", err)
+	}
+
+	assertIDs(t, got, []int64{linked.ID})
+}
+
+func testGetCommentPath(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	a := mustCreate(t, r, &root.ID, "a")
+	a1 := mustCreate(t, r, &a.ID, "a1")
+
+	path, err := r.GetCommentPath(ctx, a1.ID)
+	if err != nil {
+		t.Fatalf("GetCommentPath: %v", err)
+	}
+
+	if want := []int64{root.ID, a.ID, a1.ID}; !slices.Equal(path, want) {
+		t.Fatalf("path = %v, want %v", path, want)
+	}
+
+	path, err = r.GetCommentPath(ctx, root.ID)
+	if err != nil || !slices.Equal(path, []int64{root.ID}) {
+		t.Fatalf("GetCommentPath(root) = %v, %v", path, err)
+	}
+
+	if _, err = r.GetCommentPath(ctx, a1.ID+1000); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("GetCommentPath(missing) = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func testVersions(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	a := mustCreate(t, r, &root.ID, "a")
+	b := mustCreate(t, r, &root.ID, "b")
+
+	if root.Version != 1 || a.Version != 1 || root.UpdatedAt.IsZero() {
+		t.Fatalf("new comments must start at version 1: root %+v, a %+v", root, a)
+	}
+
+	versions := func() map[int64]entity.Comment {
+		t.Helper()
+
+		tree, err := r.GetCommentWithChildren(ctx, root.ID, entity.ChildSortOldest)
+		if err != nil {
+			t.Fatalf("GetCommentWithChildren: %v", err)
+		}
+
+		byID := make(map[int64]entity.Comment, len(tree))
+		for _, c := range tree {
+			byID[c.ID] = c
+		}
+
+		return byID
+	}
+
+	// ответы в a поднимают версии a и корня, но не соседа b
+	before := versions()
+	a1 := mustCreate(t, r, &a.ID, "a1")
+	after := versions()
+
+	if after[root.ID].Version != before[root.ID].Version+1 || after[a.ID].Version != before[a.ID].Version+1 {
+		t.Fatalf("create: root %d->%d, a %d->%d; want +1 each",
+			before[root.ID].Version, after[root.ID].Version, before[a.ID].Version, after[a.ID].Version)
+	}
+
+	if after[b.ID].Version != before[b.ID].Version {
+		t.Fatalf("create: sibling b version changed %d->%d", before[b.ID].Version, after[b.ID].Version)
+	}
+
+	if after[root.ID].UpdatedAt.Before(before[root.ID].UpdatedAt) {
+		t.Fatalf("create: root updated_at went back %v->%v", before[root.ID].UpdatedAt, after[root.ID].UpdatedAt)
+	}
+
+	// удаление поднимает версии оставшихся предков
+	before = after
+	if _, err := r.DeleteCommentWithChildren(ctx, a1.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+	after = versions()
+
+	if after[root.ID].Version != before[root.ID].Version+1 || after[a.ID].Version != before[a.ID].Version+1 {
+		t.Fatalf("delete: root %d->%d, a %d->%d; want +1 each",
+			before[root.ID].Version, after[root.ID].Version, before[a.ID].Version, after[a.ID].Version)
+	}
+
+	// версия корня видна и в списке корней
+	roots, _, err := r.GetRootComments(ctx, "", "id", "ASC", 10, 0)
+	if err != nil || len(roots) != 1 || roots[0].Version != after[root.ID].Version {
+		t.Fatalf("GetRootComments = %+v, %v; want root at version %d", roots, err, after[root.ID].Version)
+	}
+}
+
+func testExportComments(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	r1 := mustCreate(t, r, nil, "r1")
+	r1a := mustCreate(t, r, &r1.ID, "r1a")
+	r2 := mustCreate(t, r, nil, "r2")
+	r1b := mustCreate(t, r, &r1.ID, "r1b")
+	r1a1 := mustCreate(t, r, &r1a.ID, "r1a1")
+
+	export := func(rootID *int64) []entity.Comment {
+		t.Helper()
+
+		var got []entity.Comment
+		for c, err := range r.ExportComments(ctx, rootID) {
+			if err != nil {
+				t.Fatalf("ExportComments: %v", err)
+			}
+			got = append(got, c)
+		}
+
+		return got
+	}
+
+	all := export(nil)
+	assertIDs(t, all, []int64{r1.ID, r1a.ID, r2.ID, r1b.ID, r1a1.ID})
+
+	if all[3].Content != "r1b" || !all[3].ParentID.Valid || all[3].ParentID.Int64 != r1.ID || all[3].CreatedAt.IsZero() {
+		t.Fatalf("exported comment = %+v", all[3])
+	}
+
+	// поддерево - родитель раньше ребенка
+	assertIDs(t, export(&r1a.ID), []int64{r1a.ID, r1a1.ID})
+	assertIDs(t, export(&r1.ID), []int64{r1.ID, r1a.ID, r1a1.ID, r1b.ID})
+
+	missing := r1a1.ID + 1000
+	if got := export(&missing); len(got) != 0 {
+		t.Fatalf("ExportComments(missing) = %+v, want nothing", got)
+	}
+
+	// потребитель может остановиться в любой момент
+	var n int
+	for range r.ExportComments(ctx, nil) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+}
+
+func testImportComments(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	existing := mustCreate(t, r, nil, "existing")
+	createdAt := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+
+	// id источника пересекаются с уже существующими - должны быть переназначены
+	ids, err := r.ImportComments(ctx, []entity.Comment{
+		{ID: existing.ID, Content: "imported root", Author: "alice", CreatedAt: createdAt},
+		{ID: 500, ParentID: sql.NullInt64{Int64: existing.ID, Valid: true}, Content: "imported reply"},
+		{ID: 7, ParentID: sql.NullInt64{Int64: 500, Valid: true}, Content: "nested volcano"},
+	})
+	if err != nil {
+		t.Fatalf("ImportComments: %v", err)
+	}
+
+	if len(ids) != 3 || slices.Contains(ids, existing.ID) {
+		t.Fatalf("ids = %v, want 3 new ids", ids)
+	}
+
+	got, err := r.GetCommentWithChildren(ctx, ids[0], entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetCommentWithChildren: %v", err)
+	}
+
+	assertTree(t, got, []node{
+		{ids[0], 0, []int64{ids[0]}},
+		{ids[1], 1, []int64{ids[0], ids[1]}},
+		{ids[2], 2, []int64{ids[0], ids[1], ids[2]}},
+	})
+
+	if !got[0].CreatedAt.Equal(createdAt) || got[0].ParentID.Valid || got[0].Author != "alice" {
+		t.Fatalf("imported root = %+v, want created_at %v, author alice and no parent", got[0], createdAt)
+	}
+
+	if got[2].CreatedAt.IsZero() {
+		t.Fatal("comment without created_at must get the import time")
+	}
+
+	// существующее дерево не тронуто
+	tree, err := r.GetCommentWithChildren(ctx, existing.ID, entity.ChildSortOldest)
+	if err != nil || len(tree) != 1 {
+		t.Fatalf("existing tree = %+v, %v; want only the root", tree, err)
+	}
+
+	found, _, err := r.SearchComments(ctx, "volcano", "id", "ASC", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchComments: %v", err)
+	}
+
+	assertIDs(t, found, ids[2:])
+
+	ids, err = r.ImportComments(ctx, nil)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("ImportComments(nil) = %v, %v", ids, err)
+	}
+}
+
+func testCreateFromSource(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	existing := mustCreate(t, r, nil, "existing")
+	createdAt := time.Date(2015, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	c, err := r.CreateFromSource(ctx, "disqus", "100", entity.Comment{
+		ParentID:  sql.NullInt64{Int64: existing.ID, Valid: true},
+		Content:   "from disqus",
+		Author:    "bob",
+		CreatedAt: createdAt,
+	})
+	if err != nil {
+		t.Fatalf("CreateFromSource: %v", err)
+	}
+
+	if c.Depth != 1 || !slices.Equal(c.Path, []int64{existing.ID, c.ID}) {
+		t.Fatalf("created = {depth:%d path:%v}, want {depth:1 path:[%d %d]}", c.Depth, c.Path, existing.ID, c.ID)
+	}
+
+	got, err := r.GetCommentWithChildren(ctx, existing.ID, entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetCommentWithChildren: %v", err)
+	}
+
+	if len(got) != 2 || got[1].Author != "bob" || !got[1].CreatedAt.Equal(createdAt) {
+		t.Fatalf("tree = %+v, want reply by bob created at %v", got, createdAt)
+	}
+
+	if got[0].Version != existing.Version+1 {
+		t.Fatalf("parent version = %d, want %d", got[0].Version, existing.Version+1)
+	}
+
+	_, err = r.CreateFromSource(ctx, "disqus", "100", entity.Comment{Content: "again"})
+	if !errors.Is(err, errs.ErrAlreadyExists) {
+		t.Fatalf("second CreateFromSource err = %v, want ErrAlreadyExists", err)
+	}
+
+	// тот же id из другого источника - другой коммент
+	other, err := r.CreateFromSource(ctx, "reddit", "100", entity.Comment{Content: "from reddit"})
+	if err != nil {
+		t.Fatalf("CreateFromSource(reddit): %v", err)
+	}
+
+	_, err = r.CreateFromSource(ctx, "disqus", "101", entity.Comment{
+		ParentID: sql.NullInt64{Int64: 1 << 40, Valid: true},
+		Content:  "orphan",
+	})
+	if !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("CreateFromSource with missing parent err = %v, want ErrRecordNotFound", err)
+	}
+
+	mapping, err := r.GetSourceMapping(ctx, "disqus", []string{"100", "101", "999"})
+	if err != nil {
+		t.Fatalf("GetSourceMapping: %v", err)
+	}
+
+	if len(mapping) != 1 || mapping["100"] != c.ID {
+		t.Fatalf("mapping = %v, want only 100 -> %d", mapping, c.ID)
+	}
+
+	// удаление предка оставляет запись о загрузке, но уже без коммента
+	_, err = r.DeleteCommentWithChildren(ctx, existing.ID)
+	if err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	mapping, err = r.GetSourceMapping(ctx, "disqus", []string{"100"})
+	if err != nil {
+		t.Fatalf("GetSourceMapping: %v", err)
+	}
+
+	if id, ok := mapping["100"]; !ok || id != 0 {
+		t.Fatalf("mapping after delete = %v, want 100 -> 0", mapping)
+	}
+
+	mapping, err = r.GetSourceMapping(ctx, "reddit", []string{"100"})
+	if err != nil || mapping["100"] != other.ID {
+		t.Fatalf("reddit mapping = %v, %v; want 100 -> %d", mapping, err, other.ID)
+	}
+
+	mapping, err = r.GetSourceMapping(ctx, "disqus", nil)
+	if err != nil || len(mapping) != 0 {
+		t.Fatalf("GetSourceMapping(nil) = %v, %v", mapping, err)
+	}
+}
+
+type node struct {
+	id    int64
+	depth int
+	path  []int64
+}
+
+func testGetCommentsByIDs(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	child := mustCreate(t, r, &root.ID, "child")
+	grandchild := mustCreate(t, r, &child.ID, "grandchild")
+
+	got, err := r.GetCommentsByIDs(ctx, []int64{grandchild.ID, root.ID, grandchild.ID + 100})
+	if err != nil {
+		t.Fatalf("GetCommentsByIDs: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d comments, want 2 (missing id is absent): %+v", len(got), got)
+	}
+
+	g := got[grandchild.ID]
+	if g.Content != "grandchild" || g.Depth != 2 || !slices.Equal(g.Path, []int64{root.ID, child.ID, grandchild.ID}) {
+		t.Fatalf("grandchild = %+v, want depth 2 and full path from the root", g)
+	}
+
+	if rc := got[root.ID]; rc.Depth != 0 || !slices.Equal(rc.Path, []int64{root.ID}) {
+		t.Fatalf("root = %+v, want depth 0", rc)
+	}
+
+	got, err = r.GetCommentsByIDs(ctx, nil)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("GetCommentsByIDs(nil) = %v, %v; want empty", got, err)
+	}
+}
+
+func testGetChildren(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	a := mustCreate(t, r, nil, "a")
+	b := mustCreate(t, r, nil, "b")
+	leaf := mustCreate(t, r, nil, "leaf")
+
+	var aReplies, bReplies []int64
+	for range 3 {
+		aReplies = append(aReplies, mustCreate(t, r, &a.ID, "reply").ID)
+		bReplies = append(bReplies, mustCreate(t, r, &b.ID, "reply").ID)
+	}
+
+	// внуки не попадают в прямые ответы
+	mustCreate(t, r, &aReplies[0], "nested")
+
+	got, err := r.GetChildren(ctx, []int64{a.ID, b.ID, leaf.ID}, "id", "ASC", 2, 0)
+	if err != nil {
+		t.Fatalf("GetChildren: %v", err)
+	}
+
+	if _, ok := got[leaf.ID]; ok || len(got) != 2 {
+		t.Fatalf("got replies of %d parents, want 2 (no entry for a leaf)", len(got))
+	}
+
+	assertIDs(t, got[a.ID], aReplies[:2])
+	assertIDs(t, got[b.ID], bReplies[:2])
+
+	// limit и offset - у каждого родителя свои
+	got, err = r.GetChildren(ctx, []int64{a.ID, b.ID}, "created_at", "DESC", 2, 1)
+	if err != nil {
+		t.Fatalf("GetChildren: %v", err)
+	}
+
+	assertIDs(t, got[a.ID], []int64{aReplies[1], aReplies[0]})
+	assertIDs(t, got[b.ID], []int64{bReplies[1], bReplies[0]})
+
+	got, err = r.GetChildren(ctx, []int64{a.ID}, "id", "ASC", 10, 3)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("GetChildren past the last reply = %v, %v; want empty", got, err)
+	}
+}
+
+func testCountReplies(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	child := mustCreate(t, r, &root.ID, "child")
+	mustCreate(t, r, &root.ID, "child")
+	grandchild := mustCreate(t, r, &child.ID, "grandchild")
+
+	got, err := r.CountReplies(ctx, []int64{root.ID, child.ID, grandchild.ID, grandchild.ID + 100})
+	if err != nil {
+		t.Fatalf("CountReplies: %v", err)
+	}
+
+	want := map[int64]entity.ReplyCount{
+		root.ID:       {Children: 2, Descendants: 3},
+		child.ID:      {Children: 1, Descendants: 1},
+		grandchild.ID: {Children: 0, Descendants: 0},
+	}
+
+	if !maps.Equal(got, want) {
+		t.Fatalf("CountReplies = %v, want %v", got, want)
+	}
+}
+
+func testPins(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	var ids []int64
+	for range 5 {
+		ids = append(ids, mustCreate(t, r, nil, "root").ID)
+	}
+
+	one, two := int64(1), int64(2)
+
+	if err := r.SetPinOrder(ctx, ids[3], &two); err != nil {
+		t.Fatalf("SetPinOrder: %v", err)
+	}
+
+	if err := r.SetPinOrder(ctx, ids[1], &one); err != nil {
+		t.Fatalf("SetPinOrder: %v", err)
+	}
+
+	// закрепленные первыми на любой странице и при любом order, total не меняется
+	got, total, err := r.GetRootComments(ctx, "", "id", "DESC", 3, 0)
+	if err != nil || total != 5 {
+		t.Fatalf("GetRootComments = %d, %v; want total 5", total, err)
+	}
+
+	assertIDs(t, got, []int64{ids[1], ids[3], ids[4]})
+
+	if !got[0].PinOrder.Valid || got[0].PinOrder.Int64 != 1 || got[2].PinOrder.Valid {
+		t.Fatalf("pin orders = %v, %v; want 1 and none", got[0].PinOrder, got[2].PinOrder)
+	}
+
+	got, _, err = r.GetRootComments(ctx, "", "id", "DESC", 3, 3)
+	if err != nil {
+		t.Fatalf("GetRootComments: %v", err)
+	}
+
+	assertIDs(t, got, []int64{ids[2], ids[0]})
+
+	// закрепленный ответ - первый среди братьев при любом childSort
+	root := ids[0]
+	a := mustCreate(t, r, &root, "a")
+	b := mustCreate(t, r, &root, "b")
+	mustCreate(t, r, &a.ID, "a1")
+
+	if err = r.SetPinOrder(ctx, b.ID, &one); err != nil {
+		t.Fatalf("SetPinOrder: %v", err)
+	}
+
+	if err = r.SetHighlighted(ctx, a.ID, true); err != nil {
+		t.Fatalf("SetHighlighted: %v", err)
+	}
+
+	for _, childSort := range []string{entity.ChildSortOldest, entity.ChildSortNewest, entity.ChildSortScore} {
+		tree, err := r.GetCommentWithChildren(ctx, root, childSort)
+		if err != nil {
+			t.Fatalf("GetCommentWithChildren(%s): %v", childSort, err)
+		}
+
+		if len(tree) != 4 || tree[1].ID != b.ID || tree[2].ID != a.ID || !tree[2].Highlighted || tree[1].Highlighted {
+			t.Fatalf("GetCommentWithChildren(%s) = %+v, want pinned b first and highlighted a", childSort, tree)
+		}
+
+		// версия корня выросла: новый ответ, закрепление и выделение
+		if tree[0].Version < 4 {
+			t.Fatalf("root version = %d, want >= 4", tree[0].Version)
+		}
+	}
+
+	children, err := r.GetChildren(ctx, []int64{root}, "created_at", "ASC", 1, 0)
+	if err != nil || len(children[root]) != 1 || children[root][0].ID != b.ID {
+		t.Fatalf("GetChildren = %v, %v; want pinned b", children, err)
+	}
+
+	if err = r.SetPinOrder(ctx, b.ID, nil); err != nil {
+		t.Fatalf("SetPinOrder(nil): %v", err)
+	}
+
+	tree, err := r.GetCommentWithChildren(ctx, root, entity.ChildSortOldest)
+	if err != nil || tree[1].ID != a.ID || tree[3].PinOrder.Valid {
+		t.Fatalf("after unpin = %+v, %v; want a first", tree, err)
+	}
+
+	if err = r.SetPinOrder(ctx, ids[4]+1000, &one); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("SetPinOrder(missing) = %v, want ErrRecordNotFound", err)
+	}
+
+	if err = r.SetHighlighted(ctx, ids[4]+1000, true); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("SetHighlighted(missing) = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func testLocks(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	a := mustCreate(t, r, nil, "a")
+	a1 := mustCreate(t, r, &a.ID, "a1")
+	b := mustCreate(t, r, nil, "b")
+
+	if err := r.SetLock(ctx, a1.ID, entity.LockLocked, "off-topic"); err != nil {
+		t.Fatalf("SetLock: %v", err)
+	}
+
+	tree, err := r.GetCommentWithChildren(ctx, a.ID, entity.ChildSortOldest)
+	if err != nil || len(tree) != 2 {
+		t.Fatalf("GetCommentWithChildren = %+v, %v", tree, err)
+	}
+
+	if tree[0].LockState != entity.LockNone || tree[1].LockState != entity.LockLocked || tree[1].LockReason != "off-topic" {
+		t.Fatalf("lock states = %q, %q (%q); want none and locked", tree[0].LockState, tree[1].LockState, tree[1].LockReason)
+	}
+
+	// блокировка - изменение поддерева предков
+	if tree[0].Version < 3 {
+		t.Fatalf("root version = %d, want >= 3", tree[0].Version)
+	}
+
+	// хранилище само проверяет блокировку ветки при вставке
+	if _, err = r.CreateComment(ctx, &a1.ID, nil, "", "x", nil); !errors.Is(err, errs.ErrLocked) {
+		t.Fatalf("CreateComment(locked) = %v, want ErrLocked", err)
+	}
+
+	// свежие деревья не архивируются
+	ids, err := r.ArchiveInactive(ctx, time.Now().Add(-time.Hour), entity.AutoArchiveReason)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("ArchiveInactive(hour ago) = %v, %v; want none", ids, err)
+	}
+
+	ids, err = r.ArchiveInactive(ctx, time.Now().Add(time.Hour), entity.AutoArchiveReason)
+	if err != nil || !slices.Equal(ids, []int64{a.ID, b.ID}) {
+		t.Fatalf("ArchiveInactive = %v, %v; want %v", ids, err, []int64{a.ID, b.ID})
+	}
+
+	roots, err := r.GetCommentsByIDs(ctx, []int64{a.ID, b.ID, a1.ID})
+	if err != nil {
+		t.Fatalf("GetCommentsByIDs: %v", err)
+	}
+
+	if roots[b.ID].LockState != entity.LockArchived || roots[b.ID].LockReason != entity.AutoArchiveReason || roots[a1.ID].LockState != entity.LockLocked {
+		t.Fatalf("after archive = %+v", roots)
+	}
+
+	// уже заархивированные повторно не возвращаются
+	ids, err = r.ArchiveInactive(ctx, time.Now().Add(time.Hour), entity.AutoArchiveReason)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("ArchiveInactive(again) = %v, %v; want none", ids, err)
+	}
+
+	// архив строже закрытой a1 ниже него
+	if _, err = r.CreateComment(ctx, &a1.ID, nil, "", "x", nil); !errors.Is(err, errs.ErrArchived) {
+		t.Fatalf("CreateComment(archived) = %v, want ErrArchived", err)
+	}
+
+	if err = r.SetLock(ctx, b.ID, entity.LockNone, ""); err != nil {
+		t.Fatalf("SetLock(none): %v", err)
+	}
+
+	if _, err = r.CreateComment(ctx, &b.ID, nil, "", "x", nil); err != nil {
+		t.Fatalf("CreateComment(unlocked): %v", err)
+	}
+
+	roots, err = r.GetCommentsByIDs(ctx, []int64{b.ID})
+	if err != nil || roots[b.ID].LockState != entity.LockNone || roots[b.ID].LockReason != "" {
+		t.Fatalf("after unlock = %+v, %v", roots, err)
+	}
+
+	if err = r.SetLock(ctx, b.ID+1000, entity.LockLocked, ""); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("SetLock(missing) = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func testReplyTo(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	a := mustCreate(t, r, &root.ID, "a")
+	a1 := mustCreate(t, r, &a.ID, "a1")
+
+	// ответ на a1, перенесенный к a
+	c, err := r.CreateComment(ctx, &a.ID, &a1.ID, "", "flat", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	if !c.ReplyToID.Valid || c.ReplyToID.Int64 != a1.ID || c.Depth != 2 {
+		t.Fatalf("CreateComment = %+v, want reply_to %d at depth 2", c, a1.ID)
+	}
+
+	tree, err := r.GetCommentWithChildren(ctx, root.ID, entity.ChildSortOldest)
+	if err != nil || len(tree) != 4 || tree[3].ID != c.ID || tree[3].ReplyToID.Int64 != a1.ID {
+		t.Fatalf("GetCommentWithChildren = %+v, %v; want reply_to on %d", tree, err, c.ID)
+	}
+
+	five := int64(5)
+	if err = r.SetMaxDepth(ctx, root.ID, &five); err != nil {
+		t.Fatalf("SetMaxDepth: %v", err)
+	}
+
+	got, err := r.GetCommentsByIDs(ctx, []int64{root.ID})
+	if err != nil || !got[root.ID].MaxDepth.Valid || got[root.ID].MaxDepth.Int64 != 5 {
+		t.Fatalf("GetCommentsByIDs = %+v, %v; want max depth 5", got, err)
+	}
+
+	if err = r.SetMaxDepth(ctx, root.ID, nil); err != nil {
+		t.Fatalf("SetMaxDepth(nil): %v", err)
+	}
+
+	roots, _, err := r.GetRootComments(ctx, "", "id", "ASC", 10, 0)
+	if err != nil || len(roots) != 1 || roots[0].MaxDepth.Valid {
+		t.Fatalf("GetRootComments = %+v, %v; want max depth reset", roots, err)
+	}
+
+	// удаление того, на кого отвечали, ответ не трогает - ссылка обнуляется
+	if _, err = r.DeleteCommentWithChildren(ctx, a1.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	got, err = r.GetCommentsByIDs(ctx, []int64{c.ID})
+	if err != nil || got[c.ID].ReplyToID.Valid {
+		t.Fatalf("after delete = %+v, %v; want reply_to cleared", got, err)
+	}
+
+	if err = r.SetMaxDepth(ctx, root.ID+1000, &five); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("SetMaxDepth(missing) = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func testNotifications(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	reply := mustCreate(t, r, &root.ID, "hi @alice")
+	other := mustCreate(t, r, &root.ID, "other")
+
+	created, err := r.CreateNotifications(ctx, []entity.Notification{
+		{Recipient: "alice", Kind: entity.NotificationMention, CommentID: reply.ID, Actor: "bob"},
+		{Recipient: "alice", Kind: entity.NotificationReply, CommentID: other.ID, Actor: "carol"},
+		{Recipient: "bob", Kind: entity.NotificationReply, CommentID: other.ID, Actor: "carol"},
+	})
+	if err != nil || len(created) != 3 {
+		t.Fatalf("CreateNotifications = %+v, %v", created, err)
+	}
+
+	if created[0].ID == 0 || created[0].ID >= created[1].ID || created[0].CreatedAt.IsZero() || created[2].Recipient != "bob" {
+		t.Fatalf("created = %+v, want ids in order and created_at set", created)
+	}
+
+	// от новых к старым, с текстом коммента
+	got, err := r.GetNotifications(ctx, "alice", entity.NotificationsAll, 0, 10)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("GetNotifications = %+v, %v", got, err)
+	}
+
+	if got[0].ID != created[1].ID || got[0].Content != "other" || got[1].Content != "hi @alice" || got[1].Actor != "bob" || got[0].ReadAt.Valid {
+		t.Fatalf("notifications = %+v", got)
+	}
+
+	page, err := r.GetNotifications(ctx, "alice", entity.NotificationsAll, got[0].ID, 10)
+	if err != nil || len(page) != 1 || page[0].ID != created[0].ID {
+		t.Fatalf("GetNotifications(before %d) = %+v, %v", got[0].ID, page, err)
+	}
+
+	page, err = r.GetNotifications(ctx, "alice", entity.NotificationsAll, 0, 1)
+	if err != nil || len(page) != 1 || page[0].ID != created[1].ID {
+		t.Fatalf("GetNotifications(limit 1) = %+v, %v", page, err)
+	}
+
+	read, err := r.MarkNotificationsRead(ctx, "alice", []int64{created[0].ID, created[2].ID})
+	if err != nil || read != 1 {
+		t.Fatalf("MarkNotificationsRead = %d, %v; want 1 (bob's is not alice's)", read, err)
+	}
+
+	unread, err := r.GetNotifications(ctx, "alice", entity.NotificationsUnread, 0, 10)
+	if err != nil || len(unread) != 1 || unread[0].ID != created[1].ID {
+		t.Fatalf("unread = %+v, %v", unread, err)
+	}
+
+	readOnly, err := r.GetNotifications(ctx, "alice", entity.NotificationsRead, 0, 10)
+	if err != nil || len(readOnly) != 1 || readOnly[0].ID != created[0].ID || !readOnly[0].ReadAt.Valid {
+		t.Fatalf("read = %+v, %v", readOnly, err)
+	}
+
+	read, err = r.MarkNotificationsRead(ctx, "alice", nil)
+	if err != nil || read != 1 {
+		t.Fatalf("MarkNotificationsRead(all) = %d, %v; want 1", read, err)
+	}
+
+	// уведомления уходят вместе с комментом
+	if _, err = r.DeleteCommentWithChildren(ctx, other.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	got, err = r.GetNotifications(ctx, "bob", entity.NotificationsAll, 0, 10)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("bob's notifications after delete = %+v, %v; want none", got, err)
+	}
+}
+
+func testMutes(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	a := mustCreate(t, r, &root.ID, "a")
+	a1 := mustCreate(t, r, &a.ID, "a1")
+
+	if err := r.SetMute(ctx, "alice", a.ID, true); err != nil {
+		t.Fatalf("SetMute: %v", err)
+	}
+
+	// повторная заглушка - не ошибка
+	if err := r.SetMute(ctx, "alice", a.ID, true); err != nil {
+		t.Fatalf("SetMute(again): %v", err)
+	}
+
+	if err := r.SetMute(ctx, "bob", root.ID, true); err != nil {
+		t.Fatalf("SetMute(bob): %v", err)
+	}
+
+	muted, err := r.GetMutedUsers(ctx, []int64{root.ID, a.ID, a1.ID}, []string{"alice", "bob", "carol"})
+	if err != nil || !slices.Equal(muted, []string{"alice", "bob"}) {
+		t.Fatalf("GetMutedUsers = %v, %v; want [alice bob]", muted, err)
+	}
+
+	muted, err = r.GetMutedUsers(ctx, []int64{root.ID}, []string{"alice", "carol"})
+	if err != nil || len(muted) != 0 {
+		t.Fatalf("GetMutedUsers(root) = %v, %v; want none", muted, err)
+	}
+
+	if err = r.SetMute(ctx, "alice", a.ID, false); err != nil {
+		t.Fatalf("SetMute(false): %v", err)
+	}
+
+	muted, err = r.GetMutedUsers(ctx, []int64{a.ID}, []string{"alice"})
+	if err != nil || len(muted) != 0 {
+		t.Fatalf("GetMutedUsers after unmute = %v, %v; want none", muted, err)
+	}
+
+	if err = r.SetMute(ctx, "alice", a1.ID+1000, true); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("SetMute(missing) = %v, want ErrRecordNotFound", err)
+	}
+
+	// заглушки уходят вместе с комментом
+	if _, err = r.DeleteCommentWithChildren(ctx, root.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	muted, err = r.GetMutedUsers(ctx, []int64{root.ID}, []string{"bob"})
+	if err != nil || len(muted) != 0 {
+		t.Fatalf("GetMutedUsers after delete = %v, %v; want none", muted, err)
+	}
+}
+
+func testSubscriptions(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root := mustCreate(t, r, nil, "root")
+	a := mustCreate(t, r, &root.ID, "a")
+	other := mustCreate(t, r, nil, "other")
+
+	if err := r.Subscribe(ctx, "alice", root.ID, "old@example.com"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// новая подписка начинается с самого нового коммента
+	subs, err := r.GetSubscriptions(ctx, "alice")
+	if err != nil || len(subs) != 1 || subs[0].LastCommentID != other.ID || subs[0].CommentID != root.ID {
+		t.Fatalf("GetSubscriptions = %+v, %v; want root from %d", subs, err, other.ID)
+	}
+
+	if err = r.MarkDigested(ctx, "alice", map[int64]int64{root.ID: other.ID + 5}, time.Now()); err != nil {
+		t.Fatalf("MarkDigested: %v", err)
+	}
+
+	// повторная подписка позицию не сбрасывает, а адрес меняется у всех подписок
+	if err = r.Subscribe(ctx, "alice", root.ID, "alice@example.com"); err != nil {
+		t.Fatalf("Subscribe(again): %v", err)
+	}
+	if err = r.Subscribe(ctx, "alice", a.ID, "alice@example.com"); err != nil {
+		t.Fatalf("Subscribe(a): %v", err)
+	}
+	if err = r.Subscribe(ctx, "bob", other.ID, "bob@example.com"); err != nil {
+		t.Fatalf("Subscribe(bob): %v", err)
+	}
+
+	subs, err = r.GetSubscriptions(ctx, "alice")
+	if err != nil || len(subs) != 2 || subs[0].CommentID != root.ID || subs[1].CommentID != a.ID {
+		t.Fatalf("GetSubscriptions = %+v, %v; want root and a", subs, err)
+	}
+
+	if subs[0].LastCommentID != other.ID+5 || subs[0].Email != "alice@example.com" || subs[1].Email != "alice@example.com" {
+		t.Fatalf("GetSubscriptions = %+v; want kept position and new email", subs)
+	}
+
+	// позиция назад не уходит
+	past := time.Now().Add(-time.Hour)
+	if err = r.MarkDigested(ctx, "alice", map[int64]int64{root.ID: 1, a.ID: a.ID}, past); err != nil {
+		t.Fatalf("MarkDigested(past): %v", err)
+	}
+
+	subs, err = r.GetSubscriptions(ctx, "alice")
+	if err != nil || subs[0].LastCommentID != other.ID+5 || subs[1].LastCommentID != other.ID {
+		t.Fatalf("GetSubscriptions = %+v, %v; want positions %d and %d", subs, err, other.ID+5, other.ID)
+	}
+
+	// bob подписался только что, alice попала в дайджест час назад
+	due, err := r.GetDueSubscribers(ctx, time.Now().Add(-time.Minute), "", 10)
+	if err != nil || !slices.Equal(due, []string{"alice"}) {
+		t.Fatalf("GetDueSubscribers = %v, %v; want [alice]", due, err)
+	}
+
+	due, err = r.GetDueSubscribers(ctx, time.Now().Add(time.Minute), "", 1)
+	if err != nil || !slices.Equal(due, []string{"alice"}) {
+		t.Fatalf("GetDueSubscribers(limit 1) = %v, %v; want [alice]", due, err)
+	}
+
+	due, err = r.GetDueSubscribers(ctx, time.Now().Add(time.Minute), "alice", 10)
+	if err != nil || !slices.Equal(due, []string{"bob"}) {
+		t.Fatalf("GetDueSubscribers(after alice) = %v, %v; want [bob]", due, err)
+	}
+
+	if err = r.Subscribe(ctx, "alice", other.ID+1000, "alice@example.com"); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("Subscribe(missing) = %v, want ErrRecordNotFound", err)
+	}
+
+	if err = r.Unsubscribe(ctx, "alice", a.ID); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	subs, err = r.GetSubscriptions(ctx, "alice")
+	if err != nil || len(subs) != 1 || subs[0].CommentID != root.ID {
+		t.Fatalf("GetSubscriptions after unsubscribe = %+v, %v; want root", subs, err)
+	}
+
+	// подписки уходят вместе с комментом
+	if _, err = r.DeleteCommentWithChildren(ctx, other.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	subs, err = r.GetSubscriptions(ctx, "bob")
+	if err != nil || len(subs) != 0 {
+		t.Fatalf("GetSubscriptions(bob) after delete = %+v, %v; want none", subs, err)
+	}
+
+	if err = r.Unsubscribe(ctx, "alice", 0); err != nil {
+		t.Fatalf("Unsubscribe(all): %v", err)
+	}
+
+	subs, err = r.GetSubscriptions(ctx, "alice")
+	if err != nil || len(subs) != 0 {
+		t.Fatalf("GetSubscriptions after unsubscribe all = %+v, %v; want none", subs, err)
+	}
+}
+
+func testVisits(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	root, err := r.CreateComment(ctx, nil, nil, "alice", "root", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	a, err := r.CreateComment(ctx, &root.ID, nil, "bob", "a", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	if _, err = r.CreateComment(ctx, &a.ID, nil, "alice", "a1", nil); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	other := mustCreate(t, r, nil, "other")
+
+	// тред не открывался: новое все, кроме своего
+	roots, _, err := r.GetRootComments(ctx, "alice", "id", "ASC", 10, 0)
+	if err != nil || len(roots) != 2 {
+		t.Fatalf("GetRootComments = %+v, %v; want 2 roots", roots, err)
+	}
+	if roots[0].LastSeenID != 0 || roots[0].NewCount != 1 || roots[1].NewCount != 1 {
+		t.Fatalf("GetRootComments = %+v; want new counts 1 and 1", roots)
+	}
+
+	seen, err := r.MarkSeen(ctx, "alice", root.ID)
+	if err != nil || seen != other.ID {
+		t.Fatalf("MarkSeen = %d, %v; want %d", seen, err, other.ID)
+	}
+
+	b, err := r.CreateComment(ctx, &root.ID, nil, "bob", "b", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	roots, total, err := r.GetRootComments(ctx, "alice", "id", "ASC", 1, 0)
+	if err != nil || total != 2 || len(roots) != 1 {
+		t.Fatalf("GetRootComments = %+v, %d, %v; want 1 of 2", roots, total, err)
+	}
+	if roots[0].LastSeenID != other.ID || roots[0].NewCount != 1 {
+		t.Fatalf("GetRootComments = %+v; want seen %d and 1 new", roots, other.ID)
+	}
+
+	// у другого зрителя визиты свои, без зрителя непрочитанного нет
+	roots, _, err = r.GetRootComments(ctx, "bob", "id", "ASC", 1, 0)
+	if err != nil || roots[0].LastSeenID != 0 || roots[0].NewCount != 2 {
+		t.Fatalf("GetRootComments(bob) = %+v, %v; want 2 new", roots, err)
+	}
+
+	roots, _, err = r.GetRootComments(ctx, "", "id", "ASC", 1, 0)
+	if err != nil || roots[0].NewCount != 0 {
+		t.Fatalf("GetRootComments(anonymous) = %+v, %v; want no new count", roots, err)
+	}
+
+	// один запрос на все корни, неоткрытых тредов в карте нет
+	lastSeen, err := r.GetLastSeen(ctx, "alice", []int64{root.ID, other.ID, b.ID + 1000})
+	if err != nil || !maps.Equal(lastSeen, map[int64]int64{root.ID: other.ID}) {
+		t.Fatalf("GetLastSeen = %v, %v; want %d: %d", lastSeen, err, root.ID, other.ID)
+	}
+	if lastSeen, err = r.GetLastSeen(ctx, "bob", []int64{root.ID}); err != nil || len(lastSeen) != 0 {
+		t.Fatalf("GetLastSeen(bob) = %v, %v; want none", lastSeen, err)
+	}
+	if lastSeen, err = r.GetLastSeen(ctx, "alice", nil); err != nil || len(lastSeen) != 0 {
+		t.Fatalf("GetLastSeen(no roots) = %v, %v; want none", lastSeen, err)
+	}
+
+	if seen, err = r.MarkSeen(ctx, "alice", root.ID); err != nil || seen != b.ID {
+		t.Fatalf("MarkSeen(again) = %d, %v; want %d", seen, err, b.ID)
+	}
+
+	if _, err = r.MarkSeen(ctx, "alice", b.ID+1000); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("MarkSeen(missing) = %v, want ErrRecordNotFound", err)
+	}
+
+	// визиты уходят вместе с корнем
+	if _, err = r.DeleteCommentWithChildren(ctx, root.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	if lastSeen, err = r.GetLastSeen(ctx, "alice", []int64{root.ID}); err != nil || len(lastSeen) != 0 {
+		t.Fatalf("GetLastSeen after delete = %v, %v; want none", lastSeen, err)
+	}
+}
+
+func testReferences(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	target := mustCreate(t, r, nil, "target")
+	other := mustCreate(t, r, nil, "other")
+	reply := mustCreate(t, r, &target.ID, "reply")
+	root := mustCreate(t, r, nil, "root")
+	source := mustCreate(t, r, &root.ID, "source")
+
+	// порядок текста сохраняется, несуществующие и повторы пропускаются
+	if err := r.CreateReferences(ctx, source.ID, []int64{reply.ID, source.ID + 1000, other.ID}); err != nil {
+		t.Fatalf("CreateReferences: %v", err)
+	}
+	if err := r.CreateReferences(ctx, other.ID, []int64{reply.ID, reply.ID}); err != nil {
+		t.Fatalf("CreateReferences: %v", err)
+	}
+
+	refs, err := r.GetReferences(ctx, []int64{source.ID, other.ID, root.ID})
+	if err != nil || len(refs) != 2 || len(refs[source.ID]) != 2 || len(refs[other.ID]) != 1 {
+		t.Fatalf("GetReferences = %+v, %v; want 2 and 1 references", refs, err)
+	}
+	if got := refs[source.ID][0]; got.TargetID != reply.ID || got.Target == nil || got.Target.Content != "reply" || !got.Target.ParentID.Valid {
+		t.Fatalf("GetReferences[0] = %+v; want reply", got)
+	}
+	if got := refs[source.ID][1]; got.TargetID != other.ID || got.Target == nil {
+		t.Fatalf("GetReferences[1] = %+v; want other", got)
+	}
+
+	backlinks, total, err := r.GetBacklinks(ctx, reply.ID, 1, 0)
+	if err != nil || total != 2 || len(backlinks) != 1 || backlinks[0].ID != source.ID || backlinks[0].Content != "source" {
+		t.Fatalf("GetBacklinks = %+v, %d, %v; want newest of 2", backlinks, total, err)
+	}
+
+	referrers, err := r.GetReferrers(ctx, []int64{target.ID, reply.ID})
+	if err != nil || !slices.Equal(referrers, []int64{other.ID, source.ID}) {
+		t.Fatalf("GetReferrers = %v, %v; want [%d %d]", referrers, err, other.ID, source.ID)
+	}
+
+	before, err := r.GetCommentsByIDs(ctx, []int64{root.ID, source.ID})
+	if err != nil {
+		t.Fatalf("GetCommentsByIDs: %v", err)
+	}
+
+	// ссылка на удаленный коммент остается, но без цели, а версии ссылающихся растут
+	if _, err = r.DeleteCommentWithChildren(ctx, target.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	refs, err = r.GetReferences(ctx, []int64{source.ID})
+	if err != nil || len(refs[source.ID]) != 2 || refs[source.ID][0].TargetID != reply.ID || refs[source.ID][0].Target != nil {
+		t.Fatalf("GetReferences after delete = %+v, %v; want unavailable reply", refs, err)
+	}
+
+	after, err := r.GetCommentsByIDs(ctx, []int64{root.ID, source.ID})
+	if err != nil {
+		t.Fatalf("GetCommentsByIDs: %v", err)
+	}
+	for _, id := range []int64{root.ID, source.ID} {
+		if after[id].Version != before[id].Version+1 {
+			t.Fatalf("version of %d = %d, want %d", id, after[id].Version, before[id].Version+1)
+		}
+	}
+
+	// ссылки удаленного коммента уходят вместе с ним
+	if _, err = r.DeleteCommentWithChildren(ctx, other.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	backlinks, total, err = r.GetBacklinks(ctx, reply.ID, 10, 0)
+	if err != nil || total != 1 || len(backlinks) != 1 || backlinks[0].ID != source.ID {
+		t.Fatalf("GetBacklinks after delete = %+v, %d, %v; want only source", backlinks, total, err)
+	}
+
+	// CreateComment сохраняет ссылки из текста сам: без отсутствующих целей
+	quoting, err := r.CreateComment(ctx, &root.ID, nil, "", fmt.Sprintf(">>%d >>%d >>%d", source.ID, other.ID, root.ID), nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	refs, err = r.GetReferences(ctx, []int64{quoting.ID})
+	if err != nil || len(refs[quoting.ID]) != 2 || refs[quoting.ID][0].TargetID != source.ID || refs[quoting.ID][1].TargetID != root.ID {
+		t.Fatalf("GetReferences of created = %+v, %v; want source and root", refs, err)
+	}
+}
+
+func testAttachments(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	upload := func(uploader, key string) entity.Attachment {
+		t.Helper()
+
+		a, err := r.CreateAttachment(ctx, entity.Attachment{
+			Uploader: uploader, Key: key, ThumbnailKey: key + "-thumb", Filename: key + ".png",
+			ContentType: "image/png", Size: 10, Width: 4, Height: 3,
+		})
+		if err != nil || a.ID == 0 || a.CreatedAt.IsZero() {
+			t.Fatalf("CreateAttachment = %+v, %v", a, err)
+		}
+
+		return a
+	}
+
+	root := mustCreate(t, r, nil, "root")
+	reply := mustCreate(t, r, &root.ID, "reply")
+
+	a1 := upload("alice", "k1")
+	a2 := upload("alice", "k2")
+	foreign := upload("bob", "k3")
+	pending := upload("alice", "k4")
+
+	got, err := r.GetAttachment(ctx, a1.ID)
+	if err != nil || got.Key != "k1" || got.ThumbnailKey != "k1-thumb" || got.Width != 4 || got.CommentID.Valid || got.AttachedAt.Valid {
+		t.Fatalf("GetAttachment = %+v, %v; want unattached k1", got, err)
+	}
+
+	if _, err = r.GetAttachment(ctx, a1.ID+1000); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("GetAttachment(missing) = %v, want ErrRecordNotFound", err)
+	}
+
+	// чужие вложения не прикрепляются
+	n, err := r.AttachAttachments(ctx, reply.ID, "alice", []int64{a1.ID, a2.ID, foreign.ID})
+	if err != nil || n != 2 {
+		t.Fatalf("AttachAttachments = %d, %v; want 2", n, err)
+	}
+
+	// и уже прикрепленные - тоже
+	n, err = r.AttachAttachments(ctx, root.ID, "alice", []int64{a1.ID})
+	if err != nil || n != 0 {
+		t.Fatalf("AttachAttachments again = %d, %v; want 0", n, err)
+	}
+
+	attachments, err := r.GetAttachments(ctx, []int64{root.ID, reply.ID})
+	if err != nil || len(attachments) != 1 || len(attachments[reply.ID]) != 2 ||
+		attachments[reply.ID][0].ID != a1.ID || attachments[reply.ID][1].ID != a2.ID {
+		t.Fatalf("GetAttachments = %+v, %v; want a1, a2 of reply", attachments, err)
+	}
+
+	// недавние загрузки еще ждут прикрепления
+	orphans, err := r.GetOrphanAttachments(ctx, time.Now().Add(-time.Hour), 10)
+	if err != nil || len(orphans) != 0 {
+		t.Fatalf("GetOrphanAttachments = %+v, %v; want none", orphans, err)
+	}
+
+	orphans, err = r.GetOrphanAttachments(ctx, time.Now().Add(time.Hour), 1)
+	if err != nil || len(orphans) != 1 || orphans[0].ID != foreign.ID {
+		t.Fatalf("GetOrphanAttachments(limit 1) = %+v, %v; want foreign", orphans, err)
+	}
+
+	// вложения удаленного коммента брошены сразу, независимо от времени загрузки
+	if _, err = r.DeleteCommentWithChildren(ctx, root.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	orphans, err = r.GetOrphanAttachments(ctx, time.Now().Add(-time.Hour), 10)
+	if err != nil || len(orphans) != 2 || orphans[0].ID != a1.ID || orphans[1].ID != a2.ID || orphans[0].CommentID.Valid {
+		t.Fatalf("GetOrphanAttachments after delete = %+v, %v; want a1, a2", orphans, err)
+	}
+
+	if err = r.DeleteAttachments(ctx, []int64{a1.ID, a2.ID, foreign.ID}); err != nil {
+		t.Fatalf("DeleteAttachments: %v", err)
+	}
+
+	orphans, err = r.GetOrphanAttachments(ctx, time.Now().Add(time.Hour), 10)
+	if err != nil || len(orphans) != 1 || orphans[0].ID != pending.ID {
+		t.Fatalf("GetOrphanAttachments after DeleteAttachments = %+v, %v; want pending", orphans, err)
+	}
+
+	// CreateComment прикрепляет в транзакции вставки: не прикрепилось одно - не прикрепилось ничего
+	bobs := upload("bob", "k5")
+
+	if _, err = r.CreateComment(ctx, nil, nil, "alice", "with foreign", []int64{pending.ID, bobs.ID}); !errors.Is(err, errs.ErrInvalidAttachment) {
+		t.Fatalf("CreateComment(foreign attachment) = %v, want ErrInvalidAttachment", err)
+	}
+	if got, err = r.GetAttachment(ctx, pending.ID); err != nil || got.CommentID.Valid || got.AttachedAt.Valid {
+		t.Fatalf("GetAttachment after rollback = %+v, %v; want unattached", got, err)
+	}
+
+	c, err := r.CreateComment(ctx, nil, nil, "alice", "with pending", []int64{pending.ID})
+	if err != nil {
+		t.Fatalf("CreateComment(pending): %v", err)
+	}
+
+	attachments, err = r.GetAttachments(ctx, []int64{c.ID})
+	if err != nil || len(attachments[c.ID]) != 1 || attachments[c.ID][0].ID != pending.ID {
+		t.Fatalf("GetAttachments of created = %+v, %v; want pending", attachments, err)
+	}
+}
+
+func testEraseAuthor(t *testing.T, r repo.CommentRepo) {
+	ctx := context.Background()
+
+	create := func(parentID *int64, author, content string) entity.Comment {
+		t.Helper()
+
+		c, err := r.CreateComment(ctx, parentID, nil, author, content, nil)
+		if err != nil {
+			t.Fatalf("CreateComment(%q): %v", content, err)
+		}
+
+		return c
+	}
+
+	root := create(nil, "bob", "root")
+	own := create(&root.ID, "alice", "alice's reply")
+	answer := create(&own.ID, "bob", "answer to alice")
+	quoting := create(nil, "bob", "quoting")
+	other := create(nil, "carol", "unrelated")
+
+	// ссылка из чужого коммента на коммент alice и из коммента alice на чужой
+	if err := r.CreateReferences(ctx, quoting.ID, []int64{own.ID}); err != nil {
+		t.Fatalf("CreateReferences: %v", err)
+	}
+	if err := r.CreateReferences(ctx, own.ID, []int64{other.ID}); err != nil {
+		t.Fatalf("CreateReferences: %v", err)
+	}
+
+	a, err := r.CreateAttachment(ctx, entity.Attachment{Uploader: "alice", Key: "ka", Filename: "a.txt", ContentType: "text/plain", Size: 1})
+	if err != nil {
+		t.Fatalf("CreateAttachment: %v", err)
+	}
+	if _, err = r.AttachAttachments(ctx, own.ID, "alice", []int64{a.ID}); err != nil {
+		t.Fatalf("AttachAttachments: %v", err)
+	}
+	pending, err := r.CreateAttachment(ctx, entity.Attachment{Uploader: "alice", Key: "kp", Filename: "p.txt", ContentType: "text/plain", Size: 1})
+	if err != nil {
+		t.Fatalf("CreateAttachment: %v", err)
+	}
+
+	_, err = r.CreateNotifications(ctx, []entity.Notification{
+		{Recipient: "alice", Kind: entity.NotificationReply, CommentID: answer.ID, Actor: "bob"},
+		{Recipient: "bob", Kind: entity.NotificationReply, CommentID: own.ID, Actor: "alice"},
+	})
+	if err != nil {
+		t.Fatalf("CreateNotifications: %v", err)
+	}
+	if err = r.SetMute(ctx, "alice", root.ID, true); err != nil {
+		t.Fatalf("SetMute: %v", err)
+	}
+	if err = r.Subscribe(ctx, "alice", root.ID, "alice@example.com"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, err = r.MarkSeen(ctx, "alice", root.ID); err != nil {
+		t.Fatalf("MarkSeen: %v", err)
+	}
+
+	data, err := r.GetAuthorData(ctx, "alice")
+	if err != nil || data.Author != "alice" || len(data.Comments) != 1 || data.Comments[0].ID != own.ID ||
+		data.Comments[0].Content != "alice's reply" || data.Comments[0].ParentID.Int64 != root.ID {
+		t.Fatalf("GetAuthorData comments = %+v, %v; want alice's reply", data.Comments, err)
+	}
+	if len(data.Attachments) != 2 || data.Attachments[0].ID != a.ID || data.Attachments[1].ID != pending.ID ||
+		len(data.Mutes) != 1 || data.Mutes[0].CommentID != root.ID ||
+		len(data.Visits) != 1 || data.Visits[0].RootID != root.ID || data.Visits[0].LastSeenID == 0 {
+		t.Fatalf("GetAuthorData = %+v; want 2 attachments, mute and visit of root", data)
+	}
+
+	before, err := r.GetCommentsByIDs(ctx, []int64{root.ID, own.ID, answer.ID, quoting.ID, other.ID})
+	if err != nil {
+		t.Fatalf("GetCommentsByIDs: %v", err)
+	}
+
+	erasure, err := r.EraseAuthor(ctx, "alice", entity.ErasedContent)
+	if err != nil {
+		t.Fatalf("EraseAuthor: %v", err)
+	}
+	if !slices.Equal(erasure.Comments, []int64{own.ID}) || !slices.Equal(erasure.Touched, []int64{root.ID, own.ID, quoting.ID}) {
+		t.Fatalf("EraseAuthor = comments %v, touched %v; want [%d], [%d %d %d]",
+			erasure.Comments, erasure.Touched, own.ID, root.ID, own.ID, quoting.ID)
+	}
+	if len(erasure.Attachments) != 2 || erasure.Attachments[0].Key != "ka" || erasure.Attachments[0].CommentID.Valid {
+		t.Fatalf("EraseAuthor attachments = %+v; want both detached", erasure.Attachments)
+	}
+
+	// дерево на месте, у ответа bob остался родитель
+	tree, err := r.GetCommentWithChildren(ctx, root.ID, entity.ChildSortOldest)
+	if err != nil || len(tree) != 3 || tree[1].ID != own.ID || tree[1].Author != "" || tree[1].Content != entity.ErasedContent || tree[2].ID != answer.ID {
+		t.Fatalf("tree after erase = %+v, %v; want anonymized reply with bob's answer", tree, err)
+	}
+
+	after, err := r.GetCommentsByIDs(ctx, []int64{root.ID, own.ID, answer.ID, quoting.ID, other.ID})
+	if err != nil {
+		t.Fatalf("GetCommentsByIDs: %v", err)
+	}
+	for _, id := range []int64{root.ID, own.ID, quoting.ID} {
+		if after[id].Version <= before[id].Version {
+			t.Fatalf("version of %d = %d, want above %d", id, after[id].Version, before[id].Version)
+		}
+	}
+	for _, id := range []int64{answer.ID, other.ID} {
+		if after[id].Version != before[id].Version {
+			t.Fatalf("version of %d = %d, want unchanged %d", id, after[id].Version, before[id].Version)
+		}
+	}
+
+	// ссылки из стертого текста пропадают, ссылки на стертый коммент остаются
+	refs, err := r.GetReferences(ctx, []int64{own.ID, quoting.ID})
+	if err != nil || len(refs) != 1 || len(refs[quoting.ID]) != 1 || refs[quoting.ID][0].Target.Author != "" {
+		t.Fatalf("GetReferences = %+v, %v; want only quoting -> anonymized", refs, err)
+	}
+
+	// отвязанные вложения - брошенные, прикрепить их снова нельзя
+	orphans, err := r.GetOrphanAttachments(ctx, time.Now().Add(-time.Hour), 10)
+	if err != nil || len(orphans) != 2 {
+		t.Fatalf("GetOrphanAttachments = %+v, %v; want both", orphans, err)
+	}
+	if n, err := r.AttachAttachments(ctx, other.ID, "", []int64{pending.ID}); err != nil || n != 0 {
+		t.Fatalf("AttachAttachments(erased) = %d, %v; want 0", n, err)
+	}
+
+	ns, err := r.GetNotifications(ctx, "alice", entity.NotificationsAll, 0, 10)
+	if err != nil || len(ns) != 0 {
+		t.Fatalf("GetNotifications(alice) = %+v, %v; want none", ns, err)
+	}
+	ns, err = r.GetNotifications(ctx, "bob", entity.NotificationsAll, 0, 10)
+	if err != nil || len(ns) != 1 || ns[0].Actor != "" || ns[0].Content != entity.ErasedContent {
+		t.Fatalf("GetNotifications(bob) = %+v, %v; want anonymized actor", ns, err)
+	}
+
+	subs, err := r.GetSubscriptions(ctx, "alice")
+	if err != nil || len(subs) != 0 {
+		t.Fatalf("GetSubscriptions = %+v, %v; want none", subs, err)
+	}
+
+	data, err = r.GetAuthorData(ctx, "alice")
+	if err != nil || len(data.Comments) != 0 || len(data.Attachments) != 0 || len(data.Mutes) != 0 || len(data.Visits) != 0 {
+		t.Fatalf("GetAuthorData after erase = %+v, %v; want nothing", data, err)
+	}
+
+	logged, err := r.LogPrivacyRequest(ctx, entity.PrivacyRequest{
+		Action: entity.ErasureAnonymize, Author: "alice", Actor: "admin", Reason: "GDPR art. 17", Comments: 1,
+	})
+	if err != nil || logged.ID == 0 || logged.CreatedAt.IsZero() {
+		t.Fatalf("LogPrivacyRequest = %+v, %v", logged, err)
+	}
+}
+
+func testAuditLog(t *testing.T, r repo.CommentRepo) {
+	ctx := requestid.NewContext(caller.NewContext(context.Background(), caller.Info{User: "mod", IP: "10.0.0.1"}), "req-1")
+	aliceCtx := caller.WithUser(ctx, "alice")
+
+	root, err := r.CreateComment(aliceCtx, nil, nil, "alice", "root", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	reply, err := r.CreateComment(aliceCtx, &root.ID, nil, "alice", "reply", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	order := int64(1)
+	if err = r.SetPinOrder(ctx, root.ID, &order); err != nil {
+		t.Fatalf("SetPinOrder: %v", err)
+	}
+	if err = r.SetLock(ctx, root.ID, entity.LockLocked, "flame"); err != nil {
+		t.Fatalf("SetLock: %v", err)
+	}
+	if _, err = r.DeleteCommentWithChildren(ctx, reply.ID); err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	entries, err := r.GetAuditLog(ctx, entity.AuditFilter{}, 0, 10)
+	if err != nil {
+		t.Fatalf("GetAuditLog: %v", err)
+	}
+
+	actions := make([]string, len(entries))
+	for i, e := range entries {
+		actions[i] = e.Action
+	}
+	want := []string{entity.AuditDelete, entity.AuditLock, entity.AuditPin, entity.AuditCreate, entity.AuditCreate}
+	if !slices.Equal(actions, want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+
+	deleted := entries[0]
+	if deleted.Actor != "mod" || deleted.IP != "10.0.0.1" || deleted.RequestID != "req-1" ||
+		deleted.CommentID.Int64 != reply.ID || deleted.After != nil || deleted.CreatedAt.IsZero() {
+		t.Fatalf("delete entry = %+v; want mod from 10.0.0.1 deleting %d", deleted, reply.ID)
+	}
+
+	var subtree []entity.AuditComment
+	if err = json.Unmarshal(deleted.Before, &subtree); err != nil || len(subtree) != 1 ||
+		subtree[0].ID != reply.ID || subtree[0].Content != "reply" || subtree[0].Author != "alice" {
+		t.Fatalf("delete before = %s, %v; want reply snapshot", deleted.Before, err)
+	}
+
+	var lockBefore, lockAfter entity.AuditComment
+	if json.Unmarshal(entries[1].Before, &lockBefore) != nil || json.Unmarshal(entries[1].After, &lockAfter) != nil ||
+		lockBefore.LockState != "" || lockBefore.PinOrder == nil ||
+		lockAfter.LockState != entity.LockLocked || lockAfter.LockReason != "flame" {
+		t.Fatalf("lock entry = %s -> %s; want unlocked pinned -> locked", entries[1].Before, entries[1].After)
+	}
+
+	if entries[4].Actor != "alice" || entries[4].Before != nil || entries[4].CommentID.Int64 != root.ID {
+		t.Fatalf("first entry = %+v; want alice creating root", entries[4])
+	}
+
+	filtered := func(f entity.AuditFilter, beforeID int64) []int64 {
+		t.Helper()
+
+		got, err := r.GetAuditLog(ctx, f, beforeID, 10)
+		if err != nil {
+			t.Fatalf("GetAuditLog(%+v): %v", f, err)
+		}
+
+		ids := make([]int64, len(got))
+		for i, e := range got {
+			ids[i] = e.ID
+		}
+
+		return ids
+	}
+
+	ids := func(es ...entity.AuditEntry) []int64 {
+		out := make([]int64, len(es))
+		for i, e := range es {
+			out[i] = e.ID
+		}
+
+		return out
+	}
+
+	hourAgo, inHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	for _, tt := range []struct {
+		name     string
+		f        entity.AuditFilter
+		beforeID int64
+		want     []int64
+	}{
+		{"actor", entity.AuditFilter{Actor: "alice"}, 0, ids(entries[3], entries[4])},
+		{"action", entity.AuditFilter{Action: entity.AuditPin}, 0, ids(entries[2])},
+		{"comment", entity.AuditFilter{CommentID: root.ID}, 0, ids(entries[1], entries[2], entries[4])},
+		{"range", entity.AuditFilter{From: hourAgo, To: inHour}, 0, ids(entries...)},
+		{"past", entity.AuditFilter{To: hourAgo}, 0, []int64{}},
+		{"future", entity.AuditFilter{From: inHour}, 0, []int64{}},
+		{"page", entity.AuditFilter{}, entries[2].ID, ids(entries[3], entries[4])},
+	} {
+		if got := filtered(tt.f, tt.beforeID); !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: ids = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// стирание убирает автора из записей о его действиях и из снимков его комментов
+	erasure, err := r.EraseAuthor(context.Background(), "alice", entity.ErasedContent)
+	if err != nil {
+		t.Fatalf("EraseAuthor: %v", err)
+	}
+
+	entries, err = r.GetAuditLog(ctx, entity.AuditFilter{}, 0, 10)
+	if err != nil || len(entries) != 6 {
+		t.Fatalf("GetAuditLog after erase = %+v, %v; want 6 entries", entries, err)
+	}
+
+	erased := entries[0]
+	var erasedIDs []int64
+	if erased.Action != entity.AuditErase || erased.Actor != "" || erased.CommentID.Valid ||
+		json.Unmarshal(erased.After, &erasedIDs) != nil || !slices.Equal(erasedIDs, erasure.Comments) {
+		t.Fatalf("erase entry = %+v; want ids %v", erased, erasure.Comments)
+	}
+
+	for _, e := range entries {
+		if e.Actor == "alice" || bytes.Contains(e.Before, []byte("alice")) || bytes.Contains(e.After, []byte("alice")) {
+			t.Fatalf("entry %d still mentions alice: %+v", e.ID, e)
+		}
+	}
+
+	created := entries[5]
+	var snapshot entity.AuditComment
+	if created.IP != "" || created.RequestID != "req-1" ||
+		json.Unmarshal(created.After, &snapshot) != nil || snapshot.Author != "" || snapshot.Content != entity.ErasedContent {
+		t.Fatalf("create entry after erase = %+v; want anonymized snapshot without ip", created)
+	}
+
+	if err = json.Unmarshal(entries[1].Before, &subtree); err != nil || len(subtree) != 1 || subtree[0].Content != entity.ErasedContent {
+		t.Fatalf("delete before after erase = %s, %v; want anonymized reply", entries[1].Before, err)
+	}
+
+	// записи о чужих действиях остаются как есть
+	if entries[2].Actor != "mod" || entries[2].IP != "10.0.0.1" {
+		t.Fatalf("lock entry after erase = %+v; want untouched", entries[2])
+	}
+}
+
+func mustCreate(t *testing.T, r repo.CommentRepo, parentID *int64, content string) entity.Comment {
+	t.Helper()
+
+	c, err := r.CreateComment(context.Background(), parentID, nil, "", content, nil)
+	if err != nil {
+		t.Fatalf("CreateComment(%q): %v", content, err)
+	}
+
+	return c
+}
+
+func assertTree(t *testing.T, got []entity.Comment, want []node) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d comments, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i, w := range want {
+		g := got[i]
+		if g.ID != w.id || g.Depth != w.depth || !slices.Equal(g.Path, w.path) {
+			t.Fatalf("comment #%d = {id:%d depth:%d path:%v}, want {id:%d depth:%d path:%v}",
+				i, g.ID, g.Depth, g.Path, w.id, w.depth, w.path)
+		}
+	}
+}
+
+func assertIDs(t *testing.T, got []entity.Comment, want []int64) {
+	t.Helper()
+
+	ids := make([]int64, len(got))
+	for i, c := range got {
+		ids[i] = c.ID
+	}
+
+	if !slices.Equal(ids, want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,81 @@
+package webapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+)
+
+// CDNWebAPI - сброс кэша CDN по ключам: один запрос на purge URL
+// со списком ключей в заголовке Surrogate-Key (так устроены purge по ключам в Fastly и xkey в Varnish).
+// Без URL запросы не отправляются.
+type CDNWebAPI struct {
+	client     *http.Client
+	url        string
+	method     string
+	authHeader string
+	auth       string
+	l          logger.Interface
+}
+
+var _ repo.CDNWebAPI = (*CDNWebAPI)(nil)
+
+func NewCDN(url, method, authHeader, auth string, timeout time.Duration, l logger.Interface) *CDNWebAPI {
+	return &CDNWebAPI{
+		client:     &http.Client{Timeout: timeout},
+		url:        url,
+		method:     method,
+		authHeader: authHeader,
+		auth:       auth,
+		l:          l,
+	}
+}
+
+// Purge - ошибка логируется здесь же: вызывающий уже сохранил изменение и не откатывает его.
+func (w *CDNWebAPI) Purge(ctx context.Context, keys []string) error {
+	if w.url == "" || len(keys) == 0 {
+		return nil
+	}
+
+	err := w.purge(ctx, keys)
+	if err != nil {
+		err = fmt.Errorf("CDNWebAPI - Purge - w.purge: %w", err)
+		w.l.WithContext(ctx).Error(err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (w *CDNWebAPI) purge(ctx context.Context, keys []string) error {
+	req, err := http.NewRequestWithContext(ctx, w.method, w.url, nil)
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	req.Header.Set("Surrogate-Key", strings.Join(keys, " "))
+	if w.auth != "" {
+		req.Header.Set(w.authHeader, w.auth)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("w.client.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,579 @@
+package tracing
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CommentRepo - декоратор repo.CommentRepo, оборачивающий каждый метод в спан.
+type CommentRepo struct {
+	repo   repo.CommentRepo
+	tracer trace.Tracer
+}
+
+var _ repo.CommentRepo = (*CommentRepo)(nil)
+
+func NewCommentRepo(r repo.CommentRepo, tp trace.TracerProvider) *CommentRepo {
+	return &CommentRepo{
+		repo:   r,
+		tracer: tp.Tracer(_tracerName),
+	}
+}
+
+func (r *CommentRepo) CreateComment(ctx context.Context, parentID, replyToID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.CreateComment")
+	if parentID != nil {
+		span.SetAttributes(attribute.Int64("comment.parent_id", *parentID))
+	}
+	if replyToID != nil {
+		span.SetAttributes(attribute.Int64("comment.reply_to_id", *replyToID))
+	}
+
+	c, err := r.repo.CreateComment(ctx, parentID, replyToID, author, content, attachmentIDs)
+	span.SetAttributes(attribute.Int64("comment.id", c.ID))
+	end(span, err)
+
+	return c, err
+}
+
+func (r *CommentRepo) CommentExists(ctx context.Context, id int64) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.CommentExists",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := r.repo.CommentExists(ctx, id)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetCommentWithChildren(ctx context.Context, id int64, childSort string) ([]entity.Comment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetCommentWithChildren",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.String("query.child_sort", childSort)))
+
+	comments, err := r.repo.GetCommentWithChildren(ctx, id, childSort)
+	span.SetAttributes(attribute.Int("comments.count", len(comments)))
+	end(span, err)
+
+	return comments, err
+}
+
+func (r *CommentRepo) DeleteCommentWithChildren(ctx context.Context, id int64) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.DeleteCommentWithChildren",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	deleted, err := r.repo.DeleteCommentWithChildren(ctx, id)
+	span.SetAttributes(attribute.Int("comments.count", deleted))
+	end(span, err)
+
+	return deleted, err
+}
+
+func (r *CommentRepo) SearchComments(ctx context.Context, search string, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.SearchComments",
+		trace.WithAttributes(
+			attribute.String("query.sort_by", sortBy),
+			attribute.String("query.order", order),
+			attribute.Int("query.limit", limit),
+			attribute.Int("query.offset", offset),
+		))
+
+	comments, total, err := r.repo.SearchComments(ctx, search, sortBy, order, limit, offset)
+	span.SetAttributes(
+		attribute.Int("comments.count", len(comments)),
+		attribute.Int("comments.total", total),
+	)
+	end(span, err)
+
+	return comments, total, err
+}
+
+func (r *CommentRepo) GetRootComments(ctx context.Context, viewer, sortBy, order string, limit, offset int) ([]entity.Comment, int, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetRootComments",
+		trace.WithAttributes(
+			attribute.Bool("query.viewer", viewer != ""),
+			attribute.String("query.sort_by", sortBy),
+			attribute.String("query.order", order),
+			attribute.Int("query.limit", limit),
+			attribute.Int("query.offset", offset),
+		))
+
+	comments, total, err := r.repo.GetRootComments(ctx, viewer, sortBy, order, limit, offset)
+	span.SetAttributes(
+		attribute.Int("comments.count", len(comments)),
+		attribute.Int("comments.total", total),
+	)
+	end(span, err)
+
+	return comments, total, err
+}
+
+func (r *CommentRepo) GetTreesForRoots(ctx context.Context, rootIDs []int64, childSort string) ([]entity.Comment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetTreesForRoots",
+		trace.WithAttributes(attribute.Int("roots.count", len(rootIDs)), attribute.String("query.child_sort", childSort)))
+
+	comments, err := r.repo.GetTreesForRoots(ctx, rootIDs, childSort)
+	span.SetAttributes(attribute.Int("comments.count", len(comments)))
+	end(span, err)
+
+	return comments, err
+}
+
+func (r *CommentRepo) GetCommentPath(ctx context.Context, id int64) ([]int64, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetCommentPath",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	path, err := r.repo.GetCommentPath(ctx, id)
+	span.SetAttributes(attribute.Int("comment.depth", len(path)-1))
+	end(span, err)
+
+	return path, err
+}
+
+// ExportComments - спан покрывает всю выгрузку, включая время потребителя.
+func (r *CommentRepo) ExportComments(ctx context.Context, rootID *int64) iter.Seq2[entity.Comment, error] {
+	return func(yield func(entity.Comment, error) bool) {
+		ctx, span := r.tracer.Start(ctx, "CommentRepo.ExportComments")
+		if rootID != nil {
+			span.SetAttributes(attribute.Int64("comment.id", *rootID))
+		}
+
+		var (
+			count int
+			err   error
+		)
+
+		for c, e := range r.repo.ExportComments(ctx, rootID) {
+			if e != nil {
+				err = e
+			} else {
+				count++
+			}
+
+			if !yield(c, e) {
+				break
+			}
+		}
+
+		span.SetAttributes(attribute.Int("comments.count", count))
+		end(span, err)
+	}
+}
+
+func (r *CommentRepo) ImportComments(ctx context.Context, comments []entity.Comment) ([]int64, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.ImportComments",
+		trace.WithAttributes(attribute.Int("comments.count", len(comments))))
+
+	ids, err := r.repo.ImportComments(ctx, comments)
+	end(span, err)
+
+	return ids, err
+}
+
+func (r *CommentRepo) CreateFromSource(ctx context.Context, source, sourceID string, c entity.Comment) (entity.Comment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.CreateFromSource",
+		trace.WithAttributes(attribute.String("import.source", source), attribute.String("import.source_id", sourceID)))
+	if c.ParentID.Valid {
+		span.SetAttributes(attribute.Int64("comment.parent_id", c.ParentID.Int64))
+	}
+
+	c, err := r.repo.CreateFromSource(ctx, source, sourceID, c)
+	span.SetAttributes(attribute.Int64("comment.id", c.ID))
+	end(span, err)
+
+	return c, err
+}
+
+func (r *CommentRepo) GetSourceMapping(ctx context.Context, source string, sourceIDs []string) (map[string]int64, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetSourceMapping",
+		trace.WithAttributes(attribute.String("import.source", source), attribute.Int("import.source_ids.count", len(sourceIDs))))
+
+	mapping, err := r.repo.GetSourceMapping(ctx, source, sourceIDs)
+	end(span, err)
+
+	return mapping, err
+}
+
+func (r *CommentRepo) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetCommentsByIDs",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(ids))))
+
+	comments, err := r.repo.GetCommentsByIDs(ctx, ids)
+	span.SetAttributes(attribute.Int("comments.count", len(comments)))
+	end(span, err)
+
+	return comments, err
+}
+
+func (r *CommentRepo) GetChildren(ctx context.Context, parentIDs []int64, sortBy, order string, limit, offset int) (map[int64][]entity.Comment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetChildren",
+		trace.WithAttributes(
+			attribute.Int("parents.count", len(parentIDs)),
+			attribute.String("query.sort_by", sortBy),
+			attribute.String("query.order", order),
+			attribute.Int("query.limit", limit),
+			attribute.Int("query.offset", offset),
+		))
+
+	children, err := r.repo.GetChildren(ctx, parentIDs, sortBy, order, limit, offset)
+	end(span, err)
+
+	return children, err
+}
+
+func (r *CommentRepo) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.CountReplies",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(ids))))
+
+	counts, err := r.repo.CountReplies(ctx, ids)
+	end(span, err)
+
+	return counts, err
+}
+
+func (r *CommentRepo) SetPinOrder(ctx context.Context, id int64, order *int64) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.SetPinOrder",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.Bool("comment.pinned", order != nil)))
+
+	err := r.repo.SetPinOrder(ctx, id, order)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.SetHighlighted",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.Bool("comment.highlighted", highlighted)))
+
+	err := r.repo.SetHighlighted(ctx, id, highlighted)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) SetLock(ctx context.Context, id int64, state, reason string) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.SetLock",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.String("comment.lock_state", state)))
+
+	err := r.repo.SetLock(ctx, id, state, reason)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.SetMaxDepth",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+	if maxDepth != nil {
+		span.SetAttributes(attribute.Int64("comment.max_depth", *maxDepth))
+	}
+
+	err := r.repo.SetMaxDepth(ctx, id, maxDepth)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) ArchiveInactive(ctx context.Context, before time.Time, reason string) ([]int64, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.ArchiveInactive",
+		trace.WithAttributes(attribute.String("query.before", before.UTC().Format(time.RFC3339))))
+
+	ids, err := r.repo.ArchiveInactive(ctx, before, reason)
+	span.SetAttributes(attribute.Int("roots.count", len(ids)))
+	end(span, err)
+
+	return ids, err
+}
+
+// CreateNotifications - имена получателей и пользователей в атрибуты не попадают, только их число.
+func (r *CommentRepo) CreateNotifications(ctx context.Context, ns []entity.Notification) ([]entity.Notification, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.CreateNotifications",
+		trace.WithAttributes(attribute.Int("notifications.count", len(ns))))
+
+	created, err := r.repo.CreateNotifications(ctx, ns)
+	end(span, err)
+
+	return created, err
+}
+
+func (r *CommentRepo) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetNotifications",
+		trace.WithAttributes(
+			attribute.String("query.status", status),
+			attribute.Int64("query.before_id", beforeID),
+			attribute.Int("query.limit", limit),
+		))
+
+	ns, err := r.repo.GetNotifications(ctx, recipient, status, beforeID, limit)
+	end(span, err)
+
+	return ns, err
+}
+
+func (r *CommentRepo) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.MarkNotificationsRead",
+		trace.WithAttributes(attribute.Int("notification.ids.count", len(ids))))
+
+	read, err := r.repo.MarkNotificationsRead(ctx, recipient, ids)
+	end(span, err)
+
+	return read, err
+}
+
+func (r *CommentRepo) SetMute(ctx context.Context, user string, id int64, muted bool) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.SetMute",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.Bool("comment.muted", muted)))
+
+	err := r.repo.SetMute(ctx, user, id, muted)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetMutedUsers(ctx context.Context, ids []int64, users []string) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetMutedUsers",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(ids)), attribute.Int("users.count", len(users))))
+
+	muted, err := r.repo.GetMutedUsers(ctx, ids, users)
+	end(span, err)
+
+	return muted, err
+}
+
+// Subscribe - адрес и имя подписчика в атрибуты не попадают.
+func (r *CommentRepo) Subscribe(ctx context.Context, subscriber string, id int64, email string) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.Subscribe",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := r.repo.Subscribe(ctx, subscriber, id, email)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) Unsubscribe(ctx context.Context, subscriber string, id int64) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.Unsubscribe",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := r.repo.Unsubscribe(ctx, subscriber, id)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetSubscriptions(ctx context.Context, subscriber string) ([]entity.Subscription, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetSubscriptions")
+
+	subs, err := r.repo.GetSubscriptions(ctx, subscriber)
+	span.SetAttributes(attribute.Int("subscriptions.count", len(subs)))
+	end(span, err)
+
+	return subs, err
+}
+
+func (r *CommentRepo) GetDueSubscribers(ctx context.Context, before time.Time, after string, limit int) ([]string, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetDueSubscribers",
+		trace.WithAttributes(
+			attribute.String("query.before", before.UTC().Format(time.RFC3339)),
+			attribute.Int("query.limit", limit),
+		))
+
+	subscribers, err := r.repo.GetDueSubscribers(ctx, before, after, limit)
+	span.SetAttributes(attribute.Int("users.count", len(subscribers)))
+	end(span, err)
+
+	return subscribers, err
+}
+
+func (r *CommentRepo) MarkDigested(ctx context.Context, subscriber string, lastIDs map[int64]int64, at time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.MarkDigested",
+		trace.WithAttributes(attribute.Int("subscriptions.count", len(lastIDs))))
+
+	err := r.repo.MarkDigested(ctx, subscriber, lastIDs, at)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.MarkSeen",
+		trace.WithAttributes(attribute.Int64("comment.id", rootID)))
+
+	lastSeenID, err := r.repo.MarkSeen(ctx, viewer, rootID)
+	end(span, err)
+
+	return lastSeenID, err
+}
+
+func (r *CommentRepo) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetLastSeen",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(rootIDs))))
+
+	lastSeen, err := r.repo.GetLastSeen(ctx, viewer, rootIDs)
+	end(span, err)
+
+	return lastSeen, err
+}
+
+func (r *CommentRepo) CreateReferences(ctx context.Context, sourceID int64, targetIDs []int64) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.CreateReferences",
+		trace.WithAttributes(attribute.Int64("comment.id", sourceID), attribute.Int("comment.ids.count", len(targetIDs))))
+
+	err := r.repo.CreateReferences(ctx, sourceID, targetIDs)
+	end(span, err)
+
+	return err
+}
+
+func (r *CommentRepo) GetReferences(ctx context.Context, sourceIDs []int64) (map[int64][]entity.Reference, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetReferences",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(sourceIDs))))
+
+	refs, err := r.repo.GetReferences(ctx, sourceIDs)
+	end(span, err)
+
+	return refs, err
+}
+
+func (r *CommentRepo) GetBacklinks(ctx context.Context, targetID int64, limit, offset int) ([]entity.Comment, int, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetBacklinks",
+		trace.WithAttributes(
+			attribute.Int64("comment.id", targetID),
+			attribute.Int("query.limit", limit),
+			attribute.Int("query.offset", offset),
+		))
+
+	comments, total, err := r.repo.GetBacklinks(ctx, targetID, limit, offset)
+	span.SetAttributes(
+		attribute.Int("comments.count", len(comments)),
+		attribute.Int("comments.total", total),
+	)
+	end(span, err)
+
+	return comments, total, err
+}
+
+func (r *CommentRepo) GetReferrers(ctx context.Context, targetIDs []int64) ([]int64, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetReferrers",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(targetIDs))))
+
+	ids, err := r.repo.GetReferrers(ctx, targetIDs)
+	end(span, err)
+
+	return ids, err
+}
+
+// CreateAttachment - имя файла в атрибуты не попадает, только тип и размер.
+func (r *CommentRepo) CreateAttachment(ctx context.Context, a entity.Attachment) (entity.Attachment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.CreateAttachment",
+		trace.WithAttributes(attribute.String("attachment.content_type", a.ContentType), attribute.Int64("attachment.size", a.Size)))
+
+	created, err := r.repo.CreateAttachment(ctx, a)
+	span.SetAttributes(attribute.Int64("attachment.id", created.ID))
+	end(span, err)
+
+	return created, err
+}
+
+func (r *CommentRepo) AttachAttachments(ctx context.Context, commentID int64, uploader string, ids []int64) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.AttachAttachments",
+		trace.WithAttributes(attribute.Int64("comment.id", commentID), attribute.Int("attachment.ids.count", len(ids))))
+
+	n, err := r.repo.AttachAttachments(ctx, commentID, uploader, ids)
+	span.SetAttributes(attribute.Int("attachments.count", n))
+	end(span, err)
+
+	return n, err
+}
+
+func (r *CommentRepo) GetAttachment(ctx context.Context, id int64) (entity.Attachment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetAttachment",
+		trace.WithAttributes(attribute.Int64("attachment.id", id)))
+
+	a, err := r.repo.GetAttachment(ctx, id)
+	end(span, err)
+
+	return a, err
+}
+
+func (r *CommentRepo) GetAttachments(ctx context.Context, commentIDs []int64) (map[int64][]entity.Attachment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetAttachments",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(commentIDs))))
+
+	attachments, err := r.repo.GetAttachments(ctx, commentIDs)
+	end(span, err)
+
+	return attachments, err
+}
+
+func (r *CommentRepo) GetOrphanAttachments(ctx context.Context, uploadedBefore time.Time, limit int) ([]entity.Attachment, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetOrphanAttachments",
+		trace.WithAttributes(attribute.String("query.before", uploadedBefore.UTC().Format(time.RFC3339)), attribute.Int("query.limit", limit)))
+
+	orphans, err := r.repo.GetOrphanAttachments(ctx, uploadedBefore, limit)
+	span.SetAttributes(attribute.Int("attachments.count", len(orphans)))
+	end(span, err)
+
+	return orphans, err
+}
+
+func (r *CommentRepo) DeleteAttachments(ctx context.Context, ids []int64) error {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.DeleteAttachments",
+		trace.WithAttributes(attribute.Int("attachment.ids.count", len(ids))))
+
+	err := r.repo.DeleteAttachments(ctx, ids)
+	end(span, err)
+
+	return err
+}
+
+// GetAuthorData, EraseAuthor и LogPrivacyRequest - имя автора в атрибуты не попадает.
+func (r *CommentRepo) GetAuthorData(ctx context.Context, author string) (entity.AuthorData, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetAuthorData")
+
+	data, err := r.repo.GetAuthorData(ctx, author)
+	span.SetAttributes(attribute.Int("comments.count", len(data.Comments)))
+	end(span, err)
+
+	return data, err
+}
+
+func (r *CommentRepo) EraseAuthor(ctx context.Context, author, content string) (entity.Erasure, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.EraseAuthor")
+
+	erasure, err := r.repo.EraseAuthor(ctx, author, content)
+	span.SetAttributes(
+		attribute.Int("comments.count", len(erasure.Comments)),
+		attribute.Int("attachments.count", len(erasure.Attachments)),
+	)
+	end(span, err)
+
+	return erasure, err
+}
+
+func (r *CommentRepo) LogPrivacyRequest(ctx context.Context, p entity.PrivacyRequest) (entity.PrivacyRequest, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.LogPrivacyRequest",
+		trace.WithAttributes(attribute.String("privacy.action", p.Action)))
+
+	logged, err := r.repo.LogPrivacyRequest(ctx, p)
+	end(span, err)
+
+	return logged, err
+}
+
+// GetAuditLog - фильтр по actor в атрибуты не попадает, как и имена авторов.
+func (r *CommentRepo) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	ctx, span := r.tracer.Start(ctx, "CommentRepo.GetAuditLog",
+		trace.WithAttributes(attribute.String("audit.action", f.Action), attribute.Int("query.limit", limit)))
+
+	entries, err := r.repo.GetAuditLog(ctx, f, beforeID, limit)
+	span.SetAttributes(attribute.Int("entries.count", len(entries)))
+	end(span, err)
+
+	return entries, err
+}
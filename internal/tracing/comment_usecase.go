@@ -0,0 +1,474 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CommentUseCase - декоратор usecase.CommentUseCase, оборачивающий каждый метод в спан.
+type CommentUseCase struct {
+	uc     usecase.CommentUseCase
+	tracer trace.Tracer
+}
+
+var _ usecase.CommentUseCase = (*CommentUseCase)(nil)
+
+func NewCommentUseCase(uc usecase.CommentUseCase, tp trace.TracerProvider) *CommentUseCase {
+	return &CommentUseCase{
+		uc:     uc,
+		tracer: tp.Tracer(_tracerName),
+	}
+}
+
+func (u *CommentUseCase) CreateComment(ctx context.Context, parentID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.CreateComment",
+		trace.WithAttributes(attribute.Int("attachment.ids.count", len(attachmentIDs))))
+	if parentID != nil {
+		span.SetAttributes(attribute.Int64("comment.parent_id", *parentID))
+	}
+
+	c, err := u.uc.CreateComment(ctx, parentID, author, content, attachmentIDs)
+	span.SetAttributes(attribute.Int64("comment.id", c.ID))
+	if c.ReplyToID.Valid {
+		span.SetAttributes(attribute.Int64("comment.reply_to_id", c.ReplyToID.Int64))
+	}
+	end(span, err)
+
+	return c, err
+}
+
+func (u *CommentUseCase) DeleteCommentWithChildren(ctx context.Context, id int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.DeleteCommentWithChildren",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := u.uc.DeleteCommentWithChildren(ctx, id)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) GetComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetComments",
+		trace.WithAttributes(
+			attribute.Bool("query.search", params.Search != ""),
+			attribute.Bool("query.viewer", params.Viewer != ""),
+			attribute.String("query.sort_by", params.SortBy),
+			attribute.String("query.order", params.Order),
+			attribute.Int("query.limit", params.Limit),
+			attribute.Int("query.offset", params.Offset),
+		))
+	if params.ParentID != nil {
+		span.SetAttributes(attribute.Int64("query.parent_id", *params.ParentID))
+	}
+
+	result, err := u.uc.GetComments(ctx, params)
+	span.SetAttributes(
+		attribute.Int("comments.count", len(result.Comments)),
+		attribute.Int("comments.total", result.Total),
+	)
+	end(span, err)
+
+	return result, err
+}
+
+// ExportComments - спан покрывает только проверку, сама выгрузка - в спане CommentRepo.ExportComments.
+func (u *CommentUseCase) ExportComments(ctx context.Context, parentID *int64) (iter.Seq2[entity.Comment, error], error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.ExportComments")
+	if parentID != nil {
+		span.SetAttributes(attribute.Int64("query.parent_id", *parentID))
+	}
+
+	comments, err := u.uc.ExportComments(ctx, parentID)
+	end(span, err)
+
+	return comments, err
+}
+
+func (u *CommentUseCase) ImportComments(ctx context.Context, comments []dto.ImportComment) (dto.ImportResult, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.ImportComments",
+		trace.WithAttributes(attribute.Int("import.records", len(comments))))
+
+	result, err := u.uc.ImportComments(ctx, comments)
+	span.SetAttributes(
+		attribute.Int("import.imported", result.Imported),
+		attribute.Int("import.errors", len(result.Errors)),
+	)
+	end(span, err)
+
+	return result, err
+}
+
+func (u *CommentUseCase) GetRootComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetRootComments",
+		trace.WithAttributes(
+			attribute.Bool("query.viewer", params.Viewer != ""),
+			attribute.String("query.sort_by", params.SortBy),
+			attribute.String("query.order", params.Order),
+			attribute.Int("query.limit", params.Limit),
+			attribute.Int("query.offset", params.Offset),
+		))
+
+	result, err := u.uc.GetRootComments(ctx, params)
+	span.SetAttributes(
+		attribute.Int("comments.count", len(result.Comments)),
+		attribute.Int("comments.total", result.Total),
+	)
+	end(span, err)
+
+	return result, err
+}
+
+func (u *CommentUseCase) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetCommentsByIDs",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(ids))))
+
+	comments, err := u.uc.GetCommentsByIDs(ctx, ids)
+	end(span, err)
+
+	return comments, err
+}
+
+func (u *CommentUseCase) GetChildren(ctx context.Context, parentIDs []int64, params dto.GetCommentsParams) (map[int64][]entity.Comment, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetChildren",
+		trace.WithAttributes(
+			attribute.Int("parents.count", len(parentIDs)),
+			attribute.String("query.sort_by", params.SortBy),
+			attribute.String("query.order", params.Order),
+			attribute.Int("query.limit", params.Limit),
+			attribute.Int("query.offset", params.Offset),
+		))
+
+	children, err := u.uc.GetChildren(ctx, parentIDs, params)
+	end(span, err)
+
+	return children, err
+}
+
+func (u *CommentUseCase) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.CountReplies",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(ids))))
+
+	counts, err := u.uc.CountReplies(ctx, ids)
+	end(span, err)
+
+	return counts, err
+}
+
+func (u *CommentUseCase) GetReferences(ctx context.Context, ids []int64) (map[int64][]entity.Reference, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetReferences",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(ids))))
+
+	refs, err := u.uc.GetReferences(ctx, ids)
+	end(span, err)
+
+	return refs, err
+}
+
+func (u *CommentUseCase) GetAttachments(ctx context.Context, ids []int64) (map[int64][]entity.Attachment, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetAttachments",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(ids))))
+
+	attachments, err := u.uc.GetAttachments(ctx, ids)
+	end(span, err)
+
+	return attachments, err
+}
+
+func (u *CommentUseCase) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetLastSeen",
+		trace.WithAttributes(attribute.Int("comment.ids.count", len(rootIDs))))
+
+	lastSeen, err := u.uc.GetLastSeen(ctx, viewer, rootIDs)
+	end(span, err)
+
+	return lastSeen, err
+}
+
+func (u *CommentUseCase) GetThreadRoot(ctx context.Context, id int64) (entity.Comment, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetThreadRoot",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	root, err := u.uc.GetThreadRoot(ctx, id)
+	end(span, err)
+
+	return root, err
+}
+
+func (u *CommentUseCase) PinComment(ctx context.Context, id int64, order int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.PinComment",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.Int64("comment.pin_order", order)))
+
+	err := u.uc.PinComment(ctx, id, order)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) UnpinComment(ctx context.Context, id int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.UnpinComment",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := u.uc.UnpinComment(ctx, id)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.SetHighlighted",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.Bool("comment.highlighted", highlighted)))
+
+	err := u.uc.SetHighlighted(ctx, id, highlighted)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) LockComment(ctx context.Context, id int64, state, reason string) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.LockComment",
+		trace.WithAttributes(attribute.Int64("comment.id", id), attribute.String("comment.lock_state", state)))
+
+	err := u.uc.LockComment(ctx, id, state, reason)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) UnlockComment(ctx context.Context, id int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.UnlockComment",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := u.uc.UnlockComment(ctx, id)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.SetMaxDepth",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+	if maxDepth != nil {
+		span.SetAttributes(attribute.Int64("comment.max_depth", *maxDepth))
+	}
+
+	err := u.uc.SetMaxDepth(ctx, id, maxDepth)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) ArchiveInactive(ctx context.Context, inactiveFor time.Duration) (int, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.ArchiveInactive",
+		trace.WithAttributes(attribute.String("query.inactive_for", inactiveFor.String())))
+
+	n, err := u.uc.ArchiveInactive(ctx, inactiveFor)
+	span.SetAttributes(attribute.Int("roots.count", n))
+	end(span, err)
+
+	return n, err
+}
+
+func (u *CommentUseCase) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetNotifications",
+		trace.WithAttributes(
+			attribute.String("query.status", status),
+			attribute.Int64("query.before_id", beforeID),
+			attribute.Int("query.limit", limit),
+		))
+
+	ns, err := u.uc.GetNotifications(ctx, recipient, status, beforeID, limit)
+	span.SetAttributes(attribute.Int("notifications.count", len(ns)))
+	end(span, err)
+
+	return ns, err
+}
+
+func (u *CommentUseCase) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.MarkNotificationsRead",
+		trace.WithAttributes(attribute.Int("notification.ids.count", len(ids))))
+
+	n, err := u.uc.MarkNotificationsRead(ctx, recipient, ids)
+	span.SetAttributes(attribute.Int("notifications.count", n))
+	end(span, err)
+
+	return n, err
+}
+
+func (u *CommentUseCase) MuteThread(ctx context.Context, user string, id int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.MuteThread",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := u.uc.MuteThread(ctx, user, id)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) UnmuteThread(ctx context.Context, user string, id int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.UnmuteThread",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := u.uc.UnmuteThread(ctx, user, id)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.MarkSeen",
+		trace.WithAttributes(attribute.Int64("comment.id", rootID)))
+
+	lastSeenID, err := u.uc.MarkSeen(ctx, viewer, rootID)
+	end(span, err)
+
+	return lastSeenID, err
+}
+
+func (u *CommentUseCase) GetBacklinks(ctx context.Context, id int64, limit, offset int) ([]entity.Comment, int, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetBacklinks",
+		trace.WithAttributes(
+			attribute.Int64("comment.id", id),
+			attribute.Int("query.limit", limit),
+			attribute.Int("query.offset", offset),
+		))
+
+	comments, total, err := u.uc.GetBacklinks(ctx, id, limit, offset)
+	end(span, err)
+
+	return comments, total, err
+}
+
+// SubscribeNotifications - подписка живет дольше запроса, поэтому без span.
+func (u *CommentUseCase) SubscribeNotifications(ctx context.Context, recipient string) (<-chan entity.Notification, func()) {
+	return u.uc.SubscribeNotifications(ctx, recipient)
+}
+
+func (u *CommentUseCase) Subscribe(ctx context.Context, user string, id int64, email string) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.Subscribe",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := u.uc.Subscribe(ctx, user, id, email)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) Unsubscribe(ctx context.Context, user string, id int64) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.Unsubscribe",
+		trace.WithAttributes(attribute.Int64("comment.id", id)))
+
+	err := u.uc.Unsubscribe(ctx, user, id)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) GetSubscriptions(ctx context.Context, user string) ([]entity.Subscription, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetSubscriptions")
+
+	subs, err := u.uc.GetSubscriptions(ctx, user)
+	span.SetAttributes(attribute.Int("subscriptions.count", len(subs)))
+	end(span, err)
+
+	return subs, err
+}
+
+// UnsubscribeByToken - сам токен в атрибуты не попадает: по нему можно отписать пользователя.
+func (u *CommentUseCase) UnsubscribeByToken(ctx context.Context, token string) error {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.UnsubscribeByToken")
+
+	err := u.uc.UnsubscribeByToken(ctx, token)
+	end(span, err)
+
+	return err
+}
+
+func (u *CommentUseCase) SendDigests(ctx context.Context) (int, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.SendDigests")
+
+	n, err := u.uc.SendDigests(ctx)
+	span.SetAttributes(attribute.Int("digests.count", n))
+	end(span, err)
+
+	return n, err
+}
+
+// UploadAttachment - имя файла в атрибуты не попадает.
+func (u *CommentUseCase) UploadAttachment(ctx context.Context, uploader, filename string, r io.Reader) (entity.Attachment, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.UploadAttachment")
+
+	a, err := u.uc.UploadAttachment(ctx, uploader, filename, r)
+	span.SetAttributes(
+		attribute.Int64("attachment.id", a.ID),
+		attribute.String("attachment.content_type", a.ContentType),
+		attribute.Int64("attachment.size", a.Size),
+	)
+	end(span, err)
+
+	return a, err
+}
+
+// OpenAttachment - span заканчивается до отдачи файла клиенту.
+func (u *CommentUseCase) OpenAttachment(ctx context.Context, id int64, variant string, expires int64, sig string) (entity.Attachment, io.ReadCloser, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.OpenAttachment",
+		trace.WithAttributes(attribute.Int64("attachment.id", id), attribute.String("attachment.variant", variant)))
+
+	a, rc, err := u.uc.OpenAttachment(ctx, id, variant, expires, sig)
+	end(span, err)
+
+	return a, rc, err
+}
+
+func (u *CommentUseCase) CollectAttachments(ctx context.Context) (int, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.CollectAttachments")
+
+	n, err := u.uc.CollectAttachments(ctx)
+	span.SetAttributes(attribute.Int("attachments.count", n))
+	end(span, err)
+
+	return n, err
+}
+
+// ExportAuthor - имена автора и запросившего в атрибуты не попадают.
+func (u *CommentUseCase) ExportAuthor(ctx context.Context, author, actor, reason string) (entity.AuthorData, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.ExportAuthor")
+
+	data, err := u.uc.ExportAuthor(ctx, author, actor, reason)
+	span.SetAttributes(
+		attribute.Int("comments.count", len(data.Comments)),
+		attribute.Int("attachments.count", len(data.Attachments)),
+	)
+	end(span, err)
+
+	return data, err
+}
+
+func (u *CommentUseCase) EraseAuthor(ctx context.Context, author, mode, actor, reason string) (entity.PrivacyRequest, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.EraseAuthor")
+	span.SetAttributes(attribute.String("privacy.mode", mode))
+
+	p, err := u.uc.EraseAuthor(ctx, author, mode, actor, reason)
+	span.SetAttributes(attribute.Int("comments.count", p.Comments))
+	end(span, err)
+
+	return p, err
+}
+
+func (u *CommentUseCase) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	ctx, span := u.tracer.Start(ctx, "CommentUseCase.GetAuditLog")
+	span.SetAttributes(attribute.String("audit.action", f.Action), attribute.Int("query.limit", limit))
+
+	entries, err := u.uc.GetAuditLog(ctx, f, beforeID, limit)
+	span.SetAttributes(attribute.Int("entries.count", len(entries)))
+	end(span, err)
+
+	return entries, err
+}
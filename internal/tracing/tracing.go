@@ -0,0 +1,26 @@
+package tracing
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const _tracerName = "github.com/andreyxaxa/Comment-Tree/internal/tracing"
+
+// _expected - ответы клиенту, а не сбои: коммента нет, правила ветки запрещают операцию, ссылка подделана
+// или вложение нельзя прикрепить.
+var _expected = []error{errs.ErrRecordNotFound, errs.ErrLocked, errs.ErrArchived, errs.ErrMaxDepth, errs.ErrNotRoot, errs.ErrInvalidToken, errs.ErrInvalidAttachment}
+
+// end - закрывает спан; ошибки из _expected ошибкой спана не считаются.
+func end(span trace.Span, err error) {
+	if err != nil && !slices.ContainsFunc(_expected, func(e error) bool { return errors.Is(err, e) }) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
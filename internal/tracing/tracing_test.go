@@ -0,0 +1,124 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/tracing"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/tracer"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// span - поля, которые пишет экспортер file.
+type span struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		SpanID string
+	}
+	Status struct {
+		Code string
+	}
+}
+
+// run - создает и удаляет коммент через декораторы, как их собирает app.Run, и возвращает выгруженные спаны.
+func run(t *testing.T, ratio float64) []span {
+	t.Helper()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "traces.json")
+
+	tr, err := tracer.New(tracer.Exporter(tracer.ExporterFile), tracer.FilePath(path), tracer.SampleRatio(ratio))
+	if err != nil {
+		t.Fatalf("tracer.New: %v", err)
+	}
+
+	r := tracing.NewCommentRepo(memory.New(), tr.Provider)
+	l := logger.New("error")
+	uc := tracing.NewCommentUseCase(comment.New(r, webapi.NewCDN("", "", "", "", time.Second, l), l), tr.Provider)
+
+	c, err := uc.CreateComment(ctx, nil, "alice", "root", nil)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	if err = uc.DeleteCommentWithChildren(ctx, c.ID+1); !errors.Is(err, errs.ErrRecordNotFound) {
+		t.Fatalf("DeleteCommentWithChildren = %v, want ErrRecordNotFound", err)
+	}
+
+	if err = tr.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("os.Open: %v", err)
+	}
+	defer f.Close()
+
+	var spans []span
+
+	dec := json.NewDecoder(f)
+	for {
+		var s span
+		if err = dec.Decode(&s); errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decode span: %v", err)
+		}
+
+		spans = append(spans, s)
+	}
+
+	return spans
+}
+
+func TestSpans(t *testing.T) {
+	spans := run(t, 1)
+
+	byName := make(map[string]span)
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+
+	for _, name := range []string{
+		"CommentUseCase.CreateComment",
+		"CommentRepo.CreateComment",
+		"CommentUseCase.DeleteCommentWithChildren",
+		"CommentRepo.GetCommentPath",
+	} {
+		if _, ok := byName[name]; !ok {
+			t.Errorf("no span %q in %d exported", name, len(spans))
+		}
+	}
+
+	// спан репозитория - потомок спана usecase
+	parent, child := byName["CommentUseCase.CreateComment"], byName["CommentRepo.CreateComment"]
+	if child.SpanContext.TraceID != parent.SpanContext.TraceID || child.Parent.SpanID != parent.SpanContext.SpanID {
+		t.Errorf("CommentRepo.CreateComment is not a child of CommentUseCase.CreateComment")
+	}
+
+	// отсутствующий коммент - ответ клиенту, а не ошибка спана
+	if code := byName["CommentUseCase.DeleteCommentWithChildren"].Status.Code; code == "Error" {
+		t.Errorf("not found delete span status = %s, want Unset", code)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	if spans := run(t, 0); len(spans) != 0 {
+		t.Errorf("ratio 0 exported %d spans, want none", len(spans))
+	}
+}
@@ -0,0 +1,405 @@
+package comment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/media"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+const (
+	// _orphanBatch - сколько брошенных вложений удаляется за раз.
+	_orphanBatch = 100
+	// _maxFilename - длина имени файла в байтах.
+	_maxFilename = 255
+)
+
+// UploadAttachment - тип определяется по содержимому и должен быть разрешен.
+// У картинок сохраняются размеры и миниатюра; если картинка слишком большая для
+// декодирования (media.ErrTooLarge), она сохраняется без миниатюры.
+// Анонимные загрузки отклоняются: прикрепить их может только загрузивший, а анонимы друг от друга неотличимы.
+func (uc *CommentUseCase) UploadAttachment(ctx context.Context, uploader, filename string, r io.Reader) (entity.Attachment, error) {
+	if uc.blobs == nil {
+		return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment: %w", errs.ErrAttachmentsDisabled)
+	}
+
+	if uploader == "" {
+		return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - anonymous: %w", errs.ErrInvalidAttachment)
+	}
+
+	// на байт больше лимита - так видно, что файл в него не уложился
+	data, err := io.ReadAll(io.LimitReader(r, uc.attachmentLimits.MaxSize+1))
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - io.ReadAll: %w", err)
+	}
+
+	if int64(len(data)) > uc.attachmentLimits.MaxSize {
+		return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment: %w", errs.ErrFileTooLarge)
+	}
+
+	a := entity.Attachment{
+		Uploader:    uploader,
+		Filename:    cleanFilename(filename),
+		ContentType: media.Sniff(data[:min(len(data), media.SniffLen)]),
+		Size:        int64(len(data)),
+	}
+
+	if !slices.Contains(uc.attachmentLimits.Types, a.ContentType) {
+		return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - %s: %w", a.ContentType, errs.ErrUnsupportedType)
+	}
+
+	var thumb []byte
+
+	if media.IsImage(a.ContentType) {
+		// битая картинка с правильной сигнатурой - тоже неподдерживаемый файл
+		a.Width, a.Height, err = media.Dimensions(data, a.ContentType)
+		if err != nil {
+			return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - media.Dimensions: %w: %w", errs.ErrUnsupportedType, err)
+		}
+
+		thumb, _, err = media.Thumbnail(data, a.ContentType, uc.attachmentLimits.ThumbnailSize)
+		if err != nil && !errors.Is(err, media.ErrTooLarge) {
+			return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - media.Thumbnail: %w: %w", errs.ErrUnsupportedType, err)
+		}
+	}
+
+	a.Key = newBlobKey()
+
+	err = uc.blobs.Put(ctx, a.Key, bytes.NewReader(data), a.Size, a.ContentType)
+	if err != nil {
+		return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - uc.blobs.Put: %w", err)
+	}
+
+	if thumb != nil {
+		a.ThumbnailKey = a.Key + "-thumb"
+
+		err = uc.blobs.Put(ctx, a.ThumbnailKey, bytes.NewReader(thumb), int64(len(thumb)), media.ThumbnailType(a.ContentType))
+		if err != nil {
+			uc.deleteBlobs(ctx, a)
+			return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - uc.blobs.Put thumbnail: %w", err)
+		}
+	}
+
+	created, err := uc.repo.CreateAttachment(ctx, a)
+	if err != nil {
+		uc.deleteBlobs(ctx, a)
+		return entity.Attachment{}, fmt.Errorf("CommentUseCase - UploadAttachment - uc.repo.CreateAttachment: %w", err)
+	}
+
+	uc.signAttachment(&created, time.Now())
+
+	return created, nil
+}
+
+// OpenAttachment - файл по подписанной ссылке: variant - entity.AttachmentOriginal или entity.AttachmentThumbnail.
+// Вложение удаленного коммента недоступно, даже пока его файлы не удалены.
+// У миниатюры в ответе ContentType - ее собственный тип, а Size - 0.
+func (uc *CommentUseCase) OpenAttachment(ctx context.Context, id int64, variant string, expires int64, sig string) (entity.Attachment, io.ReadCloser, error) {
+	if uc.blobs == nil {
+		return entity.Attachment{}, nil, fmt.Errorf("CommentUseCase - OpenAttachment: %w", errs.ErrAttachmentsDisabled)
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, uc.attachmentMAC(id, variant, expires)) || time.Now().Unix() >= expires {
+		return entity.Attachment{}, nil, fmt.Errorf("CommentUseCase - OpenAttachment: %w", errs.ErrInvalidToken)
+	}
+
+	a, err := uc.repo.GetAttachment(ctx, id)
+	if err != nil {
+		return entity.Attachment{}, nil, fmt.Errorf("CommentUseCase - OpenAttachment - uc.repo.GetAttachment: %w", err)
+	}
+
+	if !a.CommentID.Valid && a.AttachedAt.Valid {
+		return entity.Attachment{}, nil, fmt.Errorf("CommentUseCase - OpenAttachment - comment deleted: %w", errs.ErrRecordNotFound)
+	}
+
+	key := a.Key
+	if variant == entity.AttachmentThumbnail {
+		if a.ThumbnailKey == "" {
+			return entity.Attachment{}, nil, fmt.Errorf("CommentUseCase - OpenAttachment - no thumbnail: %w", errs.ErrRecordNotFound)
+		}
+
+		key = a.ThumbnailKey
+		a.ContentType = media.ThumbnailType(a.ContentType)
+		a.Size = 0
+	}
+
+	rc, err := uc.blobs.Get(ctx, key)
+	if err != nil {
+		return entity.Attachment{}, nil, fmt.Errorf("CommentUseCase - OpenAttachment - uc.blobs.Get: %w", err)
+	}
+
+	return a, rc, nil
+}
+
+// CollectAttachments - удаляет файлы и записи вложений удаленных комментов
+// и не прикрепленных за uploadTTL загрузок, возвращает, сколько удалено.
+func (uc *CommentUseCase) CollectAttachments(ctx context.Context) (int, error) {
+	if uc.blobs == nil {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-uc.attachmentUploadTTL)
+	collected := 0
+
+	for {
+		orphans, err := uc.repo.GetOrphanAttachments(ctx, before, _orphanBatch)
+		if err != nil {
+			return collected, fmt.Errorf("CommentUseCase - CollectAttachments - uc.repo.GetOrphanAttachments: %w", err)
+		}
+
+		if len(orphans) == 0 {
+			return collected, nil
+		}
+
+		// сначала файлы: запись без файла найдется в следующий раз, файл без записи - никогда
+		err = uc.dropAttachments(ctx, orphans)
+		if err != nil {
+			return collected, fmt.Errorf("CommentUseCase - CollectAttachments - uc.dropAttachments: %w", err)
+		}
+
+		collected += len(orphans)
+	}
+}
+
+// checkAttachments - ids можно прикрепить к новому комменту author. Возвращает ids без повторов.
+// У анонимных комментов вложений нет: иначе любой аноним мог бы прикрепить чужую анонимную загрузку.
+func (uc *CommentUseCase) checkAttachments(ctx context.Context, author string, ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if uc.blobs == nil {
+		return nil, errs.ErrAttachmentsDisabled
+	}
+
+	if author == "" {
+		return nil, fmt.Errorf("anonymous comment: %w", errs.ErrInvalidAttachment)
+	}
+
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+	if len(ids) > uc.attachmentLimits.MaxPerComment {
+		return nil, errs.ErrTooManyAttachments
+	}
+
+	for _, id := range ids {
+		a, err := uc.repo.GetAttachment(ctx, id)
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return nil, fmt.Errorf("attachment %d: %w", id, errs.ErrInvalidAttachment)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("uc.repo.GetAttachment: %w", err)
+		}
+
+		if a.Uploader != author || a.CommentID.Valid || a.AttachedAt.Valid {
+			return nil, fmt.Errorf("attachment %d: %w", id, errs.ErrInvalidAttachment)
+		}
+	}
+
+	return ids, nil
+}
+
+// signNew - вложения нового коммента сразу есть в ответе на его создание. Прикреплены они
+// в транзакции вставки, так что ошибка чтения только пишется в лог: в дереве они будут.
+func (uc *CommentUseCase) signNew(ctx context.Context, c *entity.Comment, attached int) {
+	if attached == 0 {
+		return
+	}
+
+	comments := []entity.Comment{*c}
+
+	err := uc.attachAttachments(ctx, comments)
+	if err != nil {
+		uc.l.WithContext(ctx).Error(fmt.Errorf("CommentUseCase - signNew - uc.attachAttachments: %w", err))
+
+		return
+	}
+
+	c.Attachments = comments[0].Attachments
+}
+
+// attachAttachments - Attachments с подписанными ссылками у каждого коммента дерева.
+func (uc *CommentUseCase) attachAttachments(ctx context.Context, comments []entity.Comment) error {
+	if uc.blobs == nil {
+		return nil
+	}
+
+	ids := make([]int64, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+
+	attachments, err := uc.repo.GetAttachments(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("uc.repo.GetAttachments: %w", err)
+	}
+
+	now := time.Now()
+
+	for i := range comments {
+		comments[i].Attachments = attachments[comments[i].ID]
+		for j := range comments[i].Attachments {
+			uc.signAttachment(&comments[i].Attachments[j], now)
+		}
+	}
+
+	return nil
+}
+
+// subtreeAttachments - вложения комментов поддерева, их нужно найти до его удаления.
+func (uc *CommentUseCase) subtreeAttachments(ctx context.Context, subtree []entity.Comment) ([]entity.Attachment, error) {
+	if uc.blobs == nil {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(subtree))
+	for i, c := range subtree {
+		ids[i] = c.ID
+	}
+
+	byComment, err := uc.repo.GetAttachments(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("uc.repo.GetAttachments: %w", err)
+	}
+
+	var attachments []entity.Attachment
+	for _, as := range byComment {
+		attachments = append(attachments, as...)
+	}
+
+	return attachments, nil
+}
+
+// collect - вложения удаленного поддерева удаляются сразу; при ошибке их подберет CollectAttachments.
+func (uc *CommentUseCase) collect(ctx context.Context, attachments []entity.Attachment) {
+	err := uc.dropAttachments(ctx, attachments)
+	if err != nil {
+		uc.l.WithContext(ctx).Error(fmt.Errorf("CommentUseCase - collect - uc.dropAttachments: %w", err))
+	}
+}
+
+func (uc *CommentUseCase) dropAttachments(ctx context.Context, attachments []entity.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, 2*len(attachments))
+	ids := make([]int64, len(attachments))
+
+	for i, a := range attachments {
+		ids[i] = a.ID
+		keys = append(keys, a.Key)
+		if a.ThumbnailKey != "" {
+			keys = append(keys, a.ThumbnailKey)
+		}
+	}
+
+	err := uc.blobs.Delete(ctx, keys...)
+	if err != nil {
+		return fmt.Errorf("uc.blobs.Delete: %w", err)
+	}
+
+	err = uc.repo.DeleteAttachments(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("uc.repo.DeleteAttachments: %w", err)
+	}
+
+	return nil
+}
+
+// deleteBlobs - откат загрузки, ошибка только пишется в лог.
+func (uc *CommentUseCase) deleteBlobs(ctx context.Context, a entity.Attachment) {
+	keys := []string{a.Key}
+	if a.ThumbnailKey != "" {
+		keys = append(keys, a.ThumbnailKey)
+	}
+
+	err := uc.blobs.Delete(ctx, keys...)
+	if err != nil {
+		uc.l.WithContext(ctx).Error(fmt.Errorf("CommentUseCase - deleteBlobs - uc.blobs.Delete: %w", err))
+	}
+}
+
+// signAttachment - срок ссылок выровнен по окнам urlTTL, чтобы в пределах окна они не менялись
+// (и ответы с ними кэшировались), поэтому ссылка действует от urlTTL до 2*urlTTL.
+func (uc *CommentUseCase) signAttachment(a *entity.Attachment, now time.Time) {
+	expires := AttachmentURLExpiry(now, uc.attachmentURLTTL)
+
+	a.URL = uc.attachmentURL(a.ID, entity.AttachmentOriginal, expires)
+	if a.ThumbnailKey != "" {
+		a.ThumbnailURL = uc.attachmentURL(a.ID, entity.AttachmentThumbnail, expires)
+	}
+}
+
+// AttachmentURLExpiry - когда истекают ссылки на вложения, подписанные в now.
+func AttachmentURLExpiry(now time.Time, ttl time.Duration) int64 {
+	window := max(int64(ttl/time.Second), 1)
+
+	return (now.Unix()/window + 2) * window
+}
+
+func (uc *CommentUseCase) attachmentURL(id int64, variant string, expires int64) string {
+	path := "/v1/attachments/" + strconv.FormatInt(id, 10)
+	if variant == entity.AttachmentThumbnail {
+		path += "/thumbnail"
+	}
+
+	return path + "?expires=" + strconv.FormatInt(expires, 10) +
+		"&sig=" + base64.RawURLEncoding.EncodeToString(uc.attachmentMAC(id, variant, expires))
+}
+
+func (uc *CommentUseCase) attachmentMAC(id int64, variant string, expires int64) []byte {
+	h := hmac.New(sha256.New, uc.attachmentSecret)
+	fmt.Fprintf(h, "attachment:%d:%s:%d", id, variant, expires)
+
+	return h.Sum(nil)
+}
+
+func newBlobKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// cleanFilename - имя файла без каталогов и управляющих символов, не длиннее _maxFilename байт.
+func cleanFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+
+		return r
+	}, name))
+
+	for len(name) > _maxFilename {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+
+	return name
+}
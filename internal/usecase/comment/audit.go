@@ -0,0 +1,17 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+func (uc *CommentUseCase) GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error) {
+	entries, err := uc.repo.GetAuditLog(ctx, f, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetAuditLog - uc.repo.GetAuditLog: %w", err)
+	}
+
+	return entries, nil
+}
@@ -3,47 +3,183 @@ package comment
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/andreyxaxa/Comment-Tree/internal/dto"
 	"github.com/andreyxaxa/Comment-Tree/internal/entity"
 	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/pubsub"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
 )
 
 type CommentUseCase struct {
 	repo repo.CommentRepo
+	cdn  repo.CDNWebAPI
+	// l - ошибки, которые не возвращаются вызывающему: сам коммент к этому моменту уже сохранен или удален
+	l logger.Interface
+
+	maxDepth    int
+	depthPolicy string
+
+	// hub - уведомления подключенным получателям
+	hub *pubsub.Hub[entity.Notification]
+
+	// mailer - отправка дайджестов (nil - не отправляются), digestSecret подписывает ссылки отписки
+	mailer       repo.Mailer
+	digestSecret []byte
+	digestURL    string
+	digestPeriod time.Duration
+
+	// blobs - файлы вложений (nil - вложения выключены), attachmentSecret подписывает ссылки на них
+	blobs               repo.BlobStore
+	attachmentSecret    []byte
+	attachmentLimits    AttachmentLimits
+	attachmentURLTTL    time.Duration
+	attachmentUploadTTL time.Duration
 }
 
-func New(r repo.CommentRepo) *CommentUseCase {
-	return &CommentUseCase{
-		repo: r,
+func New(r repo.CommentRepo, cdn repo.CDNWebAPI, l logger.Interface, opts ...Option) *CommentUseCase {
+	uc := &CommentUseCase{
+		repo:        r,
+		cdn:         cdn,
+		l:           l,
+		depthPolicy: entity.DepthPolicyFlatten,
+		hub:         pubsub.New[entity.Notification](),
 	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
 }
 
-func (uc *CommentUseCase) CreateComment(ctx context.Context, parentID *int64, content string) (entity.Comment, error) {
+// CreateComment - ответ глубже лимита дерева либо отклоняется, либо переносится к предку
+// на последнем разрешенном уровне и запоминает, на какой коммент отвечали (ReplyToID).
+// attachmentIDs - загруженные author и еще не прикрепленные вложения.
+func (uc *CommentUseCase) CreateComment(ctx context.Context, parentID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error) {
+	var (
+		replyToID *int64
+		replyTo   *entity.Comment
+	)
+
 	if parentID != nil {
-		err := uc.repo.CommentExists(ctx, *parentID)
+		path, err := uc.repo.GetCommentPath(ctx, *parentID)
+		if err != nil {
+			return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment - uc.repo.GetCommentPath: %w", err)
+		}
+
+		chain, err := uc.chain(ctx, path)
 		if err != nil {
-			return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment - uc.repo.CommentExists: %w", err)
+			return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment - uc.chain: %w", err)
 		}
+
+		replyTo = &chain[len(chain)-1]
+
+		switch state, _ := entity.EffectiveLock(chain...); state {
+		case entity.LockArchived:
+			return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment: %w", errs.ErrArchived)
+		case entity.LockLocked:
+			return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment: %w", errs.ErrLocked)
+		}
+
+		// глубина нового коммента - len(path), предок path[limit-1] стоит на уровне limit-1
+		if limit := entity.DepthLimit(chain[0], uc.maxDepth); limit > 0 && len(path) > limit {
+			if uc.depthPolicy == entity.DepthPolicyReject {
+				return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment: %w", errs.ErrMaxDepth)
+			}
+
+			replyToID = parentID
+			parentID = &path[limit-1]
+		}
+	}
+
+	attachmentIDs, err := uc.checkAttachments(ctx, author, attachmentIDs)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment - uc.checkAttachments: %w", err)
 	}
 
-	c, err := uc.repo.CreateComment(ctx, parentID, content)
+	c, err := uc.repo.CreateComment(ctx, parentID, replyToID, author, content, attachmentIDs)
 	if err != nil {
 		return entity.Comment{}, fmt.Errorf("CommentUseCase - CreateComment - uc.repo.CreateComment: %w", err)
 	}
 
+	// новый коммент меняет поддеревья всех своих предков
+	keys := []string{entity.SearchSurrogateKey}
+	if parentID == nil {
+		keys = append(keys, entity.RootsSurrogateKey)
+	}
+	for _, id := range c.Path {
+		if id != c.ID {
+			keys = append(keys, entity.CommentSurrogateKey(id))
+		}
+	}
+
+	uc.signNew(ctx, &c, len(attachmentIDs))
+	uc.purge(ctx, keys)
+	uc.notify(ctx, c, replyTo)
+
 	return c, nil
 }
 
 func (uc *CommentUseCase) DeleteCommentWithChildren(ctx context.Context, id int64) error {
-	err := uc.repo.DeleteCommentWithChildren(ctx, id)
+	// предки и потомки нужны до удаления: их ответы на CDN тоже устаревают
+	path, err := uc.repo.GetCommentPath(ctx, id)
 	if err != nil {
-		return fmt.Errorf("CommentUseCase - DeleteCommentWithChildren - uc.repo.DeleteCommentWithChildren: %w", err)
+		return fmt.Errorf("CommentUseCase - DeleteCommentWithChildren - uc.repo.GetCommentPath: %w", err)
+	}
+
+	subtree, err := uc.repo.GetCommentWithChildren(ctx, id, entity.ChildSortOldest)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - DeleteCommentWithChildren - uc.repo.GetCommentWithChildren: %w", err)
+	}
+
+	// удалять нельзя ни внутри архивной ветки, ни ветку, в которой есть архивная
+	err = uc.checkWritable(ctx, path)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - DeleteCommentWithChildren - uc.checkWritable: %w", err)
+	}
+
+	if state, _ := entity.EffectiveLock(subtree...); state == entity.LockArchived {
+		return fmt.Errorf("CommentUseCase - DeleteCommentWithChildren: %w", errs.ErrArchived)
+	}
+
+	_, err = uc.deleteSubtree(ctx, path, subtree)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - DeleteCommentWithChildren - uc.deleteSubtree: %w", err)
 	}
 
 	return nil
 }
 
+// deleteSubtree - удаляет поддерево subtree коммента с путем path без проверки блокировок
+// и сбрасывает все, что от него зависело. Возвращает, сколько комментов удалило хранилище.
+func (uc *CommentUseCase) deleteSubtree(ctx context.Context, path []int64, subtree []entity.Comment) (int, error) {
+	// ссылки на удаляемые комменты из других веток становятся недоступными
+	referrers, err := uc.referrerPaths(ctx, subtree)
+	if err != nil {
+		return 0, fmt.Errorf("uc.referrerPaths: %w", err)
+	}
+
+	// после удаления вложения теряют коммент, а вместе с ним и способ их найти
+	attachments, err := uc.subtreeAttachments(ctx, subtree)
+	if err != nil {
+		return 0, fmt.Errorf("uc.subtreeAttachments: %w", err)
+	}
+
+	deleted, err := uc.repo.DeleteCommentWithChildren(ctx, path[len(path)-1])
+	if err != nil {
+		return 0, fmt.Errorf("uc.repo.DeleteCommentWithChildren: %w", err)
+	}
+
+	uc.purgeSubtree(ctx, path, subtree)
+	uc.purgeReferrers(ctx, referrers)
+	uc.collect(ctx, attachments)
+
+	return deleted, nil
+}
+
 func (uc *CommentUseCase) GetComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error) {
 	var comments []entity.Comment
 	var total int
@@ -66,11 +202,33 @@ func (uc *CommentUseCase) GetComments(ctx context.Context, params dto.GetComment
 
 	// 2. если указан конкретный родитель - получаем его дерево
 	if params.ParentID != nil {
-		comments, err = uc.repo.GetCommentWithChildren(ctx, *params.ParentID)
+		comments, err = uc.repo.GetCommentWithChildren(ctx, *params.ParentID, params.ChildSort)
 		if err != nil {
 			return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.repo.GetCommentWithChildren: %w", err)
 		}
 
+		err = uc.inheritLock(ctx, &comments[0])
+		if err != nil {
+			return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.inheritLock: %w", err)
+		}
+
+		if params.Viewer != "" {
+			err = uc.markSubtreeUnread(ctx, params.Viewer, comments)
+			if err != nil {
+				return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.markSubtreeUnread: %w", err)
+			}
+		}
+
+		err = uc.attachReferences(ctx, comments)
+		if err != nil {
+			return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.attachReferences: %w", err)
+		}
+
+		err = uc.attachAttachments(ctx, comments)
+		if err != nil {
+			return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.attachAttachments: %w", err)
+		}
+
 		return dto.PaginatedComments{
 			Comments: comments,
 			Total:    len(comments),
@@ -80,7 +238,7 @@ func (uc *CommentUseCase) GetComments(ctx context.Context, params dto.GetComment
 	}
 
 	// 3. иначе - получаем корневые комменты
-	roots, total, err := uc.repo.GetRootComments(ctx, params.SortBy, params.Order, params.Limit, params.Offset)
+	roots, total, err := uc.repo.GetRootComments(ctx, params.Viewer, params.SortBy, params.Order, params.Limit, params.Offset)
 	if err != nil {
 		return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.repo.GetRootComments: %w", err)
 	}
@@ -92,15 +250,279 @@ func (uc *CommentUseCase) GetComments(ctx context.Context, params dto.GetComment
 	}
 
 	// 3.2 получаем их деревья
-	comments, err = uc.repo.GetTreesForRoots(ctx, rootIDs)
+	comments, err = uc.repo.GetTreesForRoots(ctx, rootIDs, params.ChildSort)
 	if err != nil {
 		return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.repo.GetTreesForRoots: %w", err)
 	}
 
+	// 3.3 отмечаем непрочитанное по визитам, пришедшим вместе с корнями
+	if params.Viewer != "" {
+		markTreesUnread(params.Viewer, roots, comments)
+	}
+
+	// 3.4 ссылки читаются отдельно от деревьев: кэш деревьев не знает об удалении их целей
+	err = uc.attachReferences(ctx, comments)
+	if err != nil {
+		return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.attachReferences: %w", err)
+	}
+
+	// 3.5 вложения тоже: ссылки на их файлы подписываются на время
+	err = uc.attachAttachments(ctx, comments)
+	if err != nil {
+		return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetComments - uc.attachAttachments: %w", err)
+	}
+
 	return dto.PaginatedComments{
-		Comments: comments,
+		Comments: orderByRoots(comments, rootIDs),
 		Total:    total,
 		Limit:    params.Limit,
 		Offset:   params.Offset,
 	}, nil
 }
+
+func (uc *CommentUseCase) GetRootComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error) {
+	roots, total, err := uc.repo.GetRootComments(ctx, params.Viewer, params.SortBy, params.Order, params.Limit, params.Offset)
+	if err != nil {
+		return dto.PaginatedComments{}, fmt.Errorf("CommentUseCase - GetRootComments - uc.repo.GetRootComments: %w", err)
+	}
+
+	return dto.PaginatedComments{
+		Comments: roots,
+		Total:    total,
+		Limit:    params.Limit,
+		Offset:   params.Offset,
+	}, nil
+}
+
+func (uc *CommentUseCase) GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error) {
+	comments, err := uc.repo.GetCommentsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetCommentsByIDs - uc.repo.GetCommentsByIDs: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (uc *CommentUseCase) GetThreadRoot(ctx context.Context, id int64) (entity.Comment, error) {
+	path, err := uc.repo.GetCommentPath(ctx, id)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentUseCase - GetThreadRoot - uc.repo.GetCommentPath: %w", err)
+	}
+
+	comments, err := uc.repo.GetCommentsByIDs(ctx, path[:1])
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("CommentUseCase - GetThreadRoot - uc.repo.GetCommentsByIDs: %w", err)
+	}
+
+	root, ok := comments[path[0]]
+	if !ok {
+		return entity.Comment{}, fmt.Errorf("CommentUseCase - GetThreadRoot: %w", errs.ErrRecordNotFound)
+	}
+
+	return root, nil
+}
+
+func (uc *CommentUseCase) GetChildren(ctx context.Context, parentIDs []int64, params dto.GetCommentsParams) (map[int64][]entity.Comment, error) {
+	children, err := uc.repo.GetChildren(ctx, parentIDs, params.SortBy, params.Order, params.Limit, params.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetChildren - uc.repo.GetChildren: %w", err)
+	}
+
+	return children, nil
+}
+
+func (uc *CommentUseCase) CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error) {
+	counts, err := uc.repo.CountReplies(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - CountReplies - uc.repo.CountReplies: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (uc *CommentUseCase) GetReferences(ctx context.Context, ids []int64) (map[int64][]entity.Reference, error) {
+	refs, err := uc.repo.GetReferences(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetReferences - uc.repo.GetReferences: %w", err)
+	}
+
+	return refs, nil
+}
+
+func (uc *CommentUseCase) GetAttachments(ctx context.Context, ids []int64) (map[int64][]entity.Attachment, error) {
+	if uc.blobs == nil {
+		return map[int64][]entity.Attachment{}, nil
+	}
+
+	attachments, err := uc.repo.GetAttachments(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetAttachments - uc.repo.GetAttachments: %w", err)
+	}
+
+	now := time.Now()
+
+	for _, as := range attachments {
+		for i := range as {
+			uc.signAttachment(&as[i], now)
+		}
+	}
+
+	return attachments, nil
+}
+
+func (uc *CommentUseCase) GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error) {
+	lastSeen, err := uc.repo.GetLastSeen(ctx, viewer, rootIDs)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetLastSeen - uc.repo.GetLastSeen: %w", err)
+	}
+
+	return lastSeen, nil
+}
+
+func (uc *CommentUseCase) PinComment(ctx context.Context, id int64, order int64) error {
+	path, err := uc.repo.GetCommentPath(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - PinComment - uc.repo.GetCommentPath: %w", err)
+	}
+
+	err = uc.checkWritable(ctx, path)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - PinComment - uc.checkWritable: %w", err)
+	}
+
+	err = uc.repo.SetPinOrder(ctx, id, &order)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - PinComment - uc.repo.SetPinOrder: %w", err)
+	}
+
+	uc.purgeChanged(ctx, path)
+
+	return nil
+}
+
+func (uc *CommentUseCase) UnpinComment(ctx context.Context, id int64) error {
+	path, err := uc.repo.GetCommentPath(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - UnpinComment - uc.repo.GetCommentPath: %w", err)
+	}
+
+	err = uc.checkWritable(ctx, path)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - UnpinComment - uc.checkWritable: %w", err)
+	}
+
+	err = uc.repo.SetPinOrder(ctx, id, nil)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - UnpinComment - uc.repo.SetPinOrder: %w", err)
+	}
+
+	uc.purgeChanged(ctx, path)
+
+	return nil
+}
+
+func (uc *CommentUseCase) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	path, err := uc.repo.GetCommentPath(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - SetHighlighted - uc.repo.GetCommentPath: %w", err)
+	}
+
+	err = uc.checkWritable(ctx, path)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - SetHighlighted - uc.checkWritable: %w", err)
+	}
+
+	err = uc.repo.SetHighlighted(ctx, id, highlighted)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - SetHighlighted - uc.repo.SetHighlighted: %w", err)
+	}
+
+	uc.purgeChanged(ctx, path)
+
+	return nil
+}
+
+// SetMaxDepth - лимит глубины задается дереву целиком, поэтому только у корня.
+// Уже существующие ответы глубже лимита не переносятся.
+func (uc *CommentUseCase) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	path, err := uc.repo.GetCommentPath(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - SetMaxDepth - uc.repo.GetCommentPath: %w", err)
+	}
+
+	if len(path) != 1 {
+		return fmt.Errorf("CommentUseCase - SetMaxDepth: %w", errs.ErrNotRoot)
+	}
+
+	err = uc.checkWritable(ctx, path)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - SetMaxDepth - uc.checkWritable: %w", err)
+	}
+
+	err = uc.repo.SetMaxDepth(ctx, id, maxDepth)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - SetMaxDepth - uc.repo.SetMaxDepth: %w", err)
+	}
+
+	uc.purgeChanged(ctx, path)
+
+	return nil
+}
+
+// purgeChanged - изменился сам коммент с путем path: устаревают поддеревья его и всех предков,
+// результаты поиска, а у корня - еще и списки корней.
+func (uc *CommentUseCase) purgeChanged(ctx context.Context, path []int64) {
+	keys := []string{entity.SearchSurrogateKey}
+	if len(path) == 1 {
+		keys = append(keys, entity.RootsSurrogateKey)
+	}
+	for _, id := range path {
+		keys = append(keys, entity.CommentSurrogateKey(id))
+	}
+
+	uc.purge(ctx, keys)
+}
+
+// purgeSubtree - изменилось все поддерево коммента с путем path: устаревают поддеревья
+// предков и всех комментов subtree, результаты поиска, а у корня - еще и списки корней.
+func (uc *CommentUseCase) purgeSubtree(ctx context.Context, path []int64, subtree []entity.Comment) {
+	keys := []string{entity.SearchSurrogateKey}
+	if len(path) == 1 {
+		keys = append(keys, entity.RootsSurrogateKey)
+	}
+	for _, ancestorID := range path[:len(path)-1] {
+		keys = append(keys, entity.CommentSurrogateKey(ancestorID))
+	}
+	for _, c := range subtree {
+		keys = append(keys, entity.CommentSurrogateKey(c.ID))
+	}
+
+	uc.purge(ctx, keys)
+}
+
+// orderByRoots - деревья в порядке страницы корней (sort_by/order),
+// внутри дерева порядок из хранилища не меняется.
+func orderByRoots(comments []entity.Comment, rootIDs []int64) []entity.Comment {
+	trees := make(map[int64][]entity.Comment, len(rootIDs))
+	for _, c := range comments {
+		rootID := c.ID
+		if len(c.Path) > 0 {
+			rootID = c.Path[0]
+		}
+
+		trees[rootID] = append(trees[rootID], c)
+	}
+
+	ordered := make([]entity.Comment, 0, len(comments))
+	for _, id := range rootIDs {
+		ordered = append(ordered, trees[id]...)
+	}
+
+	return ordered
+}
+
+// purge - сброс CDN не откатывает уже сохраненное изменение,
+// ошибку логирует сама реализация CDNWebAPI.
+func (uc *CommentUseCase) purge(ctx context.Context, keys []string) {
+	_ = uc.cdn.Purge(ctx, keys)
+}
@@ -0,0 +1,265 @@
+package comment
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+const (
+	// _digestBatch - сколько подписчиков обрабатывается за раз, деревья кэшируются в пределах пачки.
+	_digestBatch = 100
+	// _digestThreadLimit - сколько новых комментов одной ветки попадает в письмо,
+	// остальные только упоминаются числом.
+	_digestThreadLimit = 20
+	// _digestSnippet - длина текста коммента в письме в символах.
+	_digestSnippet = 500
+)
+
+type (
+	digestData struct {
+		Subscriber     string
+		Count          int
+		Threads        []digestThread
+		UnsubscribeURL string
+	}
+
+	digestThread struct {
+		Root           string
+		Comments       []digestComment
+		More           int
+		UnsubscribeURL string
+	}
+
+	digestComment struct {
+		Author    string
+		Content   string
+		CreatedAt time.Time
+	}
+)
+
+var (
+	_digestText = template.Must(template.New("digest").Parse(`Hi {{.Subscriber}},
+
+there {{if eq .Count 1}}is 1 new comment{{else}}are {{.Count}} new comments{{end}} in threads you follow.
+{{range .Threads}}
+== {{.Root}} ==
+{{range .Comments}}
+{{with .Author}}{{.}}{{else}}anonymous{{end}}, {{.CreatedAt.Format "2006-01-02 15:04"}} UTC:
+{{.Content}}
+{{end}}{{if .More}}
+...and {{.More}} more.
+{{end}}
+Unsubscribe from this thread: {{.UnsubscribeURL}}
+{{end}}
+Unsubscribe from all threads: {{.UnsubscribeURL}}
+`))
+
+	_digestHTML = htmltemplate.Must(htmltemplate.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<p>Hi {{.Subscriber}},</p>
+<p>there {{if eq .Count 1}}is 1 new comment{{else}}are {{.Count}} new comments{{end}} in threads you follow.</p>
+{{range .Threads}}
+<h3>{{.Root}}</h3>
+{{range .Comments}}
+<p><b>{{with .Author}}{{.}}{{else}}anonymous{{end}}</b>, {{.CreatedAt.Format "2006-01-02 15:04"}} UTC<br>
+<span style="white-space: pre-wrap">{{.Content}}</span></p>
+{{end}}{{if .More}}<p>...and {{.More}} more.</p>{{end}}
+<p><small><a href="{{.UnsubscribeURL}}">Unsubscribe from this thread</a></small></p>
+{{end}}
+<hr>
+<p><small><a href="{{.UnsubscribeURL}}">Unsubscribe from all threads</a></small></p>
+</body>
+</html>
+`))
+)
+
+// SendDigests - каждому подписчику, которому пора (не было дайджеста дольше digestPeriod),
+// отправляет одно письмо с новыми комментами во всех его ветках и возвращает число писем.
+// Свои комменты в дайджест не попадают. Ошибка одного подписчика не останавливает остальных,
+// он получит дайджест при следующем запуске. Запускать нужно на одном экземпляре сервиса,
+// иначе письмо может уйти дважды.
+func (uc *CommentUseCase) SendDigests(ctx context.Context) (int, error) {
+	if uc.mailer == nil {
+		return 0, nil
+	}
+
+	now := time.Now().UTC()
+
+	var (
+		sent   int
+		failed []error
+		cursor string
+	)
+
+	for {
+		subscribers, err := uc.repo.GetDueSubscribers(ctx, now.Add(-uc.digestPeriod), cursor, _digestBatch)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("uc.repo.GetDueSubscribers: %w", err))
+			break
+		}
+
+		// одна ветка нужна многим подписчикам, дерево читается раз на пачку
+		trees := make(map[int64][]entity.Comment)
+
+		for _, subscriber := range subscribers {
+			ok, err := uc.sendDigest(ctx, subscriber, now, trees)
+			if err != nil {
+				failed = append(failed, fmt.Errorf("uc.sendDigest(%s): %w", subscriber, err))
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+
+		if len(subscribers) < _digestBatch || ctx.Err() != nil {
+			break
+		}
+		cursor = subscribers[len(subscribers)-1]
+	}
+
+	if len(failed) > 0 {
+		return sent, fmt.Errorf("CommentUseCase - SendDigests: %w", errors.Join(failed...))
+	}
+
+	return sent, nil
+}
+
+// sendDigest - письмо уходит, только если есть новые комменты, но позиции подписок сдвигаются в любом случае.
+func (uc *CommentUseCase) sendDigest(ctx context.Context, subscriber string, now time.Time, trees map[int64][]entity.Comment) (bool, error) {
+	subs, err := uc.repo.GetSubscriptions(ctx, subscriber)
+	if err != nil {
+		return false, fmt.Errorf("uc.repo.GetSubscriptions: %w", err)
+	}
+
+	if len(subs) == 0 {
+		return false, nil
+	}
+
+	data := digestData{
+		Subscriber:     subscriber,
+		UnsubscribeURL: uc.unsubscribeURL(subscriber, 0),
+	}
+	lastIDs := make(map[int64]int64, len(subs))
+	// подписки на ветку и ее предка не должны показывать один коммент дважды
+	seen := make(map[int64]bool)
+
+	for _, s := range subs {
+		tree, ok := trees[s.CommentID]
+		if !ok {
+			tree, err = uc.repo.GetCommentWithChildren(ctx, s.CommentID, entity.ChildSortOldest)
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				// коммент удален после GetSubscriptions, подписка ушла вместе с ним
+				continue
+			}
+			if err != nil {
+				return false, fmt.Errorf("uc.repo.GetCommentWithChildren: %w", err)
+			}
+			trees[s.CommentID] = tree
+		}
+
+		last := s.LastCommentID
+
+		var fresh []entity.Comment
+		for _, c := range tree {
+			if c.ID <= s.LastCommentID {
+				continue
+			}
+			last = max(last, c.ID)
+
+			if c.Author != subscriber && !seen[c.ID] {
+				seen[c.ID] = true
+				fresh = append(fresh, c)
+			}
+		}
+
+		lastIDs[s.CommentID] = last
+
+		if len(fresh) == 0 {
+			continue
+		}
+
+		slices.SortFunc(fresh, func(a, b entity.Comment) int { return cmp.Compare(a.ID, b.ID) })
+
+		// в письме - текст без разметки: обрезанный markdown может не собраться в HTML
+		thread := digestThread{
+			Root:           markdown.Snippet(tree[0].Content, 80),
+			UnsubscribeURL: uc.unsubscribeURL(subscriber, s.CommentID),
+		}
+		for _, c := range fresh[:min(len(fresh), _digestThreadLimit)] {
+			thread.Comments = append(thread.Comments, digestComment{
+				Author:    c.Author,
+				Content:   markdown.Snippet(c.Content, _digestSnippet),
+				CreatedAt: c.CreatedAt.UTC(),
+			})
+		}
+		thread.More = len(fresh) - len(thread.Comments)
+
+		data.Threads = append(data.Threads, thread)
+		data.Count += len(fresh)
+	}
+
+	if len(data.Threads) > 0 {
+		email, err := digestEmail(subs[0].Email, data)
+		if err != nil {
+			return false, fmt.Errorf("digestEmail: %w", err)
+		}
+
+		err = uc.mailer.Send(ctx, email)
+		if err != nil {
+			return false, fmt.Errorf("uc.mailer.Send: %w", err)
+		}
+	}
+
+	err = uc.repo.MarkDigested(ctx, subscriber, lastIDs, now)
+	if err != nil {
+		return false, fmt.Errorf("uc.repo.MarkDigested: %w", err)
+	}
+
+	return len(data.Threads) > 0, nil
+}
+
+func (uc *CommentUseCase) unsubscribeURL(user string, id int64) string {
+	return uc.digestURL + "/v1/unsubscribe?token=" + uc.unsubscribeToken(user, id)
+}
+
+func digestEmail(to string, data digestData) (entity.Email, error) {
+	var text, html bytes.Buffer
+
+	if err := _digestText.Execute(&text, data); err != nil {
+		return entity.Email{}, fmt.Errorf("_digestText.Execute: %w", err)
+	}
+
+	if err := _digestHTML.Execute(&html, data); err != nil {
+		return entity.Email{}, fmt.Errorf("_digestHTML.Execute: %w", err)
+	}
+
+	subject := fmt.Sprintf("%d new comments in threads you follow", data.Count)
+	if data.Count == 1 {
+		subject = "1 new comment in threads you follow"
+	}
+
+	return entity.Email{
+		To:      to,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		// отписка в один клик из почтового клиента (RFC 8058) - POST на тот же адрес
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + data.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
@@ -0,0 +1,209 @@
+package comment
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+// ExportComments - проверяет parentID сразу, чтобы контроллер успел ответить 404
+// до начала потока. У выгруженного поддерева верхний коммент становится корнем,
+// так что файл можно загрузить обратно через ImportComments.
+func (uc *CommentUseCase) ExportComments(ctx context.Context, parentID *int64) (iter.Seq2[entity.Comment, error], error) {
+	if parentID == nil {
+		return uc.repo.ExportComments(ctx, nil), nil
+	}
+
+	err := uc.repo.CommentExists(ctx, *parentID)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - ExportComments - uc.repo.CommentExists: %w", err)
+	}
+
+	comments := uc.repo.ExportComments(ctx, parentID)
+
+	return func(yield func(entity.Comment, error) bool) {
+		for c, err := range comments {
+			if err == nil && c.ID == *parentID {
+				c.ParentID = sql.NullInt64{}
+			}
+
+			if !yield(c, err) {
+				return
+			}
+		}
+	}, nil
+}
+
+// ImportComments - загружает записи как новые деревья. Запись с ошибкой пропускается
+// вместе со всеми потомками, остальные загружаются одной пачкой.
+func (uc *CommentUseCase) ImportComments(ctx context.Context, comments []dto.ImportComment) (dto.ImportResult, error) {
+	ordered, importErrors := planImport(comments)
+
+	result := dto.ImportResult{
+		IDs:    make(map[int64]int64, len(ordered)),
+		Errors: importErrors,
+	}
+
+	if len(ordered) == 0 {
+		return result, nil
+	}
+
+	batch := make([]entity.Comment, len(ordered))
+	for i, c := range ordered {
+		batch[i] = entity.Comment{
+			ID:        c.ID,
+			Content:   c.Content,
+			Author:    c.Author,
+			CreatedAt: c.CreatedAt,
+		}
+
+		if c.ParentID != nil {
+			batch[i].ParentID = sql.NullInt64{Int64: *c.ParentID, Valid: true}
+		}
+	}
+
+	ids, err := uc.repo.ImportComments(ctx, batch)
+	if err != nil {
+		return dto.ImportResult{}, fmt.Errorf("CommentUseCase - ImportComments - uc.repo.ImportComments: %w", err)
+	}
+
+	for i, c := range ordered {
+		result.IDs[c.ID] = ids[i]
+	}
+
+	result.Imported = len(ids)
+
+	// существующие деревья не меняются, появляются только новые корни
+	uc.purge(ctx, []string{entity.RootsSurrogateKey, entity.SearchSurrogateKey})
+
+	return result, nil
+}
+
+// planImport - отбрасывает некорректные записи и упорядочивает остальные
+// обходом в ширину от корней, так что родитель всегда идет раньше ребенка.
+func planImport(comments []dto.ImportComment) ([]dto.ImportComment, []dto.ImportError) {
+	var importErrors []dto.ImportError
+
+	reject := func(c dto.ImportComment, format string, args ...any) {
+		importErrors = append(importErrors, dto.ImportError{Line: c.Line, ID: c.ID, Error: fmt.Sprintf(format, args...)})
+	}
+
+	// first - индекс первой записи с этим id, valid - первая запись прошла проверки
+	first := make(map[int64]int, len(comments))
+	valid := make(map[int64]bool, len(comments))
+
+	for i, c := range comments {
+		if c.ID <= 0 {
+			reject(c, "id must be a positive number")
+
+			continue
+		}
+
+		if _, ok := first[c.ID]; ok {
+			reject(c, "duplicate id %d", c.ID)
+
+			continue
+		}
+
+		first[c.ID] = i
+
+		if strings.TrimSpace(c.Content) == "" {
+			reject(c, "content is empty")
+
+			continue
+		}
+
+		valid[c.ID] = true
+	}
+
+	isKept := func(i int, c dto.ImportComment) bool {
+		return valid[c.ID] && first[c.ID] == i
+	}
+
+	var ordered []dto.ImportComment
+	children := make(map[int64][]dto.ImportComment)
+
+	for i, c := range comments {
+		if !isKept(i, c) {
+			continue
+		}
+
+		if c.ParentID == nil {
+			ordered = append(ordered, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	for i := 0; i < len(ordered); i++ {
+		ordered = append(ordered, children[ordered[i].ID]...)
+	}
+
+	if len(ordered) == len(valid) {
+		return ordered, importErrors
+	}
+
+	// все, что не достижимо от корней: родителя нет, он отброшен или ссылки образуют цикл
+	reached := make(map[int64]bool, len(ordered))
+	for _, c := range ordered {
+		reached[c.ID] = true
+	}
+
+	for i, c := range comments {
+		if !isKept(i, c) || reached[c.ID] {
+			continue
+		}
+
+		parentID := *c.ParentID
+		_, known := first[parentID]
+
+		switch {
+		case !known:
+			reject(c, "parent %d not found in import", parentID)
+		case !valid[parentID]:
+			reject(c, "parent %d was rejected", parentID)
+		case inCycle(comments, first, c):
+			reject(c, "parent references form a cycle")
+		default:
+			reject(c, "an ancestor of parent %d was not imported", parentID)
+		}
+	}
+
+	slices.SortStableFunc(importErrors, func(a, b dto.ImportError) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+
+	return ordered, importErrors
+}
+
+// inCycle - возвращается ли цепочка родителей к самой записи.
+func inCycle(comments []dto.ImportComment, first map[int64]int, c dto.ImportComment) bool {
+	seen := map[int64]bool{}
+
+	for cur := c; cur.ParentID != nil; {
+		if seen[cur.ID] {
+			return false
+		}
+		seen[cur.ID] = true
+
+		i, ok := first[*cur.ParentID]
+		if !ok {
+			return false
+		}
+
+		if comments[i].ID == c.ID {
+			return true
+		}
+
+		cur = comments[i]
+	}
+
+	return false
+}
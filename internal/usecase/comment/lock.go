@@ -0,0 +1,151 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// LockComment - блокирует коммент со всем поддеревом. Меняется и то, что видят ответы,
+// поэтому на CDN сбрасывается все поддерево, а не только путь до корня.
+// Внутри архивной ветки блокировки не меняются; собственный архив коммента снять можно.
+func (uc *CommentUseCase) LockComment(ctx context.Context, id int64, state, reason string) error {
+	path, err := uc.repo.GetCommentPath(ctx, id)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - LockComment - uc.repo.GetCommentPath: %w", err)
+	}
+
+	if len(path) > 1 {
+		err = uc.checkWritable(ctx, path[:len(path)-1])
+		if err != nil {
+			return fmt.Errorf("CommentUseCase - LockComment - uc.checkWritable: %w", err)
+		}
+	}
+
+	subtree, err := uc.repo.GetCommentWithChildren(ctx, id, entity.ChildSortOldest)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - LockComment - uc.repo.GetCommentWithChildren: %w", err)
+	}
+
+	err = uc.repo.SetLock(ctx, id, state, reason)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - LockComment - uc.repo.SetLock: %w", err)
+	}
+
+	uc.purgeSubtree(ctx, path, subtree)
+
+	return nil
+}
+
+// UnlockComment - снимает собственную блокировку коммента; блокировка предков продолжает действовать.
+func (uc *CommentUseCase) UnlockComment(ctx context.Context, id int64) error {
+	err := uc.LockComment(ctx, id, entity.LockNone, "")
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - UnlockComment: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveInactive - архивирует деревья без изменений дольше inactiveFor и возвращает их число.
+func (uc *CommentUseCase) ArchiveInactive(ctx context.Context, inactiveFor time.Duration) (int, error) {
+	ids, err := uc.repo.ArchiveInactive(ctx, time.Now().Add(-inactiveFor), entity.AutoArchiveReason)
+	if err != nil {
+		return 0, fmt.Errorf("CommentUseCase - ArchiveInactive - uc.repo.ArchiveInactive: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	keys := []string{entity.SearchSurrogateKey, entity.RootsSurrogateKey}
+	for _, id := range ids {
+		keys = append(keys, entity.CommentSurrogateKey(id))
+	}
+
+	// поддеревья ответов тоже устарели; если их не удалось прочитать - сбрасываем хотя бы корни
+	trees, err := uc.repo.GetTreesForRoots(ctx, ids, entity.ChildSortOldest)
+	if err != nil {
+		uc.purge(ctx, keys)
+
+		return len(ids), fmt.Errorf("CommentUseCase - ArchiveInactive - uc.repo.GetTreesForRoots: %w", err)
+	}
+
+	for _, c := range trees {
+		if c.ParentID.Valid {
+			keys = append(keys, entity.CommentSurrogateKey(c.ID))
+		}
+	}
+
+	uc.purge(ctx, keys)
+
+	return len(ids), nil
+}
+
+// lockOf - действующая блокировка коммента с путем path: самая строгая у него и его предков.
+func (uc *CommentUseCase) lockOf(ctx context.Context, path []int64) (state, reason string, err error) {
+	chain, err := uc.chain(ctx, path)
+	if err != nil {
+		return "", "", fmt.Errorf("uc.chain: %w", err)
+	}
+
+	state, reason = entity.EffectiveLock(chain...)
+
+	return state, reason, nil
+}
+
+// chain - комменты пути path в том же порядке, от корня.
+func (uc *CommentUseCase) chain(ctx context.Context, path []int64) ([]entity.Comment, error) {
+	comments, err := uc.repo.GetCommentsByIDs(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("uc.repo.GetCommentsByIDs: %w", err)
+	}
+
+	chain := make([]entity.Comment, 0, len(path))
+	for _, id := range path {
+		chain = append(chain, comments[id])
+	}
+
+	return chain, nil
+}
+
+// checkWritable - errs.ErrArchived, если коммент с путем path лежит в архивной ветке.
+func (uc *CommentUseCase) checkWritable(ctx context.Context, path []int64) error {
+	state, _, err := uc.lockOf(ctx, path)
+	if err != nil {
+		return fmt.Errorf("uc.lockOf: %w", err)
+	}
+
+	if state == entity.LockArchived {
+		return errs.ErrArchived
+	}
+
+	return nil
+}
+
+// inheritLock - у верхнего коммента поддерева показывается блокировка его предков,
+// если она строже собственной: в ответ предки не попадают.
+func (uc *CommentUseCase) inheritLock(ctx context.Context, top *entity.Comment) error {
+	if !top.ParentID.Valid {
+		return nil
+	}
+
+	path, err := uc.repo.GetCommentPath(ctx, top.ParentID.Int64)
+	if err != nil {
+		return fmt.Errorf("uc.repo.GetCommentPath: %w", err)
+	}
+
+	state, reason, err := uc.lockOf(ctx, path)
+	if err != nil {
+		return fmt.Errorf("uc.lockOf: %w", err)
+	}
+
+	if entity.StrongerLock(state, top.LockState) {
+		top.LockState, top.LockReason = state, reason
+	}
+
+	return nil
+}
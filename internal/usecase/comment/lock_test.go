@@ -0,0 +1,55 @@
+package comment_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+func newUseCase(opts ...comment.Option) *comment.CommentUseCase {
+	l := logger.New("error")
+
+	return comment.New(memory.New(), webapi.NewCDN("", "", "", "", time.Second, l), l, opts...)
+}
+
+func TestLockInsideArchive(t *testing.T) {
+	ctx := context.Background()
+	uc := newUseCase()
+
+	root, _ := uc.CreateComment(ctx, nil, "", "root", nil)
+	a, _ := uc.CreateComment(ctx, &root.ID, "", "a", nil)
+
+	if err := uc.LockComment(ctx, root.ID, entity.LockArchived, "old"); err != nil {
+		t.Fatalf("LockComment(root): %v", err)
+	}
+
+	// внутри архивной ветки блокировки не меняются
+	if err := uc.LockComment(ctx, a.ID, entity.LockLocked, ""); !errors.Is(err, errs.ErrArchived) {
+		t.Fatalf("LockComment(inside archive) = %v, want ErrArchived", err)
+	}
+	if err := uc.UnlockComment(ctx, a.ID); !errors.Is(err, errs.ErrArchived) {
+		t.Fatalf("UnlockComment(inside archive) = %v, want ErrArchived", err)
+	}
+	if _, err := uc.CreateComment(ctx, &a.ID, "", "x", nil); !errors.Is(err, errs.ErrArchived) {
+		t.Fatalf("CreateComment(inside archive) = %v, want ErrArchived", err)
+	}
+
+	// а свой архив снимается
+	if err := uc.UnlockComment(ctx, root.ID); err != nil {
+		t.Fatalf("UnlockComment(root): %v", err)
+	}
+	if err := uc.LockComment(ctx, a.ID, entity.LockLocked, ""); err != nil {
+		t.Fatalf("LockComment(a) after unarchive: %v", err)
+	}
+	if _, err := uc.CreateComment(ctx, &a.ID, "", "x", nil); !errors.Is(err, errs.ErrLocked) {
+		t.Fatalf("CreateComment(locked) = %v, want ErrLocked", err)
+	}
+}
@@ -0,0 +1,127 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+// _maxMentions - сколько упоминаний одного коммента превращаются в уведомления,
+// чтобы один коммент не рассылал уведомления всем подряд.
+const _maxMentions = 20
+
+func (uc *CommentUseCase) GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error) {
+	ns, err := uc.repo.GetNotifications(ctx, recipient, status, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetNotifications - uc.repo.GetNotifications: %w", err)
+	}
+
+	return ns, nil
+}
+
+func (uc *CommentUseCase) MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error) {
+	read, err := uc.repo.MarkNotificationsRead(ctx, recipient, ids)
+	if err != nil {
+		return 0, fmt.Errorf("CommentUseCase - MarkNotificationsRead - uc.repo.MarkNotificationsRead: %w", err)
+	}
+
+	return read, nil
+}
+
+func (uc *CommentUseCase) MuteThread(ctx context.Context, user string, id int64) error {
+	err := uc.repo.SetMute(ctx, user, id, true)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - MuteThread - uc.repo.SetMute: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *CommentUseCase) UnmuteThread(ctx context.Context, user string, id int64) error {
+	err := uc.repo.SetMute(ctx, user, id, false)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - UnmuteThread - uc.repo.SetMute: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *CommentUseCase) SubscribeNotifications(_ context.Context, recipient string) (<-chan entity.Notification, func()) {
+	return uc.hub.Subscribe(recipient)
+}
+
+// notify - коммент уже сохранен, поэтому ошибка уведомлений его не отменяет и только пишется в лог.
+func (uc *CommentUseCase) notify(ctx context.Context, c entity.Comment, replyTo *entity.Comment) {
+	err := uc.createNotifications(ctx, c, replyTo)
+	if err != nil {
+		uc.l.WithContext(ctx).Error(fmt.Errorf("CommentUseCase - notify - uc.createNotifications: %w", err))
+	}
+}
+
+// createNotifications - автору коммента, на который ответили, и упомянутым в c через @username.
+// Автор c сам себе уведомлений не получает, как и заглушившие любую ветку на пути к c.
+func (uc *CommentUseCase) createNotifications(ctx context.Context, c entity.Comment, replyTo *entity.Comment) error {
+	var ns []entity.Notification
+
+	add := func(recipient, kind string) {
+		if recipient == "" || recipient == c.Author {
+			return
+		}
+		if slices.ContainsFunc(ns, func(n entity.Notification) bool { return n.Recipient == recipient }) {
+			return
+		}
+
+		ns = append(ns, entity.Notification{
+			Recipient: recipient,
+			Kind:      kind,
+			CommentID: c.ID,
+			Actor:     c.Author,
+		})
+	}
+
+	// ответ важнее упоминания: упомянутый автор родителя получает одно уведомление об ответе
+	if replyTo != nil {
+		add(replyTo.Author, entity.NotificationReply)
+	}
+
+	mentions := entity.Mentions(c.Content)
+	if len(mentions) > _maxMentions {
+		mentions = mentions[:_maxMentions]
+	}
+	for _, name := range mentions {
+		add(name, entity.NotificationMention)
+	}
+
+	if len(ns) == 0 {
+		return nil
+	}
+
+	recipients := make([]string, len(ns))
+	for i, n := range ns {
+		recipients[i] = n.Recipient
+	}
+
+	muted, err := uc.repo.GetMutedUsers(ctx, c.Path, recipients)
+	if err != nil {
+		return fmt.Errorf("uc.repo.GetMutedUsers: %w", err)
+	}
+
+	ns = slices.DeleteFunc(ns, func(n entity.Notification) bool { return slices.Contains(muted, n.Recipient) })
+	if len(ns) == 0 {
+		return nil
+	}
+
+	ns, err = uc.repo.CreateNotifications(ctx, ns)
+	if err != nil {
+		return fmt.Errorf("uc.repo.CreateNotifications: %w", err)
+	}
+
+	for _, n := range ns {
+		n.Content = c.Content
+		uc.hub.Publish(n.Recipient, n)
+	}
+
+	return nil
+}
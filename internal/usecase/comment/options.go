@@ -0,0 +1,62 @@
+package comment
+
+import (
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/pubsub"
+)
+
+// Option - настройка CommentUseCase.
+type Option func(*CommentUseCase)
+
+// MaxDepth - общий лимит глубины деревьев (0 - без лимита; у корня может быть свой)
+// и что делать с ответом глубже: entity.DepthPolicyFlatten или entity.DepthPolicyReject.
+func MaxDepth(depth int, policy string) Option {
+	return func(uc *CommentUseCase) {
+		uc.maxDepth = depth
+		uc.depthPolicy = policy
+	}
+}
+
+// Notifications - через hub новые уведомления уходят подключенным получателям.
+func Notifications(hub *pubsub.Hub[entity.Notification]) Option {
+	return func(uc *CommentUseCase) {
+		uc.hub = hub
+	}
+}
+
+// Digests - дайджесты подписчикам уходят через mailer не чаще раза в period.
+// secret подписывает токены в ссылках отписки, baseURL - адрес сервиса для этих ссылок.
+func Digests(mailer repo.Mailer, secret, baseURL string, period time.Duration) Option {
+	return func(uc *CommentUseCase) {
+		uc.mailer = mailer
+		uc.digestSecret = []byte(secret)
+		uc.digestURL = strings.TrimRight(baseURL, "/")
+		uc.digestPeriod = period
+	}
+}
+
+// AttachmentLimits - ограничения на загружаемые файлы: размер в байтах, число на коммент,
+// разрешенные типы (по содержимому) и размер миниатюр картинок по большей стороне.
+type AttachmentLimits struct {
+	MaxSize       int64
+	MaxPerComment int
+	Types         []string
+	ThumbnailSize int
+}
+
+// Attachments - файлы вложений хранятся в store (nil - вложения выключены).
+// secret подписывает ссылки на файлы, ссылка действует от urlTTL до 2*urlTTL;
+// не прикрепленные за uploadTTL загрузки удаляет CollectAttachments.
+func Attachments(store repo.BlobStore, secret string, limits AttachmentLimits, urlTTL, uploadTTL time.Duration) Option {
+	return func(uc *CommentUseCase) {
+		uc.blobs = store
+		uc.attachmentSecret = []byte(secret)
+		uc.attachmentLimits = limits
+		uc.attachmentURLTTL = urlTTL
+		uc.attachmentUploadTTL = uploadTTL
+	}
+}
@@ -0,0 +1,181 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// _exportNotificationsPage - уведомления автора для выгрузки читаются такими страницами.
+const _exportNotificationsPage = 100
+
+func (uc *CommentUseCase) ExportAuthor(ctx context.Context, author, actor, reason string) (entity.AuthorData, error) {
+	data, err := uc.repo.GetAuthorData(ctx, author)
+	if err != nil {
+		return entity.AuthorData{}, fmt.Errorf("CommentUseCase - ExportAuthor - uc.repo.GetAuthorData: %w", err)
+	}
+
+	data.Notifications, err = uc.allNotifications(ctx, author)
+	if err != nil {
+		return entity.AuthorData{}, fmt.Errorf("CommentUseCase - ExportAuthor - uc.allNotifications: %w", err)
+	}
+
+	data.Subscriptions, err = uc.repo.GetSubscriptions(ctx, author)
+	if err != nil {
+		return entity.AuthorData{}, fmt.Errorf("CommentUseCase - ExportAuthor - uc.repo.GetSubscriptions: %w", err)
+	}
+
+	// файлы можно скачать по ссылкам, пока они не истекли
+	if uc.blobs != nil {
+		now := time.Now()
+		for i := range data.Attachments {
+			uc.signAttachment(&data.Attachments[i], now)
+		}
+	}
+
+	// без записи в журнал данные не отдаются
+	_, err = uc.repo.LogPrivacyRequest(ctx, entity.PrivacyRequest{
+		Action:   entity.PrivacyExport,
+		Author:   author,
+		Actor:    actor,
+		Reason:   reason,
+		Comments: len(data.Comments),
+	})
+	if err != nil {
+		return entity.AuthorData{}, fmt.Errorf("CommentUseCase - ExportAuthor - uc.repo.LogPrivacyRequest: %w", err)
+	}
+
+	return data, nil
+}
+
+// EraseAuthor - блокировки веток не мешают: стирание обязательно и для архивных.
+// При ErasureDelete удаляются комменты автора, под которыми нет чужих ответов; остальные, как и все
+// при анонимизации, остаются в дереве без автора и с текстом entity.ErasedContent.
+// Повторное стирание ничего не находит, но тоже пишется в журнал.
+func (uc *CommentUseCase) EraseAuthor(ctx context.Context, author, mode, actor, reason string) (entity.PrivacyRequest, error) {
+	deleted := 0
+
+	if mode == entity.ErasureDelete {
+		var err error
+
+		deleted, err = uc.deleteAuthorComments(ctx, author)
+		if err != nil {
+			return entity.PrivacyRequest{}, fmt.Errorf("CommentUseCase - EraseAuthor - uc.deleteAuthorComments: %w", err)
+		}
+	}
+
+	erasure, err := uc.repo.EraseAuthor(ctx, author, entity.ErasedContent)
+	if err != nil {
+		return entity.PrivacyRequest{}, fmt.Errorf("CommentUseCase - EraseAuthor - uc.repo.EraseAuthor: %w", err)
+	}
+
+	if len(erasure.Touched) > 0 {
+		keys := []string{entity.SearchSurrogateKey, entity.RootsSurrogateKey}
+		for _, id := range erasure.Touched {
+			keys = append(keys, entity.CommentSurrogateKey(id))
+		}
+
+		uc.purge(ctx, keys)
+	}
+
+	// без хранилища файлов строки вложений остаются брошенными до его включения
+	if uc.blobs != nil {
+		uc.collect(ctx, erasure.Attachments)
+	}
+
+	p, err := uc.repo.LogPrivacyRequest(ctx, entity.PrivacyRequest{
+		Action:   mode,
+		Author:   author,
+		Actor:    actor,
+		Reason:   reason,
+		Comments: deleted + len(erasure.Comments),
+	})
+	if err != nil {
+		return entity.PrivacyRequest{}, fmt.Errorf("CommentUseCase - EraseAuthor - uc.repo.LogPrivacyRequest: %w", err)
+	}
+
+	return p, nil
+}
+
+// deleteAuthorComments - удаляет комменты автора, в поддеревьях которых только его комменты,
+// и возвращает, сколько удалено. Комменты идут по id, поэтому предок проверяется раньше потомков:
+// удаленный уносит их с собой, а под оставленным (с чужим ответом) свои поддеревья удаляются отдельно.
+func (uc *CommentUseCase) deleteAuthorComments(ctx context.Context, author string) (int, error) {
+	data, err := uc.repo.GetAuthorData(ctx, author)
+	if err != nil {
+		return 0, fmt.Errorf("uc.repo.GetAuthorData: %w", err)
+	}
+
+	removed := make(map[int64]bool)
+	deleted := 0
+
+	for _, c := range data.Comments {
+		if removed[c.ID] {
+			continue
+		}
+
+		path, err := uc.repo.GetCommentPath(ctx, c.ID)
+		if err != nil {
+			// удален параллельно
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				continue
+			}
+
+			return 0, fmt.Errorf("uc.repo.GetCommentPath: %w", err)
+		}
+
+		subtree, err := uc.repo.GetCommentWithChildren(ctx, c.ID, entity.ChildSortOldest)
+		if err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				continue
+			}
+
+			return 0, fmt.Errorf("uc.repo.GetCommentWithChildren: %w", err)
+		}
+
+		// чужие ответы не удаляются: такой коммент останется заглушкой
+		if slices.ContainsFunc(subtree, func(sc entity.Comment) bool { return sc.Author != author }) {
+			continue
+		}
+
+		n, err := uc.deleteSubtree(ctx, path, subtree)
+		if err != nil && !errors.Is(err, errs.ErrRecordNotFound) {
+			return 0, fmt.Errorf("uc.deleteSubtree: %w", err)
+		}
+
+		deleted += n
+
+		for _, sc := range subtree {
+			removed[sc.ID] = true
+		}
+	}
+
+	return deleted, nil
+}
+
+// allNotifications - все входящие пользователя от новых к старым.
+func (uc *CommentUseCase) allNotifications(ctx context.Context, recipient string) ([]entity.Notification, error) {
+	var all []entity.Notification
+
+	beforeID := int64(0)
+
+	for {
+		ns, err := uc.repo.GetNotifications(ctx, recipient, entity.NotificationsAll, beforeID, _exportNotificationsPage)
+		if err != nil {
+			return nil, fmt.Errorf("uc.repo.GetNotifications: %w", err)
+		}
+
+		all = append(all, ns...)
+
+		if len(ns) < _exportNotificationsPage {
+			return all, nil
+		}
+
+		beforeID = ns[len(ns)-1].ID
+	}
+}
@@ -0,0 +1,89 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+)
+
+func (uc *CommentUseCase) GetBacklinks(ctx context.Context, id int64, limit, offset int) ([]entity.Comment, int, error) {
+	err := uc.repo.CommentExists(ctx, id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("CommentUseCase - GetBacklinks - uc.repo.CommentExists: %w", err)
+	}
+
+	comments, total, err := uc.repo.GetBacklinks(ctx, id, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("CommentUseCase - GetBacklinks - uc.repo.GetBacklinks: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+// attachReferences - References у каждого коммента дерева.
+func (uc *CommentUseCase) attachReferences(ctx context.Context, comments []entity.Comment) error {
+	ids := make([]int64, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+
+	refs, err := uc.repo.GetReferences(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("uc.repo.GetReferences: %w", err)
+	}
+
+	for i := range comments {
+		comments[i].References = refs[comments[i].ID]
+	}
+
+	return nil
+}
+
+// referrerPaths - пути комментов вне subtree, ссылающихся на комменты из него.
+func (uc *CommentUseCase) referrerPaths(ctx context.Context, subtree []entity.Comment) ([][]int64, error) {
+	ids := make([]int64, len(subtree))
+	for i, c := range subtree {
+		ids[i] = c.ID
+	}
+
+	referrers, err := uc.repo.GetReferrers(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("uc.repo.GetReferrers: %w", err)
+	}
+
+	var paths [][]int64
+
+	for _, id := range referrers {
+		if slices.Contains(ids, id) {
+			continue
+		}
+
+		path, err := uc.repo.GetCommentPath(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("uc.repo.GetCommentPath: %w", err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// purgeReferrers - ссылки комментов с путями paths стали недоступными, хранилище повысило
+// их версии вместе с предками: устаревают их поддеревья, поиск и списки корней.
+func (uc *CommentUseCase) purgeReferrers(ctx context.Context, paths [][]int64) {
+	if len(paths) == 0 {
+		return
+	}
+
+	keys := []string{entity.SearchSurrogateKey, entity.RootsSurrogateKey}
+	for _, path := range paths {
+		for _, id := range path {
+			keys = append(keys, entity.CommentSurrogateKey(id))
+		}
+	}
+
+	uc.purge(ctx, keys)
+}
@@ -0,0 +1,106 @@
+package comment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+func (uc *CommentUseCase) Subscribe(ctx context.Context, user string, id int64, email string) error {
+	err := uc.repo.Subscribe(ctx, user, id, email)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - Subscribe - uc.repo.Subscribe: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *CommentUseCase) Unsubscribe(ctx context.Context, user string, id int64) error {
+	err := uc.repo.Unsubscribe(ctx, user, id)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - Unsubscribe - uc.repo.Unsubscribe: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *CommentUseCase) GetSubscriptions(ctx context.Context, user string) ([]entity.Subscription, error) {
+	subs, err := uc.repo.GetSubscriptions(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("CommentUseCase - GetSubscriptions - uc.repo.GetSubscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// UnsubscribeByToken - отписка по ссылке из письма, без X-User.
+func (uc *CommentUseCase) UnsubscribeByToken(ctx context.Context, token string) error {
+	user, id, ok := uc.parseUnsubscribeToken(token)
+	if !ok {
+		return fmt.Errorf("CommentUseCase - UnsubscribeByToken: %w", errs.ErrInvalidToken)
+	}
+
+	err := uc.repo.Unsubscribe(ctx, user, id)
+	if err != nil {
+		return fmt.Errorf("CommentUseCase - UnsubscribeByToken - uc.repo.Unsubscribe: %w", err)
+	}
+
+	return nil
+}
+
+// unsubscribeToken - "<id>:<user>" и его HMAC-SHA256, оба в base64url. id 0 - отписка от всего.
+// Срока действия нет: ссылка из старого письма тоже должна работать.
+func (uc *CommentUseCase) unsubscribeToken(user string, id int64) string {
+	payload := strconv.FormatInt(id, 10) + ":" + user
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(uc.sign(payload))
+}
+
+func (uc *CommentUseCase) parseUnsubscribeToken(token string) (string, int64, bool) {
+	// без секрета подписать можно что угодно
+	if len(uc.digestSecret) == 0 {
+		return "", 0, false
+	}
+
+	encoded, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", 0, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", 0, false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, uc.sign(string(payload))) {
+		return "", 0, false
+	}
+
+	rawID, user, ok := strings.Cut(string(payload), ":")
+	if !ok {
+		return "", 0, false
+	}
+
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || id < 0 {
+		return "", 0, false
+	}
+
+	return user, id, true
+}
+
+func (uc *CommentUseCase) sign(payload string) []byte {
+	h := hmac.New(sha256.New, uc.digestSecret)
+	h.Write([]byte("unsubscribe:" + payload))
+
+	return h.Sum(nil)
+}
@@ -0,0 +1,84 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// MarkSeen - визит запоминается на весь тред, поэтому только у корня.
+func (uc *CommentUseCase) MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error) {
+	path, err := uc.repo.GetCommentPath(ctx, rootID)
+	if err != nil {
+		return 0, fmt.Errorf("CommentUseCase - MarkSeen - uc.repo.GetCommentPath: %w", err)
+	}
+
+	if len(path) != 1 {
+		return 0, fmt.Errorf("CommentUseCase - MarkSeen: %w", errs.ErrNotRoot)
+	}
+
+	lastSeenID, err := uc.repo.MarkSeen(ctx, viewer, rootID)
+	if err != nil {
+		return 0, fmt.Errorf("CommentUseCase - MarkSeen - uc.repo.MarkSeen: %w", err)
+	}
+
+	return lastSeenID, nil
+}
+
+// markSubtreeUnread - subtree в порядке GetCommentWithChildren, визит берется у корня его треда.
+// У самого корня считается и NewCount.
+func (uc *CommentUseCase) markSubtreeUnread(ctx context.Context, viewer string, subtree []entity.Comment) error {
+	top := &subtree[0]
+
+	rootID := top.ID
+	if top.ParentID.Valid {
+		path, err := uc.repo.GetCommentPath(ctx, top.ParentID.Int64)
+		if err != nil {
+			return fmt.Errorf("uc.repo.GetCommentPath: %w", err)
+		}
+		rootID = path[0]
+	}
+
+	lastSeen, err := uc.repo.GetLastSeen(ctx, viewer, []int64{rootID})
+	if err != nil {
+		return fmt.Errorf("uc.repo.GetLastSeen: %w", err)
+	}
+	lastSeenID := lastSeen[rootID]
+
+	newCount := 0
+	for i := range subtree {
+		subtree[i].IsNew = entity.Unread(subtree[i], viewer, lastSeenID)
+		if subtree[i].IsNew {
+			newCount++
+		}
+	}
+
+	if !top.ParentID.Valid {
+		top.LastSeenID, top.NewCount = lastSeenID, newCount
+	}
+
+	return nil
+}
+
+// markTreesUnread - деревья из GetTreesForRoots, визиты и NewCount - у roots из GetRootComments.
+func markTreesUnread(viewer string, roots, trees []entity.Comment) {
+	byID := make(map[int64]entity.Comment, len(roots))
+	for _, r := range roots {
+		byID[r.ID] = r
+	}
+
+	for i, c := range trees {
+		if len(c.Path) == 0 {
+			continue
+		}
+
+		root := byID[c.Path[0]]
+		trees[i].IsNew = entity.Unread(c, viewer, root.LastSeenID)
+
+		if c.Depth == 0 {
+			trees[i].LastSeenID, trees[i].NewCount = root.LastSeenID, root.NewCount
+		}
+	}
+}
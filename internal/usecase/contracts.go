@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"io"
+	"iter"
+	"time"
 
 	"github.com/andreyxaxa/Comment-Tree/internal/dto"
 	"github.com/andreyxaxa/Comment-Tree/internal/entity"
@@ -9,8 +12,93 @@ import (
 
 type (
 	CommentUseCase interface {
-		CreateComment(ctx context.Context, parentID *int64, content string) (entity.Comment, error)
+		// CreateComment - author ("" - аноним) становится автором коммента. Автор коммента,
+		// на который ответили, и упомянутые через @username получают уведомления.
+		// Ссылки ">>id" на существующие комменты запоминаются (не больше entity.MaxReferences).
+		// attachmentIDs - вложения, загруженные тем же author через UploadAttachment и еще не прикрепленные;
+		// неподходящее или у анонима - errs.ErrInvalidAttachment, больше лимита - errs.ErrTooManyAttachments.
+		CreateComment(ctx context.Context, parentID *int64, author, content string, attachmentIDs []int64) (entity.Comment, error)
 		DeleteCommentWithChildren(ctx context.Context, id int64) error
+		// GetComments - с params.Viewer комменты, новые для него с последнего MarkSeen треда, отмечены IsNew,
+		// а у корней есть NewCount. Поиск непрочитанное не отмечает.
+		// У комментов деревьев заполнены References и Attachments (с подписанными ссылками).
 		GetComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error)
+		ExportComments(ctx context.Context, parentID *int64) (iter.Seq2[entity.Comment, error], error)
+		ImportComments(ctx context.Context, comments []dto.ImportComment) (dto.ImportResult, error)
+		// GetRootComments - страница корней без деревьев, с params.Viewer - с NewCount.
+		GetRootComments(ctx context.Context, params dto.GetCommentsParams) (dto.PaginatedComments, error)
+		// GetCommentsByIDs, GetChildren и CountReplies - пакетные чтения для GraphQL,
+		// отсутствующих комментов в ответе нет.
+		GetCommentsByIDs(ctx context.Context, ids []int64) (map[int64]entity.Comment, error)
+		GetChildren(ctx context.Context, parentIDs []int64, params dto.GetCommentsParams) (map[int64][]entity.Comment, error)
+		CountReplies(ctx context.Context, ids []int64) (map[int64]entity.ReplyCount, error)
+		// GetReferences, GetAttachments (с подписанными ссылками) и GetLastSeen - то же, что GetComments
+		// заполняет у комментов деревьев, пачками для GraphQL. Комментов без ссылок и вложений в ответе нет.
+		GetReferences(ctx context.Context, ids []int64) (map[int64][]entity.Reference, error)
+		GetAttachments(ctx context.Context, ids []int64) (map[int64][]entity.Attachment, error)
+		// GetLastSeen - последние увиденные viewer комменты тредов корней rootIDs, неоткрытых тредов в карте нет.
+		GetLastSeen(ctx context.Context, viewer string, rootIDs []int64) (map[int64]int64, error)
+		// GetThreadRoot - корень дерева, в котором коммент id (сам id, если это корень).
+		// Его автор - владелец треда.
+		GetThreadRoot(ctx context.Context, id int64) (entity.Comment, error)
+		// PinComment - закрепляет коммент на месте order среди братьев (корень - среди корней).
+		PinComment(ctx context.Context, id int64, order int64) error
+		UnpinComment(ctx context.Context, id int64) error
+		SetHighlighted(ctx context.Context, id int64, highlighted bool) error
+		// LockComment - блокировка (entity.LockLocked, entity.LockArchived) действует на все поддерево:
+		// в закрытую ветку нельзя отвечать (errs.ErrLocked), архивную нельзя менять вовсе (errs.ErrArchived).
+		LockComment(ctx context.Context, id int64, state, reason string) error
+		UnlockComment(ctx context.Context, id int64) error
+		// SetMaxDepth - лимит глубины дерева корня id (0 - без лимита), nil - общий из конфига.
+		// Не корень - errs.ErrNotRoot.
+		SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error
+		// ArchiveInactive - архивирует деревья без изменений дольше inactiveFor, возвращает их число.
+		ArchiveInactive(ctx context.Context, inactiveFor time.Duration) (int, error)
+		// GetNotifications - входящие получателя от новых к старым со статусом entity.Notifications*,
+		// beforeID - id последнего уведомления предыдущей страницы (0 - первая страница).
+		GetNotifications(ctx context.Context, recipient, status string, beforeID int64, limit int) ([]entity.Notification, error)
+		// MarkNotificationsRead - ids nil - все уведомления получателя, возвращает число отмеченных.
+		MarkNotificationsRead(ctx context.Context, recipient string, ids []int64) (int, error)
+		// MuteThread - уведомления о новых комментах в поддереве id пользователю user не приходят.
+		MuteThread(ctx context.Context, user string, id int64) error
+		UnmuteThread(ctx context.Context, user string, id int64) error
+		// SubscribeNotifications - новые уведомления получателя, пока не вызвана функция отписки.
+		// Не успевающий забирать подписчик теряет уведомления, во входящих они остаются.
+		SubscribeNotifications(ctx context.Context, recipient string) (<-chan entity.Notification, func())
+		// Subscribe - подписка user на дайджест новых комментов в поддереве id, письма уходят на email
+		// (последний указанный адрес - для всех подписок user).
+		Subscribe(ctx context.Context, user string, id int64, email string) error
+		// Unsubscribe - id 0 - отписка от всего.
+		Unsubscribe(ctx context.Context, user string, id int64) error
+		GetSubscriptions(ctx context.Context, user string) ([]entity.Subscription, error)
+		// UnsubscribeByToken - отписка по подписанной ссылке из письма, подделанная - errs.ErrInvalidToken.
+		UnsubscribeByToken(ctx context.Context, token string) error
+		// MarkSeen - viewer прочитал тред корня rootID целиком, возвращает последний увиденный коммент.
+		// Не корень - errs.ErrNotRoot.
+		MarkSeen(ctx context.Context, viewer string, rootID int64) (int64, error)
+		// GetBacklinks - страница комментов, ссылающихся на id, от новых к старым, и их общее число.
+		GetBacklinks(ctx context.Context, id int64, limit, offset int) ([]entity.Comment, int, error)
+		// UploadAttachment - сохраняет файл из r, еще не прикрепленный к комменту.
+		// Выключенные вложения - errs.ErrAttachmentsDisabled, файл больше лимита - errs.ErrFileTooLarge,
+		// неразрешенный тип - errs.ErrUnsupportedType, аноним (uploader "") - errs.ErrInvalidAttachment.
+		UploadAttachment(ctx context.Context, uploader, filename string, r io.Reader) (entity.Attachment, error)
+		// OpenAttachment - файл вложения (variant - entity.Attachment*) по подписанной ссылке,
+		// подделанная или истекшая - errs.ErrInvalidToken. Reader закрывает вызывающий.
+		OpenAttachment(ctx context.Context, id int64, variant string, expires int64, sig string) (entity.Attachment, io.ReadCloser, error)
+		// CollectAttachments - удаляет брошенные вложения и возвращает их число.
+		CollectAttachments(ctx context.Context) (int, error)
+		// SendDigests - рассылает дайджесты, которым пора, и возвращает число отправленных писем.
+		SendDigests(ctx context.Context) (int, error)
+		// ExportAuthor - все личные данные автора; запрос пишется в журнал privacy_requests от имени actor.
+		ExportAuthor(ctx context.Context, author, actor, reason string) (entity.AuthorData, error)
+		// EraseAuthor - стирает автора режимом mode (entity.Erasure*) и возвращает запись журнала.
+		EraseAuthor(ctx context.Context, author, mode, actor, reason string) (entity.PrivacyRequest, error)
+		// GetAuditLog - страница журнала изменений от новых к старым, beforeID - как у GetNotifications.
+		GetAuditLog(ctx context.Context, f entity.AuditFilter, beforeID int64, limit int) ([]entity.AuditEntry, error)
+	}
+
+	// ImporterUseCase - идемпотентный перенос комментов из выгрузок внешних систем.
+	ImporterUseCase interface {
+		Import(ctx context.Context, source string, comments []dto.SourceComment) (dto.SourceImportSummary, error)
 	}
 )
@@ -0,0 +1,258 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo"
+	"github.com/andreyxaxa/Comment-Tree/pkg/types/errs"
+)
+
+// ImporterUseCase - перенос обсуждений из внешних систем. Каждая запись загружается
+// вместе с отметкой source/SourceID, поэтому повторный запуск на той же или дополненной
+// выгрузке загружает только новое, а ответы можно подвешивать к загруженному раньше.
+type ImporterUseCase struct {
+	repo repo.CommentRepo
+	cdn  repo.CDNWebAPI
+}
+
+func New(r repo.CommentRepo, cdn repo.CDNWebAPI) *ImporterUseCase {
+	return &ImporterUseCase{
+		repo: r,
+		cdn:  cdn,
+	}
+}
+
+// resolved - итог по одной записи выгрузки: id коммента или причина, по которой его нет.
+type resolved struct {
+	id     int64
+	reason string
+}
+
+func (uc *ImporterUseCase) Import(ctx context.Context, source string, comments []dto.SourceComment) (dto.SourceImportSummary, error) {
+	summary := dto.SourceImportSummary{
+		Source: source,
+		Total:  len(comments),
+	}
+
+	// records - записи, которые пойдут в загрузку, по SourceID; остальные сразу в done
+	records := make(map[string]dto.SourceComment, len(comments))
+	done := make(map[string]resolved, len(comments))
+
+	var order []string
+
+	for _, c := range comments {
+		skip := c.Skip
+
+		switch _, seen := records[c.SourceID]; {
+		case c.SourceID == "":
+			skip = "missing id"
+		case seen:
+			skip = "duplicate id"
+		case skip == "" && strings.TrimSpace(c.Content) == "":
+			skip = "content is empty"
+		}
+
+		if skip != "" {
+			summary.Skipped = append(summary.Skipped, issue(c, skip))
+
+			if _, ok := done[c.SourceID]; !ok && c.SourceID != "" && !isRecord(records, c.SourceID) {
+				done[c.SourceID] = resolved{reason: fmt.Sprintf("parent %s was skipped: %s", c.SourceID, skip)}
+			}
+
+			continue
+		}
+
+		records[c.SourceID] = c
+		order = append(order, c.SourceID)
+	}
+
+	mapping, err := uc.repo.GetSourceMapping(ctx, source, lookupIDs(records))
+	if err != nil {
+		return summary, fmt.Errorf("ImporterUseCase - Import - uc.repo.GetSourceMapping: %w", err)
+	}
+
+	// обход в ширину от записей, чей родитель не в этой выгрузке: родитель всегда раньше ребенка
+	children := make(map[string][]string)
+
+	var queue []string
+
+	for _, id := range order {
+		parentID := records[id].ParentSourceID
+		if isRecord(records, parentID) {
+			children[parentID] = append(children[parentID], id)
+		} else {
+			queue = append(queue, id)
+		}
+	}
+
+	// ancestors - предки загруженных комментов, чьи поддеревья изменились
+	ancestors := make(map[int64]struct{})
+	created := make(map[int64]struct{})
+
+	for i := 0; i < len(queue); i++ {
+		id := queue[i]
+		c := records[id]
+		queue = append(queue, children[id]...)
+
+		if commentID, ok := mapping[id]; ok {
+			summary.AlreadyImported++
+
+			done[id] = resolved{id: commentID, reason: deletedReason(id, commentID)}
+
+			continue
+		}
+
+		parent, reason := resolveParent(c.ParentSourceID, done, mapping)
+		if reason != "" {
+			summary.BrokenParents = append(summary.BrokenParents, issue(c, reason))
+			done[id] = resolved{reason: notImportedReason(id)}
+
+			continue
+		}
+
+		comment, err := uc.create(ctx, source, c, parent)
+
+		switch {
+		case errors.Is(err, errs.ErrAlreadyExists):
+			// запись успел загрузить параллельный импорт того же источника
+			raced, err := uc.repo.GetSourceMapping(ctx, source, []string{id})
+			if err != nil {
+				return summary, fmt.Errorf("ImporterUseCase - Import - uc.repo.GetSourceMapping: %w", err)
+			}
+
+			summary.AlreadyImported++
+			done[id] = resolved{id: raced[id], reason: deletedReason(id, raced[id])}
+		case errors.Is(err, errs.ErrRecordNotFound):
+			summary.BrokenParents = append(summary.BrokenParents,
+				issue(c, fmt.Sprintf("parent %s was deleted during import", c.ParentSourceID)))
+			done[id] = resolved{reason: notImportedReason(id)}
+		case err != nil:
+			return summary, fmt.Errorf("ImporterUseCase - Import - uc.create: %w", err)
+		default:
+			summary.Imported++
+			done[id] = resolved{id: comment.ID}
+			created[comment.ID] = struct{}{}
+
+			for _, ancestorID := range comment.Path[:len(comment.Path)-1] {
+				ancestors[ancestorID] = struct{}{}
+			}
+		}
+	}
+
+	// недостижимы только записи, чьи ссылки на родителей замкнуты в цикл
+	for _, id := range order {
+		if _, ok := done[id]; !ok {
+			summary.BrokenParents = append(summary.BrokenParents, issue(records[id], "parent references form a cycle"))
+		}
+	}
+
+	uc.purge(ctx, ancestors, created, summary.Imported > 0)
+
+	return summary, nil
+}
+
+func (uc *ImporterUseCase) create(ctx context.Context, source string, c dto.SourceComment, parentID int64) (entity.Comment, error) {
+	comment := entity.Comment{
+		Content:   c.Content,
+		Author:    c.Author,
+		CreatedAt: c.CreatedAt,
+	}
+
+	if parentID != 0 {
+		comment.ParentID = sql.NullInt64{Int64: parentID, Valid: true}
+	}
+
+	comment, err := uc.repo.CreateFromSource(ctx, source, c.SourceID, comment)
+	if err != nil {
+		return entity.Comment{}, fmt.Errorf("uc.repo.CreateFromSource: %w", err)
+	}
+
+	return comment, nil
+}
+
+// purge - сброс CDN для предков, существовавших до импорта; новые комменты еще никто не кэшировал.
+// Ошибку логирует сама реализация CDNWebAPI.
+func (uc *ImporterUseCase) purge(ctx context.Context, ancestors, created map[int64]struct{}, changed bool) {
+	if !changed {
+		return
+	}
+
+	keys := []string{entity.RootsSurrogateKey, entity.SearchSurrogateKey}
+
+	for id := range ancestors {
+		if _, ok := created[id]; !ok {
+			keys = append(keys, entity.CommentSurrogateKey(id))
+		}
+	}
+
+	_ = uc.cdn.Purge(ctx, keys)
+}
+
+// resolveParent - id родителя в хранилище (0 - корень) или причина, по которой его нет.
+func resolveParent(parentID string, done map[string]resolved, mapping map[string]int64) (int64, string) {
+	if parentID == "" {
+		return 0, ""
+	}
+
+	if r, ok := done[parentID]; ok {
+		return r.id, r.reason
+	}
+
+	if commentID, ok := mapping[parentID]; ok {
+		return commentID, deletedReason(parentID, commentID)
+	}
+
+	return 0, fmt.Sprintf("parent %s not found", parentID)
+}
+
+func notImportedReason(sourceID string) string {
+	return fmt.Sprintf("parent %s was not imported", sourceID)
+}
+
+func deletedReason(sourceID string, commentID int64) string {
+	if commentID != 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("parent %s was deleted after import", sourceID)
+}
+
+// lookupIDs - записи выгрузки и родители, на которые они ссылаются: те могли быть загружены раньше.
+func lookupIDs(records map[string]dto.SourceComment) []string {
+	ids := make([]string, 0, len(records))
+	parents := make(map[string]struct{})
+
+	for id, c := range records {
+		ids = append(ids, id)
+
+		if c.ParentSourceID != "" && !isRecord(records, c.ParentSourceID) {
+			parents[c.ParentSourceID] = struct{}{}
+		}
+	}
+
+	for id := range parents {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func isRecord(records map[string]dto.SourceComment, id string) bool {
+	_, ok := records[id]
+
+	return ok
+}
+
+func issue(c dto.SourceComment, reason string) dto.SourceImportIssue {
+	return dto.SourceImportIssue{
+		SourceID:       c.SourceID,
+		ParentSourceID: c.ParentSourceID,
+		Reason:         reason,
+	}
+}
@@ -0,0 +1,126 @@
+package importer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/internal/dto"
+	"github.com/andreyxaxa/Comment-Tree/internal/entity"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/importer"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	r := memory.New()
+	uc := importer.New(r, webapi.NewCDN("", "", "", "", time.Second, logger.New("error")))
+
+	createdAt := time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// ответ идет раньше родителя, как бывает в выгрузках Disqus
+	dump := []dto.SourceComment{
+		{SourceID: "b", ParentSourceID: "a", Author: "bob", Content: "reply"},
+		{SourceID: "a", Author: "alice", Content: "root", CreatedAt: createdAt},
+		{SourceID: "c", ParentSourceID: "x", Content: "orphan"},
+		{SourceID: "d", ParentSourceID: "c", Content: "orphan reply"},
+		{SourceID: "e", Content: "spam", Skip: "marked as spam"},
+		{SourceID: "f", ParentSourceID: "e", Content: "reply to spam"},
+		{SourceID: "g", ParentSourceID: "h", Content: "cycle"},
+		{SourceID: "h", ParentSourceID: "g", Content: "cycle"},
+		{SourceID: "a", Content: "duplicate"},
+	}
+
+	summary, err := uc.Import(ctx, "disqus", dump)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	if summary.Total != 9 || summary.Imported != 2 || summary.AlreadyImported != 0 {
+		t.Fatalf("summary = %+v, want 9 records, 2 imported", summary)
+	}
+
+	assertIssues(t, "skipped", summary.Skipped, map[string]string{
+		"e": "marked as spam",
+		"a": "duplicate id",
+	})
+
+	assertIssues(t, "broken", summary.BrokenParents, map[string]string{
+		"c": "parent x not found",
+		"d": "parent c was not imported",
+		"f": "parent e was skipped: marked as spam",
+		"g": "parent references form a cycle",
+		"h": "parent references form a cycle",
+	})
+
+	mapping, err := r.GetSourceMapping(ctx, "disqus", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("GetSourceMapping: %v", err)
+	}
+
+	tree, err := r.GetCommentWithChildren(ctx, mapping["a"], entity.ChildSortOldest)
+	if err != nil {
+		t.Fatalf("GetCommentWithChildren: %v", err)
+	}
+
+	if len(tree) != 2 || tree[1].ID != mapping["b"] || tree[0].Author != "alice" || !tree[0].CreatedAt.Equal(createdAt) {
+		t.Fatalf("tree = %+v, want alice's root created at %v with bob's reply", tree, createdAt)
+	}
+
+	// повторный запуск на дополненной выгрузке загружает только новое,
+	// ответ на загруженный раньше коммент находит родителя через import_sources
+	summary, err = uc.Import(ctx, "disqus", []dto.SourceComment{
+		{SourceID: "a", Content: "root"},
+		{SourceID: "i", ParentSourceID: "b", Content: "late reply"},
+	})
+	if err != nil {
+		t.Fatalf("second Import: %v", err)
+	}
+
+	if summary.Imported != 1 || summary.AlreadyImported != 1 || len(summary.BrokenParents) != 0 {
+		t.Fatalf("second summary = %+v, want 1 imported and 1 already imported", summary)
+	}
+
+	tree, err = r.GetCommentWithChildren(ctx, mapping["a"], entity.ChildSortOldest)
+	if err != nil || len(tree) != 3 || tree[2].Depth != 2 {
+		t.Fatalf("tree after second import = %+v, %v; want the late reply at depth 2", tree, err)
+	}
+
+	// удаленное после импорта не возвращается, ответы на него - сломанные родители
+	_, err = r.DeleteCommentWithChildren(ctx, mapping["a"])
+	if err != nil {
+		t.Fatalf("DeleteCommentWithChildren: %v", err)
+	}
+
+	summary, err = uc.Import(ctx, "disqus", []dto.SourceComment{
+		{SourceID: "a", Content: "root"},
+		{SourceID: "j", ParentSourceID: "b", Content: "reply to deleted"},
+	})
+	if err != nil {
+		t.Fatalf("third Import: %v", err)
+	}
+
+	if summary.Imported != 0 || summary.AlreadyImported != 1 {
+		t.Fatalf("third summary = %+v, want nothing imported", summary)
+	}
+
+	assertIssues(t, "broken", summary.BrokenParents, map[string]string{
+		"j": "parent b was deleted after import",
+	})
+}
+
+func assertIssues(t *testing.T, kind string, got []dto.SourceImportIssue, want map[string]string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s = %+v, want %v", kind, got, want)
+	}
+
+	for _, i := range got {
+		if want[i.SourceID] != i.Reason {
+			t.Fatalf("%s %s: reason %q, want %q", kind, i.SourceID, i.Reason, want[i.SourceID])
+		}
+	}
+}
@@ -0,0 +1,6 @@
+-- version и updated_at коммента меняются при любом изменении в его поддереве
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT now();
+
+UPDATE comments SET updated_at = created_at WHERE created_at IS NOT NULL;
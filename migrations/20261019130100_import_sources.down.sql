@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_import_sources_comment_id;
+DROP TABLE IF EXISTS import_sources;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_pinned_roots;
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS highlighted,
+    DROP COLUMN IF EXISTS pin_order;
@@ -0,0 +1,6 @@
+-- pin_order - NULL у незакрепленных; закрепленные идут первыми среди братьев (и среди корней)
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS pin_order INTEGER,
+    ADD COLUMN IF NOT EXISTS highlighted BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_pinned_roots ON comments(pin_order) WHERE parent_id IS NULL AND pin_order IS NOT NULL;
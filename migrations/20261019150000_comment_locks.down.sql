@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_active_roots_updated_at;
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS lock_reason,
+    DROP COLUMN IF EXISTS lock_state;
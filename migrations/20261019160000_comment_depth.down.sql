@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_reply_to_id;
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS max_depth,
+    DROP COLUMN IF EXISTS reply_to_id;
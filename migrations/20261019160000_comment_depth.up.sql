@@ -0,0 +1,7 @@
+-- reply_to_id - на какой коммент на самом деле отвечали, если ответ перенесли выше из-за лимита глубины;
+-- max_depth - лимит глубины дерева у корня, NULL - общий из конфига, 0 - без лимита
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS reply_to_id INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS max_depth INTEGER CHECK (max_depth >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_reply_to_id ON comments(reply_to_id) WHERE reply_to_id IS NOT NULL;
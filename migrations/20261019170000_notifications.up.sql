@@ -0,0 +1,27 @@
+-- входящие уведомления: ответ на коммент получателя или упоминание @username;
+-- уходят вместе с комментом, о котором уведомляют
+CREATE TABLE IF NOT EXISTS notifications
+(
+    id BIGSERIAL PRIMARY KEY,
+    recipient TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('reply', 'mention')),
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    read_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_recipient ON notifications(recipient, id);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(recipient, id) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_comment_id ON notifications(comment_id);
+
+-- заглушенные ветки: уведомления о комментах в поддереве comment_id получателю не приходят
+CREATE TABLE IF NOT EXISTS thread_mutes
+(
+    recipient TEXT NOT NULL,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (recipient, comment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_mutes_comment_id ON thread_mutes(comment_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_digested_at;
+DROP INDEX IF EXISTS idx_subscriptions_comment_id;
+DROP TABLE IF EXISTS subscriptions;
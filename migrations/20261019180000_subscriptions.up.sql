@@ -0,0 +1,15 @@
+-- подписки на дайджест новых комментов в поддереве comment_id;
+-- last_comment_id - самый новый коммент, уже попавший в дайджест
+CREATE TABLE IF NOT EXISTS subscriptions
+(
+    subscriber TEXT NOT NULL,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    last_comment_id INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    digested_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscriber, comment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_comment_id ON subscriptions(comment_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_digested_at ON subscriptions(digested_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_root_visits_root_id;
+DROP TABLE IF EXISTS root_visits;
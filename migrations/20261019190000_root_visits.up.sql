@@ -0,0 +1,12 @@
+-- последний визит пользователя в тред корня root_id;
+-- last_seen_id - самый новый коммент на момент визита, комменты новее - непрочитанные
+CREATE TABLE IF NOT EXISTS root_visits
+(
+    viewer TEXT NOT NULL,
+    root_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    last_seen_id INTEGER NOT NULL,
+    seen_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (viewer, root_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_root_visits_root_id ON root_visits(root_id);
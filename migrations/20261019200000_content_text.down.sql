@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_content_tsv;
+ALTER TABLE comments DROP COLUMN IF EXISTS content_tsv;
+ALTER TABLE comments ADD COLUMN content_tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content)) STORED;
+CREATE INDEX IF NOT EXISTS idx_content_tsv ON comments USING GIN(content_tsv);
+
+ALTER TABLE comments DROP COLUMN IF EXISTS content_text;
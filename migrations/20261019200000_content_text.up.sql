@@ -0,0 +1,10 @@
+-- content - исходный markdown, content_text - текст без разметки, по нему строится поиск;
+-- до markdown комменты были простым текстом, поэтому их текст - сам content
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_text TEXT NOT NULL DEFAULT '';
+
+UPDATE comments SET content_text = content;
+
+DROP INDEX IF EXISTS idx_content_tsv;
+ALTER TABLE comments DROP COLUMN IF EXISTS content_tsv;
+ALTER TABLE comments ADD COLUMN content_tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', content_text)) STORED;
+CREATE INDEX IF NOT EXISTS idx_content_tsv ON comments USING GIN(content_tsv);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_comment_references_target_id;
+DROP TABLE IF EXISTS comment_references;
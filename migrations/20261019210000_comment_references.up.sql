@@ -0,0 +1,11 @@
+-- ссылки ">>id" из текста коммента source_id на коммент target_id, position - порядок в тексте;
+-- у target_id нет внешнего ключа: ссылка на удаленный коммент остается и показывается как недоступная
+CREATE TABLE IF NOT EXISTS comment_references
+(
+    source_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    target_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (source_id, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_references_target_id ON comment_references(target_id, source_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_attachments_orphans;
+DROP INDEX IF EXISTS idx_attachments_comment_id;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,21 @@
+-- вложения: файлы лежат в BlobStore под storage_key (и thumbnail_key у картинок), здесь - только их описание;
+-- comment_id - NULL, пока файл загружен, но еще не прикреплен; при удалении коммента тоже
+-- становится NULL, а attached_at остается - такие строки и их файлы удаляет фоновая задача
+CREATE TABLE IF NOT EXISTS attachments
+(
+    id BIGSERIAL PRIMARY KEY,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+    uploader TEXT NOT NULL DEFAULT '',
+    storage_key TEXT NOT NULL UNIQUE,
+    thumbnail_key TEXT NOT NULL DEFAULT '',
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    attached_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_comment_id ON attachments(comment_id, id);
+CREATE INDEX IF NOT EXISTS idx_attachments_orphans ON attachments(created_at) WHERE comment_id IS NULL;
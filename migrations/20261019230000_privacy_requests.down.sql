@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_notifications_actor;
+DROP INDEX IF EXISTS idx_attachments_uploader;
+DROP INDEX IF EXISTS idx_comments_author;
+DROP INDEX IF EXISTS idx_privacy_requests_author;
+DROP TABLE IF EXISTS privacy_requests;
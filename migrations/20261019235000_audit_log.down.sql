@@ -0,0 +1,12 @@
+-- роль audit_scrubber общая на кластер и может быть нужна другим базам, она остается
+DROP FUNCTION IF EXISTS audit_log_scrub(TEXT, TEXT);
+DROP FUNCTION IF EXISTS audit_log_scrub_snapshot(JSONB, TEXT, TEXT);
+DROP TRIGGER IF EXISTS audit_log_no_update ON audit_log;
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+DROP TRIGGER IF EXISTS audit_log_no_delete ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+DROP INDEX IF EXISTS idx_audit_log_action;
+DROP INDEX IF EXISTS idx_audit_log_comment_id;
+DROP INDEX IF EXISTS idx_audit_log_actor;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,100 @@
+-- журнал изменений: пишется в той же транзакции, что и изменение;
+-- comment_id без внешнего ключа - записи об удаленных комментах остаются
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id BIGSERIAL PRIMARY KEY,
+    action TEXT NOT NULL,
+    comment_id BIGINT,
+    actor TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, id) WHERE actor <> '';
+CREATE INDEX IF NOT EXISTS idx_audit_log_comment_id ON audit_log(comment_id, id) WHERE comment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+
+-- записи не удаляются и не меняются. Единственное исключение - стирание автора:
+-- UPDATE проходит, только если его выполняет роль audit_scrubber, а от ее имени работает
+-- лишь audit_log_scrub (SECURITY DEFINER). Клиент эту проверку настройкой сессии не обойдет
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND current_user = 'audit_scrubber' THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_delete
+    BEFORE DELETE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- audit_log_scrub_snapshot - снимок, где у комментов author стерты автор и текст (текст - на content),
+-- как entity.ScrubAuditSnapshot. id из import и erase не объекты и не меняются
+CREATE OR REPLACE FUNCTION audit_log_scrub_snapshot(snapshot JSONB, author TEXT, content TEXT) RETURNS JSONB AS $$
+    SELECT CASE jsonb_typeof(snapshot)
+        WHEN 'object' THEN
+            CASE WHEN snapshot->>'author' = author
+                THEN snapshot || jsonb_build_object('author', '', 'content', content)
+                ELSE snapshot
+            END
+        WHEN 'array' THEN COALESCE((
+            SELECT jsonb_agg(
+                CASE WHEN jsonb_typeof(c) = 'object' AND c->>'author' = author
+                    THEN c || jsonb_build_object('author', '', 'content', content)
+                    ELSE c
+                END ORDER BY n)
+            FROM jsonb_array_elements(snapshot) WITH ORDINALITY AS s(c, n)
+        ), snapshot)
+        ELSE snapshot
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- роль без входа: владеет только audit_log_scrub. Роли общие на кластер, поэтому создается один раз
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'audit_scrubber') THEN
+        CREATE ROLE audit_scrubber NOLOGIN;
+    END IF;
+
+    EXECUTE format('GRANT USAGE ON SCHEMA %I TO audit_scrubber', current_schema());
+END;
+$$;
+
+GRANT SELECT, UPDATE ON audit_log TO audit_scrubber;
+
+-- audit_log_scrub - стирает author из журнала: его имя и адрес в записях о его действиях,
+-- его имя и текст в снимках его комментов. Вызывается в транзакции стирания автора
+CREATE OR REPLACE FUNCTION audit_log_scrub(author TEXT, content TEXT) RETURNS void AS $$
+    UPDATE audit_log SET
+        actor = CASE WHEN actor = $1 THEN '' ELSE actor END,
+        ip = CASE WHEN actor = $1 THEN '' ELSE ip END,
+        before = audit_log_scrub_snapshot(before, $1, $2),
+        after = audit_log_scrub_snapshot(after, $1, $2)
+    WHERE actor = $1
+        OR before @> jsonb_build_object('author', $1) OR before @> jsonb_build_array(jsonb_build_object('author', $1))
+        OR after @> jsonb_build_object('author', $1) OR after @> jsonb_build_array(jsonb_build_object('author', $1));
+$$ LANGUAGE sql SECURITY DEFINER SET search_path FROM CURRENT;
+
+REVOKE ALL ON FUNCTION audit_log_scrub(TEXT, TEXT) FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION audit_log_scrub(TEXT, TEXT) TO CURRENT_USER;
+
+-- сменить владельца можно, только будучи членом роли; членство сразу забирается,
+-- иначе через SET ROLE audit_scrubber журнал можно было бы менять напрямую
+GRANT audit_scrubber TO CURRENT_USER;
+ALTER FUNCTION audit_log_scrub(TEXT, TEXT) OWNER TO audit_scrubber;
+REVOKE audit_scrubber FROM CURRENT_USER;
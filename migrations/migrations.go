@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+var (
+	// FS - SQL-миграции Postgres в формате golang-migrate, вшитые в бинарник.
+	//
+	//go:embed *.sql
+	FS embed.FS
+
+	//go:embed sqlite/*.sql
+	sqliteFS embed.FS
+)
+
+// SQLite - те же миграции для SQLite-хранилища.
+func SQLite() fs.FS {
+	sub, _ := fs.Sub(sqliteFS, "sqlite")
+
+	return sub
+}
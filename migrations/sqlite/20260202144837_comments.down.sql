@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS comments_fts_update;
+DROP TRIGGER IF EXISTS comments_fts_delete;
+DROP TRIGGER IF EXISTS comments_fts_insert;
+DROP TABLE IF EXISTS comments_fts;
+DROP INDEX IF EXISTS idx_parent_id;
+DROP INDEX IF EXISTS idx_created_at;
+DROP TABLE IF EXISTS comments;
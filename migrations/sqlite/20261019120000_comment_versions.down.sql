@@ -0,0 +1,2 @@
+ALTER TABLE comments DROP COLUMN updated_at;
+ALTER TABLE comments DROP COLUMN version;
@@ -0,0 +1,5 @@
+-- version и updated_at коммента меняются при любом изменении в его поддереве
+ALTER TABLE comments ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE comments ADD COLUMN updated_at TIMESTAMP;
+
+UPDATE comments SET updated_at = created_at;
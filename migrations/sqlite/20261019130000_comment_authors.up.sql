@@ -0,0 +1 @@
+ALTER TABLE comments ADD COLUMN author TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+-- соответствие id внешней системы (Disqus, WordPress, Reddit) и загруженного коммента;
+-- comment_id становится NULL после удаления коммента, чтобы повторный импорт его не вернул
+CREATE TABLE IF NOT EXISTS import_sources
+(
+    source TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+    imported_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (source, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_sources_comment_id ON import_sources(comment_id);
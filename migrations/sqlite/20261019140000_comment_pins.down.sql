@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pinned_roots;
+ALTER TABLE comments DROP COLUMN highlighted;
+ALTER TABLE comments DROP COLUMN pin_order;
@@ -0,0 +1,5 @@
+-- pin_order - NULL у незакрепленных; закрепленные идут первыми среди братьев (и среди корней)
+ALTER TABLE comments ADD COLUMN pin_order INTEGER;
+ALTER TABLE comments ADD COLUMN highlighted BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_pinned_roots ON comments(pin_order) WHERE parent_id IS NULL AND pin_order IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_active_roots_updated_at;
+ALTER TABLE comments DROP COLUMN lock_reason;
+ALTER TABLE comments DROP COLUMN lock_state;
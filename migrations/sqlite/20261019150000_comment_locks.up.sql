@@ -0,0 +1,7 @@
+-- lock_state: '' - открыт, 'locked' - без новых ответов, 'archived' - только чтение;
+-- действует на все поддерево коммента
+ALTER TABLE comments ADD COLUMN lock_state TEXT NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN lock_reason TEXT NOT NULL DEFAULT '';
+
+-- автоархивация ищет неактивные корни по updated_at
+CREATE INDEX IF NOT EXISTS idx_active_roots_updated_at ON comments(updated_at) WHERE parent_id IS NULL AND lock_state <> 'archived';
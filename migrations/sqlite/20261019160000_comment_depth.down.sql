@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_reply_to_id;
+ALTER TABLE comments DROP COLUMN max_depth;
+ALTER TABLE comments DROP COLUMN reply_to_id;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_thread_mutes_comment_id;
+DROP TABLE IF EXISTS thread_mutes;
+DROP INDEX IF EXISTS idx_notifications_comment_id;
+DROP INDEX IF EXISTS idx_notifications_unread;
+DROP INDEX IF EXISTS idx_notifications_recipient;
+DROP TABLE IF EXISTS notifications;
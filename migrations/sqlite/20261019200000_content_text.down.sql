@@ -0,0 +1,28 @@
+DROP TRIGGER IF EXISTS comments_fts_insert;
+DROP TRIGGER IF EXISTS comments_fts_delete;
+DROP TRIGGER IF EXISTS comments_fts_update;
+DROP TABLE IF EXISTS comments_fts;
+
+ALTER TABLE comments DROP COLUMN content_text;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS comments_fts USING fts5(
+    content,
+    content='comments',
+    content_rowid='id',
+    tokenize='porter unicode61'
+);
+
+INSERT INTO comments_fts(comments_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_insert AFTER INSERT ON comments BEGIN
+    INSERT INTO comments_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_delete AFTER DELETE ON comments BEGIN
+    INSERT INTO comments_fts(comments_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_update AFTER UPDATE OF content ON comments BEGIN
+    INSERT INTO comments_fts(comments_fts, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO comments_fts(rowid, content) VALUES (new.id, new.content);
+END;
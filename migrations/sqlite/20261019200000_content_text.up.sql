@@ -0,0 +1,32 @@
+-- content - исходный markdown, content_text - текст без разметки, по нему строится поиск;
+-- до markdown комменты были простым текстом, поэтому их текст - сам content
+DROP TRIGGER IF EXISTS comments_fts_insert;
+DROP TRIGGER IF EXISTS comments_fts_delete;
+DROP TRIGGER IF EXISTS comments_fts_update;
+DROP TABLE IF EXISTS comments_fts;
+
+ALTER TABLE comments ADD COLUMN content_text TEXT NOT NULL DEFAULT '';
+
+UPDATE comments SET content_text = content;
+
+CREATE VIRTUAL TABLE IF NOT EXISTS comments_fts USING fts5(
+    content_text,
+    content='comments',
+    content_rowid='id',
+    tokenize='porter unicode61'
+);
+
+INSERT INTO comments_fts(comments_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_insert AFTER INSERT ON comments BEGIN
+    INSERT INTO comments_fts(rowid, content_text) VALUES (new.id, new.content_text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_delete AFTER DELETE ON comments BEGIN
+    INSERT INTO comments_fts(comments_fts, rowid, content_text) VALUES ('delete', old.id, old.content_text);
+END;
+
+CREATE TRIGGER IF NOT EXISTS comments_fts_update AFTER UPDATE OF content_text ON comments BEGIN
+    INSERT INTO comments_fts(comments_fts, rowid, content_text) VALUES ('delete', old.id, old.content_text);
+    INSERT INTO comments_fts(rowid, content_text) VALUES (new.id, new.content_text);
+END;
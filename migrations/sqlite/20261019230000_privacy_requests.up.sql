@@ -0,0 +1,19 @@
+-- журнал выгрузок и стираний данных автора (запросы субъектов данных);
+-- author - тот, чьи данные, actor - кто выполнил, reason - обязательное основание
+CREATE TABLE IF NOT EXISTS privacy_requests
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL CHECK (action IN ('export', 'anonymize', 'delete')),
+    author TEXT NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL,
+    comments INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_privacy_requests_author ON privacy_requests(author, id);
+
+-- выгрузка и стирание ищут комменты и загрузки автора
+CREATE INDEX IF NOT EXISTS idx_comments_author ON comments(author) WHERE author <> '';
+CREATE INDEX IF NOT EXISTS idx_attachments_uploader ON attachments(uploader) WHERE uploader <> '';
+CREATE INDEX IF NOT EXISTS idx_notifications_actor ON notifications(actor) WHERE actor <> '';
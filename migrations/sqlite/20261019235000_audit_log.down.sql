@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS audit_log_no_update;
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+DROP TABLE IF EXISTS audit_log_scrub;
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+DROP INDEX IF EXISTS idx_audit_log_action;
+DROP INDEX IF EXISTS idx_audit_log_comment_id;
+DROP INDEX IF EXISTS idx_audit_log_actor;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,40 @@
+-- журнал изменений: пишется в той же транзакции, что и изменение;
+-- comment_id без внешнего ключа - записи об удаленных комментах остаются
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL,
+    comment_id INTEGER,
+    actor TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    before TEXT,
+    after TEXT,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor, id) WHERE actor <> '';
+CREATE INDEX IF NOT EXISTS idx_audit_log_comment_id ON audit_log(comment_id, id) WHERE comment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+
+-- записи не удаляются и не меняются. Единственное исключение - стирание автора (scrubAudit):
+-- настроек сессии в SQLite нет, поэтому оно вставляет строку в audit_log_scrub в начале своей
+-- транзакции и удаляет ее перед коммитом. Писатель один, так что другие ее не видят
+CREATE TABLE IF NOT EXISTS audit_log_scrub
+(
+    active INTEGER NOT NULL
+);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+    BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+    BEFORE UPDATE ON audit_log
+    WHEN NOT EXISTS (SELECT 1 FROM audit_log_scrub)
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
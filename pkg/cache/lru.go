@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultMaxEntries = 10_000
+	_defaultMaxBytes   = 64 << 20
+)
+
+// LRU - кэш в памяти процесса, вытесняющий давно не использованные ключи
+// при превышении лимита по числу ключей или по размеру.
+type LRU struct {
+	maxEntries int
+	maxBytes   int64
+
+	mu    sync.Mutex
+	bytes int64
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+var _ Interface = (*LRU)(nil)
+
+func NewLRU(opts ...LRUOption) *LRU {
+	c := &LRU{
+		maxEntries: _defaultMaxEntries,
+		maxBytes:   _defaultMaxBytes,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*lruEntry) //nolint:forcetypeassert
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		c.remove(el)
+
+		return nil, false, nil
+	}
+
+	c.ll.MoveToFront(el)
+
+	return e.value, true, nil
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	e := &lruEntry{
+		key:   key,
+		value: value,
+	}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+
+	// значение больше всего кэша не сохраняем
+	if c.maxBytes > 0 && entrySize(e) > c.maxBytes {
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(e)
+	c.bytes += entrySize(e)
+
+	for c.overflow() {
+		c.remove(c.ll.Back())
+	}
+
+	return nil
+}
+
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+
+	return nil
+}
+
+// Len - текущее число ключей.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRU) overflow() bool {
+	if c.ll.Len() == 0 {
+		return false
+	}
+
+	return (c.maxEntries > 0 && c.ll.Len() > c.maxEntries) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+func (c *LRU) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*lruEntry) //nolint:forcetypeassert
+	delete(c.items, e.key)
+	c.bytes -= entrySize(e)
+}
+
+func entrySize(e *lruEntry) int64 {
+	return int64(len(e.key) + len(e.value))
+}
@@ -0,0 +1,39 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/cache"
+)
+
+func TestLRULimits(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(cache.MaxEntries(2), cache.MaxBytes(1<<10))
+
+	_ = c.Set(ctx, "a", []byte("1"), 0)
+	_ = c.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = c.Get(ctx, "a")
+	_ = c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Fatal("least recently used key b must be evicted")
+	}
+
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("recently used key a must stay")
+	}
+
+	_ = c.Set(ctx, "big", make([]byte, 2<<10), 0)
+	if _, ok, _ := c.Get(ctx, "big"); ok {
+		t.Fatal("value larger than MaxBytes must not be stored")
+	}
+
+	_ = c.Set(ctx, "ttl", []byte("x"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if _, ok, _ := c.Get(ctx, "ttl"); ok {
+		t.Fatal("expired key must not be returned")
+	}
+}
@@ -0,0 +1,38 @@
+package cache
+
+type LRUOption func(*LRU)
+
+// MaxEntries - максимальное число ключей, 0 - без ограничения.
+func MaxEntries(n int) LRUOption {
+	return func(c *LRU) {
+		c.maxEntries = n
+	}
+}
+
+// MaxBytes - максимальный суммарный размер ключей и значений, 0 - без ограничения.
+func MaxBytes(n int64) LRUOption {
+	return func(c *LRU) {
+		c.maxBytes = n
+	}
+}
+
+type RedisOption func(*Redis)
+
+func Password(password string) RedisOption {
+	return func(c *Redis) {
+		c.password = password
+	}
+}
+
+func DB(db int) RedisOption {
+	return func(c *Redis) {
+		c.db = db
+	}
+}
+
+// Prefix - префикс всех ключей, чтобы несколько сервисов могли делить один Redis.
+func Prefix(prefix string) RedisOption {
+	return func(c *Redis) {
+		c.prefix = prefix
+	}
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis - кэш поверх любого сервера с протоколом Redis (Redis, Valkey, KeyDB, Dragonfly).
+type Redis struct {
+	password string
+	db       int
+	prefix   string
+
+	client *redis.Client
+}
+
+var _ Interface = (*Redis)(nil)
+
+func NewRedis(addr string, opts ...RedisOption) *Redis {
+	c := &Redis{}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.client = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: c.password,
+		DB:       c.db,
+	})
+
+	return c
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+
+		return nil, false, fmt.Errorf("cache - Redis - Get: %w", err)
+	}
+
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("cache - Redis - Set: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = c.prefix + k
+	}
+
+	err := c.client.Del(ctx, prefixed...).Err()
+	if err != nil {
+		return fmt.Errorf("cache - Redis - Delete: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Redis) Ping(ctx context.Context) error {
+	err := c.client.Ping(ctx).Err()
+	if err != nil {
+		return fmt.Errorf("cache - Redis - Ping: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
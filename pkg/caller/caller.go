@@ -0,0 +1,30 @@
+// Package caller - кто сделал запрос: пользователь и адрес клиента.
+// Адрес кладут серверы, пользователя - контроллеры, когда разобрали его из запроса.
+package caller
+
+import "context"
+
+type Info struct {
+	User string
+	IP   string
+}
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(ctxKey{}).(Info)
+
+	return info
+}
+
+// WithUser - тот же Info, что в ctx, с пользователем user.
+func WithUser(ctx context.Context, user string) context.Context {
+	info := FromContext(ctx)
+	info.User = user
+
+	return NewContext(ctx, info)
+}
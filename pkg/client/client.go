@@ -0,0 +1,635 @@
+// Package client - Go-клиент REST API сервиса комментариев (/v1/comments).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+)
+
+const (
+	_defaultTimeout    = 10 * time.Second
+	_defaultMaxRetries = 3
+	_defaultMinBackoff = 100 * time.Millisecond
+	_defaultMaxBackoff = 2 * time.Second
+
+	// _maxErrorBody - тело ошибки читаем не дальше этого
+	_maxErrorBody = 64 << 10
+)
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	user       string
+	adminToken string
+}
+
+// New - baseURL без /v1, например http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client - New - url.Parse: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client - New: unsupported scheme %q", u.Scheme)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: _defaultTimeout},
+		maxRetries: _defaultMaxRetries,
+		minBackoff: _defaultMinBackoff,
+		maxBackoff: _defaultMaxBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// CreateComment - POST не идемпотентен, поэтому не повторяется.
+// attachmentIDs - вложения, загруженные заранее через UploadAttachment тем же пользователем.
+func (c *Client) CreateComment(ctx context.Context, parentID *int64, content string, attachmentIDs ...int64) (*Comment, error) {
+	body, err := json.Marshal(createCommentRequest{ParentID: parentID, Content: content, AttachmentIDs: attachmentIDs})
+	if err != nil {
+		return nil, fmt.Errorf("client - CreateComment - json.Marshal: %w", err)
+	}
+
+	var comment Comment
+
+	err = c.do(ctx, http.MethodPost, "/v1/comments", nil, body, &comment)
+	if err != nil {
+		return nil, fmt.Errorf("client - CreateComment: %w", err)
+	}
+
+	return &comment, nil
+}
+
+// ListRoots - страница корневых комментов, у каждого - все дерево ответов.
+func (c *Client) ListRoots(ctx context.Context, params ListParams) (*Page, error) {
+	page, err := c.list(ctx, params.query())
+	if err != nil {
+		return nil, fmt.Errorf("client - ListRoots: %w", err)
+	}
+
+	return page, nil
+}
+
+// GetTree - коммент id со всеми ответами.
+func (c *Client) GetTree(ctx context.Context, id int64) (*Comment, error) {
+	q := url.Values{}
+	q.Set("parent_id", strconv.FormatInt(id, 10))
+
+	page, err := c.list(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("client - GetTree: %w", err)
+	}
+
+	if len(page.Comments) == 0 {
+		return nil, fmt.Errorf("client - GetTree: %w", &Error{StatusCode: http.StatusNotFound, Message: "comment not found"})
+	}
+
+	return page.Comments[0], nil
+}
+
+// Search - поиск по тексту, найденные комменты возвращаются вместе с ответами.
+func (c *Client) Search(ctx context.Context, query string, params ListParams) (*Page, error) {
+	q := params.query()
+	q.Set("search", query)
+
+	page, err := c.list(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("client - Search: %w", err)
+	}
+
+	return page, nil
+}
+
+// DeleteComment - удаляет коммент вместе с ответами.
+// 404 на повторе значит, что удалила одна из прошлых попыток, и ошибкой не считается.
+func (c *Client) DeleteComment(ctx context.Context, id int64) error {
+	err := c.do(ctx, http.MethodDelete, commentPath(id), nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("client - DeleteComment: %w", err)
+	}
+
+	return nil
+}
+
+// PinComment - закрепляет коммент, меньший order - выше.
+func (c *Client) PinComment(ctx context.Context, id, order int64) error {
+	body, err := json.Marshal(pinCommentRequest{Order: order})
+	if err != nil {
+		return fmt.Errorf("client - PinComment - json.Marshal: %w", err)
+	}
+
+	err = c.do(ctx, http.MethodPut, commentPath(id)+"/pin", nil, body, nil)
+	if err != nil {
+		return fmt.Errorf("client - PinComment: %w", err)
+	}
+
+	return nil
+}
+
+// UnpinComment - снимает закрепление.
+func (c *Client) UnpinComment(ctx context.Context, id int64) error {
+	err := c.do(ctx, http.MethodDelete, commentPath(id)+"/pin", nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("client - UnpinComment: %w", err)
+	}
+
+	return nil
+}
+
+// SetHighlighted - выделяет коммент или снимает выделение.
+func (c *Client) SetHighlighted(ctx context.Context, id int64, highlighted bool) error {
+	method := http.MethodPut
+	if !highlighted {
+		method = http.MethodDelete
+	}
+
+	err := c.do(ctx, method, commentPath(id)+"/highlight", nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("client - SetHighlighted: %w", err)
+	}
+
+	return nil
+}
+
+// LockComment - блокирует коммент с ответами: LockLocked запрещает новые ответы,
+// LockArchived делает ветку доступной только для чтения.
+func (c *Client) LockComment(ctx context.Context, id int64, state, reason string) error {
+	body, err := json.Marshal(lockCommentRequest{State: state, Reason: reason})
+	if err != nil {
+		return fmt.Errorf("client - LockComment - json.Marshal: %w", err)
+	}
+
+	err = c.do(ctx, http.MethodPut, commentPath(id)+"/lock", nil, body, nil)
+	if err != nil {
+		return fmt.Errorf("client - LockComment: %w", err)
+	}
+
+	return nil
+}
+
+// UnlockComment - снимает блокировку самого коммента, блокировка предков остается.
+func (c *Client) UnlockComment(ctx context.Context, id int64) error {
+	err := c.do(ctx, http.MethodDelete, commentPath(id)+"/lock", nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("client - UnlockComment: %w", err)
+	}
+
+	return nil
+}
+
+// SetMaxDepth - лимит глубины дерева корня id (0 - без лимита), nil - вернуть общий.
+func (c *Client) SetMaxDepth(ctx context.Context, id int64, maxDepth *int64) error {
+	if maxDepth == nil {
+		err := c.do(ctx, http.MethodDelete, commentPath(id)+"/max-depth", nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("client - SetMaxDepth: %w", err)
+		}
+
+		return nil
+	}
+
+	body, err := json.Marshal(setMaxDepthRequest{MaxDepth: *maxDepth})
+	if err != nil {
+		return fmt.Errorf("client - SetMaxDepth - json.Marshal: %w", err)
+	}
+
+	err = c.do(ctx, http.MethodPut, commentPath(id)+"/max-depth", nil, body, nil)
+	if err != nil {
+		return fmt.Errorf("client - SetMaxDepth: %w", err)
+	}
+
+	return nil
+}
+
+// ListNotifications - страница уведомлений пользователя из User от новых к старым.
+// Следующая страница - с Cursor из NextCursor, пустой NextCursor - страница последняя.
+func (c *Client) ListNotifications(ctx context.Context, params NotificationParams) (*NotificationPage, error) {
+	var page NotificationPage
+
+	err := c.do(ctx, http.MethodGet, "/v1/notifications", params.query(), nil, &page)
+	if err != nil {
+		return nil, fmt.Errorf("client - ListNotifications: %w", err)
+	}
+
+	return &page, nil
+}
+
+// MarkNotificationsRead - отмечает прочитанными уведомления ids (не больше 100), nil - все.
+// Возвращает, сколько из них было непрочитано.
+func (c *Client) MarkNotificationsRead(ctx context.Context, ids []int64) (int, error) {
+	body, err := json.Marshal(markNotificationsReadRequest{IDs: ids, All: ids == nil})
+	if err != nil {
+		return 0, fmt.Errorf("client - MarkNotificationsRead - json.Marshal: %w", err)
+	}
+
+	var resp markNotificationsReadResponse
+
+	err = c.do(ctx, http.MethodPost, "/v1/notifications/read", nil, body, &resp)
+	if err != nil {
+		return 0, fmt.Errorf("client - MarkNotificationsRead: %w", err)
+	}
+
+	return resp.Read, nil
+}
+
+// MuteThread - уведомления о новых комментах в поддереве id больше не приходят.
+func (c *Client) MuteThread(ctx context.Context, id int64) error {
+	err := c.do(ctx, http.MethodPut, commentPath(id)+"/mute", nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("client - MuteThread: %w", err)
+	}
+
+	return nil
+}
+
+// UnmuteThread - снимает заглушку с коммента, заглушки предков остаются.
+func (c *Client) UnmuteThread(ctx context.Context, id int64) error {
+	err := c.do(ctx, http.MethodDelete, commentPath(id)+"/mute", nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("client - UnmuteThread: %w", err)
+	}
+
+	return nil
+}
+
+// MarkSeen - пользователь прочитал тред корня id, возвращает последний увиденный коммент.
+func (c *Client) MarkSeen(ctx context.Context, id int64) (int64, error) {
+	var resp markSeenResponse
+
+	err := c.do(ctx, http.MethodPost, commentPath(id)+"/seen", nil, nil, &resp)
+	if err != nil {
+		return 0, fmt.Errorf("client - MarkSeen: %w", err)
+	}
+
+	return resp.LastSeenID, nil
+}
+
+// Backlinks - страница комментов, ссылающихся на id, от новых к старым
+// (limit 0 - по умолчанию, 20).
+func (c *Client) Backlinks(ctx context.Context, id int64, limit, offset int) (*BacklinkPage, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		q.Set("offset", strconv.Itoa(offset))
+	}
+
+	var page BacklinkPage
+
+	err := c.do(ctx, http.MethodGet, commentPath(id)+"/backlinks", q, nil, &page)
+	if err != nil {
+		return nil, fmt.Errorf("client - Backlinks: %w", err)
+	}
+
+	return &page, nil
+}
+
+// Subscribe - дайджесты новых комментов в поддереве id на email (адрес меняется у всех подписок пользователя).
+func (c *Client) Subscribe(ctx context.Context, id int64, email string) error {
+	body, err := json.Marshal(subscribeRequest{Email: email})
+	if err != nil {
+		return fmt.Errorf("client - Subscribe - json.Marshal: %w", err)
+	}
+
+	err = c.do(ctx, http.MethodPut, commentPath(id)+"/subscription", nil, body, nil)
+	if err != nil {
+		return fmt.Errorf("client - Subscribe: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) Unsubscribe(ctx context.Context, id int64) error {
+	err := c.do(ctx, http.MethodDelete, commentPath(id)+"/subscription", nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("client - Unsubscribe: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) ListSubscriptions(ctx context.Context) ([]*Subscription, error) {
+	var resp subscriptionsResponse
+
+	err := c.do(ctx, http.MethodGet, "/v1/subscriptions", nil, nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("client - ListSubscriptions: %w", err)
+	}
+
+	return resp.Subscriptions, nil
+}
+
+// UploadAttachment - загружает файл, пока не прикрепленный ни к одному комменту.
+// Прикрепить его может только тот же пользователь (User), иначе через ATTACHMENT_UPLOAD_TTL он удаляется.
+func (c *Client) UploadAttachment(ctx context.Context, filename string, r io.Reader) (*Attachment, error) {
+	var buf bytes.Buffer
+
+	w := multipart.NewWriter(&buf)
+
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("client - UploadAttachment - w.CreateFormFile: %w", err)
+	}
+
+	_, err = io.Copy(part, r)
+	if err != nil {
+		return nil, fmt.Errorf("client - UploadAttachment - io.Copy: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("client - UploadAttachment - w.Close: %w", err)
+	}
+
+	var a Attachment
+
+	err = c.request(ctx, http.MethodPost, "/v1/attachments", nil, w.FormDataContentType(), buf.Bytes(), &a)
+	if err != nil {
+		return nil, fmt.Errorf("client - UploadAttachment: %w", err)
+	}
+
+	return &a, nil
+}
+
+// DownloadAttachment - открывает файл по подписанной ссылке (Attachment.URL или ThumbnailURL).
+// Относительная ссылка считается от baseURL. Тело закрывает вызывающий.
+func (c *Client) DownloadAttachment(ctx context.Context, signedURL string) (io.ReadCloser, error) {
+	ref, err := url.Parse(signedURL)
+	if err != nil {
+		return nil, fmt.Errorf("client - DownloadAttachment - url.Parse: %w", err)
+	}
+
+	u := *c.baseURL
+	if ref.IsAbs() {
+		u = *ref
+	} else {
+		u.Path += ref.Path
+		u.RawQuery = ref.RawQuery
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("client - DownloadAttachment - http.NewRequestWithContext: %w", err)
+	}
+
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		id = requestid.New()
+	}
+	req.Header.Set(requestid.Header, id)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("client - DownloadAttachment - httpClient.Do: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("client - DownloadAttachment: %w", decode(resp, nil))
+	}
+
+	return resp.Body, nil
+}
+
+func commentPath(id int64) string {
+	return "/v1/comments/" + strconv.FormatInt(id, 10)
+}
+
+func (c *Client) list(ctx context.Context, q url.Values) (*Page, error) {
+	var page Page
+
+	err := c.do(ctx, http.MethodGet, "/v1/comments", q, nil, &page)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+func (p NotificationParams) query() url.Values {
+	q := url.Values{}
+
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+
+	if p.Cursor != "" {
+		q.Set("cursor", p.Cursor)
+	}
+
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+
+	return q
+}
+
+func (p ListParams) query() url.Values {
+	q := url.Values{}
+
+	if p.SortBy != "" {
+		q.Set("sort_by", p.SortBy)
+	}
+
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+
+	if p.Offset > 0 {
+		q.Set("offset", strconv.Itoa(p.Offset))
+	}
+
+	if p.ChildSort != "" {
+		q.Set("child_sort", p.ChildSort)
+	}
+
+	return q
+}
+
+// do - отправляет запрос и декодирует ответ в out.
+// GET, PUT и DELETE повторяются при сетевых ошибках, 429 и 5xx (кроме 501) с экспоненциальной паузой.
+// 404 на повторе DELETE самого коммента (не /pin, /highlight, /lock, /max-depth, /mute, /subscription) считается успехом.
+// X-Request-ID берется из контекста (или генерируется) и один на все попытки.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, body []byte, out any) error {
+	return c.request(ctx, method, path, q, "application/json", body, out)
+}
+
+// request - do с произвольным Content-Type тела.
+func (c *Client) request(ctx context.Context, method, path string, q url.Values, contentType string, body []byte, out any) error {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = q.Encode()
+
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		id = requestid.New()
+	}
+
+	retries := 0
+	if method != http.MethodPost {
+		retries = c.maxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, u.String(), id, contentType, body)
+
+		var wait time.Duration
+
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || attempt >= retries {
+				return err
+			}
+		case !retryable(resp.StatusCode) || attempt >= retries:
+			if method == http.MethodDelete && strings.Count(path, "/") == 3 && attempt > 0 && resp.StatusCode == http.StatusNotFound {
+				drain(resp)
+
+				return nil
+			}
+
+			return decode(resp, out)
+		default:
+			wait = retryAfter(resp)
+			drain(resp)
+		}
+
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+
+		t := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, u, id, contentType string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(requestid.Header, id)
+
+	if c.user != "" {
+		req.Header.Set("X-User", c.user)
+	}
+
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("httpClient.Do: %w", err)
+	}
+
+	return resp, nil
+}
+
+// backoff - minBackoff * 2^attempt, не больше maxBackoff, плюс случайная добавка до половины паузы.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.minBackoff << min(attempt, 30)
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + rand.N(d/2+1) //nolint:gosec // джиттер, не криптография
+}
+
+func retryable(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// retryAfter - поддерживаем только форму в секундах, с датой не встречали.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+
+	return time.Duration(secs) * time.Second
+}
+
+func decode(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+
+		var body errorResponse
+		if json.NewDecoder(io.LimitReader(resp.Body, _maxErrorBody)).Decode(&body) == nil {
+			apiErr.Message = body.Error
+		}
+
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	err := json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("json.Decode: %w", err)
+	}
+
+	return nil
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, _maxErrorBody))
+	_ = resp.Body.Close()
+}
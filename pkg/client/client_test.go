@@ -0,0 +1,921 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/config"
+	"github.com/andreyxaxa/Comment-Tree/internal/controller/restapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/blob"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/mailer"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/memory"
+	"github.com/andreyxaxa/Comment-Tree/internal/repo/webapi"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase"
+	"github.com/andreyxaxa/Comment-Tree/internal/usecase/comment"
+	"github.com/andreyxaxa/Comment-Tree/pkg/client"
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// flaky - первые failures запросов отвечает 503, остальные отдает роутеру.
+type flaky struct {
+	next http.Handler
+
+	mu         sync.Mutex
+	failures   int
+	requests   int
+	requestIDs []string
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests++
+	f.requestIDs = append(f.requestIDs, r.Header.Get(requestid.Header))
+	fail := f.failures > 0
+	if fail {
+		f.failures--
+	}
+	f.mu.Unlock()
+
+	if fail {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"try later"}`))
+
+		return
+	}
+
+	f.next.ServeHTTP(w, r)
+}
+
+func (f *flaky) fail(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = n
+	f.requests = 0
+	f.requestIDs = nil
+}
+
+// _adminToken - ADMIN_TOKEN тестового сервера.
+const _adminToken = "s3cret"
+
+func newClient(t *testing.T, opts ...client.Option) (*client.Client, *flaky) {
+	t.Helper()
+
+	url, f := newServer(t)
+
+	c, err := client.New(url, append([]client.Option{client.MaxRetries(3), client.Backoff(time.Millisecond, 5*time.Millisecond)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return c, f
+}
+
+func newServer(t *testing.T, opts ...comment.Option) (string, *flaky) {
+	t.Helper()
+
+	l := logger.New("error")
+
+	return serve(t, comment.New(memory.New(), webapi.NewCDN("", "", "", "", time.Second, l), l, opts...))
+}
+
+func serve(t *testing.T, uc usecase.CommentUseCase) (string, *flaky) {
+	t.Helper()
+
+	l := logger.New("error")
+
+	cfg := &config.Config{}
+	cfg.Admin.Token = _adminToken
+
+	app := fiber.New()
+	restapi.NewRouter(app, cfg, uc, prometheus.NewRegistry(), health.New(), l)
+
+	f := &flaky{next: adaptor.FiberApp(app)}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	return srv.URL, f
+}
+
+func TestCreateAndGetTree(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t)
+
+	root, err := c.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	reply, err := c.CreateComment(ctx, &root.ID, "reply")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	if reply.ParentID == nil || *reply.ParentID != root.ID {
+		t.Fatalf("reply parent = %v, want %d", reply.ParentID, root.ID)
+	}
+
+	tree, err := c.GetTree(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	if tree.ID != root.ID || len(tree.Children) != 1 || tree.Children[0].Content != "reply" || tree.Children[0].Depth != 1 {
+		t.Fatalf("unexpected tree: %+v", tree)
+	}
+
+	if tree.ContentHTML != "<p>root</p>\n" {
+		t.Fatalf("ContentHTML = %q, want rendered root", tree.ContentHTML)
+	}
+}
+
+func TestChildSort(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t)
+
+	root, err := c.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	var replies []int64
+
+	for range 3 {
+		reply, err := c.CreateComment(ctx, &root.ID, "reply")
+		if err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+
+		replies = append(replies, reply.ID)
+	}
+
+	page, err := c.ListRoots(ctx, client.ListParams{ChildSort: client.ChildSortNewest})
+	if err != nil {
+		t.Fatalf("ListRoots: %v", err)
+	}
+
+	children := page.Comments[0].Children
+	if len(children) != 3 || children[0].ID != replies[2] || children[2].ID != replies[0] {
+		t.Fatalf("children = %+v, want newest first", children)
+	}
+}
+
+func TestPins(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t, client.AdminToken(_adminToken))
+
+	var roots []int64
+
+	for range 3 {
+		root, err := c.CreateComment(ctx, nil, "root")
+		if err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+
+		roots = append(roots, root.ID)
+	}
+
+	if err := c.PinComment(ctx, roots[0], 1); err != nil {
+		t.Fatalf("PinComment: %v", err)
+	}
+
+	if err := c.SetHighlighted(ctx, roots[1], true); err != nil {
+		t.Fatalf("SetHighlighted: %v", err)
+	}
+
+	page, err := c.ListRoots(ctx, client.ListParams{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListRoots: %v", err)
+	}
+
+	first := page.Comments[0]
+	if first.ID != roots[0] || !first.Pinned || first.PinOrder == nil || *first.PinOrder != 1 || page.Total != 3 {
+		t.Fatalf("first = %+v, total = %d, want pinned root %d", first, page.Total, roots[0])
+	}
+
+	tree, err := c.GetTree(ctx, roots[1])
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	if !tree.Highlighted {
+		t.Fatalf("tree = %+v, want highlighted", tree)
+	}
+
+	if err := c.UnpinComment(ctx, roots[0]); err != nil {
+		t.Fatalf("UnpinComment: %v", err)
+	}
+
+	page, err = c.ListRoots(ctx, client.ListParams{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListRoots: %v", err)
+	}
+
+	if page.Comments[0].ID != roots[2] || page.Comments[0].Pinned {
+		t.Fatalf("first = %+v, want unpinned root %d", page.Comments[0], roots[2])
+	}
+
+	if err := c.PinComment(ctx, 1_000_000, 1); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("PinComment(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPinsForbidden(t *testing.T) {
+	ctx := context.Background()
+	url, _ := newServer(t)
+
+	alice, _ := client.New(url, client.User("alice"))
+	bob, _ := client.New(url, client.User("bob"))
+	anonymous, _ := client.New(url)
+
+	root, err := alice.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	reply, err := bob.CreateComment(ctx, &root.ID, "reply")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	// владелец треда закрепляет и выделяет ответы в нем, но не сам корень среди чужих корней
+	if err = alice.PinComment(ctx, reply.ID, 1); err != nil {
+		t.Fatalf("PinComment(owner) = %v", err)
+	}
+	if err = alice.SetHighlighted(ctx, root.ID, true); err != nil {
+		t.Fatalf("SetHighlighted(owner) = %v", err)
+	}
+	if err = alice.PinComment(ctx, root.ID, 1); !errors.Is(err, client.ErrForbidden) {
+		t.Fatalf("PinComment(owner, root) = %v, want ErrForbidden", err)
+	}
+
+	for name, c := range map[string]*client.Client{"bob": bob, "anonymous": anonymous} {
+		if err = c.PinComment(ctx, reply.ID, 2); !errors.Is(err, client.ErrForbidden) {
+			t.Fatalf("PinComment(%s) = %v, want ErrForbidden", name, err)
+		}
+		if err = c.UnpinComment(ctx, reply.ID); !errors.Is(err, client.ErrForbidden) {
+			t.Fatalf("UnpinComment(%s) = %v, want ErrForbidden", name, err)
+		}
+		if err = c.SetHighlighted(ctx, reply.ID, false); !errors.Is(err, client.ErrForbidden) {
+			t.Fatalf("SetHighlighted(%s) = %v, want ErrForbidden", name, err)
+		}
+	}
+}
+
+func TestLocks(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t, client.User("alice"))
+
+	root, err := c.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	reply, err := c.CreateComment(ctx, &root.ID, "reply")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	if err = c.LockComment(ctx, root.ID, client.LockLocked, "incident"); err != nil {
+		t.Fatalf("LockComment: %v", err)
+	}
+
+	// блокировка корня действует на всю ветку
+	if _, err = c.CreateComment(ctx, &reply.ID, "late"); !errors.Is(err, client.ErrLocked) {
+		t.Fatalf("CreateComment(locked) = %v, want ErrLocked", err)
+	}
+
+	tree, err := c.GetTree(ctx, reply.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	if tree.LockState != client.LockLocked || tree.LockReason != "incident" {
+		t.Fatalf("tree = %+v, want inherited lock", tree)
+	}
+
+	if err = c.LockComment(ctx, root.ID, client.LockArchived, ""); err != nil {
+		t.Fatalf("LockComment(archived): %v", err)
+	}
+
+	if err = c.DeleteComment(ctx, reply.ID); !errors.Is(err, client.ErrLocked) {
+		t.Fatalf("DeleteComment(archived) = %v, want ErrLocked", err)
+	}
+
+	if err = c.PinComment(ctx, reply.ID, 1); !errors.Is(err, client.ErrLocked) {
+		t.Fatalf("PinComment(archived) = %v, want ErrLocked", err)
+	}
+
+	if err = c.UnlockComment(ctx, root.ID); err != nil {
+		t.Fatalf("UnlockComment: %v", err)
+	}
+
+	if _, err = c.CreateComment(ctx, &reply.ID, "reopened"); err != nil {
+		t.Fatalf("CreateComment(unlocked): %v", err)
+	}
+
+	if err = c.LockComment(ctx, root.ID, "frozen", ""); !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("LockComment(invalid) = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t)
+
+	root, err := c.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	reply, err := c.CreateComment(ctx, &root.ID, "reply")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	one := int64(1)
+	if err = c.SetMaxDepth(ctx, root.ID, &one); err != nil {
+		t.Fatalf("SetMaxDepth: %v", err)
+	}
+
+	if err = c.SetMaxDepth(ctx, reply.ID, &one); !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("SetMaxDepth(reply) = %v, want ErrBadRequest", err)
+	}
+
+	// ответ глубже лимита переносится к корню и помнит, кому отвечали
+	deep, err := c.CreateComment(ctx, &reply.ID, "deep")
+	if err != nil {
+		t.Fatalf("CreateComment(deep): %v", err)
+	}
+
+	if deep.ParentID == nil || *deep.ParentID != root.ID || deep.ReplyToID == nil || *deep.ReplyToID != reply.ID {
+		t.Fatalf("deep = %+v, want parent %d and reply_to %d", deep, root.ID, reply.ID)
+	}
+
+	tree, err := c.GetTree(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	if tree.MaxDepth == nil || *tree.MaxDepth != 1 || len(tree.Children) != 2 || tree.Children[1].ReplyToID == nil {
+		t.Fatalf("tree = %+v, want max depth 1 and flattened reply", tree)
+	}
+
+	if err = c.SetMaxDepth(ctx, root.ID, nil); err != nil {
+		t.Fatalf("SetMaxDepth(nil): %v", err)
+	}
+
+	nested, err := c.CreateComment(ctx, &reply.ID, "nested")
+	if err != nil || nested.ParentID == nil || *nested.ParentID != reply.ID || nested.ReplyToID != nil {
+		t.Fatalf("CreateComment(nested) = %+v, %v; want regular reply", nested, err)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	ctx := context.Background()
+	url, _ := newServer(t)
+
+	alice, err := client.New(url, client.User("alice"))
+	if err != nil {
+		t.Fatalf("New(alice): %v", err)
+	}
+
+	bob, err := client.New(url, client.User("bob"))
+	if err != nil {
+		t.Fatalf("New(bob): %v", err)
+	}
+
+	root, err := alice.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	if root.Author != "alice" {
+		t.Fatalf("root.Author = %q, want alice", root.Author)
+	}
+
+	// ответ с упоминанием того же автора - одно уведомление об ответе
+	if _, err = bob.CreateComment(ctx, &root.ID, "hi @alice"); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	if _, err = bob.CreateComment(ctx, nil, "ping @alice"); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	page, err := alice.ListNotifications(ctx, client.NotificationParams{Limit: 1})
+	if err != nil {
+		t.Fatalf("ListNotifications: %v", err)
+	}
+
+	if len(page.Notifications) != 1 || page.Notifications[0].Kind != client.NotificationMention || page.NextCursor == "" {
+		t.Fatalf("page = %+v, want mention and next cursor", page)
+	}
+
+	page, err = alice.ListNotifications(ctx, client.NotificationParams{Cursor: page.NextCursor, Limit: 1})
+	if err != nil {
+		t.Fatalf("ListNotifications(cursor): %v", err)
+	}
+
+	if len(page.Notifications) != 1 || page.Notifications[0].Kind != client.NotificationReply || page.Notifications[0].Actor != "bob" {
+		t.Fatalf("page = %+v, want reply from bob", page)
+	}
+
+	read, err := alice.MarkNotificationsRead(ctx, nil)
+	if err != nil || read != 2 {
+		t.Fatalf("MarkNotificationsRead = %d, %v; want 2", read, err)
+	}
+
+	if err = alice.MuteThread(ctx, root.ID); err != nil {
+		t.Fatalf("MuteThread: %v", err)
+	}
+
+	if _, err = bob.CreateComment(ctx, &root.ID, "muted"); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	page, err = alice.ListNotifications(ctx, client.NotificationParams{Status: client.NotificationsUnread})
+	if err != nil || len(page.Notifications) != 0 {
+		t.Fatalf("ListNotifications(unread) = %+v, %v; want empty", page, err)
+	}
+
+	if err = alice.UnmuteThread(ctx, root.ID); err != nil {
+		t.Fatalf("UnmuteThread: %v", err)
+	}
+
+	anon, _ := newClient(t)
+	if _, err = anon.ListNotifications(ctx, client.NotificationParams{}); !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("ListNotifications(anonymous) = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestUnread(t *testing.T) {
+	ctx := context.Background()
+	url, _ := newServer(t)
+
+	alice, err := client.New(url, client.User("alice"))
+	if err != nil {
+		t.Fatalf("New(alice): %v", err)
+	}
+
+	bob, err := client.New(url, client.User("bob"))
+	if err != nil {
+		t.Fatalf("New(bob): %v", err)
+	}
+
+	root, err := alice.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	reply, err := bob.CreateComment(ctx, &root.ID, "reply")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	// тред не открывался: новое все, кроме своего
+	tree, err := alice.GetTree(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	if tree.IsNew || !tree.Children[0].IsNew || tree.NewCount == nil || *tree.NewCount != 1 {
+		t.Fatalf("tree = %+v, want only the reply new", tree)
+	}
+
+	seen, err := alice.MarkSeen(ctx, root.ID)
+	if err != nil || seen != reply.ID {
+		t.Fatalf("MarkSeen = %d, %v; want %d", seen, err, reply.ID)
+	}
+
+	if _, err = bob.CreateComment(ctx, &reply.ID, "after visit"); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	page, err := alice.ListRoots(ctx, client.ListParams{})
+	if err != nil {
+		t.Fatalf("ListRoots: %v", err)
+	}
+
+	got := page.Comments[0]
+	if got.NewCount == nil || *got.NewCount != 1 || got.Children[0].IsNew || !got.Children[0].Children[0].IsNew {
+		t.Fatalf("root = %+v, want only the comment after the visit new", got)
+	}
+
+	// без пользователя непрочитанного нет
+	anon, _ := newClient(t)
+	if _, err = anon.MarkSeen(ctx, root.ID); !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("MarkSeen(anonymous) = %v, want ErrBadRequest", err)
+	}
+
+	if _, err = alice.MarkSeen(ctx, reply.ID); !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("MarkSeen(reply) = %v, want ErrBadRequest", err)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t)
+
+	target, err := c.CreateComment(ctx, nil, "the **target**")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	root, err := c.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	reply, err := c.CreateComment(ctx, &root.ID, fmt.Sprintf(">>%d\n> target\n\nagreed, see also >>%d", target.ID, target.ID+1000))
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	tree, err := c.GetTree(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	refs := tree.Children[0].References
+	if len(refs) != 1 || refs[0].ID != target.ID || !refs[0].Available || refs[0].Snippet != "the target" {
+		t.Fatalf("references = %+v, want available target", refs)
+	}
+
+	page, err := c.Backlinks(ctx, target.ID, 0, 0)
+	if err != nil || page.Total != 1 || page.Backlinks[0].ID != reply.ID {
+		t.Fatalf("Backlinks = %+v, %v; want the reply", page, err)
+	}
+
+	// удаленная цель остается в ссылках, но недоступна
+	if err = c.DeleteComment(ctx, target.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+
+	tree, err = c.GetTree(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	refs = tree.Children[0].References
+	if len(refs) != 1 || refs[0].ID != target.ID || refs[0].Available || refs[0].Snippet != "" {
+		t.Fatalf("references after delete = %+v, want unavailable target", refs)
+	}
+
+	if _, err = c.Backlinks(ctx, target.ID, 0, 0); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("Backlinks(deleted) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	l := logger.New("error")
+	uc := comment.New(memory.New(), webapi.NewCDN("", "", "", "", time.Second, l), l,
+		comment.Digests(mailer.NewFileDrop(dir, "noreply@example.com"), "secret", "http://comments.test", 0))
+	url, _ := serve(t, uc)
+
+	alice, _ := client.New(url, client.User("alice"))
+	bob, _ := client.New(url, client.User("bob"))
+
+	root, err := alice.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	if err = alice.Subscribe(ctx, root.ID, "not an email"); !errors.Is(err, client.ErrBadRequest) {
+		t.Fatalf("Subscribe(invalid email) = %v, want ErrBadRequest", err)
+	}
+
+	if err = alice.Subscribe(ctx, root.ID, "alice@example.com"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	subs, err := alice.ListSubscriptions(ctx)
+	if err != nil || len(subs) != 1 || subs[0].CommentID != root.ID || subs[0].Email != "alice@example.com" {
+		t.Fatalf("ListSubscriptions = %+v, %v; want root", subs, err)
+	}
+
+	// свой коммент в дайджест не попадает
+	for _, cl := range []*client.Client{bob, bob, alice} {
+		if _, err = cl.CreateComment(ctx, &root.ID, "reply"); err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+	}
+
+	if n, err := uc.SendDigests(ctx); err != nil || n != 1 {
+		t.Fatalf("SendDigests = %d, %v; want 1", n, err)
+	}
+
+	if n, err := uc.SendDigests(ctx); err != nil || n != 0 {
+		t.Fatalf("SendDigests(again) = %d, %v; want 0", n, err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("emails = %v, want one", files)
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+
+	if subject := msg.Header.Get("Subject"); subject != "2 new comments in threads you follow" {
+		t.Fatalf("Subject = %q", subject)
+	}
+
+	link := strings.Trim(msg.Header.Get("List-Unsubscribe"), "<>")
+	link = strings.Replace(link, "http://comments.test", url, 1)
+
+	resp, err := http.Post(link+"x", "", nil)
+	if err != nil {
+		t.Fatalf("POST tampered link: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("POST tampered link = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = http.Post(link, "", nil)
+	if err != nil {
+		t.Fatalf("POST link: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST link = %d, want 200", resp.StatusCode)
+	}
+
+	subs, err = alice.ListSubscriptions(ctx)
+	if err != nil || len(subs) != 0 {
+		t.Fatalf("ListSubscriptions after unsubscribe = %+v, %v; want none", subs, err)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	ctx := context.Background()
+	limits := comment.AttachmentLimits{MaxSize: 1 << 20, MaxPerComment: 2, Types: []string{"image/png", "text/plain"}, ThumbnailSize: 16}
+	url, _ := newServer(t, comment.Attachments(blob.NewLocal(t.TempDir()), "secret", limits, time.Hour, time.Hour))
+
+	alice, err := client.New(url, client.User("alice"))
+	if err != nil {
+		t.Fatalf("New(alice): %v", err)
+	}
+
+	bob, err := client.New(url, client.User("bob"))
+	if err != nil {
+		t.Fatalf("New(bob): %v", err)
+	}
+
+	var img bytes.Buffer
+	if err = png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 32))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	pic, err := alice.UploadAttachment(ctx, "cat.png", bytes.NewReader(img.Bytes()))
+	if err != nil {
+		t.Fatalf("UploadAttachment(png): %v", err)
+	}
+	if pic.ContentType != "image/png" || pic.Width != 64 || pic.Height != 32 || pic.ThumbnailURL == "" {
+		t.Fatalf("attachment = %+v, want 64x32 png with thumbnail", pic)
+	}
+
+	note, err := alice.UploadAttachment(ctx, "note.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("UploadAttachment(txt): %v", err)
+	}
+
+	_, err = alice.UploadAttachment(ctx, "app.zip", bytes.NewReader([]byte("PK\x03\x04rest")))
+	if !errors.Is(err, client.ErrAttachment) || errors.Is(err, client.ErrMaxDepth) {
+		t.Fatalf("UploadAttachment(zip) = %v, want ErrAttachment", err)
+	}
+
+	// чужие загрузки прикрепить нельзя
+	_, err = bob.CreateComment(ctx, nil, "stolen", pic.ID)
+	if !errors.Is(err, client.ErrAttachment) || errors.Is(err, client.ErrMaxDepth) {
+		t.Fatalf("CreateComment(foreign) = %v, want ErrAttachment", err)
+	}
+
+	root, err := alice.CreateComment(ctx, nil, "look", pic.ID, note.ID)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	if len(root.Attachments) != 2 {
+		t.Fatalf("attachments = %+v, want 2", root.Attachments)
+	}
+
+	tree, err := bob.GetTree(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+	if len(tree.Attachments) != 2 || tree.Attachments[1].Filename != "note.txt" {
+		t.Fatalf("tree attachments = %+v, want cat.png and note.txt", tree.Attachments)
+	}
+
+	body, err := bob.DownloadAttachment(ctx, tree.Attachments[1].URL)
+	if err != nil {
+		t.Fatalf("DownloadAttachment: %v", err)
+	}
+	data, err := io.ReadAll(body)
+	_ = body.Close()
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("download = %q, %v; want hello", data, err)
+	}
+
+	body, err = bob.DownloadAttachment(ctx, tree.Attachments[0].ThumbnailURL)
+	if err != nil {
+		t.Fatalf("DownloadAttachment(thumbnail): %v", err)
+	}
+	thumb, _, err := image.Decode(body)
+	_ = body.Close()
+	if err != nil || thumb.Bounds().Dx() != 16 || thumb.Bounds().Dy() != 8 {
+		t.Fatalf("thumbnail = %v, %v; want 16x8", thumb, err)
+	}
+
+	_, err = bob.DownloadAttachment(ctx, strings.Replace(tree.Attachments[1].URL, "sig=", "sig=0", 1))
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("DownloadAttachment(bad sig) = %v, want 403", err)
+	}
+
+	// после удаления коммента файлы недоступны
+	if err = alice.DeleteComment(ctx, root.ID); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+
+	if _, err = bob.DownloadAttachment(ctx, tree.Attachments[1].URL); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("DownloadAttachment(deleted) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t)
+
+	_, err := c.GetTree(ctx, 404)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetTree err = %v, want ErrNotFound", err)
+	}
+
+	parentID := int64(404)
+
+	_, err = c.CreateComment(ctx, &parentID, "orphan")
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "parent not found" {
+		t.Fatalf("CreateComment err = %v, want 404 parent not found", err)
+	}
+
+	err = c.DeleteComment(ctx, 404)
+	if !errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrServer) {
+		t.Fatalf("DeleteComment err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "retry-1")
+	c, f := newClient(t)
+
+	root, err := c.CreateComment(ctx, nil, "root")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+
+	f.fail(2)
+
+	_, err = c.GetTree(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+
+	if f.requests != 3 {
+		t.Fatalf("requests = %d, want 3", f.requests)
+	}
+
+	for _, id := range f.requestIDs {
+		if id != "retry-1" {
+			t.Fatalf("request ids = %v, want retry-1 on every attempt", f.requestIDs)
+		}
+	}
+
+	f.fail(10)
+
+	_, err = c.ListRoots(ctx, client.ListParams{})
+	if !errors.Is(err, client.ErrServer) {
+		t.Fatalf("ListRoots err = %v, want ErrServer", err)
+	}
+
+	if f.requests != 4 {
+		t.Fatalf("requests = %d, want 4", f.requests)
+	}
+
+	// POST не повторяется
+	f.fail(1)
+
+	_, err = c.CreateComment(ctx, nil, "once")
+	if !errors.Is(err, client.ErrServer) || f.requests != 1 {
+		t.Fatalf("CreateComment err = %v after %d requests, want one 503", err, f.requests)
+	}
+
+	f.fail(1)
+
+	err = c.DeleteComment(ctx, root.ID)
+	if err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+
+	_, err = c.GetTree(ctx, root.ID)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("GetTree after delete err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newClient(t)
+
+	want := make(map[int64]bool)
+
+	for range 7 {
+		root, err := c.CreateComment(ctx, nil, "root")
+		if err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+
+		want[root.ID] = true
+
+		_, err = c.CreateComment(ctx, &root.ID, "reply")
+		if err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+	}
+
+	seen := make(map[int64]bool)
+
+	var last int64
+
+	for comment, err := range c.AllRoots(ctx, client.ListParams{SortBy: client.SortByID, Order: client.OrderAsc, Limit: 3}) {
+		if err != nil {
+			t.Fatalf("AllRoots: %v", err)
+		}
+
+		if comment.ID <= last {
+			t.Fatalf("root %d returned after %d, want ascending ids", comment.ID, last)
+		}
+
+		last = comment.ID
+		seen[comment.ID] = true
+	}
+
+	if len(seen) != len(want) {
+		t.Fatalf("AllRoots returned %d roots, want %d", len(seen), len(want))
+	}
+
+	n := 0
+
+	for _, err := range c.AllSearchResults(ctx, "root", client.ListParams{Limit: 2}) {
+		if err != nil {
+			t.Fatalf("AllSearchResults: %v", err)
+		}
+
+		n++
+
+		if n == 4 {
+			break
+		}
+	}
+
+	if n != 4 {
+		t.Fatalf("AllSearchResults stopped after %d results", n)
+	}
+}
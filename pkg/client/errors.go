@@ -0,0 +1,69 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrForbidden  = errors.New("forbidden")
+	ErrNotFound   = errors.New("not found")
+	ErrLocked     = errors.New("thread is locked")
+	ErrMaxDepth   = errors.New("max depth exceeded")
+	ErrServer     = errors.New("server error")
+	ErrAttachment = errors.New("attachment rejected")
+)
+
+// сообщения 422 о вложениях, остальные 422 - лимит глубины
+var _attachmentMessages = map[string]bool{
+	"invalid attachment":   true,
+	"too many attachments": true,
+}
+
+// Error - ответ сервиса со статусом 4xx/5xx. Message - поле error из тела ответа.
+// errors.Is сопоставляет его с ErrBadRequest, ErrForbidden, ErrNotFound, ErrLocked, ErrMaxDepth, ErrAttachment и ErrServer по статусу.
+// ErrForbidden - 403: закреплять, выделять и блокировать может только модератор (AdminToken) или владелец треда.
+// ErrLocked - 423: ветка закрыта для ответов или заархивирована, Message уточняет, что именно.
+// ErrMaxDepth - 422: ответ глубже лимита дерева, а сервис настроен отклонять такие.
+// ErrAttachment - вложение отклонено: файл больше лимита (413), тип не разрешен (415),
+// вложение чужое, уже прикреплено или их больше лимита (422), вложения выключены (501).
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("comment tree: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("comment tree: %d %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrLocked:
+		return e.StatusCode == http.StatusLocked
+	case ErrMaxDepth:
+		return e.StatusCode == http.StatusUnprocessableEntity && !_attachmentMessages[e.Message]
+	case ErrAttachment:
+		switch e.StatusCode {
+		case http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusNotImplemented:
+			return true
+		case http.StatusUnprocessableEntity:
+			return _attachmentMessages[e.Message]
+		}
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
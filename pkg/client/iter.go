@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"iter"
+)
+
+// AllRoots - все корневые комменты по страницам params.Limit, начиная с params.Offset.
+// Ошибка отдается последним элементом, после нее перебор заканчивается.
+func (c *Client) AllRoots(ctx context.Context, params ListParams) iter.Seq2[*Comment, error] {
+	return c.all(params, func(p ListParams) (*Page, error) {
+		return c.ListRoots(ctx, p)
+	})
+}
+
+// AllSearchResults - то же, что AllRoots, для Search.
+func (c *Client) AllSearchResults(ctx context.Context, query string, params ListParams) iter.Seq2[*Comment, error] {
+	return c.all(params, func(p ListParams) (*Page, error) {
+		return c.Search(ctx, query, p)
+	})
+}
+
+// all - листает по offset, пока не дойдет до total или не получит пустую страницу.
+func (c *Client) all(params ListParams, fetch func(ListParams) (*Page, error)) iter.Seq2[*Comment, error] {
+	return func(yield func(*Comment, error) bool) {
+		for {
+			page, err := fetch(params)
+			if err != nil {
+				yield(nil, err)
+
+				return
+			}
+
+			for _, comment := range page.Comments {
+				if !yield(comment, nil) {
+					return
+				}
+			}
+
+			// limit мог быть поправлен сервисом, дальше идем его шагом
+			params.Limit = page.Limit
+			params.Offset = page.Offset + page.Limit
+
+			if len(page.Comments) == 0 || page.Limit <= 0 || params.Offset >= page.Total {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option func(*Client)
+
+func HTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// MaxRetries - сколько раз повторять идемпотентный запрос (GET, DELETE), 0 - без повторов.
+func MaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// Backoff - пауза перед первым повтором и ее предел; каждая следующая вдвое длиннее.
+func Backoff(minDelay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = minDelay
+		c.maxBackoff = maxDelay
+	}
+}
+
+// User - имя пользователя, от которого идут запросы (заголовок X-User): автор новых комментов
+// и владелец уведомлений. Без него комменты анонимные, а уведомления недоступны.
+func User(name string) Option {
+	return func(c *Client) {
+		c.user = name
+	}
+}
+
+// AdminToken - ADMIN_TOKEN сервиса (заголовок "Authorization: Bearer"): с ним клиент - модератор
+// и может закреплять, выделять и блокировать любые треды, а не только те, корень которых написал User.
+func AdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
@@ -0,0 +1,200 @@
+package client
+
+import "time"
+
+const (
+	SortByCreatedAt = "created_at"
+	SortByID        = "id"
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+
+	ChildSortOldest = "oldest"
+	ChildSortNewest = "newest"
+	// ChildSortScore - сначала ответы с большим числом потомков
+	ChildSortScore = "score"
+
+	// LockLocked - новые ответы запрещены, LockArchived - ветка только для чтения.
+	LockLocked   = "locked"
+	LockArchived = "archived"
+
+	// NotificationReply - ответ на коммент пользователя, NotificationMention - упоминание @username.
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+
+	NotificationsUnread = "unread"
+	NotificationsRead   = "read"
+	NotificationsAll    = "all"
+)
+
+// Comment - коммент с ответами, как в ответе GET /v1/comments.
+type Comment struct {
+	ID        int64      `json:"id"`
+	ParentID  *int64     `json:"parent_id"`
+	ReplyToID *int64     `json:"reply_to_id,omitempty"`
+	Content   string     `json:"content"`
+	Author    string     `json:"author,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Depth     int        `json:"depth"`
+	Children  []*Comment `json:"children,omitempty"`
+
+	// ContentHTML - Content (markdown) в безопасном HTML, отрендеренный сервером.
+	ContentHTML string `json:"content_html"`
+
+	// PinOrder - позиция среди закрепленных, nil - не закреплен.
+	PinOrder    *int64 `json:"pin_order,omitempty"`
+	Pinned      bool   `json:"pinned,omitempty"`
+	Highlighted bool   `json:"highlighted,omitempty"`
+
+	// LockState - действующая блокировка (своя или предка): LockLocked, LockArchived или пусто.
+	LockState  string `json:"lock_state,omitempty"`
+	LockReason string `json:"lock_reason,omitempty"`
+
+	// MaxDepth - лимит глубины, заданный дереву (только у корня), nil - общий.
+	MaxDepth *int64 `json:"max_depth,omitempty"`
+
+	// IsNew - коммент появился после последнего MarkSeen треда, NewCount - сколько таких в треде
+	// (только у корня). Оба - только у клиента с User и не в результатах поиска.
+	IsNew    bool `json:"is_new,omitempty"`
+	NewCount *int `json:"new_count,omitempty"`
+
+	// References - ссылки ">>id" из текста (не в результатах поиска).
+	References []*Reference `json:"references,omitempty"`
+
+	Attachments []*Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment - загруженный файл. URL и ThumbnailURL - подписанные ссылки с ограниченным сроком,
+// открываются через DownloadAttachment. Миниатюра и размеры - только у картинок.
+type Attachment struct {
+	ID           int64     `json:"id"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Reference - ссылка на коммент ID. Удаленный коммент недоступен: Available false, остальные поля пусты.
+type Reference struct {
+	ID        int64      `json:"id"`
+	Available bool       `json:"available"`
+	Author    string     `json:"author,omitempty"`
+	Snippet   string     `json:"snippet,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// Backlink - коммент, ссылающийся на другой через ">>id", Snippet - начало его текста.
+type Backlink struct {
+	ID        int64     `json:"id"`
+	ParentID  *int64    `json:"parent_id"`
+	Author    string    `json:"author,omitempty"`
+	Snippet   string    `json:"snippet"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BacklinkPage struct {
+	Backlinks []*Backlink `json:"backlinks"`
+	Total     int         `json:"total"`
+	Limit     int         `json:"limit"`
+	Offset    int         `json:"offset"`
+}
+
+type Page struct {
+	Comments []*Comment `json:"comments"`
+	Total    int        `json:"total"`
+	Limit    int        `json:"limit"`
+	Offset   int        `json:"offset"`
+	Page     int        `json:"page"`
+	Pages    int        `json:"pages"`
+}
+
+// ListParams - пустые поля не отправляются, сервис подставит значения по умолчанию
+// (created_at, desc, 20 на страницу, ответы - oldest).
+type ListParams struct {
+	SortBy string
+	Order  string
+	Limit  int
+	Offset int
+
+	// ChildSort - порядок ответов на любой глубине
+	ChildSort string
+}
+
+type Notification struct {
+	ID        int64  `json:"id"`
+	Kind      string `json:"kind"`
+	CommentID int64  `json:"comment_id"`
+	// Actor - автор нового коммента, Content - его текст.
+	Actor     string     `json:"actor,omitempty"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+type NotificationPage struct {
+	Notifications []*Notification `json:"notifications"`
+	NextCursor    string          `json:"next_cursor,omitempty"`
+}
+
+// NotificationParams - пустые поля не отправляются (все уведомления, 20 на страницу).
+type NotificationParams struct {
+	Status string
+	Cursor string
+	Limit  int
+}
+
+type markNotificationsReadRequest struct {
+	IDs []int64 `json:"ids,omitempty"`
+	All bool    `json:"all,omitempty"`
+}
+
+type markSeenResponse struct {
+	LastSeenID int64 `json:"last_seen_id"`
+}
+
+type markNotificationsReadResponse struct {
+	Read int `json:"read"`
+}
+
+type Subscription struct {
+	CommentID int64     `json:"comment_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	// DigestedAt - когда подписка последний раз попадала в дайджест.
+	DigestedAt time.Time `json:"digested_at"`
+}
+
+type subscribeRequest struct {
+	Email string `json:"email"`
+}
+
+type subscriptionsResponse struct {
+	Subscriptions []*Subscription `json:"subscriptions"`
+}
+
+type setMaxDepthRequest struct {
+	MaxDepth int64 `json:"max_depth"`
+}
+
+type lockCommentRequest struct {
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type pinCommentRequest struct {
+	Order int64 `json:"order"`
+}
+
+type createCommentRequest struct {
+	ParentID      *int64  `json:"parent_id"`
+	Content       string  `json:"content"`
+	AttachmentIDs []int64 `json:"attachment_ids,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
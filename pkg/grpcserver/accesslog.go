@@ -0,0 +1,72 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type accessLog struct {
+	l logger.Interface
+}
+
+func newAccessLog(l logger.Interface) *accessLog {
+	return &accessLog{
+		l: l,
+	}
+}
+
+func (a *accessLog) log(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+
+	fields := logger.Fields{
+		"method":     method,
+		"code":       code.String(),
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["ip"] = p.Addr.String()
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		fields["user_agent"] = strings.Join(md.Get("user-agent"), " ")
+	}
+
+	l := a.l.WithContext(ctx).With(fields)
+
+	// серверные ошибки - как 5xx в HTTP
+	switch code {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded:
+		l.Warn("grpc request")
+	default:
+		l.Info("grpc request")
+	}
+}
+
+func (a *accessLog) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	resp, err := handler(ctx, req)
+
+	a.log(ctx, info.FullMethod, start, err)
+
+	return resp, err
+}
+
+func (a *accessLog) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+
+	err := handler(srv, ss)
+
+	a.log(ss.Context(), info.FullMethod, start, err)
+
+	return err
+}
@@ -0,0 +1,32 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// withCaller - кладет в контекст адрес клиента без порта, пользователя добавляют контроллеры.
+func withCaller(ctx context.Context) context.Context {
+	var ip string
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	return caller.NewContext(ctx, caller.Info{IP: ip})
+}
+
+func callerUnaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withCaller(ctx), req)
+}
+
+func callerStreamInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: withCaller(ss.Context())})
+}
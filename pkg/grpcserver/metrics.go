@@ -0,0 +1,64 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+type grpcMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newGRPCMetrics(reg prometheus.Registerer) *grpcMetrics {
+	m := &grpcMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Total number of gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "gRPC call latency by method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+
+	reg.MustRegister(m.requests, m.duration)
+
+	return m
+}
+
+func (m *grpcMetrics) observe(method string, start time.Time, err error) {
+	labels := prometheus.Labels{
+		"method": method,
+		"code":   status.Code(err).String(),
+	}
+
+	m.requests.With(labels).Inc()
+	m.duration.With(labels).Observe(time.Since(start).Seconds())
+}
+
+func (m *grpcMetrics) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	resp, err := handler(ctx, req)
+
+	m.observe(info.FullMethod, start, err)
+
+	return resp, err
+}
+
+// streamInterceptor - для стримов время считается до закрытия стрима, а не до первого сообщения.
+func (m *grpcMetrics) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+
+	err := handler(srv, ss)
+
+	m.observe(info.FullMethod, start, err)
+
+	return err
+}
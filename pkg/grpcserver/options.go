@@ -0,0 +1,48 @@
+package grpcserver
+
+import (
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+type Option func(*Server)
+
+func Port(port string) Option {
+	return func(s *Server) {
+		s.address = net.JoinHostPort("", port)
+	}
+}
+
+func Metrics(reg prometheus.Registerer) Option {
+	return func(s *Server) {
+		m := newGRPCMetrics(reg)
+
+		s.unary = append(s.unary, m.unaryInterceptor)
+		s.stream = append(s.stream, m.streamInterceptor)
+	}
+}
+
+// Tracing - продолжает трейс из метаданных traceparent/tracestate, как и HTTP-сервер.
+func Tracing(tp trace.TracerProvider) Option {
+	return func(s *Server) {
+		s.serverOptions = append(s.serverOptions, grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithTracerProvider(tp),
+			otelgrpc.WithPropagators(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})),
+		)))
+	}
+}
+
+// AccessLog - одна запись в лог на каждый вызов.
+func AccessLog() Option {
+	return func(s *Server) {
+		a := newAccessLog(s.logger)
+
+		s.unary = append(s.unary, a.unaryInterceptor)
+		s.stream = append(s.stream, a.streamInterceptor)
+	}
+}
@@ -0,0 +1,51 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// _requestIDKey - ключи метаданных gRPC всегда в нижнем регистре.
+var _requestIDKey = strings.ToLower(requestid.Header)
+
+// wrappedStream - стрим с подмененным контекстом.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
+
+// withRequestID - принимает x-request-id из метаданных клиента или генерирует новый,
+// кладет его в контекст и возвращает в заголовках ответа.
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(_requestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(_requestIDKey, id))
+
+	return requestid.NewContext(ctx, id)
+}
+
+func requestIDUnaryInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func requestIDStreamInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+}
@@ -0,0 +1,111 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+)
+
+const _defaultAddr = ":81"
+
+type Server struct {
+	ctx context.Context
+	eg  *errgroup.Group
+
+	App    *grpc.Server
+	notify chan error
+
+	address string
+
+	serverOptions []grpc.ServerOption
+	unary         []grpc.UnaryServerInterceptor
+	stream        []grpc.StreamServerInterceptor
+
+	logger logger.Interface
+}
+
+func New(l logger.Interface, opts ...Option) *Server {
+	group, ctx := errgroup.WithContext(context.Background())
+	group.SetLimit(1)
+
+	s := &Server{
+		ctx:     ctx,
+		eg:      group,
+		App:     nil,
+		notify:  make(chan error, 1),
+		address: _defaultAddr,
+		logger:  l,
+		// request id - первым, чтобы его видели все остальные перехватчики
+		unary:  []grpc.UnaryServerInterceptor{requestIDUnaryInterceptor, callerUnaryInterceptor},
+		stream: []grpc.StreamServerInterceptor{requestIDStreamInterceptor, callerStreamInterceptor},
+	}
+
+	// Custom options
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	serverOptions := append(s.serverOptions,
+		grpc.ChainUnaryInterceptor(s.unary...),
+		grpc.ChainStreamInterceptor(s.stream...),
+	)
+
+	s.App = grpc.NewServer(serverOptions...)
+
+	return s
+}
+
+func (s *Server) Start() {
+	s.eg.Go(func() error {
+		var lc net.ListenConfig
+
+		ln, err := lc.Listen(s.ctx, "tcp", s.address)
+		if err != nil {
+			s.notify <- fmt.Errorf("failed to listen: %w", err)
+
+			close(s.notify)
+
+			return err
+		}
+
+		err = s.App.Serve(ln)
+		if err != nil {
+			s.notify <- fmt.Errorf("failed to start grpc server: %w", err)
+
+			close(s.notify)
+
+			return err
+		}
+
+		return nil
+	})
+
+	s.logger.Info("grpc server - Server - Started")
+}
+
+func (s *Server) Notify() <-chan error {
+	return s.notify
+}
+
+// Shutdown - GracefulStop дожидается текущих вызовов, в том числе открытых стримов.
+func (s *Server) Shutdown() error {
+	var shutdownErrors []error
+
+	s.App.GracefulStop()
+
+	err := s.eg.Wait()
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.logger.Error(fmt.Errorf("grpc server - Server - Shutdown - s.eg.Wait: %w", err))
+
+		shutdownErrors = append(shutdownErrors, err)
+	}
+
+	s.logger.Info("grpc server - Server - Shutdown")
+
+	return errors.Join(shutdownErrors...)
+}
@@ -0,0 +1,171 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	_defaultTimeout = time.Second
+)
+
+var (
+	ErrNotStarted   = errors.New("application is starting")
+	ErrShuttingDown = errors.New("application is shutting down")
+)
+
+type Checker func(ctx context.Context) error
+
+type CheckResult struct {
+	Status   string `json:"status" example:"ok"`
+	Duration string `json:"duration,omitempty" example:"1.2ms"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedChecker struct {
+	name  string
+	check Checker
+}
+
+type Health struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedChecker
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+func New(opts ...Option) *Health {
+	h := &Health{
+		timeout: _defaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// AddReadinessCheck - регистрирует проверку, выполняемую на каждом /readyz.
+func (h *Health) AddReadinessCheck(name string, check Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, namedChecker{name: name, check: check})
+}
+
+// MarkStarted - вызывается, когда приложение полностью инициализировано.
+func (h *Health) MarkStarted() {
+	h.started.Store(true)
+}
+
+// MarkShuttingDown - вызывается в начале graceful shutdown, readiness сразу начинает падать.
+func (h *Health) MarkShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness - процесс жив и обрабатывает запросы.
+func (h *Health) Liveness(_ context.Context) Report {
+	return Report{Status: StatusOK}
+}
+
+func (h *Health) Startup(_ context.Context) Report {
+	return h.report(map[string]CheckResult{
+		"started": stateResult(h.started.Load(), ErrNotStarted),
+	})
+}
+
+func (h *Health) Readiness(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	results := make(map[string]CheckResult, len(checks)+2)
+	results["started"] = stateResult(h.started.Load(), ErrNotStarted)
+	results["shutdown"] = stateResult(!h.shuttingDown.Load(), ErrShuttingDown)
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, c := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			res := h.run(ctx, c.check)
+
+			mu.Lock()
+			results[c.name] = res
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return h.report(results)
+}
+
+func (h *Health) run(ctx context.Context, check Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+
+	res := CheckResult{
+		Status:   StatusOK,
+		Duration: time.Since(start).String(),
+	}
+
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+func (h *Health) report(results map[string]CheckResult) Report {
+	status := StatusOK
+	for _, r := range results {
+		if r.Status != StatusOK {
+			status = StatusFail
+
+			break
+		}
+	}
+
+	return Report{
+		Status: status,
+		Checks: results,
+	}
+}
+
+func stateResult(ok bool, err error) CheckResult {
+	if ok {
+		return CheckResult{Status: StatusOK}
+	}
+
+	return CheckResult{Status: StatusFail, Error: err.Error()}
+}
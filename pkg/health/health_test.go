@@ -0,0 +1,77 @@
+package health_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/health"
+)
+
+func TestReadiness(t *testing.T) {
+	ctx := context.Background()
+	h := health.New()
+
+	h.AddReadinessCheck("db", func(context.Context) error { return nil })
+
+	if r := h.Readiness(ctx); r.OK() {
+		t.Fatal("ready before MarkStarted")
+	}
+	if r := h.Startup(ctx); r.OK() {
+		t.Fatal("started before MarkStarted")
+	}
+
+	h.MarkStarted()
+
+	r := h.Readiness(ctx)
+	if !r.OK() {
+		t.Fatalf("not ready after MarkStarted: %+v", r)
+	}
+	if r.Checks["db"].Status != health.StatusOK {
+		t.Fatalf("db check = %+v", r.Checks["db"])
+	}
+
+	h.MarkShuttingDown()
+
+	r = h.Readiness(ctx)
+	if r.OK() {
+		t.Fatal("ready after MarkShuttingDown")
+	}
+	if got := r.Checks["shutdown"]; got.Status != health.StatusFail || got.Error != health.ErrShuttingDown.Error() {
+		t.Fatalf("shutdown check = %+v", got)
+	}
+
+	// liveness от shutdown не зависит
+	if !h.Liveness(ctx).OK() {
+		t.Fatal("not alive after MarkShuttingDown")
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	ctx := context.Background()
+	h := health.New(health.Timeout(50 * time.Millisecond))
+	h.MarkStarted()
+
+	// зависшая проверка обрывается по таймауту и валит readiness
+	h.AddReadinessCheck("slow", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+
+	start := time.Now()
+	r := h.Readiness(ctx)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Readiness took %s, want about 50ms", elapsed)
+	}
+	if r.OK() {
+		t.Fatal("ready with a timed out check")
+	}
+	if got := r.Checks["slow"]; got.Status != health.StatusFail || got.Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("slow check = %+v", got)
+	}
+}
@@ -0,0 +1,12 @@
+package health
+
+import "time"
+
+type Option func(*Health)
+
+// Timeout - таймаут на одну проверку готовности.
+func Timeout(timeout time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = timeout
+	}
+}
@@ -0,0 +1,82 @@
+package httpserver
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+type accessLog struct {
+	l logger.Interface
+
+	// sampleRate - доля успешных GET-запросов, попадающих в лог.
+	// Остальные запросы логируются всегда.
+	sampleRate float64
+}
+
+func newAccessLog(l logger.Interface, sampleRate float64) *accessLog {
+	return &accessLog{
+		l:          l,
+		sampleRate: sampleRate,
+	}
+}
+
+func (a *accessLog) middleware(ctx *fiber.Ctx) error {
+	start := time.Now()
+
+	err := ctx.Next()
+
+	status := ctx.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		}
+	}
+
+	if !a.sampled(ctx.Method(), status) {
+		return err
+	}
+
+	// Body() у потокового ответа (экспорт, SSE) дочитывает поток до конца, поэтому его размер неизвестен
+	bytesOut := -1
+	if !ctx.Response().IsBodyStream() {
+		bytesOut = len(ctx.Response().Body())
+	}
+
+	// пользователя в контекст кладут контроллеры (X-User), поэтому он читается после ctx.Next
+	l := a.l.WithContext(ctx.UserContext()).With(logger.Fields{
+		"method":     ctx.Method(),
+		"route":      ctx.Route().Path,
+		"path":       ctx.Path(),
+		"status":     status,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"bytes_in":   len(ctx.Request().Body()),
+		"bytes_out":  bytesOut,
+		"ip":         ctx.IP(),
+		"user":       caller.FromContext(ctx.UserContext()).User,
+		"user_agent": ctx.Get(fiber.HeaderUserAgent),
+	})
+
+	if status >= fiber.StatusInternalServerError {
+		l.Warn("http request")
+	} else {
+		l.Info("http request")
+	}
+
+	return err
+}
+
+func (a *accessLog) sampled(method string, status int) bool {
+	if method != fiber.MethodGet || status < 200 || status >= 300 || a.sampleRate >= 1 {
+		return true
+	}
+
+	return rand.Float64() < a.sampleRate //nolint:gosec
+}
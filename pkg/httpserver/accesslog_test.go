@@ -0,0 +1,65 @@
+package httpserver_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/andreyxaxa/Comment-Tree/pkg/httpserver"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAccessLogUser(t *testing.T) {
+	l, logFile := stdoutLogger(t)
+
+	s := httpserver.New(l, httpserver.AccessLog(1))
+	// так пользователя кладут контроллеры
+	s.App.Get("/ping", func(ctx *fiber.Ctx) error {
+		if user := ctx.Get("X-User"); user != "" {
+			ctx.SetUserContext(caller.WithUser(ctx.UserContext(), user))
+		}
+
+		return ctx.SendStatus(fiber.StatusNoContent)
+	})
+
+	for _, user := range []string{"alice", ""} {
+		req := httptest.NewRequest(fiber.MethodGet, "/ping", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+
+		resp, err := s.App.Test(req)
+		if err != nil {
+			t.Fatalf("App.Test: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if _, err := logFile.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+
+	var users []*string
+
+	sc := bufio.NewScanner(logFile)
+	for sc.Scan() {
+		var line struct {
+			Message string  `json:"message"`
+			User    *string `json:"user"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("log line %q: %v", sc.Text(), err)
+		}
+
+		if line.Message == "http request" {
+			users = append(users, line.User)
+		}
+	}
+
+	if len(users) != 2 || users[0] == nil || *users[0] != "alice" || users[1] == nil || *users[1] != "" {
+		t.Fatalf("access log users = %v, want alice and anonymous", users)
+	}
+}
@@ -0,0 +1,13 @@
+package httpserver
+
+import (
+	"github.com/andreyxaxa/Comment-Tree/pkg/caller"
+	"github.com/gofiber/fiber/v2"
+)
+
+// callerMiddleware - кладет адрес клиента в ctx.UserContext(), пользователя добавляют контроллеры.
+func callerMiddleware(ctx *fiber.Ctx) error {
+	ctx.SetUserContext(caller.NewContext(ctx.UserContext(), caller.Info{IP: ctx.IP()}))
+
+	return ctx.Next()
+}
@@ -0,0 +1,67 @@
+package httpserver
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newHTTPMetrics(reg prometheus.Registerer) *httpMetrics {
+	m := &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	reg.MustRegister(m.requests, m.duration)
+
+	return m
+}
+
+// middleware - route берется из шаблона маршрута (/v1/comments/:id),
+// а не из фактического пути, чтобы не раздувать кардинальность.
+func (m *httpMetrics) middleware(ctx *fiber.Ctx) error {
+	start := time.Now()
+
+	err := ctx.Next()
+
+	status := ctx.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		}
+	}
+
+	route := ctx.Route().Path
+	if status == fiber.StatusNotFound && route == "/" {
+		route = "unmatched"
+	}
+
+	labels := prometheus.Labels{
+		"method": ctx.Method(),
+		"route":  route,
+		"status": strconv.Itoa(status),
+	}
+
+	m.requests.With(labels).Inc()
+	m.duration.With(labels).Observe(time.Since(start).Seconds())
+
+	return err
+}
@@ -3,6 +3,9 @@ package httpserver
 import (
 	"net"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Option func(*Server)
@@ -30,3 +33,29 @@ func ShutdownTimeout(timeout time.Duration) Option {
 		s.shutdownTimeout = timeout
 	}
 }
+
+// BodyLimit - максимальный размер тела запроса в байтах, 0 - по умолчанию fiber (4 МБ).
+func BodyLimit(limit int) Option {
+	return func(s *Server) {
+		s.bodyLimit = limit
+	}
+}
+
+func Metrics(reg prometheus.Registerer) Option {
+	return func(s *Server) {
+		s.metrics = newHTTPMetrics(reg)
+	}
+}
+
+func Tracing(tp trace.TracerProvider) Option {
+	return func(s *Server) {
+		s.tracing = newHTTPTracing(tp)
+	}
+}
+
+// AccessLog - одна запись в лог на каждый запрос. sampleRate - доля логируемых успешных GET-запросов.
+func AccessLog(sampleRate float64) Option {
+	return func(s *Server) {
+		s.accessLog = newAccessLog(s.logger, sampleRate)
+	}
+}
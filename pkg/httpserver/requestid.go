@@ -0,0 +1,20 @@
+package httpserver
+
+import (
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestIDMiddleware - принимает X-Request-ID от клиента или генерирует новый,
+// кладет его в ctx.UserContext() и возвращает в ответе.
+func requestIDMiddleware(ctx *fiber.Ctx) error {
+	id := ctx.Get(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+
+	ctx.SetUserContext(requestid.NewContext(ctx.UserContext(), id))
+	ctx.Set(requestid.Header, id)
+
+	return ctx.Next()
+}
@@ -0,0 +1,117 @@
+package httpserver_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/httpserver"
+	"github.com/andreyxaxa/Comment-Tree/pkg/logger"
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+)
+
+// stdoutLogger - логгер, пишущий в файл вместо stdout: logger.New берет os.Stdout при создании.
+func stdoutLogger(t *testing.T) (*logger.Logger, *os.File) {
+	t.Helper()
+
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatalf("os.CreateTemp: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	stdout := os.Stdout
+	os.Stdout = f
+	l := logger.New("info")
+	os.Stdout = stdout
+
+	return l, f
+}
+
+func TestRequestID(t *testing.T) {
+	l, logFile := stdoutLogger(t)
+
+	s := httpserver.New(l, httpserver.AccessLog(1))
+	s.App.Get("/ping", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(requestid.FromContext(ctx.UserContext()))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		echo   bool
+	}{
+		{name: "client id echoed", header: "client-id-1", echo: true},
+		{name: "generated when missing"},
+		{name: "generated when invalid", header: "bad\tid"},
+	}
+
+	var ids []string
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/ping", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+
+			resp, err := s.App.Test(req)
+			if err != nil {
+				t.Fatalf("App.Test: %v", err)
+			}
+			defer resp.Body.Close()
+
+			id := resp.Header.Get(requestid.Header)
+			if tt.echo && id != tt.header {
+				t.Fatalf("%s = %q, want %q", requestid.Header, id, tt.header)
+			}
+			if !tt.echo && (id == tt.header || !requestid.Valid(id)) {
+				t.Fatalf("%s = %q, want a new id", requestid.Header, id)
+			}
+
+			// обработчик видит тот же id в контексте
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("read body: %v", err)
+			}
+			if string(body) != id {
+				t.Fatalf("id in context = %q, want %q", body, id)
+			}
+
+			ids = append(ids, id)
+		})
+	}
+
+	if _, err := logFile.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+
+	logged := make(map[string]bool)
+
+	sc := bufio.NewScanner(logFile)
+	for sc.Scan() {
+		var line struct {
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+			Route     string `json:"route"`
+			Status    int    `json:"status"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("log line %q: %v", sc.Text(), err)
+		}
+
+		if line.Message == "http request" && line.Route == "/ping" && line.Status == fiber.StatusOK {
+			logged[line.RequestID] = true
+		}
+	}
+
+	for _, id := range ids {
+		if !logged[id] {
+			t.Errorf("no access log line with request_id %q", id)
+		}
+	}
+}
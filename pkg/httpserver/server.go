@@ -29,6 +29,11 @@ type Server struct {
 	readTimeout     time.Duration
 	writeTimeout    time.Duration
 	shutdownTimeout time.Duration
+	bodyLimit       int
+
+	metrics   *httpMetrics
+	tracing   *httpTracing
+	accessLog *accessLog
 
 	logger logger.Interface
 }
@@ -57,10 +62,26 @@ func New(l logger.Interface, opts ...Option) *Server {
 	app := fiber.New(fiber.Config{
 		ReadTimeout:  s.readTimeout,
 		WriteTimeout: s.writeTimeout,
+		BodyLimit:    s.bodyLimit,
 		JSONDecoder:  json.Unmarshal,
 		JSONEncoder:  json.Marshal,
 	})
 
+	app.Use(requestIDMiddleware)
+	app.Use(callerMiddleware)
+
+	if s.accessLog != nil {
+		app.Use(s.accessLog.middleware)
+	}
+
+	if s.tracing != nil {
+		app.Use(s.tracing.middleware)
+	}
+
+	if s.metrics != nil {
+		app.Use(s.metrics.middleware)
+	}
+
 	s.App = app
 
 	return s
@@ -0,0 +1,91 @@
+package httpserver
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const _tracerName = "github.com/andreyxaxa/Comment-Tree/pkg/httpserver"
+
+type httpTracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func newHTTPTracing(tp trace.TracerProvider) *httpTracing {
+	return &httpTracing{
+		tracer:     tp.Tracer(_tracerName),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+}
+
+// headerCarrier - адаптер заголовков fasthttp под propagation.TextMapCarrier.
+type headerCarrier struct {
+	ctx *fiber.Ctx
+}
+
+func (c headerCarrier) Get(key string) string {
+	return c.ctx.Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.ctx.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	for k := range c.ctx.GetReqHeaders() {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// middleware - продолжает трейс из traceparent/tracestate входящего запроса
+// и кладет контекст со спаном в ctx.UserContext() для нижних слоев.
+func (t *httpTracing) middleware(ctx *fiber.Ctx) error {
+	parent := t.propagator.Extract(ctx.UserContext(), headerCarrier{ctx: ctx})
+
+	spanCtx, span := t.tracer.Start(parent, ctx.Method(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(ctx.Method()),
+			semconv.URLPath(ctx.Path()),
+		),
+	)
+	defer span.End()
+
+	ctx.SetUserContext(spanCtx)
+
+	err := ctx.Next()
+
+	status := ctx.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		}
+
+		span.RecordError(err)
+	}
+
+	route := ctx.Route().Path
+	span.SetName(ctx.Method() + " " + route)
+	span.SetAttributes(
+		semconv.HTTPRoute(route),
+		semconv.HTTPResponseStatusCode(status),
+	)
+
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, "")
+	}
+
+	return err
+}
@@ -1,11 +1,14 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/andreyxaxa/Comment-Tree/pkg/requestid"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Interface interface {
@@ -14,8 +17,15 @@ type Interface interface {
 	Warn(message string, args ...interface{})
 	Error(message interface{}, args ...interface{})
 	Fatal(message interface{}, args ...interface{})
+
+	// With - логгер, добавляющий поля к каждой записи.
+	With(fields Fields) Interface
+	// WithContext - логгер с request_id и trace_id из контекста запроса.
+	WithContext(ctx context.Context) Interface
 }
 
+type Fields map[string]interface{}
+
 type Logger struct {
 	logger *zerolog.Logger
 }
@@ -48,6 +58,33 @@ func New(level string) *Logger {
 	}
 }
 
+func (l *Logger) With(fields Fields) Interface {
+	logger := l.logger.With().Fields(map[string]interface{}(fields)).Logger()
+
+	return &Logger{
+		logger: &logger,
+	}
+}
+
+func (l *Logger) WithContext(ctx context.Context) Interface {
+	fields := Fields{}
+
+	if id := requestid.FromContext(ctx); id != "" {
+		fields["request_id"] = id
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields["trace_id"] = sc.TraceID().String()
+		fields["span_id"] = sc.SpanID().String()
+	}
+
+	if len(fields) == 0 {
+		return l
+	}
+
+	return l.With(fields)
+}
+
 func (l *Logger) Debug(message interface{}, args ...interface{}) {
 	l.msg(zerolog.DebugLevel, message, args...)
 }
@@ -0,0 +1,279 @@
+// Package markdown - комменты в подмножестве CommonMark: выделение, ссылки, код, цитаты и списки.
+// Кроме того, ">>id" - ссылка на другой коммент.
+//
+// HTML не хранится, а рендерится при чтении, поэтому изменение allow-list после перезапуска
+// действует и на старые комменты. Хранится только текст без разметки - по нему строится поиск.
+// Чтобы каждое чтение дерева не рендерило все его комменты заново, HTML кэшируется в памяти
+// процесса по хешу исходного текста: у одинакового текста одинаковый HTML, и правка коммента
+// просто дает новый ключ.
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/cache"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	// _linkRel - ссылки в пользовательском контенте не передают вес и помечены как UGC.
+	_linkRel = "nofollow ugc"
+
+	// _htmlCacheEntries, _htmlCacheBytes - лимиты кэша HTML
+	_htmlCacheEntries = 50_000
+	_htmlCacheBytes   = 32 << 20
+
+	// _refPrefix - адрес ссылки ">>id": якорь коммента в UI, _refClass - ее класс
+	// (обычная ссылка на тот же якорь ссылкой на коммент не считается).
+	_refPrefix = "#comment-"
+	_refClass  = "comment-ref"
+)
+
+var (
+	// разбирается только нужное подмножество (приоритеты - как у goldmark по умолчанию):
+	// сырой HTML, заголовки и линии остаются текстом, как было до markdown.
+	// goldmark без WithUnsafe выбрасывает ссылки javascript: и т.п., allow-list после него -
+	// вторая линия защиты.
+	_md = goldmark.New(
+		goldmark.WithParser(parser.NewParser(
+			parser.WithBlockParsers(
+				util.Prioritized(parser.NewListParser(), 300),
+				util.Prioritized(parser.NewListItemParser(), 400),
+				util.Prioritized(parser.NewCodeBlockParser(), 500),
+				util.Prioritized(parser.NewFencedCodeBlockParser(), 700),
+				util.Prioritized(quotes{parser.NewBlockquoteParser()}, 800),
+				util.Prioritized(parser.NewParagraphParser(), 1000),
+			),
+			parser.WithInlineParsers(
+				util.Prioritized(parser.NewCodeSpanParser(), 100),
+				util.Prioritized(parser.NewLinkParser(), 200),
+				util.Prioritized(parser.NewAutoLinkParser(), 300),
+				util.Prioritized(parser.NewEmphasisParser(), 500),
+				util.Prioritized(refs{}, 600),
+			),
+			parser.WithParagraphTransformers(parser.DefaultParagraphTransformers()...),
+			parser.WithASTTransformers(util.Prioritized(links{}, 100)),
+		)),
+	)
+
+	_policy = newPolicy()
+
+	_html = cache.NewLRU(cache.MaxEntries(_htmlCacheEntries), cache.MaxBytes(_htmlCacheBytes))
+
+	// _strip - для текста: все теги убираются, блоки не склеиваются - goldmark
+	// заканчивает каждый блок переводом строки.
+	_strip = bluemonday.StrictPolicy()
+
+	_spaces = regexp.MustCompile(`[ \t]+`)
+
+	// ">>id" не внутри слова и не после еще одного ">", id - не больше 18 цифр (влезает в int64)
+	_refRe     = regexp.MustCompile(`^>>([0-9]{1,18})\b`)
+	_refLineRe = regexp.MustCompile(`^ {0,3}>>[0-9]`)
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowElements("p", "br", "em", "strong", "code", "pre", "blockquote", "ul", "li")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowElements("ol")
+
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("rel").Matching(regexp.MustCompile(`^` + _linkRel + `$`)).OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^` + _refClass + `$`)).OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	// относительные адреса нужны ссылкам ">>id" на якоря комментов
+	p.AllowRelativeURLs(true)
+
+	return p
+}
+
+// HTML - безопасный HTML коммента src, повторный вызов с тем же src берет его из кэша.
+func HTML(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	key := string(sum[:])
+
+	// LRU в памяти не возвращает ошибок
+	if cached, ok, _ := _html.Get(context.Background(), key); ok {
+		return string(cached)
+	}
+
+	out := render(src)
+	_ = _html.Set(context.Background(), key, []byte(out), 0)
+
+	return out
+}
+
+func render(src string) string {
+	var buf bytes.Buffer
+
+	// в bytes.Buffer рендер не падает
+	_ = _md.Convert([]byte(src), &buf)
+
+	return _policy.Sanitize(buf.String())
+}
+
+// Text - текст коммента src без разметки и адресов ссылок, как его видит читатель.
+func Text(src string) string {
+	text := html.UnescapeString(_strip.Sanitize(HTML(src)))
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+
+	for _, line := range lines {
+		line = strings.TrimSpace(_spaces.ReplaceAllString(line, " "))
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+// Snippet - первые n символов Text(src).
+func Snippet(src string, n int) string {
+	text := Text(src)
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+
+	return string([]rune(text)[:n]) + "…"
+}
+
+// References - id комментов из ссылок ">>id" в src без повторов, в порядке первой ссылки.
+// ">>id" в коде ссылкой не считается.
+func References(src string) []int64 {
+	doc := _md.Parser().Parse(text.NewReader([]byte(src)))
+
+	var ids []int64
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := n.(*ast.Link)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		if class, ok := link.AttributeString("class"); !ok || string(class.([]byte)) != _refClass {
+			return ast.WalkContinue, nil
+		}
+
+		id, err := strconv.ParseInt(strings.TrimPrefix(string(link.Destination), _refPrefix), 10, 64)
+		if err == nil && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return ids
+}
+
+// refs - ">>id" в тексте становится ссылкой на якорь коммента id.
+type refs struct{}
+
+func (refs) Trigger() []byte {
+	return []byte{'>'}
+}
+
+func (refs) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	if prev := block.PrecendingCharacter(); prev == '>' || prev == '_' || unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+		return nil
+	}
+
+	line, seg := block.PeekLine()
+
+	m := _refRe.FindSubmatchIndex(line)
+	if m == nil {
+		return nil
+	}
+
+	link := ast.NewLink()
+	link.Destination = append([]byte(_refPrefix), line[m[2]:m[3]]...)
+	link.SetAttributeString("class", []byte(_refClass))
+	link.AppendChild(link, ast.NewTextSegment(text.NewSegment(seg.Start, seg.Start+m[1])))
+
+	block.Advance(m[1])
+
+	return link
+}
+
+// quotes - строка, начинающаяся с ">>id", - ссылка на коммент, а не вложенная цитата,
+// в том числе сразу после цитаты.
+type quotes struct {
+	parser.BlockParser
+}
+
+func (q quotes) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	if refLine(reader) {
+		return nil, parser.NoChildren
+	}
+
+	return q.BlockParser.Open(parent, reader, pc)
+}
+
+func (q quotes) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	if refLine(reader) {
+		return parser.Close
+	}
+
+	return q.BlockParser.Continue(node, reader, pc)
+}
+
+func refLine(reader text.Reader) bool {
+	line, _ := reader.PeekLine()
+
+	return _refLineRe.Match(line)
+}
+
+// links - картинки не встраиваются, а становятся ссылками с alt в тексте, у всех ссылок -
+// rel, который рендер goldmark выводит вместе с href.
+type links struct{}
+
+func (links) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	var images []*ast.Image
+
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Link, *ast.AutoLink:
+			n.SetAttributeString("rel", []byte(_linkRel))
+		case *ast.Image:
+			images = append(images, n)
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	for _, img := range images {
+		link := ast.NewLink()
+		link.Destination = img.Destination
+		link.Title = img.Title
+		link.SetAttributeString("rel", []byte(_linkRel))
+
+		for c := img.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			link.AppendChild(link, c)
+			c = next
+		}
+
+		img.Parent().ReplaceChild(img.Parent(), img, link)
+	}
+}
@@ -0,0 +1,89 @@
+package markdown_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/markdown"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"emphasis", "*a* **b**", "<p><em>a</em> <strong>b</strong></p>\n"},
+		{"link", "[go](https://go.dev)", `<p><a href="https://go.dev" rel="nofollow ugc">go</a></p>` + "\n"},
+		{"autolink", "<https://go.dev>", `<p><a href="https://go.dev" rel="nofollow ugc">https://go.dev</a></p>` + "\n"},
+		{"image as link", "![cat](https://x.io/cat.png)", `<p><a href="https://x.io/cat.png" rel="nofollow ugc">cat</a></p>` + "\n"},
+		{"javascript link", "[x](javascript:alert(1))", `<p><a rel="nofollow ugc">x</a></p>` + "\n"},
+		{"raw html is text", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"quote and list", "> q\n\n- a\n- b", "<blockquote>\n<p>q</p>\n</blockquote>\n<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"code block", "```go\na < b\n```", "<pre><code>a &lt; b\n</code></pre>\n"},
+		{"heading is text", "# title", "<p># title</p>\n"},
+		{"reference", ">>12 yes", `<p><a href="#comment-12" class="comment-ref" rel="nofollow ugc">&gt;&gt;12</a> yes</p>` + "\n"},
+		{"quote after reference", ">>12\n> q", `<p><a href="#comment-12" class="comment-ref" rel="nofollow ugc">&gt;&gt;12</a></p>` + "\n<blockquote>\n<p>q</p>\n</blockquote>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdown.HTML(tt.src); got != tt.want {
+				t.Fatalf("HTML(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLCached(t *testing.T) {
+	src := strings.Repeat("> **quote** with [link](https://go.dev) and >>12\n\n- a\n- b\n\n", 20)
+	want := markdown.HTML(src)
+
+	// повторное чтение не рендерит текст заново: рендер такого коммента - сотни аллокаций
+	allocs := testing.AllocsPerRun(10, func() {
+		if got := markdown.HTML(src); got != want {
+			t.Fatalf("cached HTML = %q, want %q", got, want)
+		}
+	})
+	if allocs > 10 {
+		t.Fatalf("HTML of a rendered comment: %v allocs per run, want it from the cache", allocs)
+	}
+
+	// правка коммента - другой текст и другой ключ
+	if got := markdown.HTML(src + "*edited*"); got == want || !strings.Contains(got, "<em>edited</em>") {
+		t.Fatalf("HTML of edited comment = %q, want it rendered anew", got)
+	}
+}
+
+func TestText(t *testing.T) {
+	got := markdown.Text("**Hello**, [world](https://example.com/secret)!\n\n> quoted &amp; `x < y`")
+
+	want := "Hello, world!\nquoted & x < y"
+	if got != want {
+		t.Fatalf("Text = %q, want %q", got, want)
+	}
+
+	if strings.Contains(got, "secret") {
+		t.Fatalf("Text = %q, link address must not be indexed", got)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	got := markdown.References(">>3\n> quoted\n\nsee >>1, >>3 and >>2x a>>4 >>>5 `>>6` [x](#comment-7)")
+
+	want := []int64{3, 1}
+	if !slices.Equal(got, want) {
+		t.Fatalf("References = %v, want %v", got, want)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	if got := markdown.Snippet("**абв**где", 3); got != "абв…" {
+		t.Fatalf("Snippet = %q, want %q", got, "абв…")
+	}
+
+	if got := markdown.Snippet("*ok*", 3); got != "ok" {
+		t.Fatalf("Snippet = %q, want %q", got, "ok")
+	}
+}
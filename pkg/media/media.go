@@ -0,0 +1,133 @@
+// Package media - распознавание типа загруженного файла, размеры картинок и миниатюры.
+//
+// Тип определяется только по содержимому (http.DetectContentType), заявленному клиентом
+// типу и расширению имени файла не доверяем.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// SniffLen - сколько первых байт нужно Sniff.
+const SniffLen = 512
+
+// MaxPixels - картинки больше не декодируются целиком: защита от "бомб" с маленьким файлом
+// и огромным холстом.
+const MaxPixels = 40_000_000
+
+var ErrTooLarge = errors.New("image is too large")
+
+var _decoders = map[string]func([]byte) (image.Image, error){
+	"image/jpeg": func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) },
+	"image/png":  func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) },
+	"image/gif":  func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) },
+	"image/webp": func(b []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(b)) },
+}
+
+var _configs = map[string]func([]byte) (image.Config, error){
+	"image/jpeg": func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) },
+	"image/png":  func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) },
+	"image/gif":  func(b []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(b)) },
+	"image/webp": func(b []byte) (image.Config, error) { return webp.DecodeConfig(bytes.NewReader(b)) },
+}
+
+// Sniff - тип содержимого по первым SniffLen байтам, без параметров (charset и т.п.).
+func Sniff(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	return mediaType
+}
+
+// IsImage - для типа есть декодер, то есть известны размеры и строится миниатюра.
+func IsImage(contentType string) bool {
+	_, ok := _decoders[contentType]
+
+	return ok
+}
+
+// Dimensions - ширина и высота картинки по заголовку, без декодирования пикселей.
+func Dimensions(data []byte, contentType string) (int, int, error) {
+	decodeConfig, ok := _configs[contentType]
+	if !ok {
+		return 0, 0, fmt.Errorf("media - Dimensions: unsupported type %q", contentType)
+	}
+
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return 0, 0, fmt.Errorf("media - Dimensions - decodeConfig: %w", err)
+	}
+
+	return cfg.Width, cfg.Height, nil
+}
+
+// Thumbnail - картинка, уменьшенная до size по большей стороне (меньшая не увеличивается),
+// у GIF берется первый кадр. Возвращает миниатюру и ее тип (см. ThumbnailType).
+func Thumbnail(data []byte, contentType string, size int) ([]byte, string, error) {
+	w, h, err := Dimensions(data, contentType)
+	if err != nil {
+		return nil, "", fmt.Errorf("media - Thumbnail - Dimensions: %w", err)
+	}
+
+	if w*h > MaxPixels {
+		return nil, "", fmt.Errorf("media - Thumbnail: %w", ErrTooLarge)
+	}
+
+	src, err := _decoders[contentType](data)
+	if err != nil {
+		return nil, "", fmt.Errorf("media - Thumbnail - decode: %w", err)
+	}
+
+	tw, th := fit(w, h, size)
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+
+	thumbType := ThumbnailType(contentType)
+	if thumbType == "image/jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("media - Thumbnail - encode: %w", err)
+	}
+
+	return buf.Bytes(), thumbType, nil
+}
+
+// ThumbnailType - JPEG остается JPEG, остальное (с возможной прозрачностью) кодируется в PNG.
+func ThumbnailType(contentType string) string {
+	if contentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+
+	return "image/png"
+}
+
+// fit - размеры внутри квадрата size с сохранением пропорций, не меньше 1 пикселя.
+func fit(w, h, size int) (int, int) {
+	if w <= size && h <= size {
+		return w, h
+	}
+
+	if w >= h {
+		return size, max(1, h*size/w)
+	}
+
+	return max(1, w*size/h), size
+}
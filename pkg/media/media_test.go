@@ -0,0 +1,118 @@
+package media_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/media"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", encode(t, "png", 4, 4), "image/png"},
+		{"jpeg", encode(t, "jpeg", 4, 4), "image/jpeg"},
+		{"gif", encode(t, "gif", 4, 4), "image/gif"},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"text without charset", []byte("hello"), "text/plain"},
+		{"html is not text", []byte("<html><script>alert(1)</script>"), "text/html"},
+		{"binary", []byte{0, 1, 2, 3}, "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := media.Sniff(tt.data); got != tt.want {
+				t.Errorf("Sniff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          string
+		w, h            int
+		wantW, wantH    int
+		wantContentType string
+	}{
+		{"wide jpeg", "jpeg", 800, 400, 100, 50, "image/jpeg"},
+		{"tall png", "png", 300, 600, 50, 100, "image/png"},
+		{"gif to png", "gif", 200, 200, 100, 100, "image/png"},
+		{"small is not enlarged", "png", 20, 10, 20, 10, "image/png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encode(t, tt.format, tt.w, tt.h)
+			contentType := media.Sniff(data)
+
+			w, h, err := media.Dimensions(data, contentType)
+			if err != nil || w != tt.w || h != tt.h {
+				t.Fatalf("Dimensions = %d, %d, %v; want %d, %d", w, h, err, tt.w, tt.h)
+			}
+
+			thumb, thumbType, err := media.Thumbnail(data, contentType, 100)
+			if err != nil {
+				t.Fatalf("Thumbnail: %v", err)
+			}
+
+			if thumbType != tt.wantContentType {
+				t.Errorf("content type = %q, want %q", thumbType, tt.wantContentType)
+			}
+
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil || cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Errorf("thumbnail = %dx%d, %v; want %dx%d", cfg.Width, cfg.Height, err, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailTooLarge(t *testing.T) {
+	// заголовок PNG с огромным холстом, пиксели не нужны: проверка идет до декодирования
+	data := encode(t, "png", 1, 1)
+	data[16], data[17], data[18], data[19] = 0, 0, 0x27, 0x10 // ширина 10000
+	data[20], data[21], data[22], data[23] = 0, 0, 0x27, 0x10 // высота 10000
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	if _, _, err := media.Thumbnail(data, "image/png", 100); !errors.Is(err, media.ErrTooLarge) {
+		t.Fatalf("Thumbnail of 10000x10000 = %v, want ErrTooLarge", err)
+	}
+}
+
+func encode(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.White, color.Black})
+
+	var (
+		buf bytes.Buffer
+		err error
+	)
+
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("encode %s: %v", format, err)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,183 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/postgres"
+	"github.com/andreyxaxa/Comment-Tree/pkg/sqlite"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	sqlitemigrate "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// ErrSchemaTooNew - схема в базе новее, чем последняя миграция, известная бинарнику.
+var ErrSchemaTooNew = errors.New("database schema is newer than the binary")
+
+// Migrator - применяет вшитые миграции.
+// Каждая операция выполняется под pg_advisory_lock (драйвер golang-migrate),
+// поэтому несколько реплик не мигрируют базу одновременно.
+type Migrator struct {
+	m        *migrate.Migrate
+	versions []uint
+}
+
+type Status struct {
+	Current  uint
+	Dirty    bool
+	Latest   uint
+	Versions []uint
+}
+
+func NewPostgres(pg *postgres.Postgres, migrations fs.FS) (*Migrator, error) {
+	db, err := pgxmigrate.WithInstance(stdlib.OpenDBFromPool(pg.Pool), &pgxmigrate.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("migrator - NewPostgres - pgxmigrate.WithInstance: %w", err)
+	}
+
+	return newMigrator(migrations, "pgx5", db)
+}
+
+// NewSQLite - мигратор открывает собственное соединение с базой,
+// так как драйвер golang-migrate закрывает его в Close.
+func NewSQLite(path string, migrations fs.FS) (*Migrator, error) {
+	sl, err := sqlite.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("migrator - NewSQLite - sqlite.New: %w", err)
+	}
+
+	db, err := sqlitemigrate.WithInstance(sl.DB, &sqlitemigrate.Config{})
+	if err != nil {
+		sl.Close()
+
+		return nil, fmt.Errorf("migrator - NewSQLite - sqlitemigrate.WithInstance: %w", err)
+	}
+
+	return newMigrator(migrations, "sqlite", db)
+}
+
+func newMigrator(migrations fs.FS, driverName string, db database.Driver) (*Migrator, error) {
+	versions, err := listVersions(migrations)
+	if err != nil {
+		return nil, fmt.Errorf("migrator - newMigrator - listVersions: %w", err)
+	}
+
+	src, err := iofs.New(migrations, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrator - newMigrator - iofs.New: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, driverName, db)
+	if err != nil {
+		return nil, fmt.Errorf("migrator - newMigrator - migrate.NewWithInstance: %w", err)
+	}
+
+	return &Migrator{
+		m:        m,
+		versions: versions,
+	}, nil
+}
+
+// Latest - версия последней вшитой миграции.
+func (m *Migrator) Latest() uint {
+	if len(m.versions) == 0 {
+		return 0
+	}
+
+	return m.versions[len(m.versions)-1]
+}
+
+func (m *Migrator) Up() error {
+	err := m.m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator - Up - m.m.Up: %w", err)
+	}
+
+	return nil
+}
+
+// Down - откатывает n последних миграций.
+func (m *Migrator) Down(n int) error {
+	err := m.m.Steps(-n)
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator - Down - m.m.Steps: %w", err)
+	}
+
+	return nil
+}
+
+// To - мигрирует вверх или вниз до указанной версии.
+func (m *Migrator) To(version uint) error {
+	err := m.m.Migrate(version)
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator - To - m.m.Migrate: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Status() (Status, error) {
+	current, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return Status{}, fmt.Errorf("migrator - Status - m.m.Version: %w", err)
+	}
+
+	return Status{
+		Current:  current,
+		Dirty:    dirty,
+		Latest:   m.Latest(),
+		Versions: m.versions,
+	}, nil
+}
+
+// CheckCompatible - возвращает ErrSchemaTooNew, если база мигрирована более новым бинарником.
+func (m *Migrator) CheckCompatible() error {
+	s, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	if s.Current > s.Latest {
+		return fmt.Errorf("migrator - CheckCompatible: %w: database %d, binary %d", ErrSchemaTooNew, s.Current, s.Latest)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+
+	return errors.Join(srcErr, dbErr)
+}
+
+// listVersions - версии миграций по именам файлов вида <version>_<name>.up.sql.
+func listVersions(migrations fs.FS) ([]uint, error) {
+	files, err := fs.Glob(migrations, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]uint, 0, len(files))
+
+	for _, f := range files {
+		prefix, _, _ := strings.Cut(f, "_")
+
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q: %w", f, err)
+		}
+
+		versions = append(versions, uint(v))
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	return versions, nil
+}
@@ -0,0 +1,146 @@
+package migrator_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/migrator"
+	"github.com/andreyxaxa/Comment-Tree/pkg/sqlite"
+)
+
+var _migrations = fstest.MapFS{
+	"1_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER PRIMARY KEY);")},
+	"1_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"2_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER PRIMARY KEY);")},
+	"2_b.down.sql": {Data: []byte("DROP TABLE b;")},
+	"3_c.up.sql":   {Data: []byte("CREATE TABLE c (id INTEGER PRIMARY KEY);")},
+	"3_c.down.sql": {Data: []byte("DROP TABLE c;")},
+}
+
+func newMigrator(t *testing.T, path string, migrations fstest.MapFS) *migrator.Migrator {
+	t.Helper()
+
+	mg, err := migrator.NewSQLite(path, migrations)
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	t.Cleanup(func() { mg.Close() })
+
+	return mg
+}
+
+func status(t *testing.T, mg *migrator.Migrator) migrator.Status {
+	t.Helper()
+
+	s, err := mg.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+
+	return s
+}
+
+// tables - таблицы a, b, c, которые есть в базе.
+func tables(t *testing.T, path string) []string {
+	t.Helper()
+
+	sl, err := sqlite.New(path)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	defer sl.Close()
+
+	rows, err := sl.DB.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name IN ('a', 'b', 'c') ORDER BY name")
+	if err != nil {
+		t.Fatalf("query tables: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			t.Fatalf("rows.Scan: %v", err)
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestUpDown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	mg := newMigrator(t, path, _migrations)
+
+	s := status(t, mg)
+	if s.Current != 0 || s.Dirty || s.Latest != 3 || !slices.Equal(s.Versions, []uint{1, 2, 3}) {
+		t.Fatalf("fresh status = %+v", s)
+	}
+
+	if err := mg.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	// повторный Up без новых миграций - не ошибка
+	if err := mg.Up(); err != nil {
+		t.Fatalf("second Up: %v", err)
+	}
+	if s = status(t, mg); s.Current != 3 {
+		t.Fatalf("status after Up = %+v", s)
+	}
+	if got := tables(t, path); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("tables after Up = %v", got)
+	}
+
+	if err := mg.Down(2); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if s = status(t, mg); s.Current != 1 {
+		t.Fatalf("status after Down(2) = %+v", s)
+	}
+	if got := tables(t, path); !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("tables after Down(2) = %v", got)
+	}
+
+	if err := mg.To(2); err != nil {
+		t.Fatalf("To: %v", err)
+	}
+	if s = status(t, mg); s.Current != 2 {
+		t.Fatalf("status after To(2) = %+v", s)
+	}
+	if got := tables(t, path); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("tables after To(2) = %v", got)
+	}
+}
+
+func TestCheckCompatible(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	mg := newMigrator(t, path, _migrations)
+	if err := mg.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := mg.CheckCompatible(); err != nil {
+		t.Fatalf("CheckCompatible: %v", err)
+	}
+	if err := mg.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// старый бинарник знает только первые две миграции
+	old := fstest.MapFS{}
+	for name, f := range _migrations {
+		if name[0] != '3' {
+			old[name] = f
+		}
+	}
+
+	mg = newMigrator(t, path, old)
+	if err := mg.CheckCompatible(); !errors.Is(err, migrator.ErrSchemaTooNew) {
+		t.Fatalf("CheckCompatible = %v, want ErrSchemaTooNew", err)
+	}
+}
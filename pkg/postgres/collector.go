@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const _namespace = "pgxpool"
+
+// Collector - экспортирует статистику пула соединений (pgxpool.Stat) в prometheus.
+type Collector struct {
+	pg *Postgres
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	emptyAcquireWaitTime *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+func NewCollector(pg *Postgres) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(_namespace, "", name), help, nil, nil)
+	}
+
+	return &Collector{
+		pg:                   pg,
+		acquiredConns:        desc("acquired_conns", "Number of currently acquired connections in the pool."),
+		idleConns:            desc("idle_conns", "Number of currently idle connections in the pool."),
+		totalConns:           desc("total_conns", "Total number of connections currently in the pool."),
+		maxConns:             desc("max_conns", "Maximum size of the pool."),
+		acquireCount:         desc("acquire_count_total", "Cumulative count of successful acquires from the pool."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total duration of all successful acquires from the pool."),
+		emptyAcquireCount:    desc("empty_acquire_count_total", "Cumulative count of acquires that waited for a connection because the pool was empty."),
+		emptyAcquireWaitTime: desc("empty_acquire_wait_seconds_total", "Cumulative time waited for a connection because the pool was empty."),
+		canceledAcquireCount: desc("canceled_acquire_count_total", "Cumulative count of acquires canceled by a context."),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.emptyAcquireWaitTime
+	ch <- c.canceledAcquireCount
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	if c.pg.Pool == nil {
+		return
+	}
+
+	s := c.pg.Pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireWaitTime, prometheus.CounterValue, s.EmptyAcquireWaitTime().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquireCount, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+}
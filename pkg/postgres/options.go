@@ -1,6 +1,10 @@
 package postgres
 
-import "time"
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
 
 type Option func(*Postgres)
 
@@ -21,3 +25,9 @@ func ConnTimeout(timeout time.Duration) Option {
 		c.connTimeout = timeout
 	}
 }
+
+func Tracing(tp trace.TracerProvider) Option {
+	return func(c *Postgres) {
+		c.tracer = newQueryTracer(tp)
+	}
+}
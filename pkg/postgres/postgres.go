@@ -20,6 +20,7 @@ type Postgres struct {
 	maxPoolSize  int
 	connAttempts int
 	connTimeout  time.Duration
+	tracer       *queryTracer
 
 	Builder squirrel.StatementBuilderType
 	Pool    *pgxpool.Pool
@@ -45,6 +46,10 @@ func New(url string, opts ...Option) (*Postgres, error) {
 
 	poolConfig.MaxConns = int32(pg.maxPoolSize) //nolint:gosec
 
+	if pg.tracer != nil {
+		poolConfig.ConnConfig.Tracer = pg.tracer
+	}
+
 	for pg.connAttempts > 0 {
 		pg.Pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 		if err == nil {
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoSchema - таблица schema_migrations пуста, миграции не применялись.
+var ErrNoSchema = errors.New("no migrations applied")
+
+func (p *Postgres) Ping(ctx context.Context) error {
+	err := p.Pool.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres - Ping - p.Pool.Ping: %w", err)
+	}
+
+	return nil
+}
+
+// SchemaVersion - текущая версия схемы из таблицы schema_migrations (golang-migrate).
+func (p *Postgres) SchemaVersion(ctx context.Context) (version uint, dirty bool, err error) {
+	err = p.Pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, fmt.Errorf("postgres - SchemaVersion: %w", ErrNoSchema)
+		}
+
+		return 0, false, fmt.Errorf("postgres - SchemaVersion - p.Pool.QueryRow.Scan: %w", err)
+	}
+
+	return version, dirty, nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const _tracerName = "github.com/andreyxaxa/Comment-Tree/pkg/postgres"
+
+var (
+	_stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	_numericLiteral = regexp.MustCompile(`(^|[^$\w.])\d+(?:\.\d+)?`)
+)
+
+// queryTracer - pgx.QueryTracer, оборачивающий каждый запрос в спан.
+// Аргументы запроса в спан не попадают, а литералы в тексте заменяются на "?".
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+var _ pgx.QueryTracer = (*queryTracer)(nil)
+
+func newQueryTracer(tp trace.TracerProvider) *queryTracer {
+	return &queryTracer{
+		tracer: tp.Tracer(_tracerName),
+	}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	query := SanitizeSQL(data.SQL)
+	operation := operationName(query)
+
+	ctx, _ = t.tracer.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(query),
+		),
+	)
+
+	return ctx
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+
+		return
+	}
+
+	span.SetAttributes(attribute.Int64("db.response.rows_affected", data.CommandTag.RowsAffected()))
+}
+
+// SanitizeSQL - схлопывает пробелы и заменяет строковые и числовые литералы на "?".
+func SanitizeSQL(sql string) string {
+	sql = strings.Join(strings.Fields(sql), " ")
+	sql = _stringLiteral.ReplaceAllString(sql, "?")
+	sql = _numericLiteral.ReplaceAllString(sql, "${1}?")
+
+	return sql
+}
+
+func operationName(sql string) string {
+	op, _, _ := strings.Cut(sql, " ")
+
+	return strings.ToUpper(op)
+}
@@ -0,0 +1,91 @@
+// Package pubsub - рассылка сообщений подписчикам внутри одного процесса.
+package pubsub
+
+import "sync"
+
+// _buffer - сколько сообщений подписчик может не забрать, прежде чем новые начнут теряться.
+const _buffer = 16
+
+// Hub - подписки по ключу (например, имени получателя). Publish не блокируется:
+// медленный подписчик теряет сообщения, а не тормозит отправителя.
+type Hub[T any] struct {
+	mu     sync.Mutex
+	subs   map[string]map[chan T]struct{}
+	closed bool
+}
+
+func New[T any]() *Hub[T] {
+	return &Hub[T]{
+		subs: make(map[string]map[chan T]struct{}),
+	}
+}
+
+// Subscribe - канал закрывается функцией отписки или Close.
+// После Close возвращается уже закрытый канал.
+func (h *Hub[T]) Subscribe(key string) (<-chan T, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan T, _buffer)
+
+	if h.closed {
+		close(ch)
+
+		return ch, func() {}
+	}
+
+	if h.subs[key] == nil {
+		h.subs[key] = make(map[chan T]struct{})
+	}
+	h.subs[key][ch] = struct{}{}
+
+	var once sync.Once
+
+	return ch, func() {
+		once.Do(func() { h.unsubscribe(key, ch) })
+	}
+}
+
+func (h *Hub[T]) Publish(key string, msg T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[key] {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// Close - закрывает все подписки, например, чтобы потоковые ответы завершились до остановки сервера.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+
+	for key, chans := range h.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(h.subs, key)
+	}
+}
+
+func (h *Hub[T]) unsubscribe(key string, ch chan T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// после Close канал уже закрыт и удален
+	if _, ok := h.subs[key][ch]; !ok {
+		return
+	}
+
+	close(ch)
+	delete(h.subs[key], ch)
+
+	if len(h.subs[key]) == 0 {
+		delete(h.subs, key)
+	}
+}
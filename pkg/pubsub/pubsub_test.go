@@ -0,0 +1,54 @@
+package pubsub_test
+
+import (
+	"testing"
+
+	"github.com/andreyxaxa/Comment-Tree/pkg/pubsub"
+)
+
+func TestHub(t *testing.T) {
+	h := pubsub.New[int]()
+
+	alice, unsubscribe := h.Subscribe("alice")
+	bob, _ := h.Subscribe("bob")
+
+	h.Publish("alice", 1)
+
+	if got := <-alice; got != 1 {
+		t.Fatalf("alice got %d, want 1", got)
+	}
+
+	select {
+	case got := <-bob:
+		t.Fatalf("bob got %d, want nothing", got)
+	default:
+	}
+
+	// переполненный подписчик теряет сообщения, Publish не блокируется
+	for i := range 100 {
+		h.Publish("alice", i)
+	}
+
+	unsubscribe()
+	unsubscribe()
+
+	n := 0
+	for range alice {
+		n++
+	}
+
+	if n == 0 || n == 100 {
+		t.Fatalf("alice drained %d messages, want some but not all", n)
+	}
+
+	h.Close()
+
+	if _, ok := <-bob; ok {
+		t.Fatal("bob's channel must be closed by Close")
+	}
+
+	late, _ := h.Subscribe("carol")
+	if _, ok := <-late; ok {
+		t.Fatal("subscription after Close must be closed")
+	}
+}
@@ -0,0 +1,45 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	Header = "X-Request-ID"
+
+	_maxLength = 128
+)
+
+type ctxKey struct{}
+
+func New() string {
+	return uuid.NewString()
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+
+	return id
+}
+
+// Valid - принимаем только короткие id из печатных ASCII символов,
+// чтобы клиент не мог протащить в логи мусор или переводы строк.
+func Valid(id string) bool {
+	if id == "" || len(id) > _maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
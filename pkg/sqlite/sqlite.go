@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	_ "modernc.org/sqlite" // SQLite driver.
+)
+
+const _defaultMaxOpenConns = 4
+
+type SQLite struct {
+	Builder squirrel.StatementBuilderType
+	DB      *sql.DB
+}
+
+// New - открывает базу по пути к файлу. Внешние ключи включены,
+// журнал в режиме WAL, чтобы чтение не блокировалось записью.
+// Транзакции сразу берут блокировку на запись (BEGIN IMMEDIATE).
+func New(path string) (*SQLite, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite - New - sql.Open: %w", err)
+	}
+
+	db.SetMaxOpenConns(_defaultMaxOpenConns)
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+
+		return nil, fmt.Errorf("sqlite - New - db.Ping: %w", err)
+	}
+
+	return &SQLite{
+		Builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Question),
+		DB:      db,
+	}, nil
+}
+
+func (s *SQLite) Ping(ctx context.Context) error {
+	err := s.DB.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("sqlite - Ping - s.DB.PingContext: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) Close() {
+	if s.DB != nil {
+		s.DB.Close()
+	}
+}
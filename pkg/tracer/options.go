@@ -0,0 +1,43 @@
+package tracer
+
+type Option func(*Tracer)
+
+// Exporter - none, stdout, file или otlp.
+func Exporter(exporter string) Option {
+	return func(t *Tracer) {
+		t.exporter = exporter
+	}
+}
+
+func ServiceName(name string) Option {
+	return func(t *Tracer) {
+		t.serviceName = name
+	}
+}
+
+// OTLPEndpoint - host:port OTLP/HTTP коллектора.
+func OTLPEndpoint(endpoint string) Option {
+	return func(t *Tracer) {
+		t.otlpEndpoint = endpoint
+	}
+}
+
+func OTLPInsecure(insecure bool) Option {
+	return func(t *Tracer) {
+		t.otlpInsecure = insecure
+	}
+}
+
+// FilePath - куда писать спаны для экспортера file.
+func FilePath(path string) Option {
+	return func(t *Tracer) {
+		t.filePath = path
+	}
+}
+
+// SampleRatio - доля трассируемых корневых запросов, от 0 до 1.
+func SampleRatio(ratio float64) Option {
+	return func(t *Tracer) {
+		t.sampleRatio = ratio
+	}
+}
@@ -0,0 +1,129 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+
+	_defaultServiceName  = "comment-tree"
+	_defaultOTLPEndpoint = "localhost:4318"
+	_defaultFilePath     = "traces.json"
+	_defaultSampleRatio  = 1.0
+)
+
+type Tracer struct {
+	exporter     string
+	serviceName  string
+	otlpEndpoint string
+	otlpInsecure bool
+	filePath     string
+	sampleRatio  float64
+
+	Provider trace.TracerProvider
+
+	sdk    *sdktrace.TracerProvider
+	closer io.Closer
+}
+
+func New(opts ...Option) (*Tracer, error) {
+	t := &Tracer{
+		exporter:     ExporterNone,
+		serviceName:  _defaultServiceName,
+		otlpEndpoint: _defaultOTLPEndpoint,
+		filePath:     _defaultFilePath,
+		sampleRatio:  _defaultSampleRatio,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	var (
+		exp sdktrace.SpanExporter
+		err error
+	)
+
+	switch t.exporter {
+	case ExporterNone, "":
+		t.Provider = noop.NewTracerProvider()
+
+		return t, nil
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var f *os.File
+
+		f, err = os.OpenFile(t.filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("tracer - New - os.OpenFile: %w", err)
+		}
+
+		t.closer = f
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case ExporterOTLP:
+		httpOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(t.otlpEndpoint)}
+		if t.otlpInsecure {
+			httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+		}
+
+		exp, err = otlptracehttp.New(context.Background(), httpOpts...)
+	default:
+		return nil, fmt.Errorf("tracer - New - unknown exporter %q", t.exporter)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("tracer - New - %s exporter: %w", t.exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(t.serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracer - New - resource.Merge: %w", err)
+	}
+
+	t.sdk = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.sampleRatio))),
+	)
+	t.Provider = t.sdk
+
+	return t, nil
+}
+
+// Shutdown - сбрасывает накопленные спаны в экспортер и освобождает ресурсы.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	var shutdownErrors []error
+
+	if t.sdk != nil {
+		err := t.sdk.Shutdown(ctx)
+		if err != nil {
+			shutdownErrors = append(shutdownErrors, fmt.Errorf("tracer - Shutdown - t.sdk.Shutdown: %w", err))
+		}
+	}
+
+	if t.closer != nil {
+		err := t.closer.Close()
+		if err != nil {
+			shutdownErrors = append(shutdownErrors, fmt.Errorf("tracer - Shutdown - t.closer.Close: %w", err))
+		}
+	}
+
+	return errors.Join(shutdownErrors...)
+}
@@ -4,4 +4,25 @@ import "errors"
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
+	ErrAlreadyExists  = errors.New("record already exists")
+
+	// ErrLocked - в закрытую ветку нельзя отвечать, ErrArchived - архивную нельзя менять вовсе.
+	ErrLocked   = errors.New("thread is locked")
+	ErrArchived = errors.New("thread is archived")
+
+	// ErrMaxDepth - ответ глубже лимита дерева, ErrNotRoot - операция только для корней.
+	ErrMaxDepth = errors.New("max depth exceeded")
+	ErrNotRoot  = errors.New("comment is not a root")
+
+	// ErrInvalidToken - подпись токена из ссылки не сходится.
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrAttachmentsDisabled - хранилище вложений не настроено.
+	// ErrFileTooLarge и ErrUnsupportedType - файл больше лимита или его тип (по содержимому) не разрешен.
+	// ErrInvalidAttachment - вложения нет, оно чужое или уже прикреплено, ErrTooManyAttachments - больше лимита на коммент.
+	ErrAttachmentsDisabled = errors.New("attachments are disabled")
+	ErrFileTooLarge        = errors.New("file too large")
+	ErrUnsupportedType     = errors.New("unsupported file type")
+	ErrInvalidAttachment   = errors.New("invalid attachment")
+	ErrTooManyAttachments  = errors.New("too many attachments")
 )